@@ -1,28 +1,46 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	"math"
-	"net"
-	"net/url"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
 	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mdb/pkg/mdbcore"
 	"github.com/minio/pkg/v3/console"
+	"gopkg.in/yaml.v3"
 )
 
 // Version information - set at build time via ldflags
@@ -42,16 +60,86 @@ const (
 	Reset  = "\033[0m"
 )
 
-// clusterStruct wraps Info message together with fields "Status" and "Error"
+// Output formats accepted by --format
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatCSV      = "csv"
+	formatGrafana  = "grafana"
+)
+
+// Color modes accepted by --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// Size units accepted by --units
+const (
+	unitsIEC   = "iec"
+	unitsSI    = "si"
+	unitsBytes = "bytes"
+)
+
+// Units accepted by --fixed-unit
+const (
+	fixedUnitGiB = "GiB"
+	fixedUnitTiB = "TiB"
+)
+
+// clusterStruct is a snapshot as loaded from a file, with what mdb adds to it.
+// Its Timestamp is the file's "timestamp" field or else its modification time.
 type clusterStruct struct {
-	Status string             `json:"status"`
-	Error  string             `json:"error,omitempty"`
-	Info   madmin.InfoMessage `json:"info,omitempty"`
+	mdbcore.Snapshot
+
+	// Trend holds the statistics of every record of an NDJSON file, for --trend
+	Trend []trendPoint `json:"-"`
+
+	// RecordIndex is the 1-based position of the record in an NDJSON file of
+	// RecordCount records; both are 0 for plain JSON files
+	RecordIndex int `json:"-"`
+	RecordCount int `json:"-"`
+
+	// RecordLine is the line of an NDJSON file the record was read from, for
+	// --show-unknown to read it again
+	RecordLine int `json:"-"`
+
+	// Heal is the background heal status read with --heal, its drives merged
+	// into the servers
+	Heal *healStatus `json:"-"`
+
+	// PoolStatus holds the decommission and rebalance state of the pools read
+	// with --pool-status, keyed by pool index
+	PoolStatus map[int]poolState `json:"-"`
+}
+
+// DiskInfo and ErasureSetInfo are the drive and erasure set views built by
+// the mdbcore package
+type (
+	DiskInfo       = mdbcore.DiskInfo
+	ErasureSetInfo = mdbcore.ErasureSetInfo
+)
+
+// ClusterStats holds cluster-wide statistics, with the health grade and
+// history mdb adds to those computed by the mdbcore package
+type ClusterStats struct {
+	mdbcore.ClusterStats
+	HealthGrade string
+	History     []HealthRecord
+	UUIDs       mdbcore.UUIDCheck
+	ILMExpiry   []string // servers with ILM expiry in progress
+	Online      int      // online servers
 }
 
 // Config holds command-line configuration
 type Config struct {
 	JSONFile          string
+	JSONFiles         []string
+	URL               string       // JSONFile was downloaded from, shown in its place
+	Archive           string       // diagnostics archive JSONFile was extracted from, see extractArchive
+	Fetch             fetchOptions // how URL inputs are fetched, from --header, --insecure and --timeout
 	ShowSummary       bool
 	ShowServers       bool
 	ShowSets          bool
@@ -59,110 +147,621 @@ type Config struct {
 	ScanningMode      bool
 	PagerMode         bool
 	FailedMode        bool
+	FailedOnlyAvg     bool // set counts and averages over failed drives only
 	LowSpaceThreshold *float64
+	MinFreeBytes      int64
+	CritFreeBytes     int64 // red limit of --free-color-by bytes from --crit-free-bytes, 0 for defaultCritFreeBytes
+	MinSetFree        int64
+	FreeColorBy       string
+	InodeThreshold    *float64
+	ShowUnaccounted   bool
+	ErrorThreshold    *uint64
+	SaturationRatio   float64 // waiting/tokens ratio from --saturation, see saturationRatio
 	MinBadDisks       *int
 	TrimDomain        string
-}
-
-// DiskInfo represents a single disk
-type DiskInfo struct {
-	Server         string
-	Path           string
-	State          string
-	UUID           string
-	Scanning       bool
-	DiskIndex      interface{}
-	TotalSpace     int64
-	UsedSpace      int64
-	AvailableSpace int64
-	UsedInodes     int64
-	FreeInodes     int64
-	Local          bool
-	Metrics        *madmin.DiskMetrics
-	PoolIndex      int
-	SetIndex       int
-	FreeSpacePct   float64
-	UsedSpacePct   float64
-}
-
-// ErasureSetInfo holds information about an erasure set
-type ErasureSetInfo struct {
-	PoolIdx          int
-	SetIdx           int
-	Drives           []DiskInfo
-	AvgSpaceUsedPct  float64
-	AvgFreeSpacePct  float64
-	AvgInodesUsedPct float64
-	Good             int
-	Bad              int
-	Scanning         int
-}
-
-// ClusterStats holds cluster-wide statistics
-type ClusterStats struct {
-	TotalDisks    int
-	ScanningDisks int
-	OkDisks       int
-	BadDisks      int
-	TotalSpace    int64
-	UsedSpace     int64
-	DeploymentID  string
-	ParityDisks   int
+	FQDN              bool // full server host names, overrides TrimDomain
+	BusyServers       bool
+	ServerSummary     bool
+	ServerDetails     bool // CPU, Go runtime and GC columns in the Servers table
+	ServerCapacity    bool
+	CapacitySort      string // --server-capacity sort key, empty for server name
+	DriveSort         string // drives table sort key of "mdb drives", empty for pool, set and disk index
+	SetSort           string // erasure sets table sort key, setSortRisk or empty for pool and set order
+	SetsOnly          bool   // nothing but the erasure sets table
+	Format            string
+	Title             bool
+	Legend            bool
+	HistorySize       int
+	Verbose           bool
+	OutputPath        string
+	ColorMode         string
+	Units             string
+	FixedUnit         string
+	Precision         *int            // decimals of sizes and percentages, defaultPrecision if nil
+	Exact             bool            // exact bytes after the sizes of the summary
+	Thresholds        pctThresholds   // color thresholds from --warn-used and friends
+	Settings          *renderSettings // formatting settings of the report being rendered, see settings
+	DriveColumns      []string
+	RetryOnChange     bool
+	NoPager           bool
+	NoMouse           bool
+	Width             int // tables are narrowed to this many columns, 0 for no limit
+	MaxRows           int // drive tables are cut after this many rows, 0 for no limit
+	GrepPattern       string
+	GroupByPattern    string
+	GroupBy           *regexp.Regexp // the first capture group names the failure domain of a server, from --group-by
+	ByPath            bool
+	PathPattern       string
+	PathRegex         *regexp.Regexp // the first capture group names the drive path for --by-path, from --path-regex
+	States            []string       // drive states shown in the disks and sets views, from --state
+	Grep              *regexp.Regexp // servers and drives shown must match, from --grep
+	AssumeYes         bool
+	ShowUnknown       bool
+	Consistency       bool
+	SimulateLoss      []lossTarget // servers and drives taken away by --simulate-loss
+	Decommission      *int         // pool whose data --decommission moves to the other pools
+	BundlePath        string
+	BaselinePath      string
+	BaselineChanges   *baselineChanges
+	UpdateBaseline    bool
+	Command           string
+	Flags             []string
+	MaxAge            time.Duration
+	HealStuck         time.Duration // heals running longer are shown in red, see renderSettings
+	Trend             bool
+	Project           bool
+	ProjectAt         []float64     // used percentages of usable capacity --project dates
+	Interval          time.Duration // time between the snapshots of --project, if the files lack timestamps
+	Latest            bool
+	At                time.Time
+	Record            int
+	Compare           bool
+	Anonymize         bool
+	AnonymizeMap      string
+	ShowServices      bool
+	ShowILM           bool
+	ShowBuckets       bool
+	ShowOffline       bool
+	Bars              bool // used space bars in the sets and pool tables
+	ASCII             bool // bars and trees drawn with ASCII characters
+	Tree              bool // pool, set and server tree
+	TreeDrives        bool // drives in the tree
+	Interactive       bool
+	Quiet             bool   // only the problems, one line each
+	FailOn            string // lowest finding severity that fails the run, from --fail-on
+	LogLine           bool   // MDB_SUMMARY record of the whole cluster on stderr
+	DrillSet          string // key of the only erasure set shown, for the drives of a set in --interactive
+	ReplicationFile   string
+	HealFile          string
+	MetricsFile       string
+	PoolStatusFile    string
+	SetMetrics        bool
+	Wide              bool // list every server of an erasure set in the Servers column
 }
 
 // Pager handles paginated output using bubbletea and viewport
 type Pager struct {
-	enabled bool
-	buffer  *strings.Builder
+	enabled      bool
+	auto         bool            // enabled automatically, only page when the content is taller than the terminal
+	lines        []string        // collected output, one entry per complete line
+	partial      strings.Builder // collected output after the last newline
+	csv          bool            // collect tables instead of printing them
+	tables       []reportTable   // tables collected in CSV format
+	stripColor   bool            // remove ANSI colors from stdout and pager output
+	output       *os.File        // optional file receiving the rendered report
+	outputWriter *bufio.Writer   // buffers writes to output
+	outputColor  bool            // keep ANSI colors in the output file
+	outputErr    error
+	stdout       io.Writer
+	isTerminal   func() bool                    // whether stdout is a terminal, so Show pages
+	termHeight   func() (int, error)            // height of the terminal stdout is shown on
+	writer       *bufio.Writer                  // buffers writes to stdout, flushed by Show and Close
+	guard        *terminalGuard                 // asks before large structured output is written to a terminal
+	noMouse      bool                           // leave the mouse to the terminal, for text selection
+	sections     []pagerSection                 // section titles collected, the targets of the number keys
+	setRows      map[int]mdbcore.ErasureSetInfo // erasure set of each row of the Erasure Sets table, keyed by line
 }
 
+// pagerSection is a section title collected by the pager and its line
+type pagerSection struct {
+	Title string
+	Line  int
+}
+
+// pagerBufferSize is the size of the buffers in front of stdout and the output file
+const pagerBufferSize = 64 << 10
+
 func NewPager(enabled bool) *Pager {
 	return &Pager{
-		enabled: enabled,
-		buffer:  &strings.Builder{},
+		enabled:    enabled,
+		stdout:     os.Stdout,
+		isTerminal: stdoutIsTerminal,
+		termHeight: terminalHeight,
+	}
+}
+
+// terminalGuardLimit is the amount of structured output written to a terminal without asking
+const terminalGuardLimit = 1 << 20
+
+// errOutputDeclined is returned when the user declines to write large output to the terminal
+var errOutputDeclined = errors.New("output not written to the terminal (redirect it, use --output, or pass --yes)")
+
+// terminalGuard asks once for confirmation before more than limit bytes are written to a terminal
+type terminalGuard struct {
+	limit      int
+	isTerminal func() bool
+	input      io.Reader // answers to the prompt
+	prompt     io.Writer // where the prompt is printed
+	written    int
+	asked      bool
+	allowed    bool
+}
+
+func newTerminalGuard() *terminalGuard {
+	return &terminalGuard{
+		limit:      terminalGuardLimit,
+		isTerminal: stdoutIsTerminal,
+		input:      os.Stdin,
+		prompt:     os.Stderr,
+	}
+}
+
+// allow reports whether n more bytes may be written, prompting when they cross the limit on a terminal
+func (g *terminalGuard) allow(n int) bool {
+	g.written += n
+	if g.asked {
+		return g.allowed
+	}
+	if g.written <= g.limit || !g.isTerminal() {
+		return true
 	}
+
+	g.asked = true
+	fmt.Fprintf(g.prompt, "about to write %s to terminal — continue? [y/N] ", humanize.Bytes(uint64(g.written)))
+	answer, _ := bufio.NewReader(g.input).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		g.allowed = true
+	}
+	return g.allowed
 }
 
 func (p *Pager) Printf(format string, args ...interface{}) {
-	text := fmt.Sprintf(format, args...)
+	p.WriteString(fmt.Sprintf(format, args...))
+}
+
+// WriteString prints text as is, cheaper than Printf for text built by the caller
+func (p *Pager) WriteString(text string) {
+	if p.output != nil {
+		p.writeOutput(text)
+		// The file replaces stdout, the report is still shown when paging
+		if !p.enabled {
+			return
+		}
+	}
+	if p.stripColor {
+		text = stripANSI(text)
+	}
 	if p.enabled {
-		p.buffer.WriteString(text)
-	} else {
-		fmt.Printf(format, args...)
+		p.collect(text)
+		return
+	}
+	if p.guard != nil {
+		// Show everything written so far before the prompt appears
+		if !p.guard.asked && p.guard.written+len(text) > p.guard.limit {
+			p.flush()
+		}
+		if !p.guard.allow(len(text)) {
+			p.outputErr = errOutputDeclined
+			return
+		}
+	}
+	if p.writer == nil {
+		p.writer = bufio.NewWriterSize(p.stdout, pagerBufferSize)
+	}
+	p.writer.WriteString(text)
+}
+
+// collect splits text into lines and appends them to the collected output
+func (p *Pager) collect(text string) {
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			p.partial.WriteString(text)
+			return
+		}
+		if p.partial.Len() == 0 {
+			p.lines = append(p.lines, text[:i])
+		} else {
+			p.partial.WriteString(text[:i])
+			p.lines = append(p.lines, p.partial.String())
+			p.partial.Reset()
+		}
+		text = text[i+1:]
+	}
+}
+
+// String returns the collected output
+func (p *Pager) String() string {
+	var content strings.Builder
+	for _, line := range p.lines {
+		content.WriteString(line)
+		content.WriteByte('\n')
+	}
+	content.WriteString(p.partial.String())
+	return content.String()
+}
+
+// contentLines returns the collected lines, including an unterminated last line
+func (p *Pager) contentLines() []string {
+	if p.partial.Len() == 0 {
+		return p.lines
+	}
+	return append(p.lines, p.partial.String())
+}
+
+// resetContent discards the collected output
+func (p *Pager) resetContent() {
+	p.lines = nil
+	p.partial.Reset()
+	p.sections = nil
+	p.setRows = nil
+}
+
+// markSection records that the section title is printed next
+func (p *Pager) markSection(title string) {
+	if p.enabled {
+		p.sections = append(p.sections, pagerSection{Title: title, Line: len(p.lines)})
+	}
+}
+
+// markSetRows records the erasure sets of the table rows printed last, one
+// set per row, for --interactive to drill into
+func (p *Pager) markSetRows(sets []mdbcore.ErasureSetInfo) {
+	if !p.enabled || p.csv {
+		return
+	}
+	if p.setRows == nil {
+		p.setRows = make(map[int]mdbcore.ErasureSetInfo)
+	}
+	first := len(p.lines) - len(sets)
+	for i, set := range sets {
+		p.setRows[first+i] = set
+	}
+}
+
+// sectionLines returns the number of lines of each collected section, up to
+// the next section or the end of the output
+func (p *Pager) sectionLines() []int {
+	counts := make([]int, len(p.sections))
+	end := len(p.contentLines())
+	for i := len(p.sections) - 1; i >= 0; i-- {
+		counts[i] = end - p.sections[i].Line
+		end = p.sections[i].Line
+	}
+	return counts
+}
+
+// appendPager prints the output collected by other, keeping its sections
+func (p *Pager) appendPager(other *Pager) {
+	if p.enabled {
+		for _, section := range other.sections {
+			p.sections = append(p.sections, pagerSection{Title: section.Title, Line: len(p.lines) + section.Line})
+		}
+	}
+	p.WriteString(other.String())
+}
+
+// flush writes buffered stdout output
+func (p *Pager) flush() {
+	if p.writer != nil {
+		p.writer.Flush()
+	}
+}
+
+// SetOutput creates path and writes everything printed from now on into it
+func (p *Pager) SetOutput(path string, color bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file '%s': %v", path, err)
+	}
+	p.output = file
+	p.outputWriter = bufio.NewWriterSize(file, pagerBufferSize)
+	p.outputColor = color
+	return nil
+}
+
+func (p *Pager) writeOutput(text string) {
+	if p.outputErr != nil {
+		return
+	}
+	if !p.outputColor {
+		text = stripANSI(text)
+	}
+	if _, err := p.outputWriter.WriteString(text); err != nil {
+		p.outputErr = fmt.Errorf("failed to write output file '%s': %v", p.output.Name(), err)
+	}
+}
+
+// Close flushes stdout, closes the output file and reports any error that
+// occurred while writing the output
+func (p *Pager) Close() error {
+	p.flush()
+	if p.output == nil {
+		return p.outputErr
+	}
+	if err := p.outputWriter.Flush(); err != nil && p.outputErr == nil {
+		p.outputErr = fmt.Errorf("failed to write output file '%s': %v", p.output.Name(), err)
+	}
+	err := p.output.Close()
+	if p.outputErr != nil {
+		return p.outputErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close output file '%s': %v", p.output.Name(), err)
 	}
+	return nil
 }
 
-// Show displays the buffered output using bubbletea viewport
+// Show displays the collected output using bubbletea viewport. Without a terminal
+// on stdout, or when an automatic pager's content fits on the screen, the
+// content is printed plainly instead.
 func (p *Pager) Show() {
 	if !p.enabled {
+		p.flush()
+		return
+	}
+
+	lines := p.contentLines()
+	if len(lines) == 0 {
 		return
 	}
 
-	content := p.buffer.String()
-	if content == "" {
+	if !p.pages(lines) {
+		p.printContent()
 		return
 	}
 
-	pager := newViewportModel(content)
-	if err := tea.NewProgram(pager, tea.WithAltScreen()).Start(); err != nil {
-		fmt.Print(content)
+	pager := newViewportModel(lines)
+	pager.auto = p.auto
+	pager.sections = p.sections
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if !p.noMouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	if _, err := tea.NewProgram(pager, options...).Run(); err != nil {
+		p.printContent()
+	}
+}
+
+// pages reports whether Show opens the pager for lines rather than printing
+// them: stdout must be a terminal and, when paging automatically, lines must
+// not fit on it with the help line below them
+func (p *Pager) pages(lines []string) bool {
+	if !p.isTerminal() {
+		return false
+	}
+	if p.auto {
+		height, err := p.termHeight()
+		return err == nil && len(lines) >= height
+	}
+	return true
+}
+
+// printContent writes the collected output to stdout without paging
+func (p *Pager) printContent() {
+	w := bufio.NewWriterSize(p.stdout, pagerBufferSize)
+	for _, line := range p.lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	w.WriteString(p.partial.String())
+	w.Flush()
+}
+
+// terminalWidth returns the width of the terminal stdout is shown on, 0 when
+// stdout is not a terminal
+func terminalWidth() int {
+	if !stdoutIsTerminal() {
+		return 0
 	}
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || width <= 0 {
+		return 0
+	}
+	return width
+}
+
+// terminalHeight returns the height of the terminal stdout is shown on
+func terminalHeight() (int, error) {
+	_, height, err := term.GetSize(os.Stdout.Fd())
+	return height, err
+}
+
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(os.Stdout.Fd())
 }
 
-// viewportModel holds the state for the viewport pager
+// viewportModel holds the state for the viewport pager. The viewport only
+// receives the lines on screen, scrolling moves offset through lines.
 type viewportModel struct {
-	viewport viewport.Model
-	content  string
+	viewport  viewport.Model
+	all       []string // the whole report
+	lines     []string // the lines shown, all of them or those matching filter
+	offset    int      // first line on screen
+	saving    bool     // filename prompt is active, keys go to the prompt instead of scrolling
+	filename  string   // filename typed at the prompt
+	filtering bool     // filter prompt is active
+	filter    string   // substring the shown lines match, "" for all lines
+	query     string   // filter typed at the prompt
+	status    string   // transient confirmation or error shown instead of the help text
+	statusErr bool
+	statusID  int // identifies the status so an older timer does not clear a newer one
+
+	auto        bool  // opened automatically, the help line mentions --no-pager
+	problems    []int // line numbers that contain a failure indicator
+	current     int   // line of the last problem jumped to, -1 before the first jump
+	highlight   int   // line shown highlighted, -1 for none
+	highlightID int
+
+	sections []pagerSection // section titles of the report, jumped to with the number keys
+}
+
+// statusTimeout is how long a save confirmation or error stays on the help line
+const statusTimeout = 3 * time.Second
+
+// mouseWheelLines is how many lines one step of the mouse wheel scrolls
+const mouseWheelLines = 3
+
+// highlightTimeout is how long the line jumped to with e/E stays highlighted
+const highlightTimeout = 1500 * time.Millisecond
+
+// clearStatusMsg clears the status with the given ID from the help line
+type clearStatusMsg struct {
+	id int
+}
+
+// clearHighlightMsg removes the highlight with the given ID
+type clearHighlightMsg struct {
+	id int
 }
 
-func newViewportModel(content string) viewportModel {
-	vp := viewport.New(0, 0)
-	vp.SetContent(content)
+// problemWords mark lines with non-ok drive or server states
+var problemWords = []string{"offline", "faulty", "unformatted", "corrupt"}
 
+func newViewportModel(lines []string) viewportModel {
 	return viewportModel{
-		viewport: vp,
-		content:  content,
+		viewport:  viewport.New(0, 0),
+		all:       lines,
+		lines:     lines,
+		problems:  indexProblemLines(lines),
+		current:   -1,
+		highlight: -1,
+	}
+}
+
+// indexProblemLines returns the numbers of lines that are colored red or mention a non-ok state
+func indexProblemLines(lines []string) []int {
+	var problems []int
+	for i, line := range lines {
+		if hasRedValue(line) {
+			problems = append(problems, i)
+			continue
+		}
+		text := strings.ToLower(stripANSI(line))
+		for _, word := range problemWords {
+			if strings.Contains(text, word) {
+				problems = append(problems, i)
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// hasRedValue reports whether the line has a red span other than a zero count
+func hasRedValue(line string) bool {
+	for _, span := range strings.Split(line, Red)[1:] {
+		value, _, _ := strings.Cut(span, Reset)
+		if strings.TrimSpace(value) != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// jumpToProblem scrolls to the next (or previous) problem line, centers and highlights it
+func (m viewportModel) jumpToProblem(forward bool) (tea.Model, tea.Cmd) {
+	if len(m.problems) == 0 {
+		return m.setStatus("No problem lines", false)
+	}
+
+	// Continue from the last jump while it is on screen, otherwise from the visible page
+	anchor := m.current
+	top, bottom := m.offset, m.offset+m.viewport.Height
+	if anchor < top || anchor >= bottom {
+		if forward {
+			anchor = top - 1
+		} else {
+			anchor = bottom
+		}
+	}
+
+	// Wrap around at the end of the content
+	idx := -1
+	if forward {
+		idx = sort.SearchInts(m.problems, anchor+1)
+		if idx == len(m.problems) {
+			idx = 0
+		}
+	} else {
+		idx = sort.SearchInts(m.problems, anchor) - 1
+		if idx < 0 {
+			idx = len(m.problems) - 1
+		}
+	}
+
+	line := m.problems[idx]
+	m.current = line
+	m.highlight = line
+	m.highlightID++
+	m.scrollTo(line - m.viewport.Height/2)
+
+	id := m.highlightID
+	clearHighlight := tea.Tick(highlightTimeout, func(time.Time) tea.Msg {
+		return clearHighlightMsg{id: id}
+	})
+	model, status := m.setStatus(fmt.Sprintf("Problem %d of %d", idx+1, len(m.problems)), false)
+	return model, tea.Batch(status, clearHighlight)
+}
+
+// jumpToSection scrolls the title of the nth section, counted from 1, to the
+// top of the screen and highlights it
+func (m viewportModel) jumpToSection(n int) (tea.Model, tea.Cmd) {
+	if len(m.sections) == 0 {
+		return m, nil
+	}
+	if m.filter != "" {
+		return m.setStatus("Clear the filter with F to jump to a section", true)
+	}
+	if n > len(m.sections) {
+		return m.setStatus(fmt.Sprintf("The report has %d sections", len(m.sections)), true)
+	}
+	section := m.sections[n-1]
+	m.highlight = section.Line
+	m.highlightID++
+	m.scrollTo(section.Line)
+
+	id := m.highlightID
+	clearHighlight := tea.Tick(highlightTimeout, func(time.Time) tea.Msg {
+		return clearHighlightMsg{id: id}
+	})
+	model, status := m.setStatus(fmt.Sprintf("Section %d of %d: %s", n, len(m.sections), section.Title), false)
+	return model, tea.Batch(status, clearHighlight)
+}
+
+// scrollTo moves the first line on screen to offset, kept within the content
+func (m *viewportModel) scrollTo(offset int) {
+	m.offset = max(0, min(offset, len(m.lines)-m.viewport.Height))
+	m.renderWindow()
+}
+
+// renderWindow hands the lines on screen to the viewport, the highlighted line
+// shown in reverse video
+func (m *viewportModel) renderWindow() {
+	end := min(m.offset+m.viewport.Height, len(m.lines))
+	var window strings.Builder
+	for i := m.offset; i < end; i++ {
+		if i > m.offset {
+			window.WriteByte('\n')
+		}
+		if i == m.highlight {
+			window.WriteString(lipgloss.NewStyle().Reverse(true).Render(stripANSI(m.lines[i])))
+			continue
+		}
+		window.WriteString(m.lines[i])
 	}
+	m.viewport.SetContent(window.String())
 }
 
 func (m viewportModel) Init() tea.Cmd {
@@ -171,62 +770,1190 @@ func (m viewportModel) Init() tea.Cmd {
 }
 
 func (m viewportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.viewport.Width = msg.Width
 		m.viewport.Height = msg.Height - 1 // Reserve space for help text
-		m.viewport.SetContent(m.content) // Re-set content with new dimensions
+		m.scrollTo(m.offset)               // Re-render the window with new dimensions
+		return m, nil
+
+	case clearHighlightMsg:
+		if msg.id == m.highlightID {
+			m.highlight = -1
+			m.renderWindow()
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scrollTo(m.offset - mouseWheelLines)
+		case tea.MouseButtonWheelDown:
+			m.scrollTo(m.offset + mouseWheelLines)
+		}
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.saving || m.filtering {
+			return m.updatePrompt(msg)
+		}
 		switch msg.String() {
 		case "q", "Q", "ctrl+c":
 			return m, tea.Quit
+		case "s":
+			m.saving = true
+			m.filename = ""
+			m.status = ""
+			return m, nil
+		case "f":
+			m.filtering = true
+			m.query = ""
+			m.status = ""
+			return m, nil
+		case "F":
+			if m.filter == "" {
+				return m, nil
+			}
+			m.showLines("", m.all)
+			return m.setStatus("Filter cleared", false)
+		case "e", "n":
+			return m.jumpToProblem(true)
+		case "E", "p":
+			return m.jumpToProblem(false)
 		case "up", "k":
-			m.viewport.LineUp(1)
+			m.scrollTo(m.offset - 1)
 			return m, nil
 		case "down", "j":
-			m.viewport.LineDown(1)
+			m.scrollTo(m.offset + 1)
 			return m, nil
 		case " ":
 			// Space scrolls half a page down (more convenient for quick navigation)
-			m.viewport.HalfViewDown()
+			m.scrollTo(m.offset + m.viewport.Height/2)
 			return m, nil
 		case "pgdown", "ctrl+f":
-			m.viewport.HalfViewDown()
+			m.scrollTo(m.offset + m.viewport.Height/2)
 			return m, nil
 		case "pgup", "ctrl+b":
-			m.viewport.HalfViewUp()
+			m.scrollTo(m.offset - m.viewport.Height/2)
 			return m, nil
 		case "home", "g":
-			m.viewport.GotoTop()
+			m.scrollTo(0)
 			return m, nil
 		case "end", "G":
-			m.viewport.GotoBottom()
+			m.scrollTo(len(m.lines))
+			return m, nil
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			return m.jumpToSection(int(msg.String()[0] - '0'))
+		}
+	}
+
+	return m, nil
+}
+
+// updatePrompt handles keys while the filename or filter prompt is active
+func (m viewportModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	input := &m.filename
+	if m.filtering {
+		input = &m.query
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		if m.filtering {
+			m.filtering = false
+			return m.applyFilter(m.query)
+		}
+		m.saving = false
+		if strings.TrimSpace(m.filename) == "" {
 			return m, nil
 		}
+		path, err := saveReportContent(m.filename, m.all)
+		if err != nil {
+			return m.setStatus(err.Error(), true)
+		}
+		return m.setStatus("Saved to "+path, false)
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.saving = false
+		m.filtering = false
+		return m, nil
+	case tea.KeyBackspace:
+		if runes := []rune(*input); len(runes) > 0 {
+			*input = string(runes[:len(runes)-1])
+		}
+		return m, nil
+	case tea.KeyRunes, tea.KeySpace:
+		*input += string(msg.Runes)
+		return m, nil
+	}
+	return m, nil
+}
+
+// applyFilter shows only the lines matching query, or all lines for an empty
+// query. A query nothing matches leaves the shown lines as they are.
+func (m viewportModel) applyFilter(query string) (tea.Model, tea.Cmd) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		m.showLines("", m.all)
+		return m, nil
+	}
+	lines := filterReportLines(m.all, m.sections, query)
+	if len(lines) == 0 {
+		return m.setStatus(fmt.Sprintf("No lines match %q", query), true)
+	}
+	m.showLines(query, lines)
+	return m, nil
+}
+
+// showLines shows lines, the lines matching filter, from the top
+func (m *viewportModel) showLines(filter string, lines []string) {
+	m.filter = filter
+	m.lines = lines
+	m.problems = indexProblemLines(lines)
+	m.current = -1
+	m.highlight = -1
+	m.scrollTo(0)
+}
+
+// filterReportLines returns the lines whose text contains query, ignoring case
+// and colors. Matching table rows are preceded by the title of their section,
+// one of sections, and the header of their table, once per table, so the
+// columns stay readable.
+func filterReportLines(lines []string, sections []pagerSection, query string) []string {
+	query = strings.ToLower(query)
+	titles := make(map[int]bool, len(sections))
+	for _, section := range sections {
+		titles[section.Line] = true
+	}
+	// isRule reports whether lines[i] is the dashed line under a table header
+	isRule := func(i int) bool {
+		trimmed := strings.TrimSpace(stripANSI(lines[i]))
+		return trimmed != "" && strings.Trim(trimmed, "- ") == ""
+	}
+	var matched []string
+	title, header := -1, -1 // lines of the current section title and table header
+	shownTitle, shownHeader := -1, -1
+	for i, line := range lines {
+		plain := stripANSI(line)
+		switch {
+		case strings.TrimSpace(plain) == "":
+			header = -1
+			continue
+		case titles[i]:
+			title, header = i, -1
+		case isRule(i):
+			continue
+		case i+1 < len(lines) && isRule(i+1):
+			header = i
+		}
+		if !strings.Contains(strings.ToLower(plain), query) {
+			continue
+		}
+		if title >= 0 && title != shownTitle {
+			matched = append(matched, lines[title])
+			shownTitle = title
+			if title == i {
+				continue
+			}
+		}
+		if header >= 0 && header != shownHeader {
+			matched = append(matched, lines[header], lines[header+1])
+			shownHeader = header
+			if header == i {
+				continue
+			}
+		}
+		matched = append(matched, line)
 	}
+	return matched
+}
+
+// setStatus shows a transient message on the help line
+func (m viewportModel) setStatus(status string, isErr bool) (tea.Model, tea.Cmd) {
+	m.status = status
+	m.statusErr = isErr
+	m.statusID++
+	id := m.statusID
+	return m, tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatusMsg{id: id}
+	})
+}
 
-	m.viewport, cmd = m.viewport.Update(msg)
-	return m, cmd
+// saveReportContent writes the report lines without ANSI colors to filename and returns the path written
+func saveReportContent(filename string, lines []string) (string, error) {
+	path := strings.TrimSpace(filename)
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[2:])
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to save report: %v", err)
+	}
+	w := bufio.NewWriterSize(file, pagerBufferSize)
+	for _, line := range lines {
+		w.WriteString(stripANSI(line))
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save report: %v", err)
+	}
+	return path, nil
 }
 
 func (m viewportModel) View() string {
+	autoNote := ""
+	if m.auto {
+		autoNote = "  (--no-pager disables)"
+	}
+	sectionKeys := ""
+	if len(m.sections) > 0 {
+		sectionKeys = "  1-9: section"
+	}
+	if m.saving {
+		return fmt.Sprintf("%s\n Save to: %s█", m.viewport.View(), m.filename)
+	}
+	if m.filtering {
+		return fmt.Sprintf("%s\n Filter: %s█", m.viewport.View(), m.query)
+	}
+	if m.status != "" {
+		color := lipgloss.Color("42")
+		if m.statusErr {
+			color = lipgloss.Color("196")
+		}
+		return fmt.Sprintf("%s\n%s", m.viewport.View(), lipgloss.NewStyle().Foreground(color).Render(" "+m.status))
+	}
+
 	helpText := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
-		Render(" ↑/↓/j/k: scroll  space: page down  g/G: top/bottom  q: quit")
-	
-	return fmt.Sprintf("%s\n%s", m.viewport.View(), helpText)
+		Render(" ↑/↓/j/k: scroll  space: page down  g/G: top/bottom  e/E: next/prev problem" + sectionKeys + "  f/F: filter/clear  s: save  q: quit" + autoNote)
+	if m.filter != "" {
+		helpText = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render(fmt.Sprintf(" Filter %q: %d of %d lines  F: clear  f: filter  e/E: next/prev problem  s: save  q: quit", m.filter, len(m.lines), len(m.all)))
+	}
+
+	// The position goes to the right end of the help line
+	position := m.position()
+	gap := max(m.viewport.Width-lipgloss.Width(helpText)-len(position)-1, 2)
+	return fmt.Sprintf("%s\n%s%s%s", m.viewport.View(), helpText, strings.Repeat(" ", gap), position)
 }
 
-func main() {
-	app := cli.NewApp()
-	app.Name = "mdb"
-	app.Usage = "MinIO Debug - analyze MinIO diagnostic JSON files"
-	app.Version = Version
-	app.Commands = []cli.Command{
+// position returns how far through the lines the bottom of the screen is, e.g.
+// "42%  123/2980"
+func (m viewportModel) position() string {
+	if len(m.lines) == 0 {
+		return ""
+	}
+	bottom := min(m.offset+m.viewport.Height, len(m.lines))
+	return fmt.Sprintf("%d%%  %d/%d", bottom*100/len(m.lines), bottom, len(m.lines))
+}
+
+// Views of --interactive, in tab order
+const (
+	viewSummary = iota
+	viewServers
+	viewSets
+	viewDrives
+)
+
+// interactiveViews are the tab titles of the views of --interactive
+var interactiveViews = []string{"Summary", "Servers", "Erasure Sets", "Drives"}
+
+// interactiveModel is the state of --interactive. Each view is rendered by
+// renderReport with its own configuration whenever the view or a filter changes.
+type interactiveModel struct {
+	infoStruct *clusterStruct
+	config     Config // of the command line, the base of every view
+	view       int
+	failed     bool   // failed-only filter, toggled with f
+	scanning   bool   // scanning-only filter, toggled with s
+	drill      string // erasure set key whose drives the drives view shows, "" for all
+	lines      []string
+	rows       []int                          // lines that are table rows, selectable
+	sets       map[int]mdbcore.ErasureSetInfo // erasure set of the rows of the Erasure Sets table, keyed by line
+	selected   int                            // index in rows
+	offset     int                            // first line on screen
+	width      int
+	height     int
+	status     string
+}
+
+// runInteractive shows infoStruct in the interactive browser until q is pressed
+func runInteractive(infoStruct *clusterStruct, config *Config) error {
+	if !stdoutIsTerminal() {
+		return fmt.Errorf("--interactive needs a terminal")
+	}
+	_, err := tea.NewProgram(newInteractiveModel(infoStruct, config), tea.WithAltScreen()).Run()
+	return err
+}
+
+func newInteractiveModel(infoStruct *clusterStruct, config *Config) interactiveModel {
+	m := interactiveModel{
+		infoStruct: infoStruct,
+		config:     *config,
+		failed:     config.FailedMode,
+		scanning:   config.ScanningMode,
+	}
+	switch {
+	case config.ShowDisks && !config.ShowSets:
+		m.view = viewDrives
+	case config.ShowSets && !config.ShowSummary:
+		m.view = viewSets
+	case config.ShowServers && !config.ShowSummary:
+		m.view = viewServers
+	}
+	m.render()
+	return m
+}
+
+// viewConfig returns the configuration that renders the current view
+func (m interactiveModel) viewConfig() *Config {
+	config := m.config
+	config.ShowSummary = m.view == viewSummary
+	config.ShowServers = m.view == viewServers
+	config.ShowSets = m.view == viewSets
+	config.ShowDisks = m.view == viewDrives
+	config.FailedMode = m.failed
+	config.ScanningMode = m.scanning
+	config.DrillSet = ""
+	if m.view == viewDrives {
+		config.DrillSet = m.drill
+	}
+	config.Interactive = false
+	config.Title = false
+	config.Legend = false
+	return &config
+}
+
+// render renders the current view and selects its first table row
+func (m *interactiveModel) render() {
+	pager := NewPager(true)
+	pager.stripColor = m.config.ColorMode == colorNever
+	if err := renderReport(pager, m.infoStruct, m.viewConfig()); err != nil {
+		pager = NewPager(true)
+		pager.Printf("%s%v%s\n", Red, err, Reset)
+	}
+	m.lines = pager.contentLines()
+	m.rows = tableRowLines(m.lines)
+	m.sets = pager.setRows
+	m.selected = 0
+	m.offset = 0
+	m.scrollToSelected()
+}
+
+// tableRowLines returns the indexes of the table rows of rendered lines: the
+// lines after a header separator up to the next blank line
+func tableRowLines(lines []string) []int {
+	var rows []int
+	inTable := false
+	for i, line := range lines {
+		plain := strings.TrimSpace(stripANSI(line))
+		switch {
+		case plain == "":
+			inTable = false
+		case strings.Trim(plain, "- ") == "":
+			inTable = true
+		case inTable:
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// contentHeight is the number of lines between the tab bar and the status bar
+func (m interactiveModel) contentHeight() int {
+	return max(m.height-2, 1)
+}
+
+// scrollTo moves the first line on screen to offset, kept within the content
+func (m *interactiveModel) scrollTo(offset int) {
+	m.offset = max(0, min(offset, len(m.lines)-m.contentHeight()))
+}
+
+// scrollToSelected scrolls just enough to show the selected row
+func (m *interactiveModel) scrollToSelected() {
+	if len(m.rows) == 0 {
+		return
+	}
+	line := m.rows[m.selected]
+	switch {
+	case line < m.offset:
+		m.scrollTo(line)
+	case line >= m.offset+m.contentHeight():
+		m.scrollTo(line - m.contentHeight() + 1)
+	}
+}
+
+// move moves the selection by delta rows, or scrolls if the view has no rows
+func (m *interactiveModel) move(delta int) {
+	if len(m.rows) == 0 {
+		m.scrollTo(m.offset + delta)
+		return
+	}
+	m.selected = max(0, min(m.selected+delta, len(m.rows)-1))
+	m.scrollToSelected()
+}
+
+// switchView shows view, leaving a drill-down into a set
+func (m *interactiveModel) switchView(view int) {
+	m.view = (view + len(interactiveViews)) % len(interactiveViews)
+	m.drill = ""
+	m.status = ""
+	m.render()
+}
+
+// drillIntoSet shows the drives of the erasure set of the selected row
+func (m *interactiveModel) drillIntoSet() {
+	if m.view != viewSets || len(m.rows) == 0 {
+		return
+	}
+	set, ok := m.sets[m.rows[m.selected]]
+	if !ok {
+		m.status = "Select an erasure set row"
+		return
+	}
+	m.view = viewDrives
+	m.drill = mdbcore.SetKey(set.PoolIdx, set.SetIdx)
+	m.status = ""
+	m.render()
+}
+
+func (m interactiveModel) Init() tea.Cmd {
+	return tea.WindowSize()
+}
+
+func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scrollTo(m.offset)
+		m.scrollToSelected()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "Q", "ctrl+c":
+			return m, tea.Quit
+		case "tab", "right", "l":
+			m.switchView(m.view + 1)
+		case "shift+tab", "left", "h":
+			m.switchView(m.view - 1)
+		case "1", "2", "3", "4":
+			m.switchView(int(msg.Runes[0] - '1'))
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "pgup", "ctrl+b":
+			m.move(-m.contentHeight() / 2)
+		case "pgdown", "ctrl+f", " ":
+			m.move(m.contentHeight() / 2)
+		case "home", "g":
+			m.move(-len(m.lines))
+		case "end", "G":
+			m.move(len(m.lines))
+		case "enter":
+			m.drillIntoSet()
+		case "esc", "backspace":
+			if m.drill != "" {
+				m.switchView(viewSets)
+			}
+		case "f":
+			m.failed = !m.failed
+			m.render()
+		case "s":
+			m.scanning = !m.scanning
+			m.render()
+		}
+	}
+	return m, nil
+}
+
+// statusLine describes the view, the active filters and the keys
+func (m interactiveModel) statusLine() string {
+	var filters []string
+	if m.failed {
+		filters = append(filters, "failed")
+	}
+	if m.scanning {
+		filters = append(filters, "scanning")
+	}
+	if len(filters) == 0 {
+		filters = append(filters, "none")
+	}
+	parts := []string{"Filters: " + strings.Join(filters, ", ")}
+	if m.drill != "" {
+		pool, set, _ := strings.Cut(m.drill, ":")
+		parts = append(parts, fmt.Sprintf("Pool %s, Set %s (esc: back)", pool, set))
+	}
+	if m.status != "" {
+		parts = append(parts, m.status)
+	}
+	keys := "↑/↓: select  tab/←/→: view  f: failed  s: scanning  q: quit"
+	if m.view == viewSets {
+		keys = "↑/↓: select  enter: drives  tab/←/→: view  f: failed  s: scanning  q: quit"
+	}
+	return " " + strings.Join(parts, "  |  ") + "  |  " + keys
+}
+
+func (m interactiveModel) View() string {
+	var b strings.Builder
+	for i, name := range interactiveViews {
+		tab := fmt.Sprintf(" %d %s ", i+1, name)
+		if i == m.view {
+			tab = lipgloss.NewStyle().Reverse(true).Bold(true).Render(tab)
+		}
+		b.WriteString(tab)
+	}
+	b.WriteByte('\n')
+
+	selectedLine := -1
+	if len(m.rows) > 0 {
+		selectedLine = m.rows[m.selected]
+	}
+	end := min(m.offset+m.contentHeight(), len(m.lines))
+	for i := m.offset; i < m.offset+m.contentHeight(); i++ {
+		switch {
+		case i >= end:
+		case i == selectedLine:
+			b.WriteString(lipgloss.NewStyle().Reverse(true).Render(stripANSI(m.lines[i])))
+		default:
+			b.WriteString(m.lines[i])
+		}
+		b.WriteByte('\n')
+	}
+
+	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(m.statusLine()))
+	return b.String()
+}
+
+// showFlags are accepted by "mdb show" and all of its subcommands
+var showFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "pager",
+		Usage: "Enable pagination (pauses output after each screen, press space to continue)",
+	},
+	cli.BoolFlag{
+		Name:  "no-pager",
+		Usage: "Never page, even when the output is taller than the terminal",
+	},
+	cli.BoolFlag{
+		Name:  "no-mouse",
+		Usage: "Do not scroll the pager with the mouse wheel, so the terminal can select text",
+	},
+	cli.IntFlag{
+		Name:  "width",
+		Usage: "Fit tables in COLUMNS characters, 0 for no limit (default: the terminal width on a terminal, no limit otherwise)",
+	},
+	cli.IntFlag{
+		Name:  "max-rows",
+		Usage: "Cut the drive tables after N rows with a count of the rest, 0 for no limit; text output only, not with --pager",
+	},
+	cli.StringFlag{
+		Name:  "grep",
+		Usage: "Show only the servers and drives with a cell containing PATTERN, case-insensitive; erasure sets are shown for the matching drives",
+	},
+	cli.StringFlag{
+		Name:  "group-by",
+		Usage: "Sum servers and drives per failure domain, named by the first capture group of REGEX in the server name, e.g. '^minio-(r\\d+)'",
+	},
+	cli.BoolFlag{
+		Name:  "grep-regex",
+		Usage: "Match --grep as a regular expression instead of a substring",
+	},
+	cli.StringFlag{
+		Name:  "trim-domain",
+		Usage: "Trim domain suffixes from endpoint names for cleaner display (e.g., '.example.com', '.dc1.example.com,.dc2.example.com' or 'auto' for the suffix all servers share)",
+	},
+	cli.BoolFlag{
+		Name:  "fqdn",
+		Usage: "Show full server host names, ignoring --trim-domain",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Value: formatText,
+		Usage: "Output format: text, markdown, html, csv (tables only), grafana",
+	},
+	cli.BoolFlag{
+		Name:  "title",
+		Usage: "Print a heading with the source file and snapshot timestamp",
+	},
+	cli.BoolFlag{
+		Name:  "legend",
+		Usage: "Print a legend of the colors and an index of the report sections before the report",
+	},
+	cli.IntFlag{
+		Name:  "history-size",
+		Value: defaultHistorySize,
+		Usage: "Number of runs to keep in the per-deployment health history (default 0 records no history)",
+	},
+	cli.StringFlag{
+		Name:  "baseline",
+		Usage: "Compare with the drive and server states saved in FILE and tag what failed since as NEW",
+	},
+	cli.BoolFlag{
+		Name:  "update-baseline",
+		Usage: "Save the drive and server states of this snapshot to the --baseline file",
+	},
+	cli.StringFlag{
+		Name:  "output",
+		Usage: "Write the report to PATH instead of stdout",
+	},
+	cli.BoolFlag{
+		Name:  "yes",
+		Usage: "Write large structured output to the terminal without asking",
+	},
+	cli.BoolFlag{
+		Name:  "retry-on-change",
+		Usage: "Read the file again once if it changes while being read (e.g. still being collected)",
+	},
+	cli.StringFlag{
+		Name:  "color",
+		Value: colorAuto,
+		Usage: "Color output: auto (colors on stdout, none in --output files), always, never",
+	},
+	cli.StringFlag{
+		Name:  "units",
+		Value: unitsIEC,
+		Usage: "Size units: iec (GiB, TiB), si (GB, TB) or bytes",
+	},
+	cli.StringFlag{
+		Name:  "fixed-unit",
+		Usage: "Show the sizes of the drive tables in one unit, GiB or TiB, for scripts that parse them",
+	},
+	cli.IntFlag{
+		Name:  "precision",
+		Value: defaultPrecision,
+		Usage: "Decimal places of sizes and percentages",
+	},
+	cli.BoolFlag{
+		Name:  "exact",
+		Usage: "Follow the sizes of the summary with their exact number of bytes",
+	},
+	cli.BoolFlag{
+		Name:  "show-unknown",
+		Usage: "List fields of the input file that mdb does not read, as JSON pointers",
+	},
+	cli.BoolFlag{
+		Name:  "ascii",
+		Usage: "Draw --bars and --tree with ASCII characters for terminals without Unicode",
+	},
+	cli.BoolFlag{
+		Name:  "quiet",
+		Usage: "Print only problems, one line each: the findings of the Problems section except info (nothing when healthy)",
+	},
+	cli.BoolFlag{
+		Name:  "log-line",
+		Usage: "Print a MDB_SUMMARY line of key=value pairs for the whole cluster to stderr, for log pipelines",
+	},
+	cli.StringFlag{
+		Name:  "fail-on",
+		Usage: "Exit with an error after the report if there is a finding of this severity or worse: critical, warning, info",
+	},
+	cli.StringSliceFlag{
+		Name:  "header",
+		Usage: "HTTP header sent when the file is an http:// or https:// URL, e.g. 'Authorization: Bearer TOKEN' (repeatable)",
+	},
+	cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "Do not verify the TLS certificate when the file is an https:// URL",
+	},
+	cli.StringFlag{
+		Name:  "timeout",
+		Value: "30s",
+		Usage: "Give up fetching an http:// or https:// URL after DURATION",
+	},
+	cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "Browse the summary, servers, erasure sets and drives in tabs, toggling the failed and scanning filters live",
+	},
+	cli.StringFlag{
+		Name:  "max-age",
+		Usage: "Exit with an error after the report if the snapshot is older than DURATION (e.g. 36h, 7d)",
+	},
+	cli.StringFlag{
+		Name:  "heal-stuck",
+		Usage: "Show heals running longer than DURATION in red (e.g. 36h, 3d; default 48h)",
+	},
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "Analyze record N (1-based), first or last of an NDJSON file (default: first)",
+	},
+	cli.BoolFlag{
+		Name:  "latest",
+		Usage: "Analyze the last record of an NDJSON file instead of the first",
+	},
+	cli.StringFlag{
+		Name:  "at",
+		Usage: "Analyze the last record of an NDJSON file taken at or before TIMESTAMP (e.g. 2024-05-02T13:45:00Z)",
+	},
+	cli.BoolFlag{
+		Name:  "compare",
+		Usage: "With several files, show only a side-by-side comparison with one column per cluster",
+	},
+	cli.BoolFlag{
+		Name:  "anonymize",
+		Usage: "Replace server names with server-01, server-02..., hash drive UUIDs and drop the deployment ID",
+	},
+	cli.StringFlag{
+		Name:  "anonymize-map",
+		Usage: "Write the anonymized names and their real values as JSON to PATH (implies --anonymize)",
+	},
+	cli.StringFlag{
+		Name:  "bundle",
+		Usage: "Also write the snapshot, text, HTML and JSON reports and a manifest to a .tar.gz archive at PATH",
+	},
+	cli.StringFlag{
+		Name:  "saturation",
+		Usage: "Waiting/tokens ratio at which a drive counts as saturated (default 0.5; 1.0 or more is shown in red)",
+	},
+	cli.StringFlag{
+		Name:  "heal",
+		Usage: "Add heal progress from a file of 'mc admin heal --json' (Heal % column and ETA)",
+	},
+	cli.StringFlag{
+		Name:  "metrics-file",
+		Usage: "Add drive latency and IOPS from a Prometheus dump of /minio/v2/metrics/node (Latency and IOPS columns)",
+	},
+	cli.StringFlag{
+		Name:  "warn-used",
+		Usage: "Used space percentage shown in yellow (default 80)",
+	},
+	cli.StringFlag{
+		Name:  "crit-used",
+		Usage: "Used space percentage shown in red (default 95)",
+	},
+	cli.StringFlag{
+		Name:  "warn-free",
+		Usage: "Free space percentage shown in yellow (default 20)",
+	},
+	cli.StringFlag{
+		Name:  "crit-free",
+		Usage: "Free space percentage shown in red (default 5)",
+	},
+	cli.StringFlag{
+		Name:  "warn-inodes",
+		Usage: "Inode usage percentage shown in yellow (default 80)",
+	},
+	cli.StringFlag{
+		Name:  "crit-inodes",
+		Usage: "Inode usage percentage shown in red (default 95)",
+	},
+	cli.StringFlag{
+		Name:  "warn-unaccounted",
+		Usage: "Share of a drive neither used nor available above which it is a problem (default 10)",
+	},
+	cli.BoolFlag{
+		Name:  "no-config",
+		Usage: "Ignore the defaults of ~/.config/mdb/config.yaml and MDB_* environment variables",
+	},
+}
+
+// analysisFlags add cluster-wide sections to the summary, accepted by "mdb
+// summary", "mdb report" and "mdb show"
+var analysisFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "simulate-loss",
+		Usage: "Show which erasure sets lose quorum without server:NAME or drive:UUID (repeatable)",
+	},
+	cli.StringFlag{
+		Name:  "decommission",
+		Usage: "Check whether the other pools can take the data of pool POOL, exit with an error if not",
+	},
+	cli.BoolFlag{
+		Name:  "consistency",
+		Usage: "Add sections checking the pool, set and server layout and listing drives that share a UUID or have none",
+	},
+	cli.BoolFlag{
+		Name:  "trend",
+		Usage: "Show how used space, bad and scanning disks evolve across the records of an NDJSON file",
+	},
+	cli.BoolFlag{
+		Name:  "project",
+		Usage: "Project when used space reaches --project-at from two files or the first and last record of an NDJSON file",
+	},
+	cli.StringFlag{
+		Name:  "project-at",
+		Usage: "Comma-separated used percentages of usable capacity to project dates for with --project (default 90,100)",
+	},
+	cli.StringFlag{
+		Name:  "interval",
+		Usage: "Time between the snapshots of --project if the files have no timestamps (e.g. 7d)",
+	},
+	cli.StringFlag{
+		Name:  "pool-status",
+		Usage: "Add the decommission or rebalance state of the pools from 'mc admin decommission status --json' or 'mc admin rebalance status --json'",
+	},
+	cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "Print the full health history below the summary",
+	},
+}
+
+// setTreeFlags draw the erasure sets as a tree, accepted by the commands that
+// show the sets
+var setTreeFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "tree",
+		Usage: "Show the pools, their erasure sets and the servers with drives in each set as a tree",
+	},
+	cli.BoolFlag{
+		Name:  "tree-drives",
+		Usage: "Show --tree down to each drive with its state (implies --tree)",
+	},
+}
+
+// drivePathFlags sum the drives per path, accepted by the commands that show
+// the drives
+var drivePathFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "by-path",
+		Usage: "Sum the drives of all servers per drive path, most bad drives first, to find mount slots that fail across servers",
+	},
+	cli.StringFlag{
+		Name:  "path-regex",
+		Usage: "With --by-path, name drive paths by the first capture group of REGEX, e.g. '(disk\\d+)$'; paths it does not match are kept as they are",
+	},
+}
+
+// barsFlag draws usage bars, accepted by the commands that show the sets or
+// the pools
+var barsFlag = cli.BoolFlag{
+	Name:  "bars",
+	Usage: "Draw a bar of the used space next to each erasure set and pool",
+}
+
+// summaryFlags are the flags of "mdb summary" and "mdb show summary"
+var summaryFlags = slices.Concat([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "services",
+		Usage: "Show only the KMS, LDAP, logger and notification target status",
+	},
+	cli.BoolFlag{
+		Name:  "ilm",
+		Usage: "Show only which servers have ILM expiry in progress",
+	},
+	cli.BoolFlag{
+		Name:  "buckets",
+		Usage: "Show only the buckets sorted by size, if the snapshot has per-bucket usage",
+	},
+	cli.BoolFlag{
+		Name:  "offline",
+		Usage: "Show only the offline servers with their pools, drives and erasure sets",
+	},
+	cli.StringFlag{
+		Name:  "replication",
+		Usage: "Show the peer sites from a file of 'mc admin replicate status --json'",
+	},
+	cli.BoolFlag{
+		Name:  "sets-only",
+		Usage: "Show nothing but the erasure sets table, the sets at most risk first",
+	},
+	barsFlag,
+}, analysisFlags, showFlags)
+
+// failedFlags are the flags of "mdb failed", which always shows failed drives
+var failedFlags = slices.Concat([]cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "state",
+		Usage: "Show only drives in STATE, e.g. faulty or unformatted (repeatable)",
+	},
+	cli.StringFlag{
+		Name:  "low-space",
+		Usage: "Filter by free space percentage",
+	},
+	cli.StringFlag{
+		Name:  "min-bad-disks",
+		Usage: "Filter by minimum bad disks",
+	},
+	cli.StringFlag{
+		Name:  "min-set-free",
+		Usage: "Show the free capacity of erasure sets with less than SIZE free in red, e.g. 10TiB",
+	},
+	cli.BoolFlag{
+		Name:  "set-metrics",
+		Usage: "Add a table of drive metrics summed per erasure set",
+	},
+	cli.BoolFlag{
+		Name:  "failed-only-averages",
+		Usage: "With --failed, count and average the erasure sets over their failed drives only",
+	},
+	cli.BoolFlag{
+		Name:  "wide",
+		Usage: "List every server in the Servers column of the erasure sets table",
+	},
+	cli.StringFlag{
+		Name:  "sets-sort",
+		Usage: "Sort the erasure sets table by: risk (bad drives, then scanning drives, then the least free space first)",
+	},
+	cli.BoolFlag{
+		Name:  "sets-only",
+		Usage: "Show nothing but the erasure sets table, the sets at most risk first",
+	},
+	barsFlag,
+}, setTreeFlags, showFlags)
+
+// reportFlags are the flags of "mdb report"
+var reportFlags = slices.Concat([]cli.Flag{
+	cli.StringFlag{
+		Name:  "out",
+		Usage: "Directory the report files are written to, or - for a gzip-compressed tar archive on stdout",
+	},
+	cli.StringFlag{
+		Name:  "low-space",
+		Usage: "Free space percentage below which erasure sets are listed in low-space-sets.csv (default: the --warn-free threshold)",
+	},
+	barsFlag,
+}, analysisFlags, setTreeFlags, drivePathFlags, showFlags)
+
+// setsFlags are the flags of "mdb sets" and "mdb show sets"
+var setsFlags = append([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "scanning",
+		Usage: "Show only scanning disks",
+	},
+	cli.BoolFlag{
+		Name:  "failed",
+		Usage: "Show only failed/faulty disks (not 'ok' state)",
+	},
+}, failedFlags...)
+
+// disksFlags are the flags of "mdb drives" and "mdb show disks"
+var disksFlags = slices.Concat([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "scanning",
+		Usage: "Show only scanning disks",
+	},
+	cli.BoolFlag{
+		Name:  "failed",
+		Usage: "Show only failed/faulty disks (not 'ok' state)",
+	},
+	cli.StringSliceFlag{
+		Name:  "state",
+		Usage: "Show only drives in STATE, e.g. faulty or unformatted (repeatable)",
+	},
+	cli.StringFlag{
+		Name:  "low-space",
+		Usage: "Filter by free space percentage",
+	},
+	cli.StringFlag{
+		Name:  "min-free-bytes",
+		Usage: "Show only drives with less free space than SIZE, e.g. 200GiB or 1.5TB",
+	},
+	cli.StringFlag{
+		Name:  "free-color-by",
+		Value: freeColorByPct,
+		Usage: "Color the free space of drives by pct (--warn-free, --crit-free) or bytes (--crit-free-bytes)",
+	},
+	cli.StringFlag{
+		Name:  "crit-free-bytes",
+		Usage: "With --free-color-by bytes, free space below which drives are red, yellow below twice that (default: 100GiB)",
+	},
+	cli.StringFlag{
+		Name:  "inodes",
+		Usage: "Show only drives whose inode usage is above a percentage, e.g. --inodes=90 (--inodes alone uses 80)",
+	},
+	cli.StringFlag{
+		Name:  "errors",
+		Usage: "Show only drives with I/O errors sorted by error count, e.g. --errors=10 (--errors alone uses 1)",
+	},
+	cli.BoolFlag{
+		Name:  "unaccounted",
+		Usage: "Show only drives with more unaccounted space than --warn-unaccounted, e.g. filled by another tenant",
+	},
+	cli.StringFlag{
+		Name:  "preset",
+		Usage: "Drives table column preset: capacity, health, hardware or a preset from the config file",
+	},
+	cli.StringFlag{
+		Name:  "columns",
+		Usage: "Comma-separated Drives table columns by identifier or header, e.g. server,path,state,uuid ('all' for the default columns)",
+	},
+	cli.StringFlag{
+		Name:  "sort-by",
+		Usage: "Sort the drives table by: server, raw, used, used-pct, free",
+	},
+}, drivePathFlags, showFlags)
+
+// serversFlags are the flags of "mdb servers" and "mdb show servers"
+var serversFlags = append([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "failed",
+		Usage: "Show only offline servers",
+	},
+	cli.BoolFlag{
+		Name:  "busy-servers",
+		Usage: "Show only servers with healing/scanning drives, sorted by healing drive count",
+	},
+	cli.BoolFlag{
+		Name:  "server-summary",
+		Usage: "Show per-server drive health summary table",
+	},
+	cli.BoolFlag{
+		Name:  "server-details",
+		Usage: "Add CPU, Go runtime and garbage collection columns to the servers table",
+	},
+	cli.BoolFlag{
+		Name:  "server-capacity",
+		Usage: "Show only the raw, used and free space per server with a totals row",
+	},
+	cli.StringFlag{
+		Name:  "sort-by",
+		Usage: "Sort the --server-capacity table by: server, drives, raw, used, used-pct, free",
+	},
+}, showFlags...)
+
+func main() {
+	// Handle __complete for dynamic completion
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		handleCompletion(os.Args[2:])
+		return
+	}
+
+	if err := runApp(os.Args); err != nil {
+		console.Fatalln(err)
+	}
+}
+
+// runApp runs the application with command-line arguments args
+func runApp(args []string) error {
+	app := newApp()
+	return app.Run(reorderShowArgs(app, interactiveArgs(app, withFlagDefaults(app, args))))
+}
+
+// withFlagDefaults resolves the flag defaults for args once, keeps them in the
+// metadata of app for applyFlagDefaults and returns args with optional flag
+// values expanded
+func withFlagDefaults(app *cli.App, args []string) []string {
+	defaults := resolveFlagDefaults(args)
+	app.Metadata = map[string]interface{}{flagDefaultsKey: defaults}
+	return expandOptionalFlags(args, defaults)
+}
+
+// interactiveArgs turns "mdb FILE --interactive" into "mdb show FILE --interactive",
+// the root command has no report flags of its own
+func interactiveArgs(app *cli.App, args []string) []string {
+	if len(args) < 2 || app.Command(args[1]) != nil {
+		return args
+	}
+	interactive := slices.ContainsFunc(args[1:], func(arg string) bool {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		return strings.HasPrefix(arg, "-") && name == "interactive" && value != "false"
+	})
+	if !interactive {
+		return args
+	}
+	return append([]string{args[0], "show"}, args[1:]...)
+}
+
+// optionalFlagDefaults holds the built-in value used for flags given without one,
+// the defaults file and environment can change it (see flagDefaults.optional)
+var optionalFlagDefaults = map[string]string{
+	"inodes": fmt.Sprintf("%g", defaultInodeThreshold),
+	"errors": "1",
+}
+
+// expandOptionalFlags turns a bare --inodes or --errors into --flag=<default>. The
+// cli package has no optional flag values, so a bare flag would consume the file argument.
+func expandOptionalFlags(args []string, defaults *flagDefaults) []string {
+	expanded := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(expanded, args[i:]...)
+		}
+		if value, ok := defaults.optional(strings.TrimLeft(arg, "-")); ok && strings.HasPrefix(arg, "-") {
+			arg = "--" + strings.TrimLeft(arg, "-") + "=" + value
+		}
+		expanded = append(expanded, arg)
+	}
+	return expanded
+}
+
+// reorderShowArgs moves flags in front of the file argument of plain "mdb show".
+// The cli package only does this for commands without subcommands, so without
+// it "mdb show prod.json --pager" would treat --pager as a second file.
+func reorderShowArgs(app *cli.App, args []string) []string {
+	if len(args) < 3 || args[1] != "show" {
+		return args
+	}
+	show := app.Command("show")
+	if show == nil || args[2] == "help" || args[2] == "h" {
+		return args
+	}
+	for _, sub := range show.Subcommands {
+		if sub.HasName(args[2]) {
+			return args
+		}
+	}
+
+	// Flags that take a value consume the following argument
+	valueFlags := make(map[string]bool)
+	for _, f := range show.Flags {
+		if _, isBool := f.(cli.BoolFlag); isBool {
+			continue
+		}
+		for _, name := range strings.Split(f.GetName(), ",") {
+			valueFlags[strings.TrimSpace(name)] = true
+		}
+	}
+
+	var flagArgs, regularArgs []string
+	rest := args[2:]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		switch {
+		case arg == "--":
+			regularArgs = append(regularArgs, rest[i:]...)
+			i = len(rest)
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			flagArgs = append(flagArgs, arg)
+			name := strings.TrimLeft(arg, "-")
+			if !strings.Contains(name, "=") && valueFlags[name] && i+1 < len(rest) {
+				i++
+				flagArgs = append(flagArgs, rest[i])
+			}
+		default:
+			regularArgs = append(regularArgs, arg)
+		}
+	}
+
+	reordered := append([]string{}, args[:2]...)
+	reordered = append(reordered, flagArgs...)
+	return append(reordered, regularArgs...)
+}
+
+// newApp builds the command-line application
+func newApp() *cli.App {
+	// --version/-v are only recognized before the command, print them like "mdb version"
+	cli.VersionPrinter = func(ctx *cli.Context) {
+		cmdVersion(ctx)
+	}
+
+	app := cli.NewApp()
+	app.Name = "mdb"
+	app.Usage = "MinIO Debug - analyze MinIO diagnostic JSON files"
+	app.Version = Version
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "validate",
+			Usage: "Check a diagnostic file for truncation, wrong format and missing fields",
+		},
+	}
+	app.Action = cmdRoot
+	app.Commands = []cli.Command{
 		{
 			Name:   "version",
 			Usage:  "Show version information",
@@ -247,6 +1974,11 @@ func main() {
 					Usage:  "Generate zsh completion script",
 					Action: cmdCompletionZsh,
 				},
+				{
+					Name:   "fish",
+					Usage:  "Generate fish completion script",
+					Action: cmdCompletionFish,
+				},
 			},
 		},
 		{
@@ -266,6 +1998,12 @@ func main() {
 					UsageText: "mdb config info",
 					Action:    cmdConfigInfo,
 				},
+				{
+					Name:      "show",
+					Usage:     "Show the flag defaults in effect and where each is set",
+					UsageText: "mdb config show",
+					Action:    cmdConfigShow,
+				},
 				{
 					Name:      "list",
 					Usage:     "List all available configurations",
@@ -289,102 +2027,136 @@ func main() {
 		{
 			Name:            "show",
 			Usage:           "Show cluster information",
-			UsageText:       "mdb show [command]",
+			UsageText:       "mdb show [command] [file.json] [flags]",
 			Action:          cmdShow,
 			Subcommands: []cli.Command{
 				{
-					Name:   "summary",
-					Usage:  "Show summary only",
-					Action: cmdShowSummary,
+					Name:      "summary",
+					Usage:     "Show summary only",
+					UsageText: "mdb show summary [file.json] [flags]",
+					Action:    cmdShowSummary,
+					Flags:     summaryFlags,
 				},
 				{
-					Name:   "sets",
-					Usage:  "Show erasure sets only",
-					Action: cmdShowSets,
-					Flags: []cli.Flag{
-						cli.BoolFlag{
-							Name:  "scanning",
-							Usage: "Show only scanning disks",
-						},
-						cli.BoolFlag{
-							Name:  "failed",
-							Usage: "Show only failed/faulty disks (not 'ok' state)",
-						},
-						cli.StringFlag{
-							Name:  "low-space",
-							Usage: "Filter by free space percentage",
-						},
-						cli.StringFlag{
-							Name:  "min-bad-disks",
-							Usage: "Filter by minimum bad disks",
-						},
-						cli.BoolFlag{
-							Name:  "pager",
-							Usage: "Enable pagination (pauses output after each screen, press space to continue)",
-						},
-						cli.StringFlag{
-							Name:  "trim-domain",
-							Usage: "Trim domain suffix from endpoint names for cleaner display (e.g., '.example.com')",
-						},
-					},
+					Name:      "sets",
+					Usage:     "Show erasure sets only",
+					UsageText: "mdb show sets [file.json] [flags]",
+					Action:    cmdShowSets,
+					Flags:     setsFlags,
 				},
 				{
-					Name:   "disks",
-					Usage:  "Show disks only",
-					Action: cmdShowDisks,
-					Flags: []cli.Flag{
-						cli.BoolFlag{
-							Name:  "scanning",
-							Usage: "Show only scanning disks",
-						},
-						cli.BoolFlag{
-							Name:  "failed",
-							Usage: "Show only failed/faulty disks (not 'ok' state)",
-						},
-						cli.StringFlag{
-							Name:  "low-space",
-							Usage: "Filter by free space percentage",
-						},
-						cli.BoolFlag{
-							Name:  "pager",
-							Usage: "Enable pagination (pauses output after each screen, press space to continue)",
-						},
-						cli.StringFlag{
-							Name:  "trim-domain",
-							Usage: "Trim domain suffix from endpoint names for cleaner display (e.g., '.example.com')",
-						},
-					},
+					Name:      "disks",
+					Usage:     "Show disks only",
+					UsageText: "mdb show disks [file.json] [flags]",
+					Action:    cmdShowDisks,
+					Flags:     disksFlags,
 				},
 				{
-					Name:   "servers",
-					Usage:  "Show servers only",
-					Action: cmdShowServers,
-					Flags: []cli.Flag{
-						cli.BoolFlag{
-							Name:  "failed",
-							Usage: "Show only offline servers",
-						},
-						cli.BoolFlag{
-							Name:  "pager",
-							Usage: "Enable pagination (pauses output after each screen, press space to continue)",
-						},
-						cli.StringFlag{
-							Name:  "trim-domain",
-							Usage: "Trim domain suffix from endpoint names for cleaner display (e.g., '.example.com')",
-						},
-					},
+					Name:      "servers",
+					Usage:     "Show servers only",
+					UsageText: "mdb show servers [file.json] [flags]",
+					Action:    cmdShowServers,
+					Flags:     serversFlags,
 				},
 			},
-			Flags: []cli.Flag{
+			Flags: slices.Concat([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "busy-servers",
+					Usage: "Show only servers with healing/scanning drives, sorted by healing drive count",
+				},
 				cli.BoolFlag{
-					Name:  "pager",
-					Usage: "Enable pagination (pauses output after each screen, press space to continue)",
+					Name:  "server-summary",
+					Usage: "Show per-server drive health summary table",
+				},
+				cli.BoolFlag{
+					Name:  "server-capacity",
+					Usage: "Show only the raw, used and free space per server with a totals row",
 				},
 				cli.StringFlag{
-					Name:  "trim-domain",
-					Usage: "Trim domain suffix from endpoint names for cleaner display (e.g., '.example.com')",
+					Name:  "sort-by",
+					Usage: "Sort the --server-capacity table by: server, drives, raw, used, used-pct, free",
 				},
-			},
+				cli.StringFlag{
+					Name:  "sets-sort",
+					Usage: "Sort the erasure sets table by: risk (bad drives, then scanning drives, then the least free space first)",
+				},
+				cli.BoolFlag{
+					Name:  "sets-only",
+					Usage: "Show nothing but the erasure sets table, the sets at most risk first",
+				},
+				cli.BoolFlag{
+					Name:  "services",
+					Usage: "Show only the KMS, LDAP, logger and notification target status",
+				},
+				cli.BoolFlag{
+					Name:  "ilm",
+					Usage: "Show only which servers have ILM expiry in progress",
+				},
+				cli.BoolFlag{
+					Name:  "buckets",
+					Usage: "Show only the buckets sorted by size, if the snapshot has per-bucket usage",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Show only the offline servers with their pools, drives and erasure sets",
+				},
+				cli.StringFlag{
+					Name:  "replication",
+					Usage: "Show the peer sites from a file of 'mc admin replicate status --json'",
+				},
+				cli.BoolFlag{
+					Name:  "set-metrics",
+					Usage: "Add a table of drive metrics summed per erasure set",
+				},
+				cli.BoolFlag{
+					Name:  "wide",
+					Usage: "List every server in the Servers column of the erasure sets table",
+				},
+				barsFlag,
+			}, analysisFlags, setTreeFlags, drivePathFlags, showFlags),
+		},
+		{
+			Name:      "summary",
+			Usage:     "Show the cluster summary",
+			UsageText: "mdb summary [file.json] [flags]",
+			Action:    cmdShowSummary,
+			Flags:     summaryFlags,
+		},
+		{
+			Name:      "drives",
+			Aliases:   []string{"disks"},
+			Usage:     "Show the drives",
+			UsageText: "mdb drives [file.json] [flags]",
+			Action:    cmdShowDisks,
+			Flags:     disksFlags,
+		},
+		{
+			Name:      "servers",
+			Usage:     "Show the servers",
+			UsageText: "mdb servers [file.json] [flags]",
+			Action:    cmdShowServers,
+			Flags:     serversFlags,
+		},
+		{
+			Name:      "sets",
+			Usage:     "Show the erasure sets",
+			UsageText: "mdb sets [file.json] [flags]",
+			Action:    cmdShowSets,
+			Flags:     setsFlags,
+		},
+		{
+			Name:      "failed",
+			Usage:     "Show the erasure sets with failed drives",
+			UsageText: "mdb failed [file.json] [flags]",
+			Action:    cmdFailed,
+			Flags:     failedFlags,
+		},
+		{
+			Name:      "report",
+			Usage:     "Write the summary, text report, failed drives, low space sets and drives to a directory",
+			UsageText: "mdb report [file.json] --out DIR [flags]",
+			Action:    cmdReport,
+			Flags:     reportFlags,
 		},
 	}
 	app.CustomAppHelpTemplate = `NAME:
@@ -392,6 +2164,7 @@ func main() {
 
 USAGE:
   {{.Name}} [command]
+  {{.Name}} --validate [file.json]
 
 COMMANDS:
   {{range .Commands}}{{.Name}}{{with .ShortName}}, {{.ShortName}}{{end}}{{ "\t" }}{{.Usage}}
@@ -413,44 +2186,56 @@ EXAMPLES:
   5. Show summary, servers, and erasure sets:
      {{.Prompt}} {{.Name}} show
 
-  3. Show only summary:
-     {{.Prompt}} {{.Name}} show summary
+  6. Show only summary:
+     {{.Prompt}} {{.Name}} summary prod.json
 
-  4. Show only servers:
-     {{.Prompt}} {{.Name}} show servers
+  7. Show only offline servers:
+     {{.Prompt}} {{.Name}} servers prod.json --failed
 
-  5. Show only offline servers:
-     {{.Prompt}} {{.Name}} show servers --failed
+  8. Show erasure sets with at least 2 failed disks:
+     {{.Prompt}} {{.Name}} failed prod.json --min-bad-disks 2
 
-  6. Show erasure sets with failed disks:
-     {{.Prompt}} {{.Name}} show sets --failed
+  9. Show drives with low free space, with pagination:
+     {{.Prompt}} {{.Name}} drives prod.json --low-space 10 --pager
 
-  7. Show erasure sets with minimum bad disks:
-     {{.Prompt}} {{.Name}} show sets --failed --min-bad-disks 2
+  10. Check a file received from a customer:
+     {{.Prompt}} {{.Name}} --validate file.json
 
-  8. Show disks with pagination:
-     {{.Prompt}} {{.Name}} show disks --pager
+  11. Write an incident report directory:
+     {{.Prompt}} {{.Name}} report prod.json --out report/
 
+"{{.Name}} show summary|sets|disks|servers" work as before.
 Use "{{.Name}} [command] --help" for more information about a command.
 `
 
-	// Handle --version flag
-	for _, arg := range os.Args[1:] {
-		if arg == "--version" || arg == "-v" {
-			cmdVersion(nil)
-			return
-		}
-	}
+	return app
+}
 
-	// Handle __complete for dynamic completion
-	if len(os.Args) > 1 && os.Args[1] == "__complete" {
-		handleCompletion(os.Args[2:])
-		return
+// cmdRoot handles "mdb" without a command: "mdb --validate [file.json]" or help
+func cmdRoot(ctx *cli.Context) error {
+	if !ctx.Bool("validate") {
+		if ctx.Args().Present() {
+			return cli.ShowCommandHelp(ctx, ctx.Args().First())
+		}
+		return cli.ShowAppHelp(ctx)
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		console.Fatalln(err)
+	var filename string
+	switch ctx.NArg() {
+	case 0:
+		configsData, err := loadConfigsData()
+		if err != nil {
+			return fmt.Errorf("failed to load configs data: %v", err)
+		}
+		if filename, err = currentConfigFile(configsData); err != nil {
+			return err
+		}
+	case 1:
+		filename = ctx.Args().First()
+	default:
+		return fmt.Errorf("usage: mdb --validate [file.json]")
 	}
+	return validateFile(os.Stdout, filename, stdoutIsTerminal())
 }
 
 // cmdVersion handles "mdb version"
@@ -478,6 +2263,12 @@ func cmdCompletionZsh(ctx *cli.Context) error {
 	return nil
 }
 
+// cmdCompletionFish handles "mdb completion fish"
+func cmdCompletionFish(ctx *cli.Context) error {
+	fmt.Print(generateFishCompletion())
+	return nil
+}
+
 // handleCompletion handles "__complete" command for dynamic completion
 func handleCompletion(args []string) {
 	if len(args) == 0 {
@@ -583,6 +2374,53 @@ func cmdConfigInfo(ctx *cli.Context) error {
 	return nil
 }
 
+// cmdConfigShow handles "mdb config show"
+func cmdConfigShow(ctx *cli.Context) error {
+	resolved, ok := ctx.App.Metadata[flagDefaultsKey].(*flagDefaults)
+	if !ok {
+		resolved = resolveFlagDefaults(nil)
+	}
+	return printFlagDefaults(os.Stdout, resolved)
+}
+
+// printFlagDefaults prints the default of every defaultable flag and its
+// source: an MDB_* variable, the defaults file or built-in
+func printFlagDefaults(w io.Writer, resolved *flagDefaults) error {
+	path, err := defaultsFile()
+	if err != nil {
+		return err
+	}
+	if resolved.err != nil {
+		return resolved.err
+	}
+	defaults := resolved.values
+	for _, warning := range resolved.warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	status := ""
+	if _, err := os.Stat(path); err != nil {
+		status = " (not found)"
+	}
+	fmt.Fprintf(w, "Defaults file: %s%s\n", path, status)
+	fmt.Fprintf(w, "Command-line flags override these defaults, --no-config ignores the file and environment.\n\n")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  Flag\tValue\tSource\n")
+	for _, name := range defaultableFlags {
+		def, ok := defaults[name]
+		if !ok {
+			def = flagDefault{Value: builtinFlagDefault(name), Source: "built-in"}
+		}
+		value := def.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "  --%s\t%s\t%s\n", name, value, def.Source)
+	}
+	return tw.Flush()
+}
+
 // cmdConfigList handles "mdb config list"
 func cmdConfigList(ctx *cli.Context) error {
 	configs, currentName, err := listConfigs()
@@ -695,1586 +2533,10107 @@ func cmdConfigRemove(ctx *cli.Context) error {
 	return nil
 }
 
+// displayReport renders the report for the show commands, replaced in tests
+var displayReport = processAndDisplay
+
 // processAndDisplay processes the JSON data and displays it according to config
 func processAndDisplay(config *Config) error {
-	infoStruct, err := loadJSON(config.JSONFile)
-	if err != nil {
-		return fmt.Errorf("failed to load JSON file '%s': %v", config.JSONFile, err)
+	// Page text reports automatically on a terminal unless the report goes to a file
+	autoPager := config.Format == formatText && !config.Quiet && !config.PagerMode && !config.NoPager && config.OutputPath == "" && stdoutIsTerminal()
+	pager := NewPager((config.PagerMode || autoPager) && !config.NoPager)
+	pager.auto = autoPager
+	pager.noMouse = config.NoMouse
+	pager.stripColor = config.ColorMode == colorNever
+
+	// Structured formats ask before flooding the terminal
+	if config.Format != formatText && !config.AssumeYes {
+		pager.guard = newTerminalGuard()
+	}
+
+	// The output file is only created once there is a report to write, so a
+	// snapshot that fails to load leaves an existing file alone
+	setOutput := func() error {
+		if config.OutputPath == "" {
+			return nil
+		}
+		return pager.SetOutput(config.OutputPath, config.ColorMode == colorAlways)
 	}
 
-	servers := infoStruct.Info.Servers
-	pools := extractPoolsFromServers(servers)
-	parityDisks := int(infoStruct.Info.Backend.StandardSCParity)
-	if parityDisks == 0 {
-		parityDisks = 2 // Default to EC-2
+	if len(config.JSONFiles) > 1 {
+		// Files that fail to load are reported in the output
+		if err := setOutput(); err != nil {
+			return err
+		}
+		return displayFiles(pager, config)
 	}
 
-	pager := NewPager(config.PagerMode)
-	
-	pager.Printf("%sDetected Erasure Coding Configuration: EC:%d%s\n", Bold, parityDisks, Reset)
-	pager.Printf("\n")
-
-	poolSetDrives := make(map[string][]DiskInfo)
-	allPoolSetDrives := make(map[string][]DiskInfo) // For capacity calculations (all drives)
-	stats := ClusterStats{ParityDisks: parityDisks}
-
-	// Process all drives
-	for _, server := range servers {
-		drives := getDrives(server, config.TrimDomain)
-		for _, drive := range drives {
-			stats.TotalDisks++
-			if drive.Scanning {
-				stats.ScanningDisks++
-			}
-			if drive.State == "ok" {
-				stats.OkDisks++
-			} else {
-				stats.BadDisks++
-			}
-			stats.TotalSpace += drive.TotalSpace
-			stats.UsedSpace += drive.UsedSpace
-
-			// Store all drives for capacity calculations
-			key := fmt.Sprintf("%d:%d", drive.PoolIndex, drive.SetIndex)
-			allPoolSetDrives[key] = append(allPoolSetDrives[key], drive)
+	defer removeLocalInput(config)
+	infoStruct, err := prepareInput(config)
+	if err != nil {
+		pager.Close()
+		return err
+	}
+	applyBaseline(infoStruct, config)
 
-			// Apply filters for display (only for disks/sets views)
-			if config.ShowDisks || config.ShowSets {
-				if config.ScanningMode && !drive.Scanning {
-					continue
-				}
-				if config.FailedMode && drive.State == "ok" {
-					continue
-				}
-			}
+	if config.Interactive {
+		pager.Close()
+		return runInteractive(infoStruct, config)
+	}
 
-			poolSetDrives[key] = append(poolSetDrives[key], drive)
+	if config.BundlePath != "" {
+		members, err := writeBundle(config.BundlePath, infoStruct, config)
+		if err != nil {
+			pager.Close()
+			return err
 		}
+		fmt.Fprintf(os.Stderr, "Wrote support bundle %s (%d files)\n", config.BundlePath, members)
 	}
 
-	stats.DeploymentID = infoStruct.Info.DeploymentID
-
-	// Print summary if requested
-	if config.ShowSummary {
-		printClusterSummary(pager, stats, pools, allPoolSetDrives, servers, infoStruct, config)
+	if err := setOutput(); err != nil {
+		return err
+	}
+	findings, err := renderReportFindings(pager, infoStruct, config)
+	if err != nil {
+		pager.Close()
+		return err
 	}
 
-	// Print servers if requested
-	if config.ShowServers {
-		// Filter servers based on --failed flag
-		filteredServers := servers
-		if config.FailedMode {
-			// With --failed: show only offline servers
-			filteredServers = make([]madmin.ServerProperties, 0)
-			for _, server := range servers {
-				if server.State != "online" {
-					filteredServers = append(filteredServers, server)
-				}
-			}
+	// Show the pager if enabled
+	pager.Show()
+
+	if err := pager.Close(); err != nil {
+		return err
+	}
+	if config.LogLine {
+		printLogLine(recordStats(infoStruct))
+	}
+	if age := snapshotAge(infoStruct.Timestamp, timeNow()); config.MaxAge > 0 && age > config.MaxAge {
+		return fmt.Errorf("snapshot '%s' is %s old, older than --max-age %s", inputName(config), humanizeDuration(age.Round(time.Minute)), config.MaxAge)
+	}
+	if config.Decommission != nil {
+		plan, err := planDecommission(infoStruct.DrivesBySet(mdbcore.DriveFilter{}), *config.Decommission, infoStruct.ParityDisks(), config)
+		if err != nil {
+			return err
+		}
+		if problems := plan.problems(config); len(problems) > 0 {
+			return fmt.Errorf("pool %d cannot be decommissioned: %s", plan.Pool.Pool, strings.Join(problems, "; "))
 		}
-		// Without --failed: show all servers (offline will overwrite online during merge)
-		printServerInfo(pager, filteredServers, pools, config.TrimDomain)
 	}
-
-	// Handle special modes for sets/disks
-	if config.ShowDisks && config.FailedMode && !config.ShowSets {
-		printFailedDisksTable(pager, poolSetDrives, config)
-		pager.Show()
-		return nil
+	if config.FailOn != "" {
+		return failOnFindings(findings, config.FailOn)
 	}
+	return nil
+}
 
-	if config.ShowSets && config.LowSpaceThreshold != nil {
-		printLowSpaceErasureSets(pager, pools, poolSetDrives, *config.LowSpaceThreshold, config)
-		pager.Show()
-		return nil
+// failOnFindings returns an error if any finding is at least as severe as failOn
+func failOnFindings(findings []finding, failOn string) error {
+	failing := 0
+	for _, f := range findings {
+		if severityRank(f.Severity) <= severityRank(failOn) {
+			failing++
+		}
 	}
-
-	// Print sets/disks if requested
-	if config.ShowSets || config.ShowDisks {
-		printPoolsAndSets(pager, pools, poolSetDrives, allPoolSetDrives, config, servers)
+	if failing > 0 {
+		return fmt.Errorf("%s of severity %s or worse (--fail-on %s)", countNoun(failing, "finding"), failOn, failOn)
 	}
-	
-	// Show the pager if enabled
-	pager.Show()
-	
 	return nil
 }
 
-// cmdShow handles "mdb show" (default - shows summary, servers, and erasure sets)
-func cmdShow(ctx *cli.Context) error {
-	config, err := parseShowFlags(ctx, true, true, true, false)
-	if err != nil {
-		return err
-	}
-	return processAndDisplay(config)
+// isURL reports whether a file argument is an http:// or https:// URL
+func isURL(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
 }
 
-// cmdShowSummary handles "mdb show summary"
-func cmdShowSummary(ctx *cli.Context) error {
-	config, err := parseShowFlags(ctx, true, false, false, false)
-	if err != nil {
-		return err
-	}
-	return processAndDisplay(config)
+// fetchOptions are how URL inputs are fetched, from --header, --insecure and --timeout
+type fetchOptions struct {
+	Headers  http.Header
+	Insecure bool
+	Timeout  time.Duration
 }
 
-// cmdShowSets handles "mdb show sets"
-func cmdShowSets(ctx *cli.Context) error {
-	config, err := parseShowFlags(ctx, false, false, true, false)
-	if err != nil {
-		return err
+// inputName returns the name config.JSONFile is shown with, its URL if it was
+// downloaded or its archive if it was extracted
+func inputName(config *Config) string {
+	switch {
+	case config.URL != "":
+		return config.URL
+	case config.Archive != "":
+		return config.Archive
 	}
-	return processAndDisplay(config)
+	return config.JSONFile
 }
 
-// cmdShowDisks handles "mdb show disks"
-func cmdShowDisks(ctx *cli.Context) error {
-	config, err := parseShowFlags(ctx, false, false, false, true)
+// downloadInput fetches config.JSONFile to a temporary file if it is a URL,
+// keeping the URL in config.URL. removeLocalInput removes the file.
+func downloadInput(config *Config) error {
+	if !isURL(config.JSONFile) {
+		return nil
+	}
+	path, err := fetchInput(config.JSONFile, config.Fetch)
 	if err != nil {
 		return err
 	}
-	return processAndDisplay(config)
+	config.URL, config.JSONFile = config.JSONFile, path
+	return nil
 }
 
-// cmdShowServers handles "mdb show servers"
-func cmdShowServers(ctx *cli.Context) error {
-	config, err := parseShowFlags(ctx, false, true, false, false)
-	if err != nil {
-		return err
+// removeLocalInput removes the temporary files downloadInput and
+// extractArchive made of the input
+func removeLocalInput(config *Config) {
+	downloaded := config.JSONFile
+	if config.Archive != "" {
+		os.RemoveAll(filepath.Dir(config.JSONFile))
+		downloaded = config.Archive
+	}
+	if config.URL != "" {
+		os.Remove(downloaded)
 	}
-	return processAndDisplay(config)
 }
 
+// Kinds of diagnostics archives read by extractArchive
+const (
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+	archiveGzip  = "gz" // a gzip-compressed snapshot, not a tar archive
+)
 
-// parseShowFlags parses flags for show commands
-func parseShowFlags(ctx *cli.Context, showSummary, showServers, showSets, showDisks bool) (*Config, error) {
-	config := &Config{}
-	
-	// Load JSON file from current config - reload configsData fresh each time
-	currentName, err := getCurrentConfig()
-	if err != nil {
-		return nil, err
-	}
-	
-	// Double-check that we have the right current config by reloading
-	configsData, err := loadConfigsData()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load configs data: %v", err)
-	}
-	
-	if configsData.CurrentConfig != currentName {
-		// Current config changed, use the one from configsData
-		currentName = configsData.CurrentConfig
-	}
-	
-	jsonFile, err := loadConfig(currentName)
+// detectArchive returns the kind of archive file is, or "" if it is none
+func detectArchive(file string) string {
+	f, err := os.Open(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config '%s': %v", currentName, err)
+		return ""
 	}
-	config.JSONFile = jsonFile
-	
-	config.ShowSummary = showSummary
-	config.ShowServers = showServers
-	config.ShowSets = showSets
-	config.ShowDisks = showDisks
-	config.ScanningMode = ctx.Bool("scanning")
-	config.PagerMode = ctx.Bool("pager")
-	config.FailedMode = ctx.Bool("failed")
-	config.TrimDomain = ctx.String("trim-domain")
-	
-	// Parse string flags that need conversion
-	if ctx.String("low-space") != "" {
-		if val, err := strconv.ParseFloat(ctx.String("low-space"), 64); err == nil {
-			config.LowSpaceThreshold = &val
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	magic, _ := reader.Peek(512)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return archiveZip
+	case isTarHeader(magic):
+		return archiveTar
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return ""
 		}
-	}
-	if ctx.String("min-bad-disks") != "" {
-		if val, err := strconv.Atoi(ctx.String("min-bad-disks")); err == nil && val >= 0 {
-			config.MinBadDisks = &val
+		header := make([]byte, 512)
+		if _, err := io.ReadFull(gz, header); err == nil && isTarHeader(header) {
+			return archiveTarGz
 		}
+		return archiveGzip
 	}
-	
-	// Validate mutually exclusive flags
-	if config.ScanningMode && config.FailedMode {
-		return nil, fmt.Errorf("--failed and --scanning cannot be used together")
-	}
-	
-	// Validate flag usage
-	if config.LowSpaceThreshold != nil && !showSets && !showDisks {
-		return nil, fmt.Errorf("--low-space can only be used with 'show sets' or 'show disks'")
-	}
-	if config.MinBadDisks != nil && !showSets {
-		return nil, fmt.Errorf("--min-bad-disks can only be used with 'show sets'")
-	}
-	if config.ScanningMode && !showSets && !showDisks {
-		return nil, fmt.Errorf("--scanning can only be used with 'show sets' or 'show disks'")
-	}
-	
-	return config, nil
+	return ""
 }
 
-// ConfigInfo represents a stored configuration
-type ConfigInfo struct {
-	Name      string    `json:"name"`
-	FilePath  string    `json:"filePath"`
-	CreatedAt time.Time `json:"createdAt"`
+// isTarHeader reports whether block starts with a POSIX tar header
+func isTarHeader(block []byte) bool {
+	return len(block) >= 262 && string(block[257:262]) == "ustar"
 }
 
-// ConfigsData holds all configurations and current active config
-type ConfigsData struct {
-	Configs      []ConfigInfo `json:"configs"`
-	CurrentConfig string      `json:"currentConfig"`
+// isMetricsMember reports whether an archive member named name is a
+// Prometheus metrics dump
+func isMetricsMember(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".prom") || (strings.Contains(name, "metrics") && !strings.HasSuffix(name, ".json"))
 }
 
-// Config file management functions
-func getConfigDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %v", err)
-	}
-	configDir := filepath.Join(homeDir, ".mdb", "configs")
-	// Create configs directory if it doesn't exist
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create config directory: %v", err)
+// extractArchive replaces config.JSONFile by the snapshot of a diagnostics
+// archive (.tar, .tar.gz or .zip), its first .json member, keeping the archive
+// in config.Archive. Unless --metrics-file is given, a Prometheus dump in the
+// archive (a .prom member or one named metrics) becomes the metrics file. The
+// members are extracted to a temporary directory removed by removeLocalInput.
+func extractArchive(config *Config) error {
+	kind := detectArchive(config.JSONFile)
+	switch kind {
+	case "":
+		return nil
+	case archiveGzip:
+		return decompressInput(config)
 	}
-	return configDir, nil
-}
-
-func getConfigsFile() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	dir, err := os.MkdirTemp("", "mdb-archive-*")
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %v", err)
+		return err
 	}
-	configDir := filepath.Join(homeDir, ".mdb")
-	// Create .mdb directory if it doesn't exist
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create config directory: %v", err)
+	snapshot, metrics := "", ""
+	extract := func(name string, modified time.Time, r io.Reader) error {
+		base := path.Base(name)
+		var target *string
+		switch {
+		case base == "." || base == ".." || base == "/":
+			return nil
+		case snapshot == "" && strings.HasSuffix(strings.ToLower(base), ".json"):
+			target = &snapshot
+		case metrics == "" && isMetricsMember(base):
+			target = &metrics
+		default:
+			return nil
+		}
+		*target = filepath.Join(dir, base)
+		file, err := os.Create(*target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, r); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to extract '%s': %v", name, err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		// The member's time stands in for the snapshot time like a file's
+		return os.Chtimes(*target, modified, modified)
 	}
-	return filepath.Join(configDir, "configs.json"), nil
-}
 
-func loadConfigsData() (*ConfigsData, error) {
-	configsPath, err := getConfigsFile()
-	if err != nil {
-		return nil, err
+	if kind == archiveZip {
+		err = extractZip(config.JSONFile, extract)
+	} else {
+		err = extractTar(config.JSONFile, kind == archiveTarGz, extract)
 	}
-	
-	data := &ConfigsData{
-		Configs:      []ConfigInfo{},
-		CurrentConfig: "",
+	if err == nil && snapshot == "" {
+		err = fmt.Errorf("archive has no .json snapshot")
 	}
-	
-	fileData, err := os.ReadFile(configsPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Return empty configs data if file doesn't exist
-			return data, nil
-		}
-		return nil, fmt.Errorf("failed to read configs file: %v", err)
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to read archive '%s': %v", inputName(config), err)
 	}
-	
-	if err := json.Unmarshal(fileData, data); err != nil {
-		return nil, fmt.Errorf("failed to parse configs file: %v", err)
+	config.Archive, config.JSONFile = config.JSONFile, snapshot
+	if metrics != "" && config.MetricsFile == "" && !config.Anonymize {
+		config.MetricsFile = metrics
 	}
-	
-	return data, nil
+	return nil
 }
 
-func saveConfigsData(data *ConfigsData) error {
-	configsPath, err := getConfigsFile()
+// decompressInput decompresses the gzip-compressed snapshot config.JSONFile to
+// a temporary file, keeping the compressed file in config.Archive. The
+// temporary file keeps the modification time of the compressed one, which
+// stands in for the snapshot time.
+func decompressInput(config *Config) error {
+	dir, err := os.MkdirTemp("", "mdb-archive-*")
 	if err != nil {
 		return err
 	}
-	
-	fileData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal configs data: %v", err)
-	}
-	
-	if err := os.WriteFile(configsPath, fileData, 0644); err != nil {
-		return fmt.Errorf("failed to write configs file: %v", err)
+	snapshot := filepath.Join(dir, strings.TrimSuffix(filepath.Base(config.JSONFile), ".gz"))
+	if err := gunzipFile(config.JSONFile, snapshot); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to decompress '%s': %v", config.JSONFile, err)
 	}
-	
+	config.Archive, config.JSONFile = config.JSONFile, snapshot
 	return nil
 }
 
-func saveConfig(name, filePath string) error {
-	// Validate that the file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("file '%s' does not exist", filePath)
-	}
-	
-	// Resolve absolute path
-	absPath, err := filepath.Abs(filePath)
+// gunzipFile writes the decompressed content of the gzip file src to dst,
+// dated like src
+func gunzipFile(src, dst string) error {
+	f, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("failed to resolve absolute path: %v", err)
+		return err
 	}
-	
-	// Load existing configs
-	configsData, err := loadConfigsData()
+	defer f.Close()
+	fi, err := f.Stat()
 	if err != nil {
 		return err
 	}
-	
-	// Check if config with this name already exists
-	for i, cfg := range configsData.Configs {
-		if cfg.Name == name {
-			// Update existing config
-			configsData.Configs[i].FilePath = absPath
-			configsData.Configs[i].CreatedAt = time.Now()
-			if err := saveConfigsData(configsData); err != nil {
-				return err
-			}
-			return nil
-		}
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return err
 	}
-	
-	// Add new config
-	newConfig := ConfigInfo{
-		Name:      name,
-		FilePath:  absPath,
-		CreatedAt: time.Now(),
+	defer gz.Close()
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return err
 	}
-	configsData.Configs = append(configsData.Configs, newConfig)
-	
-	// If this is the first config, set it as current
-	if configsData.CurrentConfig == "" {
-		configsData.CurrentConfig = name
+	if _, err := io.Copy(file, gz); err != nil {
+		file.Close()
+		return err
 	}
-	
-	if err := saveConfigsData(configsData); err != nil {
+	if err := file.Close(); err != nil {
 		return err
 	}
-	
-	return nil
+	return os.Chtimes(dst, fi.ModTime(), fi.ModTime())
 }
 
-func loadConfig(name string) (string, error) {
-	configsData, err := loadConfigsData()
+// extractZip calls extract with every file of the zip archive file
+func extractZip(file string, extract func(string, time.Time, io.Reader) error) error {
+	archive, err := zip.OpenReader(file)
 	if err != nil {
-		return "", err
+		return err
 	}
-	
-	for _, cfg := range configsData.Configs {
-		if cfg.Name == name {
-			// Validate that the file still exists
-			if _, err := os.Stat(cfg.FilePath); os.IsNotExist(err) {
-				return "", fmt.Errorf("config '%s' file '%s' does not exist", name, cfg.FilePath)
-			}
-			return cfg.FilePath, nil
+	defer archive.Close()
+	for _, member := range archive.File {
+		if member.FileInfo().IsDir() {
+			continue
+		}
+		r, err := member.Open()
+		if err != nil {
+			return err
+		}
+		err = extract(member.Name, member.Modified, r)
+		r.Close()
+		if err != nil {
+			return err
 		}
 	}
-	
-	return "", fmt.Errorf("config '%s' not found", name)
+	return nil
 }
 
-func getCurrentConfig() (string, error) {
-	configsData, err := loadConfigsData()
+// extractTar calls extract with every regular file of the tar archive file,
+// gzip-compressed if compressed is set
+func extractTar(file string, compressed bool, extract func(string, time.Time, io.Reader) error) error {
+	f, err := os.Open(file)
 	if err != nil {
-		return "", err
+		return err
 	}
-	
-	if configsData.CurrentConfig == "" {
-		return "", fmt.Errorf("no current config set. Use 'mdb config add <name> <file.json>' to add a config")
+	defer f.Close()
+	var reader io.Reader = bufio.NewReader(f)
+	if compressed {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extract(header.Name, header.ModTime, tr); err != nil {
+			return err
+		}
 	}
-	
-	return configsData.CurrentConfig, nil
 }
 
-func setCurrentConfig(name string) error {
-	configsData, err := loadConfigsData()
+// fetchInput downloads url to a temporary file and returns its path. Gzip
+// bodies are decompressed, the other formats are read from the file like any
+// snapshot. The file is dated with the Last-Modified header, which stands in
+// for the snapshot time like the modification time of a local file.
+func fetchInput(url string, opts fetchOptions) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Transport: transport, Timeout: opts.Timeout}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to fetch '%s': %v", url, err)
 	}
-	
-	// Verify config exists
-	found := false
-	for _, cfg := range configsData.Configs {
-		if cfg.Name == name {
-			found = true
-			break
-		}
+	for name, values := range opts.Headers {
+		request.Header[name] = values
 	}
-	
-	if !found {
-		return fmt.Errorf("config '%s' not found", name)
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch '%s': %v", url, err)
 	}
-	
-	configsData.CurrentConfig = name
-	if err := saveConfigsData(configsData); err != nil {
-		return err
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch '%s': HTTP %s", url, response.Status)
 	}
-	
-	return nil
-}
 
-func listConfigs() ([]ConfigInfo, string, error) {
-	configsData, err := loadConfigsData()
-	if err != nil {
-		return nil, "", err
+	body := bufio.NewReader(response.Body)
+	var reader io.Reader = body
+	if magic, _ := body.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch '%s': %v", url, err)
+		}
+		defer gz.Close()
+		reader = gz
 	}
-	
-	return configsData.Configs, configsData.CurrentConfig, nil
-}
 
-func removeConfig(name string) error {
-	configsData, err := loadConfigsData()
+	file, err := os.CreateTemp("", "mdb-*.json")
 	if err != nil {
-		return err
+		return "", err
 	}
-	
-	// Find and remove config
-	newConfigs := []ConfigInfo{}
-	found := false
-	for _, cfg := range configsData.Configs {
-		if cfg.Name == name {
-			found = true
-			continue
-		}
-		newConfigs = append(newConfigs, cfg)
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to fetch '%s': %v", url, err)
 	}
-	
-	if !found {
-		return fmt.Errorf("config '%s' not found", name)
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
 	}
-	
-	// If removing current config, clear it
-	if configsData.CurrentConfig == name {
-		configsData.CurrentConfig = ""
-		if len(newConfigs) > 0 {
-			// Set first remaining config as current
-			configsData.CurrentConfig = newConfigs[0].Name
+	if modified, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+		if err := os.Chtimes(file.Name(), modified, modified); err != nil {
+			os.Remove(file.Name())
+			return "", err
 		}
 	}
-	
-	configsData.Configs = newConfigs
-	if err := saveConfigsData(configsData); err != nil {
-		return err
-	}
-	
-	return nil
+	return file.Name(), nil
 }
 
-func loadJSON(filename string) (*clusterStruct, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("file '%s' not found: %v", filename, err)
+// loadInput loads config.JSONFile, picking an NDJSON record if one was selected
+func loadInput(config *Config) (*clusterStruct, error) {
+	if config.Trend || config.Project || config.Latest || !config.At.IsZero() || config.Record > 0 {
+		return loadRecordRetry(config)
 	}
-	defer file.Close()
+	return loadJSONRetry(config.JSONFile, config.RetryOnChange)
+}
 
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file '%s': %v", filename, err)
-	}
+// fileResult is the outcome of loading one of several input files
+type fileResult struct {
+	File  string
+	Info  *clusterStruct
+	Stats ClusterStats
+	Err   error
+}
 
-	// Check for raw prefix and remove it (like stats does)
-	data = []byte(strings.Replace(string(data), `{"version":"3"}`, "", 1))
+// displayFiles shows the report of each of config.JSONFiles in turn, headed by
+// the file name and deployment ID, followed by a comparison of the files. With
+// --compare only a side-by-side comparison is shown. A file that fails to load
+// is reported and the others are still shown.
+func displayFiles(pager *Pager, config *Config) error {
+	config.Settings = newRenderSettings(config)
+	// One anonymizer for all files, so server names stay unique across clusters
+	anon := newAnonymizer()
+	results := make([]fileResult, 0, len(config.JSONFiles))
+	for _, file := range config.JSONFiles {
+		fileConfig := *config
+		fileConfig.JSONFile = file
+		fileConfig.JSONFiles = nil
+		// The projection is over the files, not the records of each
+		fileConfig.Project = false
+
+		result := fileResult{File: file}
+		if result.Err = downloadInput(&fileConfig); result.Err == nil {
+			result.Err = extractArchive(&fileConfig)
+		}
+		if result.Err == nil {
+			result.Info, result.Err = loadInput(&fileConfig)
+		}
+		if result.Err == nil && config.Anonymize {
+			anon.apply(file, result.Info)
+		}
+		if result.Err == nil && config.Compare {
+			result.Stats = recordStats(result.Info)
+		} else if result.Err == nil {
+			printSectionTitle(pager, config, fmt.Sprintf("%s (deployment %s)", file, deploymentName(result.Info)))
+			pager.Printf("\n")
+			result.Stats = recordStats(result.Info)
+			result.Err = renderReport(pager, result.Info, &fileConfig)
+		} else if !config.Compare {
+			printSectionTitle(pager, config, file)
+			pager.Printf("\n")
+		}
+		if result.Err != nil {
+			printProblem(pager, config, fmt.Sprintf("Failed to load '%s': %v", file, result.Err))
+		}
+		removeLocalInput(&fileConfig)
+		results = append(results, result)
+	}
 
-	infoStruct := clusterStruct{}
-	err = json.Unmarshal(data, &infoStruct)
-	if err != nil {
-		// Try with minio wrapper format
-		anotherFormat := struct {
-			InfoStruct clusterStruct `json:"minio"`
-		}{}
-		err = json.Unmarshal(data, &anotherFormat)
-		if err != nil {
-			// Try NDJSON format
-			return loadNDJSON(filename)
+	if config.Compare {
+		printClusterComparison(pager, results, config)
+	} else {
+		printFileComparison(pager, results, config)
+	}
+	var projectErr error
+	if config.Project {
+		if projectErr = printFileProjection(pager, results, config); projectErr != nil {
+			printProblem(pager, config, projectErr.Error())
+		}
+	}
+	pager.Show()
+	if err := pager.Close(); err != nil {
+		return err
+	}
+	if config.Anonymize {
+		if err := anon.writeMap(config.AnonymizeMap); err != nil {
+			return err
 		}
-		return &anotherFormat.InfoStruct, nil
 	}
 
-	// If there is no server found on the first try, trying with different format
-	// data could be from subnet diagnostics page
-	if len(infoStruct.Info.Servers) == 0 {
-		anotherFormat := struct {
-			InfoStruct clusterStruct `json:"minio"`
-		}{}
-		err = json.Unmarshal(data, &anotherFormat)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	var failed, stale []string
+	for _, result := range results {
+		if result.Err == nil && config.LogLine {
+			printLogLine(result.Stats)
 		}
-		return &anotherFormat.InfoStruct, nil
+		if result.Err != nil {
+			failed = append(failed, result.File)
+		} else if age := snapshotAge(result.Info.Timestamp, timeNow()); config.MaxAge > 0 && age > config.MaxAge {
+			stale = append(stale, result.File)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to load %d of %d files: %s", len(failed), len(results), strings.Join(failed, ", "))
 	}
+	if len(stale) > 0 {
+		return fmt.Errorf("snapshots older than --max-age %s: %s", config.MaxAge, strings.Join(stale, ", "))
+	}
+	return projectErr
+}
 
-	return &infoStruct, nil
+// logLineWriter receives the --log-line records, replaced in tests
+var logLineWriter io.Writer = os.Stderr
+
+// summaryLogLine returns the --log-line record of a cluster: the MDB_SUMMARY
+// tag and key=value pairs with fixed keys and raw numbers, without colors
+func summaryLogLine(stats ClusterStats) string {
+	deployment := stats.DeploymentID
+	if deployment == "" {
+		deployment = "unknown"
+	}
+	healthPct := 0.0
+	if stats.TotalDisks > 0 {
+		healthPct = float64(stats.OkDisks) / float64(stats.TotalDisks) * 100
+	}
+	return fmt.Sprintf("MDB_SUMMARY deployment=%s drives=%d bad=%d scanning=%d used_pct=%.1f usable_tb=%.1f health_pct=%.1f",
+		deployment, stats.TotalDisks, stats.BadDisks, stats.ScanningDisks,
+		usableSpacePct(stats), float64(stats.UsableSpace)/1e12, healthPct)
 }
 
-func loadNDJSON(filename string) (*clusterStruct, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// printLogLine writes the --log-line record of a cluster to logLineWriter
+func printLogLine(stats ClusterStats) {
+	fmt.Fprintln(logLineWriter, summaryLogLine(stats))
+}
+
+// deploymentName returns the deployment ID of a snapshot, or "unknown"
+func deploymentName(infoStruct *clusterStruct) string {
+	if infoStruct.Info.DeploymentID == "" {
+		return "unknown"
 	}
-	defer file.Close()
+	return infoStruct.Info.DeploymentID
+}
+
+// printProblem prints a line in red, or in bold in markdown format
+func printProblem(pager *Pager, config *Config, text string) {
+	if config.Format == formatMarkdown {
+		pager.Printf("**%s**\n\n", text)
+		return
+	}
+	pager.Printf("%s%s%s\n\n", Red, text, Reset)
+}
+
+// printFileComparison prints one row per input file with its drive counts, usage and health
+func printFileComparison(pager *Pager, results []fileResult, config *Config) {
+	printSectionTitle(pager, config, "Comparison")
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			rows = append(rows, []string{result.File, Red + "load failed" + Reset, "-", "-", "-", "-"})
 			continue
 		}
-		var infoStruct clusterStruct
-		if err := json.Unmarshal(line, &infoStruct); err == nil {
-			if len(infoStruct.Info.Servers) > 0 {
-				return &infoStruct, nil
-			}
+		stats := result.Stats
+		badColor := Green
+		if stats.BadDisks > 0 {
+			badColor = Red
+		}
+		used := "N/A"
+		if stats.UsableSpace > 0 {
+			usedPct := usableSpacePct(stats)
+			used = fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset)
 		}
-		// Try with minio wrapper
-		anotherFormat := struct {
-			InfoStruct clusterStruct `json:"minio"`
-		}{}
-		if err := json.Unmarshal(line, &anotherFormat); err == nil {
-			if len(anotherFormat.InfoStruct.Info.Servers) > 0 {
-				return &anotherFormat.InfoStruct, nil
+		health := "N/A"
+		if stats.TotalDisks > 0 {
+			healthPct := float64(stats.OkDisks) / float64(stats.TotalDisks) * 100
+			healthColor := Red
+			if healthPct >= 90 {
+				healthColor = Green
+			} else if healthPct >= 75 {
+				healthColor = Yellow
 			}
+			health = fmt.Sprintf("%s%s%s", healthColor, formatPct(healthPct, config), Reset)
 		}
+		rows = append(rows, []string{
+			result.File,
+			deploymentName(result.Info),
+			fmt.Sprintf("%d", stats.TotalDisks),
+			fmt.Sprintf("%s%d%s", badColor, stats.BadDisks, Reset),
+			used,
+			health,
+		})
 	}
-	return nil, fmt.Errorf("no valid JSON found")
+	printTableRows(pager, config, []string{"File", "Deployment ID", "Drives", "Bad Drives", "Used %", "Health %"}, rows)
+	pager.Printf("\n")
 }
 
-func extractPoolsFromServers(servers []madmin.ServerProperties) map[string]map[string]interface{} {
-	pools := make(map[string]map[string]interface{})
-
-	// Build pools structure from drive information
+// clusterVersion returns the oldest MinIO release running in a cluster, marked
+// as mixed when servers run different releases
+func clusterVersion(servers []madmin.ServerProperties) string {
+	var oldest time.Time
+	releases := make(map[time.Time]bool)
 	for _, server := range servers {
-		for _, disk := range server.Disks {
-			poolIdx := disk.PoolIndex
-			setIdx := disk.SetIndex
-			poolKey := strconv.Itoa(poolIdx)
-			setKey := strconv.Itoa(setIdx)
-
-			if pools[poolKey] == nil {
-				pools[poolKey] = make(map[string]interface{})
-			}
-			if pools[poolKey][setKey] == nil {
-				pools[poolKey][setKey] = make(map[string]interface{})
-			}
+		release, ok := parseReleaseTime(server.Version)
+		if !ok {
+			continue
+		}
+		releases[release] = true
+		if oldest.IsZero() || release.Before(oldest) {
+			oldest = release
 		}
 	}
-	return pools
-}
-
-func getErasureCodingConfig(servers []madmin.ServerProperties) int {
-	// Try to determine from backend info if available in infoStruct
-	// For now, use standardSCParity if available, otherwise default
-	// This will be set from infoStruct.Info.Backend.StandardSCParity in mainMDB
-	return 2 // Default to EC-2, will be updated if available from backend
+	switch len(releases) {
+	case 0:
+		return "unknown"
+	case 1:
+		return releaseName(oldest)
+	}
+	return fmt.Sprintf("%s (mixed, %d releases)", releaseName(oldest), len(releases))
 }
 
-func getDrives(server madmin.ServerProperties, trimDomain string) []DiskInfo {
-	serverEndpoint := trimDomainData(server.Endpoint, trimDomain)
-	drives := make([]DiskInfo, 0, len(server.Disks))
-
-	for _, disk := range server.Disks {
-		diskInfo := DiskInfo{
-			Server:         serverEndpoint,
-			Path:           disk.DrivePath,
-			State:          disk.State,
-			UUID:           disk.UUID,
-			Scanning:       disk.Healing,
-			DiskIndex:      disk.DiskIndex,
-			TotalSpace:     int64(disk.TotalSpace),
-			UsedSpace:      int64(disk.UsedSpace),
-			AvailableSpace: int64(disk.AvailableSpace),
-			UsedInodes:     int64(disk.UsedInodes),
-			FreeInodes:     int64(disk.FreeInodes),
-			Local:          disk.Local,
-			Metrics:        disk.Metrics,
-			PoolIndex:      disk.PoolIndex,
-			SetIndex:       disk.SetIndex,
+// printClusterComparison prints one row per metric and one column per loaded
+// file. Differing versions are shown in yellow and the cluster with the worst
+// health in red.
+func printClusterComparison(pager *Pager, results []fileResult, config *Config) {
+	loaded := make([]fileResult, 0, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			loaded = append(loaded, result)
 		}
+	}
+	if len(loaded) == 0 {
+		return
+	}
 
-		// Extract path from endpoint if path is not provided
-		if diskInfo.Path == "" && disk.Endpoint != "" {
-			diskInfo.Path = extractPathFromEndpoint(disk.Endpoint)
+	healthPct := func(stats ClusterStats) float64 {
+		if stats.TotalDisks == 0 {
+			return 0
 		}
-
-		// Calculate percentages
-		if diskInfo.TotalSpace > 0 {
-			diskInfo.FreeSpacePct = float64(diskInfo.AvailableSpace) / float64(diskInfo.TotalSpace) * 100
-			diskInfo.UsedSpacePct = float64(diskInfo.UsedSpace) / float64(diskInfo.TotalSpace) * 100
+		return float64(stats.OkDisks) / float64(stats.TotalDisks) * 100
+	}
+	worst := 0
+	versions := make(map[string]bool)
+	for i, result := range loaded {
+		if healthPct(result.Stats) < healthPct(loaded[worst].Stats) {
+			worst = i
+		}
+		versions[clusterVersion(result.Info.Info.Servers)] = true
+	}
+	sameHealth := true
+	for _, result := range loaded {
+		if healthPct(result.Stats) != healthPct(loaded[0].Stats) {
+			sameHealth = false
 		}
-
-		drives = append(drives, diskInfo)
 	}
 
-	return drives
-}
+	headers := []string{"Metric"}
+	for _, result := range loaded {
+		id := deploymentName(result.Info)
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		headers = append(headers, fmt.Sprintf("%s (%s)", filepath.Base(result.File), id))
+	}
+
+	metrics := []struct {
+		name  string
+		value func(i int, result fileResult) string
+	}{
+		{"Servers", func(i int, result fileResult) string { return fmt.Sprintf("%d", len(result.Info.Info.Servers)) }},
+		{"Drives", func(i int, result fileResult) string { return fmt.Sprintf("%d", result.Stats.TotalDisks) }},
+		{"Bad Drives", func(i int, result fileResult) string {
+			if result.Stats.BadDisks > 0 {
+				return fmt.Sprintf("%s%d%s", Red, result.Stats.BadDisks, Reset)
+			}
+			return "0"
+		}},
+		{"Raw Capacity", func(i int, result fileResult) string { return formatSize(result.Stats.TotalSpace, config) }},
+		{"Usable Capacity", func(i int, result fileResult) string { return formatSize(result.Stats.UsableSpace, config) }},
+		{"Used %", func(i int, result fileResult) string {
+			if result.Stats.UsableSpace == 0 {
+				return "N/A"
+			}
+			usedPct := usableSpacePct(result.Stats)
+			return fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset)
+		}},
+		{"Health %", func(i int, result fileResult) string {
+			text := formatPct(healthPct(result.Stats), config)
+			if i == worst && !sameHealth {
+				return Red + text + " (worst)" + Reset
+			}
+			return text
+		}},
+		{"MinIO Version", func(i int, result fileResult) string {
+			version := clusterVersion(result.Info.Info.Servers)
+			if len(versions) > 1 {
+				return Yellow + version + Reset
+			}
+			return version
+		}},
+	}
 
-func extractPathFromEndpoint(endpoint string) string {
-	if strings.Contains(endpoint, "/hadoop/") {
-		parts := strings.Split(endpoint, "/hadoop/")
-		if len(parts) > 1 {
-			return "/" + parts[1]
+	rows := make([][]string, 0, len(metrics))
+	for _, metric := range metrics {
+		row := []string{metric.name}
+		for i, result := range loaded {
+			row = append(row, metric.value(i, result))
 		}
+		rows = append(rows, row)
+	}
+
+	printSectionTitle(pager, config, fmt.Sprintf("Cluster Comparison (%d clusters)", len(loaded)))
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+	if len(versions) > 1 {
+		printProblem(pager, config, fmt.Sprintf("Version mismatch: the clusters run %d different MinIO versions", len(versions)))
 	}
-	parts := strings.Split(endpoint, "/")
-	if len(parts) > 3 {
-		return "/" + strings.Join(parts[3:], "/")
+	if !sameHealth {
+		printProblem(pager, config, fmt.Sprintf("Worst health: %s (%s)", loaded[worst].File, formatPct(healthPct(loaded[worst].Stats), config)))
 	}
-	return ""
 }
 
-func getString(m map[string]interface{}, key string, defaultValue string) string {
-	if val, ok := m[key].(string); ok {
-		return val
-	}
-	return defaultValue
+// anonymizer replaces identifying values of snapshots with stable placeholders
+// and remembers the real values for --anonymize-map
+type anonymizer struct {
+	servers     map[string]string // real host -> placeholder
+	uuids       map[string]string // real UUID -> placeholder
+	deployments map[string]string // file -> real deployment ID
 }
 
-func getInt(m map[string]interface{}, key string, defaultValue int) int {
-	switch v := m[key].(type) {
-	case float64:
-		return int(v)
-	case int:
-		return v
-	case int64:
-		return int(v)
+func newAnonymizer() *anonymizer {
+	return &anonymizer{
+		servers:     make(map[string]string),
+		uuids:       make(map[string]string),
+		deployments: make(map[string]string),
 	}
-	return defaultValue
 }
 
-func getInt64(m map[string]interface{}, key string, defaultValue int64) int64 {
-	switch v := m[key].(type) {
-	case float64:
-		return int64(v)
-	case int:
-		return int64(v)
-	case int64:
-		return v
+// serverName returns the placeholder of host, numbering new hosts in call order
+func (a *anonymizer) serverName(host string) string {
+	if name, ok := a.servers[host]; ok {
+		return name
 	}
-	return defaultValue
+	name := fmt.Sprintf("server-%02d", len(a.servers)+1)
+	a.servers[host] = name
+	return name
 }
 
-func getBool(m map[string]interface{}, key string, defaultValue bool) bool {
-	if val, ok := m[key].(bool); ok {
-		return val
+// endpoint replaces the host of endpoint with its placeholder, keeping scheme, port and path
+func (a *anonymizer) endpoint(endpoint string) string {
+	host := mdbcore.EndpointHost(endpoint)
+	if host == "" {
+		return endpoint
 	}
-	return defaultValue
+	return strings.Replace(endpoint, host, a.serverName(host), 1)
 }
 
-func printClusterSummary(pager *Pager, stats ClusterStats, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, servers []madmin.ServerProperties, infoStruct *clusterStruct, config *Config) {
-	pager.Printf("%sSummary%s\n", Bold, Reset)
-
-	if stats.DeploymentID != "" {
-		pager.Printf("  Deployment ID: %s\n", stats.DeploymentID)
-	} else {
-		pager.Printf("  Deployment ID: Not available\n")
+// uuid returns a placeholder derived from a hash of uuid, so the same drive
+// gets the same placeholder in every file
+func (a *anonymizer) uuid(uuid string) string {
+	if uuid == "" {
+		return ""
 	}
-
-	// Backend configuration
-	if infoStruct != nil && len(infoStruct.Info.Backend.TotalSets) > 0 {
-		totalSetsStr := fmt.Sprintf("%v", infoStruct.Info.Backend.TotalSets)
-		pager.Printf("  Backend: totalSets=%s, standardSCParity=%d, rrSCParity=%d, drivesPerSet=%v\n",
-			totalSetsStr, infoStruct.Info.Backend.StandardSCParity, infoStruct.Info.Backend.RRSCParity, infoStruct.Info.Backend.DrivesPerSet)
+	if name, ok := a.uuids[uuid]; ok {
+		return name
 	}
+	sum := sha256.Sum256([]byte(uuid))
+	name := "uuid-" + hex.EncodeToString(sum[:6])
+	a.uuids[uuid] = name
+	return name
+}
 
-	pager.Printf("\n")
-
-	pager.Printf("  Total Disks: %d\n", stats.TotalDisks)
-	pager.Printf("  Scanning Disks: %s%d%s\n", Yellow, stats.ScanningDisks, Reset)
-	pager.Printf("  Healthy Disks: %s%d%s\n", Green, stats.OkDisks, Reset)
-	pager.Printf("  Problem Disks: %s%d%s\n", Red, stats.BadDisks, Reset)
-
-	if stats.TotalDisks > 0 {
-		healthPct := float64(stats.OkDisks) / float64(stats.TotalDisks) * 100
-		var healthColor string
-		if healthPct >= 90 {
-			healthColor = Green
-		} else if healthPct >= 75 {
-			healthColor = Yellow
-		} else {
-			healthColor = Red
+// apply anonymizes infoStruct in place. Servers are numbered by pool and then
+// in natural order of their names, so server-01 is the first server of pool 0.
+func (a *anonymizer) apply(file string, infoStruct *clusterStruct) {
+	servers := infoStruct.Info.Servers
+	pool := func(server madmin.ServerProperties) int {
+		first := math.MaxInt
+		for _, disk := range server.Disks {
+			if disk.PoolIndex < first {
+				first = disk.PoolIndex
+			}
+		}
+		return first
+	}
+	order := make([]int, len(servers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		pi, pj := pool(servers[order[i]]), pool(servers[order[j]])
+		if pi != pj {
+			return pi < pj
+		}
+		return naturalLess(mdbcore.EndpointHost(servers[order[i]].Endpoint), mdbcore.EndpointHost(servers[order[j]].Endpoint))
+	})
+	for _, i := range order {
+		a.serverName(mdbcore.EndpointHost(servers[i].Endpoint))
+	}
+	// Hosts only seen as peers or drive endpoints follow in natural order, as
+	// the network of a server is a map and its order changes between runs
+	var others []string
+	for _, server := range servers {
+		for endpoint := range server.Network {
+			others = append(others, mdbcore.EndpointHost(endpoint))
+		}
+		for _, disk := range server.Disks {
+			others = append(others, mdbcore.EndpointHost(disk.Endpoint))
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool { return naturalLess(others[i], others[j]) })
+	for _, host := range others {
+		if host != "" {
+			a.serverName(host)
 		}
-		pager.Printf("  Health: %s%.1f%%%s\n", healthColor, healthPct, Reset)
 	}
 
-	if stats.TotalSpace > 0 {
-		totalTB := float64(stats.TotalSpace) / (1024 * 1024 * 1024 * 1024)
-		usedTB := float64(stats.UsedSpace) / (1024 * 1024 * 1024 * 1024)
-
-		// Calculate usable capacity
-		totalUsableSpace := int64(0)
-		for poolIdx, sets := range pools {
-			for setIdx := range sets {
-				key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
-				drives := poolSetDrives[key]
-				totalDisksInSet := len(drives)
-				if totalDisksInSet > 0 {
-					if totalDisksInSet >= stats.ParityDisks {
-						dataDisks := totalDisksInSet - stats.ParityDisks
-						usableRatio := float64(dataDisks) / float64(totalDisksInSet)
-						for _, drive := range drives {
-							totalUsableSpace += int64(float64(drive.TotalSpace) * usableRatio)
-						}
-					}
-				}
+	errorTimes := make(map[string]mdbcore.DriveErrorTimes)
+	for i := range servers {
+		server := &servers[i]
+		realEndpoint := server.Endpoint
+		server.Endpoint = a.endpoint(server.Endpoint)
+		if len(server.Network) > 0 {
+			network := make(map[string]string, len(server.Network))
+			for endpoint, state := range server.Network {
+				network[a.endpoint(endpoint)] = state
 			}
+			server.Network = network
 		}
-
-		usableTB := float64(totalUsableSpace) / (1024 * 1024 * 1024 * 1024)
-		usagePct := float64(stats.UsedSpace) / float64(totalUsableSpace) * 100
-		if totalUsableSpace == 0 {
-			usagePct = 0
+		// The plan and expiry of a license are kept, not whom it was issued to
+		if server.License != nil {
+			license := *server.License
+			license.ID, license.Organization, license.APIKey = "", "", ""
+			server.License = &license
 		}
-		var usageColor string
-		if usagePct < 80 {
-			usageColor = Green
-		} else if usagePct < 95 {
-			usageColor = Yellow
-		} else {
-			usageColor = Red
+		for j := range server.Disks {
+			disk := &server.Disks[j]
+			key := mdbcore.DriveErrorKey(realEndpoint, disk.Endpoint, disk.DrivePath)
+			disk.Endpoint = a.endpoint(disk.Endpoint)
+			disk.UUID = a.uuid(disk.UUID)
+			if times, ok := infoStruct.ErrorTimes[key]; ok {
+				errorTimes[mdbcore.DriveErrorKey(server.Endpoint, disk.Endpoint, disk.DrivePath)] = times
+			}
 		}
-
-		pager.Printf("  Raw Capacity: %.1f TB\n", totalTB)
-		pager.Printf("  Usable Capacity: %.1f TB\n", usableTB)
-		pager.Printf("  Used Space: %.1f TB (%s%.1f%%%s)\n", usedTB, usageColor, usagePct, Reset)
-		pager.Printf("  Available Space: %.1f TB\n", usableTB-usedTB)
 	}
+	infoStruct.ErrorTimes = errorTimes
 
-	pager.Printf("  Pools: %d\n", len(pools))
-	pager.Printf("  Servers: %d\n", len(servers))
-
-	totalErasureSets := 0
-	for _, sets := range pools {
-		totalErasureSets += len(sets)
+	if infoStruct.Info.DeploymentID != "" {
+		a.deployments[file] = infoStruct.Info.DeploymentID
 	}
-	pager.Printf("  Erasure Sets: %d\n", totalErasureSets)
+	infoStruct.Info.DeploymentID = ""
+	infoStruct.Info.Domain = nil
+	infoStruct.Info.Services = madmin.Services{}
 
-	// Scanner status
-	if infoStruct != nil {
-		pager.Printf("  Scanner Status: buckets=%d, objects=%d, versions=%d, deletemarkers=%d, usage=%s\n",
-			infoStruct.Info.Buckets.Count, infoStruct.Info.Objects.Count,
-			infoStruct.Info.Versions.Count, infoStruct.Info.DeleteMarkers.Count,
-			humanize.IBytes(infoStruct.Info.Usage.Size))
+	// Bucket names are numbered by size, largest first
+	if len(infoStruct.BucketsUsage) > 0 {
+		buckets := make(map[string]madmin.BucketUsageInfo, len(infoStruct.BucketsUsage))
+		for i, bucket := range mdbcore.SortedBuckets(infoStruct.BucketsUsage) {
+			buckets[fmt.Sprintf("bucket-%02d", i+1)] = bucket.BucketUsageInfo
+		}
+		infoStruct.BucketsUsage = buckets
 	}
+}
 
-	pager.Printf("\n")
+// writeMap writes the placeholders and the real values they stand for to path as JSON.
+// The file is readable only by the owner since it holds the real names.
+func (a *anonymizer) writeMap(path string) error {
+	if path == "" {
+		return nil
+	}
+	mapping := struct {
+		Servers     map[string]string `json:"servers"`
+		UUIDs       map[string]string `json:"uuids"`
+		Deployments map[string]string `json:"deployments"`
+	}{make(map[string]string), make(map[string]string), a.deployments}
+	for real, name := range a.servers {
+		mapping.Servers[name] = real
+	}
+	for real, name := range a.uuids {
+		mapping.UUIDs[name] = real
+	}
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal anonymize map: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write anonymize map '%s': %v", path, err)
+	}
+	return nil
 }
 
-func printFailedDisksTable(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
-	allFailedDrives := make([]DiskInfo, 0)
-	for _, drives := range poolSetDrives {
-		for _, drive := range drives {
-			if drive.State != "ok" {
-				allFailedDrives = append(allFailedDrives, drive)
-			}
+// bundleSchema is the version of the support bundle manifest layout
+const bundleSchema = 1
+
+// bundleManifest describes a support bundle. Each member is listed with its
+// SHA-256 checksum so truncation or tampering can be detected by the receiver.
+type bundleManifest struct {
+	Schema     int            `json:"schema"`
+	MdbVersion string         `json:"mdbVersion"`
+	Commit     string         `json:"commit,omitempty"`
+	Created    time.Time      `json:"created"`
+	Command    string         `json:"command"`
+	Flags      []string       `json:"flags"`
+	Source     string         `json:"source"`
+	Anonymized bool           `json:"anonymized,omitempty"`
+	Members    []bundleMember `json:"members"`
+}
+
+// bundleMember is a file of a support bundle
+type bundleMember struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// bundleReports are the report formats included in a support bundle
+var bundleReports = []struct {
+	Name   string
+	Format string
+}{
+	{"report.txt", formatText},
+	{"report.html", formatHTML},
+	{"report.json", formatGrafana},
+}
+
+// bundleFile is a member of a --bundle archive or a file of "mdb report"
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// writeTarGz writes files to w as a gzip-compressed tar archive
+func writeTarGz(w io.Writer, files []bundleFile, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    f.name,
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: modTime,
 		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
 	}
+	return gz.Close()
+}
 
-	if len(allFailedDrives) == 0 {
-		pager.Printf("%sNo failed/faulty disks found in the provided data.%s\n", Yellow, Reset)
-		return
+// writeBundle writes the snapshot, the reports selected by config and a
+// manifest to a gzip-compressed tar archive at path and returns the number of
+// files written. The archive is created under a temporary name and renamed
+// when complete, so path never holds a partial bundle.
+func writeBundle(path string, infoStruct *clusterStruct, config *Config) (int, error) {
+	snapshot, err := readFile(config.JSONFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file '%s': %v", config.JSONFile, err)
+	}
+	if config.Anonymize {
+		// The original file holds the real names, bundle what mdb decoded instead
+		if snapshot, err = json.MarshalIndent(infoStruct, "", "  "); err != nil {
+			return 0, fmt.Errorf("failed to marshal anonymized snapshot: %v", err)
+		}
 	}
 
-	// Sort by pool, set, disk index
-	sort.Slice(allFailedDrives, func(i, j int) bool {
-		if allFailedDrives[i].PoolIndex != allFailedDrives[j].PoolIndex {
-			return allFailedDrives[i].PoolIndex < allFailedDrives[j].PoolIndex
+	files := []bundleFile{{"snapshot/" + filepath.Base(inputName(config)), snapshot}}
+	for _, report := range bundleReports {
+		reportConfig := *config
+		reportConfig.Format = report.Format
+		out := NewPager(true)
+		out.stripColor = true
+		if err := renderReport(out, infoStruct, &reportConfig); err != nil {
+			return 0, fmt.Errorf("failed to render %s for bundle: %v", report.Name, err)
 		}
-		if allFailedDrives[i].SetIndex != allFailedDrives[j].SetIndex {
-			return allFailedDrives[i].SetIndex < allFailedDrives[j].SetIndex
+		files = append(files, bundleFile{report.Name, []byte(out.String())})
+	}
+
+	manifest := bundleManifest{
+		Schema:     bundleSchema,
+		MdbVersion: Version,
+		Created:    timeNow().UTC(),
+		Command:    config.Command,
+		Flags:      config.Flags,
+		Source:     filepath.Base(inputName(config)),
+		Anonymized: config.Anonymize,
+	}
+	if Commit != "unknown" {
+		manifest.Commit = Commit
+	}
+	for _, f := range files {
+		sum := sha256.Sum256(f.data)
+		manifest.Members = append(manifest.Members, bundleMember{Name: f.name, Size: len(f.data), SHA256: hex.EncodeToString(sum[:])})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal bundle manifest: %v", err)
+	}
+	files = append(files, bundleFile{"manifest.json", append(manifestData, '\n')})
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create bundle '%s': %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeTarGz(tmp, files, manifest.Created); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write bundle '%s': %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write bundle '%s': %v", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write bundle '%s': %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to write bundle '%s': %v", path, err)
+	}
+	return len(files), nil
+}
+
+// renderIncidentReport renders the files of "mdb report" from one parsed
+// snapshot, each through the renderer of the corresponding view: the summary
+// in markdown, the text report of "mdb show", the failed drives and the low
+// space erasure sets as CSV and every drive as JSON
+func renderIncidentReport(infoStruct *clusterStruct, config *Config) ([]bundleFile, error) {
+	render := func(reportConfig Config) ([]byte, error) {
+		out := NewPager(true)
+		out.stripColor = true
+		if err := renderReport(out, infoStruct, &reportConfig); err != nil {
+			return nil, err
 		}
-		return fmt.Sprintf("%v", allFailedDrives[i].DiskIndex) < fmt.Sprintf("%v", allFailedDrives[j].DiskIndex)
+		return []byte(out.String()), nil
+	}
+
+	// The low space view would replace the erasure sets of the report
+	reportConfig := *config
+	reportConfig.LowSpaceThreshold = nil
+	summaryConfig := reportConfig
+	summaryConfig.Format = formatMarkdown
+	summaryConfig.ShowServers, summaryConfig.ShowSets, summaryConfig.ShowDisks = false, false, false
+	summary, err := render(summaryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render summary.md: %v", err)
+	}
+	textConfig := reportConfig
+	textConfig.Format = formatText
+	text, err := render(textConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report.txt: %v", err)
+	}
+
+	// The CSV files are the views of "mdb drives --failed" and "mdb sets --low-space"
+	failedConfig := reportConfig
+	failedConfig.Format = formatCSV
+	failedConfig.ShowSummary, failedConfig.ShowServers, failedConfig.ShowSets, failedConfig.ShowDisks = false, false, false, true
+	failedConfig.FailedMode = true
+	failedCSV, err := render(failedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render failed-drives.csv: %v", err)
+	}
+	lowSpaceConfig := *config
+	lowSpaceConfig.Format = formatCSV
+	lowSpaceConfig.ShowSummary, lowSpaceConfig.ShowServers, lowSpaceConfig.ShowSets, lowSpaceConfig.ShowDisks = false, false, true, false
+	if lowSpaceConfig.LowSpaceThreshold == nil {
+		threshold := colorThresholds(config).WarnFree
+		lowSpaceConfig.LowSpaceThreshold = &threshold
+	}
+	setsCSV, err := render(lowSpaceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render low-space-sets.csv: %v", err)
+	}
+
+	drives := infoStruct.Drives(mdbcore.DriveFilter{TrimDomain: config.TrimDomain, FQDN: config.FQDN})
+	sort.SliceStable(drives, func(i, j int) bool {
+		return driveLess(drives[i], drives[j])
 	})
+	drivesJSON, err := json.MarshalIndent(drives, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render drives.json: %v", err)
+	}
 
-	pager.Printf("%sMinIO Failed/Faulty Disks from: %s%s\n", Bold, config.JSONFile, Reset)
-	pager.Printf("================================================================================\n")
+	return []bundleFile{
+		{"summary.md", summary},
+		{"report.txt", text},
+		{"failed-drives.csv", failedCSV},
+		{"low-space-sets.csv", setsCSV},
+		{"drives.json", drivesJSON},
+	}, nil
+}
 
-	printTable(pager, allFailedDrives, config)
+// csvTable formats a table as CSV, without the ANSI colors of its cells
+func csvTable(headers []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = stripANSI(cell)
+		}
+		if err := w.Write(cells); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
 }
 
-func printLowSpaceErasureSets(pager *Pager, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, threshold float64, config *Config) {
-	erasureSets := make([]ErasureSetInfo, 0)
+// writeReportDir writes the files of "mdb report" to dir, creating it if needed
+func writeReportDir(dir string, files []bundleFile) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory '%s': %v", dir, err)
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, f.data, 0644); err != nil {
+			return fmt.Errorf("failed to write '%s': %v", path, err)
+		}
+	}
+	return nil
+}
 
-	for poolIdx, sets := range pools {
-		for setIdx := range sets {
-			key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
-			drives := poolSetDrives[key]
-			if len(drives) == 0 {
-				continue
-			}
+// printReportManifest lists the files "mdb report" wrote and their sizes
+func printReportManifest(w io.Writer, out string, files []bundleFile) error {
+	if out == "-" {
+		fmt.Fprintf(w, "Wrote a report archive with %d files to stdout:\n", len(files))
+	} else {
+		fmt.Fprintf(w, "Wrote %d files to %s:\n", len(files), out)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range files {
+		fmt.Fprintf(tw, "  %s\t%s bytes\n", f.name, formatInt(int64(len(f.data))))
+	}
+	return tw.Flush()
+}
 
-			// Calculate averages
-			totalDrives := len(drives)
-			avgTotalSpace := int64(0)
-			avgUsedSpace := int64(0)
-			avgFreeSpace := int64(0)
-			for _, d := range drives {
-				avgTotalSpace += d.TotalSpace
-				avgUsedSpace += d.UsedSpace
-				avgFreeSpace += d.AvailableSpace
-			}
-			avgTotalSpace /= int64(totalDrives)
-			avgUsedSpace /= int64(totalDrives)
-			avgFreeSpace /= int64(totalDrives)
+// renderReport renders the sections selected by config into out
+func renderReport(out *Pager, infoStruct *clusterStruct, config *Config) error {
+	_, err := renderReportFindings(out, infoStruct, config)
+	return err
+}
 
-			var avgFreeSpacePct float64
-			if avgTotalSpace > 0 {
-				avgFreeSpacePct = float64(avgFreeSpace) / float64(avgTotalSpace) * 100
-			}
+// renderReportFindings is renderReport that also returns the findings of the
+// whole snapshot, before the filters of the views
+func renderReportFindings(out *Pager, infoStruct *clusterStruct, config *Config) ([]finding, error) {
+	config.Settings = newRenderSettings(config)
+	config.Settings.NoSets = !infoStruct.IsErasure()
+	servers := infoStruct.Info.Servers
+	pools := mdbcore.Pools(servers)
+	parityDisks := infoStruct.ParityDisks()
 
-			// Only include erasure sets with free space below threshold
-			if avgFreeSpacePct < threshold {
-				poolIdxInt, _ := strconv.Atoi(poolIdx)
-				setIdxInt, _ := strconv.Atoi(setIdx)
-				es := ErasureSetInfo{
-					PoolIdx:         poolIdxInt,
-					SetIdx:          setIdxInt,
-					Drives:          drives,
-					AvgFreeSpacePct: avgFreeSpacePct,
-					AvgSpaceUsedPct: float64(avgUsedSpace) / float64(avgTotalSpace) * 100,
+	poolSetDrives := make(map[string][]DiskInfo)
+	allPoolSetDrives := make(map[string][]DiskInfo) // For capacity calculations (all drives)
+	stats := recordStats(infoStruct)
+	// A zero timestamp is shown as unknown, see loadJSON for when the file
+	// modification time stands in for it
+	snapshot := infoStruct.Timestamp
+	stats.Snapshot = snapshot
+
+	// Servers are named once over all servers, so filtered views name them alike
+	names := serverNamer(servers, config)
+
+	// Process all drives
+	for _, server := range servers {
+		drives := mdbcore.ServerDrives(server, names)
+		mdbcore.ApplyErrorTimes(drives, server, infoStruct.ErrorTimes, snapshot)
+		mdbcore.ApplyHealAges(drives, snapshot)
+		mdbcore.ApplyDriveIO(drives, server, infoStruct.DriveIO)
+		for _, drive := range drives {
+			// Store all drives for capacity calculations
+			key := mdbcore.SetKey(drive.PoolIndex, drive.SetIndex)
+			allPoolSetDrives[key] = append(allPoolSetDrives[key], drive)
+
+			// Apply filters for display (only for disks/sets views)
+			if config.ShowDisks || config.ShowSets {
+				if config.ScanningMode && !drive.Scanning {
+					continue
 				}
-				if avgTotalSpace > 0 {
-					es.AvgSpaceUsedPct = float64(avgUsedSpace) / float64(avgTotalSpace) * 100
+				if config.FailedMode && drive.State == "ok" {
+					continue
+				}
+				if len(config.States) > 0 && !slices.Contains(config.States, drive.State) {
+					continue
+				}
+				// Drives that report no capacity have no free space to compare
+				if config.MinFreeBytes > 0 && (drive.TotalSpace <= 0 || drive.AvailableSpace >= config.MinFreeBytes) {
+					continue
+				}
+				if config.DrillSet != "" && key != config.DrillSet {
+					continue
+				}
+				if config.Grep != nil && !driveMatches(drive, config) {
+					continue
 				}
-				erasureSets = append(erasureSets, es)
 			}
+
+			poolSetDrives[key] = append(poolSetDrives[key], drive)
 		}
 	}
 
-	// Sort by utilization (used space percentage) descending
-	sort.Slice(erasureSets, func(i, j int) bool {
-		return erasureSets[i].AvgSpaceUsedPct > erasureSets[j].AvgSpaceUsedPct
-	})
+	stats.HealthGrade = computeHealthGrade(stats, allPoolSetDrives, servers)
+	stats.UUIDs = checkDriveUUIDs(allPoolSetDrives, servers, names)
+	for _, server := range servers {
+		if server.State == "online" {
+			stats.Online++
+		}
+		if server.ILMExpiryInProgress {
+			stats.ILMExpiry = append(stats.ILMExpiry, names.Name(server.Endpoint))
+		}
+	}
 
-	if len(erasureSets) == 0 {
-		pager.Printf("%sNo erasure sets found with average free space less than %.1f%%.%s\n", Yellow, threshold, Reset)
-		return
+	// Record this run in the per-deployment health history
+	if config.HistorySize > 0 && stats.DeploymentID != "" {
+		stats.History = recordHealthHistory(stats, config)
 	}
 
-	pager.Printf("%sErasure Sets with Average Free Space < %.1f%% (sorted by utilization)%s\n", Bold, threshold, Reset)
-	pager.Printf("================================================================================\n")
+	findings := collectFindings(servers, allPoolSetDrives, names, findingsParity(infoStruct), infoStruct.PoolStatus, config)
+	if config.BaselineChanges != nil {
+		config.BaselineChanges.markFindings(findings)
+	}
+	if config.Quiet {
+		printQuietProblems(out, findings)
+		return findings, nil
+	}
 
-	for _, es := range erasureSets {
-		good := 0
-		bad := 0
-		scanning := 0
-		for _, d := range es.Drives {
-			if d.State == "ok" {
-				good++
-			} else {
-				bad++
-			}
-			if d.Scanning {
-				scanning++
-			}
+	// Structured snapshot for Grafana contains all drives regardless of filters
+	if config.Format == formatGrafana {
+		var groups []*failureGroup
+		if config.GroupBy != nil {
+			groups = failureGroups(servers, names, config.GroupBy)
 		}
+		return findings, writeGrafanaSnapshot(out, pools, allPoolSetDrives, parityDisks, stats.History, infoStruct.BucketsUsage, groups, findings, config)
+	}
 
-		goodText := fmt.Sprintf("%d", good)
-		if good > 0 {
-			goodText = fmt.Sprintf("%s%d%s", Green, good, Reset)
-		}
-		badText := fmt.Sprintf("%d", bad)
-		if bad > 0 {
-			badText = fmt.Sprintf("%s%d%s", Red, bad, Reset)
-		}
-		scanningText := fmt.Sprintf("%d", scanning)
-		if scanning > 0 {
-			scanningText = fmt.Sprintf("%s%d%s", Yellow, scanning, Reset)
+	// The HTML report is built from the structures above, not from the text output
+	if config.Format == formatHTML {
+		var page strings.Builder
+		if err := renderHTMLReport(&page, newHTMLReport(infoStruct, config, stats, allPoolSetDrives, poolSetDrives, findings)); err != nil {
+			return nil, fmt.Errorf("failed to render HTML report: %v", err)
 		}
+		out.Printf("%s", page.String())
+		return findings, nil
+	}
+
+	pager := out
+	switch config.Format {
+	case formatMarkdown:
+		// Buffer the report so free-form text can be converted before it is shown
+		pager = NewPager(true)
+	case formatCSV:
+		// Collect the tables, free-form text is dropped
+		pager = NewPager(true)
+		pager.csv = true
+	}
+
+	if config.Title {
+		printReportTitle(pager, config, snapshot)
+	}
 
-		spaceUsedColor := Green
-		if es.AvgSpaceUsedPct >= 95 {
-			spaceUsedColor = Red
-		} else if es.AvgSpaceUsedPct >= 80 {
-			spaceUsedColor = Yellow
+	// The section index of --legend counts the lines of each section, so the
+	// report is collected first and printed below the legend
+	legendPager := pager
+	if config.Legend && !pager.csv {
+		pager = NewPager(true)
+	}
+
+	erasure := infoStruct.IsErasure()
+	// Snapshots of older releases without pool and set indices have no sets to analyze
+	missingTopology := infoStruct.MissingTopology()
+	// --sets-only prints the erasure sets table alone, or else says why there is none
+	if !config.SetsOnly || !erasure || missingTopology != "" {
+		if erasure {
+			pager.Printf("%sDetected Erasure Coding Configuration: EC:%d%s\n", Bold, parityDisks, Reset)
+		} else {
+			pager.Printf("%sBackend type %s is not erasure coded: erasure sets, parity and usable capacity do not apply, drives are listed as reported.%s\n", Yellow, infoStruct.BackendType(), Reset)
 		}
-		freeSpaceColor := Green
-		if es.AvgFreeSpacePct <= 5 {
-			freeSpaceColor = Red
-		} else if es.AvgFreeSpacePct <= 20 {
-			freeSpaceColor = Yellow
+		if missingTopology != "" {
+			pager.Printf("%s%sTopology information is missing: %s. Erasure sets are not analyzed, drives are listed as reported.%s\n", Bold, Yellow, missingTopology, Reset)
 		}
+		pager.Printf("\n")
+	}
 
-		avgUsedInodes := int64(0)
-		avgFreeInodes := int64(0)
-		for _, d := range es.Drives {
-			avgUsedInodes += d.UsedInodes
-			avgFreeInodes += d.FreeInodes
-		}
-		avgTotalInodes := avgUsedInodes + avgFreeInodes
-		var avgInodesUsedPct float64
-		if avgTotalInodes > 0 {
-			avgInodesUsedPct = float64(avgUsedInodes) / float64(avgTotalInodes) * 100
+	if config.Trend {
+		printTrend(pager, infoStruct.Trend, config)
+	}
+
+	if config.Project {
+		if len(infoStruct.Trend) < 2 {
+			return nil, fmt.Errorf("--project needs two snapshots: two files or an NDJSON file with several records")
 		}
-		inodesColor := Green
-		if avgInodesUsedPct >= 95 {
-			inodesColor = Red
-		} else if avgInodesUsedPct >= 80 {
-			inodesColor = Yellow
+		projection, err := projectCapacity(infoStruct.Trend[0], infoStruct.Trend[len(infoStruct.Trend)-1], config)
+		if err != nil {
+			return nil, err
 		}
+		printProjection(pager, projection, config)
+	}
 
-		pager.Printf("  Pool %d, Erasure Set %d: Good disks: %s, Bad disks: %s, Scanning: %s, Avg Space Used: %s%.1f%%%s, Avg Free Space: %s%.1f%%%s, Avg Inodes Used: %s%.1f%%%s\n",
-			es.PoolIdx, es.SetIdx, goodText, badText, scanningText,
-			spaceUsedColor, es.AvgSpaceUsedPct, Reset,
-			freeSpaceColor, es.AvgFreeSpacePct, Reset,
-			inodesColor, avgInodesUsedPct, Reset)
+	// The summary always shows the snapshot time, other views only warn about stale data
+	if !config.ShowSummary && !config.SetsOnly && snapshotAge(snapshot, timeNow()) > staleSnapshotAge {
+		pager.Printf("Snapshot taken: %s\n\n", formatSnapshotTaken(snapshot, timeNow()))
 	}
-}
 
-// printServerInfo prints server metadata for all servers in table format
-func printServerInfo(pager *Pager, servers []madmin.ServerProperties, pools map[string]map[string]interface{}, trimDomain string) {
-	pager.Printf("%sServers%s\n", Bold, Reset)
-	
-	// Collect all unique servers and determine which pools each belongs to
-	serversData := make(map[string]struct {
-		server  madmin.ServerProperties
-		pools   []int
-	})
-	
-	// Build map of valid pool indices from pools map
-	validPools := make(map[int]bool)
-	for poolKey := range pools {
-		if poolIdx, err := strconv.Atoi(poolKey); err == nil {
-			validPools[poolIdx] = true
+	// Print summary if requested, headed by all findings
+	if config.ShowSummary {
+		printProblems(pager, findings, config)
+		if config.BaselineChanges != nil {
+			printBaselineChanges(pager, config.BaselineChanges)
+		}
+		if offline := offlineServers(servers, names); len(offline) > 0 {
+			printOfflineServers(pager, offline, config)
+		}
+		printClusterSummary(pager, stats, pools, allPoolSetDrives, servers, infoStruct, config)
+		if erasure && missingTopology == "" {
+			printStorageClassCapacity(pager, allPoolSetDrives, parityDisks, infoStruct.RRSParityDisks(), infoStruct.PoolStatus, config)
 		}
 	}
 
-	// Build map of servers to their pool membership
-	for _, server := range servers {
-		endpointName := trimDomainData(server.Endpoint, trimDomain)
-		
-		// Collect all pools this server belongs to by checking its disks
-		// Only include pools that exist in the valid pools map
-		poolSet := make(map[int]bool)
-		for _, disk := range server.Disks {
-			if validPools[disk.PoolIndex] {
-				poolSet[disk.PoolIndex] = true
+	// Filter servers based on --failed flag
+	filteredServers := servers
+	if config.ShowServers && config.FailedMode {
+		// With --failed: show only offline servers
+		filteredServers = make([]madmin.ServerProperties, 0)
+		for _, server := range servers {
+			if server.State != "online" {
+				filteredServers = append(filteredServers, server)
 			}
 		}
-		
-		// Convert pool set to sorted slice
-		var poolList []int
-		for poolIdx := range poolSet {
-			poolList = append(poolList, poolIdx)
-		}
-		sort.Ints(poolList)
-		
-		// Store or update server data
-		if existing, exists := serversData[endpointName]; exists {
-			// Merge pool lists and deduplicate
-			allPools := make(map[int]bool)
-			for _, p := range existing.pools {
-				allPools[p] = true
-			}
-			for _, p := range poolList {
-				allPools[p] = true
-			}
-			var mergedPools []int
-			for p := range allPools {
-				mergedPools = append(mergedPools, p)
-			}
-			sort.Ints(mergedPools)
-			
-			// When merging, prefer offline state over online (offline is more critical)
-			// If current server is offline, use it; otherwise keep existing
-			preferredServer := existing.server
-			if server.State == "offline" || (existing.server.State == "online" && server.State != "online") {
-				preferredServer = server
-			}
-			
-			serversData[endpointName] = struct {
-				server  madmin.ServerProperties
-				pools   []int
-			}{server: preferredServer, pools: mergedPools}
+	}
+
+	// Print servers if requested
+	if config.ShowServers {
+		// Without --failed: show all servers (offline will overwrite online during merge)
+		printServerInfo(pager, filteredServers, pools, names, config)
+	}
+
+	// Print per-server health summary right after the servers table
+	if config.ServerSummary || config.ShowSummary {
+		printServerHealthSummary(pager, filteredServers, names, config)
+	}
+
+	if config.ShowOffline {
+		if offline := offlineServers(servers, names); len(offline) > 0 {
+			printOfflineServers(pager, offline, config)
 		} else {
-			serversData[endpointName] = struct {
-				server  madmin.ServerProperties
-				pools   []int
-			}{server: server, pools: poolList}
+			pager.Printf("%sNo servers are offline.%s\n\n", Green, Reset)
 		}
 	}
 
-	if len(serversData) == 0 {
-		pager.Printf("\n")
-		return
+	if config.ServerCapacity {
+		printServerCapacity(pager, servers, names, config)
 	}
 
-	// Get sorted server names (natural/alphanumeric sort)
-	serverNames := make([]string, 0, len(serversData))
-	for name := range serversData {
-		serverNames = append(serverNames, name)
+	if config.GroupBy != nil {
+		printFailureGroups(pager, failureGroups(servers, names, config.GroupBy), config)
 	}
-	sort.Slice(serverNames, func(i, j int) bool {
-		return naturalLess(serverNames[i], serverNames[j])
-	})
 
-	// Prepare table data
-	headers := []string{"Pool", "Server", "State", "Edition", "Version", "Commit ID", "Memory", "ILM Status", "Uptime"}
-	rows := make([][]string, 0, len(serverNames))
+	if config.ShowSummary {
+		printReleaseTrains(pager, servers, names, config)
+	}
 
-	for _, serverName := range serverNames {
-		data := serversData[serverName]
-		server := data.server
+	if config.ShowSummary || config.ShowServices {
+		printServices(pager, infoStruct.Info.Services, config)
+	}
+
+	if config.ShowILM {
+		printILMExpiry(pager, servers, names, stats, config)
+	}
+
+	if config.ShowSummary && len(infoStruct.BucketsUsage) > 0 {
+		printBuckets(pager, "Top Buckets", infoStruct.BucketsUsage, topBuckets, config)
+	}
+	if config.ShowBuckets {
+		if len(infoStruct.BucketsUsage) == 0 {
+			pager.Printf("%sNo per-bucket usage in this snapshot.%s\n\n", Yellow, Reset)
+		} else {
+			printBuckets(pager, "Buckets", infoStruct.BucketsUsage, 0, config)
+		}
+	}
+
+	if config.ShowSummary && config.ReplicationFile != "" {
+		status, err := loadReplicationStatus(config.ReplicationFile)
+		if err != nil {
+			return nil, err
+		}
+		printReplication(pager, status, infoStruct.Info.DeploymentID, config)
+	}
+
+	if infoStruct.Heal != nil {
+		printHealProgress(pager, infoStruct.Heal, names, config)
+	}
+
+	// Handle special modes for sets/disks
+	if config.Grep != nil && (config.ShowSets || config.ShowDisks) && len(poolSetDrives) == 0 {
+		pager.Printf("%sNo drives match --grep %q.%s\n\n", Yellow, config.GrepPattern, Reset)
+	} else if len(config.States) > 0 && (config.ShowSets || config.ShowDisks) && len(poolSetDrives) == 0 {
+		pager.Printf("%sNo drives in state %s.%s\n\n", Yellow, strings.Join(config.States, " or "), Reset)
+	} else if config.MinFreeBytes > 0 && (config.ShowSets || config.ShowDisks) && len(poolSetDrives) == 0 {
+		pager.Printf("%sNo drives found with less than %s free.%s\n\n", Yellow, formatSize(config.MinFreeBytes, config), Reset)
+	} else if config.ShowDisks && !config.ShowSets && config.LowSpaceThreshold != nil {
+		printLowSpaceDrives(pager, poolSetDrives, *config.LowSpaceThreshold, config)
+	} else if config.ShowDisks && !config.ShowSets && config.InodeThreshold != nil {
+		printHighInodeDrives(pager, poolSetDrives, *config.InodeThreshold, config)
+	} else if config.ShowDisks && !config.ShowSets && config.ShowUnaccounted {
+		printUnaccountedDrives(pager, poolSetDrives, config)
+	} else if config.ShowDisks && !config.ShowSets && config.ErrorThreshold != nil {
+		printErrorDrives(pager, poolSetDrives, *config.ErrorThreshold, config)
+	} else if config.ShowDisks && config.FailedMode && !config.ShowSets {
+		printFailedDisksTable(pager, poolSetDrives, config)
+	} else if (!erasure || missingTopology != "") && (config.ShowSets || config.ShowDisks) {
+		// Without erasure coding or topology there are no sets, only the drives
+		if config.FailedMode {
+			printFailedDisksTable(pager, poolSetDrives, config)
+		} else {
+			printSectionTitle(pager, config, "Drives")
+			drives := sortedDrives(poolSetDrives)
+			sortDrivesBy(drives, config.DriveSort)
+			printTable(pager, drives, config)
+		}
+		pager.Printf("\n")
+	} else if config.ShowSets && config.LowSpaceThreshold != nil {
+		printLowSpaceErasureSets(pager, lowSpaceSetDrives(poolSetDrives, allPoolSetDrives, config), *config.LowSpaceThreshold, config)
+	} else if config.ShowSets || config.ShowDisks {
+		// Print sets/disks if requested
+		printPoolsAndSets(pager, pools, poolSetDrives, allPoolSetDrives, parityDisks, config, servers)
+	}
+
+	if config.SetMetrics && missingTopology == "" {
+		printSetMetrics(pager, allPoolSetDrives, config)
+	}
+
+	if config.ByPath {
+		printPathStats(pager, pathStats(allPoolSetDrives, config.PathRegex), config)
+	}
+
+	if config.Tree && missingTopology == "" {
+		printPoolTree(pager, poolSetDrives, config)
+	}
+
+	if missingTopology != "" && (config.Decommission != nil || len(config.SimulateLoss) > 0) {
+		return nil, fmt.Errorf("the erasure set of each drive is needed to plan decommissioning or simulate a loss, but %s", missingTopology)
+	}
+
+	if config.Decommission != nil {
+		plan, err := planDecommission(allPoolSetDrives, *config.Decommission, parityDisks, config)
+		if err != nil {
+			return nil, err
+		}
+		printDecommissionPlan(pager, plan, config)
+	}
+
+	if len(config.SimulateLoss) > 0 {
+		if err := printSimulatedLoss(pager, allPoolSetDrives, servers, names, parityDisks, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Consistency {
+		printTopologyCheck(pager, mdbcore.CheckTopology(sortedDrives(allPoolSetDrives), infoStruct.Info.Backend), config)
+		printUUIDConsistency(pager, stats.UUIDs, config)
+		printEndpointConsistency(pager, servers, config)
+	}
+
+	if config.ShowUnknown {
+		data, err := readSnapshotDocument(config.JSONFile, infoStruct.RecordLine)
+		if err != nil {
+			return nil, err
+		}
+		printUnknownFields(pager, findUnknownFields(data), config)
+	}
+
+	if pager != legendPager {
+		printLegend(legendPager, pager, config)
+		pager = legendPager
+	}
+
+	switch config.Format {
+	case formatMarkdown:
+		out.Printf("%s", textToMarkdown(pager.String()))
+	case formatCSV:
+		if err := writeCSVTables(out, pager.tables); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %v", err)
+		}
+	}
+
+	return findings, nil
+}
+
+// writeCSVTables writes every table of the report as CSV with a header row,
+// tables separated by a blank line
+func writeCSVTables(out *Pager, tables []reportTable) error {
+	for i, table := range tables {
+		data, err := csvTable(table.Headers, table.Rows)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			out.Printf("\n")
+		}
+		out.Printf("%s", data)
+	}
+	return nil
+}
+
+// serverNamer returns the namer of servers for --trim-domain and --fqdn
+func serverNamer(servers []madmin.ServerProperties, config *Config) *mdbcore.ServerNamer {
+	if config.FQDN {
+		return mdbcore.NewFQDNServerNamer(servers)
+	}
+	return mdbcore.NewServerNamer(servers, config.TrimDomain)
+}
+
+// prepareInput loads config.JSONFile, downloading it first if it is a URL and
+// extracting it if it is an archive, with the --heal status merged in and
+// anonymized with --anonymize. The caller removes the temporary files with
+// removeLocalInput.
+func prepareInput(config *Config) (*clusterStruct, error) {
+	if err := downloadInput(config); err != nil {
+		return nil, err
+	}
+	if err := extractArchive(config); err != nil {
+		return nil, err
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JSON file '%s': %v", inputName(config), err)
+	}
+	if config.HealFile != "" {
+		heal, err := loadHealStatus(config.HealFile)
+		if err != nil {
+			return nil, err
+		}
+		heal.merge(infoStruct)
+	}
+	if config.MetricsFile != "" {
+		metrics, err := loadDriveMetrics(config.MetricsFile, infoStruct.Info.Servers)
+		if err != nil {
+			return nil, err
+		}
+		infoStruct.DriveIO = metrics
+		// The Latency and IOPS columns are added to the default and preset columns
+		if config.ShowDisks {
+			columns := config.DriveColumns
+			if len(columns) == 0 {
+				columns = defaultDriveColumns
+			}
+			columns = append([]string{}, columns...)
+			for _, id := range []string{"latency", "iops"} {
+				if !slices.Contains(columns, id) {
+					columns = append(columns, id)
+				}
+			}
+			config.DriveColumns = columns
+		}
+	}
+	if config.PoolStatusFile != "" {
+		status, err := loadPoolStatus(config.PoolStatusFile)
+		if err != nil {
+			return nil, err
+		}
+		infoStruct.PoolStatus = status
+	}
+	if config.Anonymize {
+		anon := newAnonymizer()
+		anon.apply(config.JSONFile, infoStruct)
+		if err := anon.writeMap(config.AnonymizeMap); err != nil {
+			return nil, err
+		}
+	}
+	return infoStruct, nil
+}
+
+// cmdShow handles "mdb show" (default - shows summary, servers, and erasure sets)
+func cmdShow(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, true, true, true, false)
+	if err != nil {
+		return err
+	}
+	return displayReport(config)
+}
+
+// cmdShowSummary handles "mdb summary" and "mdb show summary"
+func cmdShowSummary(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, true, false, false, false)
+	if err != nil {
+		return err
+	}
+	return displayReport(config)
+}
+
+// cmdShowSets handles "mdb sets" and "mdb show sets"
+func cmdShowSets(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, false, false, true, false)
+	if err != nil {
+		return err
+	}
+	return displayReport(config)
+}
+
+// cmdShowDisks handles "mdb drives" and "mdb show disks"
+func cmdShowDisks(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, false, false, false, true)
+	if err != nil {
+		return err
+	}
+	return displayReport(config)
+}
+
+// cmdFailed handles "mdb failed", the erasure sets with failed drives like
+// "mdb show sets --failed"
+func cmdFailed(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, false, false, true, false)
+	if err != nil {
+		return err
+	}
+	config.FailedMode = true
+	return displayReport(config)
+}
+
+// cmdReport handles "mdb report"
+func cmdReport(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, true, true, true, false)
+	if err != nil {
+		return err
+	}
+	out := ctx.String("out")
+	switch {
+	case out == "":
+		return fmt.Errorf("mdb report needs --out DIR, or --out - for a tar archive on stdout")
+	case len(config.JSONFiles) > 1:
+		return fmt.Errorf("mdb report takes a single file")
+	case config.OutputPath != "" || config.BundlePath != "" || config.Interactive || config.Quiet:
+		return fmt.Errorf("mdb report writes its own files and cannot be used with --output, --bundle, --interactive or --quiet")
+	case out == "-" && stdoutIsTerminal():
+		return fmt.Errorf("not writing a tar archive to a terminal, redirect stdout or use --out DIR")
+	}
+
+	defer removeLocalInput(config)
+	infoStruct, err := prepareInput(config)
+	if err != nil {
+		return err
+	}
+	applyBaseline(infoStruct, config)
+	files, err := renderIncidentReport(infoStruct, config)
+	if err != nil {
+		return err
+	}
+
+	manifest := ctx.App.Writer
+	if out == "-" {
+		// The archive goes to stdout, the manifest to stderr
+		manifest = ctx.App.ErrWriter
+		if manifest == nil {
+			manifest = os.Stderr
+		}
+		if err := writeTarGz(os.Stdout, files, timeNow().UTC()); err != nil {
+			return fmt.Errorf("failed to write report archive: %v", err)
+		}
+	} else if err := writeReportDir(out, files); err != nil {
+		return err
+	}
+	if err := printReportManifest(manifest, out, files); err != nil {
+		return err
+	}
+	if config.LogLine {
+		printLogLine(recordStats(infoStruct))
+	}
+	return nil
+}
+
+// cmdShowServers handles "mdb servers" and "mdb show servers"
+func cmdShowServers(ctx *cli.Context) error {
+	config, err := parseShowFlags(ctx, false, true, false, false)
+	if err != nil {
+		return err
+	}
+	return displayReport(config)
+}
+
+
+// parseShowFlags parses flags for show commands
+func parseShowFlags(ctx *cli.Context, showSummary, showServers, showSets, showDisks bool) (*Config, error) {
+	config := &Config{}
+
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configs data: %v", err)
+	}
+
+	switch ctx.NArg() {
+	case 0:
+		jsonFile, err := currentConfigFile(configsData)
+		if err != nil {
+			return nil, err
+		}
+		config.JSONFile = jsonFile
+	case 1:
+		// A file given on the command line takes precedence over the current config
+		config.JSONFile = ctx.Args().First()
+		if _, err := os.Stat(config.JSONFile); err != nil && !isURL(config.JSONFile) {
+			return nil, fmt.Errorf("file '%s' not found: %v", config.JSONFile, err)
+		}
+	default:
+		// Several files are shown one after another, followed by a comparison
+		for _, file := range ctx.Args() {
+			if _, err := os.Stat(file); err != nil && !isURL(file) {
+				return nil, fmt.Errorf("file '%s' not found: %v", file, err)
+			}
+		}
+		config.JSONFile = ctx.Args().First()
+		config.JSONFiles = append([]string{}, ctx.Args()...)
+	}
+
+	// Flags not given on the command line take their defaults from the
+	// environment and the defaults file
+	if !ctx.Bool("no-config") {
+		if err := applyFlagDefaults(ctx); err != nil {
+			return nil, err
+		}
+	}
+	
+	config.ShowSummary = showSummary
+	config.ShowServers = showServers
+	config.ShowSets = showSets
+	config.ShowDisks = showDisks
+	config.ScanningMode = ctx.Bool("scanning")
+	config.PagerMode = ctx.Bool("pager")
+	config.NoPager = ctx.Bool("no-pager")
+	config.NoMouse = ctx.Bool("no-mouse")
+	config.AssumeYes = ctx.Bool("yes")
+	config.FailedMode = ctx.Bool("failed")
+	for _, state := range ctx.StringSlice("state") {
+		state = strings.ToLower(strings.TrimSpace(state))
+		if state != "" && !slices.Contains(driveStates, state) {
+			return nil, fmt.Errorf("unsupported --state '%s' (valid values: %s)", state, strings.Join(driveStates, ", "))
+		}
+		if state != "" && !slices.Contains(config.States, state) {
+			config.States = append(config.States, state)
+		}
+	}
+	config.FailedOnlyAvg = ctx.Bool("failed-only-averages")
+	config.TrimDomain = ctx.String("trim-domain")
+	config.FQDN = ctx.Bool("fqdn")
+	config.BusyServers = ctx.Bool("busy-servers")
+	config.ServerSummary = ctx.Bool("server-summary")
+	config.ServerDetails = ctx.Bool("server-details")
+	config.ServerCapacity = ctx.Bool("server-capacity")
+	if showDisks && !showSets && !showServers {
+		// "mdb drives" has no servers table, --sort-by sorts its drives table
+		config.DriveSort = strings.ToLower(ctx.String("sort-by"))
+	} else {
+		config.CapacitySort = strings.ToLower(ctx.String("sort-by"))
+	}
+	config.SetSort = strings.ToLower(ctx.String("sets-sort"))
+	config.SetsOnly = ctx.Bool("sets-only")
+	config.ShowServices = ctx.Bool("services")
+	config.ShowILM = ctx.Bool("ilm")
+	config.ShowBuckets = ctx.Bool("buckets")
+	config.ShowOffline = ctx.Bool("offline")
+	config.Bars = ctx.Bool("bars")
+	config.ASCII = ctx.Bool("ascii")
+	config.TreeDrives = ctx.Bool("tree-drives")
+	config.Tree = ctx.Bool("tree") || config.TreeDrives
+	config.Interactive = ctx.Bool("interactive")
+	config.Quiet = ctx.Bool("quiet")
+	config.LogLine = ctx.Bool("log-line")
+	config.FailOn = strings.ToLower(ctx.String("fail-on"))
+	switch config.FailOn {
+	case "", severityCritical, severityWarning, severityInfo:
+	default:
+		return nil, fmt.Errorf("unsupported --fail-on '%s' (valid values: critical, warning, info)", ctx.String("fail-on"))
+	}
+	config.ReplicationFile = ctx.String("replication")
+	config.HealFile = ctx.String("heal")
+	config.MetricsFile = ctx.String("metrics-file")
+	config.PoolStatusFile = ctx.String("pool-status")
+	config.SetMetrics = ctx.Bool("set-metrics")
+	config.Wide = ctx.Bool("wide")
+	if ctx.String("saturation") != "" {
+		val, err := strconv.ParseFloat(ctx.String("saturation"), 64)
+		if err != nil || val <= 0 {
+			return nil, fmt.Errorf("invalid --saturation value: %q (expected a waiting/tokens ratio above 0, e.g. 0.5)", ctx.String("saturation"))
+		}
+		config.SaturationRatio = val
+	}
+	thresholds, err := parseThresholds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.Thresholds = thresholds
+	config.Title = ctx.Bool("title")
+	config.Legend = ctx.Bool("legend")
+	config.HistorySize = ctx.Int("history-size")
+	config.Verbose = ctx.Bool("verbose")
+	config.ShowUnknown = ctx.Bool("show-unknown")
+	config.Consistency = ctx.Bool("consistency")
+	if ctx.String("decommission") != "" {
+		pool, err := strconv.Atoi(ctx.String("decommission"))
+		if err != nil || pool < 0 {
+			return nil, fmt.Errorf("invalid --decommission value: %q (expected a pool index, e.g. 0)", ctx.String("decommission"))
+		}
+		config.Decommission = &pool
+	}
+	for _, value := range ctx.StringSlice("simulate-loss") {
+		target, err := parseLossTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		config.SimulateLoss = append(config.SimulateLoss, target)
+	}
+	config.BundlePath = ctx.String("bundle")
+	config.Compare = ctx.Bool("compare")
+	config.AnonymizeMap = ctx.String("anonymize-map")
+	config.Anonymize = ctx.Bool("anonymize") || config.AnonymizeMap != ""
+	config.Trend = ctx.Bool("trend")
+	config.Project = ctx.Bool("project")
+	config.ProjectAt = defaultProjectAt
+	if ctx.String("project-at") != "" {
+		config.ProjectAt = nil
+		for _, value := range strings.Split(ctx.String("project-at"), ",") {
+			pct, err := parsePercent(value)
+			if err != nil || pct <= 0 {
+				return nil, fmt.Errorf("invalid --project-at value: %q (expected comma-separated percentages, e.g. 80,90,100)", ctx.String("project-at"))
+			}
+			config.ProjectAt = append(config.ProjectAt, pct)
+		}
+		sort.Float64s(config.ProjectAt)
+	}
+	if ctx.String("interval") != "" {
+		interval, err := parseMaxAge(ctx.String("interval"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --interval value: %v", err)
+		}
+		config.Interval = interval
+	}
+	if !config.Project && (ctx.String("project-at") != "" || config.Interval > 0) {
+		return nil, fmt.Errorf("--project-at and --interval need --project")
+	}
+	config.Latest = ctx.Bool("latest")
+	if ctx.String("at") != "" {
+		at, err := parseRecordTime(ctx.String("at"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --at value: %v", err)
+		}
+		config.At = at
+	}
+	if ctx.String("record") != "" {
+		if config.Latest || !config.At.IsZero() {
+			return nil, fmt.Errorf("--record cannot be used with --latest or --at")
+		}
+		switch record := strings.ToLower(ctx.String("record")); record {
+		case "first":
+			config.Record = 1
+		case "last":
+			config.Latest = true
+		default:
+			n, err := strconv.Atoi(record)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid --record value '%s' (use a record number starting at 1, first or last)", ctx.String("record"))
+			}
+			config.Record = n
+		}
+	}
+	if config.Latest && !config.At.IsZero() {
+		return nil, fmt.Errorf("--latest and --at cannot be used together")
+	}
+	if ctx.String("max-age") != "" {
+		maxAge, err := parseMaxAge(ctx.String("max-age"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --max-age value: %v", err)
+		}
+		config.MaxAge = maxAge
+	}
+	if ctx.String("heal-stuck") != "" {
+		healStuck, err := parseMaxAge(ctx.String("heal-stuck"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --heal-stuck value: %v", err)
+		}
+		config.HealStuck = healStuck
+	}
+	config.Command = ctx.Command.FullName()
+	for _, name := range ctx.FlagNames() {
+		if name == "header" && ctx.IsSet(name) {
+			// Headers carry credentials, keep them out of bundles
+			config.Flags = append(config.Flags, "--header=<redacted>")
+		} else if ctx.IsSet(name) {
+			config.Flags = append(config.Flags, fmt.Sprintf("--%s=%v", name, ctx.Generic(name)))
+		}
+	}
+	config.OutputPath = ctx.String("output")
+	config.RetryOnChange = ctx.Bool("retry-on-change")
+
+	if preset := ctx.String("preset"); preset != "" {
+		columns, err := resolveDrivePreset(strings.ToLower(preset), configsData.Presets)
+		if err != nil {
+			return nil, err
+		}
+		config.DriveColumns = columns
+	}
+	if value := ctx.String("columns"); value != "" {
+		if ctx.String("preset") != "" {
+			return nil, fmt.Errorf("--columns and --preset cannot be used together")
+		}
+		columns, err := parseDriveColumns(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --columns value: %v", err)
+		}
+		config.DriveColumns = columns
+	}
+
+	config.ColorMode = strings.ToLower(ctx.String("color"))
+	switch config.ColorMode {
+	case "":
+		config.ColorMode = colorAuto
+	case colorAuto, colorAlways, colorNever:
+	default:
+		return nil, fmt.Errorf("unsupported --color '%s' (valid values: auto, always, never)", ctx.String("color"))
+	}
+	if config.Quiet && !ctx.IsSet("color") {
+		// Quiet output is meant for scripts, colors only when asked for
+		config.ColorMode = colorNever
+	}
+	config.Units = strings.ToLower(ctx.String("units"))
+	switch config.Units {
+	case "":
+		config.Units = unitsIEC
+	case unitsIEC, unitsSI, unitsBytes:
+	default:
+		return nil, fmt.Errorf("unsupported --units '%s' (valid values: iec, si, bytes)", ctx.String("units"))
+	}
+	switch strings.ToLower(ctx.String("fixed-unit")) {
+	case "":
+	case strings.ToLower(fixedUnitGiB):
+		config.FixedUnit = fixedUnitGiB
+	case strings.ToLower(fixedUnitTiB):
+		config.FixedUnit = fixedUnitTiB
+	default:
+		return nil, fmt.Errorf("unsupported --fixed-unit '%s' (valid values: GiB, TiB)", ctx.String("fixed-unit"))
+	}
+	if config.FixedUnit != "" && config.Units != unitsIEC {
+		return nil, fmt.Errorf("--fixed-unit cannot be combined with --units %s", config.Units)
+	}
+	digits := ctx.Int("precision")
+	if digits < 0 || digits > maxPrecision {
+		return nil, fmt.Errorf("invalid --precision value: %d (must be between 0 and %d)", digits, maxPrecision)
+	}
+	config.Precision = &digits
+	config.Exact = ctx.Bool("exact")
+	if config.HistorySize < 0 {
+		return nil, fmt.Errorf("invalid --history-size value: %d (must be 0 or greater)", config.HistorySize)
+	}
+
+	config.Format = strings.ToLower(ctx.String("format"))
+	switch config.Format {
+	case "", formatText:
+		config.Format = formatText
+	case "md", formatMarkdown:
+		config.Format = formatMarkdown
+	case formatHTML, formatCSV, formatGrafana:
+	default:
+		return nil, fmt.Errorf("unsupported --format '%s' (valid formats: text, markdown, html, csv, grafana)", ctx.String("format"))
+	}
+	if config.GroupByPattern = ctx.String("group-by"); config.GroupByPattern != "" {
+		groupBy, err := regexp.Compile(config.GroupByPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --group-by pattern: %v", err)
+		}
+		if groupBy.NumSubexp() == 0 {
+			return nil, fmt.Errorf("--group-by pattern %q has no capture group, e.g. '^minio-(r\\d+)'", config.GroupByPattern)
+		}
+		config.GroupBy = groupBy
+	}
+	config.BaselinePath = ctx.String("baseline")
+	config.UpdateBaseline = ctx.Bool("update-baseline")
+	if config.UpdateBaseline && config.BaselinePath == "" {
+		return nil, fmt.Errorf("--update-baseline needs --baseline FILE")
+	}
+	if config.BaselinePath != "" && len(config.JSONFiles) > 1 {
+		return nil, fmt.Errorf("--baseline supports a single file")
+	}
+	config.ByPath = ctx.Bool("by-path")
+	if config.PathPattern = ctx.String("path-regex"); config.PathPattern != "" {
+		if !config.ByPath {
+			return nil, fmt.Errorf("--path-regex needs --by-path")
+		}
+		pathRegex, err := regexp.Compile(config.PathPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --path-regex pattern: %v", err)
+		}
+		if pathRegex.NumSubexp() == 0 {
+			return nil, fmt.Errorf("--path-regex pattern %q has no capture group, e.g. '(disk\\d+)$'", config.PathPattern)
+		}
+		config.PathRegex = pathRegex
+	}
+	if config.GrepPattern = ctx.String("grep"); config.GrepPattern != "" {
+		pattern := regexp.QuoteMeta(config.GrepPattern)
+		if ctx.Bool("grep-regex") {
+			pattern = config.GrepPattern
+		}
+		grep, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %v", err)
+		}
+		config.Grep = grep
+	} else if ctx.Bool("grep-regex") {
+		return nil, fmt.Errorf("--grep-regex needs --grep")
+	}
+	if ctx.IsSet("width") {
+		config.Width = ctx.Int("width")
+		if config.Width < 0 {
+			return nil, fmt.Errorf("invalid --width value: %d (must be 0 or greater)", config.Width)
+		}
+	} else if config.Format == formatText && config.OutputPath == "" {
+		config.Width = terminalWidth()
+	}
+	config.MaxRows = ctx.Int("max-rows")
+	if config.MaxRows < 0 {
+		return nil, fmt.Errorf("invalid --max-rows value: %d (must be 0 or greater)", config.MaxRows)
+	}
+	if config.Format != formatText || config.PagerMode {
+		// Files are meant to be complete and the pager scrolls through all rows
+		config.MaxRows = 0
+	}
+	if len(config.JSONFiles) > 1 {
+		if config.Format != formatText && config.Format != formatMarkdown {
+			return nil, fmt.Errorf("--format %s supports a single file, several files can be shown as text or markdown", config.Format)
+		}
+		if config.BundlePath != "" {
+			return nil, fmt.Errorf("--bundle supports a single file")
+		}
+	}
+	if config.Compare && len(config.JSONFiles) < 2 {
+		return nil, fmt.Errorf("--compare needs at least two files")
+	}
+	
+	// Parse string flags that need conversion
+	if ctx.String("low-space") != "" {
+		val, err := parsePercent(ctx.String("low-space"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --low-space value: %v", err)
+		}
+		config.LowSpaceThreshold = &val
+	}
+	if ctx.String("min-free-bytes") != "" {
+		val, err := parseSize(ctx.String("min-free-bytes"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --min-free-bytes value: %v", err)
+		}
+		config.MinFreeBytes = val
+	}
+	if ctx.String("min-set-free") != "" {
+		val, err := parseSize(ctx.String("min-set-free"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --min-set-free value: %v", err)
+		}
+		config.MinSetFree = val
+	}
+	if ctx.String("crit-free-bytes") != "" {
+		val, err := parseSize(ctx.String("crit-free-bytes"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --crit-free-bytes value: %v", err)
+		}
+		config.CritFreeBytes = val
+	}
+	config.FreeColorBy = strings.ToLower(ctx.String("free-color-by"))
+	switch config.FreeColorBy {
+	case "":
+		config.FreeColorBy = freeColorByPct
+	case freeColorByPct, freeColorByBytes:
+	default:
+		return nil, fmt.Errorf("unsupported --free-color-by '%s' (valid values: pct, bytes)", ctx.String("free-color-by"))
+	}
+	if ctx.String("inodes") != "" {
+		val, err := parsePercent(ctx.String("inodes"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --inodes value: %v", err)
+		}
+		config.InodeThreshold = &val
+	}
+	if ctx.String("errors") != "" {
+		val, err := strconv.ParseUint(ctx.String("errors"), 10, 64)
+		if err != nil || val == 0 {
+			return nil, fmt.Errorf("invalid --errors value: %q (expected a positive error count)", ctx.String("errors"))
+		}
+		config.ErrorThreshold = &val
+	}
+	if ctx.String("min-bad-disks") != "" {
+		if val, err := strconv.Atoi(ctx.String("min-bad-disks")); err == nil && val >= 0 {
+			config.MinBadDisks = &val
+		}
+	}
+	
+	// Validate mutually exclusive flags
+	if config.ScanningMode && config.FailedMode {
+		return nil, fmt.Errorf("--failed and --scanning cannot be used together")
+	}
+	if config.PagerMode && config.NoPager {
+		return nil, fmt.Errorf("--pager and --no-pager cannot be used together")
+	}
+	if config.InodeThreshold != nil && config.LowSpaceThreshold != nil {
+		return nil, fmt.Errorf("--inodes and --low-space cannot be used together")
+	}
+	if config.ErrorThreshold != nil && (config.InodeThreshold != nil || config.LowSpaceThreshold != nil) {
+		return nil, fmt.Errorf("--errors cannot be used with --inodes or --low-space")
+	}
+	config.ShowUnaccounted = ctx.Bool("unaccounted")
+	if config.ShowUnaccounted && (config.InodeThreshold != nil || config.LowSpaceThreshold != nil || config.ErrorThreshold != nil) {
+		return nil, fmt.Errorf("--unaccounted cannot be used with --inodes, --low-space or --errors")
+	}
+
+
+	// Flags that only apply to some views are only defined on their commands
+	if config.HealFile != "" {
+		if len(config.JSONFiles) > 1 {
+			return nil, fmt.Errorf("--heal supports a single file")
+		}
+		if config.Anonymize {
+			return nil, fmt.Errorf("--heal cannot be used with --anonymize")
+		}
+		if _, err := os.Stat(config.HealFile); err != nil {
+			return nil, fmt.Errorf("file '%s' not found: %v", config.HealFile, err)
+		}
+		// The Heal % column is added to the default and preset columns
+		if showDisks && !slices.Contains(config.DriveColumns, "heal_pct") {
+			columns := config.DriveColumns
+			if len(columns) == 0 {
+				columns = defaultDriveColumns
+			}
+			config.DriveColumns = append(append([]string{}, columns...), "heal_pct")
+		}
+	}
+	if config.PoolStatusFile != "" {
+		if len(config.JSONFiles) > 1 {
+			return nil, fmt.Errorf("--pool-status supports a single file")
+		}
+		if _, err := os.Stat(config.PoolStatusFile); err != nil {
+			return nil, fmt.Errorf("file '%s' not found: %v", config.PoolStatusFile, err)
+		}
+	}
+	if config.MetricsFile != "" {
+		if len(config.JSONFiles) > 1 {
+			return nil, fmt.Errorf("--metrics-file supports a single file")
+		}
+		if config.Anonymize {
+			return nil, fmt.Errorf("--metrics-file cannot be used with --anonymize")
+		}
+		if _, err := os.Stat(config.MetricsFile); err != nil {
+			return nil, fmt.Errorf("file '%s' not found: %v", config.MetricsFile, err)
+		}
+	}
+	if config.ReplicationFile != "" {
+		if config.Anonymize {
+			return nil, fmt.Errorf("--replication cannot be used with --anonymize")
+		}
+		if _, err := os.Stat(config.ReplicationFile); err != nil {
+			return nil, fmt.Errorf("file '%s' not found: %v", config.ReplicationFile, err)
+		}
+	}
+	if config.ShowServices {
+		if config.ServerSummary || config.BusyServers {
+			return nil, fmt.Errorf("--services cannot be used with --server-summary or --busy-servers")
+		}
+		if config.ReplicationFile != "" {
+			return nil, fmt.Errorf("--services cannot be used with --replication")
+		}
+		// The services section is shown alone
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = false
+	}
+	if config.ShowILM {
+		if config.ShowServices || config.ServerSummary || config.BusyServers {
+			return nil, fmt.Errorf("--ilm cannot be used with --services, --server-summary or --busy-servers")
+		}
+		if config.ReplicationFile != "" {
+			return nil, fmt.Errorf("--ilm cannot be used with --replication")
+		}
+		// The ILM section is shown alone
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = false
+	}
+	if config.ShowBuckets {
+		if config.ShowServices || config.ShowILM || config.ServerSummary || config.BusyServers {
+			return nil, fmt.Errorf("--buckets cannot be used with --services, --ilm, --server-summary or --busy-servers")
+		}
+		if config.ReplicationFile != "" {
+			return nil, fmt.Errorf("--buckets cannot be used with --replication")
+		}
+		// The buckets section is shown alone
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = false
+	}
+	if config.ShowOffline {
+		if config.ShowServices || config.ShowILM || config.ShowBuckets || config.ServerSummary || config.BusyServers {
+			return nil, fmt.Errorf("--offline cannot be used with --services, --ilm, --buckets, --server-summary or --busy-servers")
+		}
+		if config.ReplicationFile != "" {
+			return nil, fmt.Errorf("--offline cannot be used with --replication")
+		}
+		// The offline servers are shown alone
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = false
+	}
+	if config.SetsOnly {
+		switch {
+		case config.ShowServices || config.ShowILM || config.ShowBuckets || config.ShowOffline || config.ServerSummary || config.BusyServers || config.ServerCapacity:
+			return nil, fmt.Errorf("--sets-only cannot be used with --services, --ilm, --buckets, --offline, --server-summary, --busy-servers or --server-capacity")
+		case config.ReplicationFile != "" || config.HealFile != "" || config.PoolStatusFile != "":
+			return nil, fmt.Errorf("--sets-only cannot be used with --replication, --heal or --pool-status")
+		case config.SetMetrics || config.Tree || config.ByPath || config.GroupBy != nil || config.LowSpaceThreshold != nil:
+			return nil, fmt.Errorf("--sets-only cannot be used with --set-metrics, --tree, --by-path, --group-by or --low-space")
+		case config.Trend || config.Project || config.Decommission != nil || len(config.SimulateLoss) > 0:
+			return nil, fmt.Errorf("--sets-only cannot be used with --trend, --project, --decommission or --simulate-loss")
+		case config.CapacitySort != "" || (config.SetSort != "" && config.SetSort != setSortRisk):
+			return nil, fmt.Errorf("--sets-only sorts by risk, it cannot be used with --sort-by or another --sets-sort")
+		}
+		// The erasure sets table is shown alone, the sets at most risk first
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = true
+		config.ShowDisks = false
+		config.SetSort = setSortRisk
+	}
+	if config.SetSort != "" {
+		if !slices.Contains(setSortKeys, config.SetSort) {
+			return nil, fmt.Errorf("unsupported --sets-sort '%s' (valid values: %s)", ctx.String("sets-sort"), strings.Join(setSortKeys, ", "))
+		}
+		if !config.ShowSets || config.ServerCapacity {
+			return nil, fmt.Errorf("--sets-sort %s sorts the erasure sets table, which is not shown", config.SetSort)
+		}
+	}
+	if config.CapacitySort != "" {
+		if !config.ServerCapacity {
+			return nil, fmt.Errorf("--sort-by needs --server-capacity")
+		}
+		if !slices.Contains(serverCapacitySortKeys, config.CapacitySort) {
+			return nil, fmt.Errorf("unsupported --sort-by '%s' (valid values: %s)", ctx.String("sort-by"), strings.Join(serverCapacitySortKeys, ", "))
+		}
+	}
+	if config.DriveSort != "" {
+		if !slices.Contains(driveSortKeys, config.DriveSort) {
+			return nil, fmt.Errorf("unsupported --sort-by '%s' (valid values: %s)", ctx.String("sort-by"), strings.Join(driveSortKeys, ", "))
+		}
+		if config.FailedMode || config.LowSpaceThreshold != nil || config.InodeThreshold != nil || config.ErrorThreshold != nil || config.ShowUnaccounted {
+			return nil, fmt.Errorf("--sort-by sorts the drives table, it cannot be used with --failed, --low-space, --inodes, --errors or --unaccounted")
+		}
+	}
+	if config.ServerCapacity {
+		if config.ShowServices || config.ShowILM || config.ShowBuckets || config.ShowOffline || config.ServerSummary || config.BusyServers {
+			return nil, fmt.Errorf("--server-capacity cannot be used with --services, --ilm, --buckets, --offline, --server-summary or --busy-servers")
+		}
+		if config.ReplicationFile != "" {
+			return nil, fmt.Errorf("--server-capacity cannot be used with --replication")
+		}
+		// The server capacity table is shown alone
+		config.ShowSummary = false
+		config.ShowServers = false
+		config.ShowSets = false
+	}
+	if config.Interactive {
+		switch {
+		case len(config.JSONFiles) > 1:
+			return nil, fmt.Errorf("--interactive takes a single file")
+		case config.Format != formatText:
+			return nil, fmt.Errorf("--interactive cannot be used with --format %s", config.Format)
+		case config.OutputPath != "":
+			return nil, fmt.Errorf("--interactive cannot be used with --output")
+		case config.BundlePath != "":
+			return nil, fmt.Errorf("--interactive cannot be used with --bundle")
+		case config.LogLine:
+			return nil, fmt.Errorf("--interactive cannot be used with --log-line")
+		}
+	}
+	if config.Quiet {
+		switch {
+		case len(config.JSONFiles) > 1:
+			return nil, fmt.Errorf("--quiet takes a single file")
+		case config.Format != formatText:
+			return nil, fmt.Errorf("--quiet cannot be used with --format %s", config.Format)
+		case config.Interactive:
+			return nil, fmt.Errorf("--quiet and --interactive cannot be used together")
+		}
+	}
+	if config.FailOn != "" {
+		switch {
+		case len(config.JSONFiles) > 1:
+			return nil, fmt.Errorf("--fail-on takes a single file")
+		case config.Interactive:
+			return nil, fmt.Errorf("--fail-on cannot be used with --interactive")
+		}
+	}
+
+	// URLs are downloaded when the input is loaded, see downloadInput
+	config.Fetch = fetchOptions{Headers: http.Header{}, Insecure: ctx.Bool("insecure")}
+	for _, header := range ctx.StringSlice("header") {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --header %q (expected 'Name: value')", header)
+		}
+		config.Fetch.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if timeout := ctx.String("timeout"); timeout != "" {
+		if config.Fetch.Timeout, err = parseMaxAge(timeout); err != nil {
+			return nil, fmt.Errorf("invalid --timeout value: %v", err)
+		}
+	}
+	files := config.JSONFiles
+	if len(files) == 0 {
+		files = []string{config.JSONFile}
+	}
+	hasURL := false
+	for _, file := range files {
+		hasURL = hasURL || isURL(file)
+	}
+	if !hasURL && (ctx.IsSet("header") || ctx.IsSet("insecure") || ctx.IsSet("timeout")) {
+		return nil, fmt.Errorf("--header, --insecure and --timeout need an http:// or https:// URL")
+	}
+
+	return config, nil
+}
+
+// currentConfigFile returns the JSON file of the current configuration
+func currentConfigFile(configsData *ConfigsData) (string, error) {
+	// Load JSON file from current config - reload configsData fresh each time
+	currentName, err := getCurrentConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if configsData.CurrentConfig != currentName {
+		// Current config changed, use the one from configsData
+		currentName = configsData.CurrentConfig
+	}
+
+	jsonFile, err := loadConfig(currentName)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config '%s': %v", currentName, err)
+	}
+	return jsonFile, nil
+}
+
+// parsePercent parses a percentage flag value such as "10", "10.5", "10%" or "7,5".
+// A comma is accepted as decimal separator; values outside 0-100 are rejected.
+func parsePercent(value string) (float64, error) {
+	s := strings.TrimSpace(value)
+	s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+	if s == "" {
+		return 0, fmt.Errorf("'%s' is not a valid percentage", value)
+	}
+
+	if strings.Contains(s, ",") {
+		// Only a single comma used as decimal separator is unambiguous
+		if strings.Count(s, ",") > 1 || strings.Contains(s, ".") {
+			return 0, fmt.Errorf("'%s' is not a valid percentage (use a single '.' or ',' as decimal separator)", value)
+		}
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(val) {
+		return 0, fmt.Errorf("'%s' is not a valid percentage", value)
+	}
+	if val < 0 || val > 100 {
+		return 0, fmt.Errorf("'%s' is out of range (must be between 0 and 100)", value)
+	}
+	return val, nil
+}
+
+// thousandsPattern matches a size whose number groups thousands with commas,
+// such as "500,000" or "1,000.5 GiB"
+var thousandsPattern = regexp.MustCompile(`^\d{1,3}(,\d{3})+(\.\d+)?\s*[A-Za-z]*$`)
+
+// parseSize parses a size flag value such as "200GiB", "1.5 TB", "500M" or a
+// number of bytes. Commas separate thousands, "500,000" is 500000 bytes;
+// unlike parsePercent they are never a decimal separator, so "1,5TiB" is an
+// error rather than a guess. Sizes must be above zero.
+func parseSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	if strings.Contains(s, ",") {
+		// Commas only separate thousands: "1,5TiB" is ambiguous and rejected
+		if !thousandsPattern.MatchString(s) {
+			return 0, fmt.Errorf("'%s' is not a valid size (commas only separate thousands, use '.' as decimal separator)", value)
+		}
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	size, err := humanize.ParseBytes(s)
+	if err != nil || s == "" {
+		return 0, fmt.Errorf("'%s' is not a valid size (expected e.g. 200GiB, 1.5TB or a number of bytes)", value)
+	}
+	if size == 0 || size > math.MaxInt64 {
+		return 0, fmt.Errorf("'%s' is out of range (must be above 0 and below 8 EiB)", value)
+	}
+	return int64(size), nil
+}
+
+// ConfigInfo represents a stored configuration
+type ConfigInfo struct {
+	Name      string    `json:"name"`
+	FilePath  string    `json:"filePath"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ConfigsData holds all configurations and current active config
+type ConfigsData struct {
+	Configs       []ConfigInfo        `json:"configs"`
+	CurrentConfig string              `json:"currentConfig"`
+	Presets       map[string][]string `json:"presets,omitempty"` // user-defined Drives table column presets
+}
+
+// Config file management functions
+func getConfigDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	configDir := filepath.Join(homeDir, ".mdb", "configs")
+	// Create configs directory if it doesn't exist
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %v", err)
+	}
+	return configDir, nil
+}
+
+func getConfigsFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	configDir := filepath.Join(homeDir, ".mdb")
+	// Create .mdb directory if it doesn't exist
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %v", err)
+	}
+	return filepath.Join(configDir, "configs.json"), nil
+}
+
+func loadConfigsData() (*ConfigsData, error) {
+	configsPath, err := getConfigsFile()
+	if err != nil {
+		return nil, err
+	}
+	
+	data := &ConfigsData{
+		Configs:      []ConfigInfo{},
+		CurrentConfig: "",
+	}
+	
+	fileData, err := os.ReadFile(configsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Return empty configs data if file doesn't exist
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to read configs file: %v", err)
+	}
+	
+	if err := json.Unmarshal(fileData, data); err != nil {
+		return nil, fmt.Errorf("failed to parse configs file: %v", err)
+	}
+	
+	return data, nil
+}
+
+func saveConfigsData(data *ConfigsData) error {
+	configsPath, err := getConfigsFile()
+	if err != nil {
+		return err
+	}
+	
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal configs data: %v", err)
+	}
+	
+	if err := os.WriteFile(configsPath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write configs file: %v", err)
+	}
+	
+	return nil
+}
+
+func saveConfig(name, filePath string) error {
+	// Validate that the file exists
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return fmt.Errorf("file '%s' does not exist", filePath)
+	}
+	
+	// Resolve absolute path
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve absolute path: %v", err)
+	}
+	
+	// Load existing configs
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return err
+	}
+	
+	// Check if config with this name already exists
+	for i, cfg := range configsData.Configs {
+		if cfg.Name == name {
+			// Update existing config
+			configsData.Configs[i].FilePath = absPath
+			configsData.Configs[i].CreatedAt = timeNow()
+			if err := saveConfigsData(configsData); err != nil {
+				return err
+			}
+			return nil
+		}
+	}
+	
+	// Add new config
+	newConfig := ConfigInfo{
+		Name:      name,
+		FilePath:  absPath,
+		CreatedAt: timeNow(),
+	}
+	configsData.Configs = append(configsData.Configs, newConfig)
+	
+	// If this is the first config, set it as current
+	if configsData.CurrentConfig == "" {
+		configsData.CurrentConfig = name
+	}
+	
+	if err := saveConfigsData(configsData); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+func loadConfig(name string) (string, error) {
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return "", err
+	}
+	
+	for _, cfg := range configsData.Configs {
+		if cfg.Name == name {
+			// Validate that the file still exists
+			if _, err := os.Stat(cfg.FilePath); os.IsNotExist(err) {
+				return "", fmt.Errorf("config '%s' file '%s' does not exist", name, cfg.FilePath)
+			}
+			return cfg.FilePath, nil
+		}
+	}
+	
+	return "", fmt.Errorf("config '%s' not found", name)
+}
+
+func getCurrentConfig() (string, error) {
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return "", err
+	}
+	
+	if configsData.CurrentConfig == "" {
+		return "", fmt.Errorf("no current config set. Use 'mdb config add <name> <file.json>' to add a config")
+	}
+	
+	return configsData.CurrentConfig, nil
+}
+
+func setCurrentConfig(name string) error {
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return err
+	}
+	
+	// Verify config exists
+	found := false
+	for _, cfg := range configsData.Configs {
+		if cfg.Name == name {
+			found = true
+			break
+		}
+	}
+	
+	if !found {
+		return fmt.Errorf("config '%s' not found", name)
+	}
+	
+	configsData.CurrentConfig = name
+	if err := saveConfigsData(configsData); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+func listConfigs() ([]ConfigInfo, string, error) {
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return nil, "", err
+	}
+	
+	return configsData.Configs, configsData.CurrentConfig, nil
+}
+
+func removeConfig(name string) error {
+	configsData, err := loadConfigsData()
+	if err != nil {
+		return err
+	}
+	
+	// Find and remove config
+	newConfigs := []ConfigInfo{}
+	found := false
+	for _, cfg := range configsData.Configs {
+		if cfg.Name == name {
+			found = true
+			continue
+		}
+		newConfigs = append(newConfigs, cfg)
+	}
+	
+	if !found {
+		return fmt.Errorf("config '%s' not found", name)
+	}
+	
+	// If removing current config, clear it
+	if configsData.CurrentConfig == name {
+		configsData.CurrentConfig = ""
+		if len(newConfigs) > 0 {
+			// Set first remaining config as current
+			configsData.CurrentConfig = newConfigs[0].Name
+		}
+	}
+	
+	configsData.Configs = newConfigs
+	if err := saveConfigsData(configsData); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+// Defaults of frequently used flags can be set in ~/.config/mdb/config.yaml
+// ($XDG_CONFIG_HOME/mdb/config.yaml if set) or with MDB_* environment
+// variables. Flags on the command line take precedence over the environment,
+// which takes precedence over the file.
+const defaultsEnvPrefix = "MDB_"
+
+// defaultableFlags are the flags whose defaults can be set. For --inodes and
+// --errors the default is the threshold used when the flag is given without a
+// value, the flags themselves stay off.
+var defaultableFlags = []string{
+	"trim-domain",
+	"fqdn",
+	"pager",
+	"no-pager",
+	"no-mouse",
+	"color",
+	"units",
+	"fixed-unit",
+	"precision",
+	"format",
+	"history-size",
+	"max-age",
+	"heal-stuck",
+	"saturation",
+	"warn-used",
+	"crit-used",
+	"warn-free",
+	"crit-free",
+	"warn-inodes",
+	"crit-inodes",
+	"warn-unaccounted",
+	"inodes",
+	"errors",
+	"free-color-by",
+	"crit-free-bytes",
+}
+
+// defaultConflicts are defaultable flags whose default is not applied when
+// the flag they exclude is given on the command line
+var defaultConflicts = map[string]string{
+	"pager":      "no-pager",
+	"no-pager":   "pager",
+	"fixed-unit": "units",
+}
+
+// flagDefault is the default of a flag and where it was set
+type flagDefault struct {
+	Value  string
+	Source string // the environment variable or the path of the defaults file
+}
+
+// defaultsFile returns the path of the defaults file
+func defaultsFile() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "mdb", "config.yaml"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".config", "mdb", "config.yaml"), nil
+}
+
+// defaultsEnvVar returns the environment variable of a defaultable flag, e.g. MDB_TRIM_DOMAIN
+func defaultsEnvVar(name string) string {
+	return defaultsEnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// loadFlagDefaults reads the defaults file, if there is one, and the MDB_*
+// environment variables. Empty variables count as unset. A file that is not
+// YAML, unknown keys and variables and values that are not scalars are skipped
+// and returned as warnings.
+func loadFlagDefaults() (map[string]flagDefault, []string, error) {
+	defaults := make(map[string]flagDefault)
+	var warnings []string
+
+	path, err := defaultsFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read defaults file: %v", err)
+	}
+	if err == nil {
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			warnings = append(warnings, fmt.Sprintf("defaults file %s ignored, it cannot be parsed: %v", path, err))
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch value := values[key]; value.(type) {
+			case string, bool, int, float64:
+				if !slices.Contains(defaultableFlags, key) {
+					warnings = append(warnings, fmt.Sprintf("unknown key '%s' in %s ignored (known keys: %s)", key, path, strings.Join(defaultableFlags, ", ")))
+					continue
+				}
+				defaults[key] = flagDefault{Value: fmt.Sprint(value), Source: path}
+			default:
+				warnings = append(warnings, fmt.Sprintf("key '%s' in %s ignored, its value must be a string, number or boolean", key, path))
+			}
+		}
+	}
+
+	var unknown []string
+	for _, env := range os.Environ() {
+		variable, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(variable, defaultsEnvPrefix) || value == "" {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(variable, defaultsEnvPrefix), "_", "-"))
+		if !slices.Contains(defaultableFlags, name) {
+			unknown = append(unknown, variable)
+			continue
+		}
+		defaults[name] = flagDefault{Value: value, Source: variable}
+	}
+	sort.Strings(unknown)
+	for _, variable := range unknown {
+		warnings = append(warnings, fmt.Sprintf("unknown environment variable %s ignored", variable))
+	}
+	return defaults, warnings, nil
+}
+
+// flagDefaultsKey is the key of the resolved flagDefaults in the app metadata
+const flagDefaultsKey = "flagDefaults"
+
+// flagDefaults are the flag defaults of one run, loaded once by resolveFlagDefaults
+type flagDefaults struct {
+	values   map[string]flagDefault
+	warnings []string
+	err      error // why the defaults could not be loaded, returned when they are applied
+}
+
+// resolveFlagDefaults loads the flag defaults unless args has --no-config
+func resolveFlagDefaults(args []string) *flagDefaults {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "no-config" && value != "false" {
+			return &flagDefaults{}
+		}
+	}
+	values, warnings, err := loadFlagDefaults()
+	return &flagDefaults{values: values, warnings: warnings, err: err}
+}
+
+// optional returns the value of name when it is given without one, and false
+// if name is not a flag with an optional value
+func (d *flagDefaults) optional(name string) (string, bool) {
+	value, ok := optionalFlagDefaults[name]
+	if def, set := d.values[name]; ok && set && def.Value != "" {
+		value = def.Value
+	}
+	return value, ok
+}
+
+// applyFlagDefaults sets the flags of ctx that are not given on the command
+// line to their defaults, and prints warnings about the defaults to stderr
+func applyFlagDefaults(ctx *cli.Context) error {
+	resolved, ok := ctx.App.Metadata[flagDefaultsKey].(*flagDefaults)
+	if !ok {
+		return nil
+	}
+	if resolved.err != nil {
+		return resolved.err
+	}
+	defaults := resolved.values
+	for _, warning := range resolved.warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	given := make(map[string]bool)
+	for _, name := range ctx.FlagNames() {
+		given[name] = ctx.IsSet(name)
+	}
+	for _, name := range defaultableFlags {
+		def, ok := defaults[name]
+		if _, defined := given[name]; !ok || !defined || given[name] || given[defaultConflicts[name]] {
+			continue
+		}
+		if _, optional := optionalFlagDefaults[name]; optional {
+			continue
+		}
+		if err := ctx.Set(name, def.Value); err != nil {
+			return fmt.Errorf("invalid %s value '%s' from %s: %v", name, def.Value, def.Source, err)
+		}
+	}
+	return nil
+}
+
+// builtinFlagDefault returns the value of a defaultable flag set nowhere
+func builtinFlagDefault(name string) string {
+	if value, ok := optionalFlagDefaults[name]; ok {
+		return value
+	}
+	switch name {
+	case "saturation":
+		return strconv.FormatFloat(defaultSaturationRatio, 'g', -1, 64)
+	case "heal-stuck":
+		return strings.TrimSuffix(defaultHealStuckAge.String(), "0m0s")
+	case "crit-free-bytes":
+		return humanize.IBytes(defaultCritFreeBytes)
+	}
+	for _, flag := range thresholdFlags {
+		if flag.Name == name {
+			return strconv.FormatFloat(*flag.Field(&defaultThresholds), 'g', -1, 64)
+		}
+	}
+	for _, flag := range showFlags {
+		if flag.GetName() != name {
+			continue
+		}
+		switch flag := flag.(type) {
+		case cli.StringFlag:
+			return flag.Value
+		case cli.IntFlag:
+			return strconv.Itoa(flag.Value)
+		case cli.BoolFlag:
+			return "false"
+		}
+	}
+	return ""
+}
+
+// Health history keeps the last runs of every deployment in
+// ~/.mdb/history/<deployment-id>.json. It is only recorded with --history-size.
+const (
+	defaultHistorySize  = 0
+	healthHistorySchema = 1
+)
+
+// HealthRecord is a single run in the health history of a deployment
+type HealthRecord struct {
+	RunAt        time.Time `json:"runAt"`
+	Source       string    `json:"source"`
+	SnapshotTime time.Time `json:"snapshotTime"`
+	Grade        string    `json:"grade"`
+	FailedDrives int       `json:"failedDrives"`
+	UsedPct      float64   `json:"usedPct"`
+}
+
+// HealthHistory is the on-disk history file of a deployment
+type HealthHistory struct {
+	Schema       int            `json:"schema"`
+	DeploymentID string         `json:"deploymentId"`
+	Runs         []HealthRecord `json:"runs"`
+}
+
+func getHistoryFile(deploymentID string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	historyDir := filepath.Join(homeDir, ".mdb", "history")
+	// Create history directory if it doesn't exist
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %v", err)
+	}
+	// Deployment IDs are UUIDs, but never let them escape the history directory
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, deploymentID)
+	return filepath.Join(historyDir, name+".json"), nil
+}
+
+// loadHealthHistory reads the history of a deployment. A missing file yields an
+// empty history; an unreadable, corrupt or outdated file is reported as an error
+// together with an empty history so the caller can start fresh.
+func loadHealthHistory(path, deploymentID string) (*HealthHistory, error) {
+	history := &HealthHistory{
+		Schema:       healthHistorySchema,
+		DeploymentID: deploymentID,
+		Runs:         []HealthRecord{},
+	}
+
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return history, fmt.Errorf("failed to read history file: %v", err)
+	}
+
+	loaded := &HealthHistory{}
+	if err := json.Unmarshal(fileData, loaded); err != nil {
+		return history, fmt.Errorf("failed to parse history file: %v", err)
+	}
+	if loaded.Schema != healthHistorySchema {
+		return history, fmt.Errorf("unsupported history schema %d (expected %d)", loaded.Schema, healthHistorySchema)
+	}
+	if loaded.DeploymentID != deploymentID {
+		return history, fmt.Errorf("history file belongs to deployment '%s'", loaded.DeploymentID)
+	}
+	history.Runs = loaded.Runs
+	return history, nil
+}
+
+func saveHealthHistory(path string, history *HealthHistory) error {
+	fileData, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %v", err)
+	}
+
+	// Write to a temporary file first so an interrupted run cannot corrupt the history
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	return nil
+}
+
+// recordHealthHistory appends the current run to the deployment history, keeps
+// the last config.HistorySize runs and returns them. Problems with the history
+// file are printed as warnings and never abort the report.
+func recordHealthHistory(stats ClusterStats, config *Config) []HealthRecord {
+	path, err := getHistoryFile(stats.DeploymentID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: health history disabled: %v\n", err)
+		return nil
+	}
+
+	history, err := loadHealthHistory(path, stats.DeploymentID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, starting a new health history for %s\n", err, stats.DeploymentID)
+	}
+
+	record := HealthRecord{
+		RunAt:        timeNow().UTC(),
+		Source:       filepath.Base(inputName(config)),
+		Grade:        stats.HealthGrade,
+		FailedDrives: stats.BadDisks,
+		UsedPct:      usableSpacePct(stats),
+	}
+	// The time of the "Snapshot taken" line, zero when it is unknown
+	if !stats.Snapshot.IsZero() {
+		record.SnapshotTime = stats.Snapshot.UTC()
+	}
+
+	// Viewing the same snapshot again replaces its entry instead of adding a new run
+	if n := len(history.Runs); n > 0 && history.Runs[n-1].Source == record.Source && history.Runs[n-1].SnapshotTime.Equal(record.SnapshotTime) {
+		history.Runs[n-1] = record
+	} else {
+		history.Runs = append(history.Runs, record)
+	}
+	if len(history.Runs) > config.HistorySize {
+		history.Runs = history.Runs[len(history.Runs)-config.HistorySize:]
+	}
+
+	if err := saveHealthHistory(path, history); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return history.Runs
+}
+
+// formatHealthTrend formats the grades of the history as "B → B → C over last 3 runs ↓"
+func formatHealthTrend(runs []HealthRecord) string {
+	grades := make([]string, 0, len(runs))
+	for _, run := range runs {
+		grades = append(grades, gradeColor(run.Grade)+run.Grade+Reset)
+	}
+
+	// Grades sort alphabetically from best to worst
+	arrow := "→"
+	first, last := runs[0].Grade, runs[len(runs)-1].Grade
+	if last < first {
+		arrow = Green + "↑" + Reset
+	} else if last > first {
+		arrow = Red + "↓" + Reset
+	}
+	return fmt.Sprintf("%s over last %d runs %s", strings.Join(grades, " → "), len(runs), arrow)
+}
+
+// Baselines are saved with --update-baseline and compared with --baseline
+const baselineSchema = 1
+
+// BaselineDrive is the state of a drive in a baseline
+type BaselineDrive struct {
+	UUID  string `json:"uuid,omitempty"`
+	State string `json:"state"`
+}
+
+// Baseline is the on-disk state of a deployment that --baseline compares a
+// snapshot with. Servers are keyed by host and drives by host and path, like
+// mdbcore.DriveIOKey, so trimmed names and UUIDs lost by failed drives do not
+// matter.
+type Baseline struct {
+	Schema       int                      `json:"schema"`
+	DeploymentID string                   `json:"deploymentId"`
+	SavedAt      time.Time                `json:"savedAt"`
+	Source       string                   `json:"source"`
+	SnapshotTime time.Time                `json:"snapshotTime"`
+	Servers      map[string]string        `json:"servers"`
+	Drives       map[string]BaselineDrive `json:"drives"`
+}
+
+// baselineServerKey is the key of a server in a Baseline
+func baselineServerKey(endpoint string) string {
+	return strings.ToLower(mdbcore.EndpointHost(endpoint))
+}
+
+// loadBaseline reads a baseline. A missing file yields nil without error, as
+// before the first --update-baseline; an unreadable, corrupt or outdated file
+// is reported as an error so the caller can go on without it.
+func loadBaseline(path string) (*Baseline, error) {
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read baseline file: %v", err)
+	}
+	baseline := &Baseline{}
+	if err := json.Unmarshal(fileData, baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file: %v", err)
+	}
+	if baseline.Schema != baselineSchema {
+		return nil, fmt.Errorf("unsupported baseline schema %d (expected %d)", baseline.Schema, baselineSchema)
+	}
+	return baseline, nil
+}
+
+// newBaseline returns the state of the servers and drives of a snapshot
+func newBaseline(servers []madmin.ServerProperties, names *mdbcore.ServerNamer, deploymentID, source string, snapshot time.Time) *Baseline {
+	baseline := &Baseline{
+		Schema:       baselineSchema,
+		DeploymentID: deploymentID,
+		SavedAt:      timeNow().UTC(),
+		Source:       source,
+		SnapshotTime: snapshot.UTC(),
+		Servers:      make(map[string]string),
+		Drives:       make(map[string]BaselineDrive),
+	}
+	for _, server := range servers {
+		// Offline wins over online when a server is listed twice
+		if key := baselineServerKey(server.Endpoint); baseline.Servers[key] == "" || server.State != "online" {
+			baseline.Servers[key] = server.State
+		}
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			baseline.Drives[mdbcore.DriveIOKey(server.Endpoint, drive.Path)] = BaselineDrive{UUID: drive.UUID, State: drive.State}
+		}
+	}
+	return baseline
+}
+
+// saveBaseline writes a baseline through a temporary file, like saveHealthHistory
+func saveBaseline(path string, baseline *Baseline) error {
+	fileData, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %v", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline file: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write baseline file: %v", err)
+	}
+	return nil
+}
+
+// applyBaseline compares the snapshot with the --baseline once per run and sets
+// config.BaselineChanges; with --update-baseline the snapshot then replaces it.
+// A baseline of another deployment is neither compared nor overwritten.
+func applyBaseline(infoStruct *clusterStruct, config *Config) {
+	config.BaselineChanges = nil
+	if config.BaselinePath == "" {
+		return
+	}
+	baseline, err := loadBaseline(config.BaselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, ignoring baseline %s\n", err, config.BaselinePath)
+	}
+	deploymentID := infoStruct.Info.DeploymentID
+	if baseline != nil && baseline.DeploymentID != "" && deploymentID != "" && baseline.DeploymentID != deploymentID {
+		fmt.Fprintf(os.Stderr, "Warning: baseline %s belongs to deployment '%s', ignoring it\n", config.BaselinePath, baseline.DeploymentID)
+		return
+	}
+	servers := infoStruct.Info.Servers
+	names := serverNamer(servers, config)
+	if baseline != nil {
+		config.BaselineChanges = compareBaseline(baseline, servers, names)
+	}
+	if config.UpdateBaseline {
+		if err := saveBaseline(config.BaselinePath, newBaseline(servers, names, deploymentID, filepath.Base(inputName(config)), infoStruct.Timestamp)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// baselineChanges are the drives and servers that failed or recovered since a
+// baseline. Drives are keyed by server name and path and servers by name, as
+// shown in the report.
+type baselineChanges struct {
+	Baseline         *Baseline
+	NewDrives        map[string]bool
+	NewServers       map[string]bool
+	RecoveredDrives  int
+	RecoveredServers int
+}
+
+// compareBaseline compares the servers and drives of a snapshot with a
+// baseline. A drive that is not ok or a server that is not online is new unless
+// it was already failed in the baseline; drives and servers the baseline does
+// not know count as new when they fail.
+func compareBaseline(baseline *Baseline, servers []madmin.ServerProperties, names *mdbcore.ServerNamer) *baselineChanges {
+	changes := &baselineChanges{Baseline: baseline, NewDrives: make(map[string]bool), NewServers: make(map[string]bool)}
+	recoveredServers := make(map[string]bool)
+	for _, server := range servers {
+		name := names.Name(server.Endpoint)
+		was, known := baseline.Servers[baselineServerKey(server.Endpoint)]
+		if server.State != "online" && (!known || was == "online") {
+			changes.NewServers[name] = true
+		} else if server.State == "online" && known && was != "online" {
+			recoveredServers[name] = true
+		}
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			was, known := baseline.Drives[mdbcore.DriveIOKey(server.Endpoint, drive.Path)]
+			if drive.State != "ok" && (!known || was.State == "ok") {
+				changes.NewDrives[baselineDriveKey(drive)] = true
+			} else if drive.State == "ok" && known && was.State != "ok" {
+				changes.RecoveredDrives++
+			}
+		}
+	}
+	for name := range changes.NewServers {
+		delete(recoveredServers, name)
+	}
+	changes.RecoveredServers = len(recoveredServers)
+	return changes
+}
+
+// baselineDriveKey is the key of a drive in baselineChanges
+func baselineDriveKey(drive DiskInfo) string {
+	return drive.Server + "|" + drive.Path
+}
+
+// newDrive reports whether a drive failed since the baseline
+func (c *baselineChanges) newDrive(drive DiskInfo) bool {
+	return c != nil && c.NewDrives[baselineDriveKey(drive)]
+}
+
+// newServer reports whether a server went offline since the baseline
+func (c *baselineChanges) newServer(name string) bool {
+	return c != nil && c.NewServers[name]
+}
+
+// markFindings flags the findings about drives and servers that failed since
+// the baseline as new. A failed drive has no other findings and a failed server
+// no remote drives, so every finding of a new one is about its failure.
+func (c *baselineChanges) markFindings(findings []finding) {
+	for i, f := range findings {
+		switch {
+		case f.Category == "drive" && f.Path != "":
+			findings[i].New = c.NewDrives[f.Server+"|"+f.Path]
+		case f.Category == "server":
+			findings[i].New = c.NewServers[f.Server]
+		}
+	}
+}
+
+// newTag follows a cell with a NEW tag when its drive or server failed since the baseline
+func newTag(text string, isNew bool) string {
+	if !isNew {
+		return text
+	}
+	return text + " " + Bold + Red + "NEW" + Reset
+}
+
+// printBaselineChanges prints what failed and recovered since the baseline
+func printBaselineChanges(pager *Pager, changes *baselineChanges) {
+	baseline := changes.Baseline
+	since := baseline.SnapshotTime
+	if since.IsZero() {
+		since = baseline.SavedAt
+	}
+	var parts []string
+	if n := len(changes.NewDrives); n > 0 {
+		parts = append(parts, fmt.Sprintf("%s%s%s", Red, countNoun(n, "newly failed drive"), Reset))
+	}
+	if n := len(changes.NewServers); n > 0 {
+		parts = append(parts, fmt.Sprintf("%s%s%s", Red, countNoun(n, "newly offline server"), Reset))
+	}
+	if changes.RecoveredDrives > 0 {
+		parts = append(parts, fmt.Sprintf("%s%s recovered%s", Green, countNoun(changes.RecoveredDrives, "drive"), Reset))
+	}
+	if changes.RecoveredServers > 0 {
+		parts = append(parts, fmt.Sprintf("%s%s back online%s", Green, countNoun(changes.RecoveredServers, "server"), Reset))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, Green+"no changes"+Reset)
+	}
+	pager.Printf("Since baseline of %s (%s): %s\n\n", since.Format("2006-01-02 15:04 UTC"), baseline.Source, strings.Join(parts, ", "))
+}
+
+// printHealthHistory prints every recorded run of the deployment history
+func printHealthHistory(pager *Pager, runs []HealthRecord, config *Config) {
+	printSectionTitle(pager, config, "Health History")
+
+	headers := []string{"Run", "Source", "Snapshot", "Grade", "Failed Drives", "Used"}
+	rows := make([][]string, 0, len(runs))
+	for _, run := range runs {
+		snapshot := "unknown"
+		if !run.SnapshotTime.IsZero() {
+			snapshot = run.SnapshotTime.Format("2006-01-02 15:04:05")
+		}
+		failed := fmt.Sprintf("%d", run.FailedDrives)
+		if run.FailedDrives > 0 {
+			failed = Red + failed + Reset
+		}
+		rows = append(rows, []string{
+			run.RunAt.Format("2006-01-02 15:04:05"),
+			run.Source,
+			snapshot,
+			gradeColor(run.Grade) + run.Grade + Reset,
+			failed,
+			fmt.Sprintf("%s%s%s", usageColor(run.UsedPct, config), formatPct(run.UsedPct, config), Reset),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// errFileChanged is returned when the analyzed file is modified while it is read,
+// typically because the collector is still writing it
+var errFileChanged = errors.New("file changed during read — collection may still be in progress, retry shortly (or use --retry-on-change)")
+
+// retryOnChangeDelay is how long --retry-on-change waits before reading the file again
+const retryOnChangeDelay = 2 * time.Second
+
+// readFile reads the analyzed file for support bundles and load error diagnosis
+var readFile = os.ReadFile
+
+// openFile opens the analyzed file, replaced in tests to simulate concurrent writers
+var openFile = os.Open
+
+// loadBufferSize is the read buffer of the streaming JSON decoder
+const loadBufferSize = 1 << 20
+
+// loadJSON loads a diagnostic file and fails with errFileChanged if its size or
+// modification time changed while it was read and parsed
+func loadJSON(filename string) (*clusterStruct, error) {
+	before, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s' not found: %v", filename, err)
+	}
+
+	infoStruct, err := parseJSONFile(filename)
+
+	after, statErr := os.Stat(filename)
+	if statErr == nil && (after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime())) {
+		return nil, errFileChanged
+	}
+	// Only the last record of an NDJSON file was written at the modification time
+	if err == nil && infoStruct.Timestamp.IsZero() && infoStruct.RecordIndex == infoStruct.RecordCount {
+		infoStruct.Timestamp = before.ModTime()
+	}
+	return infoStruct, err
+}
+
+// loadJSONRetry loads a diagnostic file and, if retry is set, reads it once more
+// after a short delay when it changed during the first read
+func loadJSONRetry(filename string, retry bool) (*clusterStruct, error) {
+	infoStruct, err := loadJSON(filename)
+	if retry && errors.Is(err, errFileChanged) {
+		fmt.Fprintf(os.Stderr, "Warning: '%s' changed during read, retrying in %v\n", filename, retryOnChangeDelay)
+		time.Sleep(retryOnChangeDelay)
+		infoStruct, err = loadJSON(filename)
+	}
+	return infoStruct, err
+}
+
+// parseJSONFile decodes filename while reading it, so the file is never held in
+// memory as a whole, and falls back to NDJSON if it is not a single document
+func parseJSONFile(filename string) (*clusterStruct, error) {
+	infoStruct, attempts, err := decodeDocument(filename)
+	if err != nil || infoStruct != nil {
+		return infoStruct, err
+	}
+	// A document that is valid as a whole is not NDJSON either
+	if attempts[0].Format != mdbcore.FormatPlainJSON {
+		return nil, diagnoseFile(filename, attempts)
+	}
+
+	// Try NDJSON format
+	records, _, err := loadNDJSONRecords(filename)
+	if err != nil {
+		attempts = append(attempts, mdbcore.FormatAttempt{Format: mdbcore.FormatNDJSON, Err: err})
+		return nil, diagnoseFile(filename, attempts)
+	}
+	return records[0], nil
+}
+
+// decodeDocument decodes filename as a single JSON document, or returns the
+// attempts that failed
+func decodeDocument(filename string) (*clusterStruct, []mdbcore.FormatAttempt, error) {
+	file, err := openFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("file '%s' not found: %v", filename, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, loadBufferSize)
+	if err := mdbcore.SkipVersionPrefix(reader); err != nil {
+		return nil, nil, fmt.Errorf("failed to read file '%s': %v", filename, err)
+	}
+	snapshot, attempts := mdbcore.Decode(reader)
+	if snapshot == nil {
+		return nil, attempts, nil
+	}
+	return &clusterStruct{Snapshot: *snapshot}, nil, nil
+}
+
+// diagnoseFile reads filename, which none of attempts could decode, to explain why
+func diagnoseFile(filename string, attempts []mdbcore.FormatAttempt) error {
+	data, err := readFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read file '%s': %v", filename, err)
+	}
+	return newLoadError(bytes.TrimPrefix(data, []byte(mdbcore.VersionPrefix)), attempts)
+}
+
+// readSnapshotDocument reads the JSON document of filename, or of line line of an
+// NDJSON file, for --show-unknown
+func readSnapshotDocument(filename string, line int) ([]byte, error) {
+	file, err := openFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s' not found: %v", filename, err)
+	}
+	defer file.Close()
+
+	if line == 0 {
+		reader := bufio.NewReaderSize(file, loadBufferSize)
+		if err := mdbcore.SkipVersionPrefix(reader); err != nil {
+			return nil, fmt.Errorf("failed to read file '%s': %v", filename, err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file '%s': %v", filename, err)
+		}
+		return data, nil
+	}
+
+	var data []byte
+	err = mdbcore.ReadLines(file, func(number int, text []byte) bool {
+		if number == line {
+			data = text
+		}
+		return number < line
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %v", filename, err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("file '%s' has no line %d", filename, line)
+	}
+	return data, nil
+}
+
+// loadErrorHeadLength is how much of the file a loadError quotes
+const loadErrorHeadLength = 200
+
+// loadError explains why a file matched none of the input formats: what the
+// file looks like, where its first JSON syntax error is, how it starts and the
+// error of every format that was tried
+type loadError struct {
+	Guess    string
+	Syntax   string
+	Head     string
+	Attempts []mdbcore.FormatAttempt
+}
+
+// newLoadError diagnoses data, the content of a file none of attempts could read
+func newLoadError(data []byte, attempts []mdbcore.FormatAttempt) *loadError {
+	loadErr := &loadError{Guess: guessFileContent(data), Attempts: attempts}
+	if offset, err := firstSyntaxError(data); errors.Is(err, io.ErrUnexpectedEOF) {
+		loadErr.Syntax = fmt.Sprintf("byte %d (end of file): %v", offset, err)
+	} else if err != nil {
+		// offset counts the bytes read, including the offending one
+		line := bytes.Count(data[:offset], []byte("\n")) + 1
+		column := offset - int64(bytes.LastIndexByte(data[:offset], '\n')) - 1
+		loadErr.Syntax = fmt.Sprintf("byte %d (line %d, column %d): %v", offset, line, column, err)
+	}
+	head := data
+	if len(head) > loadErrorHeadLength {
+		head = head[:loadErrorHeadLength]
+	}
+	loadErr.Head = strings.ToValidUTF8(string(head), "?")
+	return loadErr
+}
+
+func (e *loadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no supported format matched: %s", e.Guess)
+	if e.Syntax != "" {
+		fmt.Fprintf(&b, "\n  first syntax error at %s", e.Syntax)
+	}
+	fmt.Fprintf(&b, "\n  file starts with: %q", e.Head)
+	for _, attempt := range e.Attempts {
+		fmt.Fprintf(&b, "\n  as %s: %v", attempt.Format, attempt.Err)
+	}
+	return b.String()
+}
+
+// firstSyntaxError decodes every top-level JSON value of data, so NDJSON is
+// checked record by record, and returns the byte offset of the first error.
+// Truncated input is reported at its end as io.ErrUnexpectedEOF.
+func firstSyntaxError(data []byte) (int64, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return 0, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return int64(len(data)), io.ErrUnexpectedEOF
+		}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return syntaxErr.Offset, err
+		}
+		if err != nil {
+			return decoder.InputOffset(), err
+		}
+	}
+}
+
+// guessFileContent describes what data, which is not a snapshot mdb can read,
+// most likely is, with advice on how to get a usable file
+func guessFileContent(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	lower := bytes.ToLower(trimmed[:min(len(trimmed), 512)])
+	switch {
+	case len(trimmed) == 0:
+		return "the file is empty"
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return "the file is gzip-compressed, decompress it with gunzip first"
+	case bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.Contains(lower, []byte("<html")):
+		return "looks like an HTML page, probably an error or login page saved instead of the download"
+	case bytes.HasPrefix(trimmed, []byte("# HELP ")) || bytes.HasPrefix(trimmed, []byte("# TYPE ")):
+		return "looks like Prometheus metrics, mdb needs `mc admin info --json` output and reads metrics with --metrics-file"
+	case bytes.Contains(data, []byte("Uptime:")) && bytes.Contains(data, []byte("Drives:")):
+		return "looks like `mc admin info` without --json, collect it again with `mc admin info --json ALIAS`"
+	case trimmed[0] != '{' && trimmed[0] != '[':
+		return fmt.Sprintf("not JSON, the file starts with %q", string(trimmed[:min(len(trimmed), 20)]))
+	}
+
+	offset, err := firstSyntaxError(data)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Sprintf("the file is truncated at byte %d, the collection was probably interrupted", offset)
+	case err != nil:
+		return fmt.Sprintf("invalid JSON at byte %d", offset)
+	}
+	return "valid JSON but without servers, not `mc admin info --json` output or a SUBNET diagnostics upload"
+}
+
+// loadNDJSON returns the first record of an NDJSON file, like --record=first
+func loadNDJSON(filename string) (*clusterStruct, error) {
+	records, _, err := loadNDJSONRecords(filename)
+	if err != nil {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+// loadNDJSONRecords decodes every record of an NDJSON file in file order and
+// returns how many non-empty lines were skipped because they could not be
+// parsed or have no servers
+func loadNDJSONRecords(filename string) ([]*clusterStruct, int, error) {
+	file, err := openFile(filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("file '%s' not found: %v", filename, err)
+	}
+	defer file.Close()
+
+	loaded, skipped, err := mdbcore.LoadRecords(file)
+	var readErr *mdbcore.ReadError
+	if errors.As(err, &readErr) {
+		return nil, 0, fmt.Errorf("failed to read file '%s': %v", filename, readErr.Err)
+	}
+	if err != nil {
+		return nil, skipped, err
+	}
+	records := make([]*clusterStruct, len(loaded))
+	for i, record := range loaded {
+		records[i] = &clusterStruct{
+			Snapshot:    *record.Snapshot,
+			RecordIndex: i + 1,
+			RecordCount: len(loaded),
+			RecordLine:  record.Line,
+		}
+	}
+	return records, skipped, nil
+}
+
+// loadRecord loads the NDJSON record selected by --record, --latest or --at (the
+// first one by default) and, with --trend, the statistics of all records. A
+// single document is a series of one record, which has no trend.
+func loadRecord(config *Config) (*clusterStruct, error) {
+	before, err := os.Stat(config.JSONFile)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s' not found: %v", config.JSONFile, err)
+	}
+
+	var records []*clusterStruct
+	skipped := 0
+	single, attempts, err := decodeDocument(config.JSONFile)
+	switch {
+	case err != nil:
+	case single != nil && config.Trend:
+		err = fmt.Errorf("--trend needs an NDJSON file with a record per snapshot, '%s' is a single snapshot", config.JSONFile)
+	case single != nil:
+		records = []*clusterStruct{single}
+	case attempts[0].Format != mdbcore.FormatPlainJSON:
+		err = diagnoseFile(config.JSONFile, attempts)
+	default:
+		records, skipped, err = loadNDJSONRecords(config.JSONFile)
+	}
+
+	after, statErr := os.Stat(config.JSONFile)
+	if statErr == nil && (after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime())) {
+		return nil, errFileChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d NDJSON record(s) that could not be parsed\n", skipped)
+	}
+
+	// The last record was written when the file was last modified
+	if last := records[len(records)-1]; last.Timestamp.IsZero() {
+		last.Timestamp = before.ModTime()
+	}
+
+	selected, err := selectRecord(records, config.Record, config.Latest, config.At)
+	if err != nil {
+		return nil, err
+	}
+	if config.Trend || config.Project {
+		selected.Trend = make([]trendPoint, 0, len(records))
+		for _, record := range records {
+			selected.Trend = append(selected.Trend, newTrendPoint(record))
+		}
+	}
+	return selected, nil
+}
+
+// loadRecordRetry is loadRecord with the --retry-on-change behavior of loadJSONRetry
+func loadRecordRetry(config *Config) (*clusterStruct, error) {
+	infoStruct, err := loadRecord(config)
+	if config.RetryOnChange && errors.Is(err, errFileChanged) {
+		fmt.Fprintf(os.Stderr, "Warning: '%s' changed during read, retrying in %v\n", config.JSONFile, retryOnChangeDelay)
+		time.Sleep(retryOnChangeDelay)
+		infoStruct, err = loadRecord(config)
+	}
+	return infoStruct, err
+}
+
+// selectRecord picks record index (1-based) if it is set, the last record with
+// latest, the last record taken at or before at if it is set, and the first
+// record otherwise
+func selectRecord(records []*clusterStruct, index int, latest bool, at time.Time) (*clusterStruct, error) {
+	if index > 0 {
+		if index > len(records) {
+			return nil, fmt.Errorf("record %d is out of range, the file has %d records", index, len(records))
+		}
+		return records[index-1], nil
+	}
+	if latest {
+		return records[len(records)-1], nil
+	}
+	if at.IsZero() {
+		return records[0], nil
+	}
+
+	var selected *clusterStruct
+	for _, record := range records {
+		if !record.Timestamp.IsZero() && !record.Timestamp.After(at) && (selected == nil || !record.Timestamp.Before(selected.Timestamp)) {
+			selected = record
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("no record taken at or before %s", at.UTC().Format(time.RFC3339))
+	}
+	return selected, nil
+}
+
+// parseRecordTime parses an --at timestamp, RFC 3339 or "2006-01-02 15:04" in UTC
+func parseRecordTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a valid timestamp (use e.g. 2024-05-02T13:45:00Z)", value)
+}
+
+// formatRecordPosition describes which NDJSON record was analyzed, e.g.
+// "5 of 12 (2024-05-02 13:45 UTC)"
+func formatRecordPosition(infoStruct *clusterStruct) string {
+	position := fmt.Sprintf("%d of %d", infoStruct.RecordIndex, infoStruct.RecordCount)
+	if !infoStruct.Timestamp.IsZero() {
+		position += fmt.Sprintf(" (%s)", infoStruct.Timestamp.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	return position
+}
+
+// Levels of --validate findings
+const (
+	validateOK   = "OK"
+	validateWarn = "WARN"
+	validateFail = "FAIL"
+)
+
+// validationCheck is one finding of --validate
+type validationCheck struct {
+	Level   string
+	Message string
+}
+
+// validateFile loads filename like "mdb show" does and writes what was found,
+// and what is missing, to w. It fails if the file cannot be analyzed.
+func validateFile(w io.Writer, filename string, color bool) error {
+	// A gzip-compressed file is read decompressed, like "mdb show" reads it
+	input := &Config{JSONFile: filename}
+	if detectArchive(filename) == archiveGzip {
+		if err := decompressInput(input); err != nil {
+			return err
+		}
+		defer removeLocalInput(input)
+	}
+
+	var checks []validationCheck
+	infoStruct, err := loadJSON(input.JSONFile)
+	var loadErr *loadError
+	if errors.As(err, &loadErr) {
+		checks = append(checks, validationCheck{validateFail, strings.ToUpper(loadErr.Guess[:1]) + loadErr.Guess[1:]})
+		if loadErr.Syntax != "" {
+			checks = append(checks, validationCheck{validateFail, "First syntax error at " + loadErr.Syntax})
+		}
+	} else if err != nil {
+		checks = append(checks, validationCheck{validateFail, fmt.Sprintf("Loading failed: %v", err)})
+	} else {
+		checks = validateSnapshot(infoStruct)
+		if infoStruct.RecordCount > 0 {
+			if _, skipped, err := loadNDJSONRecords(input.JSONFile); err == nil && skipped > 0 {
+				checks = append(checks, validationCheck{validateWarn, fmt.Sprintf("%d NDJSON line(s) could not be parsed, the last record may be truncated", skipped)})
+			}
+		}
+	}
+
+	levelColors := map[string]string{validateOK: Green, validateWarn: Yellow, validateFail: Red}
+	failed, warnings := 0, 0
+	fmt.Fprintf(w, "Validating %s\n", filename)
+	for _, check := range checks {
+		level := fmt.Sprintf("%-4s", check.Level)
+		if color {
+			level = levelColors[check.Level] + level + Reset
+		}
+		fmt.Fprintf(w, "  %s  %s\n", level, check.Message)
+		switch check.Level {
+		case validateFail:
+			failed++
+		case validateWarn:
+			warnings++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("'%s' cannot be analyzed by mdb", filename)
+	}
+	fmt.Fprintf(w, "File is usable (%d warnings)\n", warnings)
+	return nil
+}
+
+// validateSnapshot checks a loaded snapshot for the gaps that make reports
+// incomplete: missing servers, drives or backend info, servers without drives,
+// holes in pool and set numbering and fields that are normally populated
+func validateSnapshot(infoStruct *clusterStruct) []validationCheck {
+	var checks []validationCheck
+	add := func(level, format string, args ...interface{}) {
+		checks = append(checks, validationCheck{level, fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case infoStruct.RecordCount > 0:
+		add(validateOK, "Format: NDJSON, %d records (record %d checked)", infoStruct.RecordCount, infoStruct.RecordIndex)
+	case infoStruct.Wrapped:
+		add(validateOK, "Format: \"minio\" wrapper (SUBNET diagnostics)")
+	default:
+		add(validateOK, "Format: plain (mc admin info --json)")
+	}
+
+	servers := infoStruct.Info.Servers
+	if infoStruct.Error != "" {
+		add(validateFail, "The collection reported an error: %s", infoStruct.Error)
+	}
+	if len(servers) == 0 {
+		add(validateFail, "No servers found, the file is valid JSON but not from `mc admin info --json` or a SUBNET diagnostics upload")
+		return checks
+	}
+
+	drives := 0
+	var noDrives []string
+	for _, server := range servers {
+		drives += len(server.Disks)
+		if len(server.Disks) == 0 {
+			noDrives = append(noDrives, fmt.Sprintf("%s (%s)", server.Endpoint, server.State))
+		}
+	}
+	if drives == 0 {
+		add(validateFail, "Found %d servers but no drives", len(servers))
+		return checks
+	}
+	add(validateOK, "Found %d servers and %d drives", len(servers), drives)
+	if len(noDrives) > 0 {
+		add(validateWarn, "%d server(s) list no drives: %s", len(noDrives), strings.Join(noDrives, ", "))
+	}
+
+	backend := infoStruct.Info.Backend
+	if !infoStruct.IsErasure() {
+		add(validateWarn, "Backend type %s is not erasure coded, erasure sets and usable capacity do not apply", infoStruct.BackendType())
+	} else if len(backend.TotalSets) > 0 || backend.StandardSCParity > 0 {
+		add(validateOK, "Backend info present (standard parity EC:%d)", backend.StandardSCParity)
+	} else if infoStruct.Missing[mdbcore.FieldBackend] > 0 {
+		add(validateWarn, "Backend info missing (no field %q), usable capacity assumes parity EC:%d", mdbcore.FieldBackend, mdbcore.DefaultParityDisks)
+	} else {
+		add(validateWarn, "Backend info missing, usable capacity assumes parity EC:%d", mdbcore.DefaultParityDisks)
+	}
+
+	// Older releases leave out the indices that place a drive in its set
+	for _, field := range []string{mdbcore.FieldPoolIndex, mdbcore.FieldSetIndex, mdbcore.FieldDiskIndex} {
+		if absent := infoStruct.Missing[field]; absent > 0 {
+			add(validateWarn, "Field %q is absent from %d of %d drives", field, absent, drives)
+		}
+	}
+	missingTopology := infoStruct.MissingTopology()
+	if missingTopology != "" {
+		add(validateWarn, "Topology information is missing: %s; erasure sets are not analyzed", missingTopology)
+	}
+
+	// Pools and the sets of each pool are numbered from 0 without gaps
+	poolSets := make(map[int]map[int]bool)
+	for _, server := range servers {
+		for _, disk := range server.Disks {
+			if poolSets[disk.PoolIndex] == nil {
+				poolSets[disk.PoolIndex] = make(map[int]bool)
+			}
+			poolSets[disk.PoolIndex][disk.SetIndex] = true
+		}
+	}
+	missing := func(indices map[int]bool) []string {
+		highest := -1
+		for idx := range indices {
+			if idx > highest {
+				highest = idx
+			}
+		}
+		var gaps []string
+		for idx := 0; idx <= highest; idx++ {
+			if !indices[idx] {
+				gaps = append(gaps, strconv.Itoa(idx))
+			}
+		}
+		return gaps
+	}
+	pools := make(map[int]bool, len(poolSets))
+	poolIndices := make([]int, 0, len(poolSets))
+	for pool := range poolSets {
+		pools[pool] = true
+		poolIndices = append(poolIndices, pool)
+	}
+	sort.Ints(poolIndices)
+	contiguous := true
+	if gaps := missing(pools); len(gaps) > 0 {
+		add(validateWarn, "Pool indices have gaps, no drives in pool %s", strings.Join(gaps, ", "))
+		contiguous = false
+	}
+	sets := 0
+	for _, pool := range poolIndices {
+		sets += len(poolSets[pool])
+		if gaps := missing(poolSets[pool]); len(gaps) > 0 {
+			add(validateWarn, "Pool %d has no drives in erasure set %s", pool, strings.Join(gaps, ", "))
+			contiguous = false
+		}
+	}
+	if contiguous && missingTopology == "" {
+		add(validateOK, "Pool and set indices are contiguous (%d pools, %d sets)", len(poolSets), sets)
+	}
+
+	// Fields that are zero here usually mean an incomplete collection
+	noUptime, noSpace := 0, 0
+	for _, server := range servers {
+		if server.State == "online" && server.Uptime == 0 {
+			noUptime++
+		}
+		for _, disk := range server.Disks {
+			if disk.State == "ok" && disk.TotalSpace == 0 {
+				noSpace++
+			}
+		}
+	}
+	if noUptime > 0 {
+		add(validateWarn, "%d online server(s) report no uptime", noUptime)
+	}
+	if noSpace > 0 {
+		add(validateWarn, "%d drive(s) in state ok report no total space, capacity figures will be low", noSpace)
+	}
+
+	// Drives of online servers have a UUID of their own
+	names := mdbcore.NewServerNamer(servers, "")
+	poolSetDrives := make(map[string][]DiskInfo)
+	for _, server := range servers {
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			key := mdbcore.SetKey(drive.PoolIndex, drive.SetIndex)
+			poolSetDrives[key] = append(poolSetDrives[key], drive)
+		}
+	}
+	uuids := checkDriveUUIDs(poolSetDrives, servers, names)
+	for _, group := range uuids.Duplicates {
+		members := make([]string, 0, len(group))
+		for _, drive := range group {
+			members = append(members, fmt.Sprintf("%s:%s (pool %d, set %d)", drive.Server, drive.Path, drive.PoolIndex, drive.SetIndex))
+		}
+		add(validateWarn, "UUID %s is shared by %d drives: %s", group[0].UUID, len(group), strings.Join(members, ", "))
+	}
+	if uuids.Empty > 0 {
+		add(validateWarn, "%d drive(s) of online servers have no UUID", uuids.Empty)
+	}
+	if len(uuids.Duplicates) == 0 && uuids.Empty == 0 {
+		add(validateOK, "Drive UUIDs are unique")
+	}
+
+	// Drive endpoints name the host of their server
+	mismatches, compared := mdbcore.CheckEndpointHosts(servers)
+	for _, m := range mismatches {
+		add(validateWarn, "Drive %s (pool %d, set %d) is listed under server %s on another host", m.DiskEndpoint, m.PoolIndex, m.SetIndex, m.ServerEndpoint)
+	}
+	if compared > 0 && len(mismatches) == 0 {
+		add(validateOK, "Drive endpoints are on their servers")
+	}
+	return checks
+}
+
+// trendPoint is the statistics of one record of an NDJSON time series
+type trendPoint struct {
+	Time  time.Time
+	Stats ClusterStats
+	Pools []mdbcore.PoolSpace // usable capacity and used space per pool, for --project
+}
+
+// newTrendPoint returns the statistics of a record or file for --trend and --project
+func newTrendPoint(infoStruct *clusterStruct) trendPoint {
+	return trendPoint{
+		Time:  infoStruct.Timestamp,
+		Stats: recordStats(infoStruct),
+		Pools: mdbcore.PoolSpaces(infoStruct.DrivesBySet(mdbcore.DriveFilter{}), infoStruct.ParityDisks()),
+	}
+}
+
+// recordStats counts the drives and space of a snapshot, for its report and
+// for each record of --trend
+func recordStats(infoStruct *clusterStruct) ClusterStats {
+	return ClusterStats{ClusterStats: infoStruct.ClusterStats()}
+}
+
+// defaultProjectAt are the used percentages of usable capacity --project
+// projects dates for without --project-at
+var defaultProjectAt = []float64{90, 100}
+
+// capacityProjection is the growth of used space between two snapshots
+type capacityProjection struct {
+	From, To time.Time     // when the snapshots were taken, zero if unknown
+	Span     time.Duration // time between the snapshots
+	Scopes   []projectionScope
+}
+
+// projectionScope is the cluster or one pool in a capacityProjection
+type projectionScope struct {
+	Name         string
+	Capacity     int64   // usable capacity in the later snapshot
+	Used         int64   // usable space used in the later snapshot
+	GrowthPerDay float64 // usable space used per day, NaN if the scope is new
+}
+
+// projectCapacity measures the growth of used space from first to last, for
+// the cluster and each pool of last. The time between them is --interval if
+// given, the time between their timestamps otherwise.
+func projectCapacity(first, last trendPoint, config *Config) (capacityProjection, error) {
+	projection := capacityProjection{From: first.Time, To: last.Time, Span: config.Interval}
+	if projection.Span == 0 {
+		if first.Time.IsZero() || last.Time.IsZero() {
+			return projection, fmt.Errorf("--project needs the time between the snapshots, which have no timestamps: give it with --interval (e.g. 7d)")
+		}
+		projection.Span = last.Time.Sub(first.Time)
+		if projection.Span <= 0 {
+			return projection, fmt.Errorf("--project needs snapshots taken at different times, both are from %s: give the time between them with --interval (e.g. 7d)", last.Time.UTC().Format("2006-01-02 15:04 UTC"))
+		}
+	} else if !last.Time.IsZero() {
+		projection.From = last.Time.Add(-projection.Span)
+	}
+	days := projection.Span.Hours() / 24
+
+	firstUsed := make(map[int]int64, len(first.Pools))
+	var firstTotal int64
+	for _, space := range first.Pools {
+		firstUsed[space.Pool] = space.Used
+		firstTotal += space.Used
+	}
+	cluster := projectionScope{Name: "cluster"}
+	var pools []projectionScope
+	for _, space := range last.Pools {
+		cluster.Capacity += space.Capacity
+		cluster.Used += space.Used
+		scope := projectionScope{Name: fmt.Sprintf("pool %d", space.Pool), Capacity: space.Capacity, Used: space.Used, GrowthPerDay: math.NaN()}
+		if used, ok := firstUsed[space.Pool]; ok {
+			scope.GrowthPerDay = float64(space.Used-used) / days
+		}
+		pools = append(pools, scope)
+	}
+	cluster.GrowthPerDay = float64(cluster.Used-firstTotal) / days
+	projection.Scopes = append([]projectionScope{cluster}, pools...)
+	return projection, nil
+}
+
+// printFileProjection prints the projection from the earliest to the latest of
+// several files, in the order given if some have no timestamp
+func printFileProjection(pager *Pager, results []fileResult, config *Config) error {
+	var points []trendPoint
+	timed := true
+	for _, result := range results {
+		if result.Err == nil {
+			points = append(points, newTrendPoint(result.Info))
+			timed = timed && !result.Info.Timestamp.IsZero()
+		}
+	}
+	if len(points) < 2 {
+		return fmt.Errorf("--project needs two snapshots that can be loaded")
+	}
+	if timed {
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Time.Before(points[j].Time)
+		})
+	}
+	projection, err := projectCapacity(points[0], points[len(points)-1], config)
+	if err != nil {
+		return err
+	}
+	printProjection(pager, projection, config)
+	return nil
+}
+
+// projectionWarnDays and projectionCritDays are the times from which a
+// projected date is shown in yellow and red
+const (
+	projectionWarnDays = 90
+	projectionCritDays = 30
+)
+
+// projectedDate describes when a scope reaches pct of its usable capacity
+func projectedDate(scope projectionScope, pct float64, to time.Time) string {
+	target := float64(scope.Capacity) * pct / 100
+	switch {
+	case math.IsNaN(scope.GrowthPerDay):
+		return "-"
+	case float64(scope.Used) >= target:
+		return Red + "reached" + Reset
+	case scope.GrowthPerDay <= 0:
+		return Green + "no exhaustion projected" + Reset
+	}
+	days := (target - float64(scope.Used)) / scope.GrowthPerDay
+	if days > 3650 {
+		return Green + "in more than 10 years" + Reset
+	}
+	text := fmt.Sprintf("in %.0f days", math.Ceil(days))
+	if !to.IsZero() {
+		text = to.Add(time.Duration(days*24*float64(time.Hour))).UTC().Format("2006-01-02") + " (" + text + ")"
+	}
+	switch {
+	case days < projectionCritDays:
+		return Red + text + Reset
+	case days < projectionWarnDays:
+		return Yellow + text + Reset
+	}
+	return text
+}
+
+// printProjection prints the daily growth of used space of the cluster and each
+// pool and the dates they reach the --project-at percentages
+func printProjection(pager *Pager, projection capacityProjection, config *Config) {
+	days := projection.Span.Hours() / 24
+	title := fmt.Sprintf("Capacity Projection (over %.1f days)", days)
+	if !projection.From.IsZero() && !projection.To.IsZero() {
+		title = fmt.Sprintf("Capacity Projection (%s → %s, %.1f days)",
+			projection.From.UTC().Format("2006-01-02 15:04"), projection.To.UTC().Format("2006-01-02 15:04 UTC"), days)
+	}
+	printSectionTitle(pager, config, title)
+
+	growth := func(perDay float64) string {
+		if math.IsNaN(perDay) {
+			return "-"
+		}
+		sign := "+"
+		if perDay < 0 {
+			sign = ""
+		}
+		return sign + formatSize(int64(math.Round(perDay)), config) + "/day"
+	}
+	headers := []string{"Scope", "Usable Capacity", "Used", "Growth"}
+	for _, pct := range config.ProjectAt {
+		headers = append(headers, strconv.FormatFloat(pct, 'f', -1, 64)+"%")
+	}
+	rows := make([][]string, 0, len(projection.Scopes))
+	for _, scope := range projection.Scopes {
+		usedPct := 0.0
+		if scope.Capacity > 0 {
+			usedPct = float64(scope.Used) / float64(scope.Capacity) * 100
+		}
+		row := []string{
+			scope.Name,
+			formatSize(scope.Capacity, config),
+			fmt.Sprintf("%s (%s%s%s)", formatSize(scope.Used, config), usageColor(usedPct, config), formatPct(usedPct, config), Reset),
+			growth(scope.GrowthPerDay),
+		}
+		for _, pct := range config.ProjectAt {
+			row = append(row, projectedDate(scope, pct, projection.To))
+		}
+		rows = append(rows, row)
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// sparklineWidth is the maximum number of records shown in a trend sparkline
+const sparklineWidth = 60
+
+// sparkline draws values as a row of block characters scaled between their minimum and maximum
+func sparkline(values []float64) string {
+	if len(values) > sparklineWidth {
+		values = values[len(values)-sparklineWidth:]
+	}
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		sb.WriteRune(blocks[level])
+	}
+	return sb.String()
+}
+
+// printTrend prints the evolution of used space, bad and scanning disks over
+// the records of an NDJSON file, with the change between the first and last record
+func printTrend(pager *Pager, points []trendPoint, config *Config) {
+	if len(points) == 0 {
+		return
+	}
+	first, last := points[0], points[len(points)-1]
+	title := fmt.Sprintf("Trend (%d records)", len(points))
+	if !first.Time.IsZero() && !last.Time.IsZero() {
+		title = fmt.Sprintf("Trend (%d records, %s → %s)", len(points),
+			first.Time.UTC().Format("2006-01-02 15:04"), last.Time.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	printSectionTitle(pager, config, title)
+
+	series := func(value func(ClusterStats) float64) []float64 {
+		values := make([]float64, len(points))
+		for i, point := range points {
+			values[i] = value(point.Stats)
+		}
+		return values
+	}
+	// Rising values are bad for every metric of the trend
+	change := func(delta float64, text string) string {
+		switch {
+		case delta > 0:
+			return Red + text + Reset
+		case delta < 0:
+			return Green + text + Reset
+		}
+		return text
+	}
+
+	usedPct := series(usableSpacePct)
+	usedBytes := series(func(stats ClusterStats) float64 { return float64(stats.UsedSpace) })
+	bad := series(func(stats ClusterStats) float64 { return float64(stats.BadDisks) })
+	scanning := series(func(stats ClusterStats) float64 { return float64(stats.ScanningDisks) })
+	n := len(points) - 1
+
+	usedDelta := last.Stats.UsedSpace - first.Stats.UsedSpace
+	usedSign := ""
+	if usedDelta >= 0 {
+		usedSign = "+"
+	}
+	rows := [][]string{
+		{"Used Space", formatSize(first.Stats.UsedSpace, config), formatSize(last.Stats.UsedSpace, config),
+			change(float64(usedDelta), usedSign+formatSize(usedDelta, config)), sparkline(usedBytes)},
+		{"Used %", formatPct(usedPct[0], config), formatPct(usedPct[n], config),
+			change(usedPct[n]-usedPct[0], signedPct(usedPct[n]-usedPct[0], config)), sparkline(usedPct)},
+		{"Bad Disks", fmt.Sprintf("%d", first.Stats.BadDisks), fmt.Sprintf("%d", last.Stats.BadDisks),
+			change(bad[n]-bad[0], fmt.Sprintf("%+d", last.Stats.BadDisks-first.Stats.BadDisks)), sparkline(bad)},
+		{"Scanning Disks", fmt.Sprintf("%d", first.Stats.ScanningDisks), fmt.Sprintf("%d", last.Stats.ScanningDisks),
+			change(scanning[n]-scanning[0], fmt.Sprintf("%+d", last.Stats.ScanningDisks-first.Stats.ScanningDisks)), sparkline(scanning)},
+	}
+	printTableRows(pager, config, []string{"Metric", "First", "Last", "Change", "Trend"}, rows)
+	pager.Printf("\n")
+}
+
+// unknownField is a field of the input file that is not decoded into the
+// structures mdb uses
+type unknownField struct {
+	Pointer string
+	Type    string
+	Example string
+}
+
+// unknownExampleLength is the maximum length of an example value of an unknown field
+const unknownExampleLength = 80
+
+// jsonFieldNames returns the lowercased JSON keys encoding/json decodes into t.
+// Keys are matched case-insensitively, like encoding/json does.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+// jsonPointerToken escapes a key for use in a JSON pointer (RFC 6901)
+func jsonPointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// jsonValueType returns the JSON type of a raw value
+func jsonValueType(value json.RawMessage) string {
+	trimmed := strings.TrimSpace(string(value))
+	if trimmed == "" {
+		return "unknown"
+	}
+	switch trimmed[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
+// jsonExample returns a compact example of a raw value, truncated to unknownExampleLength
+func jsonExample(value json.RawMessage) string {
+	example := strings.TrimSpace(string(value))
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err == nil {
+		example = buf.String()
+	}
+	if runes := []rune(example); len(runes) > unknownExampleLength {
+		return string(runes[:unknownExampleLength-3]) + "..."
+	}
+	return example
+}
+
+// findUnknownFields lists the top-level, info and per-server keys of a snapshot
+// that are not mapped into clusterStruct, sorted by JSON pointer. A key found
+// on several servers is reported once, at the first server that has it.
+func findUnknownFields(data []byte) []unknownField {
+	var unknown []unknownField
+	seen := make(map[string]bool)
+	check := func(prefix string, object map[string]json.RawMessage, known map[string]bool, dedupe string) {
+		for key, value := range object {
+			if known[strings.ToLower(key)] {
+				continue
+			}
+			if dedupe != "" {
+				if seen[dedupe+key] {
+					continue
+				}
+				seen[dedupe+key] = true
+			}
+			unknown = append(unknown, unknownField{
+				Pointer: prefix + "/" + jsonPointerToken(key),
+				Type:    jsonValueType(value),
+				Example: jsonExample(value),
+			})
+		}
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil
+	}
+	clusterKeys := jsonFieldNames(reflect.TypeOf(clusterStruct{}))
+
+	// Subnet diagnostics wrap the cluster info in a "minio" object
+	prefix := ""
+	if wrapped, ok := root["minio"]; ok && root["info"] == nil {
+		check("", root, map[string]bool{"minio": true}, "")
+		prefix = "/minio"
+		root = nil
+		if err := json.Unmarshal(wrapped, &root); err != nil {
+			return unknown
+		}
+	}
+	check(prefix, root, clusterKeys, "")
+
+	var info map[string]json.RawMessage
+	if err := json.Unmarshal(root["info"], &info); err != nil {
+		return sortUnknownFields(unknown)
+	}
+	infoKeys := jsonFieldNames(reflect.TypeOf(madmin.InfoMessage{}))
+	infoKeys["bucketsusageinfo"] = true // decoded into BucketsUsage
+	check(prefix+"/info", info, infoKeys, "")
+
+	var servers []map[string]json.RawMessage
+	if err := json.Unmarshal(info["servers"], &servers); err != nil {
+		return sortUnknownFields(unknown)
+	}
+	serverKeys := jsonFieldNames(reflect.TypeOf(madmin.ServerProperties{}))
+	for i, server := range servers {
+		check(fmt.Sprintf("%s/info/servers/%d", prefix, i), server, serverKeys, "server:")
+	}
+	return sortUnknownFields(unknown)
+}
+
+func sortUnknownFields(fields []unknownField) []unknownField {
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Pointer < fields[j].Pointer
+	})
+	return fields
+}
+
+// printUnknownFields prints the fields found by findUnknownFields
+func printUnknownFields(pager *Pager, fields []unknownField, config *Config) {
+	printSectionTitle(pager, config, "Unrecognized Snapshot Fields")
+	pager.Printf("================================================================================\n")
+
+	if len(fields) == 0 {
+		pager.Printf("%sAll fields of the snapshot are recognized.%s\n\n", Green, Reset)
+		return
+	}
+
+	rows := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		example := field.Example
+		if config.Anonymize {
+			example = "(hidden by --anonymize)"
+		}
+		rows = append(rows, []string{field.Pointer, field.Type, example})
+	}
+	printTableRows(pager, config, []string{"Pointer", "Type", "Example"}, rows)
+	pager.Printf("\n")
+}
+
+// defaultHealStuckAge is the --heal-stuck default
+const defaultHealStuckAge = 48 * time.Hour
+
+// healAgeColor is red for heals running longer than --heal-stuck, otherwise yellow.
+// A heal is stuck by the same rule as in findDriveProblems.
+func healAgeColor(drive DiskInfo, config *Config) string {
+	if drive.Healing && drive.HealAge != nil && *drive.HealAge > config.settings().HealStuckAge {
+		return Red
+	}
+	return Yellow
+}
+
+// scanningText is the Scanning column: "Yes, 14h" for healing drives with a
+// known start time, otherwise the plain Yes or No
+func scanningText(drive DiskInfo, config *Config) string {
+	if !drive.Scanning {
+		return fmt.Sprintf("%s%s%s", Green, boolToYesNo(false), Reset)
+	}
+	text := boolToYesNo(true)
+	if drive.HealAge != nil {
+		text += ", " + formatAge(*drive.HealAge)
+	}
+	return fmt.Sprintf("%s%s%s", healAgeColor(drive, config), text, Reset)
+}
+
+// scanningProgressText is the Scanning column with --scanning: "Yes (42%, started 3h ago)"
+// for healing drives that report progress, otherwise the plain Yes or No
+func scanningProgressText(drive DiskInfo, config *Config) string {
+	if !drive.Scanning {
+		return fmt.Sprintf("%s%s%s", Green, boolToYesNo(false), Reset)
+	}
+	var details []string
+	if pct, ok := mdbcore.HealProgressPct(drive.HealInfo); ok {
+		details = append(details, fmt.Sprintf("%.0f%%", pct))
+	}
+	if drive.HealAge != nil {
+		details = append(details, "started "+formatAgo(*drive.HealAge))
+	}
+	text := boolToYesNo(true)
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	return fmt.Sprintf("%s%s%s", healAgeColor(drive, config), text, Reset)
+}
+
+// longestHeal returns the drive that has been healing the longest, among the
+// drives whose heal start time is known
+func longestHeal(poolSetDrives map[string][]DiskInfo) (DiskInfo, bool) {
+	var longest DiskInfo
+	found := false
+	for _, drive := range sortedDrives(poolSetDrives) {
+		if !drive.Healing || drive.HealAge == nil {
+			continue
+		}
+		if !found || *drive.HealAge > *longest.HealAge {
+			longest = drive
+			found = true
+		}
+	}
+	return longest, found
+}
+
+// recentErrorWindow separates recent drive errors from historical counters
+const recentErrorWindow = time.Hour
+
+// driveLastErrorAge returns the age of the most recent error of any kind on the drive
+func driveLastErrorAge(drive DiskInfo) (time.Duration, bool) {
+	switch {
+	case drive.LastErrorAge != nil && drive.LastTimeoutAge != nil:
+		if *drive.LastTimeoutAge < *drive.LastErrorAge {
+			return *drive.LastTimeoutAge, true
+		}
+		return *drive.LastErrorAge, true
+	case drive.LastErrorAge != nil:
+		return *drive.LastErrorAge, true
+	case drive.LastTimeoutAge != nil:
+		return *drive.LastTimeoutAge, true
+	}
+	return 0, false
+}
+
+// formatAgo formats a duration as a short relative time such as "3m ago"
+func formatAgo(d time.Duration) string {
+	return formatAge(d) + " ago"
+}
+
+// formatAge formats a duration in its largest whole unit, such as "14h"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int64(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int64(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int64(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int64(d.Hours()/24))
+}
+
+// lastErrorText describes when the drive last had errors, e.g. "last timeout: 3m ago"
+func lastErrorText(drive DiskInfo) string {
+	parts := make([]string, 0, 2)
+	if drive.LastTimeoutAge != nil {
+		parts = append(parts, "last timeout: "+formatAgo(*drive.LastTimeoutAge))
+	}
+	if drive.LastErrorAge != nil {
+		parts = append(parts, "last error: "+formatAgo(*drive.LastErrorAge))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func getErasureCodingConfig(servers []madmin.ServerProperties) int {
+	// Try to determine from backend info if available in infoStruct
+	// For now, use standardSCParity if available, otherwise default
+	// This will be set from infoStruct.Info.Backend.StandardSCParity in mainMDB
+	return 2 // Default to EC-2, will be updated if available from backend
+}
+
+// Drive activity states reported in the Servers table
+const (
+	activityHealing  = "healing"
+	activityScanning = "scanning"
+	activityIdle     = "idle"
+)
+
+// driveActivity classifies a drive as healing, scanning or idle.
+// Healing takes precedence since a healing drive is also being scanned. Other
+// drives that are not ok, such as offline or faulty ones, are not idle but
+// have no activity at all and return "".
+func driveActivity(drive DiskInfo) string {
+	if drive.Healing {
+		return activityHealing
+	}
+	if drive.State != "ok" {
+		return ""
+	}
+	if drive.ScannerActive {
+		return activityScanning
+	}
+	return activityIdle
+}
+
+func getString(m map[string]interface{}, key string, defaultValue string) string {
+	if val, ok := m[key].(string); ok {
+		return val
+	}
+	return defaultValue
+}
+
+func getInt(m map[string]interface{}, key string, defaultValue int) int {
+	switch v := m[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	}
+	return defaultValue
+}
+
+func getInt64(m map[string]interface{}, key string, defaultValue int64) int64 {
+	switch v := m[key].(type) {
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return defaultValue
+}
+
+func getBool(m map[string]interface{}, key string, defaultValue bool) bool {
+	if val, ok := m[key].(bool); ok {
+		return val
+	}
+	return defaultValue
+}
+
+func printClusterSummary(pager *Pager, stats ClusterStats, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, servers []madmin.ServerProperties, infoStruct *clusterStruct, config *Config) {
+	printSectionTitle(pager, config, "Summary")
+
+	if config.Grep != nil {
+		pager.Printf("  Row filter: --grep %q (the summary is not filtered)\n", config.GrepPattern)
+	}
+
+	pager.Printf("  Snapshot taken: %s\n", formatSnapshotTaken(stats.Snapshot, timeNow()))
+	if infoStruct != nil && infoStruct.RecordCount > 0 {
+		pager.Printf("  Record: %s\n", formatRecordPosition(infoStruct))
+	}
+
+	if stats.DeploymentID != "" {
+		pager.Printf("  Deployment ID: %s\n", stats.DeploymentID)
+	} else {
+		pager.Printf("  Deployment ID: Not available\n")
+	}
+
+	printSnapshotPerspective(pager, servers, config)
+
+	// Backend configuration
+	if infoStruct != nil && infoStruct.BackendType() != "" {
+		backendColor := ""
+		if !infoStruct.IsErasure() {
+			backendColor = Yellow
+		}
+		pager.Printf("  Backend Type: %s%s%s\n", backendColor, infoStruct.BackendType(), Reset)
+	}
+	if infoStruct != nil && len(infoStruct.Info.Backend.TotalSets) > 0 {
+		totalSetsStr := fmt.Sprintf("%v", infoStruct.Info.Backend.TotalSets)
+		pager.Printf("  Backend: totalSets=%s, standardSCParity=%d, rrSCParity=%d, drivesPerSet=%v\n",
+			totalSetsStr, infoStruct.Info.Backend.StandardSCParity, infoStruct.Info.Backend.RRSCParity, infoStruct.Info.Backend.DrivesPerSet)
+	}
+
+	pager.Printf("\n")
+
+	pager.Printf("  Total Disks: %d\n", stats.TotalDisks)
+	pager.Printf("  Scanning Disks: %s%d%s\n", Yellow, stats.ScanningDisks, Reset)
+	if drive, ok := longestHeal(poolSetDrives); ok {
+		// Drives without a disk index are named by pool and set alone
+		where := fmt.Sprintf("pool %d set %d", drive.PoolIndex, drive.SetIndex)
+		if !drive.NoDiskIndex {
+			where += fmt.Sprintf(" disk %d", drive.DiskIndex)
+		}
+		pager.Printf("  Longest running heal: %s%s%s (%s)\n", healAgeColor(drive, config), formatAge(*drive.HealAge), Reset, where)
+	}
+	pager.Printf("  Healthy Disks: %s%d%s\n", Green, stats.OkDisks, Reset)
+	pager.Printf("  Problem Disks: %s%d%s\n", Red, stats.BadDisks, Reset)
+	if states := driveStateCounts(poolSetDrives); len(states) > 0 {
+		pager.Printf("  Drive States: %s\n", formatStateCounts(states))
+	}
+
+	if stats.TotalDisks > 0 {
+		healthPct := float64(stats.OkDisks) / float64(stats.TotalDisks) * 100
+		var healthColor string
+		if healthPct >= 90 {
+			healthColor = Green
+		} else if healthPct >= 75 {
+			healthColor = Yellow
+		} else {
+			healthColor = Red
+		}
+		pager.Printf("  Health: %s%s%s\n", healthColor, formatPct(healthPct, config), Reset)
+	}
+	if stats.HealthGrade != "" {
+		pager.Printf("  Health Grade: %s%s%s\n", gradeColor(stats.HealthGrade), stats.HealthGrade, Reset)
+	}
+	if len(stats.History) > 1 {
+		pager.Printf("  Trend: %s\n", formatHealthTrend(stats.History))
+	}
+
+	erasure := infoStruct == nil || infoStruct.IsErasure()
+	if stats.TotalSpace > 0 && !erasure {
+		// Without erasure coding all raw space is usable
+		usagePct := float64(stats.UsedSpace) / float64(stats.TotalSpace) * 100
+		pager.Printf("  Raw Capacity: %s\n", exactSize(stats.TotalSpace, config))
+		pager.Printf("  Used Space: %s (%s%s%s)\n", exactSize(stats.UsedSpace, config), usageColor(usagePct, config), formatPct(usagePct, config), Reset)
+		pager.Printf("  Available Space: %s\n", exactSize(stats.FreeSpace, config))
+	} else if stats.TotalSpace > 0 {
+		totalUsableSpace := stats.UsableSpace
+		usagePct := float64(stats.UsedSpace) / float64(totalUsableSpace) * 100
+		if totalUsableSpace == 0 {
+			usagePct = 0
+		}
+
+		pager.Printf("  Raw Capacity: %s\n", exactSize(stats.TotalSpace, config))
+		pager.Printf("  Usable Capacity: %s\n", exactSize(totalUsableSpace, config))
+		if infoStruct != nil {
+			printStorageClassUsable(pager, pools, poolSetDrives, stats.ParityDisks, infoStruct.RRSParityDisks(), config)
+		}
+		pager.Printf("  Used Space: %s (%s%s%s)\n", exactSize(stats.UsedSpace, config), usageColor(usagePct, config), formatPct(usagePct, config), Reset)
+		pager.Printf("  Available Space: %s\n", exactSize(totalUsableSpace-stats.UsedSpace, config))
+	}
+
+	// Heal risk and set counts need the sets the drives belong to
+	sets := erasure && (infoStruct == nil || infoStruct.MissingTopology() == "")
+	printInodePressure(pager, poolSetDrives, config)
+	if sets {
+		printHealRiskSummary(pager, poolSetDrives, stats.ParityDisks)
+	}
+	printIOErrorSummary(pager, poolSetDrives)
+	printUnaccountedSummary(pager, poolSetDrives, config)
+	printSaturatedDrives(pager, poolSetDrives, config)
+	printUUIDWarnings(pager, stats.UUIDs)
+
+	if sets {
+		pager.Printf("  Pools: %d\n", len(pools))
+	}
+	pager.Printf("  Servers: %d\n", len(servers))
+	if editions := serverEditions(servers); len(editions) > 0 {
+		pager.Printf("  Edition: %s\n", editionSummary(editions))
+	}
+	for _, license := range licenseSummaries(servers, timeNow()) {
+		pager.Printf("  License: %s\n", license)
+	}
+
+	if sets {
+		totalErasureSets := 0
+		for _, sets := range pools {
+			totalErasureSets += len(sets)
+		}
+		pager.Printf("  Erasure Sets: %d\n", totalErasureSets)
+	}
+	pager.Printf("  %s\n", ilmExpirySummary(stats))
+
+	// Scanner status
+	if infoStruct != nil {
+		pager.Printf("  Scanner Status: buckets=%d, objects=%d, versions=%d, deletemarkers=%d, usage=%s\n",
+			infoStruct.Info.Buckets.Count, infoStruct.Info.Objects.Count,
+			infoStruct.Info.Versions.Count, infoStruct.Info.DeleteMarkers.Count,
+			formatSize(int64(infoStruct.Info.Usage.Size), config))
+	}
+
+	pager.Printf("\n")
+
+	if config.Verbose && len(stats.History) > 0 {
+		printHealthHistory(pager, stats.History, config)
+	}
+}
+
+// usableSpacePct returns the used space as a percentage of the usable capacity
+func usableSpacePct(stats ClusterStats) float64 {
+	if stats.UsableSpace == 0 {
+		return 0
+	}
+	return float64(stats.UsedSpace) / float64(stats.UsableSpace) * 100
+}
+
+// computeHealthGrade grades the cluster from A (healthy) to F (data unavailable).
+// The grade is driven by the erasure set closest to losing quorum and is capped
+// at B above 80% and at C above 95% usable capacity used.
+func computeHealthGrade(stats ClusterStats, poolSetDrives map[string][]DiskInfo, servers []madmin.ServerProperties) string {
+	maxBad := 0
+	for _, drives := range poolSetDrives {
+		bad := 0
+		for _, drive := range drives {
+			if drive.State != "ok" {
+				bad++
+			}
+		}
+		if bad > maxBad {
+			maxBad = bad
+		}
+	}
+
+	offlineServers := 0
+	for _, server := range servers {
+		if server.State != "online" {
+			offlineServers++
+		}
+	}
+
+	grade := "A"
+	switch {
+	case maxBad > stats.ParityDisks:
+		grade = "F"
+	case maxBad == stats.ParityDisks:
+		grade = "D"
+	case maxBad > stats.ParityDisks/2:
+		grade = "C"
+	case maxBad > 0 || offlineServers > 0:
+		grade = "B"
+	}
+
+	usedPct := usableSpacePct(stats)
+	if usedPct >= 95 && grade < "C" {
+		grade = "C"
+	} else if usedPct >= 80 && grade < "B" {
+		grade = "B"
+	}
+	return grade
+}
+
+// gradeColor returns the color used to display a health grade
+func gradeColor(grade string) string {
+	switch grade {
+	case "A":
+		return Green
+	case "B", "C":
+		return Yellow
+	}
+	return Red
+}
+
+func printFailedDisksTable(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	allFailedDrives := make([]DiskInfo, 0)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if drive.State != "ok" {
+				allFailedDrives = append(allFailedDrives, drive)
+			}
+		}
+	}
+
+	if len(allFailedDrives) == 0 {
+		pager.Printf("%sNo failed/faulty disks found in the provided data.%s\n", Yellow, Reset)
+		return
+	}
+
+	// Sort drives with recent errors first (most recent on top), then by pool, set, disk index
+	sort.Slice(allFailedDrives, func(i, j int) bool {
+		ageI, okI := driveLastErrorAge(allFailedDrives[i])
+		ageJ, okJ := driveLastErrorAge(allFailedDrives[j])
+		recentI := okI && ageI <= recentErrorWindow
+		recentJ := okJ && ageJ <= recentErrorWindow
+		if recentI != recentJ {
+			return recentI
+		}
+		if recentI && ageI != ageJ {
+			return ageI < ageJ
+		}
+		return driveLess(allFailedDrives[i], allFailedDrives[j])
+	})
+
+	printSectionTitle(pager, config, fmt.Sprintf("MinIO Failed/Faulty Disks from: %s", inputName(config)))
+	pager.Printf("================================================================================\n")
+
+	printTable(pager, allFailedDrives, config)
+}
+
+// printLowSpaceDrives prints the drives with less free space than threshold, fullest first.
+// Drives that report no capacity are skipped.
+func printLowSpaceDrives(pager *Pager, poolSetDrives map[string][]DiskInfo, threshold float64, config *Config) {
+	lowSpaceDrives := make([]DiskInfo, 0)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if drive.TotalSpace > 0 && drive.FreeSpacePct < threshold {
+				lowSpaceDrives = append(lowSpaceDrives, drive)
+			}
+		}
+	}
+
+	// With --failed the drives are the failed drives with low space
+	drivesName := "Drives"
+	if config.FailedMode {
+		drivesName = "Failed Drives"
+	}
+	if len(lowSpaceDrives) == 0 {
+		pager.Printf("%sNo %s found with free space less than %.1f%%.%s\n", Yellow, strings.ToLower(drivesName), threshold, Reset)
+		return
+	}
+
+	// Sort by free space ascending, then by pool, set, disk index
+	sort.Slice(lowSpaceDrives, func(i, j int) bool {
+		if lowSpaceDrives[i].FreeSpacePct != lowSpaceDrives[j].FreeSpacePct {
+			return lowSpaceDrives[i].FreeSpacePct < lowSpaceDrives[j].FreeSpacePct
+		}
+		return driveLess(lowSpaceDrives[i], lowSpaceDrives[j])
+	})
+
+	printSectionTitle(pager, config, fmt.Sprintf("%s with Free Space < %.1f%% (sorted by free space)", drivesName, threshold))
+	pager.Printf("================================================================================\n")
+
+	printTable(pager, lowSpaceDrives, config)
+	pager.Printf("\n")
+}
+
+// defaultInodeThreshold is the inode usage percentage above which a drive is
+// under inode pressure, used by the summary and by a bare --inodes
+const defaultInodeThreshold = 80.0
+
+// inodeUsagePct returns the inode usage of a drive. Drives that report no inodes
+// at all (some filesystems) have no meaningful usage and return false.
+func inodeUsagePct(drive DiskInfo) (float64, bool) {
+	totalInodes := drive.UsedInodes + drive.FreeInodes
+	if totalInodes <= 0 {
+		return 0, false
+	}
+	return float64(drive.UsedInodes) / float64(totalInodes) * 100, true
+}
+
+// printStorageClassUsable prints the usable capacity under the STANDARD and
+// REDUCED_REDUNDANCY parities, and warns when reduced redundancy keeps as much
+// parity or more. Snapshots without a reduced redundancy parity print nothing.
+func printStorageClassUsable(pager *Pager, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, standard, rrs int, config *Config) {
+	if rrs <= 0 {
+		return
+	}
+	pager.Printf("  Usable (STANDARD, EC:%d): %s; Usable (REDUCED_REDUNDANCY, EC:%d): %s\n",
+		standard, exactSize(mdbcore.UsableSpace(pools, poolSetDrives, standard), config),
+		rrs, exactSize(mdbcore.UsableSpace(pools, poolSetDrives, rrs), config))
+	if rrs >= standard {
+		pager.Printf("  %sREDUCED_REDUNDANCY parity EC:%d is not below STANDARD EC:%d, reduced redundancy saves no capacity%s\n", Yellow, rrs, standard, Reset)
+	}
+}
+
+// printStorageClassCapacity prints the usable capacity of every pool under
+// the STANDARD and REDUCED_REDUNDANCY parities. A single pool is covered by
+// the summary line of printStorageClassUsable. With --bars or --pool-status
+// the table is printed for any number of pools and gains the used space of
+// every pool, with --pool-status also its decommission or rebalance state.
+func printStorageClassCapacity(pager *Pager, poolSetDrives map[string][]DiskInfo, standard, rrs int, pools map[int]poolState, config *Config) {
+	standardSpaces := mdbcore.PoolSpaces(poolSetDrives, standard)
+	showRRS := rrs > 0 && len(standardSpaces) >= 2
+	showUsed := pools != nil || config.Bars
+	if !showRRS && !showUsed {
+		return
+	}
+	rrsCapacity := make(map[int]int64)
+	if showRRS {
+		for _, space := range mdbcore.PoolSpaces(poolSetDrives, rrs) {
+			rrsCapacity[space.Pool] = space.Capacity
+		}
+	}
+	raw := make(map[int]int64)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			raw[drive.PoolIndex] += drive.TotalSpace
+		}
+	}
+
+	printSectionTitle(pager, config, "Storage Class Capacity")
+	headers := []string{"Pool", "Raw Capacity", fmt.Sprintf("STANDARD (EC:%d)", standard)}
+	if showRRS {
+		headers = append(headers, fmt.Sprintf("REDUCED_REDUNDANCY (EC:%d)", rrs), "Difference")
+	}
+	if showUsed {
+		headers = append(headers, "Used", "Used %")
+	}
+	if pools != nil {
+		headers = append(headers, "Status")
+	}
+	rows := make([][]string, 0, len(standardSpaces)+1)
+	var totalRaw, totalStandard, totalRRS, totalUsed int64
+	difference := func(standard, rrs int64) string {
+		if rrs < standard {
+			return "-" + formatSize(standard-rrs, config)
+		}
+		return "+" + formatSize(rrs-standard, config)
+	}
+	usedPctText := func(used, capacity int64) string {
+		usedPct := 0.0
+		if capacity > 0 {
+			usedPct = float64(used) / float64(capacity) * 100
+		}
+		if config.Bars {
+			return usageBar(usedPct, config)
+		}
+		return formatPct(usedPct, config)
+	}
+	decommissioning, rebalancing := 0, 0
+	for _, space := range standardSpaces {
+		row := []string{
+			fmt.Sprintf("%s%d%s", Blue, space.Pool, Reset),
+			formatSize(raw[space.Pool], config),
+			formatSize(space.Capacity, config),
+		}
+		if showRRS {
+			row = append(row, formatSize(rrsCapacity[space.Pool], config), difference(space.Capacity, rrsCapacity[space.Pool]))
+		}
+		if showUsed {
+			row = append(row, formatSize(space.Used, config), usedPctText(space.Used, space.Capacity))
+		}
+		if pools != nil {
+			state := pools[space.Pool]
+			status := state.text()
+			switch {
+			case state.Decommission != nil && state.Decommission.Failed:
+				status = Red + status + Reset
+			case state.draining():
+				if state.rebalancing() && state.Decommission == nil {
+					rebalancing++
+				} else {
+					decommissioning++
+				}
+				status = Yellow + status + Reset
+			case state.Decommission != nil && state.Decommission.Complete:
+				status = Green + status + Reset
+			}
+			row = append(row, status)
+		}
+		rows = append(rows, row)
+		totalRaw += raw[space.Pool]
+		totalStandard += space.Capacity
+		totalRRS += rrsCapacity[space.Pool]
+		totalUsed += space.Used
+	}
+	if len(standardSpaces) > 1 {
+		total := []string{"Total", formatSize(totalRaw, config), formatSize(totalStandard, config)}
+		if showRRS {
+			total = append(total, formatSize(totalRRS, config), difference(totalStandard, totalRRS))
+		}
+		if showUsed {
+			total = append(total, formatSize(totalUsed, config), usedPctText(totalUsed, totalStandard))
+		}
+		if pools != nil {
+			total = append(total, "")
+		}
+		rows = append(rows, total)
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+	if decommissioning > 0 {
+		pager.Printf("  %s%s being decommissioned%s\n\n", Yellow, countNoun(decommissioning, "pool"), Reset)
+	}
+	if rebalancing > 0 {
+		pager.Printf("  %s%s being rebalanced%s\n\n", Yellow, countNoun(rebalancing, "pool"), Reset)
+	}
+}
+
+// printInodePressure prints how many drives are above defaultInodeThreshold inode
+// usage, colored by the fullest drive. Nothing is printed if no drive reports inodes.
+func printInodePressure(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	reporting, above := 0, 0
+	maxPct := 0.0
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			pct, ok := inodeUsagePct(drive)
+			if !ok {
+				continue
+			}
+			reporting++
+			if pct > defaultInodeThreshold {
+				above++
+			}
+			maxPct = math.Max(maxPct, pct)
+		}
+	}
+	if reporting == 0 {
+		return
+	}
+
+	pressureColor := Green
+	if above > 0 {
+		pressureColor = inodeColor(maxPct, config)
+	}
+	pager.Printf("  Inode pressure: %s%d drives above %.0f%%%s\n", pressureColor, above, defaultInodeThreshold, Reset)
+}
+
+// printHighInodeDrives prints the drives whose inode usage exceeds threshold, highest first
+func printHighInodeDrives(pager *Pager, poolSetDrives map[string][]DiskInfo, threshold float64, config *Config) {
+	highInodeDrives := make([]DiskInfo, 0)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if pct, ok := inodeUsagePct(drive); ok && pct > threshold {
+				highInodeDrives = append(highInodeDrives, drive)
+			}
+		}
+	}
+
+	if len(highInodeDrives) == 0 {
+		pager.Printf("%sNo drives found with inode usage above %.1f%%.%s\n", Green, threshold, Reset)
+		return
+	}
+
+	// Sort by inode usage descending, then by pool, set, disk index
+	sort.Slice(highInodeDrives, func(i, j int) bool {
+		pctI, _ := inodeUsagePct(highInodeDrives[i])
+		pctJ, _ := inodeUsagePct(highInodeDrives[j])
+		if pctI != pctJ {
+			return pctI > pctJ
+		}
+		return driveLess(highInodeDrives[i], highInodeDrives[j])
+	})
+
+	printSectionTitle(pager, config, fmt.Sprintf("Drives with Inode Usage > %.1f%% (sorted by inode usage)", threshold))
+	pager.Printf("================================================================================\n")
+
+	printTable(pager, highInodeDrives, config)
+	pager.Printf("\n")
+}
+
+// unaccountedSpace returns the space of a drive that is neither used nor
+// available, Total - Used - Available, and its share of the total. MinIO plans
+// capacity with the used and available space, so space taken by another tenant
+// of a shared filesystem, or reserved by it, is unaccounted. Drives that report
+// no capacity return false.
+func unaccountedSpace(drive DiskInfo) (int64, float64, bool) {
+	if drive.TotalSpace <= 0 {
+		return 0, 0, false
+	}
+	unaccounted := max(drive.TotalSpace-drive.UsedSpace-drive.AvailableSpace, 0)
+	return unaccounted, float64(unaccounted) / float64(drive.TotalSpace) * 100, true
+}
+
+// unaccountedText formats the unaccounted space of a drive with its share,
+// yellow above --warn-unaccounted
+func unaccountedText(drive DiskInfo, config *Config) string {
+	unaccounted, pct, ok := unaccountedSpace(drive)
+	if !ok {
+		return "N/A"
+	}
+	color := Green
+	if pct > config.settings().Thresholds.WarnUnaccounted {
+		color = Yellow
+	}
+	return fmt.Sprintf("%s (%s%s%s)", formatDriveSize(unaccounted, config), color, formatPct(pct, config), Reset)
+}
+
+// unaccountedAbove reports whether the unaccounted space of a drive is above --warn-unaccounted
+func unaccountedAbove(drive DiskInfo, config *Config) bool {
+	_, pct, ok := unaccountedSpace(drive)
+	return ok && pct > config.settings().Thresholds.WarnUnaccounted
+}
+
+// printUnaccountedSummary prints how many drives have more unaccounted space
+// than --warn-unaccounted and how much in total. Nothing is printed if none has.
+func printUnaccountedSummary(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	above := 0
+	var total int64
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if unaccountedAbove(drive, config) {
+				unaccounted, _, _ := unaccountedSpace(drive)
+				above++
+				total += unaccounted
+			}
+		}
+	}
+	if above > 0 {
+		pager.Printf("  Unaccounted space: %s%s above %g%%%s (%s neither used nor available, see --unaccounted)\n",
+			Yellow, countNoun(above, "drive"), config.settings().Thresholds.WarnUnaccounted, Reset, formatSize(total, config))
+	}
+}
+
+// printUnaccountedDrives prints the drives with more unaccounted space than
+// --warn-unaccounted, most unaccounted space first
+func printUnaccountedDrives(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	drives := make([]DiskInfo, 0)
+	for _, set := range poolSetDrives {
+		for _, drive := range set {
+			if unaccountedAbove(drive, config) {
+				drives = append(drives, drive)
+			}
+		}
+	}
+
+	if len(drives) == 0 {
+		pager.Printf("%sNo drives found with unaccounted space above %g%%.%s\n", Green, config.settings().Thresholds.WarnUnaccounted, Reset)
+		return
+	}
+
+	sort.Slice(drives, func(i, j int) bool {
+		_, pctI, _ := unaccountedSpace(drives[i])
+		_, pctJ, _ := unaccountedSpace(drives[j])
+		if pctI != pctJ {
+			return pctI > pctJ
+		}
+		return driveLess(drives[i], drives[j])
+	})
+
+	printSectionTitle(pager, config, fmt.Sprintf("Drives with Unaccounted Space > %g%% (sorted by unaccounted space)", config.settings().Thresholds.WarnUnaccounted))
+	pager.Printf("================================================================================\n")
+
+	headers := []string{"Server", "Disk Path", "Pool", "Erasure Set", "Total Space", "Space Used", "Free Space", "Unaccounted"}
+	rows := make([][]string, 0, len(drives))
+	for _, drive := range drives {
+		rows = append(rows, []string{
+			drive.Server,
+			drive.Path,
+			fmt.Sprintf("%s%d%s", Blue, drive.PoolIndex, Reset),
+			fmt.Sprintf("%s%d%s", Blue, drive.SetIndex, Reset),
+			formatDriveSize(drive.TotalSpace, config),
+			fmt.Sprintf("%s (%s)", formatDriveSize(drive.UsedSpace, config), formatPct(drive.UsedSpacePct, config)),
+			fmt.Sprintf("%s (%s)", formatDriveSize(drive.AvailableSpace, config), formatPct(drive.FreeSpacePct, config)),
+			unaccountedText(drive, config),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// driveErrorCount returns the I/O error count of a drive. Availability errors
+// include timeouts, but the larger counter is used in case a server reports otherwise.
+func driveErrorCount(drive DiskInfo) uint64 {
+	if drive.Metrics == nil {
+		return 0
+	}
+	return max(drive.Metrics.TotalErrorsAvailability, drive.Metrics.TotalErrorsTimeout)
+}
+
+// printSnapshotPerspective notes which node a snapshot was taken through when
+// servers list remote drives, which explains missing metrics of those drives.
+// Nothing is printed when every online server reports its own drives.
+func printSnapshotPerspective(pager *Pager, servers []madmin.ServerProperties, config *Config) {
+	remote, perspective := mdbcore.CheckDriveLocality(servers)
+	if len(remote) == 0 {
+		return
+	}
+	if perspective != "" {
+		names := serverNamer(servers, config)
+		pager.Printf("  Snapshot perspective: %s%s%s (the only server with local drives; the drives of %s are remote and may lack metrics)\n",
+			Yellow, names.Name(perspective), Reset, countNoun(len(remote), "other server"))
+		return
+	}
+	pager.Printf("  Remote drives: %s%s%s list drives as remote, their metrics may be missing\n", Yellow, countNoun(len(remote), "server"), Reset)
+}
+
+// printHealRiskSummary prints how many erasure sets are at risk during heal.
+// Nothing is printed if none is.
+func printHealRiskSummary(pager *Pager, poolSetDrives map[string][]DiskInfo, parity int) {
+	atRisk := 0
+	for _, drives := range poolSetDrives {
+		if scanning, bad := healLoad(drives); atRiskDuringHeal(scanning, bad, parity) {
+			atRisk++
+		}
+	}
+	if atRisk > 0 {
+		pager.Printf("  Sets at risk during heal: %s%d%s (scanning and bad drives reach parity EC:%d)\n", Red, atRisk, Reset, parity)
+	}
+}
+
+// printIOErrorSummary prints how many drives have I/O errors. Nothing is printed
+// if no drive has any.
+func printIOErrorSummary(pager *Pager, poolSetDrives map[string][]DiskInfo) {
+	erroring := 0
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if driveErrorCount(drive) > 0 {
+				erroring++
+			}
+		}
+	}
+	if erroring > 0 {
+		pager.Printf("  Drives with I/O errors: %s%d%s\n", Red, erroring, Reset)
+	}
+}
+
+// printErrorDrives prints the drives with at least threshold I/O errors, those
+// with recent errors first, then most errors first
+func printErrorDrives(pager *Pager, poolSetDrives map[string][]DiskInfo, threshold uint64, config *Config) {
+	errorDrives := make([]DiskInfo, 0)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			if driveErrorCount(drive) >= threshold {
+				errorDrives = append(errorDrives, drive)
+			}
+		}
+	}
+
+	if len(errorDrives) == 0 {
+		if threshold > 1 {
+			pager.Printf("%sNo drives found with at least %d I/O errors.%s\n", Green, threshold, Reset)
+		} else {
+			pager.Printf("%sNo drives found with I/O errors.%s\n", Green, Reset)
+		}
+		return
+	}
+
+	// Drives failing now come before larger historical counters, like in the
+	// failed drives table, then by error count descending and pool, set, disk index
+	sort.Slice(errorDrives, func(i, j int) bool {
+		ageI, okI := driveLastErrorAge(errorDrives[i])
+		ageJ, okJ := driveLastErrorAge(errorDrives[j])
+		recentI := okI && ageI <= recentErrorWindow
+		recentJ := okJ && ageJ <= recentErrorWindow
+		if recentI != recentJ {
+			return recentI
+		}
+		errorsI, errorsJ := driveErrorCount(errorDrives[i]), driveErrorCount(errorDrives[j])
+		if errorsI != errorsJ {
+			return errorsI > errorsJ
+		}
+		return driveLess(errorDrives[i], errorDrives[j])
+	})
+
+	title := "Drives with I/O Errors (recent first, sorted by error count)"
+	if threshold > 1 {
+		title = fmt.Sprintf("Drives with at least %d I/O Errors (recent first, sorted by error count)", threshold)
+	}
+	printSectionTitle(pager, config, title)
+	pager.Printf("================================================================================\n")
+
+	// Availability errors include timeouts, so the counters are shown apart
+	headers := []string{"Server", "Disk Path", "Pool", "Erasure Set", "Availability Errors", "Timeout Errors", "Last Error"}
+	rows := make([][]string, 0, len(errorDrives))
+	for _, drive := range errorDrives {
+		// Errors older than recentErrorWindow are historical counters
+		errorsColor := Red
+		if age, ok := driveLastErrorAge(drive); ok && age > recentErrorWindow {
+			errorsColor = Yellow
+		}
+		errorText := func(n uint64) string {
+			if n == 0 {
+				return "0"
+			}
+			return fmt.Sprintf("%s%s%s", errorsColor, formatInt(int64(n)), Reset)
+		}
+		lastError := lastErrorText(drive)
+		if lastError == "" {
+			lastError = "unknown"
+		}
+		rows = append(rows, []string{
+			drive.Server,
+			drive.Path,
+			fmt.Sprintf("%s%d%s", Blue, drive.PoolIndex, Reset),
+			fmt.Sprintf("%s%d%s", Blue, drive.SetIndex, Reset),
+			errorText(drive.Metrics.TotalErrorsAvailability),
+			errorText(drive.Metrics.TotalErrorsTimeout),
+			lastError,
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+func printLowSpaceErasureSets(pager *Pager, poolSetDrives map[string][]DiskInfo, threshold float64, config *Config) {
+	erasureSets := lowSpaceErasureSets(poolSetDrives, threshold)
+
+	// CSV has only the table, with its header even without sets
+	if config.Format == formatCSV {
+		rows := make([][]string, 0, len(erasureSets))
+		for _, es := range erasureSets {
+			rows = append(rows, []string{
+				strconv.Itoa(es.PoolIdx), strconv.Itoa(es.SetIdx), strconv.Itoa(len(es.Drives)),
+				strconv.Itoa(es.Good), strconv.Itoa(es.Bad), strconv.Itoa(es.Scanning),
+				formatPct(es.AvgSpaceUsedPct, config), formatPct(es.AvgFreeSpacePct, config), formatPct(es.AvgInodesUsedPct, config),
+			})
+		}
+		printTableRows(pager, config, []string{"Pool", "Erasure Set", "Drives", "Good", "Bad", "Scanning", "Avg Space Used", "Avg Free Space", "Avg Inodes Used"}, rows)
+		return
+	}
+
+	// With --failed the sets are the sets with failed drives and low space
+	with := "with"
+	if config.FailedMode {
+		with = "with Failed Drives and"
+	}
+	if len(erasureSets) == 0 {
+		pager.Printf("%sNo erasure sets found %s average free space less than %.1f%%.%s\n", Yellow, strings.ToLower(with), threshold, Reset)
+		return
+	}
+
+	printSectionTitle(pager, config, fmt.Sprintf("Erasure Sets %s Average Free Space < %.1f%% (sorted by utilization)", with, threshold))
+	pager.Printf("================================================================================\n")
+
+	for _, es := range erasureSets {
+		goodText := fmt.Sprintf("%d", es.Good)
+		if es.Good > 0 {
+			goodText = fmt.Sprintf("%s%d%s", Green, es.Good, Reset)
+		}
+		badText := fmt.Sprintf("%d", es.Bad)
+		if es.Bad > 0 {
+			badText = fmt.Sprintf("%s%d%s", Red, es.Bad, Reset)
+		}
+		scanningText := fmt.Sprintf("%d", es.Scanning)
+		if es.Scanning > 0 {
+			scanningText = fmt.Sprintf("%s%d%s", Yellow, es.Scanning, Reset)
+		}
+
+		pager.Printf("  Pool %d, Erasure Set %d: Good disks: %s, Bad disks: %s, Scanning: %s, Avg Space Used: %s%s%s, Avg Free Space: %s%s%s, Avg Inodes Used: %s%s%s\n",
+			es.PoolIdx, es.SetIdx, goodText, badText, scanningText,
+			usageColor(es.AvgSpaceUsedPct, config), formatPct(es.AvgSpaceUsedPct, config), Reset,
+			freeColor(es.AvgFreeSpacePct, config), formatPct(es.AvgFreeSpacePct, config), Reset,
+			inodeColor(es.AvgInodesUsedPct, config), formatPct(es.AvgInodesUsedPct, config), Reset)
+	}
+}
+
+// lowSpaceSetDrives returns the drives of the sets judged by --low-space. With
+// --failed only the sets with failed drives are kept and, like in the Erasure
+// Sets table, averaged over all their drives unless --failed-only-averages.
+func lowSpaceSetDrives(poolSetDrives, allPoolSetDrives map[string][]DiskInfo, config *Config) map[string][]DiskInfo {
+	if !config.FailedMode || config.FailedOnlyAvg {
+		return poolSetDrives
+	}
+	setDrives := make(map[string][]DiskInfo, len(poolSetDrives))
+	for key := range poolSetDrives {
+		setDrives[key] = allPoolSetDrives[key]
+	}
+	return setDrives
+}
+
+// lowSpaceErasureSets returns the erasure sets whose average free space is
+// below threshold, most utilized first
+func lowSpaceErasureSets(poolSetDrives map[string][]DiskInfo, threshold float64) []ErasureSetInfo {
+	erasureSets := make([]ErasureSetInfo, 0)
+	for _, drives := range poolSetDrives {
+		if len(drives) == 0 {
+			continue
+		}
+		es := mdbcore.SummarizeErasureSet(drives[0].PoolIndex, drives[0].SetIndex, drives)
+		if es.AvgFreeSpacePct < threshold {
+			erasureSets = append(erasureSets, es)
+		}
+	}
+
+	// Sort by utilization (used space percentage) descending
+	sort.Slice(erasureSets, func(i, j int) bool {
+		if erasureSets[i].AvgSpaceUsedPct != erasureSets[j].AvgSpaceUsedPct {
+			return erasureSets[i].AvgSpaceUsedPct > erasureSets[j].AvgSpaceUsedPct
+		}
+		if erasureSets[i].PoolIdx != erasureSets[j].PoolIdx {
+			return erasureSets[i].PoolIdx < erasureSets[j].PoolIdx
+		}
+		return erasureSets[i].SetIdx < erasureSets[j].SetIdx
+	})
+	return erasureSets
+}
+
+// printServerInfo prints server metadata for all servers in table format
+func printServerInfo(pager *Pager, servers []madmin.ServerProperties, pools map[string]map[string]interface{}, names *mdbcore.ServerNamer, config *Config) {
+	if config.BusyServers {
+		printSectionTitle(pager, config, "Busy Servers")
+	} else {
+		printSectionTitle(pager, config, "Servers")
+	}
+	
+	// Collect all unique servers and determine which pools each belongs to
+	serversData := make(map[string]struct {
+		server  madmin.ServerProperties
+		pools   []int
+	})
+	
+	// Build map of valid pool indices from pools map
+	validPools := make(map[int]bool)
+	for poolKey := range pools {
+		if poolIdx, err := strconv.Atoi(poolKey); err == nil {
+			validPools[poolIdx] = true
+		}
+	}
+
+	// Build map of servers to their pool membership
+	for _, server := range servers {
+		endpointName := names.Name(server.Endpoint)
+		
+		// Collect all pools this server belongs to by checking its disks
+		// Only include pools that exist in the valid pools map
+		poolSet := make(map[int]bool)
+		for _, disk := range server.Disks {
+			if validPools[disk.PoolIndex] {
+				poolSet[disk.PoolIndex] = true
+			}
+		}
+		
+		// Convert pool set to sorted slice
+		var poolList []int
+		for poolIdx := range poolSet {
+			poolList = append(poolList, poolIdx)
+		}
+		sort.Ints(poolList)
+		
+		// Store or update server data
+		if existing, exists := serversData[endpointName]; exists {
+			// Merge pool lists and deduplicate
+			allPools := make(map[int]bool)
+			for _, p := range existing.pools {
+				allPools[p] = true
+			}
+			for _, p := range poolList {
+				allPools[p] = true
+			}
+			var mergedPools []int
+			for p := range allPools {
+				mergedPools = append(mergedPools, p)
+			}
+			sort.Ints(mergedPools)
+			
+			// When merging, prefer offline state over online (offline is more critical)
+			// If current server is offline, use it; otherwise keep existing
+			preferredServer := existing.server
+			if server.State == "offline" || (existing.server.State == "online" && server.State != "online") {
+				preferredServer = server
+			}
+			
+			serversData[endpointName] = struct {
+				server  madmin.ServerProperties
+				pools   []int
+			}{server: preferredServer, pools: mergedPools}
+		} else {
+			serversData[endpointName] = struct {
+				server  madmin.ServerProperties
+				pools   []int
+			}{server: server, pools: poolList}
+		}
+	}
+
+	if len(serversData) == 0 {
+		pager.Printf("\n")
+		return
+	}
+
+	// Get sorted server names (natural/alphanumeric sort)
+	serverNames := make([]string, 0, len(serversData))
+	for name := range serversData {
+		serverNames = append(serverNames, name)
+	}
+	sort.Slice(serverNames, func(i, j int) bool {
+		return naturalLess(serverNames[i], serverNames[j])
+	})
+
+	// Roll up drive activity per server
+	activity := make(map[string]map[string]int, len(serverNames))
+	for _, serverName := range serverNames {
+		counts := make(map[string]int)
+		for _, drive := range mdbcore.ServerDrives(serversData[serverName].server, names) {
+			if state := driveActivity(drive); state != "" {
+				counts[state]++
+			}
+		}
+		activity[serverName] = counts
+	}
+
+	// With --busy-servers: keep only servers with healing/scanning drives, busiest healers first
+	if config.BusyServers {
+		busyNames := make([]string, 0, len(serverNames))
+		for _, serverName := range serverNames {
+			if activity[serverName][activityHealing]+activity[serverName][activityScanning] > 0 {
+				busyNames = append(busyNames, serverName)
+			}
+		}
+		sort.SliceStable(busyNames, func(i, j int) bool {
+			return activity[busyNames[i]][activityHealing] > activity[busyNames[j]][activityHealing]
+		})
+		serverNames = busyNames
+
+		if len(serverNames) == 0 {
+			pager.Printf("%sNo servers with healing or scanning drives found.%s\n\n", Yellow, Reset)
+			return
+		}
+	}
+
+	// Prepare table data
+	headers := []string{"Pool", "Server", "State", "Healing", "Scanning", "Idle", "Edition", "Version", "Commit ID", "Memory", "ILM Status", "Uptime"}
+	if config.ServerDetails {
+		headers = append(headers, "CPUs", "Go", "GC")
+	}
+	rows := make([][]string, 0, len(serverNames))
+
+	for _, serverName := range serverNames {
+		data := serversData[serverName]
+		server := data.server
+		counts := activity[serverName]
 
 		// Format pool list
 		var poolStr string
 		if len(data.pools) == 0 {
 			poolStr = "N/A"
 		} else {
-			poolStrs := make([]string, len(data.pools))
-			for i, p := range data.pools {
-				poolStrs[i] = strconv.Itoa(p)
+			poolStrs := make([]string, len(data.pools))
+			for i, p := range data.pools {
+				poolStrs[i] = strconv.Itoa(p)
+			}
+			poolStr = strings.Join(poolStrs, ",")
+		}
+
+		// Color code state
+		stateColor := Green
+		if server.State == "offline" {
+			stateColor = Red
+		}
+		stateText := newTag(fmt.Sprintf("%s%s%s", stateColor, server.State, Reset), config.BaselineChanges.newServer(serverName))
+
+		// Format commit ID (use full commit ID, no truncation)
+		commitID := server.CommitID
+
+		// Format ILM status
+		ilmStatus := "false"
+		if server.ILMExpiryInProgress {
+			ilmStatus = "true"
+		}
+
+		// Format uptime
+		uptime := compactDuration(time.Duration(server.Uptime) * time.Second)
+
+		// Format drive activity counts
+		healingText := fmt.Sprintf("%d", counts[activityHealing])
+		if counts[activityHealing] > 0 {
+			healingText = fmt.Sprintf("%s%d%s", Yellow, counts[activityHealing], Reset)
+		}
+
+		row := make([]string, len(headers))
+		row[0] = poolStr
+		row[1] = serverName
+		row[2] = stateText
+		row[3] = healingText
+		row[4] = fmt.Sprintf("%d", counts[activityScanning])
+		row[5] = fmt.Sprintf("%d", counts[activityIdle])
+		row[6] = server.Edition
+		row[7] = server.Version
+		row[8] = commitID
+		row[9] = formatSize(int64(server.MemStats.Alloc), config)
+		row[10] = ilmStatus
+		if server.State == "offline" {
+			row[11] = "N/A"
+		} else {
+			row[11] = uptime
+		}
+		if config.ServerDetails {
+			row[12] = serverCPUs(server)
+			row[13] = server.RuntimeVersion
+			if row[13] == "" {
+				row[13] = "N/A"
+			}
+			row[14] = serverGC(server)
+		}
+
+		if config.Grep != nil && !rowMatches(config.Grep, row, server.Endpoint) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && config.Grep != nil {
+		pager.Printf("%sNo servers match --grep %q.%s\n\n", Yellow, config.GrepPattern, Reset)
+		return
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// rowMatches reports whether a table row, without its colors, or one of the
+// extra identifiers not shown in full matches the --grep pattern
+func rowMatches(grep *regexp.Regexp, row []string, extra ...string) bool {
+	for _, cell := range row {
+		if grep.MatchString(stripANSI(cell)) {
+			return true
+		}
+	}
+	return slices.ContainsFunc(extra, grep.MatchString)
+}
+
+// driveMatches reports whether any Drives table column of drive, or its full
+// UUID, matches the --grep pattern of config
+func driveMatches(drive DiskInfo, config *Config) bool {
+	row := make([]string, len(driveColumns))
+	for i, column := range driveColumns {
+		row[i] = column.Value(drive, config)
+	}
+	return rowMatches(config.Grep, row, drive.UUID)
+}
+
+// serverCPUs formats the CPU count of a server, with GOMAXPROCS when the
+// process is limited to fewer CPUs
+func serverCPUs(server madmin.ServerProperties) string {
+	if server.NumCPU == 0 {
+		return "N/A"
+	}
+	if server.GoMaxProcs > 0 && server.GoMaxProcs != server.NumCPU {
+		return fmt.Sprintf("%d (max procs %d)", server.NumCPU, server.GoMaxProcs)
+	}
+	return strconv.Itoa(server.NumCPU)
+}
+
+// serverGC formats the garbage collections of a server with their total pause
+// and the share of the uptime spent paused
+func serverGC(server madmin.ServerProperties) string {
+	gc := server.GCStats
+	if gc == nil {
+		return "N/A"
+	}
+	text := fmt.Sprintf("%d runs, %s pause", gc.NumGC, gc.PauseTotal.Round(time.Millisecond))
+	if server.Uptime > 0 {
+		share := float64(gc.PauseTotal) / float64(time.Duration(server.Uptime)*time.Second) * 100
+		text += fmt.Sprintf(" (%.2f%%)", share)
+	}
+	return text
+}
+
+// setMetrics holds the drive metrics of an erasure set, summed over the drives
+// that report metrics
+type setMetrics struct {
+	PoolIdx    int
+	SetIdx     int
+	Drives     int // drives in the set
+	Reporting  int // drives with metrics, the others are skipped
+	Writes     uint64
+	Deletes    uint64
+	Timeouts   uint64
+	Errors     uint64 // availability errors, including timeouts
+	MaxWaiting uint32
+	WaitTokens uint32 // tokens of the drive with the most waiting I/O
+	IODrives   int    // drives with series in --metrics-file
+	IOPS       float64
+	MaxLatency float64 // highest drive latency in microseconds, 0 without latency series
+}
+
+// summarizeSetMetrics sums the metrics of the drives of one erasure set
+func summarizeSetMetrics(poolIdx, setIdx int, drives []DiskInfo) setMetrics {
+	sm := setMetrics{PoolIdx: poolIdx, SetIdx: setIdx, Drives: len(drives)}
+	for _, drive := range drives {
+		if drive.IO != nil {
+			sm.IODrives++
+			sm.IOPS += drive.IO.IOPS()
+			sm.MaxLatency = max(sm.MaxLatency, drive.IO.LatencyUs)
+		}
+		m := drive.Metrics
+		if m == nil {
+			continue
+		}
+		sm.Reporting++
+		sm.Writes += m.TotalWrites
+		sm.Deletes += m.TotalDeletes
+		sm.Timeouts += m.TotalErrorsTimeout
+		sm.Errors += m.TotalErrorsAvailability
+		if m.TotalWaiting > sm.MaxWaiting {
+			sm.MaxWaiting = m.TotalWaiting
+			sm.WaitTokens = m.TotalTokens
+		}
+	}
+	return sm
+}
+
+// formatLatency formats a latency in microseconds as µs, ms or s
+func formatLatency(us float64) string {
+	switch {
+	case us < 1000:
+		return fmt.Sprintf("%.0fµs", us)
+	case us < 1000000:
+		return fmt.Sprintf("%.1fms", us/1000)
+	}
+	return fmt.Sprintf("%.2fs", us/1000000)
+}
+
+// formatIOPS formats reads and writes per second, rounded to whole operations
+func formatIOPS(iops float64) string {
+	return formatInt(int64(math.Round(iops)))
+}
+
+// waitingColor returns the color for waiting I/O: yellow when any I/O waits,
+// red when as much waits as the drive can run concurrently
+func waitingColor(waiting, tokens uint32) string {
+	switch {
+	case waiting == 0:
+		return Green
+	case tokens > 0 && waiting >= tokens:
+		return Red
+	}
+	return Yellow
+}
+
+// defaultSaturationRatio is the waiting/tokens ratio at which a drive counts as
+// saturated unless --saturation sets another one
+const defaultSaturationRatio = 0.5
+
+// saturationRatio returns the --saturation ratio, or the default if it is not set
+func saturationRatio(config *Config) float64 {
+	if config.SaturationRatio > 0 {
+		return config.SaturationRatio
+	}
+	return defaultSaturationRatio
+}
+
+// driveWaitingRatio returns how much I/O waits on a drive relative to the I/O it
+// can run concurrently. Drives without metrics or tokens return false.
+func driveWaitingRatio(drive DiskInfo) (float64, bool) {
+	if drive.Metrics == nil || drive.Metrics.TotalTokens == 0 {
+		return 0, false
+	}
+	return float64(drive.Metrics.TotalWaiting) / float64(drive.Metrics.TotalTokens), true
+}
+
+// saturationColor returns the color of a waiting/tokens ratio: red at 1.0 or
+// more, yellow at the saturation threshold or more, "" below it
+func saturationColor(ratio, threshold float64) string {
+	switch {
+	case ratio >= 1:
+		return Red
+	case ratio >= threshold:
+		return Yellow
+	}
+	return ""
+}
+
+// saturationText describes a saturated drive for the Metrics column, e.g.
+// "saturated 0.75", or returns "" if the drive is below the threshold
+func saturationText(drive DiskInfo, threshold float64) string {
+	ratio, ok := driveWaitingRatio(drive)
+	if !ok {
+		return ""
+	}
+	saturatedColor := saturationColor(ratio, threshold)
+	if saturatedColor == "" {
+		return ""
+	}
+	return fmt.Sprintf("%ssaturated %.2f%s", saturatedColor, ratio, Reset)
+}
+
+// printSaturatedDrives prints how many drives wait for at least the saturation
+// ratio of their tokens. Nothing is printed if no drive reports tokens.
+func printSaturatedDrives(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	threshold := saturationRatio(config)
+	reporting, saturated := 0, 0
+	maxRatio := 0.0
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			ratio, ok := driveWaitingRatio(drive)
+			if !ok {
+				continue
+			}
+			reporting++
+			if ratio >= threshold {
+				saturated++
+			}
+			maxRatio = math.Max(maxRatio, ratio)
+		}
+	}
+	if reporting == 0 {
+		return
+	}
+
+	saturatedColor := Green
+	if saturated > 0 {
+		saturatedColor = saturationColor(maxRatio, threshold)
+	}
+	pager.Printf("  Saturated drives: %s%d%s (waiting/tokens %.2f or more)\n", saturatedColor, saturated, Reset, threshold)
+}
+
+// decommissionPlan is where the data of a pool would go if it were
+// decommissioned. Pools receive data in proportion to their free space, as
+// MinIO places new objects.
+type decommissionPlan struct {
+	Pool       mdbcore.PoolSpace   // the pool to decommission
+	Remaining  []mdbcore.PoolSpace // the other pools before the migration
+	Incoming   []int64             // data moved to each of Remaining
+	MaxUsedPct float64             // usage no remaining pool may exceed, --crit-used
+}
+
+// planDecommission plans moving the used space of pool to the other pools
+func planDecommission(poolSetDrives map[string][]DiskInfo, pool, parityDisks int, config *Config) (decommissionPlan, error) {
+	plan := decommissionPlan{MaxUsedPct: colorThresholds(config).CritUsed}
+	found := false
+	var pools []string
+	for _, space := range mdbcore.PoolSpaces(poolSetDrives, parityDisks) {
+		pools = append(pools, strconv.Itoa(space.Pool))
+		if space.Pool == pool {
+			plan.Pool = space
+			found = true
+		} else {
+			plan.Remaining = append(plan.Remaining, space)
+		}
+	}
+	if !found {
+		return plan, fmt.Errorf("--decommission %d: the snapshot has no pool %d (pools: %s)", pool, pool, strings.Join(pools, ", "))
+	}
+
+	free := plan.free()
+	plan.Incoming = make([]int64, len(plan.Remaining))
+	for i, space := range plan.Remaining {
+		if free > 0 {
+			plan.Incoming[i] = int64(float64(plan.Pool.Used) * float64(space.Capacity-space.Used) / float64(free))
+		}
+	}
+	return plan, nil
+}
+
+// free returns the usable free space of the remaining pools
+func (p decommissionPlan) free() int64 {
+	var free int64
+	for _, space := range p.Remaining {
+		free += space.Capacity - space.Used
+	}
+	return free
+}
+
+// usedPctAfter returns the usage of remaining pool i after the migration
+func (p decommissionPlan) usedPctAfter(i int) float64 {
+	space := p.Remaining[i]
+	if space.Capacity == 0 {
+		return 0
+	}
+	return float64(space.Used+p.Incoming[i]) / float64(space.Capacity) * 100
+}
+
+// problems returns why the data of the pool does not fit in the other pools,
+// nothing if it does
+func (p decommissionPlan) problems(config *Config) []string {
+	if len(p.Remaining) == 0 {
+		return []string{"there is no other pool to move its data to"}
+	}
+	var problems []string
+	if free := p.free(); p.Pool.Used > free {
+		problems = append(problems, fmt.Sprintf("its %s of data exceed the %s free on the other pools", formatSize(p.Pool.Used, config), formatSize(free, config)))
+	}
+	for i, space := range p.Remaining {
+		if pct := p.usedPctAfter(i); pct > p.MaxUsedPct {
+			problems = append(problems, fmt.Sprintf("pool %d would be %s used, above %.0f%%", space.Pool, formatPct(pct, config), p.MaxUsedPct))
+		}
+	}
+	return problems
+}
+
+// printDecommissionPlan prints the data of the pool to decommission, the free
+// space of the other pools and their usage after the migration
+func printDecommissionPlan(pager *Pager, plan decommissionPlan, config *Config) {
+	printSectionTitle(pager, config, fmt.Sprintf("Decommission Pool %d", plan.Pool.Pool))
+	usedPct := 0.0
+	if plan.Pool.Capacity > 0 {
+		usedPct = float64(plan.Pool.Used) / float64(plan.Pool.Capacity) * 100
+	}
+	pager.Printf("  Data to move: %s (%s of %s usable)\n", formatSize(plan.Pool.Used, config), formatPct(usedPct, config), formatSize(plan.Pool.Capacity, config))
+	pager.Printf("  Free on other pools: %s\n\n", formatSize(plan.free(), config))
+
+	if len(plan.Remaining) > 0 {
+		headers := []string{"Pool", "Usable Capacity", "Used Now", "Incoming", "Used After"}
+		rows := make([][]string, 0, len(plan.Remaining))
+		for i, space := range plan.Remaining {
+			pct := plan.usedPctAfter(i)
+			rows = append(rows, []string{
+				fmt.Sprintf("%s%d%s", Blue, space.Pool, Reset),
+				formatSize(space.Capacity, config),
+				formatSize(space.Used, config),
+				formatSize(plan.Incoming[i], config),
+				fmt.Sprintf("%s (%s%s%s)", formatSize(space.Used+plan.Incoming[i], config), usageColor(pct, config), formatPct(pct, config), Reset),
+			})
+			if config.Bars {
+				rows[i][4] = fmt.Sprintf("%s %s", formatSize(space.Used+plan.Incoming[i], config), usageBar(pct, config))
+			}
+		}
+		printTableRows(pager, config, headers, rows)
+		pager.Printf("\n")
+	}
+
+	problems := plan.problems(config)
+	if len(problems) == 0 {
+		pager.Printf("  %sThe data of pool %d fits in the other pools%s\n\n", Green, plan.Pool.Pool, Reset)
+		return
+	}
+	for _, problem := range problems {
+		pager.Printf("  %sDoes not fit: %s%s\n", Red, problem, Reset)
+	}
+	pager.Printf("\n")
+}
+
+// Kinds of --simulate-loss targets
+const (
+	lossServer = "server"
+	lossDrive  = "drive"
+)
+
+// lossTarget is a server or drive taken away by --simulate-loss
+type lossTarget struct {
+	Kind string
+	Name string // server name, host or endpoint, or drive UUID
+}
+
+// String returns the target as given on the command line
+func (t lossTarget) String() string {
+	return t.Kind + ":" + t.Name
+}
+
+// parseLossTarget parses a --simulate-loss value, server:NAME or drive:UUID
+func parseLossTarget(value string) (lossTarget, error) {
+	kind, name, _ := strings.Cut(value, ":")
+	if (kind != lossServer && kind != lossDrive) || name == "" {
+		return lossTarget{}, fmt.Errorf("invalid --simulate-loss value: %q (expected server:NAME or drive:UUID)", value)
+	}
+	return lossTarget{Kind: kind, Name: name}, nil
+}
+
+// Erasure set states of --simulate-loss, from best to worst
+const (
+	setHealthy       = "healthy"
+	setDegraded      = "degraded"
+	setNoWriteQuorum = "no write quorum"
+	setNoReadQuorum  = "no read quorum"
+)
+
+// setQuorumState classifies an erasure set by how many of its drives are online
+func setQuorumState(online, setSize, parity int) string {
+	read, write := mdbcore.Quorum(setSize, parity)
+	switch {
+	case online >= setSize:
+		return setHealthy
+	case online >= write:
+		return setDegraded
+	case online >= read:
+		return setNoWriteQuorum
+	}
+	return setNoReadQuorum
+}
+
+// setAtRiskDuringHeal labels erasure sets whose scanning and bad drives
+// together reach the parity
+const setAtRiskDuringHeal = "at risk during heal"
+
+// healLoad counts the scanning and bad drives of an erasure set
+func healLoad(drives []DiskInfo) (scanning, bad int) {
+	for _, drive := range drives {
+		if drive.Scanning {
+			scanning++
+		}
+		if drive.State != "ok" {
+			bad++
+		}
+	}
+	return scanning, bad
+}
+
+// atRiskDuringHeal reports whether an erasure set with drives scanning is at
+// risk during heal: with the bad drives they reach the parity, so rebuild
+// traffic competes within the set and read quorum is at risk. Sets with more
+// bad drives than parity have lost read quorum already.
+func atRiskDuringHeal(scanning, bad, parity int) bool {
+	return scanning > 0 && scanning+bad >= parity && bad <= parity
+}
+
+// quorumStateText returns an erasure set state in its color
+func quorumStateText(state string) string {
+	switch state {
+	case setHealthy:
+		return Green + state + Reset
+	case setDegraded:
+		return Yellow + state + Reset
+	}
+	return Red + state + Reset
+}
+
+// severityInfo is the severity of findings that need no action, such as scanning
+const severityInfo = "info"
+
+// severityRank orders finding severities, most severe first
+func severityRank(severity string) int {
+	switch severity {
+	case severityCritical:
+		return 0
+	case severityWarning:
+		return 1
+	}
+	return 2
+}
+
+// finding is one problem or notable state of a snapshot with where it was found.
+// Pool and Set are nil for findings that are not about an erasure set or drive.
+type finding struct {
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	Pool     *int   `json:"pool"`
+	Set      *int   `json:"set"`
+	Server   string `json:"server,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+	New      bool   `json:"new,omitempty"` // failed since --baseline
+}
+
+// location returns where a finding was found as space-separated key=value pairs
+func (f finding) location() string {
+	var parts []string
+	if f.Pool != nil {
+		parts = append(parts, fmt.Sprintf("pool=%d", *f.Pool))
+	}
+	if f.Set != nil {
+		parts = append(parts, fmt.Sprintf("set=%d", *f.Set))
+	}
+	if f.Server != "" {
+		parts = append(parts, "server="+f.Server)
+	}
+	if f.Path != "" {
+		parts = append(parts, "path="+f.Path)
+	}
+	return strings.Join(parts, " ")
+}
+
+// findingCollector collects the findings of the analyses of a snapshot
+type findingCollector struct {
+	findings []finding
+}
+
+// add records a finding
+func (c *findingCollector) add(f finding) {
+	c.findings = append(c.findings, f)
+}
+
+// addDrive records a finding about a drive
+func (c *findingCollector) addDrive(severity string, drive DiskInfo, message string) {
+	pool, set := drive.PoolIndex, drive.SetIndex
+	c.add(finding{Severity: severity, Category: "drive", Pool: &pool, Set: &set, Server: drive.Server, Path: drive.Path, Message: message})
+}
+
+// sorted returns the findings by severity, then pool, set, server and path.
+// Findings without a pool come before those of the pools.
+func (c *findingCollector) sorted() []finding {
+	index := func(p *int) int {
+		if p == nil {
+			return -1
+		}
+		return *p
+	}
+	findings := append([]finding{}, c.findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		switch {
+		case severityRank(a.Severity) != severityRank(b.Severity):
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		case index(a.Pool) != index(b.Pool):
+			return index(a.Pool) < index(b.Pool)
+		case index(a.Set) != index(b.Set):
+			return index(a.Set) < index(b.Set)
+		case a.Server != b.Server:
+			return naturalLess(a.Server, b.Server)
+		}
+		return naturalLess(a.Path, b.Path)
+	})
+	return findings
+}
+
+// findingsParity returns the parity the erasure sets of a snapshot are checked
+// against by collectFindings, negative for backends without erasure coding and
+// snapshots without topology
+func findingsParity(infoStruct *clusterStruct) int {
+	if !infoStruct.IsErasure() || infoStruct.MissingTopology() != "" {
+		return -1
+	}
+	return infoStruct.ParityDisks()
+}
+
+// collectFindings runs the analyses of a snapshot and returns their findings
+// sorted. Erasure sets are not checked if parity is negative, and pools only
+// when the --pool-status states are given.
+func collectFindings(servers []madmin.ServerProperties, poolSetDrives map[string][]DiskInfo, names *mdbcore.ServerNamer, parity int, pools map[int]poolState, config *Config) []finding {
+	var c findingCollector
+	if parity >= 0 {
+		findSetQuorum(&c, poolSetDrives, parity)
+	}
+	findOfflineServers(&c, servers, names)
+	findRemoteDrives(&c, servers, names)
+	findDriveProblems(&c, poolSetDrives, config)
+	findMixedEditions(&c, servers)
+	findPoolStatus(&c, pools)
+	return c.sorted()
+}
+
+// findMixedEditions reports servers running different editions of MinIO as a
+// warning, usually a migration between editions that was not finished
+func findMixedEditions(c *findingCollector, servers []madmin.ServerProperties) {
+	editions := serverEditions(servers)
+	if len(editions) < 2 {
+		return
+	}
+	parts := make([]string, 0, len(editions))
+	for _, edition := range editions {
+		parts = append(parts, fmt.Sprintf("%s on %s", edition.Edition, countNoun(edition.Servers, "server")))
+	}
+	c.add(finding{Severity: severityWarning, Category: "server", Message: "mixed editions: " + strings.Join(parts, ", ") + ", unfinished migration?"})
+}
+
+// findPoolStatus reports the pools that are draining, being decommissioned or
+// rebalanced, and decommissions that failed
+func findPoolStatus(c *findingCollector, pools map[int]poolState) {
+	for id, state := range pools {
+		pool := id
+		switch {
+		case state.Decommission != nil && state.Decommission.Failed:
+			c.add(finding{Severity: severityWarning, Category: "pool", Pool: &pool, Message: state.text()})
+		case state.draining():
+			c.add(finding{Severity: severityInfo, Category: "pool", Pool: &pool, Message: state.text()})
+		}
+	}
+}
+
+// findSetQuorum reports erasure sets below write or read quorum as critical,
+// sets at risk during heal as warnings and sets with scanning drives as info
+func findSetQuorum(c *findingCollector, poolSetDrives map[string][]DiskInfo, parity int) {
+	for _, drives := range poolSetDrives {
+		if len(drives) == 0 {
+			continue
+		}
+		pool, set := drives[0].PoolIndex, drives[0].SetIndex
+		scanning, bad := healLoad(drives)
+		online := len(drives) - bad
+		state := setQuorumState(online, len(drives), parity)
+		if state == setNoWriteQuorum || state == setNoReadQuorum {
+			c.add(finding{Severity: severityCritical, Category: "set", Pool: &pool, Set: &set,
+				Message: fmt.Sprintf("%s, %d of %d drives online", state, online, len(drives))})
+		}
+		if atRiskDuringHeal(scanning, bad, parity) {
+			c.add(finding{Severity: severityWarning, Category: "set", Pool: &pool, Set: &set,
+				Message: fmt.Sprintf("%s, %d scanning and %d bad drives reach parity EC:%d", setAtRiskDuringHeal, scanning, bad, parity)})
+		}
+		if scanning > 0 {
+			c.add(finding{Severity: severityInfo, Category: "set", Pool: &pool, Set: &set,
+				Message: fmt.Sprintf("scanning in progress on %s", countNoun(scanning, "drive"))})
+		}
+	}
+}
+
+// driveStates are the drive states MinIO reports
+var driveStates = []string{
+	madmin.DriveStateOk,
+	madmin.DriveStateOffline,
+	madmin.DriveStateCorrupt,
+	madmin.DriveStateMissing,
+	madmin.DriveStatePermission,
+	madmin.DriveStateFaulty,
+	madmin.DriveStateRootMount,
+	madmin.DriveStateUnknown,
+	madmin.DriveStateUnformatted,
+}
+
+// driveStateColors are the colors of drive states: ok in green and states an
+// operator fixes without replacing the drive in yellow. Other states are hard
+// failures and shown in red.
+var driveStateColors = map[string]string{
+	madmin.DriveStateOk:          Green,
+	madmin.DriveStateUnformatted: Yellow, // a new drive waiting to be formatted
+	madmin.DriveStatePermission:  Yellow, // ownership of the mount
+	madmin.DriveStateRootMount:   Yellow, // the drive is not mounted
+}
+
+// driveStateColor returns the color of a drive state
+func driveStateColor(state string) string {
+	if color, ok := driveStateColors[state]; ok {
+		return color
+	}
+	return Red
+}
+
+// stateCount is the number of drives in a state
+type stateCount struct {
+	State  string
+	Drives int
+}
+
+// driveStateCounts counts the drives per state, ok first and then the most
+// common states, ties by name
+func driveStateCounts(poolSetDrives map[string][]DiskInfo) []stateCount {
+	var drives []DiskInfo
+	for _, setDrives := range poolSetDrives {
+		drives = append(drives, setDrives...)
+	}
+	return countStates(drives, false)
+}
+
+// countStates counts drives per state in the order of driveStateCounts,
+// without ok drives if badOnly is set
+func countStates(drives []DiskInfo, badOnly bool) []stateCount {
+	byState := make(map[string]int)
+	for _, drive := range drives {
+		if badOnly && drive.State == madmin.DriveStateOk {
+			continue
+		}
+		byState[drive.State]++
+	}
+	counts := make([]stateCount, 0, len(byState))
+	for state, n := range byState {
+		counts = append(counts, stateCount{State: state, Drives: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		switch {
+		case (a.State == madmin.DriveStateOk) != (b.State == madmin.DriveStateOk):
+			return a.State == madmin.DriveStateOk
+		case a.Drives != b.Drives:
+			return a.Drives > b.Drives
+		}
+		return a.State < b.State
+	})
+	return counts
+}
+
+// formatStateCounts formats state counts as "6 ok, 1 faulty", each in the
+// color of its state
+func formatStateCounts(counts []stateCount) string {
+	parts := make([]string, len(counts))
+	for i, count := range counts {
+		parts[i] = fmt.Sprintf("%s%d %s%s", driveStateColor(count.State), count.Drives, count.State, Reset)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findOfflineServers reports servers that are not online as warnings
+func findOfflineServers(c *findingCollector, servers []madmin.ServerProperties, names *mdbcore.ServerNamer) {
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		name := names.Name(server.Endpoint)
+		if server.State != "online" && !seen[name] {
+			seen[name] = true
+			c.add(finding{Severity: severityWarning, Category: "server", Server: name, Message: server.State})
+		}
+	}
+}
+
+// findRemoteDrives reports online servers that list drives as remote as info,
+// their drives were listed by another node and may lack metrics
+func findRemoteDrives(c *findingCollector, servers []madmin.ServerProperties, names *mdbcore.ServerNamer) {
+	remote, _ := mdbcore.CheckDriveLocality(servers)
+	for _, locality := range remote {
+		c.add(finding{Severity: severityInfo, Category: "server", Server: names.Name(locality.Endpoint),
+			Message: fmt.Sprintf("%s of %d listed as remote, not reported by the server itself", countNoun(locality.Remote, "drive"), locality.Local+locality.Remote)})
+	}
+}
+
+// findDriveProblems reports drives that are not ok, above the --warn-used
+// threshold or healing for longer than --heal-stuck as warnings
+func findDriveProblems(c *findingCollector, poolSetDrives map[string][]DiskInfo, config *Config) {
+	for _, drive := range sortedDrives(poolSetDrives) {
+		if drive.State != "ok" {
+			c.addDrive(severityWarning, drive, drive.State)
+			continue
+		}
+		if drive.TotalSpace > 0 && drive.UsedSpacePct >= config.settings().Thresholds.WarnUsed {
+			c.addDrive(severityWarning, drive, fmt.Sprintf("%s used (--warn-used %g%%)", formatPct(drive.UsedSpacePct, config), config.settings().Thresholds.WarnUsed))
+		}
+		if unaccountedAbove(drive, config) {
+			unaccounted, pct, _ := unaccountedSpace(drive)
+			c.addDrive(severityWarning, drive, fmt.Sprintf("%s (%s) neither used nor available, shared filesystem? (--warn-unaccounted %g%%)", formatSize(unaccounted, config), formatPct(pct, config), config.settings().Thresholds.WarnUnaccounted))
+		}
+		if drive.Healing && drive.HealAge != nil && *drive.HealAge > config.settings().HealStuckAge {
+			c.addDrive(severityWarning, drive, fmt.Sprintf("healing for %s (--heal-stuck %s)", formatAge(*drive.HealAge), formatAge(config.settings().HealStuckAge)))
+		}
+	}
+}
+
+// severityText returns a finding severity in upper case and its color
+func severityText(severity string) string {
+	switch severity {
+	case severityCritical:
+		return Red + strings.ToUpper(severity) + Reset
+	case severityWarning:
+		return Yellow + strings.ToUpper(severity) + Reset
+	}
+	return Blue + strings.ToUpper(severity) + Reset
+}
+
+// printProblems prints the findings as the Problems section at the top of the report
+func printProblems(pager *Pager, findings []finding, config *Config) {
+	printSectionTitle(pager, config, "Problems")
+	if len(findings) == 0 {
+		pager.Printf("  %sNo problems found.%s\n\n", Green, Reset)
+		return
+	}
+	headers := []string{"Severity", "Category", "Location", "Problem"}
+	rows := make([][]string, 0, len(findings))
+	for _, f := range findings {
+		rows = append(rows, []string{severityText(f.Severity), f.Category, f.location(), newTag(f.Message, f.New)})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// printQuietProblems prints one tab-separated line per finding above info for
+// --quiet: severity, category, location and detail, in the order of
+// collectFindings. Nothing is printed for a healthy cluster.
+func printQuietProblems(pager *Pager, findings []finding) {
+	for _, f := range findings {
+		if f.Severity == severityInfo {
+			continue
+		}
+		pager.Printf("%s\t%s\t%s\t%s\n", severityText(f.Severity), f.Category, f.location(), newTag(f.Message, f.New))
+	}
+}
+
+// printSimulatedLoss prints the erasure sets that are already degraded and those
+// that would become degraded or lose quorum if the --simulate-loss targets
+// went away. Drives that are already bad count as lost either way.
+func printSimulatedLoss(pager *Pager, poolSetDrives map[string][]DiskInfo, servers []madmin.ServerProperties, names *mdbcore.ServerNamer, parity int, config *Config) error {
+	lostServers := make(map[string]bool)
+	lostUUIDs := make(map[string]bool)
+	targets := make([]string, 0, len(config.SimulateLoss))
+	for _, target := range config.SimulateLoss {
+		targets = append(targets, target.String())
+		found := false
+		switch target.Kind {
+		case lossServer:
+			for _, server := range servers {
+				name := names.Name(server.Endpoint)
+				if target.Name == name || target.Name == mdbcore.EndpointHost(server.Endpoint) || target.Name == server.Endpoint {
+					lostServers[name] = true
+					found = true
+				}
+			}
+		case lossDrive:
+			for _, drives := range poolSetDrives {
+				for _, drive := range drives {
+					if drive.UUID == target.Name {
+						lostUUIDs[drive.UUID] = true
+						found = true
+					}
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("--simulate-loss %s matches no %s of the snapshot", target, target.Kind)
+		}
+	}
+
+	type setLoss struct {
+		Pool, Set                 int
+		Size, Online, OnlineAfter int
+		State, StateAfter         string
+	}
+	var sets []setLoss
+	for _, drives := range poolSetDrives {
+		if len(drives) == 0 {
+			continue
+		}
+		sl := setLoss{Pool: drives[0].PoolIndex, Set: drives[0].SetIndex, Size: len(drives)}
+		for _, drive := range drives {
+			if drive.State != "ok" {
+				continue
+			}
+			sl.Online++
+			if !lostServers[drive.Server] && !lostUUIDs[drive.UUID] {
+				sl.OnlineAfter++
+			}
+		}
+		sl.State = setQuorumState(sl.Online, sl.Size, parity)
+		sl.StateAfter = setQuorumState(sl.OnlineAfter, sl.Size, parity)
+		sets = append(sets, sl)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Pool != sets[j].Pool {
+			return sets[i].Pool < sets[j].Pool
+		}
+		return sets[i].Set < sets[j].Set
+	})
+
+	var already, would [][]string
+	for _, sl := range sets {
+		pool := fmt.Sprintf("%s%d%s", Blue, sl.Pool, Reset)
+		set := fmt.Sprintf("%s%d%s", Blue, sl.Set, Reset)
+		if sl.State != setHealthy {
+			already = append(already, []string{pool, set, fmt.Sprintf("%d/%d", sl.Online, sl.Size), quorumStateText(sl.State)})
+		}
+		if sl.OnlineAfter < sl.Online {
+			would = append(would, []string{pool, set, fmt.Sprintf("%d/%d", sl.Online, sl.Size), fmt.Sprintf("%d/%d", sl.OnlineAfter, sl.Size), quorumStateText(sl.StateAfter)})
+		}
+	}
+
+	printSectionTitle(pager, config, "Simulated Loss: Already Degraded")
+	if len(already) == 0 {
+		pager.Printf("  %sNo erasure set is degraded%s\n", Green, Reset)
+	} else {
+		printTableRows(pager, config, []string{"Pool", "Erasure Set", "Online", "State"}, already)
+	}
+	pager.Printf("\n")
+
+	printSectionTitle(pager, config, "Simulated Loss: Would Become Degraded")
+	pager.Printf("  Without: %s\n\n", strings.Join(targets, ", "))
+	if len(would) == 0 {
+		pager.Printf("  %sNo erasure set would lose a drive%s\n", Green, Reset)
+	} else {
+		printTableRows(pager, config, []string{"Pool", "Erasure Set", "Online Now", "Online After", "State After"}, would)
+	}
+	pager.Printf("\n")
+	return nil
+}
+
+// checkDriveUUIDs looks for drives that share a UUID or have none, ignoring
+// the drives of offline servers
+func checkDriveUUIDs(poolSetDrives map[string][]DiskInfo, servers []madmin.ServerProperties, names *mdbcore.ServerNamer) mdbcore.UUIDCheck {
+	offline := make(map[string]bool)
+	for _, server := range servers {
+		if server.State != "online" {
+			offline[names.Name(server.Endpoint)] = true
+		}
+	}
+	return mdbcore.CheckUUIDs(sortedDrives(poolSetDrives), offline)
+}
+
+// sortedDrives returns the drives of all sets in drive order
+func sortedDrives(poolSetDrives map[string][]DiskInfo) []DiskInfo {
+	var drives []DiskInfo
+	for _, setDrives := range poolSetDrives {
+		drives = append(drives, setDrives...)
+	}
+	sort.Slice(drives, func(i, j int) bool {
+		return driveLess(drives[i], drives[j])
+	})
+	return drives
+}
+
+// treeNode is a pool, erasure set, server or drive of --tree
+type treeNode struct {
+	Label    string
+	Children []*treeNode
+}
+
+// countNoun formats a count with its noun, plural unless n is 1
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// joinInts joins the decimal forms of values with sep
+func joinInts(values []int, sep string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, sep)
+}
+
+// treeCounts summarizes drives for a tree label, with the bad drives in red
+func treeCounts(drives []DiskInfo) string {
+	bad := 0
+	for _, d := range drives {
+		if d.State != "ok" {
+			bad++
+		}
+	}
+	text := countNoun(len(drives), "drive")
+	if bad > 0 {
+		text += fmt.Sprintf(", %s%d bad%s", Red, bad, Reset)
+	}
+	return text
+}
+
+// buildPoolTree groups drives by pool, erasure set and server. Servers are in
+// natural order, drives in disk index order.
+func buildPoolTree(poolSetDrives map[string][]DiskInfo, withDrives bool) []*treeNode {
+	drives := sortedDrives(poolSetDrives)
+	var pools []*treeNode
+	for start := 0; start < len(drives); {
+		end := start
+		for end < len(drives) && drives[end].PoolIndex == drives[start].PoolIndex {
+			end++
+		}
+		poolDrives := drives[start:end]
+		pool := &treeNode{}
+		servers := make(map[string]bool)
+		for setStart := 0; setStart < len(poolDrives); {
+			setEnd := setStart
+			for setEnd < len(poolDrives) && poolDrives[setEnd].SetIndex == poolDrives[setStart].SetIndex {
+				setEnd++
+			}
+			set := buildSetTree(poolDrives[setStart:setEnd], withDrives)
+			pool.Children = append(pool.Children, set)
+			for _, d := range poolDrives[setStart:setEnd] {
+				servers[d.Server] = true
+			}
+			setStart = setEnd
+		}
+		pool.Label = fmt.Sprintf("Pool %d: %s, %s, %s", poolDrives[0].PoolIndex, countNoun(len(pool.Children), "set"), countNoun(len(servers), "server"), treeCounts(poolDrives))
+		pools = append(pools, pool)
+		start = end
+	}
+	return pools
+}
+
+// buildSetTree groups the drives of one erasure set by server
+func buildSetTree(drives []DiskInfo, withDrives bool) *treeNode {
+	byServer := make(map[string][]DiskInfo)
+	for _, d := range drives {
+		byServer[d.Server] = append(byServer[d.Server], d)
+	}
+	serverNames := make([]string, 0, len(byServer))
+	for name := range byServer {
+		serverNames = append(serverNames, name)
+	}
+	sort.Slice(serverNames, func(i, j int) bool {
+		return naturalLess(serverNames[i], serverNames[j])
+	})
+
+	set := &treeNode{Label: fmt.Sprintf("Set %d: %s, %s", drives[0].SetIndex, countNoun(len(serverNames), "server"), treeCounts(drives))}
+	for _, name := range serverNames {
+		server := &treeNode{Label: fmt.Sprintf("%s: %s", name, treeCounts(byServer[name]))}
+		if withDrives {
+			for _, d := range byServer[name] {
+				state := d.State
+				if state != "ok" {
+					state = Red + state + Reset
+				}
+				server.Children = append(server.Children, &treeNode{Label: fmt.Sprintf("%s %s", d.Path, state)})
+			}
+		}
+		set.Children = append(set.Children, server)
+	}
+	return set
+}
+
+// printPoolTree prints the pool, erasure set and server hierarchy of the drives
+// with box-drawing branches, or ASCII ones with --ascii. Markdown gets the tree
+// as a code block without colors.
+func printPoolTree(pager *Pager, poolSetDrives map[string][]DiskInfo, config *Config) {
+	pools := buildPoolTree(poolSetDrives, config.TreeDrives)
+	if len(pools) == 0 {
+		return
+	}
+	printSectionTitle(pager, config, "Pool Tree")
+
+	branch, lastBranch, pipe := "├── ", "└── ", "│   "
+	if config.ASCII {
+		branch, lastBranch, pipe = "|-- ", "`-- ", "|   "
+	}
+	indent := "  "
+	if config.Format == formatMarkdown {
+		indent = ""
+		pager.Printf("```\n")
+	}
+
+	// Each line is built first and printed with a single write
+	var line strings.Builder
+	var walk func(node *treeNode, prefix, childPrefix string)
+	walk = func(node *treeNode, prefix, childPrefix string) {
+		line.Reset()
+		line.WriteString(indent)
+		line.WriteString(prefix)
+		line.WriteString(node.Label)
+		text := line.String()
+		if config.Format == formatMarkdown {
+			text = stripANSI(text)
+		}
+		pager.WriteString(text + "\n")
+		for i, child := range node.Children {
+			if i == len(node.Children)-1 {
+				walk(child, childPrefix+lastBranch, childPrefix+"    ")
+			} else {
+				walk(child, childPrefix+branch, childPrefix+pipe)
+			}
+		}
+	}
+	for _, pool := range pools {
+		walk(pool, "", "")
+	}
+
+	if config.Format == formatMarkdown {
+		pager.Printf("```\n")
+	}
+	pager.Printf("\n")
+}
+
+// topBuckets is the number of buckets listed in the summary
+const topBuckets = 10
+
+// printBuckets prints the largest limit buckets of usage, or all of them if
+// limit is 0, with their object, version and delete marker counts
+func printBuckets(pager *Pager, title string, usage map[string]madmin.BucketUsageInfo, limit int, config *Config) {
+	buckets := mdbcore.SortedBuckets(usage)
+	if limit > 0 && len(buckets) > limit {
+		buckets = buckets[:limit]
+		title = fmt.Sprintf("%s (%d of %d)", title, limit, len(usage))
+	}
+	printSectionTitle(pager, config, title)
+
+	headers := []string{"Bucket", "Size", "Objects", "Versions", "Delete Markers"}
+	rows := make([][]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		rows = append(rows, []string{
+			bucket.Name,
+			formatSize(int64(bucket.Size), config),
+			strconv.FormatUint(bucket.ObjectsCount, 10),
+			strconv.FormatUint(bucket.VersionsCount, 10),
+			strconv.FormatUint(bucket.DeleteMarkersCount, 10),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// ilmExpirySummary describes on how many online servers ILM expiry is in
+// progress, naming them if there are any
+func ilmExpirySummary(stats ClusterStats) string {
+	if len(stats.ILMExpiry) == 0 {
+		return fmt.Sprintf("ILM expiry in progress on 0/%d online servers", stats.Online)
+	}
+	return fmt.Sprintf("ILM expiry in progress on %s%d%s/%d online servers: %s", Yellow, len(stats.ILMExpiry), Reset, stats.Online, strings.Join(stats.ILMExpiry, ", "))
+}
+
+// editionCount is how many servers run an edition of MinIO
+type editionCount struct {
+	Edition string
+	Servers int
+}
+
+// serverEditions counts the servers per edition they report, the most common
+// edition first and ties by name. Servers that report no edition, such as
+// offline ones, are left out.
+func serverEditions(servers []madmin.ServerProperties) []editionCount {
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		if server.Edition == "" || seen[server.Endpoint] {
+			continue
+		}
+		seen[server.Endpoint] = true
+		counts[server.Edition]++
+	}
+	editions := make([]editionCount, 0, len(counts))
+	for edition, n := range counts {
+		editions = append(editions, editionCount{Edition: edition, Servers: n})
+	}
+	sort.Slice(editions, func(i, j int) bool {
+		if editions[i].Servers != editions[j].Servers {
+			return editions[i].Servers > editions[j].Servers
+		}
+		return editions[i].Edition < editions[j].Edition
+	})
+	return editions
+}
+
+// editionSummary describes the editions of the servers, e.g. "AGPLv3 (12
+// servers), Enterprise (2 servers) — MIXED" with MIXED in yellow
+func editionSummary(editions []editionCount) string {
+	parts := make([]string, 0, len(editions))
+	for _, edition := range editions {
+		parts = append(parts, fmt.Sprintf("%s (%s)", edition.Edition, countNoun(edition.Servers, "server")))
+	}
+	summary := strings.Join(parts, ", ")
+	if len(editions) > 1 {
+		summary += fmt.Sprintf(" — %sMIXED%s", Yellow, Reset)
+	}
+	return summary
+}
+
+// licenseExpiryWarning is how long before a license expires its expiry is
+// shown in yellow; expired licenses are red
+const licenseExpiryWarning = 30 * 24 * time.Hour
+
+// licenseSummaries describes the distinct licenses the servers report with
+// their plan, organization and expiry, the expiry colored when it is near or
+// past. Servers without license information are left out.
+func licenseSummaries(servers []madmin.ServerProperties, now time.Time) []string {
+	var summaries []string
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		license := server.License
+		if license == nil {
+			continue
+		}
+		key := license.Plan + "|" + license.Organization + "|" + license.ExpiresAt.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		summary := license.Plan
+		if summary == "" {
+			summary = "unknown plan"
+		}
+		if license.Organization != "" {
+			summary += " for " + license.Organization
+		}
+		if license.Trial {
+			summary += " (trial)"
+		}
+		switch left := license.ExpiresAt.Sub(now); {
+		case license.ExpiresAt.IsZero():
+			summary += ", no expiry"
+		case left <= 0:
+			summary += fmt.Sprintf(", %sexpired %s (%s)%s", Red, license.ExpiresAt.UTC().Format("2006-01-02"), formatAgo(-left), Reset)
+		case left < licenseExpiryWarning:
+			summary += fmt.Sprintf(", %sexpires %s (in %s)%s", Yellow, license.ExpiresAt.UTC().Format("2006-01-02"), formatAge(left), Reset)
+		default:
+			summary += fmt.Sprintf(", expires %s (in %s)", license.ExpiresAt.UTC().Format("2006-01-02"), formatAge(left))
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// printILMExpiry prints the ILM expiry summary and the ILM expiry state of
+// every server, for --ilm
+func printILMExpiry(pager *Pager, servers []madmin.ServerProperties, names *mdbcore.ServerNamer, stats ClusterStats, config *Config) {
+	printSectionTitle(pager, config, "ILM Expiry")
+	pager.Printf("  %s\n\n", ilmExpirySummary(stats))
+
+	rows := make([][]string, 0, len(servers))
+	for _, server := range servers {
+		expiry := "idle"
+		switch {
+		case server.State != "online":
+			expiry = Red + "unknown (" + server.State + ")" + Reset
+		case server.ILMExpiryInProgress:
+			expiry = Yellow + "in progress" + Reset
+		}
+		rows = append(rows, []string{names.Name(server.Endpoint), server.State, expiry})
+	}
+	printTableRows(pager, config, []string{"Server", "State", "ILM Expiry"}, rows)
+	pager.Printf("\n")
+}
+
+// printTopologyCheck prints the violations of the pool, set and server layout
+// found by mdbcore.CheckTopology, for --consistency
+func printTopologyCheck(pager *Pager, findings []string, config *Config) {
+	printSectionTitle(pager, config, "Topology")
+	if len(findings) == 0 {
+		pager.Printf("  %sPools, erasure sets and servers are consistent%s\n\n", Green, Reset)
+		return
+	}
+	for _, finding := range findings {
+		pager.Printf("  %s%s%s\n", Yellow, strings.ToUpper(finding[:1])+finding[1:], Reset)
+	}
+	pager.Printf("\n")
+}
+
+// printUUIDWarnings prints summary lines for drives that share a UUID or have
+// none, nothing if there are none
+func printUUIDWarnings(pager *Pager, check mdbcore.UUIDCheck) {
+	if len(check.Duplicates) > 0 {
+		drives := 0
+		for _, group := range check.Duplicates {
+			drives += len(group)
+		}
+		pager.Printf("  Duplicate UUIDs: %s%d%s (%d drives)\n", Red, len(check.Duplicates), Reset, drives)
+	}
+	if check.Empty > 0 {
+		pager.Printf("  Drives without UUID: %s%d%s\n", Yellow, check.Empty, Reset)
+	}
+}
+
+// printUUIDConsistency prints every drive whose UUID is shared with another
+// drive and the number of drives without a UUID, for --consistency
+func printUUIDConsistency(pager *Pager, check mdbcore.UUIDCheck, config *Config) {
+	printSectionTitle(pager, config, "Drive UUIDs")
+	if len(check.Duplicates) == 0 && check.Empty == 0 {
+		pager.Printf("  %sEvery drive has a unique UUID%s\n\n", Green, Reset)
+		return
+	}
+
+	if len(check.Duplicates) > 0 {
+		headers := []string{"UUID", "Server", "Path", "Pool", "Erasure Set", "State"}
+		var rows [][]string
+		for _, group := range check.Duplicates {
+			for _, drive := range group {
+				rows = append(rows, []string{
+					fmt.Sprintf("%s%s%s", Red, drive.UUID, Reset),
+					drive.Server,
+					drive.Path,
+					fmt.Sprintf("%s%d%s", Blue, drive.PoolIndex, Reset),
+					fmt.Sprintf("%s%d%s", Blue, drive.SetIndex, Reset),
+					drive.State,
+				})
+			}
+		}
+		printTableRows(pager, config, headers, rows)
+	}
+	if check.Empty > 0 {
+		pager.Printf("  Drives without UUID: %s%d%s\n", Yellow, check.Empty, Reset)
+	}
+	pager.Printf("\n")
+}
+
+// printEndpointConsistency prints the drives whose endpoint names another host
+// than the server they are listed under, for --consistency
+func printEndpointConsistency(pager *Pager, servers []madmin.ServerProperties, config *Config) {
+	mismatches, compared := mdbcore.CheckEndpointHosts(servers)
+	if compared == 0 {
+		return
+	}
+	printSectionTitle(pager, config, "Drive Endpoints")
+	if len(mismatches) == 0 {
+		pager.Printf("  %sEvery drive endpoint is on its server%s\n\n", Green, Reset)
+		return
+	}
+	headers := []string{"Server", "Drive Endpoint", "Pool", "Erasure Set"}
+	rows := make([][]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		rows = append(rows, []string{
+			m.ServerEndpoint,
+			fmt.Sprintf("%s%s%s", Red, m.DiskEndpoint, Reset),
+			fmt.Sprintf("%s%d%s", Blue, m.PoolIndex, Reset),
+			fmt.Sprintf("%s%d%s", Blue, m.SetIndex, Reset),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("  %s on another host than their server, check the endpoints and DNS aliases\n\n", countNoun(len(mismatches), "drive"))
+}
+
+// printSetMetrics prints the drive metrics summed per erasure set, to spot a
+// struggling set without reading the metrics of every drive
+func printSetMetrics(pager *Pager, allPoolSetDrives map[string][]DiskInfo, config *Config) {
+	summaries := make([]setMetrics, 0, len(allPoolSetDrives))
+	reporting, ioDrives := 0, 0
+	for _, drives := range allPoolSetDrives {
+		if len(drives) == 0 {
+			continue
+		}
+		sm := summarizeSetMetrics(drives[0].PoolIndex, drives[0].SetIndex, drives)
+		reporting += sm.Reporting
+		ioDrives += sm.IODrives
+		summaries = append(summaries, sm)
+	}
+	if len(summaries) == 0 {
+		return
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].PoolIdx != summaries[j].PoolIdx {
+			return summaries[i].PoolIdx < summaries[j].PoolIdx
+		}
+		return summaries[i].SetIdx < summaries[j].SetIdx
+	})
+
+	printSectionTitle(pager, config, "Erasure Set Metrics")
+	if reporting == 0 && ioDrives == 0 {
+		pager.Printf("  No drive reports metrics\n\n")
+		return
+	}
+
+	errorText := func(n uint64) string {
+		if n == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%s%s%s", Red, formatInt(int64(n)), Reset)
+	}
+	headers := []string{"Pool", "Erasure Set", "Drives", "Writes", "Deletes", "Timeouts", "Errors", "Max Waiting"}
+	// IOPS and latency come from --metrics-file, only shown when it has series
+	if ioDrives > 0 {
+		headers = append(headers, "IOPS", "Max Latency")
+	}
+	rows := make([][]string, 0, len(summaries))
+	for _, sm := range summaries {
+		row := []string{
+			fmt.Sprintf("%s%d%s", Blue, sm.PoolIdx, Reset),
+			fmt.Sprintf("%s%d%s", Blue, sm.SetIdx, Reset),
+			fmt.Sprintf("%d of %d", sm.Reporting, sm.Drives),
+		}
+		if sm.Reporting == 0 {
+			row = append(row, "N/A", "N/A", "N/A", "N/A", "N/A")
+		} else {
+			row = append(row,
+				formatInt(int64(sm.Writes)),
+				formatInt(int64(sm.Deletes)),
+				errorText(sm.Timeouts),
+				errorText(sm.Errors),
+				fmt.Sprintf("%s%d%s", waitingColor(sm.MaxWaiting, sm.WaitTokens), sm.MaxWaiting, Reset),
+			)
+		}
+		if ioDrives > 0 {
+			switch {
+			case sm.IODrives == 0:
+				row = append(row, "N/A", "N/A")
+			case sm.MaxLatency == 0:
+				row = append(row, formatIOPS(sm.IOPS), "N/A")
+			default:
+				row = append(row, formatIOPS(sm.IOPS), formatLatency(sm.MaxLatency))
+			}
+		}
+		rows = append(rows, row)
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// setServersShown is how many servers the Servers column of the erasure sets
+// table lists before eliding the rest, unless --wide is given
+const setServersShown = 4
+
+// setServersText lists the distinct servers with drives in a set, in natural
+// order with servers owning bad drives in red. Long lists are elided to
+// "a,b,+14 more" unless wide, listing the servers with bad drives first.
+func setServersText(drives []DiskInfo, wide bool) string {
+	bad := make(map[string]bool)
+	for _, drive := range drives {
+		bad[drive.Server] = bad[drive.Server] || drive.State != "ok"
+	}
+	servers := make([]string, 0, len(bad))
+	for server := range bad {
+		servers = append(servers, server)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return naturalLess(servers[i], servers[j])
+	})
+
+	more := 0
+	if !wide && len(servers) > setServersShown+1 {
+		sort.SliceStable(servers, func(i, j int) bool {
+			return bad[servers[i]] && !bad[servers[j]]
+		})
+		more = len(servers) - setServersShown
+		servers = servers[:setServersShown]
+	}
+
+	parts := make([]string, 0, len(servers)+1)
+	for _, server := range servers {
+		if bad[server] {
+			server = Red + server + Reset
+		}
+		parts = append(parts, server)
+	}
+	if more > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more", more))
+	}
+	return strings.Join(parts, ",")
+}
+
+// setErrorRecency describes whether the drive errors of a set are recent or only
+// historical. It returns "" unless every drive with errors has a timestamp.
+func setErrorRecency(drives []DiskInfo) string {
+	var latest time.Duration
+	erroring := 0
+	for _, drive := range drives {
+		// Like driveLastErrorAge, timeouts count as errors of their own
+		if drive.Metrics == nil || (drive.Metrics.TotalErrorsAvailability == 0 && drive.Metrics.TotalErrorsTimeout == 0) {
+			continue
+		}
+		age, ok := driveLastErrorAge(drive)
+		if !ok {
+			return ""
+		}
+		if erroring == 0 || age < latest {
+			latest = age
+		}
+		erroring++
+	}
+	if erroring == 0 {
+		return ""
+	}
+	if latest > recentErrorWindow {
+		return fmt.Sprintf("%serrors historical, none in last hour%s (last %s)", Green, Reset, formatAgo(latest))
+	}
+	return fmt.Sprintf("%serrors in last hour%s (last %s)", Red, Reset, formatAgo(latest))
+}
+
+// releaseTimePattern matches the timestamp in MinIO versions such as
+// "RELEASE.2024-05-10T01-41-38Z" or "2024-05-10T01:41:38Z"
+var releaseTimePattern = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})T(\d{2})[-:](\d{2})[-:](\d{2})Z`)
+
+// parseReleaseTime returns the release timestamp of a MinIO server version.
+// Development builds and empty versions have no release timestamp.
+func parseReleaseTime(version string) (time.Time, bool) {
+	version = strings.TrimSpace(version)
+	if version == "" || strings.HasPrefix(strings.ToUpper(version), "DEVELOPMENT") {
+		return time.Time{}, false
+	}
+	m := releaseTimePattern.FindStringSubmatch(version)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02T15:04:05Z", fmt.Sprintf("%sT%s:%s:%sZ", m[1], m[2], m[3], m[4]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// releaseName formats a release timestamp the way MinIO names its releases
+func releaseName(t time.Time) string {
+	return "RELEASE." + t.UTC().Format("2006-01-02T15-04-05Z")
+}
+
+// serviceStatus is a row of the Services table
+type serviceStatus struct {
+	service string
+	target  string
+	status  string
+	details string
+}
+
+// collectServices flattens the KMS, LDAP, logger, audit and notification
+// status into rows, targets of each kind sorted by name
+func collectServices(services madmin.Services) []serviceStatus {
+	var rows []serviceStatus
+
+	// Older servers only fill the deprecated single KMS entry
+	kms := services.KMSStatus
+	if len(kms) == 0 && services.KMS != (madmin.KMS{}) {
+		kms = []madmin.KMS{services.KMS}
+	}
+	for _, k := range kms {
+		var details []string
+		if k.Version != "" {
+			details = append(details, "version "+k.Version)
+		}
+		if k.Encrypt != "" && k.Encrypt != "success" {
+			details = append(details, "encrypt: "+k.Encrypt)
+		}
+		if k.Decrypt != "" && k.Decrypt != "success" {
+			details = append(details, "decrypt: "+k.Decrypt)
+		}
+		rows = append(rows, serviceStatus{"KMS", k.Endpoint, k.Status, strings.Join(details, ", ")})
+	}
+
+	if services.LDAP.Status != "" {
+		rows = append(rows, serviceStatus{"LDAP", "", services.LDAP.Status, ""})
+	}
+
+	targets := func(service string, byName map[string]madmin.Status) {
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+		for _, name := range names {
+			rows = append(rows, serviceStatus{service, name, byName[name].Status, ""})
+		}
+	}
+	for _, logger := range services.Logger {
+		targets("Logger", logger)
+	}
+	for _, audit := range services.Audit {
+		targets("Audit", audit)
+	}
+	for _, notifications := range services.Notifications {
+		kinds := make([]string, 0, len(notifications))
+		for kind := range notifications {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			for _, status := range notifications[kind] {
+				targets("Notification ("+kind+")", status)
+			}
+		}
+	}
+	return rows
+}
+
+// serviceStatusColor returns the color of a service or target status
+func serviceStatusColor(status string) string {
+	switch strings.ToLower(status) {
+	case "online", "success":
+		return Green
+	case "offline":
+		return Red
+	}
+	return Yellow
+}
+
+// printServices prints the status of KMS, LDAP, logger, audit and notification
+// targets. Nothing is printed when the snapshot has no services.
+func printServices(pager *Pager, services madmin.Services, config *Config) {
+	statuses := collectServices(services)
+	if len(statuses) == 0 {
+		return
+	}
+
+	printSectionTitle(pager, config, "Services")
+	offline := 0
+	rows := make([][]string, 0, len(statuses))
+	for _, svc := range statuses {
+		if strings.EqualFold(svc.status, "offline") {
+			offline++
+		}
+		status := "unknown"
+		if svc.status != "" {
+			status = svc.status
+		}
+		target := "-"
+		if svc.target != "" {
+			target = svc.target
+		}
+		rows = append(rows, []string{svc.service, target, serviceStatusColor(svc.status) + status + Reset, svc.details})
+	}
+	printTableRows(pager, config, []string{"Service", "Target", "Status", "Details"}, rows)
+	pager.Printf("\n")
+
+	if offline > 0 {
+		pager.Printf("  %s%d of %d services offline%s\n\n", Red, offline, len(statuses), Reset)
+	}
+}
+
+// replicationStatus is the output of "mc admin replicate status --json"
+type replicationStatus struct {
+	Status string `json:"status"`
+	madmin.SRStatusInfo
+}
+
+// loadReplicationStatus reads the site replication status written by "mc admin replicate status --json"
+func loadReplicationStatus(filename string) (*replicationStatus, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication status '%s': %v", filename, err)
+	}
+	var status replicationStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse replication status '%s': %v", filename, err)
+	}
+	if status.Status != "" && status.Status != "success" {
+		return nil, fmt.Errorf("replication status '%s' reports status '%s'", filename, status.Status)
+	}
+	return &status, nil
+}
+
+// replicationMissing describes the entities a site has fewer of than the site with the most
+func replicationMissing(summary madmin.SRSiteSummary, status *replicationStatus) string {
+	var missing []string
+	for _, entity := range []struct {
+		name       string
+		count, max int
+	}{
+		{"buckets", summary.TotalBucketsCount, status.MaxBuckets},
+		{"users", summary.TotalUsersCount, status.MaxUsers},
+		{"groups", summary.TotalGroupsCount, status.MaxGroups},
+		{"policies", summary.TotalIAMPoliciesCount, status.MaxPolicies},
+	} {
+		if entity.count < entity.max {
+			missing = append(missing, fmt.Sprintf("%d %s", entity.max-entity.count, entity.name))
+		}
+	}
+	return strings.Join(missing, ", ")
+}
+
+// printReplication lists the peer sites of site replication with their
+// deployment IDs, replication mode, online state and whether they are in sync.
+// Nothing is printed when the status has no sites.
+func printReplication(pager *Pager, status *replicationStatus, localDeploymentID string, config *Config) {
+	if status == nil || len(status.Sites) == 0 {
+		return
+	}
+
+	deploymentIDs := make([]string, 0, len(status.Sites))
+	for id := range status.Sites {
+		deploymentIDs = append(deploymentIDs, id)
+	}
+	sort.Slice(deploymentIDs, func(i, j int) bool {
+		return naturalLess(status.Sites[deploymentIDs[i]].Name, status.Sites[deploymentIDs[j]].Name)
+	})
+
+	printSectionTitle(pager, config, "Replication")
+	offline, outOfSync := 0, 0
+	rows := make([][]string, 0, len(deploymentIDs))
+	for _, id := range deploymentIDs {
+		peer := status.Sites[id]
+		mode := "async"
+		if peer.SyncState == madmin.SyncEnabled {
+			mode = "sync"
+		}
+
+		// Metrics describe the peers of the site that was queried
+		state := Yellow + "unknown" + Reset
+		if id == localDeploymentID {
+			state = Green + "this cluster" + Reset
+		} else if metric, ok := status.Metrics.Metrics[id]; ok && metric.Online {
+			state = Green + "online" + Reset
+		} else if ok {
+			offline++
+			state = Red + "offline" + Reset
+			if !metric.LastOnline.IsZero() {
+				state = fmt.Sprintf("%soffline since %s%s", Red, metric.LastOnline.UTC().Format(time.RFC3339), Reset)
+			}
+		}
+
+		synced := "N/A"
+		if summary, ok := status.StatsSummary[id]; ok {
+			synced = Green + "yes" + Reset
+			if missing := replicationMissing(summary, status); missing != "" {
+				outOfSync++
+				synced = Yellow + "missing " + missing + Reset
+			}
+		}
+
+		rows = append(rows, []string{peer.Name, id, peer.Endpoint, mode, state, synced})
+	}
+	printTableRows(pager, config, []string{"Site", "Deployment ID", "Endpoint", "Mode", "State", "In Sync"}, rows)
+	pager.Printf("\n")
+
+	if !status.Enabled {
+		pager.Printf("  %sSite replication is not enabled%s\n\n", Yellow, Reset)
+	}
+	if offline > 0 {
+		pager.Printf("  %s%d of %d peer sites offline%s\n\n", Red, offline, len(deploymentIDs), Reset)
+	}
+	if outOfSync > 0 {
+		pager.Printf("  %s%d of %d sites out of sync%s\n\n", Yellow, outOfSync, len(deploymentIDs), Reset)
+	}
+}
+
+// healStatus is the background heal state written by "mc admin heal --json"
+type healStatus struct {
+	madmin.BgHealState
+	// Unmatched are the drives of the heal file missing from the info file
+	Unmatched []madmin.Disk
+}
+
+// loadHealStatus reads the background heal state written by "mc admin heal --json",
+// with or without the "healinfo" wrapper of mc
+func loadHealStatus(filename string) (*healStatus, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read heal status '%s': %v", filename, err)
+	}
+	var wrapped struct {
+		Status   string              `json:"status"`
+		HealInfo *madmin.BgHealState `json:"healinfo"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse heal status '%s': %v", filename, err)
+	}
+	if wrapped.Status != "" && wrapped.Status != "success" {
+		return nil, fmt.Errorf("heal status '%s' reports status '%s'", filename, wrapped.Status)
+	}
+	heal := &healStatus{}
+	if wrapped.HealInfo != nil {
+		heal.BgHealState = *wrapped.HealInfo
+	} else if err := json.Unmarshal(data, &heal.BgHealState); err != nil {
+		return nil, fmt.Errorf("failed to parse heal status '%s': %v", filename, err)
+	}
+	if len(heal.Sets) == 0 {
+		return nil, fmt.Errorf("heal status '%s' has no erasure sets", filename)
+	}
+	return heal, nil
+}
+
+// loadDriveMetrics reads the drive series of a Prometheus metrics dump for
+// --metrics-file. Lines that cannot be parsed and drives that match no drive
+// of servers only warn, their columns show N/A.
+func loadDriveMetrics(filename string, servers []madmin.ServerProperties) (*mdbcore.DriveMetrics, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics '%s': %v", filename, err)
+	}
+	metrics, err := mdbcore.ParseDriveMetrics(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics '%s': %v", filename, err)
+	}
+	if metrics.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d %s* line(s) of '%s' that could not be parsed\n", metrics.Skipped, mdbcore.DriveMetricPrefix, filename)
+	}
+	if len(metrics.Drives) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: '%s' has no %s* series with server and drive labels\n", filename, mdbcore.DriveMetricPrefix)
+		return metrics, nil
+	}
+	matched := 0
+	for _, server := range servers {
+		matched += mdbcore.ApplyDriveIO(mdbcore.ServerDrives(server, nil), server, metrics)
+	}
+	if matched < len(metrics.Drives) {
+		fmt.Fprintf(os.Stderr, "Warning: no drive of the snapshot matches %s of '%s'\n", countNoun(len(metrics.Drives)-matched, "drive"), filename)
+	}
+	return metrics, nil
+}
+
+// poolState is the decommission and rebalance state of a pool read with --pool-status
+type poolState struct {
+	Decommission *madmin.PoolDecommissionInfo
+	Rebalance    string // status of the pool in a rebalance, "" if it takes no part
+}
+
+// decommissionPct returns how much of the data a pool held when its
+// decommission started has been moved off, from the free space at the start
+// and now. ok is false if the pool held no data.
+func (state poolState) decommissionPct() (pct float64, ok bool) {
+	info := state.Decommission
+	usedStart := info.TotalSize - info.StartSize
+	if usedStart <= 0 {
+		return 0, false
+	}
+	usedNow := info.TotalSize - info.CurrentSize
+	pct = float64(usedStart-usedNow) / float64(usedStart) * 100
+	return math.Min(math.Max(pct, 0), 100), true
+}
+
+// rebalancing reports whether the pool takes part in a running rebalance
+func (state poolState) rebalancing() bool {
+	return strings.EqualFold(state.Rebalance, "Started") || strings.EqualFold(state.Rebalance, "Active")
+}
+
+// draining reports whether data is being moved off the pool, by a running
+// decommission or a rebalance that moves data between the pools
+func (state poolState) draining() bool {
+	if info := state.Decommission; info != nil && !info.Complete && !info.Failed && !info.Canceled {
+		return true
+	}
+	return state.rebalancing()
+}
+
+// text describes the state, "active" for a pool that is neither decommissioned
+// nor rebalanced
+func (state poolState) text() string {
+	if info := state.Decommission; info != nil {
+		switch {
+		case info.Complete:
+			return "decommissioned"
+		case info.Failed:
+			return "decommission failed"
+		case info.Canceled:
+			return "decommission canceled"
+		}
+		if pct, ok := state.decommissionPct(); ok {
+			return fmt.Sprintf("decommissioning (%.0f%% complete)", pct)
+		}
+		return "decommissioning"
+	}
+	switch {
+	case state.rebalancing():
+		return "rebalancing"
+	case state.Rebalance != "" && !strings.EqualFold(state.Rebalance, "None"):
+		return "rebalance " + strings.ToLower(state.Rebalance)
+	}
+	return "active"
+}
+
+// loadPoolStatus reads the pool states written by "mc admin decommission status --json"
+// or "mc admin rebalance status --json" for --pool-status: pool statuses, as a
+// list or one per line, or a rebalance status with its pools
+func loadPoolStatus(filename string) (map[int]poolState, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pool status '%s': %v", filename, err)
+	}
+	pools := make(map[int]poolState)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse pool status '%s': %v", filename, err)
+		}
+		if err := addPoolStatus(pools, doc); err != nil {
+			return nil, fmt.Errorf("pool status '%s' %v", filename, err)
+		}
+	}
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("pool status '%s' has no pools", filename)
+	}
+	return pools, nil
+}
+
+// addPoolStatus adds the pools of a document of a --pool-status file to pools
+func addPoolStatus(pools map[int]poolState, doc json.RawMessage) error {
+	if trimmed := bytes.TrimSpace(doc); len(trimmed) > 0 && trimmed[0] == '[' {
+		var docs []json.RawMessage
+		if err := json.Unmarshal(trimmed, &docs); err != nil {
+			return fmt.Errorf("cannot be parsed: %v", err)
+		}
+		for _, doc := range docs {
+			if err := addPoolStatus(pools, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// A rebalance status identifies the operation by a UUID, a pool status the
+	// pool by its index
+	var status struct {
+		Status       string                       `json:"status"`
+		ID           json.RawMessage              `json:"id"`
+		Decommission *madmin.PoolDecommissionInfo `json:"decommissionInfo"`
+		Pools        []madmin.RebalancePoolStatus `json:"pools"`
+	}
+	if err := json.Unmarshal(doc, &status); err != nil {
+		return fmt.Errorf("cannot be parsed: %v", err)
+	}
+	if status.Status != "" && status.Status != "success" {
+		return fmt.Errorf("reports status '%s'", status.Status)
+	}
+	if status.Pools != nil {
+		for _, pool := range status.Pools {
+			state := pools[pool.ID]
+			state.Rebalance = pool.Status
+			pools[pool.ID] = state
+		}
+		return nil
+	}
+	var id int
+	if err := json.Unmarshal(status.ID, &id); err != nil {
+		return fmt.Errorf("is neither a decommission nor a rebalance status")
+	}
+	state := pools[id]
+	state.Decommission = status.Decommission
+	pools[id] = state
+	return nil
+}
+
+// merge copies the heal progress of each drive into the matching drive of
+// infoStruct, matched by endpoint or else UUID, and keeps the drives that
+// have no match
+func (heal *healStatus) merge(infoStruct *clusterStruct) {
+	byEndpoint := make(map[string]*madmin.Disk)
+	byUUID := make(map[string]*madmin.Disk)
+	for i := range infoStruct.Info.Servers {
+		disks := infoStruct.Info.Servers[i].Disks
+		for j := range disks {
+			if disks[j].Endpoint != "" {
+				byEndpoint[disks[j].Endpoint] = &disks[j]
+			}
+			if disks[j].UUID != "" {
+				byUUID[disks[j].UUID] = &disks[j]
+			}
+		}
+	}
+
+	heal.Unmatched = nil
+	for _, set := range heal.Sets {
+		for _, disk := range set.Disks {
+			target := byEndpoint[disk.Endpoint]
+			if target == nil && disk.UUID != "" {
+				target = byUUID[disk.UUID]
+			}
+			if target == nil {
+				heal.Unmatched = append(heal.Unmatched, disk)
+				continue
+			}
+			if disk.HealInfo != nil {
+				target.HealInfo = disk.HealInfo
+				target.Healing = !disk.HealInfo.Finished
+			}
+		}
+	}
+	infoStruct.Heal = heal
+}
+
+// healETA extrapolates the time left to heal a drive from the rate at which
+// its objects were processed so far
+func healETA(info *madmin.HealingDisk) (time.Duration, bool) {
+	processed := mdbcore.HealedItems(info) + info.ItemsFailed + info.ItemsSkipped
+	elapsed := info.LastUpdate.Sub(info.Started)
+	if processed == 0 || elapsed <= 0 || info.ObjectsTotalCount == 0 {
+		return 0, false
+	}
+	if processed >= info.ObjectsTotalCount {
+		return 0, true
+	}
+	remaining := info.ObjectsTotalCount - processed
+	return time.Duration(float64(elapsed) * float64(remaining) / float64(processed)), true
+}
+
+// formatHealPct returns the healed share of a drive's objects, or "N/A" without a total
+func formatHealPct(info *madmin.HealingDisk, config *Config) string {
+	if info == nil || info.ObjectsTotalCount == 0 {
+		return "N/A"
+	}
+	pct, _ := mdbcore.HealProgressPct(info)
+	return fmt.Sprintf("%s%s%s (%s of %s)", Yellow, formatPct(pct, config), Reset, formatInt(int64(mdbcore.HealedItems(info))), formatInt(int64(info.ObjectsTotalCount)))
+}
+
+// printHealProgress prints the overall progress of the drives being healed
+// according to the --heal file, with an ETA of the slowest drive, and the
+// drives of the heal file that are missing from the info file
+func printHealProgress(pager *Pager, heal *healStatus, names *mdbcore.ServerNamer, config *Config) {
+	printSectionTitle(pager, config, "Heal Progress")
+
+	var drives int
+	var healed, failed, total uint64
+	var eta time.Duration
+	etaKnown := false
+	for _, set := range heal.Sets {
+		for _, disk := range set.Disks {
+			if disk.HealInfo == nil || disk.HealInfo.Finished {
+				continue
+			}
+			drives++
+			healed += mdbcore.HealedItems(disk.HealInfo)
+			failed += disk.HealInfo.ItemsFailed
+			total += disk.HealInfo.ObjectsTotalCount
+			if left, ok := healETA(disk.HealInfo); ok {
+				eta = max(eta, left)
+				etaKnown = true
+			}
+		}
+	}
+
+	switch {
+	case drives == 0:
+		pager.Printf("  %sNo drives are healing%s\n", Green, Reset)
+	case total == 0:
+		pager.Printf("  Healing %d drives, progress unknown\n", drives)
+	default:
+		etaText := "unknown"
+		if etaKnown {
+			etaText = humanizeDuration(eta.Round(time.Minute))
+		}
+		pager.Printf("  Healing %d drives: %s%s%s (%s of %s objects healed), ETA %s\n",
+			drives, Yellow, formatPct(float64(healed)/float64(total)*100, config), Reset, formatInt(int64(healed)), formatInt(int64(total)), etaText)
+	}
+	if failed > 0 {
+		pager.Printf("  Failed to heal: %s%s objects%s\n", Red, formatInt(int64(failed)), Reset)
+	}
+	if heal.ScannedItemsCount > 0 {
+		pager.Printf("  Background heal scanned %s items\n", formatInt(heal.ScannedItemsCount))
+	}
+	if len(heal.OfflineEndpoints) > 0 {
+		pager.Printf("  %sOffline, no heal status: %s%s\n", Red, strings.Join(heal.OfflineEndpoints, ", "), Reset)
+	}
+	pager.Printf("\n")
+
+	if len(heal.Unmatched) == 0 {
+		return
+	}
+	pager.Printf("  %d drives of the heal file are not in the info file:\n\n", len(heal.Unmatched))
+	rows := make([][]string, 0, len(heal.Unmatched))
+	for _, disk := range heal.Unmatched {
+		stateColor := driveStateColor(disk.State)
+		healPct := "-"
+		if disk.HealInfo != nil && !disk.HealInfo.Finished {
+			healPct = formatHealPct(disk.HealInfo, config)
+		}
+		path := disk.DrivePath
+		if path == "" {
+			path = mdbcore.PathFromEndpoint(disk.Endpoint)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(disk.PoolIndex),
+			strconv.Itoa(disk.SetIndex),
+			names.Name(disk.Endpoint),
+			path,
+			stateColor + disk.State + Reset,
+			healPct,
+		})
+	}
+	printTableRows(pager, config, []string{"Pool", "Erasure Set", "Server", "Disk Path", "State", "Heal %"}, rows)
+	pager.Printf("\n")
+}
+
+// releaseServer is a server with the pool it serves and its parsed release
+type releaseServer struct {
+	name    string
+	pool    int
+	release time.Time
+	known   bool
+}
+
+// printReleaseTrains groups servers by MinIO release and, when more than one
+// release is running, lists the servers left to upgrade pool by pool
+func printReleaseTrains(pager *Pager, servers []madmin.ServerProperties, names *mdbcore.ServerNamer, config *Config) {
+	// Deduplicate servers by endpoint, keeping the entry that reports a version
+	byName := make(map[string]releaseServer)
+	for _, server := range servers {
+		name := names.Name(server.Endpoint)
+		pool := -1
+		for _, disk := range server.Disks {
+			if pool < 0 || disk.PoolIndex < pool {
+				pool = disk.PoolIndex
+			}
+		}
+		if pool < 0 && len(server.PoolNumbers) > 0 {
+			pool = server.PoolNumbers[0]
+		}
+		release, known := parseReleaseTime(server.Version)
+		if existing, ok := byName[name]; ok && (existing.known || !known) {
+			continue
+		}
+		byName[name] = releaseServer{name: name, pool: pool, release: release, known: known}
+	}
+
+	trains := make(map[time.Time][]releaseServer)
+	var unknown []releaseServer
+	for _, rs := range byName {
+		if rs.known {
+			trains[rs.release] = append(trains[rs.release], rs)
+		} else {
+			unknown = append(unknown, rs)
+		}
+	}
+	if len(trains) == 0 {
+		return
+	}
+
+	releases := make([]time.Time, 0, len(trains))
+	for release := range trains {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Before(releases[j]) })
+
+	// Servers are listed in upgrade order: pool by pool, then by name
+	byUpgradeOrder := func(list []releaseServer) {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].pool != list[j].pool {
+				return list[i].pool < list[j].pool
+			}
+			return naturalLess(list[i].name, list[j].name)
+		})
+	}
+
+	printSectionTitle(pager, config, "Release Trains")
+	newest := releases[len(releases)-1]
+	headers := []string{"Release", "Servers", "Pools"}
+	rows := make([][]string, 0, len(releases)+1)
+	poolList := func(list []releaseServer) string {
+		seen := make(map[int]bool)
+		var pools []string
+		for _, rs := range list {
+			if rs.pool >= 0 && !seen[rs.pool] {
+				seen[rs.pool] = true
+				pools = append(pools, strconv.Itoa(rs.pool))
+			}
+		}
+		if len(pools) == 0 {
+			return "N/A"
+		}
+		return strings.Join(pools, ",")
+	}
+	for _, release := range releases {
+		list := trains[release]
+		byUpgradeOrder(list)
+		name := releaseName(release)
+		if len(releases) > 1 && !release.Equal(newest) {
+			name = Yellow + name + Reset
+		}
+		rows = append(rows, []string{name, strconv.Itoa(len(list)), poolList(list)})
+	}
+	if len(unknown) > 0 {
+		byUpgradeOrder(unknown)
+		rows = append(rows, []string{"unknown (dev build or no version)", strconv.Itoa(len(unknown)), poolList(unknown)})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+
+	if len(releases) == 1 {
+		pager.Printf("  All servers with a known release run %s\n\n", releaseName(newest))
+		return
+	}
+
+	oldest := releases[0]
+	spread := newest.Sub(oldest)
+	pager.Printf("  Release spread: %s%d days%s (%s → %s)\n", Yellow, int(spread.Hours()/24), Reset, releaseName(oldest), releaseName(newest))
+
+	var behind []releaseServer
+	for _, release := range releases[:len(releases)-1] {
+		behind = append(behind, trains[release]...)
+	}
+	byUpgradeOrder(behind)
+	if len(releases) == 2 {
+		pager.Printf("  Rolling upgrade in progress: %d servers remain on %s\n", len(behind), releaseName(oldest))
+	} else {
+		pager.Printf("  %s%d releases running%s: %d servers are behind %s\n", Red, len(releases), Reset, len(behind), releaseName(newest))
+	}
+	pager.Printf("  Upgrade order:\n")
+	for i := 0; i < len(behind); {
+		pool := behind[i].pool
+		var names []string
+		for ; i < len(behind) && behind[i].pool == pool; i++ {
+			names = append(names, behind[i].name)
+		}
+		poolLabel := fmt.Sprintf("Pool %d", pool)
+		if pool < 0 {
+			poolLabel = "Unknown pool"
+		}
+		pager.Printf("    %s: %s\n", poolLabel, strings.Join(names, ", "))
+	}
+	pager.Printf("\n")
+}
+
+// serverHealth aggregates the drives of a single server
+type serverHealth struct {
+	Name           string
+	State          string
+	TotalDrives    int
+	OkDrives       int
+	BadDrives      int
+	ScanningDrives int
+	TotalSpace     int64
+	UsedSpace      int64
+	WorstUsedPct   float64
+}
+
+// printServerHealthSummary prints one row per server with drive counts and capacity usage
+func printServerHealthSummary(pager *Pager, servers []madmin.ServerProperties, names *mdbcore.ServerNamer, config *Config) {
+	healthByServer := make(map[string]*serverHealth)
+	for _, server := range servers {
+		drives := mdbcore.ServerDrives(server, names)
+		name := names.Name(server.Endpoint)
+
+		health, exists := healthByServer[name]
+		if !exists {
+			health = &serverHealth{Name: name, State: server.State}
+			healthByServer[name] = health
+		}
+		// Prefer offline state over online when the same endpoint is listed twice
+		if server.State != "online" {
+			health.State = server.State
+		}
+
+		for _, drive := range drives {
+			health.TotalDrives++
+			if drive.State == "ok" {
+				health.OkDrives++
+			} else {
+				health.BadDrives++
+			}
+			if drive.Scanning {
+				health.ScanningDrives++
+			}
+			health.TotalSpace += drive.TotalSpace
+			health.UsedSpace += drive.UsedSpace
+			if drive.UsedSpacePct > health.WorstUsedPct {
+				health.WorstUsedPct = drive.UsedSpacePct
+			}
+		}
+	}
+
+	if len(healthByServer) == 0 {
+		return
+	}
+
+	serverNames := make([]string, 0, len(healthByServer))
+	for name := range healthByServer {
+		serverNames = append(serverNames, name)
+	}
+	sort.Slice(serverNames, func(i, j int) bool {
+		return naturalLess(serverNames[i], serverNames[j])
+	})
+
+	printSectionTitle(pager, config, "Server Health Summary")
+
+	headers := []string{"Server", "State", "Drives", "OK", "Bad", "Scanning", "Raw Capacity", "Used", "Worst Drive Used"}
+	rows := make([][]string, 0, len(serverNames))
+	for _, name := range serverNames {
+		health := healthByServer[name]
+
+		stateColor := Green
+		if health.State != "online" {
+			stateColor = Red
+		}
+
+		okText := fmt.Sprintf("%d", health.OkDrives)
+		if health.OkDrives > 0 {
+			okText = fmt.Sprintf("%s%d%s", Green, health.OkDrives, Reset)
+		}
+		badText := fmt.Sprintf("%d", health.BadDrives)
+		if health.BadDrives > 0 {
+			badText = fmt.Sprintf("%s%d%s", Red, health.BadDrives, Reset)
+		}
+		scanningText := fmt.Sprintf("%d", health.ScanningDrives)
+		if health.ScanningDrives > 0 {
+			scanningText = fmt.Sprintf("%s%d%s", Yellow, health.ScanningDrives, Reset)
+		}
+
+		// Offline servers report no reliable space figures
+		rawText, usedText, worstText := "N/A", "N/A", "N/A"
+		if health.State == "online" && health.TotalSpace > 0 {
+			usedPct := float64(health.UsedSpace) / float64(health.TotalSpace) * 100
+			rawText = formatSize(health.TotalSpace, config)
+			usedText = fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset)
+			worstText = fmt.Sprintf("%s%s%s", usageColor(health.WorstUsedPct, config), formatPct(health.WorstUsedPct, config), Reset)
+		}
+
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%s%s%s", stateColor, health.State, Reset),
+			fmt.Sprintf("%d", health.TotalDrives),
+			okText,
+			badText,
+			scanningText,
+			rawText,
+			usedText,
+			worstText,
+		})
+	}
+
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// Sort keys accepted by --sort-by for the --server-capacity table
+var serverCapacitySortKeys = []string{"server", "drives", "raw", "used", "used-pct", "free"}
+
+// Sort keys accepted by --sort-by for the drives table of "mdb drives"
+var driveSortKeys = []string{"server", "raw", "used", "used-pct", "free"}
+
+// sortDrivesBy stably sorts drives by sortBy with the largest values first,
+// or by server name, keeping their order for equal keys. An empty sortBy
+// leaves the drives as they are.
+func sortDrivesBy(drives []DiskInfo, sortBy string) {
+	if sortBy == "" {
+		return
+	}
+	key := func(d DiskInfo) float64 {
+		switch sortBy {
+		case "raw":
+			return float64(d.TotalSpace)
+		case "used":
+			return float64(d.UsedSpace)
+		case "used-pct":
+			return d.UsedSpacePct
+		case "free":
+			return float64(d.AvailableSpace)
+		}
+		return 0
+	}
+	sort.SliceStable(drives, func(i, j int) bool {
+		if sortBy == "server" {
+			return naturalLess(drives[i].Server, drives[j].Server)
+		}
+		return key(drives[i]) > key(drives[j])
+	})
+}
+
+// setSortRisk is the --sets-sort key that sorts the erasure sets table by
+// mdbcore.RiskLess, the sets at most risk first
+const setSortRisk = "risk"
+
+// Sort keys accepted by --sets-sort for the erasure sets table
+var setSortKeys = []string{setSortRisk}
+
+// serverCapacity holds the space of one server summed over its drives
+type serverCapacity struct {
+	Name       string
+	State      string
+	Drives     int
+	TotalSpace int64
+	UsedSpace  int64
+	FreeSpace  int64
+}
+
+// usedPct returns the used share of the server's raw capacity in percent
+func (c *serverCapacity) usedPct() float64 {
+	if c.TotalSpace == 0 {
+		return 0
+	}
+	return float64(c.UsedSpace) / float64(c.TotalSpace) * 100
+}
+
+// serverCapacities sums the DiskInfo of each server's drives, sorted by sortBy
+// with the largest values first, or by server name
+func serverCapacities(servers []madmin.ServerProperties, names *mdbcore.ServerNamer, sortBy string) []*serverCapacity {
+	byServer := make(map[string]*serverCapacity)
+	for _, server := range servers {
+		name := names.Name(server.Endpoint)
+		capacity, exists := byServer[name]
+		if !exists {
+			capacity = &serverCapacity{Name: name, State: server.State}
+			byServer[name] = capacity
+		}
+		// Prefer offline state over online when the same endpoint is listed twice
+		if server.State != "online" {
+			capacity.State = server.State
+		}
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			capacity.Drives++
+			capacity.TotalSpace += drive.TotalSpace
+			capacity.UsedSpace += drive.UsedSpace
+			capacity.FreeSpace += drive.AvailableSpace
+		}
+	}
+
+	capacities := make([]*serverCapacity, 0, len(byServer))
+	for _, capacity := range byServer {
+		capacities = append(capacities, capacity)
+	}
+	key := func(c *serverCapacity) float64 {
+		switch sortBy {
+		case "drives":
+			return float64(c.Drives)
+		case "raw":
+			return float64(c.TotalSpace)
+		case "used":
+			return float64(c.UsedSpace)
+		case "used-pct":
+			return c.usedPct()
+		case "free":
+			return float64(c.FreeSpace)
+		}
+		return 0
+	}
+	sort.Slice(capacities, func(i, j int) bool {
+		if ki, kj := key(capacities[i]), key(capacities[j]); ki != kj {
+			return ki > kj
+		}
+		return naturalLess(capacities[i].Name, capacities[j].Name)
+	})
+	return capacities
+}
+
+// printServerCapacity prints the raw, used and free space per server and the
+// share of the cluster's raw capacity each one holds, with a totals row
+func printServerCapacity(pager *Pager, servers []madmin.ServerProperties, names *mdbcore.ServerNamer, config *Config) {
+	capacities := serverCapacities(servers, names, config.CapacitySort)
+	if len(capacities) == 0 {
+		return
+	}
+
+	total := serverCapacity{Name: "Total"}
+	for _, capacity := range capacities {
+		total.Drives += capacity.Drives
+		total.TotalSpace += capacity.TotalSpace
+		total.UsedSpace += capacity.UsedSpace
+		total.FreeSpace += capacity.FreeSpace
+	}
+
+	printSectionTitle(pager, config, "Server Capacity")
+
+	headers := []string{"Server", "Drives", "Raw Capacity", "Used", "Used %", "Free", "Share"}
+	row := func(capacity *serverCapacity) []string {
+		share := 0.0
+		if total.TotalSpace > 0 {
+			share = float64(capacity.TotalSpace) / float64(total.TotalSpace) * 100
+		}
+		usedPct := capacity.usedPct()
+		return []string{
+			capacity.Name,
+			fmt.Sprintf("%d", capacity.Drives),
+			formatSize(capacity.TotalSpace, config),
+			formatSize(capacity.UsedSpace, config),
+			fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset),
+			formatSize(capacity.FreeSpace, config),
+			formatPct(share, config),
+		}
+	}
+	rows := make([][]string, 0, len(capacities)+1)
+	for _, capacity := range capacities {
+		rows = append(rows, row(capacity))
+	}
+	rows = append(rows, row(&total))
+	printTableRows(pager, config, headers, rows)
+
+	offline := 0
+	for _, capacity := range capacities {
+		if capacity.State != "online" {
+			offline++
+		}
+	}
+	if offline > 0 {
+		pager.Printf("%s%s offline, their drives report no space.%s\n", Yellow, countNoun(offline, "server"), Reset)
+	}
+	pager.Printf("\n")
+}
+
+// offlineServer is an offline server with the drives it contributes, as listed
+// by the Offline Servers block
+type offlineServer struct {
+	Name    string
+	Pools   []int
+	Sets    map[int][]int // erasure sets of its drives by pool
+	Drives  int
+	Version string // last known, empty if the snapshot has none
+	Uptime  int64  // last known, in seconds
+}
+
+// offlineServers returns the servers that are not online sorted by name,
+// entries of the same server merged
+func offlineServers(servers []madmin.ServerProperties, names *mdbcore.ServerNamer) []*offlineServer {
+	byName := make(map[string]*offlineServer)
+	var offline []*offlineServer
+	for _, server := range servers {
+		if server.State == "online" {
+			continue
+		}
+		name := names.Name(server.Endpoint)
+		entry := byName[name]
+		if entry == nil {
+			entry = &offlineServer{Name: name, Sets: make(map[int][]int)}
+			byName[name] = entry
+			offline = append(offline, entry)
+		}
+		if entry.Version == "" {
+			entry.Version, entry.Uptime = server.Version, server.Uptime
+		}
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			entry.Drives++
+			if !slices.Contains(entry.Pools, drive.PoolIndex) {
+				entry.Pools = append(entry.Pools, drive.PoolIndex)
+			}
+			if !slices.Contains(entry.Sets[drive.PoolIndex], drive.SetIndex) {
+				entry.Sets[drive.PoolIndex] = append(entry.Sets[drive.PoolIndex], drive.SetIndex)
+			}
+		}
+	}
+	for _, entry := range offline {
+		sort.Ints(entry.Pools)
+		for _, sets := range entry.Sets {
+			sort.Ints(sets)
+		}
+	}
+	sort.Slice(offline, func(i, j int) bool {
+		return naturalLess(offline[i].Name, offline[j].Name)
+	})
+	return offline
+}
+
+// printOfflineServers prints the offline servers with the pools, drives and
+// erasure sets they take down and their last known version and uptime
+func printOfflineServers(pager *Pager, offline []*offlineServer, config *Config) {
+	printSectionTitle(pager, config, "Offline Servers")
+
+	headers := []string{"Server", "Pools", "Drives", "Erasure Sets", "Version", "Uptime"}
+	rows := make([][]string, 0, len(offline))
+	drives := 0
+	for _, server := range offline {
+		drives += server.Drives
+		pools := "N/A"
+		if len(server.Pools) > 0 {
+			pools = joinInts(server.Pools, ",")
+		}
+		sets := make([]string, 0, len(server.Pools))
+		for _, pool := range server.Pools {
+			sets = append(sets, fmt.Sprintf("pool %d: %s", pool, joinInts(server.Sets[pool], ",")))
+		}
+		setsText := "N/A"
+		if len(sets) > 0 {
+			setsText = strings.Join(sets, "; ")
+		}
+		version := "N/A"
+		if server.Version != "" {
+			version = server.Version
+		}
+		uptime := "N/A"
+		if server.Uptime > 0 {
+			uptime = compactDuration(time.Duration(server.Uptime) * time.Second)
+		}
+		rows = append(rows, []string{
+			newTag(fmt.Sprintf("%s%s%s", Red, server.Name, Reset), config.BaselineChanges.newServer(server.Name)),
+			pools,
+			fmt.Sprintf("%d", server.Drives),
+			setsText,
+			version,
+			uptime,
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("%s%s offline with %s.%s\n\n", Red, countNoun(len(offline), "server"), countNoun(drives, "drive"), Reset)
+}
+
+// otherGroup is the --group-by group of the servers the pattern does not match
+const otherGroup = "other"
+
+// failureGroup sums the servers and drives of one --group-by group
+type failureGroup struct {
+	Name           string
+	Servers        int
+	OfflineServers int
+	Drives         int
+	BadDrives      int
+	TotalSpace     int64
+	UsedSpace      int64
+}
+
+// usedPct returns the used share of the group's raw capacity in percent
+func (g *failureGroup) usedPct() float64 {
+	if g.TotalSpace == 0 {
+		return 0
+	}
+	return float64(g.UsedSpace) / float64(g.TotalSpace) * 100
+}
+
+// failureGroupKey returns the first capture group of pattern in a server name,
+// or otherGroup if the pattern does not match or captures nothing
+func failureGroupKey(pattern *regexp.Regexp, name string) string {
+	if match := pattern.FindStringSubmatch(name); len(match) > 1 && match[1] != "" {
+		return match[1]
+	}
+	return otherGroup
+}
+
+// failureGroups sums the servers and drives of each --group-by group, keyed by
+// the server names shown in the tables, in natural order with otherGroup last
+func failureGroups(servers []madmin.ServerProperties, names *mdbcore.ServerNamer, pattern *regexp.Regexp) []*failureGroup {
+	byName := make(map[string]*failureGroup)
+	for _, server := range servers {
+		key := failureGroupKey(pattern, names.Name(server.Endpoint))
+		group, exists := byName[key]
+		if !exists {
+			group = &failureGroup{Name: key}
+			byName[key] = group
+		}
+		group.Servers++
+		if server.State != "online" {
+			group.OfflineServers++
+		}
+		for _, drive := range mdbcore.ServerDrives(server, names) {
+			group.Drives++
+			if drive.State != "ok" {
+				group.BadDrives++
+			}
+			group.TotalSpace += drive.TotalSpace
+			group.UsedSpace += drive.UsedSpace
+		}
+	}
+
+	groups := make([]*failureGroup, 0, len(byName))
+	for _, group := range byName {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Name == otherGroup) != (groups[j].Name == otherGroup) {
+			return groups[j].Name == otherGroup
+		}
+		return naturalLess(groups[i].Name, groups[j].Name)
+	})
+	return groups
+}
+
+// printFailureGroups prints the servers, drives and capacity of each
+// --group-by group, to see whether failures cluster in one rack or row
+func printFailureGroups(pager *Pager, groups []*failureGroup, config *Config) {
+	if len(groups) == 0 {
+		return
+	}
+	printSectionTitle(pager, config, fmt.Sprintf("Failure Domains (--group-by %s)", config.GroupByPattern))
+	count := func(n int, color string) string {
+		if n == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%s%d%s", color, n, Reset)
+	}
+	headers := []string{"Group", "Servers", "Offline", "Drives", "Bad Drives", "Raw Capacity", "Used", "Used %"}
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		usedPct := group.usedPct()
+		rows = append(rows, []string{
+			group.Name,
+			strconv.Itoa(group.Servers),
+			count(group.OfflineServers, Red),
+			strconv.Itoa(group.Drives),
+			count(group.BadDrives, Red),
+			formatSize(group.TotalSpace, config),
+			formatSize(group.UsedSpace, config),
+			fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// pathStat sums the drives of one --by-path drive path over all servers
+type pathStat struct {
+	Path        string
+	Drives      int
+	BadDrives   int
+	SizedDrives int     // drives that report their capacity
+	UsedPctSum  float64 // used space percentages of the sized drives
+}
+
+// badPct returns the share of bad drives in percent
+func (p *pathStat) badPct() float64 {
+	if p.Drives == 0 {
+		return 0
+	}
+	return float64(p.BadDrives) / float64(p.Drives) * 100
+}
+
+// avgUsedPct returns the average used space of the drives that report their capacity
+func (p *pathStat) avgUsedPct() float64 {
+	if p.SizedDrives == 0 {
+		return 0
+	}
+	return p.UsedPctSum / float64(p.SizedDrives)
+}
+
+// drivePathKey returns the first capture group of pattern in a drive path, or
+// the path itself if there is no pattern, it does not match or captures nothing
+func drivePathKey(pattern *regexp.Regexp, path string) string {
+	if pattern == nil {
+		return path
+	}
+	if match := pattern.FindStringSubmatch(path); len(match) > 1 && match[1] != "" {
+		return match[1]
+	}
+	return path
+}
+
+// pathStats sums the drives of each --by-path path, most bad drives first,
+// then in natural order of the paths
+func pathStats(poolSetDrives map[string][]DiskInfo, pattern *regexp.Regexp) []*pathStat {
+	byPath := make(map[string]*pathStat)
+	for _, drives := range poolSetDrives {
+		for _, drive := range drives {
+			key := drivePathKey(pattern, drive.Path)
+			stat, exists := byPath[key]
+			if !exists {
+				stat = &pathStat{Path: key}
+				byPath[key] = stat
+			}
+			stat.Drives++
+			if drive.State != "ok" {
+				stat.BadDrives++
+			}
+			if drive.TotalSpace > 0 {
+				stat.SizedDrives++
+				stat.UsedPctSum += drive.UsedSpacePct
+			}
+		}
+	}
+
+	stats := make([]*pathStat, 0, len(byPath))
+	for _, stat := range byPath {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].BadDrives != stats[j].BadDrives {
+			return stats[i].BadDrives > stats[j].BadDrives
+		}
+		return naturalLess(stats[i].Path, stats[j].Path)
+	})
+	return stats
+}
+
+// printPathStats prints the drives, bad drives and average used space of each
+// --by-path path, to find mount slots that fail across servers
+func printPathStats(pager *Pager, stats []*pathStat, config *Config) {
+	if len(stats) == 0 {
+		return
+	}
+	title := "Drives by Path"
+	if config.PathRegex != nil {
+		title = fmt.Sprintf("Drives by Path (--path-regex %s)", config.PathPattern)
+	}
+	printSectionTitle(pager, config, title)
+	headers := []string{"Path", "Drives", "Bad Drives", "Bad %", "Avg Used %"}
+	rows := make([][]string, 0, len(stats))
+	for _, stat := range stats {
+		bad, badPct := "0", formatPct(0, config)
+		if stat.BadDrives > 0 {
+			bad = fmt.Sprintf("%s%d%s", Red, stat.BadDrives, Reset)
+			badPct = fmt.Sprintf("%s%s%s", Red, formatPct(stat.badPct(), config), Reset)
+		}
+		usedPct := stat.avgUsedPct()
+		rows = append(rows, []string{
+			stat.Path,
+			strconv.Itoa(stat.Drives),
+			bad,
+			badPct,
+			fmt.Sprintf("%s%s%s", usageColor(usedPct, config), formatPct(usedPct, config), Reset),
+		})
+	}
+	printTableRows(pager, config, headers, rows)
+	pager.Printf("\n")
+}
+
+// renderSettings are the formatting settings of a report, resolved from the
+// flags of its Config by newRenderSettings and read by the formatting helpers
+type renderSettings struct {
+	Units         string        // units of formatSize, from --units
+	FixedUnit     string        // unit of formatDriveSize from --fixed-unit, empty to pick one per size
+	Precision     int           // decimals of formatSize and formatPct, from --precision
+	Thresholds    pctThresholds // color thresholds, from --warn-used and friends
+	CritFreeBytes int64         // see configCritFreeBytes, 0 colors free space by percentage
+	HealStuckAge  time.Duration // heals running longer are shown in red, from --heal-stuck
+	NoSets        bool          // the backend is not erasure coded, drive tables have no pool or set columns
+}
+
+// newRenderSettings resolves the render settings of config
+func newRenderSettings(config *Config) *renderSettings {
+	settings := &renderSettings{
+		Units:         config.Units,
+		FixedUnit:     config.FixedUnit,
+		Precision:     configPrecision(config),
+		Thresholds:    colorThresholds(config),
+		CritFreeBytes: configCritFreeBytes(config),
+		HealStuckAge:  defaultHealStuckAge,
+	}
+	if config.HealStuck > 0 {
+		settings.HealStuckAge = config.HealStuck
+	}
+	return settings
+}
+
+// settings returns the render settings of config, resolved on first use.
+// renderReport resolves them again for every report.
+func (config *Config) settings() *renderSettings {
+	if config.Settings == nil {
+		config.Settings = newRenderSettings(config)
+	}
+	return config.Settings
+}
+
+// formatSize formats a size in bytes in the units of --units: binary units
+// (1 TiB = 1024 GiB) by default, decimal units (1 TB = 1000 GB) or plain bytes.
+// Every size in tables and summaries goes through it so the labels match the numbers.
+func formatSize(size int64, config *Config) string {
+	settings := config.settings()
+	if settings.Units == unitsBytes {
+		return fmt.Sprintf("%d B", size)
+	}
+	base, units := 1024.0, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	if settings.Units == unitsSI {
+		base, units = 1000.0, []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	}
+	value := math.Abs(float64(size))
+	sign := ""
+	if size < 0 {
+		sign = "-"
+	}
+	if value < base {
+		return fmt.Sprintf("%s%d B", sign, int64(value))
+	}
+	unit := 0
+	for value >= base && unit < len(units)-1 {
+		value /= base
+		unit++
+	}
+	// 1023.96 GiB rounds up to the next unit rather than printing as 1024.0 GiB
+	scale := math.Pow10(settings.Precision)
+	if math.Round(value*scale)/scale >= base && unit < len(units)-1 {
+		value /= base
+		unit++
+	}
+	return fmt.Sprintf("%s%.*f %s", sign, settings.Precision, value, units[unit])
+}
+
+// formatDriveSize formats a size of the drive tables like formatSize, or always
+// in the unit of --fixed-unit so every row of a column can be compared and parsed
+func formatDriveSize(size int64, config *Config) string {
+	settings := config.settings()
+	switch settings.FixedUnit {
+	case fixedUnitGiB:
+		return fmt.Sprintf("%.*f %s", settings.Precision, float64(size)/(1<<30), fixedUnitGiB)
+	case fixedUnitTiB:
+		return fmt.Sprintf("%.*f %s", settings.Precision, float64(size)/(1<<40), fixedUnitTiB)
+	}
+	return formatSize(size, config)
+}
+
+// defaultPrecision is the number of decimals of sizes and percentages unless
+// --precision sets another
+const defaultPrecision = 1
+
+// maxPrecision is the most decimals --precision accepts, beyond it float64
+// sizes show noise rather than digits
+const maxPrecision = 6
+
+// configPrecision returns the --precision of config, or the default if it is not set
+func configPrecision(config *Config) int {
+	if config.Precision != nil {
+		return *config.Precision
+	}
+	return defaultPrecision
+}
+
+// formatPct formats a percentage with the decimals of --precision
+func formatPct(pct float64, config *Config) string {
+	return strconv.FormatFloat(pct, 'f', config.settings().Precision, 64) + "%"
+}
+
+// signedPct formats a change of percentage with its sign, like formatPct
+func signedPct(delta float64, config *Config) string {
+	if delta >= 0 {
+		return "+" + formatPct(delta, config)
+	}
+	return formatPct(delta, config)
+}
+
+// exactSize formats a size like formatSize, followed by the exact number of
+// bytes with --exact unless the size is already shown in bytes
+func exactSize(size int64, config *Config) string {
+	if !config.Exact || config.settings().Units == unitsBytes {
+		return formatSize(size, config)
+	}
+	return fmt.Sprintf("%s (%s bytes)", formatSize(size, config), formatInt(size))
+}
+
+// Severities of a percentage against its thresholds, shown as green, yellow and red
+const (
+	severityOK       = "ok"
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+// pctThresholds are the percentages at which used space, free space and inode
+// usage become a warning or critical. Free space is bad when it is low, so its
+// warning threshold is above its critical one. Unaccounted space has a warning
+// threshold only.
+type pctThresholds struct {
+	WarnUsed, CritUsed     float64
+	WarnFree, CritFree     float64
+	WarnInodes, CritInodes float64
+	WarnUnaccounted        float64
+}
+
+// defaultThresholds are the thresholds without any --warn-* or --crit-* flags
+var defaultThresholds = pctThresholds{
+	WarnUsed: 80, CritUsed: 95,
+	WarnFree: 20, CritFree: 5,
+	WarnInodes: 80, CritInodes: 95,
+	WarnUnaccounted: 10,
+}
+
+// thresholdFlags are the threshold flags and the threshold each one sets
+var thresholdFlags = []struct {
+	Name  string
+	Field func(t *pctThresholds) *float64
+}{
+	{"warn-used", func(t *pctThresholds) *float64 { return &t.WarnUsed }},
+	{"crit-used", func(t *pctThresholds) *float64 { return &t.CritUsed }},
+	{"warn-free", func(t *pctThresholds) *float64 { return &t.WarnFree }},
+	{"crit-free", func(t *pctThresholds) *float64 { return &t.CritFree }},
+	{"warn-inodes", func(t *pctThresholds) *float64 { return &t.WarnInodes }},
+	{"crit-inodes", func(t *pctThresholds) *float64 { return &t.CritInodes }},
+	{"warn-unaccounted", func(t *pctThresholds) *float64 { return &t.WarnUnaccounted }},
+}
+
+// colorThresholds returns the thresholds of config, or the defaults if they are not set
+func colorThresholds(config *Config) pctThresholds {
+	if config.Thresholds == (pctThresholds{}) {
+		return defaultThresholds
+	}
+	return config.Thresholds
+}
+
+// parseThresholds returns the default thresholds with those of the threshold flags of ctx
+func parseThresholds(ctx *cli.Context) (pctThresholds, error) {
+	t := defaultThresholds
+	for _, flag := range thresholdFlags {
+		if ctx.String(flag.Name) == "" {
+			continue
+		}
+		val, err := parsePercent(ctx.String(flag.Name))
+		if err != nil {
+			return t, fmt.Errorf("invalid --%s value: %v", flag.Name, err)
+		}
+		*flag.Field(&t) = val
+	}
+	switch {
+	case t.WarnUsed > t.CritUsed:
+		return t, fmt.Errorf("--warn-used (%g) must not be above --crit-used (%g)", t.WarnUsed, t.CritUsed)
+	case t.WarnFree < t.CritFree:
+		return t, fmt.Errorf("--warn-free (%g) must not be below --crit-free (%g)", t.WarnFree, t.CritFree)
+	case t.WarnInodes > t.CritInodes:
+		return t, fmt.Errorf("--warn-inodes (%g) must not be above --crit-inodes (%g)", t.WarnInodes, t.CritInodes)
+	}
+	return t, nil
+}
+
+// severityForPct classifies a percentage against its warning and critical
+// thresholds. With invert low values are bad, as for free space.
+func severityForPct(value, warn, crit float64, invert bool) string {
+	if invert {
+		value, warn, crit = -value, -warn, -crit
+	}
+	switch {
+	case value >= crit:
+		return severityCritical
+	case value >= warn:
+		return severityWarning
+	}
+	return severityOK
+}
+
+// colorForPct returns the color of a percentage against its warning and
+// critical thresholds, see severityForPct
+func colorForPct(value, warn, crit float64, invert bool) string {
+	switch severityForPct(value, warn, crit, invert) {
+	case severityCritical:
+		return Red
+	case severityWarning:
+		return Yellow
+	}
+	return Green
+}
+
+// usageColor returns the color for a used space percentage
+func usageColor(usedPct float64, config *Config) string {
+	thresholds := config.settings().Thresholds
+	return colorForPct(usedPct, thresholds.WarnUsed, thresholds.CritUsed, false)
+}
+
+// Bar widths for --bars: the default when the terminal width is unknown and
+// the bounds of the width derived from it
+const (
+	defaultBarWidth = 20
+	minBarWidth     = 10
+	maxBarWidth     = 40
+)
+
+// barWidth returns the width of --bars, an eighth of the report width when
+// it is limited by --width or the terminal
+func barWidth(config *Config) int {
+	if config.Width <= 0 {
+		return defaultBarWidth
+	}
+	return min(max(config.Width/8, minBarWidth), maxBarWidth)
+}
+
+// usageBar draws a used space percentage as a bar followed by the percentage,
+// colored like the percentage, e.g. [████████░░░░] 62.0%
+func usageBar(usedPct float64, config *Config) string {
+	full, empty := "█", "░"
+	if config.ASCII {
+		full, empty = "#", "-"
+	}
+	width := barWidth(config)
+	filled := int(math.Round(min(max(usedPct, 0), 100) / 100 * float64(width)))
+	return fmt.Sprintf("%s[%s%s] %s%s", usageColor(usedPct, config), strings.Repeat(full, filled), strings.Repeat(empty, width-filled), formatPct(usedPct, config), Reset)
+}
+
+// freeColor returns the color for a free space percentage
+func freeColor(freePct float64, config *Config) string {
+	thresholds := config.settings().Thresholds
+	return colorForPct(freePct, thresholds.WarnFree, thresholds.CritFree, true)
+}
+
+// Values of --free-color-by
+const (
+	freeColorByPct   = "pct"
+	freeColorByBytes = "bytes"
+)
+
+// defaultCritFreeBytes is the free space below which --free-color-by bytes
+// colors a drive red unless --crit-free-bytes sets another
+const defaultCritFreeBytes = 100 << 30
+
+// configCritFreeBytes returns the free space below which the Free Space column
+// of a drive is red and below twice which it is yellow, with --free-color-by
+// bytes; 0 colors by percentage
+func configCritFreeBytes(config *Config) int64 {
+	if config.FreeColorBy != freeColorByBytes {
+		return 0
+	}
+	if config.CritFreeBytes > 0 {
+		return config.CritFreeBytes
+	}
+	return defaultCritFreeBytes
+}
+
+// driveFreeColor returns the color of the free space of a drive, by
+// percentage or with --free-color-by bytes by its absolute free space
+func driveFreeColor(drive DiskInfo, config *Config) string {
+	critFreeBytes := config.settings().CritFreeBytes
+	if critFreeBytes <= 0 {
+		return freeColor(drive.FreeSpacePct, config)
+	}
+	switch {
+	case drive.AvailableSpace < critFreeBytes:
+		return Red
+	case drive.AvailableSpace < 2*critFreeBytes:
+		return Yellow
+	}
+	return Green
+}
+
+// inodeColor returns the color for an inode usage percentage
+func inodeColor(inodePct float64, config *Config) string {
+	thresholds := config.settings().Thresholds
+	return colorForPct(inodePct, thresholds.WarnInodes, thresholds.CritInodes, false)
+}
+
+// printTableRows prints an aligned table with a header separator, accounting for ANSI codes.
+// In markdown format the table is rendered as a GitHub-flavored Markdown table instead.
+func printTableRows(pager *Pager, config *Config, headers []string, rows [][]string) {
+	switch config.Format {
+	case formatMarkdown:
+		printMarkdownTable(pager, headers, rows)
+		return
+	case formatCSV:
+		pager.addReportTable(headers, rows)
+		return
+	}
+
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := visibleWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	// Tables wider than --width or the terminal are narrowed to fit
+	fitted := config.Width > 0 && tableLineWidth(widths) > config.Width
+	if fitted {
+		minWidths := make([]int, len(headers))
+		for i, h := range headers {
+			minWidths[i] = min(widths[i], max(utf8.RuneCountInString(h), minColumnWidth))
+		}
+		widths = fitTableWidths(widths, minWidths, config.Width)
+	}
+
+	// Each line is built first and printed with a single write
+	var line strings.Builder
+	writeLine := func(cells []string) {
+		line.Reset()
+		line.WriteString("  ")
+		for i, w := range widths {
+			cell := cells[i]
+			if fitted {
+				cell = truncateVisible(cell, w)
+			}
+			line.WriteString(padString(cell, w))
+			if i < len(widths)-1 {
+				line.WriteString("  ")
+			}
+		}
+		line.WriteByte('\n')
+		pager.WriteString(line.String())
+	}
+
+	writeLine(headers)
+	separators := make([]string, len(widths))
+	for i, w := range widths {
+		separators[i] = strings.Repeat("-", w)
+	}
+	writeLine(separators)
+	for _, row := range rows {
+		writeLine(row)
+	}
+	if hidden := len(headers) - len(widths); hidden > 0 {
+		pager.Printf("  %s(%s not shown: %s; widen the terminal or use --width 0)%s\n",
+			Yellow, countNoun(hidden, "column"), strings.Join(headers[len(widths):], ", "), Reset)
+	}
+}
+
+// minColumnWidth is the width table columns are narrowed to before columns
+// are dropped, unless their header is wider
+const minColumnWidth = 8
+
+// tableLineWidth returns the width of a table line with columns of widths
+func tableLineWidth(widths []int) int {
+	n := 2 + 2*(len(widths)-1)
+	for _, w := range widths {
+		n += w
+	}
+	return n
+}
+
+// fitTableWidths narrows the widest columns of a table one at a time until its
+// lines fit in limit, but not below minWidths. Columns are dropped from the
+// right while that is not enough. It returns the widths of the columns kept.
+func fitTableWidths(widths, minWidths []int, limit int) []int {
+	for n := len(widths); ; n-- {
+		fitted := slices.Clone(widths[:n])
+		for tableLineWidth(fitted) > limit {
+			widest := -1
+			for i, w := range fitted {
+				if w > minWidths[i] && (widest < 0 || w > fitted[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			fitted[widest]--
+		}
+		if n == 1 || tableLineWidth(fitted) <= limit {
+			return fitted
+		}
+	}
+}
+
+// truncateVisible shortens s to width visible characters, ending in "...",
+// keeping its ANSI colors
+func truncateVisible(s string, width int) string {
+	if visibleWidth(s) <= width {
+		return s
+	}
+	keep := width - 3
+	if keep < 0 {
+		keep = width
+	}
+	var result strings.Builder
+	visible := 0
+	inANSI, colored := false, false
+	for _, r := range s {
+		if r == '\033' {
+			inANSI, colored = true, true
+			result.WriteRune(r)
+			continue
+		}
+		if inANSI {
+			if r == 'm' {
+				inANSI = false
+			}
+			result.WriteRune(r)
+			continue
+		}
+		if visible == keep {
+			// Keep copying color codes so the colors are closed
+			continue
+		}
+		result.WriteRune(r)
+		visible++
+	}
+	if keep < width {
+		result.WriteString("...")
+	}
+	if colored {
+		result.WriteString(Reset)
+	}
+	return result.String()
+}
+
+func printPoolsAndSets(pager *Pager, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, allPoolSetDrives map[string][]DiskInfo, parity int, config *Config, servers []madmin.ServerProperties) {
+	// Collect all drives from all pools and erasure sets
+	allDrives := make([]DiskInfo, 0)
+	
+	// For show sets, collect erasure set statistics and display in table format
+	if config.ShowSets {
+		erasureSetSummaries := make([]ErasureSetInfo, 0)
+		
+		for poolIdx, sets := range pools {
+			// Check if pool has failed disks (for failed mode) - use all drives for checking
+			poolHasFailed := false
+			if config.FailedMode {
+				for setIdx := range sets {
+					key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
+					drives := allPoolSetDrives[key]
+					if len(drives) == 0 {
+						drives = poolSetDrives[key] // Fallback
+					}
+					for _, d := range drives {
+						if d.State != "ok" {
+							poolHasFailed = true
+							break
+						}
+					}
+					if poolHasFailed {
+						break
+					}
+				}
+				if !poolHasFailed {
+					continue
+				}
+			}
+
+			for setIdx := range sets {
+				key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
+				allDrivesForSet := poolSetDrives[key] // All drives (may be filtered by scanning/failed already)
+
+				// For summary mode with failed, we need ALL drives to count properly
+				// So we need to get them from allPoolSetDrives instead
+				var drivesForCounting []DiskInfo
+				if config.FailedMode {
+					// Get all drives from the original map for counting
+					allKey := fmt.Sprintf("%s:%s", poolIdx, setIdx)
+					drivesForCounting = allPoolSetDrives[allKey]
+					if len(drivesForCounting) == 0 {
+						// Fallback to poolSetDrives if not found
+						drivesForCounting = allDrivesForSet
+					}
+				} else {
+					drivesForCounting = allDrivesForSet
+				}
+
+				if config.ScanningMode && len(allDrivesForSet) == 0 {
+					continue
+				}
+
+				// In failed mode only sets with failed disks are shown, still
+				// counted over all their drives unless --failed-only-averages
+				if config.FailedMode {
+					failedDrives := make([]DiskInfo, 0)
+					for _, d := range drivesForCounting {
+						if d.State != "ok" {
+							failedDrives = append(failedDrives, d)
+						}
+					}
+					if len(failedDrives) == 0 {
+						continue
+					}
+					if config.FailedOnlyAvg {
+						drivesForCounting = failedDrives
+					}
+				}
+
+				if len(drivesForCounting) == 0 {
+					continue
+				}
+
+				poolIdxInt, _ := strconv.Atoi(poolIdx)
+				setIdxInt, _ := strconv.Atoi(setIdx)
+				es := mdbcore.SummarizeErasureSet(poolIdxInt, setIdxInt, drivesForCounting)
+
+				// Filter by minimum bad disks threshold if specified
+				if config.MinBadDisks != nil {
+					if es.Bad < *config.MinBadDisks {
+						continue
+					}
+				}
+
+				erasureSetSummaries = append(erasureSetSummaries, es)
+			}
+		}
+		
+		// Sort erasure sets by Pool and Erasure Set, or with --sets-sort risk
+		// the sets at most risk first
+		if config.SetSort == setSortRisk {
+			mdbcore.SortByRisk(erasureSetSummaries)
+		} else {
+			sort.Slice(erasureSetSummaries, func(i, j int) bool {
+				if erasureSetSummaries[i].PoolIdx != erasureSetSummaries[j].PoolIdx {
+					return erasureSetSummaries[i].PoolIdx < erasureSetSummaries[j].PoolIdx
+				}
+				return erasureSetSummaries[i].SetIdx < erasureSetSummaries[j].SetIdx
+			})
+		}
+		
+		// Print Erasure Sets table
+		if len(erasureSetSummaries) > 0 {
+			printSectionTitle(pager, config, "Erasure Sets")
+			
+			headers := []string{"Pool", "Erasure Set", "Good Disks", "Bad Disks", "Scanning", "Avg Space Used", "Avg Free Space", "Avg Inodes Used", "Usable", "Free", "Servers"}
+			rows := make([][]string, 0, len(erasureSetSummaries))
+			
+			for _, es := range erasureSetSummaries {
+				row := make([]string, len(headers))
+				
+				poolIdxStr := fmt.Sprintf("%d", es.PoolIdx)
+				setIdxStr := fmt.Sprintf("%d", es.SetIdx)
+				
+				goodText := fmt.Sprintf("%d", es.Good)
+				if es.Good > 0 {
+					goodText = fmt.Sprintf("%s%d%s", Green, es.Good, Reset)
+				}
+				
+				badText := fmt.Sprintf("%d", es.Bad)
+				if es.Bad > 0 {
+					badText = fmt.Sprintf("%s%d%s", Red, es.Bad, Reset)
+				}
+				// Name the states when the bad drives are in several
+				if states := countStates(es.Drives, true); len(states) > 1 {
+					badText += " (" + formatStateCounts(states) + ")"
+				}
+				
+				scanningText := fmt.Sprintf("%d", es.Scanning)
+				if es.Scanning > 0 && config.ScanningMode && es.MinHealPct != nil {
+					scanningText = fmt.Sprintf("%s%d (min %.0f%%)%s", Yellow, es.Scanning, *es.MinHealPct, Reset)
+				} else if es.Scanning > 0 {
+					scanningText = fmt.Sprintf("%s%d%s", Yellow, es.Scanning, Reset)
+				}
+				// The risk is judged on all drives of the set, whatever the filters
+				if scanning, bad := healLoad(allPoolSetDrives[fmt.Sprintf("%d:%d", es.PoolIdx, es.SetIdx)]); atRiskDuringHeal(scanning, bad, parity) {
+					scanningText = fmt.Sprintf("%s%s, %s%s", Red, stripANSI(scanningText), setAtRiskDuringHeal, Reset)
+				}
+				
+				spaceUsedText := fmt.Sprintf("%s%s%s", usageColor(es.AvgSpaceUsedPct, config), formatPct(es.AvgSpaceUsedPct, config), Reset)
+				if config.Bars {
+					spaceUsedText = usageBar(es.AvgSpaceUsedPct, config)
+				}
+				freeSpaceText := fmt.Sprintf("%s%s%s", freeColor(es.AvgFreeSpacePct, config), formatPct(es.AvgFreeSpacePct, config), Reset)
+				inodesText := fmt.Sprintf("%s%s%s", inodeColor(es.AvgInodesUsedPct, config), formatPct(es.AvgInodesUsedPct, config), Reset)
+				
+				row[0] = fmt.Sprintf("%s%s%s", Blue, poolIdxStr, Reset)
+				row[1] = fmt.Sprintf("%s%s%s", Blue, setIdxStr, Reset)
+				row[2] = goodText
+				row[3] = badText
+				row[4] = scanningText
+				row[5] = spaceUsedText
+				row[6] = freeSpaceText
+				row[7] = inodesText
+				row[8], row[9] = setCapacityText(allPoolSetDrives[fmt.Sprintf("%d:%d", es.PoolIdx, es.SetIdx)], parity, config)
+				row[10] = setServersText(allPoolSetDrives[fmt.Sprintf("%d:%d", es.PoolIdx, es.SetIdx)], config.Wide)
+				
+				rows = append(rows, row)
 			}
-			poolStr = strings.Join(poolStrs, ",")
+			
+			printTableRows(pager, config, headers, rows)
+			pager.markSetRows(erasureSetSummaries)
+			pager.Printf("\n")
+
+			// Annotate sets whose drive errors carry timestamps with their
+			// recency, unless the table is shown alone
+			for _, es := range erasureSetSummaries {
+				drives := allPoolSetDrives[fmt.Sprintf("%d:%d", es.PoolIdx, es.SetIdx)]
+				if note := setErrorRecency(drives); note != "" && !config.SetsOnly {
+					pager.Printf("  Pool %d, Set %d: %s\n", es.PoolIdx, es.SetIdx, note)
+				}
+			}
+		}
+	}
+
+	// Collect all drives for the table (for show disks mode)
+	if config.ShowDisks {
+		for _, drives := range poolSetDrives {
+			allDrives = append(allDrives, drives...)
+		}
+	}
+
+	// Only print drives table if ShowDisks is true
+	if !config.ShowDisks {
+		return
+	}
+
+	// Sort all drives by Pool, Erasure Set, Disk Index
+	sort.Slice(allDrives, func(i, j int) bool {
+		return driveLess(allDrives[i], allDrives[j])
+	})
+	sortDrivesBy(allDrives, config.DriveSort)
+
+	// Print single table with all drives
+	if len(allDrives) > 0 {
+		// Only print "Drives" header if ShowDisks is true and not already showing sets
+	if config.ShowDisks && !config.ShowSets {
+		printSectionTitle(pager, config, "Drives")
+	} else if config.ShowDisks && config.ShowSets {
+		// When both ShowDisks and ShowSets are true, we don't need a separate header
+		printSectionTitle(pager, config, "Drives")
+	}
+		printTable(pager, allDrives, config)
+		pager.Printf("\n")
+	}
+}
+
+// setCapacityText returns the Usable and Free cells of an erasure set, see
+// mdbcore.SetCapacity. Free is red below --min-set-free, or without it colored
+// like the free space percentage of the set.
+func setCapacityText(drives []DiskInfo, parity int, config *Config) (string, string) {
+	usable, free, ok := mdbcore.SetCapacity(drives, parity)
+	if !ok {
+		return "N/A", "N/A"
+	}
+	color := freeColor(float64(free)/float64(usable)*100, config)
+	if config.MinSetFree > 0 {
+		color = Green
+		if free < config.MinSetFree {
+			color = Red
+		}
+	}
+	return formatSize(usable, config), fmt.Sprintf("%s%s%s", color, formatSize(free, config), Reset)
+}
+
+func printTable(pager *Pager, drives []DiskInfo, config *Config) {
+	if len(drives) == 0 {
+		return
+	}
+	// The callers sort the drives, so the most relevant rows are kept
+	hidden := 0
+	if config.MaxRows > 0 && len(drives) > config.MaxRows {
+		hidden = len(drives) - config.MaxRows
+		drives = drives[:config.MaxRows]
+	}
+	headers, rows := driveTableRows(drives, config)
+	printTableRows(pager, config, headers, rows)
+	if hidden > 0 {
+		noun := "rows"
+		if hidden == 1 {
+			noun = "row"
+		}
+		pager.Printf("  ... %s more %s (use --max-rows=0 or --pager to see all)\n", formatInt(int64(hidden)), noun)
+	}
+}
+
+// driveTableColumns returns the Drives table columns selected by config
+func driveTableColumns(config *Config) []driveColumn {
+	columnNames := config.DriveColumns
+	if len(columnNames) == 0 {
+		columnNames = defaultDriveColumns
+	}
+	columns := make([]driveColumn, 0, len(columnNames))
+	for _, name := range columnNames {
+		if column, err := lookupDriveColumn(name); err == nil {
+			// Drives of a backend without erasure sets are all in pool 0 set 0
+			if config.settings().NoSets && (column.ID == "pool" || column.ID == "erasure_set") {
+				continue
+			}
+			// With --scanning the healing progress is the interesting part
+			if column.ID == "scanning" && config.ScanningMode {
+				column.Value = func(drive DiskInfo, config *Config) string {
+					return scanningProgressText(drive, config)
+				}
+			}
+			// Saturation depends on the --saturation ratio, so it is added here
+			if column.ID == "metrics" {
+				metricsValue, threshold := column.Value, saturationRatio(config)
+				column.Value = func(drive DiskInfo, config *Config) string {
+					return strings.TrimSpace(metricsValue(drive, config) + " " + saturationText(drive, threshold))
+				}
+			}
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// driveTableRows returns the headers and rows of the Drives table of drives
+func driveTableRows(drives []DiskInfo, config *Config) ([]string, [][]string) {
+	columns := driveTableColumns(config)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+
+	rows := make([][]string, 0, len(drives))
+	for _, drive := range drives {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = column.Value(drive, config)
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+// driveColumn is a column of the Drives table. ID is the canonical snake_case
+// identifier used in flags and presets; Aliases are older names still accepted.
+type driveColumn struct {
+	ID      string
+	Aliases []string
+	Header  string
+	Value   func(drive DiskInfo, config *Config) string
+}
+
+// driveColumns is the registry of every column the Drives table can show
+var driveColumns = []driveColumn{
+	{"pool", nil, "Pool", func(drive DiskInfo, config *Config) string {
+		return fmt.Sprintf("%s%d%s", Blue, drive.PoolIndex, Reset)
+	}},
+	{"erasure_set", []string{"set"}, "Erasure Set", func(drive DiskInfo, config *Config) string {
+		return fmt.Sprintf("%s%d%s", Blue, drive.SetIndex, Reset)
+	}},
+	{"disk_index", []string{"index"}, "Disk Index", func(drive DiskInfo, config *Config) string {
+		if drive.NoDiskIndex {
+			return "-"
+		}
+		return strconv.Itoa(drive.DiskIndex)
+	}},
+	{"server", nil, "Server", func(drive DiskInfo, config *Config) string {
+		return drive.Server
+	}},
+	{"disk_path", []string{"path"}, "Disk Path", func(drive DiskInfo, config *Config) string {
+		return drive.Path
+	}},
+	{"state", nil, "State", func(drive DiskInfo, config *Config) string {
+		return newTag(fmt.Sprintf("%s%s%s", driveStateColor(drive.State), drive.State, Reset), config.BaselineChanges.newDrive(drive))
+	}},
+	{"scanning", nil, "Scanning", func(drive DiskInfo, config *Config) string {
+		return scanningText(drive, config)
+	}},
+	{"healing", nil, "Healing", func(drive DiskInfo, config *Config) string {
+		healingColor := Yellow
+		if !drive.Healing {
+			healingColor = Green
+		}
+		return fmt.Sprintf("%s%s%s", healingColor, boolToYesNo(drive.Healing), Reset)
+	}},
+	{"heal_pct", []string{"heal"}, "Heal %", func(drive DiskInfo, config *Config) string {
+		if !drive.Scanning {
+			return "-"
+		}
+		return formatHealPct(drive.HealInfo, config)
+	}},
+	{"errors", nil, "Errors", func(drive DiskInfo, config *Config) string {
+		if drive.Metrics == nil {
+			return "N/A"
+		}
+		// Availability errors include timeouts
+		errors := drive.Metrics.TotalErrorsAvailability
+		if errors == 0 {
+			return "0"
 		}
+		// Errors older than recentErrorWindow are historical counters
+		errorsColor := Red
+		if age, ok := driveLastErrorAge(drive); ok && age > recentErrorWindow {
+			errorsColor = Yellow
+		}
+		details := fmt.Sprintf("%d timeout", drive.Metrics.TotalErrorsTimeout)
+		if last := lastErrorText(drive); last != "" {
+			details += ", " + last
+		}
+		return fmt.Sprintf("%s%d%s (%s)", errorsColor, errors, Reset, details)
+	}},
+	{"uuid", nil, "UUID", func(drive DiskInfo, config *Config) string {
+		uuid := drive.UUID
+		if len(uuid) > 16 {
+			uuid = uuid[:16] + "..."
+		}
+		return uuid
+	}},
+	{"model", nil, "Model", func(drive DiskInfo, config *Config) string {
+		if drive.Model == "" {
+			return "N/A"
+		}
+		return drive.Model
+	}},
+	{"type", []string{"drive_type"}, "Type", func(drive DiskInfo, config *Config) string {
+		if drive.RootDisk {
+			return Yellow + "root" + Reset
+		}
+		return "data"
+	}},
+	{"size", nil, "Size", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return formatDriveSize(drive.TotalSpace, config)
+	}},
+	{"total_space", []string{"total"}, "Total Space", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return formatDriveSize(drive.TotalSpace, config)
+	}},
+	{"used_space", []string{"used", "space_used"}, "Space Used", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return fmt.Sprintf("%s (%s%s%s)", formatDriveSize(drive.UsedSpace, config), usageColor(drive.UsedSpacePct, config), formatPct(drive.UsedSpacePct, config), Reset)
+	}},
+	{"free_space", []string{"free"}, "Free Space", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return fmt.Sprintf("%s (%s%s%s)", formatDriveSize(drive.AvailableSpace, config), driveFreeColor(drive, config), formatPct(drive.FreeSpacePct, config), Reset)
+	}},
+	{"used_pct", []string{"used_percent"}, "Used %", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return fmt.Sprintf("%s%s%s", usageColor(drive.UsedSpacePct, config), formatPct(drive.UsedSpacePct, config), Reset)
+	}},
+	{"free_pct", []string{"free_percent"}, "Free %", func(drive DiskInfo, config *Config) string {
+		if drive.TotalSpace <= 0 {
+			return "N/A"
+		}
+		return formatPct(drive.FreeSpacePct, config)
+	}},
+	{"unaccounted", nil, "Unaccounted", func(drive DiskInfo, config *Config) string {
+		return unaccountedText(drive, config)
+	}},
+	{"inodes_used", []string{"inodes"}, "Inodes Used", func(drive DiskInfo, config *Config) string {
+		inodePct, ok := inodeUsagePct(drive)
+		if !ok {
+			return "N/A"
+		}
+		return fmt.Sprintf("%s (%s%s%s)", formatInt(drive.UsedInodes), inodeColor(inodePct, config), formatPct(inodePct, config), Reset)
+	}},
+	{"local", nil, "Local", func(drive DiskInfo, config *Config) string {
+		localColor := Green
+		if !drive.Local {
+			localColor = Yellow
+		}
+		return fmt.Sprintf("%s%s%s", localColor, boolToYesNo(drive.Local), Reset)
+	}},
+	{"metrics", nil, "Metrics", func(drive DiskInfo, config *Config) string {
+		metrics := formatMetrics(drive.Metrics)
+		if last := lastErrorText(drive); last != "" {
+			metrics = strings.TrimSpace(metrics + " " + last)
+		}
+		return metrics
+	}},
+	{"latency", nil, "Latency", func(drive DiskInfo, config *Config) string {
+		if drive.IO == nil || !drive.IO.HasLatency {
+			return "N/A"
+		}
+		latency := formatLatency(drive.IO.LatencyUs)
+		if api := strings.TrimPrefix(drive.IO.LatencyAPI, "storage."); api != "" {
+			latency += " " + api
+		}
+		return latency
+	}},
+	{"iops", nil, "IOPS", func(drive DiskInfo, config *Config) string {
+		if drive.IO == nil || !drive.IO.HasRates {
+			return "N/A"
+		}
+		return formatIOPS(drive.IO.IOPS())
+	}},
+}
+
+// defaultDriveColumns are shown in the Drives table when no preset is selected
+var defaultDriveColumns = []string{"pool", "erasure_set", "disk_index", "server", "disk_path", "state", "scanning", "uuid", "total_space", "used_space", "free_space", "inodes_used", "local", "metrics"}
+
+// drivePresets are the built-in named column sets for --preset
+var drivePresets = map[string][]string{
+	"capacity": {"pool", "erasure_set", "server", "disk_path", "total_space", "used_space", "free_space", "inodes_used"},
+	"health":   {"pool", "erasure_set", "server", "disk_path", "state", "healing", "errors"},
+	"hardware": {"server", "disk_path", "model", "type", "uuid", "size"},
+}
+
+// normalizeColumnName folds case and treats spaces and dashes like underscores,
+// so "Erasure Set", "erasure-set" and "erasure_set" name the same column
+func normalizeColumnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// driveColumnIDs returns the canonical identifiers of all columns in table order
+func driveColumnIDs() []string {
+	ids := make([]string, 0, len(driveColumns))
+	for _, column := range driveColumns {
+		ids = append(ids, column.ID)
+	}
+	return ids
+}
+
+// lookupDriveColumn finds a column by its identifier, header or one of its aliases
+func lookupDriveColumn(name string) (driveColumn, error) {
+	key := normalizeColumnName(name)
+	for _, column := range driveColumns {
+		if column.ID == key || normalizeColumnName(column.Header) == key {
+			return column, nil
+		}
+		for _, alias := range column.Aliases {
+			if alias == key {
+				return column, nil
+			}
+		}
+	}
+	return driveColumn{}, fmt.Errorf("unknown column '%s' (valid columns: %s)", name, strings.Join(driveColumnIDs(), ", "))
+}
+
+// resolveDriveColumns maps column names and aliases to their canonical identifiers
+func resolveDriveColumns(names []string) ([]string, error) {
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		column, err := lookupDriveColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, column.ID)
+	}
+	return ids, nil
+}
+
+// parseDriveColumns parses the comma-separated column names of --columns into
+// canonical identifiers. "all" selects the default columns and returns nil.
+func parseDriveColumns(value string) ([]string, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "all") {
+		return nil, nil
+	}
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no columns given (valid columns: %s)", strings.Join(driveColumnIDs(), ", "))
+	}
+	return resolveDriveColumns(names)
+}
+
+// resolveDrivePreset returns the canonical column identifiers of a preset.
+// User-defined presets from the config file take precedence over the built-in ones.
+func resolveDrivePreset(name string, userPresets map[string][]string) ([]string, error) {
+	columns, ok := userPresets[name]
+	if !ok {
+		columns, ok = drivePresets[name]
+	}
+	if !ok {
+		names := make([]string, 0, len(drivePresets)+len(userPresets))
+		for presetName := range drivePresets {
+			names = append(names, presetName)
+		}
+		for presetName := range userPresets {
+			if _, builtin := drivePresets[presetName]; !builtin {
+				names = append(names, presetName)
+			}
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown preset '%s' (available presets: %s)", name, strings.Join(names, ", "))
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("preset '%s' has no columns", name)
+	}
+	ids, err := resolveDriveColumns(columns)
+	if err != nil {
+		return nil, fmt.Errorf("preset '%s' has %v", name, err)
+	}
+	return ids, nil
+}
+
+// printSectionTitle prints a bold section title, or a markdown heading in markdown format
+func printSectionTitle(pager *Pager, config *Config, title string) {
+	pager.markSection(title)
+	switch config.Format {
+	case formatMarkdown:
+		pager.Printf("## %s\n\n", title)
+		return
+	case formatCSV:
+		return
+	}
+	pager.Printf("%s%s%s\n", Bold, title, Reset)
+}
 
-		// Color code state
-		stateColor := Green
-		if server.State == "offline" {
-			stateColor = Red
-		}
-		stateText := fmt.Sprintf("%s%s%s", stateColor, server.State, Reset)
+// timeNow is the clock of mdb: snapshot and heal ages are measured against it
+// and the times recorded in configurations, histories, baselines and bundles
+// are read from it. Tests replace it with a fixed time.
+var timeNow = time.Now
 
-		// Format commit ID (use full commit ID, no truncation)
-		commitID := server.CommitID
+const (
+	// staleSnapshotAge is the age from which a snapshot is shown in yellow
+	staleSnapshotAge = 24 * time.Hour
+	// expiredSnapshotAge is the age from which a snapshot is shown in red
+	expiredSnapshotAge = 7 * 24 * time.Hour
+)
 
-		// Format ILM status
-		ilmStatus := "false"
-		if server.ILMExpiryInProgress {
-			ilmStatus = "true"
-		}
+// snapshotAge returns how long before now the snapshot was taken
+func snapshotAge(taken, now time.Time) time.Duration {
+	if taken.IsZero() || now.Before(taken) {
+		return 0
+	}
+	return now.Sub(taken)
+}
 
-		// Format uptime
-		uptime := humanizeDuration(time.Duration(server.Uptime) * time.Second)
+// formatSnapshotTaken describes when a snapshot was taken, e.g.
+// "2024-05-02 13:45 UTC (3 days 0 hours 0 minutes 0 seconds ago)", colored by age
+func formatSnapshotTaken(taken, now time.Time) string {
+	if taken.IsZero() {
+		return "unknown"
+	}
+	age := snapshotAge(taken, now)
+	text := fmt.Sprintf("%s (%s ago)", taken.UTC().Format("2006-01-02 15:04 UTC"), humanizeDuration(age.Round(time.Minute)))
+	switch {
+	case age > expiredSnapshotAge:
+		return Red + text + Reset
+	case age > staleSnapshotAge:
+		return Yellow + text + Reset
+	}
+	return text
+}
 
-		row := make([]string, len(headers))
-		row[0] = poolStr
-		row[1] = serverName
-		row[2] = stateText
-		row[3] = server.Edition
-		row[4] = server.Version
-		row[5] = commitID
-		row[6] = humanize.IBytes(server.MemStats.Alloc)
-		row[7] = ilmStatus
-		if server.State == "offline" {
-			row[8] = "N/A"
-		} else {
-			row[8] = uptime
+// parseMaxAge parses a --max-age or --interval value: a Go duration such as "36h" or a number of days such as "7d"
+func parseMaxAge(value string) (time.Duration, error) {
+	var maxAge time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid duration", value)
+		}
+		maxAge = time.Duration(n * float64(24*time.Hour))
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid duration", value)
 		}
+		maxAge = d
+	}
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("'%s' must be greater than zero", value)
+	}
+	return maxAge, nil
+}
 
-		rows = append(rows, row)
+// printReportTitle prints a heading with the source file and the snapshot timestamp
+func printReportTitle(pager *Pager, config *Config, taken time.Time) {
+	snapshot := taken.UTC().Format("2006-01-02 15:04:05 UTC")
+
+	switch config.Format {
+	case formatMarkdown:
+		pager.Printf("# MinIO Report: %s\n\n", filepath.Base(inputName(config)))
+		pager.Printf("Snapshot: %s\n\n", snapshot)
+		return
+	case formatCSV:
+		return
 	}
+	pager.Printf("%sMinIO Report: %s%s\n", Bold, inputName(config), Reset)
+	pager.Printf("Snapshot: %s\n\n", snapshot)
+}
 
-	// Calculate column widths
-	widths := make([]int, len(headers))
-	for i, h := range headers {
-		widths[i] = utf8.RuneCountInString(h)
+// printLegend prints what the colors of the report mean and an index of the
+// sections collected in report with their line counts, then the report itself
+func printLegend(out *Pager, report *Pager, config *Config) {
+	pct := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64) + "%"
 	}
-	for _, row := range rows {
-		for i, cell := range row {
-			// Strip ANSI codes for width calculation
-			cleanCell := stripANSI(cell)
-			if w := utf8.RuneCountInString(cleanCell); w > widths[i] {
-				widths[i] = w
-			}
-		}
+	rising := func(warn, crit float64) string {
+		return fmt.Sprintf("%sbelow %s%s  %sfrom %s%s  %sfrom %s%s", Green, pct(warn), Reset, Yellow, pct(warn), Reset, Red, pct(crit), Reset)
 	}
 
-	// Print header
-	pager.Printf("  ")
-	for i, h := range headers {
-		pager.Printf("%s", padString(h, widths[i]))
-		if i < len(headers)-1 {
-			pager.Printf("  ")
+	out.Printf("%sLegend%s\n", Bold, Reset)
+	out.Printf("  Used:   %s\n", rising(config.settings().Thresholds.WarnUsed, config.settings().Thresholds.CritUsed))
+	if config.settings().CritFreeBytes > 0 {
+		out.Printf("  Free:   %sfrom %s%s  %sbelow %s%s  %sbelow %s%s (drives, --free-color-by bytes)\n",
+			Green, formatSize(2*config.settings().CritFreeBytes, config), Reset, Yellow, formatSize(2*config.settings().CritFreeBytes, config), Reset, Red, formatSize(config.settings().CritFreeBytes, config), Reset)
+	} else {
+		out.Printf("  Free:   %sabove %s%s  %s%s or less%s  %s%s or less%s\n",
+			Green, pct(config.settings().Thresholds.WarnFree), Reset, Yellow, pct(config.settings().Thresholds.WarnFree), Reset, Red, pct(config.settings().Thresholds.CritFree), Reset)
+	}
+	out.Printf("  Inodes: %s\n", rising(config.settings().Thresholds.WarnInodes, config.settings().Thresholds.CritInodes))
+	var colored []string
+	for state := range driveStateColors {
+		if state != madmin.DriveStateOk {
+			colored = append(colored, state)
 		}
 	}
-	pager.Printf("\n")
+	sort.Strings(colored)
+	states := []string{Green + madmin.DriveStateOk + Reset}
+	for _, state := range colored {
+		states = append(states, driveStateColors[state]+state+Reset)
+	}
+	out.Printf("  States: %s  %sany other%s\n", strings.Join(states, "  "), Red, Reset)
+	out.Printf("\n")
 
-	// Print separator
-	pager.Printf("  ")
-	for i, w := range widths {
-		pager.Printf("%s", strings.Repeat("-", w))
-		if i < len(widths)-1 {
-			pager.Printf("  ")
+	if len(report.sections) > 0 {
+		out.Printf("%sSections%s (in the pager, keys 1-9 jump to the first nine)\n", Bold, Reset)
+		rows := make([][]string, len(report.sections))
+		for i, lines := range report.sectionLines() {
+			rows[i] = []string{strconv.Itoa(i + 1), report.sections[i].Title, countNoun(lines, "line")}
 		}
+		printTableRows(out, config, []string{"#", "Section", "Lines"}, rows)
+		out.Printf("\n")
 	}
-	pager.Printf("\n")
+	out.appendPager(report)
+}
+
+// printMarkdownTable prints a GitHub-flavored Markdown table, turning colored problem values into bold text
+func printMarkdownTable(pager *Pager, headers []string, rows [][]string) {
+	pager.Printf("| %s |\n", strings.Join(headers, " | "))
+	separators := make([]string, len(headers))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	pager.Printf("| %s |\n", strings.Join(separators, " | "))
 
-	// Print rows
 	for _, row := range rows {
-		pager.Printf("  ")
+		cells := make([]string, len(row))
 		for i, cell := range row {
-			pager.Printf("%s", padString(cell, widths[i]))
-			if i < len(row)-1 {
-				pager.Printf("  ")
-			}
+			cells[i] = strings.ReplaceAll(ansiToMarkdown(cell), "|", "\\|")
 		}
-		pager.Printf("\n")
+		pager.Printf("| %s |\n", strings.Join(cells, " | "))
 	}
-	pager.Printf("\n")
 }
 
-func printPoolsAndSets(pager *Pager, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, allPoolSetDrives map[string][]DiskInfo, config *Config, servers []madmin.ServerProperties) {
-	// Collect all drives from all pools and erasure sets
-	allDrives := make([]DiskInfo, 0)
-	
-	// For show sets, collect erasure set statistics and display in table format
-	if config.ShowSets {
-		type ErasureSetSummary struct {
-			PoolIndex       int
-			SetIndex        int
-			GoodDisks       int
-			BadDisks        int
-			ScanningDisks   int
-			AvgSpaceUsedPct float64
-			AvgFreeSpacePct float64
-			AvgInodesUsedPct float64
+// ansiToMarkdown replaces red and yellow colored spans with bold text and drops all other ANSI codes
+func ansiToMarkdown(s string) string {
+	var result strings.Builder
+	emphasized := false
+	for len(s) > 0 {
+		if !strings.HasPrefix(s, "\033[") {
+			r, size := utf8.DecodeRuneInString(s)
+			result.WriteRune(r)
+			s = s[size:]
+			continue
 		}
-		
-		erasureSetSummaries := make([]ErasureSetSummary, 0)
-		
-		for poolIdx, sets := range pools {
-			// Check if pool has failed disks (for failed mode) - use all drives for checking
-			poolHasFailed := false
-			if config.FailedMode {
-				for setIdx := range sets {
-					key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
-					drives := allPoolSetDrives[key]
-					if len(drives) == 0 {
-						drives = poolSetDrives[key] // Fallback
-					}
-					for _, d := range drives {
-						if d.State != "ok" {
-							poolHasFailed = true
-							break
-						}
-					}
-					if poolHasFailed {
-						break
-					}
-				}
-				if !poolHasFailed {
-					continue
-				}
+
+		end := strings.IndexByte(s, 'm')
+		if end < 0 {
+			break
+		}
+		code := s[:end+1]
+		s = s[end+1:]
+
+		switch code {
+		case Red, Yellow:
+			if !emphasized {
+				result.WriteString("**")
+				emphasized = true
+			}
+		case Reset:
+			if emphasized {
+				result.WriteString("**")
+				emphasized = false
 			}
+		}
+	}
+	if emphasized {
+		result.WriteString("**")
+	}
+	return result.String()
+}
 
-			for setIdx := range sets {
-				key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
-				allDrivesForSet := poolSetDrives[key] // All drives (may be filtered by scanning/failed already)
+// textToMarkdown converts rendered text output into Markdown. Headings and tables
+// are already emitted as Markdown and are passed through; indented lines become
+// bullet points, bold lines become bold paragraphs and underline separators are dropped.
+// Code blocks are kept, only without colors.
+func textToMarkdown(text string) string {
+	var result strings.Builder
+	lastBlank := true
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(stripANSI(line))
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inCode = !inCode
+			result.WriteString(line)
+		case inCode:
+			result.WriteString(stripANSI(line))
+		case trimmed == "" || strings.Trim(trimmed, "=") == "":
+			if !lastBlank {
+				result.WriteString("\n")
+			}
+			lastBlank = true
+			continue
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "|"):
+			result.WriteString(line)
+		case strings.HasPrefix(line, Bold):
+			result.WriteString("**" + trimmed + "**")
+		case strings.HasPrefix(line, " "):
+			result.WriteString("- " + strings.TrimSpace(ansiToMarkdown(line)))
+		default:
+			result.WriteString(ansiToMarkdown(line))
+		}
+		result.WriteString("\n")
+		lastBlank = false
+	}
+	return result.String()
+}
 
-				// For summary mode with failed, we need ALL drives to count properly
-				// So we need to get them from allPoolSetDrives instead
-				var drivesForCounting []DiskInfo
-				if config.FailedMode {
-					// Get all drives from the original map for counting
-					allKey := fmt.Sprintf("%s:%s", poolIdx, setIdx)
-					drivesForCounting = allPoolSetDrives[allKey]
-					if len(drivesForCounting) == 0 {
-						// Fallback to poolSetDrives if not found
-						drivesForCounting = allDrivesForSet
-					}
-				} else {
-					drivesForCounting = allDrivesForSet
-				}
+// grafanaSnapshotVersion is bumped whenever the Grafana snapshot layout changes
+const grafanaSnapshotVersion = 4
 
-				if config.ScanningMode && len(allDrivesForSet) == 0 {
-					continue
-				}
+// grafanaSnapshot is a set of table frames for the Grafana JSON API datasource
+type grafanaSnapshot struct {
+	Version  int            `json:"version"`
+	Tables   []grafanaTable `json:"tables"`
+	Findings []finding      `json:"findings"`
+}
 
-				// Filter to only failed disks in failed mode (for summary mode)
-				if config.FailedMode {
-					failedDrives := make([]DiskInfo, 0)
-					for _, d := range drivesForCounting {
-						if d.State != "ok" {
-							failedDrives = append(failedDrives, d)
-						}
-					}
-					if len(failedDrives) == 0 {
-						continue
-					}
-					// For counting, use the filtered failed drives (to match Python behavior)
-					drivesForCounting = failedDrives
-				}
+// grafanaTable is a table frame with typed columns and row arrays
+type grafanaTable struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
 
-				good := 0
-				bad := 0
-				scanning := 0
-				for _, d := range drivesForCounting {
-					if d.State == "ok" {
-						good++
-					} else {
-						bad++
-					}
-					if d.Scanning {
-						scanning++
-					}
-				}
+// grafanaColumn describes a table frame column, type is one of number, string, boolean, time
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
 
-				// Filter by minimum bad disks threshold if specified
-				if config.MinBadDisks != nil {
-					if bad < *config.MinBadDisks {
-						continue
-					}
-				}
+// newGrafanaTable creates an empty table frame from alternating column name/type pairs
+func newGrafanaTable(name string, columns ...string) grafanaTable {
+	table := grafanaTable{Name: name, Type: "table", Rows: [][]interface{}{}}
+	for i := 0; i+1 < len(columns); i += 2 {
+		table.Columns = append(table.Columns, grafanaColumn{Text: columns[i], Type: columns[i+1]})
+	}
+	return table
+}
 
-				// Calculate averages - use all drives for averaging, not just filtered ones
-				totalDrives := len(drivesForCounting)
-				if totalDrives > 0 {
-					avgTotalSpace := int64(0)
-					avgUsedSpace := int64(0)
-					avgFreeSpace := int64(0)
-					avgUsedInodes := int64(0)
-					avgFreeInodes := int64(0)
-					for _, d := range drivesForCounting {
-						avgTotalSpace += d.TotalSpace
-						avgUsedSpace += d.UsedSpace
-						avgFreeSpace += d.AvailableSpace
-						avgUsedInodes += d.UsedInodes
-						avgFreeInodes += d.FreeInodes
-					}
-					avgTotalSpace /= int64(totalDrives)
-					avgUsedSpace /= int64(totalDrives)
-					avgFreeSpace /= int64(totalDrives)
-					avgUsedInodes /= int64(totalDrives)
-					avgFreeInodes /= int64(totalDrives)
-
-					avgTotalInodes := avgUsedInodes + avgFreeInodes
-					var avgSpaceUsedPct, avgFreeSpacePct, avgInodesUsedPct float64
-					if avgTotalSpace > 0 {
-						avgSpaceUsedPct = float64(avgUsedSpace) / float64(avgTotalSpace) * 100
-						avgFreeSpacePct = float64(avgFreeSpace) / float64(avgTotalSpace) * 100
-					}
-					if avgTotalInodes > 0 {
-						avgInodesUsedPct = float64(avgUsedInodes) / float64(avgTotalInodes) * 100
-					}
+// writeGrafanaSnapshot writes the drives, sets and pools tables as a Grafana table-frame JSON document
+func writeGrafanaSnapshot(out *Pager, pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, parityDisks int, history []HealthRecord, buckets map[string]madmin.BucketUsageInfo, groups []*failureGroup, findings []finding, config *Config) error {
+	drivesTable := newGrafanaTable("drives",
+		"pool", "number", "set", "number", "disk_index", "number",
+		"server", "string", "path", "string", "state", "string",
+		"healing", "boolean", "scanning", "boolean", "uuid", "string",
+		"total_bytes", "number", "used_bytes", "number", "free_bytes", "number",
+		"used_pct", "number", "free_pct", "number",
+		"used_severity", "string", "free_severity", "string",
+		"used_inodes", "number", "free_inodes", "number", "inodes_severity", "string",
+		"local", "boolean", "waiting_ratio", "number")
+	setsTable := newGrafanaTable("sets",
+		"pool", "number", "set", "number", "drives", "number",
+		"good", "number", "bad", "number", "scanning", "number",
+		"total_bytes", "number", "used_bytes", "number", "free_bytes", "number",
+		"avg_used_pct", "number", "avg_free_pct", "number", "avg_inodes_used_pct", "number",
+		"used_severity", "string", "free_severity", "string", "inodes_severity", "string")
+	poolsTable := newGrafanaTable("pools",
+		"pool", "number", "sets", "number", "drives", "number", "bad", "number",
+		"raw_bytes", "number", "usable_bytes", "number", "used_bytes", "number", "used_pct", "number",
+		"used_severity", "string")
+
+	// Sort pools and sets numerically so the output is deterministic
+	poolIdxs := make([]int, 0, len(pools))
+	for poolKey := range pools {
+		if poolIdx, err := strconv.Atoi(poolKey); err == nil {
+			poolIdxs = append(poolIdxs, poolIdx)
+		}
+	}
+	sort.Ints(poolIdxs)
 
-					poolIdxInt, _ := strconv.Atoi(poolIdx)
-					setIdxInt, _ := strconv.Atoi(setIdx)
-					
-					erasureSetSummaries = append(erasureSetSummaries, ErasureSetSummary{
-						PoolIndex:        poolIdxInt,
-						SetIndex:         setIdxInt,
-						GoodDisks:        good,
-						BadDisks:         bad,
-						ScanningDisks:    scanning,
-						AvgSpaceUsedPct:  avgSpaceUsedPct,
-						AvgFreeSpacePct:  avgFreeSpacePct,
-						AvgInodesUsedPct: avgInodesUsedPct,
-					})
-				}
+	for _, poolIdx := range poolIdxs {
+		setIdxs := make([]int, 0, len(pools[strconv.Itoa(poolIdx)]))
+		for setKey := range pools[strconv.Itoa(poolIdx)] {
+			if setIdx, err := strconv.Atoi(setKey); err == nil {
+				setIdxs = append(setIdxs, setIdx)
 			}
 		}
-		
-		// Sort erasure sets by Pool and Erasure Set
-		sort.Slice(erasureSetSummaries, func(i, j int) bool {
-			if erasureSetSummaries[i].PoolIndex != erasureSetSummaries[j].PoolIndex {
-				return erasureSetSummaries[i].PoolIndex < erasureSetSummaries[j].PoolIndex
+		sort.Ints(setIdxs)
+
+		var poolDrives, poolBad int
+		var poolRaw, poolUsable, poolUsed int64
+		for _, setIdx := range setIdxs {
+			drives := append([]DiskInfo(nil), poolSetDrives[fmt.Sprintf("%d:%d", poolIdx, setIdx)]...)
+			if len(drives) == 0 {
+				continue
 			}
-			return erasureSetSummaries[i].SetIndex < erasureSetSummaries[j].SetIndex
-		})
-		
-		// Print Erasure Sets table
-		if len(erasureSetSummaries) > 0 {
-			pager.Printf("%sErasure Sets%s\n", Bold, Reset)
-			
-			headers := []string{"Pool", "Erasure Set", "Good Disks", "Bad Disks", "Scanning", "Avg Space Used", "Avg Free Space", "Avg Inodes Used"}
-			rows := make([][]string, 0, len(erasureSetSummaries))
-			
-			for _, es := range erasureSetSummaries {
-				row := make([]string, len(headers))
-				
-				poolIdxStr := fmt.Sprintf("%d", es.PoolIndex)
-				setIdxStr := fmt.Sprintf("%d", es.SetIndex)
-				
-				goodText := fmt.Sprintf("%d", es.GoodDisks)
-				if es.GoodDisks > 0 {
-					goodText = fmt.Sprintf("%s%d%s", Green, es.GoodDisks, Reset)
-				}
-				
-				badText := fmt.Sprintf("%d", es.BadDisks)
-				if es.BadDisks > 0 {
-					badText = fmt.Sprintf("%s%d%s", Red, es.BadDisks, Reset)
-				}
-				
-				scanningText := fmt.Sprintf("%d", es.ScanningDisks)
-				if es.ScanningDisks > 0 {
-					scanningText = fmt.Sprintf("%s%d%s", Yellow, es.ScanningDisks, Reset)
-				}
-				
-				spaceUsedColor := Green
-				if es.AvgSpaceUsedPct >= 95 {
-					spaceUsedColor = Red
-				} else if es.AvgSpaceUsedPct >= 80 {
-					spaceUsedColor = Yellow
-				}
-				spaceUsedText := fmt.Sprintf("%s%.1f%%%s", spaceUsedColor, es.AvgSpaceUsedPct, Reset)
-				
-				freeSpaceColor := Green
-				if es.AvgFreeSpacePct <= 5 {
-					freeSpaceColor = Red
-				} else if es.AvgFreeSpacePct <= 20 {
-					freeSpaceColor = Yellow
-				}
-				freeSpaceText := fmt.Sprintf("%s%.1f%%%s", freeSpaceColor, es.AvgFreeSpacePct, Reset)
-				
-				inodesColor := Green
-				if es.AvgInodesUsedPct >= 95 {
-					inodesColor = Red
-				} else if es.AvgInodesUsedPct >= 80 {
-					inodesColor = Yellow
+			sort.Slice(drives, func(i, j int) bool {
+				return driveLess(drives[i], drives[j])
+			})
+
+			var setTotal, setUsed, setFree int64
+			for _, d := range drives {
+				// Drives without metrics have no ratio and get null
+				var waitingRatio interface{}
+				if ratio, ok := driveWaitingRatio(d); ok {
+					waitingRatio = ratio
 				}
-				inodesText := fmt.Sprintf("%s%.1f%%%s", inodesColor, es.AvgInodesUsedPct, Reset)
-				
-				row[0] = fmt.Sprintf("%s%s%s", Blue, poolIdxStr, Reset)
-				row[1] = fmt.Sprintf("%s%s%s", Blue, setIdxStr, Reset)
-				row[2] = goodText
-				row[3] = badText
-				row[4] = scanningText
-				row[5] = spaceUsedText
-				row[6] = freeSpaceText
-				row[7] = inodesText
-				
-				rows = append(rows, row)
-			}
-			
-			// Calculate column widths
-			widths := make([]int, len(headers))
-			for i, h := range headers {
-				widths[i] = utf8.RuneCountInString(h)
-			}
-			for _, row := range rows {
-				for i, cell := range row {
-					cleanCell := stripANSI(cell)
-					if w := utf8.RuneCountInString(cleanCell); w > widths[i] {
-						widths[i] = w
-					}
+				// Drives without a disk index get null
+				var diskIndex interface{}
+				if !d.NoDiskIndex {
+					diskIndex = d.DiskIndex
 				}
-			}
-			
-			// Print header
-			pager.Printf("  ")
-			for i, h := range headers {
-				pager.Printf("%s", padString(h, widths[i]))
-				if i < len(headers)-1 {
-					pager.Printf("  ")
+				// Severities as colored in the tables, null where the tables show N/A
+				var usedSeverity, freeSeverity, inodesSeverity interface{}
+				if d.TotalSpace > 0 {
+					usedSeverity = severityForPct(d.UsedSpacePct, config.settings().Thresholds.WarnUsed, config.settings().Thresholds.CritUsed, false)
+					freeSeverity = severityForPct(d.FreeSpacePct, config.settings().Thresholds.WarnFree, config.settings().Thresholds.CritFree, true)
 				}
-			}
-			pager.Printf("\n")
-			
-			// Print separator
-			pager.Printf("  ")
-			for i, w := range widths {
-				pager.Printf("%s", strings.Repeat("-", w))
-				if i < len(widths)-1 {
-					pager.Printf("  ")
+				if inodePct, ok := inodeUsagePct(d); ok {
+					inodesSeverity = severityForPct(inodePct, config.settings().Thresholds.WarnInodes, config.settings().Thresholds.CritInodes, false)
 				}
+				drivesTable.Rows = append(drivesTable.Rows, []interface{}{
+					d.PoolIndex, d.SetIndex, diskIndex,
+					d.Server, d.Path, d.State,
+					d.Healing, d.Scanning, d.UUID,
+					d.TotalSpace, d.UsedSpace, d.AvailableSpace,
+					d.UsedSpacePct, d.FreeSpacePct,
+					usedSeverity, freeSeverity,
+					d.UsedInodes, d.FreeInodes, inodesSeverity,
+					d.Local, waitingRatio,
+				})
+				setTotal += d.TotalSpace
+				setUsed += d.UsedSpace
+				setFree += d.AvailableSpace
 			}
-			pager.Printf("\n")
-			
-			// Print rows with spacing
-			for _, row := range rows {
-				pager.Printf("  ")
-				for i, cell := range row {
-					pager.Printf("%s", padString(cell, widths[i]))
-					if i < len(row)-1 {
-						pager.Printf("  ")
-					}
-				}
-				pager.Printf("\n")
+
+			es := mdbcore.SummarizeErasureSet(poolIdx, setIdx, drives)
+			setsTable.Rows = append(setsTable.Rows, []interface{}{
+				poolIdx, setIdx, len(drives),
+				es.Good, es.Bad, es.Scanning,
+				setTotal, setUsed, setFree,
+				es.AvgSpaceUsedPct, es.AvgFreeSpacePct, es.AvgInodesUsedPct,
+				severityForPct(es.AvgSpaceUsedPct, config.settings().Thresholds.WarnUsed, config.settings().Thresholds.CritUsed, false),
+				severityForPct(es.AvgFreeSpacePct, config.settings().Thresholds.WarnFree, config.settings().Thresholds.CritFree, true),
+				severityForPct(es.AvgInodesUsedPct, config.settings().Thresholds.WarnInodes, config.settings().Thresholds.CritInodes, false),
+			})
+
+			poolDrives += len(drives)
+			poolBad += es.Bad
+			poolRaw += setTotal
+			poolUsed += setUsed
+			if len(drives) >= parityDisks {
+				poolUsable += int64(float64(setTotal) * float64(len(drives)-parityDisks) / float64(len(drives)))
 			}
-			pager.Printf("\n")
 		}
+
+		var usedPct float64
+		if poolUsable > 0 {
+			usedPct = float64(poolUsed) / float64(poolUsable) * 100
+		}
+		poolsTable.Rows = append(poolsTable.Rows, []interface{}{
+			poolIdx, len(setIdxs), poolDrives, poolBad,
+			poolRaw, poolUsable, poolUsed, usedPct,
+			severityForPct(usedPct, config.settings().Thresholds.WarnUsed, config.settings().Thresholds.CritUsed, false),
+		})
 	}
 
-	// Collect all drives for the table (for show disks mode)
+	snapshot := grafanaSnapshot{
+		Version:  grafanaSnapshotVersion,
+		Tables:   []grafanaTable{drivesTable, setsTable, poolsTable},
+		Findings: append([]finding{}, findings...),
+	}
+
+	// Health history is only present when it is recorded for the deployment
+	if len(history) > 0 {
+		historyTable := newGrafanaTable("history",
+			"run_at", "time", "source", "string", "snapshot_time", "time",
+			"grade", "string", "failed_drives", "number", "used_pct", "number")
+		for _, run := range history {
+			historyTable.Rows = append(historyTable.Rows, []interface{}{
+				run.RunAt.UnixMilli(), run.Source, run.SnapshotTime.UnixMilli(),
+				run.Grade, run.FailedDrives, run.UsedPct,
+			})
+		}
+		snapshot.Tables = append(snapshot.Tables, historyTable)
+	}
+
+	// Failure domains are only present with --group-by
+	if len(groups) > 0 {
+		groupsTable := newGrafanaTable("groups",
+			"group", "string", "servers", "number", "offline_servers", "number",
+			"drives", "number", "bad_drives", "number",
+			"total_bytes", "number", "used_bytes", "number", "used_pct", "number")
+		for _, group := range groups {
+			groupsTable.Rows = append(groupsTable.Rows, []interface{}{
+				group.Name, group.Servers, group.OfflineServers,
+				group.Drives, group.BadDrives,
+				group.TotalSpace, group.UsedSpace, group.usedPct(),
+			})
+		}
+		snapshot.Tables = append(snapshot.Tables, groupsTable)
+	}
+
+	// Per-bucket usage is only present in some captures
+	if len(buckets) > 0 {
+		bucketsTable := newGrafanaTable("buckets",
+			"bucket", "string", "size_bytes", "number", "objects", "number",
+			"versions", "number", "delete_markers", "number")
+		for _, bucket := range mdbcore.SortedBuckets(buckets) {
+			bucketsTable.Rows = append(bucketsTable.Rows, []interface{}{
+				bucket.Name, bucket.Size, bucket.ObjectsCount,
+				bucket.VersionsCount, bucket.DeleteMarkersCount,
+			})
+		}
+		snapshot.Tables = append(snapshot.Tables, bucketsTable)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Grafana snapshot: %v", err)
+	}
+	out.Printf("%s\n", data)
+	return nil
+}
+
+// htmlReport is the data model rendered by the HTML report template
+type htmlReport struct {
+	Title    string
+	Snapshot string
+	Sections []htmlSection
+}
+
+// htmlSection is a titled part of the HTML report
+type htmlSection struct {
+	Title  string
+	Cards  []htmlCard
+	Tables []htmlTable
+}
+
+// htmlCard is a single "label: value" figure of the summary
+type htmlCard struct {
+	Label string
+	Value string
+	Class string
+}
+
+// htmlCell is a table cell with the class of its severity
+type htmlCell struct {
+	Text  string
+	Class string
+}
+
+// htmlTable is a table of the HTML report
+type htmlTable struct {
+	Headers []string
+	Rows    [][]htmlCell
+}
+
+// colorClass returns the CSS class of a color chosen by the threshold helpers
+// like usageColor, so cells are classed as the text output colors them
+func colorClass(color string) string {
+	switch color {
+	case Red:
+		return "bad"
+	case Yellow:
+		return "warn"
+	case Green:
+		return "ok"
+	}
+	return ""
+}
+
+// severityClass returns the CSS class of a finding severity
+func severityClass(severity string) string {
+	switch severity {
+	case severityCritical:
+		return "bad"
+	case severityWarning:
+		return "warn"
+	}
+	return ""
+}
+
+// countClass returns class when count is not zero
+func countClass(count int, class string) string {
+	if count == 0 {
+		return ""
+	}
+	return class
+}
+
+// newHTMLReport builds the HTML report of the sections selected by config
+// from the structures computed by renderReport: the findings, the cluster
+// statistics, the servers and the drives of every erasure set
+func newHTMLReport(infoStruct *clusterStruct, config *Config, stats ClusterStats, allPoolSetDrives, poolSetDrives map[string][]DiskInfo, findings []finding) *htmlReport {
+	report := &htmlReport{
+		Title: "MinIO Report: " + filepath.Base(inputName(config)),
+	}
+	if !stats.Snapshot.IsZero() {
+		report.Snapshot = stats.Snapshot.UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	if config.ShowSummary {
+		if len(findings) > 0 {
+			report.Sections = append(report.Sections, htmlSection{Title: "Problems", Tables: []htmlTable{htmlProblemsTable(findings)}})
+		}
+		report.Sections = append(report.Sections, htmlSection{Title: "Summary", Cards: htmlSummaryCards(infoStruct, stats, config)})
+	}
+	if config.ShowServers {
+		names := serverNamer(infoStruct.Info.Servers, config)
+		report.Sections = append(report.Sections, htmlSection{Title: "Servers", Tables: []htmlTable{htmlServersTable(infoStruct.Info.Servers, names, allPoolSetDrives, config)}})
+	}
+	if config.ShowSets && infoStruct.IsErasure() && infoStruct.MissingTopology() == "" {
+		report.Sections = append(report.Sections, htmlSection{Title: "Erasure Sets", Tables: []htmlTable{htmlSetsTable(poolSetDrives, stats.ParityDisks, config)}})
+	}
 	if config.ShowDisks {
-		for _, drives := range poolSetDrives {
-			allDrives = append(allDrives, drives...)
+		report.Sections = append(report.Sections, htmlSection{Title: "Drives", Tables: []htmlTable{htmlDrivesTable(poolSetDrives, config)}})
+	}
+	return report
+}
+
+// htmlProblemsTable returns the findings, classed by severity
+func htmlProblemsTable(findings []finding) htmlTable {
+	table := htmlTable{Headers: []string{"Severity", "Category", "Location", "Problem"}}
+	for _, f := range findings {
+		message := f.Message
+		if f.New {
+			message += " NEW"
 		}
+		table.Rows = append(table.Rows, []htmlCell{
+			{Text: strings.ToUpper(f.Severity), Class: severityClass(f.Severity)},
+			{Text: f.Category},
+			{Text: f.location()},
+			{Text: message},
+		})
 	}
+	return table
+}
 
-	// Only print drives table if ShowDisks is true
-	if !config.ShowDisks {
-		return
+// htmlSummaryCards returns the figures of the cluster summary as cards
+func htmlSummaryCards(infoStruct *clusterStruct, stats ClusterStats, config *Config) []htmlCard {
+	deploymentID := stats.DeploymentID
+	if deploymentID == "" {
+		deploymentID = "Not available"
+	}
+	servers := len(infoStruct.Info.Servers)
+	cards := []htmlCard{
+		{Label: "Deployment ID", Value: deploymentID},
+		{Label: "Servers", Value: fmt.Sprintf("%d of %d online", stats.Online, servers), Class: countClass(servers-stats.Online, "bad")},
+		{Label: "Total Disks", Value: strconv.Itoa(stats.TotalDisks)},
+		{Label: "Healthy Disks", Value: strconv.Itoa(stats.OkDisks), Class: "ok"},
+		{Label: "Problem Disks", Value: strconv.Itoa(stats.BadDisks), Class: countClass(stats.BadDisks, "bad")},
+		{Label: "Scanning Disks", Value: strconv.Itoa(stats.ScanningDisks), Class: countClass(stats.ScanningDisks, "warn")},
+	}
+	if stats.HealthGrade != "" {
+		cards = append(cards, htmlCard{Label: "Health Grade", Value: stats.HealthGrade, Class: colorClass(gradeColor(stats.HealthGrade))})
+	}
+	if stats.TotalSpace <= 0 {
+		return cards
+	}
+	// Without erasure coding all raw space is usable
+	usable, available := stats.TotalSpace, stats.FreeSpace
+	cards = append(cards, htmlCard{Label: "Raw Capacity", Value: exactSize(stats.TotalSpace, config)})
+	if infoStruct.IsErasure() {
+		usable = stats.UsableSpace
+		available = usable - stats.UsedSpace
+		cards = append(cards, htmlCard{Label: "Usable Capacity", Value: exactSize(usable, config)})
+	}
+	var usedPct float64
+	if usable > 0 {
+		usedPct = float64(stats.UsedSpace) / float64(usable) * 100
+	}
+	return append(cards,
+		htmlCard{Label: "Used Space", Value: fmt.Sprintf("%s (%s)", exactSize(stats.UsedSpace, config), formatPct(usedPct, config)), Class: colorClass(usageColor(usedPct, config))},
+		htmlCard{Label: "Available Space", Value: exactSize(available, config)},
+	)
+}
+
+// htmlServersTable returns the state and drive capacity of every server
+func htmlServersTable(servers []madmin.ServerProperties, names *mdbcore.ServerNamer, allPoolSetDrives map[string][]DiskInfo, config *Config) htmlTable {
+	byServer := make(map[string][]DiskInfo)
+	for _, drives := range allPoolSetDrives {
+		for _, drive := range drives {
+			byServer[drive.Server] = append(byServer[drive.Server], drive)
+		}
 	}
+	sorted := append([]madmin.ServerProperties(nil), servers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return names.Name(sorted[i].Endpoint) < names.Name(sorted[j].Endpoint)
+	})
 
-	// Sort all drives by Pool, Erasure Set, Disk Index
-	sort.Slice(allDrives, func(i, j int) bool {
-		if allDrives[i].PoolIndex != allDrives[j].PoolIndex {
-			return allDrives[i].PoolIndex < allDrives[j].PoolIndex
+	table := htmlTable{Headers: []string{"Server", "State", "Drives", "Problem Drives", "Total Space", "Used Space", "Used %"}}
+	for _, server := range sorted {
+		name := names.Name(server.Endpoint)
+		var bad int
+		var total, used int64
+		for _, drive := range byServer[name] {
+			if drive.State != "ok" {
+				bad++
+			}
+			total += drive.TotalSpace
+			used += drive.UsedSpace
 		}
-		if allDrives[i].SetIndex != allDrives[j].SetIndex {
-			return allDrives[i].SetIndex < allDrives[j].SetIndex
+		state := htmlCell{Text: server.State, Class: "ok"}
+		if server.State != "online" {
+			state.Class = "bad"
 		}
-		// Compare DiskIndex - handle interface{} type
-		diStr := fmt.Sprintf("%v", allDrives[i].DiskIndex)
-		djStr := fmt.Sprintf("%v", allDrives[j].DiskIndex)
-		// Try numeric comparison first
-		if di, err1 := strconv.Atoi(diStr); err1 == nil {
-			if dj, err2 := strconv.Atoi(djStr); err2 == nil {
-				return di < dj
-			}
+		if config.BaselineChanges.newServer(name) {
+			state.Text += " NEW"
 		}
-		return diStr < djStr
-	})
+		usedPct := htmlCell{Text: "N/A"}
+		if total > 0 {
+			pct := float64(used) / float64(total) * 100
+			usedPct = htmlCell{Text: formatPct(pct, config), Class: colorClass(usageColor(pct, config))}
+		}
+		table.Rows = append(table.Rows, []htmlCell{
+			{Text: name},
+			state,
+			{Text: strconv.Itoa(len(byServer[name]))},
+			{Text: strconv.Itoa(bad), Class: countClass(bad, "bad")},
+			{Text: formatSize(total, config)},
+			{Text: formatSize(used, config)},
+			usedPct,
+		})
+	}
+	return table
+}
 
-	// Print single table with all drives
-	if len(allDrives) > 0 {
-		// Only print "Drives" header if ShowDisks is true and not already showing sets
-	if config.ShowDisks && !config.ShowSets {
-		pager.Printf("%sDrives%s\n", Bold, Reset)
-	} else if config.ShowDisks && config.ShowSets {
-		// When both ShowDisks and ShowSets are true, we don't need a separate header
-		pager.Printf("%sDrives%s\n", Bold, Reset)
+// htmlSetsTable returns the drive counts and average usage of every erasure set
+func htmlSetsTable(poolSetDrives map[string][]DiskInfo, parityDisks int, config *Config) htmlTable {
+	table := htmlTable{Headers: []string{"Pool", "Erasure Set", "Drives", "Good", "Bad", "Scanning", "Avg Used %", "Avg Free %", "Avg Inodes Used %"}}
+	sets := make([]ErasureSetInfo, 0, len(poolSetDrives))
+	for _, drives := range poolSetDrives {
+		if len(drives) > 0 {
+			sets = append(sets, mdbcore.SummarizeErasureSet(drives[0].PoolIndex, drives[0].SetIndex, drives))
+		}
 	}
-		printTable(pager, allDrives, config)
-		pager.Printf("\n")
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].PoolIdx != sets[j].PoolIdx {
+			return sets[i].PoolIdx < sets[j].PoolIdx
+		}
+		return sets[i].SetIdx < sets[j].SetIdx
+	})
+	for _, es := range sets {
+		// A set that lost more drives than its parity is down
+		badClass := "warn"
+		if es.Bad > parityDisks {
+			badClass = "bad"
+		}
+		table.Rows = append(table.Rows, []htmlCell{
+			{Text: strconv.Itoa(es.PoolIdx)},
+			{Text: strconv.Itoa(es.SetIdx)},
+			{Text: strconv.Itoa(len(es.Drives))},
+			{Text: strconv.Itoa(es.Good)},
+			{Text: strconv.Itoa(es.Bad), Class: countClass(es.Bad, badClass)},
+			{Text: strconv.Itoa(es.Scanning), Class: countClass(es.Scanning, "warn")},
+			{Text: formatPct(es.AvgSpaceUsedPct, config), Class: colorClass(usageColor(es.AvgSpaceUsedPct, config))},
+			{Text: formatPct(es.AvgFreeSpacePct, config), Class: colorClass(freeColor(es.AvgFreeSpacePct, config))},
+			{Text: formatPct(es.AvgInodesUsedPct, config), Class: colorClass(inodeColor(es.AvgInodesUsedPct, config))},
+		})
 	}
+	return table
 }
 
-func printTable(pager *Pager, drives []DiskInfo, config *Config) {
-	if len(drives) == 0 {
-		return
+// htmlDrivesTable returns the Drives table with the columns selected by
+// config, each cell classed by the drive value it shows
+func htmlDrivesTable(poolSetDrives map[string][]DiskInfo, config *Config) htmlTable {
+	var drives []DiskInfo
+	for _, setDrives := range poolSetDrives {
+		drives = append(drives, setDrives...)
 	}
+	sort.Slice(drives, func(i, j int) bool {
+		return driveLess(drives[i], drives[j])
+	})
 
-	headers := []string{"Pool", "Erasure Set", "Disk Index", "Server", "Disk Path", "State", "Scanning", "UUID", "Total Space", "Space Used", "Free Space", "Inodes Used", "Local", "Metrics"}
-
-	rows := make([][]string, 0, len(drives))
+	columns := driveTableColumns(config)
+	table := htmlTable{Headers: make([]string, len(columns))}
+	for i, column := range columns {
+		table.Headers[i] = column.Header
+	}
 	for _, drive := range drives {
-		row := make([]string, len(headers))
-
-		poolIdxStr := fmt.Sprintf("%d", drive.PoolIndex)
-		setIdxStr := fmt.Sprintf("%d", drive.SetIndex)
-		diskIdxStr := fmt.Sprintf("%v", drive.DiskIndex)
-
-		serverParts := strings.Split(drive.Server, ".")
-		serverName := serverParts[0]
-
-		stateColor := Green
-		if drive.State != "ok" {
-			stateColor = Red
+		row := make([]htmlCell, len(columns))
+		for i, column := range columns {
+			row[i] = htmlCell{Text: stripANSI(column.Value(drive, config)), Class: driveCellClass(column.ID, drive, config)}
 		}
-		stateText := fmt.Sprintf("%s%s%s", stateColor, drive.State, Reset)
-
-		scanningColor := Yellow
-		if !drive.Scanning {
-			scanningColor = Green
-		}
-		scanningText := fmt.Sprintf("%s%s%s", scanningColor, boolToYesNo(drive.Scanning), Reset)
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
 
-		uuid := drive.UUID
-		if len(uuid) > 16 {
-			uuid = uuid[:16] + "..."
+// driveCellClass returns the class of the Drives table column id of drive,
+// with the thresholds the text output colors the column with
+func driveCellClass(id string, drive DiskInfo, config *Config) string {
+	switch id {
+	case "state":
+		return colorClass(driveStateColor(drive.State))
+	case "used_space", "used_pct":
+		if drive.TotalSpace > 0 {
+			return colorClass(usageColor(drive.UsedSpacePct, config))
 		}
-
-		var totalSpaceStr, spaceUsedStr, freeSpaceStr string
+	case "free_space":
 		if drive.TotalSpace > 0 {
-			totalGB := float64(drive.TotalSpace) / (1024 * 1024 * 1024)
-			usedGB := float64(drive.UsedSpace) / (1024 * 1024 * 1024)
-			freeGB := float64(drive.AvailableSpace) / (1024 * 1024 * 1024)
-
-			usageColor := Green
-			if drive.UsedSpacePct >= 95 {
-				usageColor = Red
-			} else if drive.UsedSpacePct >= 80 {
-				usageColor = Yellow
-			}
-			freeColor := Green
-			if drive.FreeSpacePct <= 5 {
-				freeColor = Red
-			} else if drive.FreeSpacePct <= 20 {
-				freeColor = Yellow
-			}
-
-			totalSpaceStr = fmt.Sprintf("%.1fGB", totalGB)
-			spaceUsedStr = fmt.Sprintf("%.1fGB (%s%.1f%%%s)", usedGB, usageColor, drive.UsedSpacePct, Reset)
-			freeSpaceStr = fmt.Sprintf("%.1fGB (%s%.1f%%%s)", freeGB, freeColor, drive.FreeSpacePct, Reset)
-		} else {
-			totalSpaceStr = "N/A"
-			spaceUsedStr = "N/A"
-			freeSpaceStr = "N/A"
-		}
-
-		var inodeStr string
-		if drive.UsedInodes > 0 {
-			totalInodes := drive.UsedInodes + drive.FreeInodes
-			inodePct := float64(drive.UsedInodes) / float64(totalInodes) * 100
-			inodeColor := Green
-			if inodePct >= 95 {
-				inodeColor = Red
-			} else if inodePct >= 80 {
-				inodeColor = Yellow
-			}
-			inodeStr = fmt.Sprintf("%s (%s%.1f%%%s)", formatInt(drive.UsedInodes), inodeColor, inodePct, Reset)
-		} else {
-			inodeStr = "N/A"
+			return colorClass(driveFreeColor(drive, config))
 		}
-
-		localColor := Green
+	case "inodes_used":
+		if inodePct, ok := inodeUsagePct(drive); ok {
+			return colorClass(inodeColor(inodePct, config))
+		}
+	case "healing":
+		if drive.Healing {
+			return "warn"
+		}
+		return "ok"
+	case "local":
 		if !drive.Local {
-			localColor = Yellow
+			return "warn"
 		}
-		localText := fmt.Sprintf("%s%s%s", localColor, boolToYesNo(drive.Local), Reset)
-
-		metricsStr := formatMetrics(drive.Metrics)
-
-		row[0] = fmt.Sprintf("%s%s%s", Blue, poolIdxStr, Reset)
-		row[1] = fmt.Sprintf("%s%s%s", Blue, setIdxStr, Reset)
-		row[2] = diskIdxStr
-		row[3] = serverName
-		row[4] = drive.Path
-		row[5] = stateText
-		row[6] = scanningText
-		row[7] = uuid
-		row[8] = totalSpaceStr
-		row[9] = spaceUsedStr
-		row[10] = freeSpaceStr
-		row[11] = inodeStr
-		row[12] = localText
-		row[13] = metricsStr
-
-		rows = append(rows, row)
-	}
-
-	// Calculate column widths
-	widths := make([]int, len(headers))
-	for i, h := range headers {
-		widths[i] = utf8.RuneCountInString(h)
-	}
-	for _, row := range rows {
-		for i, cell := range row {
-			// Strip ANSI codes for width calculation
-			cleanCell := stripANSI(cell)
-			if w := utf8.RuneCountInString(cleanCell); w > widths[i] {
-				widths[i] = w
+		return "ok"
+	case "type":
+		if drive.RootDisk {
+			return "warn"
+		}
+	case "errors":
+		if drive.Metrics != nil && drive.Metrics.TotalErrorsAvailability > 0 {
+			if age, ok := driveLastErrorAge(drive); ok && age > recentErrorWindow {
+				return "warn"
 			}
+			return "bad"
 		}
 	}
+	return ""
+}
 
-	// Print header
-	pager.Printf("  ")
-	for i, h := range headers {
-		pager.Printf("%s", padString(h, widths[i]))
-		if i < len(headers)-1 {
-			pager.Printf("  ")
-		}
-	}
-	pager.Printf("\n")
+// reportTable is a table collected for CSV output, without colors
+type reportTable struct {
+	Headers []string
+	Rows    [][]string
+}
 
-	// Print separator
-	pager.Printf("  ")
-	for i, w := range widths {
-		pager.Printf("%s", strings.Repeat("-", w))
-		if i < len(widths)-1 {
-			pager.Printf("  ")
+// addReportTable collects a table for CSV output
+func (p *Pager) addReportTable(headers []string, rows [][]string) {
+	table := reportTable{Headers: headers, Rows: make([][]string, len(rows))}
+	for i, row := range rows {
+		table.Rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			table.Rows[i][j] = stripANSI(cell)
 		}
 	}
-	pager.Printf("\n")
+	p.tables = append(p.tables, table)
+}
 
-	// Print rows
-	for _, row := range rows {
-		pager.Printf("  ")
-		for i, cell := range row {
-			pager.Printf("%s", padString(cell, widths[i]))
-			if i < len(row)-1 {
-				pager.Printf("  ")
-			}
-		}
-		pager.Printf("\n")
-	}
+// htmlReportTemplate renders a self-contained HTML document without external dependencies
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8"/>
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #222; }
+h1 { font-size: 22px; }
+h2 { font-size: 18px; margin-top: 28px; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+.snapshot { color: #666; }
+.cards { display: flex; flex-wrap: wrap; gap: 10px; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 8px 12px; min-width: 140px; background: #fafafa; }
+.card .label { font-size: 12px; color: #666; }
+.card .value { font-size: 16px; font-weight: 600; word-break: break-word; }
+table { border-collapse: collapse; font-size: 13px; margin-top: 8px; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; white-space: nowrap; }
+th { background: #f0f0f0; cursor: pointer; user-select: none; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+.ok { color: #1a7f37; }
+.warn { color: #9a6700; background: #fff8c5; }
+.bad { color: #cf222e; background: #ffebe9; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Snapshot}}<p class="snapshot">Snapshot: {{.Snapshot}}</p>{{end}}
+{{range .Sections}}<section>
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+{{if .Cards}}<div class="cards">
+{{range .Cards}}<div class="card"><div class="label">{{.Label}}</div><div class="value {{.Class}}">{{.Value}}</div></div>
+{{end}}</div>{{end}}
+{{range .Tables}}<table class="sortable">
+<thead><tr>{{range .Headers}}<th onclick="sortTable(this)">{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td class="{{.Class}}">{{.Text}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}</section>
+{{end}}<script>
+function sortTable(th) {
+  var tbody = th.closest("table").tBodies[0];
+  var headers = Array.prototype.slice.call(th.parentNode.children);
+  var idx = headers.indexOf(th);
+  var asc = th.getAttribute("data-order") !== "asc";
+  headers.forEach(function (h) { h.removeAttribute("data-order"); });
+  th.setAttribute("data-order", asc ? "asc" : "desc");
+  var rows = Array.prototype.slice.call(tbody.rows);
+  rows.sort(function (a, b) {
+    var x = a.cells[idx].textContent.trim(), y = b.cells[idx].textContent.trim();
+    var nx = parseFloat(x.replace(/,/g, "")), ny = parseFloat(y.replace(/,/g, ""));
+    var c = (isNaN(nx) || isNaN(ny)) ? x.localeCompare(y, undefined, {numeric: true}) : nx - ny;
+    return asc ? c : -c;
+  });
+  rows.forEach(function (r) { tbody.appendChild(r); });
+}
+</script>
+</body>
+</html>
+`))
+
+// renderHTMLReport writes the HTML report document to w
+func renderHTMLReport(w io.Writer, report *htmlReport) error {
+	return htmlReportTemplate.Execute(w, report)
 }
 
 func stripANSI(s string) string {
@@ -2296,9 +12655,29 @@ func stripANSI(s string) string {
 	return result.String()
 }
 
+// visibleWidth returns the number of runes of s outside ANSI codes
+func visibleWidth(s string) int {
+	width := 0
+	inANSI := false
+	for _, r := range s {
+		if r == '\033' {
+			inANSI = true
+			continue
+		}
+		if inANSI {
+			if r == 'm' {
+				inANSI = false
+			}
+			continue
+		}
+		width++
+	}
+	return width
+}
+
 // padString pads a string to the specified width, accounting for ANSI codes
 func padString(s string, width int) string {
-	visibleWidth := utf8.RuneCountInString(stripANSI(s))
+	visibleWidth := visibleWidth(s)
 	if visibleWidth >= width {
 		return s
 	}
@@ -2313,6 +12692,28 @@ func boolToYesNo(b bool) string {
 	return "No"
 }
 
+// driveLess orders drives by pool, erasure set and disk index, drives without
+// an index last, then by server and path in natural order, so /data/disk2
+// comes before /data/disk10
+func driveLess(a, b DiskInfo) bool {
+	if a.PoolIndex != b.PoolIndex {
+		return a.PoolIndex < b.PoolIndex
+	}
+	if a.SetIndex != b.SetIndex {
+		return a.SetIndex < b.SetIndex
+	}
+	if a.NoDiskIndex != b.NoDiskIndex {
+		return !a.NoDiskIndex
+	}
+	if a.DiskIndex != b.DiskIndex {
+		return a.DiskIndex < b.DiskIndex
+	}
+	if a.Server != b.Server {
+		return naturalLess(a.Server, b.Server)
+	}
+	return naturalLess(a.Path, b.Path)
+}
+
 // naturalLess compares two strings using natural/alphanumeric sorting
 // This ensures that "rack2" comes before "rack10"
 func naturalLess(a, b string) bool {
@@ -2397,40 +12798,6 @@ func formatInt(n int64) string {
 	return result.String()
 }
 
-// trimDomainData trims domain suffix from endpoint for cleaner display
-func trimDomainData(endpoint, domainString string) string {
-	// Normalize endpoint to extract host (remove scheme, path and port)
-	host := endpoint
-
-	// If endpoint contains a scheme or a path, try parsing it as a URL
-	if strings.Contains(host, "://") {
-		if u, err := url.Parse(host); err == nil {
-			host = u.Host
-		}
-	} else if strings.Contains(host, "/") {
-		// try parsing by adding a scheme so url.Parse treats the first part as host
-		if u, err := url.Parse("http://" + host); err == nil {
-			host = u.Host
-		}
-	}
-
-	// Strip port if present (handles host:port and [ipv6]:port)
-	if h, _, err := net.SplitHostPort(host); err == nil {
-		host = h
-	}
-
-	// If host is an IP address (v4 or v6), return it as-is
-	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
-		return ip.String()
-	}
-
-	// Fallback to previous behaviour for domain names
-	if domainString == "" {
-		return strings.SplitN(host, ".", 2)[0]
-	}
-	return strings.TrimSuffix(strings.TrimSuffix(host, domainString), ".")
-}
-
 // humanizeDuration humanizes time.Duration output to a meaningful value
 func humanizeDuration(duration time.Duration) string {
 	if duration.Seconds() < 60.0 {
@@ -2454,186 +12821,519 @@ func humanizeDuration(duration time.Duration) string {
 		int64(remainingMinutes), int64(remainingSeconds))
 }
 
-// generateBashCompletion generates bash completion script
+// compactDurationUnits are the units of compactDuration, largest first
+var compactDurationUnits = []struct {
+	size   time.Duration
+	suffix string
+}{{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}}
+
+// compactDuration is the compact form of humanizeDuration for table cells: the
+// two largest units of the duration, such as "3d4h", "4h12m" or "45s", or only
+// the largest one when the next is zero, such as "10d"
+func compactDuration(duration time.Duration) string {
+	units := compactDurationUnits
+	i := 0
+	for i < len(units)-1 && duration < units[i].size {
+		i++
+	}
+	if duration < 0 {
+		duration = 0
+	}
+	text := fmt.Sprintf("%d%s", duration/units[i].size, units[i].suffix)
+	if i+1 < len(units) {
+		if rest := duration % units[i].size / units[i+1].size; rest > 0 {
+			text += fmt.Sprintf("%d%s", rest, units[i+1].suffix)
+		}
+	}
+	return text
+}
+
+// snapshotExtensions are the file name extensions completed for snapshot file arguments
+var snapshotExtensions = []string{".json", ".json.gz", ".ndjson"}
+
+// flagValueHint describes what shell completion offers as the value of a flag
+type flagValueHint struct {
+	Values   []string // fixed choices
+	File     bool     // any file name
+	Snapshot bool     // a snapshot file, see snapshotExtensions
+}
+
+// flagValueHints holds the value hints of flags by name. Other flags that take
+// a value take free-form values and complete nothing.
+var flagValueHints = map[string]flagValueHint{
+	"format":        {Values: []string{formatText, formatMarkdown, formatHTML, formatCSV, formatGrafana}},
+	"color":         {Values: []string{colorAuto, colorAlways, colorNever}},
+	"units":         {Values: []string{unitsIEC, unitsSI, unitsBytes}},
+	"fixed-unit":    {Values: []string{fixedUnitGiB, fixedUnitTiB}},
+	"free-color-by": {Values: []string{freeColorByPct, freeColorByBytes}},
+	"trim-domain":   {Values: []string{mdbcore.AutoTrimDomain}},
+	"record":        {Values: []string{"first", "last"}},
+	"preset":        {Values: builtinPresetNames()},
+	"columns":       {Values: append(driveColumnIDs(), "all")},
+	"sort-by":       {Values: serverCapacitySortKeys},
+	"sets-sort":     {Values: setSortKeys},
+	"state":         {Values: driveStates},
+	"fail-on":       {Values: []string{severityCritical, severityWarning, severityInfo}},
+	"output":        {File: true},
+	"out":           {File: true},
+	"bundle":        {File: true},
+	"anonymize-map": {File: true},
+	"heal":          {File: true},
+	"replication":   {File: true},
+	"metrics-file":  {File: true},
+	"pool-status":   {File: true},
+	"validate":      {Snapshot: true},
+}
+
+// builtinPresetNames returns the names of drivePresets in sorted order
+func builtinPresetNames() []string {
+	names := make([]string, 0, len(drivePresets))
+	for name := range drivePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completionNode is a command line prefix such as "mdb show sets" with the
+// commands, flags and arguments that can follow it
+type completionNode struct {
+	Path     string
+	Commands []cli.Command
+	Flags    []cli.Flag
+	Snapshot bool // takes a snapshot file, "file.json" in its usage text
+	Config   bool // takes the name of a configuration, "<name>" in its usage text
+}
+
+// completionNodes returns the root of app and every command under it, once per
+// name and alias, in definition order
+func completionNodes(app *cli.App) []completionNode {
+	nodes := []completionNode{{Path: app.Name, Commands: app.Commands, Flags: app.Flags}}
+	var walk func(path string, commands []cli.Command)
+	walk = func(path string, commands []cli.Command) {
+		for _, command := range commands {
+			snapshot := strings.Contains(command.UsageText, "file.json")
+			for _, name := range commandNames(command) {
+				nodes = append(nodes, completionNode{
+					Path:     path + " " + name,
+					Commands: command.Subcommands,
+					Flags:    command.Flags,
+					Snapshot: snapshot,
+					Config:   !snapshot && strings.Contains(command.UsageText, "<name>"),
+				})
+				walk(path+" "+name, command.Subcommands)
+			}
+		}
+	}
+	walk(app.Name, app.Commands)
+	return nodes
+}
+
+// commandNames returns the name and aliases of command
+func commandNames(command cli.Command) []string {
+	return append([]string{command.Name}, command.Aliases...)
+}
+
+// flagNames returns the names of flag with their dashes, e.g. "--format"
+func flagNames(flag cli.Flag) []string {
+	var names []string
+	for _, name := range strings.Split(flag.GetName(), ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 1 {
+			names = append(names, "-"+name)
+		} else {
+			names = append(names, "--"+name)
+		}
+	}
+	return names
+}
+
+// flagUsage returns the usage text of flag
+func flagUsage(flag cli.Flag) string {
+	switch flag := flag.(type) {
+	case cli.BoolFlag:
+		return flag.Usage
+	case cli.StringFlag:
+		return flag.Usage
+	case cli.IntFlag:
+		return flag.Usage
+	}
+	return ""
+}
+
+// flagHint returns the value hint of flag and whether it is followed by a
+// value: most flags that take one are, boolean flags and flags with an
+// optional value (see optionalFlagDefaults) only with a hint
+func flagHint(flag cli.Flag) (flagValueHint, bool) {
+	name := strings.TrimLeft(flagNames(flag)[0], "-")
+	hint, ok := flagValueHints[name]
+	_, isBool := flag.(cli.BoolFlag)
+	if _, optional := optionalFlagDefaults[name]; isBool || optional {
+		return hint, ok
+	}
+	return hint, true
+}
+
+// hintGroups groups the flags of nodes followed by a value by the completion
+// of that value: one group per list of values, then files, snapshot files and
+// free-form values. Each group is a "|"-separated list of flag names.
+func hintGroups(nodes []completionNode) (values [][2]string, files, snapshots, freeForm string) {
+	var fileNames, snapshotNames, freeFormNames []string
+	for _, flag := range valueFlags(nodes) {
+		hint, _ := flagHint(flag)
+		names := strings.Join(flagNames(flag), "|")
+		switch {
+		case len(hint.Values) > 0:
+			values = append(values, [2]string{names, strings.Join(hint.Values, " ")})
+		case hint.File:
+			fileNames = append(fileNames, names)
+		case hint.Snapshot:
+			snapshotNames = append(snapshotNames, names)
+		default:
+			freeFormNames = append(freeFormNames, names)
+		}
+	}
+	return values, strings.Join(fileNames, "|"), strings.Join(snapshotNames, "|"), strings.Join(freeFormNames, "|")
+}
+
+// valueFlags returns the flags of nodes followed by a value, once per name, sorted by name
+func valueFlags(nodes []completionNode) []cli.Flag {
+	seen := make(map[string]bool)
+	var flags []cli.Flag
+	for _, node := range nodes {
+		for _, flag := range node.Flags {
+			if _, ok := flagHint(flag); ok && !seen[flag.GetName()] {
+				seen[flag.GetName()] = true
+				flags = append(flags, flag)
+			}
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].GetName() < flags[j].GetName() })
+	return flags
+}
+
+// commandPaths returns the paths of nodes below the root, quoted for a shell case pattern
+func commandPaths(nodes []completionNode) string {
+	paths := make([]string, 0, len(nodes))
+	for _, node := range nodes[1:] {
+		paths = append(paths, `"`+node.Path+`"`)
+	}
+	return strings.Join(paths, "|")
+}
+
+// nodeWords returns the command names and the flag names of node
+func nodeWords(node completionNode) (commands, flags []string) {
+	for _, command := range node.Commands {
+		commands = append(commands, commandNames(command)...)
+	}
+	for _, flag := range node.Flags {
+		flags = append(flags, flagNames(flag)...)
+	}
+	return commands, flags
+}
+
+// snapshotPattern returns a regular expression matching snapshot file names
+func snapshotPattern() string {
+	quoted := make([]string, len(snapshotExtensions))
+	for i, ext := range snapshotExtensions {
+		quoted[i] = regexp.QuoteMeta(strings.TrimPrefix(ext, "."))
+	}
+	return `\.(` + strings.Join(quoted, "|") + `)$`
+}
+
+// generateBashCompletion generates the bash completion script from the
+// commands and flags of the application
 func generateBashCompletion() string {
-	return `# bash completion for mdb
+	app := newApp()
+	nodes := completionNodes(app)
+	var b strings.Builder
+	fmt.Fprintf(&b, `# bash completion for %[1]s, generated by "%[1]s completion bash"
+
+# _%[1]s_snapshots adds snapshot files and directories to the completions
+_%[1]s_snapshots() {
+    local IFS=$'\n'
+    compopt -o filenames 2>/dev/null
+    COMPREPLY+=($(compgen -d -- "$cur"; compgen -f -- "$cur" | grep -E '%[2]s'))
+}
 
-_mdb() {
-    local cur prev words cword
+_%[1]s() {
+    local cur prev cmd i
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
-    words=("${COMP_WORDS[@]}")
 
+    # The value of the flag before the current word
     case "$prev" in
-        mdb)
-            COMPREPLY=($(compgen -W "version completion config show" -- "$cur"))
-            return 0
-            ;;
-        config)
-            COMPREPLY=($(compgen -W "add info list switch remove" -- "$cur"))
-            return 0
-            ;;
-        show)
-            COMPREPLY=($(compgen -W "summary sets disks servers" -- "$cur"))
-            return 0
-            ;;
-        completion)
-            COMPREPLY=($(compgen -W "bash zsh" -- "$cur"))
-            return 0
-            ;;
-        switch|remove)
-            # Dynamic completion for config names
-            if [ "${COMP_WORDS[COMP_CWORD-2]}" = "config" ]; then
-                local configs
-                configs=$(mdb __complete config "$prev" "$cur" 2>/dev/null)
-                if [ $? -eq 0 ] && [ -n "$configs" ]; then
-                    COMPREPLY=($(compgen -W "$configs" -- "$cur"))
-                fi
-            fi
-            return 0
-            ;;
-        --low-space|--min-bad-disks|--trim-domain)
-            return 0
-            ;;
-    esac
-
-    # Complete flags
-    if [[ "$cur" == -* ]]; then
-        local flags=""
-        case "${words[1]}" in
-            show)
-                flags="--pager --trim-domain"
-                if [ ${#words[@]} -ge 3 ]; then
-                    case "${words[2]}" in
-                        sets)
-                            flags="$flags --scanning --failed --low-space --min-bad-disks"
-                            ;;
-                        disks)
-                            flags="$flags --scanning --failed --low-space"
-                            ;;
-                        servers)
-                            flags="$flags --failed"
-                            ;;
-                    esac
-                fi
+`, app.Name, snapshotPattern())
+	values, files, snapshots, freeForm := hintGroups(nodes)
+	for _, group := range values {
+		fmt.Fprintf(&b, "        %s)\n            COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n            return 0\n            ;;\n", group[0], group[1])
+	}
+	if files != "" {
+		fmt.Fprintf(&b, "        %s)\n            compopt -o filenames 2>/dev/null\n            COMPREPLY=($(compgen -f -- \"$cur\"))\n            return 0\n            ;;\n", files)
+	}
+	if snapshots != "" {
+		fmt.Fprintf(&b, "        %s)\n            _%s_snapshots\n            return 0\n            ;;\n", snapshots, app.Name)
+	}
+	if freeForm != "" {
+		fmt.Fprintf(&b, "        %s)\n            return 0\n            ;;\n", freeForm)
+	}
+	fmt.Fprintf(&b, `    esac
+
+    # The commands before the current word, e.g. "%[1]s show sets"
+    cmd=%[1]s
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "$cmd ${COMP_WORDS[i]}" in
+            %[2]s)
+                cmd="$cmd ${COMP_WORDS[i]}"
+                ;;
+            *)
+                break
                 ;;
         esac
-        if [ -n "$flags" ]; then
-            COMPREPLY=($(compgen -W "$flags" -- "$cur"))
-        fi
-        return 0
-    fi
+    done
 
+    case "$cmd" in
+`, app.Name, commandPaths(nodes))
+	for _, node := range nodes {
+		commands, flags := nodeWords(node)
+		if len(commands) == 0 && len(flags) == 0 && !node.Snapshot && !node.Config {
+			continue
+		}
+		var lines []string
+		if len(commands) > 0 {
+			lines = append(lines, fmt.Sprintf("COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))", strings.Join(commands, " ")))
+		}
+		if node.Snapshot {
+			lines = append(lines, "_"+app.Name+"_snapshots")
+		}
+		if node.Config {
+			lines = append(lines, fmt.Sprintf("COMPREPLY+=($(compgen -W \"$(%s __complete %s \"$cur\" 2>/dev/null)\" -- \"$cur\"))", app.Name, strings.TrimPrefix(node.Path, app.Name+" ")))
+		}
+		fmt.Fprintf(&b, "        %q)\n", node.Path)
+		indent := "            "
+		if len(flags) > 0 {
+			fmt.Fprintf(&b, "            if [[ \"$cur\" == -* ]]; then\n                COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(flags, " "))
+			if len(lines) > 0 {
+				b.WriteString("            else\n")
+			}
+			indent = "                "
+		}
+		for _, line := range lines {
+			b.WriteString(indent + line + "\n")
+		}
+		if len(flags) > 0 {
+			b.WriteString("            fi\n")
+		}
+		b.WriteString("            ;;\n")
+	}
+	fmt.Fprintf(&b, `    esac
     return 0
 }
 
-complete -F _mdb mdb
-`
+complete -F _%[1]s %[1]s
+`, app.Name)
+	return b.String()
+}
+
+// zshQuote quotes text for zsh in single quotes
+func zshQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
 }
 
-// generateZshCompletion generates zsh completion script
+// generateZshCompletion generates the zsh completion script from the
+// commands and flags of the application
 func generateZshCompletion() string {
-	return `#compdef mdb
-
-_mdb() {
-    local -a commands subcommands flags
-
-    _arguments -C \
-        '1: :->command' \
-        '2: :->subcommand' \
-        '*: :->args' \
-        && return 0
-
-    case $state in
-        command)
-            commands=(
-                'version:Show version information'
-                'completion:Generate shell completion scripts'
-                'config:Manage configuration files'
-                'show:Show cluster information'
-            )
-            _describe 'commands' commands
-            ;;
-        subcommand)
-            case $words[2] in
-                completion)
-                    subcommands=(
-                        'bash:Generate bash completion script'
-                        'zsh:Generate zsh completion script'
-                    )
-                    _describe 'completion shells' subcommands
-                    ;;
-                config)
-                    subcommands=(
-                        'add:Add a new configuration'
-                        'info:Show current configuration information'
-                        'list:List all available configurations'
-                        'switch:Switch to a different configuration'
-                        'remove:Remove a configuration'
-                    )
-                    _describe 'config commands' subcommands
-                    ;;
-                show)
-                    subcommands=(
-                        'summary:Show summary only'
-                        'sets:Show erasure sets only'
-                        'disks:Show disks only'
-                        'servers:Show servers only'
-                    )
-                    _describe 'show commands' subcommands
-                    ;;
-            esac
-            ;;
-        args)
-            # Dynamic completion for config names
-            if [[ $words[2] == "config" ]] && [[ $words[3] == (switch|remove) ]]; then
-                local configs
-                configs=(${(f)"$(mdb __complete config $words[3] "" 2>/dev/null)"})
-                if [[ $? -eq 0 ]] && [[ -n "$configs" ]]; then
-                    _describe 'config names' configs
-                fi
-                return 0
-            fi
-
-            # Flag completion
-            case $words[2] in
-                show)
-                    flags=(
-                        '--pager:Enable pagination'
-                        '--trim-domain:Trim domain suffix from endpoint names'
-                    )
-                    case $words[3] in
-                        sets)
-                            flags+=(
-                                '--scanning:Show only scanning disks'
-                                '--failed:Show only failed/faulty disks'
-                                '--low-space:Filter by free space percentage'
-                                '--min-bad-disks:Filter by minimum bad disks'
-                            )
-                            ;;
-                        disks)
-                            flags+=(
-                                '--scanning:Show only scanning disks'
-                                '--failed:Show only failed/faulty disks'
-                                '--low-space:Filter by free space percentage'
-                            )
-                            ;;
-                        servers)
-                            flags+=(
-                                '--failed:Show only offline servers'
-                            )
-                            ;;
-                    esac
-                    _describe 'flags' flags
-                    ;;
-            esac
+	app := newApp()
+	nodes := completionNodes(app)
+	var b strings.Builder
+	globs := make([]string, len(snapshotExtensions))
+	for i, ext := range snapshotExtensions {
+		globs[i] = strings.TrimPrefix(ext, ".")
+	}
+	fmt.Fprintf(&b, `#compdef %[1]s
+# zsh completion for %[1]s, generated by "%[1]s completion zsh"
+
+# _%[1]s_snapshots completes snapshot files and directories
+_%[1]s_snapshots() {
+    _files -g '*.(%[2]s)'
+}
+
+_%[1]s() {
+    local cmd=%[1]s i
+    local -a commands flags
+
+    # The value of the flag before the current word
+    case $words[CURRENT-1] in
+`, app.Name, strings.Join(globs, "|"))
+	values, files, snapshots, freeForm := hintGroups(nodes)
+	for _, group := range values {
+		fmt.Fprintf(&b, "        (%s)\n            compadd -- %s\n            return\n            ;;\n", group[0], group[1])
+	}
+	if files != "" {
+		fmt.Fprintf(&b, "        (%s)\n            _files\n            return\n            ;;\n", files)
+	}
+	if snapshots != "" {
+		fmt.Fprintf(&b, "        (%s)\n            _%s_snapshots\n            return\n            ;;\n", snapshots, app.Name)
+	}
+	if freeForm != "" {
+		fmt.Fprintf(&b, "        (%s)\n            _message 'value'\n            return\n            ;;\n", freeForm)
+	}
+	fmt.Fprintf(&b, `    esac
+
+    # The commands before the current word, e.g. "%[1]s show sets"
+    for ((i = 2; i < CURRENT; i++)); do
+        case "$cmd $words[i]" in
+            (%[2]s)
+                cmd="$cmd $words[i]"
+                ;;
+            (*)
+                break
+                ;;
+        esac
+    done
+
+    case $cmd in
+`, app.Name, commandPaths(nodes))
+	var snapshotPaths []string
+	var configNodes []completionNode
+	for _, node := range nodes {
+		if node.Snapshot {
+			snapshotPaths = append(snapshotPaths, `"`+node.Path+`"`)
+		}
+		if node.Config {
+			configNodes = append(configNodes, node)
+		}
+		if len(node.Commands) == 0 && len(node.Flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "        (%q)\n", node.Path)
+		if len(node.Commands) > 0 {
+			b.WriteString("            commands=(\n")
+			for _, command := range node.Commands {
+				for _, name := range commandNames(command) {
+					fmt.Fprintf(&b, "                %s\n", zshQuote(name+":"+command.Usage))
+				}
+			}
+			b.WriteString("            )\n")
+		}
+		if len(node.Flags) > 0 {
+			b.WriteString("            flags=(\n")
+			for _, flag := range node.Flags {
+				for _, name := range flagNames(flag) {
+					fmt.Fprintf(&b, "                %s\n", zshQuote(name+":"+flagUsage(flag)))
+				}
+			}
+			b.WriteString("            )\n")
+		}
+		b.WriteString("            ;;\n")
+	}
+	fmt.Fprintf(&b, `    esac
+
+    if [[ $PREFIX == -* ]]; then
+        _describe 'flags' flags
+        return
+    fi
+    (( $#commands )) && _describe 'commands' commands
+    case $cmd in
+`)
+	if len(snapshotPaths) > 0 {
+		fmt.Fprintf(&b, "        (%s)\n            _%s_snapshots\n            ;;\n", strings.Join(snapshotPaths, "|"), app.Name)
+	}
+	for _, node := range configNodes {
+		fmt.Fprintf(&b, `        (%q)
+            local -a configs
+            configs=(${(f)"$(%s __complete %s "$PREFIX" 2>/dev/null)"})
+            (( $#configs )) && _describe 'configurations' configs
             ;;
-    esac
+`, node.Path, app.Name, strings.TrimPrefix(node.Path, app.Name+" "))
+	}
+	fmt.Fprintf(&b, `    esac
 }
 
-_mdb "$@"
-`
+_%[1]s "$@"
+`, app.Name)
+	return b.String()
+}
+
+// fishQuote quotes text for fish in single quotes
+func fishQuote(text string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(text) + "'"
+}
+
+// generateFishCompletion generates the fish completion script from the
+// commands and flags of the application
+func generateFishCompletion() string {
+	app := newApp()
+	nodes := completionNodes(app)
+	var b strings.Builder
+	cases := make([]string, 0, len(nodes)-1)
+	for _, node := range nodes[1:] {
+		cases = append(cases, fishQuote(node.Path))
+	}
+	fmt.Fprintf(&b, `# fish completion for %[1]s, generated by "%[1]s completion fish"
+
+# __%[1]s_command prints the commands on the command line, e.g. "%[1]s show sets"
+function __%[1]s_command
+    set -l cmd %[1]s
+    set -l words (commandline -opc)
+    set -e words[1]
+    for word in $words
+        switch "$cmd $word"
+            case %[2]s
+                set cmd "$cmd $word"
+            case '*'
+                break
+        end
+    end
+    echo $cmd
+end
+
+# __%[1]s_snapshots completes snapshot files and directories
+function __%[1]s_snapshots
+`, app.Name, strings.Join(cases, " "))
+	for _, ext := range snapshotExtensions {
+		fmt.Fprintf(&b, "    __fish_complete_suffix %s\n", ext)
+	}
+	fmt.Fprintf(&b, "end\n\ncomplete -c %s -f\n", app.Name)
+	for _, node := range nodes {
+		condition := fishQuote(fmt.Sprintf("test (__%s_command) = %q", app.Name, node.Path))
+		for _, command := range node.Commands {
+			for _, name := range commandNames(command) {
+				fmt.Fprintf(&b, "complete -c %s -n %s -a %s -d %s\n", app.Name, condition, name, fishQuote(command.Usage))
+			}
+		}
+		for _, flag := range node.Flags {
+			var options []string
+			for _, name := range flagNames(flag) {
+				if strings.HasPrefix(name, "--") {
+					options = append(options, "-l "+strings.TrimPrefix(name, "--"))
+				} else {
+					options = append(options, "-s "+strings.TrimPrefix(name, "-"))
+				}
+			}
+			if hint, ok := flagHint(flag); ok {
+				switch {
+				case len(hint.Values) > 0:
+					options = append(options, "-x -a "+fishQuote(strings.Join(hint.Values, " ")))
+				case hint.File:
+					options = append(options, "-r -F")
+				case hint.Snapshot:
+					options = append(options, fmt.Sprintf("-x -a '(__%s_snapshots)'", app.Name))
+				default:
+					options = append(options, "-x")
+				}
+			}
+			fmt.Fprintf(&b, "complete -c %s -n %s %s -d %s\n", app.Name, condition, strings.Join(options, " "), fishQuote(flagUsage(flag)))
+		}
+		if node.Snapshot {
+			fmt.Fprintf(&b, "complete -c %s -n %s -k -a '(__%s_snapshots)'\n", app.Name, condition, app.Name)
+		}
+		if node.Config {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a '(%s __complete %s (commandline -ct))'\n", app.Name, condition, app.Name, strings.TrimPrefix(node.Path, app.Name+" "))
+		}
+	}
+	return b.String()
 }
 
 // formatMetrics formats disk metrics in compact format
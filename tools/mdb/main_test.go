@@ -0,0 +1,5805 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/minio/cli"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mdb/pkg/mdbcore"
+)
+
+func TestParsePercent(t *testing.T) {
+	accepted := []struct {
+		input string
+		want  float64
+	}{
+		{"10", 10},
+		{"10.5", 10.5},
+		{"10%", 10},
+		{"10.5%", 10.5},
+		{"7,5", 7.5},
+		{"7,5%", 7.5},
+		{" 15 % ", 15},
+		{"0", 0},
+		{"100", 100},
+		{"100%", 100},
+	}
+	for _, tc := range accepted {
+		got, err := parsePercent(tc.input)
+		if err != nil {
+			t.Errorf("parsePercent(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parsePercent(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	rejected := []string{
+		"",
+		"%",
+		"abc",
+		"10%%",
+		"-1",
+		"100.1",
+		"101%",
+		"1,000.5",
+		"1,2,3",
+		"NaN",
+		"Inf",
+	}
+	for _, input := range rejected {
+		if got, err := parsePercent(input); err == nil {
+			t.Errorf("parsePercent(%q) = %v, want error", input, got)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	accepted := []struct {
+		input string
+		want  int64
+	}{
+		{"200GiB", 200 << 30},
+		{"200 GiB", 200 << 30},
+		{"200gib", 200 << 30},
+		{"1.5TiB", 3 << 39},
+		{"500,000", 500000},
+		{"1,000GiB", 1000 << 30},
+		{"1,000.5GiB", 1000<<30 + 1<<29},
+		{"1.5TB", 1500000000000},
+		{"500M", 500000000},
+		{"1KiB", 1024},
+		{"4096", 4096},
+		{"4096B", 4096},
+		{" 2 TiB ", 2 << 40},
+	}
+	for _, tc := range accepted {
+		got, err := parseSize(tc.input)
+		if err != nil {
+			t.Errorf("parseSize(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseSize(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	rejected := []string{
+		"",
+		"GiB",
+		"abc",
+		"10%",
+		"-1GiB",
+		"0",
+		"0GiB",
+		"1,5TiB",
+		"500,00",
+		"1,2,3GiB",
+		"1,000,5GiB",
+		"10 GiBs",
+		"9EiB",
+	}
+	for _, input := range rejected {
+		if got, err := parseSize(input); err == nil {
+			t.Errorf("parseSize(%q) = %v, want error", input, got)
+		}
+	}
+}
+
+// testCluster builds a small two-server cluster with one faulty drive
+func testCluster() *clusterStruct {
+	disk := func(server string, idx int, state string) madmin.Disk {
+		return madmin.Disk{
+			Endpoint:       fmt.Sprintf("http://%s:9000/data/disk%d", server, idx),
+			DrivePath:      fmt.Sprintf("/data/disk%d", idx),
+			State:          state,
+			UUID:           fmt.Sprintf("%s-uuid-%d", server, idx),
+			TotalSpace:     1000,
+			UsedSpace:      600,
+			AvailableSpace: 400,
+			PoolIndex:      0,
+			SetIndex:       0,
+			DiskIndex:      idx,
+		}
+	}
+	return &clusterStruct{Snapshot: mdbcore.Snapshot{
+		Status: "success",
+		Info: madmin.InfoMessage{
+			DeploymentID: "test-deployment",
+			Backend:      madmin.ErasureBackend{StandardSCParity: 2},
+			Servers: []madmin.ServerProperties{
+				{
+					State:    "online",
+					Endpoint: "node1.example.com:9000",
+					Disks:    []madmin.Disk{disk("node1", 0, "ok"), disk("node1", 1, "ok")},
+				},
+				{
+					State:    "online",
+					Endpoint: "node2.example.com:9000",
+					Disks:    []madmin.Disk{disk("node2", 2, "ok"), disk("node2", 3, "faulty")},
+				},
+			},
+		},
+	}}
+}
+
+func TestRenderHTMLReport(t *testing.T) {
+	config := &Config{
+		JSONFile:    "cluster.json",
+		ShowSummary: true,
+		ShowServers: true,
+		ShowSets:    true,
+		ShowDisks:   true,
+		Format:      formatHTML,
+	}
+	pager := NewPager(true)
+	if err := renderReport(pager, testCluster(), config); err != nil {
+		t.Fatalf("renderReport failed: %v", err)
+	}
+
+	// The document must parse and contain the expected cards and table rows
+	decoder := xml.NewDecoder(strings.NewReader(pager.String()))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		tables     int
+		bodyRows   int
+		inBody     bool
+		badCells   int
+		headings   []string
+		inHeading  bool
+		cardValues = map[string]string{}
+		lastLabel  string
+		inLabel    bool
+		inValue    bool
+	)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("generated HTML does not parse: %v", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			class := ""
+			for _, attr := range el.Attr {
+				if attr.Name.Local == "class" {
+					class = attr.Value
+				}
+			}
+			switch el.Name.Local {
+			case "table":
+				tables++
+			case "tbody":
+				inBody = true
+			case "tr":
+				if inBody {
+					bodyRows++
+				}
+			case "td":
+				if class == "bad" {
+					badCells++
+				}
+			case "h2":
+				inHeading = true
+			case "div":
+				inLabel = class == "label"
+				inValue = strings.HasPrefix(class, "value")
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "tbody":
+				inBody = false
+			case "h2":
+				inHeading = false
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(el))
+			switch {
+			case inHeading:
+				headings = append(headings, text)
+			case inLabel:
+				lastLabel = text
+				inLabel = false
+			case inValue:
+				cardValues[lastLabel] = text
+				inValue = false
+			}
+		}
+	}
+
+	wantHeadings := []string{"Problems", "Summary", "Servers", "Erasure Sets", "Drives"}
+	if strings.Join(headings, ",") != strings.Join(wantHeadings, ",") {
+		t.Errorf("headings = %v, want %v", headings, wantHeadings)
+	}
+	if cardValues["Total Disks"] != "4" {
+		t.Errorf("Total Disks card = %q, want 4", cardValues["Total Disks"])
+	}
+	if cardValues["Problem Disks"] != "1" {
+		t.Errorf("Problem Disks card = %q, want 1", cardValues["Problem Disks"])
+	}
+	if cardValues["Servers"] != "2 of 2 online" {
+		t.Errorf("Servers card = %q, want 2 of 2 online", cardValues["Servers"])
+	}
+	if tables != 4 {
+		t.Errorf("tables = %d, want 4", tables)
+	}
+	// 1 problem + 2 servers + 1 erasure set + 4 drives
+	if bodyRows != 8 {
+		t.Errorf("table rows = %d, want 8", bodyRows)
+	}
+	// The faulty drive shows up as bad in the problem drives of its server and
+	// in its state in the drives table; one bad drive is within the set parity
+	if badCells != 2 {
+		t.Errorf("bad cells = %d, want 2", badCells)
+	}
+
+	// The cells come from the drives, not from the text output: no ANSI codes
+	// or padding, and --color never does not change the classes
+	page := pager.String()
+	if strings.Contains(page, "\033[") || !strings.Contains(page, `<td class="bad">faulty</td>`) {
+		t.Errorf("drive state cell not built from the drive:\n%s", page)
+	}
+	config.ColorMode = colorNever
+	plain := NewPager(true)
+	if err := renderReport(plain, testCluster(), config); err != nil {
+		t.Fatal(err)
+	}
+	if plain.String() != page {
+		t.Errorf("--color never changed the HTML report")
+	}
+}
+
+func TestLoadHealthHistory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.json", `{"schema":1,"deploymentId":"dep","runs":[{"grade":"B","failedDrives":1}]}`)
+	history, err := loadHealthHistory(valid, "dep")
+	if err != nil {
+		t.Fatalf("loadHealthHistory(valid) returned error: %v", err)
+	}
+	if len(history.Runs) != 1 || history.Runs[0].Grade != "B" {
+		t.Errorf("loadHealthHistory(valid) runs = %+v, want one run with grade B", history.Runs)
+	}
+
+	history, err = loadHealthHistory(filepath.Join(dir, "missing.json"), "dep")
+	if err != nil || len(history.Runs) != 0 {
+		t.Errorf("loadHealthHistory(missing) = %+v, %v, want empty history without error", history.Runs, err)
+	}
+
+	broken := map[string]string{
+		"corrupt":    write("corrupt.json", `{"schema":1,"runs":[`),
+		"schema":     write("schema.json", `{"schema":99,"deploymentId":"dep","runs":[{"grade":"A"}]}`),
+		"deployment": write("deployment.json", `{"schema":1,"deploymentId":"other","runs":[{"grade":"A"}]}`),
+	}
+	for name, path := range broken {
+		history, err := loadHealthHistory(path, "dep")
+		if err == nil {
+			t.Errorf("loadHealthHistory(%s) returned no error", name)
+		}
+		if history == nil || len(history.Runs) != 0 || history.Schema != healthHistorySchema {
+			t.Errorf("loadHealthHistory(%s) = %+v, want a fresh history", name, history)
+		}
+	}
+}
+
+func TestHealthHistoryOptIn(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join("testdata", "healthy.json")
+
+	// A plain run only reads the snapshot
+	renderGolden(t, "summary", "--no-config", "--color", "never", file)
+	if _, err := os.Stat(filepath.Join(home, ".mdb", "history")); !os.IsNotExist(err) {
+		t.Errorf("a run without --history-size writes the health history: %v", err)
+	}
+
+	renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "3", file)
+	entries, err := os.ReadDir(filepath.Join(home, ".mdb", "history"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("--history-size 3 history files = %v, %v, want one", entries, err)
+	}
+
+	// The run is keyed by the timestamp in the snapshot, not the file's modification time
+	history, err := loadHealthHistory(filepath.Join(home, ".mdb", "history", entries[0].Name()), "11111111-1111-4111-8111-111111111111")
+	if err != nil || len(history.Runs) != 1 {
+		t.Fatalf("health history = %+v, %v, want one run", history, err)
+	}
+	if want := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC); !history.Runs[0].SnapshotTime.Equal(want) {
+		t.Errorf("SnapshotTime = %v, want %v from the snapshot", history.Runs[0].SnapshotTime, want)
+	}
+}
+
+func TestFormatHealthTrend(t *testing.T) {
+	runs := []HealthRecord{{Grade: "B"}, {Grade: "B"}, {Grade: "C"}}
+	got := stripANSI(formatHealthTrend(runs))
+	if want := "B → B → C over last 3 runs ↓"; got != want {
+		t.Errorf("formatHealthTrend() = %q, want %q", got, want)
+	}
+}
+
+func TestDrivePresetColumns(t *testing.T) {
+	drive := DiskInfo{
+		Server:         "node2",
+		Path:           "/data/disk3",
+		State:          "faulty",
+		UUID:           "node2-uuid-3",
+		Model:          "SAMSUNG MZQL2",
+		DiskIndex:      3,
+		TotalSpace:     4 << 30,
+		UsedSpace:      3 << 30,
+		AvailableSpace: 1 << 30,
+		UsedInodes:     100,
+		FreeInodes:     300,
+		Metrics:        &madmin.DiskMetrics{TotalErrorsAvailability: 5, TotalErrorsTimeout: 2},
+		PoolIndex:      1,
+		SetIndex:       2,
+		UsedSpacePct:   75,
+		FreeSpacePct:   25,
+	}
+
+	tests := []struct {
+		preset string
+		want   string
+	}{
+		{"capacity", "| Pool | Erasure Set | Server | Disk Path | Total Space | Space Used | Free Space | Inodes Used |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| 1 | 2 | node2 | /data/disk3 | 4.0 GiB | 3.0 GiB (75.0%) | 1.0 GiB (25.0%) | 100 (25.0%) |\n"},
+		{"health", "| Pool | Erasure Set | Server | Disk Path | State | Healing | Errors |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| 1 | 2 | node2 | /data/disk3 | **faulty** | No | **5** (2 timeout) |\n"},
+		{"hardware", "| Server | Disk Path | Model | Type | UUID | Size |\n" +
+			"| --- | --- | --- | --- | --- | --- |\n" +
+			"| node2 | /data/disk3 | SAMSUNG MZQL2 | data | node2-uuid-3 | 4.0 GiB |\n"},
+	}
+	for _, tc := range tests {
+		columns, err := resolveDrivePreset(tc.preset, nil)
+		if err != nil {
+			t.Fatalf("resolveDrivePreset(%q) returned error: %v", tc.preset, err)
+		}
+		pager := NewPager(true)
+		printTable(pager, []DiskInfo{drive}, &Config{Format: formatMarkdown, DriveColumns: columns})
+		if got := pager.String(); got != tc.want {
+			t.Errorf("preset %q output:\n%s\nwant:\n%s", tc.preset, got, tc.want)
+		}
+	}
+
+	userPresets := map[string][]string{
+		"mine":   {"server", "path", "errors"},
+		"health": {"server"},
+		"broken": {"server", "nope"},
+	}
+	if columns, err := resolveDrivePreset("mine", userPresets); err != nil || strings.Join(columns, ",") != "server,disk_path,errors" {
+		t.Errorf("resolveDrivePreset(mine) = %v, %v", columns, err)
+	}
+	if columns, err := resolveDrivePreset("health", userPresets); err != nil || strings.Join(columns, ",") != "server" {
+		t.Errorf("user preset should override built-in health, got %v, %v", columns, err)
+	}
+	if _, err := resolveDrivePreset("broken", userPresets); err == nil || !strings.Contains(err.Error(), "unknown column 'nope'") {
+		t.Errorf("resolveDrivePreset(broken) error = %v, want unknown column", err)
+	}
+	_, err := resolveDrivePreset("nope", userPresets)
+	if err == nil || !strings.Contains(err.Error(), "available presets: broken, capacity, hardware, health, mine") {
+		t.Errorf("resolveDrivePreset(nope) error = %v, want list of available presets", err)
+	}
+}
+
+func TestDriveColumnsFlag(t *testing.T) {
+	columns, err := parseDriveColumns("server, Disk Path,STATE,uuid")
+	if err != nil || strings.Join(columns, ",") != "server,disk_path,state,uuid" {
+		t.Errorf("parseDriveColumns = %v, %v", columns, err)
+	}
+	if columns, err := parseDriveColumns("all"); err != nil || columns != nil {
+		t.Errorf("parseDriveColumns(all) = %v, %v, want default columns", columns, err)
+	}
+	if _, err := parseDriveColumns(" , "); err == nil {
+		t.Error("parseDriveColumns with no names should fail")
+	}
+	if _, err := parseDriveColumns("server,bogus"); err == nil || !strings.Contains(err.Error(), "valid columns: pool, erasure_set") {
+		t.Errorf("parseDriveColumns(bogus) error = %v, want list of valid columns", err)
+	}
+
+	out := renderGolden(t, "show", "disks", "testdata/failed-drives.json", "--format", "markdown", "--columns", "server,path,state")
+	if !strings.Contains(out, "| Server | Disk Path | State |\n") || strings.Contains(out, "| UUID |") {
+		t.Errorf("--columns markdown output should only have the chosen columns:\n%s", out)
+	}
+
+	_, err = runShow(t, "show", "disks", "testdata/failed-drives.json", "--columns", "server", "--preset", "health")
+	if err == nil || !strings.Contains(err.Error(), "--columns and --preset cannot be used together") {
+		t.Errorf("--columns with --preset error = %v", err)
+	}
+}
+
+func TestDriveColumnRegistry(t *testing.T) {
+	seen := make(map[string]string)
+	for _, column := range driveColumns {
+		if column.ID != normalizeColumnName(column.ID) {
+			t.Errorf("column id %q is not in canonical snake_case", column.ID)
+		}
+		if column.Header == "" || column.Value == nil {
+			t.Errorf("column %q is missing a header or value function", column.ID)
+		}
+		for _, name := range append([]string{column.ID}, column.Aliases...) {
+			if owner, dup := seen[name]; dup {
+				t.Errorf("name %q is used by both %q and %q", name, owner, column.ID)
+			}
+			seen[name] = column.ID
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"erasure_set", "erasure_set"},
+		{"disk_index", "disk_index"},
+		{"used_pct", "used_pct"},
+		{"set", "erasure_set"},
+		{"index", "disk_index"},
+		{"path", "disk_path"},
+		{"total", "total_space"},
+		{"used", "used_space"},
+		{"free", "free_space"},
+		{"inodes", "inodes_used"},
+		{"Erasure Set", "erasure_set"},
+		{"Disk-Index", "disk_index"},
+		{" FREE_PCT ", "free_pct"},
+	}
+	for _, tc := range tests {
+		column, err := lookupDriveColumn(tc.name)
+		if err != nil {
+			t.Errorf("lookupDriveColumn(%q) returned error: %v", tc.name, err)
+			continue
+		}
+		if column.ID != tc.want {
+			t.Errorf("lookupDriveColumn(%q) = %q, want %q", tc.name, column.ID, tc.want)
+		}
+	}
+
+	for _, column := range driveColumns {
+		if got, err := lookupDriveColumn(column.Header); column.ID == normalizeColumnName(column.Header) && (err != nil || got.ID != column.ID) {
+			t.Errorf("lookupDriveColumn(%q) = %q, %v; want %q", column.Header, got.ID, err, column.ID)
+		}
+	}
+
+	_, err := lookupDriveColumn("erasure set index")
+	if err == nil {
+		t.Fatal("lookupDriveColumn accepted an unknown column")
+	}
+	want := "unknown column 'erasure set index' (valid columns: " + strings.Join(driveColumnIDs(), ", ") + ")"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+	for _, alias := range []string{"set", "index", "path"} {
+		if strings.Contains(err.Error(), " "+alias+",") {
+			t.Errorf("error lists alias %q instead of canonical identifiers: %v", alias, err)
+		}
+	}
+
+	ids, err := resolveDriveColumns([]string{"set", "Disk Path", "used_pct"})
+	if err != nil || strings.Join(ids, ",") != "erasure_set,disk_path,used_pct" {
+		t.Errorf("resolveDriveColumns = %v, %v", ids, err)
+	}
+	if _, err := resolveDriveColumns([]string{"server", "bogus"}); err == nil || !strings.Contains(err.Error(), "unknown column 'bogus'") {
+		t.Errorf("resolveDriveColumns(bogus) error = %v", err)
+	}
+	for _, name := range defaultDriveColumns {
+		if column, err := lookupDriveColumn(name); err != nil || column.ID != name {
+			t.Errorf("default column %q is not a canonical identifier", name)
+		}
+	}
+	for preset, columns := range drivePresets {
+		for _, name := range columns {
+			if column, err := lookupDriveColumn(name); err != nil || column.ID != name {
+				t.Errorf("preset %q column %q is not a canonical identifier", preset, name)
+			}
+		}
+	}
+}
+
+func TestInodePressure(t *testing.T) {
+	drives := map[string][]DiskInfo{
+		"0-0": {
+			{Path: "/data/disk1", UsedInodes: 90, FreeInodes: 10},
+			{Path: "/data/disk2", UsedInodes: 97, FreeInodes: 3},
+			{Path: "/data/disk3", UsedInodes: 10, FreeInodes: 90},
+			{Path: "/data/disk4"},
+		},
+	}
+
+	if _, ok := inodeUsagePct(drives["0-0"][3]); ok {
+		t.Error("drive without inodes should have no inode usage")
+	}
+	if pct, ok := inodeUsagePct(drives["0-0"][2]); !ok || pct != 10 {
+		t.Errorf("inodeUsagePct = %v, %v; want 10, true", pct, ok)
+	}
+
+	pager := NewPager(true)
+	printInodePressure(pager, drives, &Config{})
+	if got, want := pager.String(), "  Inode pressure: "+Red+"2 drives above 80%"+Reset+"\n"; got != want {
+		t.Errorf("printInodePressure = %q, want %q", got, want)
+	}
+
+	pager = NewPager(true)
+	printInodePressure(pager, map[string][]DiskInfo{"0-0": {{Path: "/data/disk4"}}}, &Config{})
+	if got := pager.String(); got != "" {
+		t.Errorf("printInodePressure without inode data = %q, want nothing", got)
+	}
+
+	pager = NewPager(true)
+	printHighInodeDrives(pager, drives, 50, &Config{Format: formatMarkdown, DriveColumns: []string{"disk_path", "inodes_used"}})
+	want := "## Drives with Inode Usage > 50.0% (sorted by inode usage)\n\n" +
+		"================================================================================\n" +
+		"| Disk Path | Inodes Used |\n" +
+		"| --- | --- |\n" +
+		"| /data/disk2 | 97 (**97.0%**) |\n" +
+		"| /data/disk1 | 90 (**90.0%**) |\n\n"
+	if got := pager.String(); got != want {
+		t.Errorf("printHighInodeDrives output:\n%s\nwant:\n%s", got, want)
+	}
+
+	args := expandOptionalFlags([]string{"mdb", "show", "disks", "--inodes", "x.json", "--", "--inodes"}, &flagDefaults{})
+	if got := strings.Join(args, " "); got != "mdb show disks --inodes=80 x.json -- --inodes" {
+		t.Errorf("expandOptionalFlags = %q", got)
+	}
+}
+
+func TestErrorDrives(t *testing.T) {
+	drives := map[string][]DiskInfo{
+		"0-0": {
+			{Server: "node1", Path: "/data/disk1", DiskIndex: 0, Metrics: &madmin.DiskMetrics{TotalErrorsAvailability: 3, TotalErrorsTimeout: 1}},
+			{Server: "node1", Path: "/data/disk2", DiskIndex: 1, Metrics: &madmin.DiskMetrics{}},
+			{Server: "node2", Path: "/data/disk3", DiskIndex: 2},
+		},
+		"0-1": {
+			{Server: "node2", Path: "/data/disk1", SetIndex: 1, Metrics: &madmin.DiskMetrics{TotalErrorsAvailability: 12}},
+		},
+	}
+
+	pager := NewPager(true)
+	printIOErrorSummary(pager, drives)
+	if got, want := pager.String(), "  Drives with I/O errors: "+Red+"2"+Reset+"\n"; got != want {
+		t.Errorf("printIOErrorSummary = %q, want %q", got, want)
+	}
+	pager = NewPager(true)
+	printIOErrorSummary(pager, map[string][]DiskInfo{"0-0": drives["0-0"][1:]})
+	if got := pager.String(); got != "" {
+		t.Errorf("printIOErrorSummary without errors = %q, want nothing", got)
+	}
+
+	pager = NewPager(true)
+	printErrorDrives(pager, drives, 1, &Config{Format: formatMarkdown})
+	want := "## Drives with I/O Errors (recent first, sorted by error count)\n\n" +
+		"================================================================================\n" +
+		"| Server | Disk Path | Pool | Erasure Set | Availability Errors | Timeout Errors | Last Error |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| node2 | /data/disk1 | 0 | 1 | **12** | 0 | unknown |\n" +
+		"| node1 | /data/disk1 | 0 | 0 | **3** | **1** | unknown |\n\n"
+	if got := pager.String(); got != want {
+		t.Errorf("printErrorDrives output:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A drive failing now comes before a larger historical counter, which is yellow
+	stale, recent := 2*time.Hour, 5*time.Minute
+	drives["0-1"][0].LastErrorAge = &stale
+	drives["0-0"][0].LastTimeoutAge = &recent
+	pager = NewPager(true)
+	printErrorDrives(pager, drives, 1, &Config{})
+	got := pager.String()
+	first, second := strings.Index(stripANSI(got), "node1   /data/disk1"), strings.Index(stripANSI(got), "node2   /data/disk1")
+	if first < 0 || second < first || !strings.Contains(got, Yellow+"12"+Reset) || !strings.Contains(got, Red+"3"+Reset) {
+		t.Errorf("printErrorDrives with a recent and a stale drive:\n%s", got)
+	}
+
+	pager = NewPager(true)
+	printErrorDrives(pager, drives, 20, &Config{Format: formatMarkdown})
+	if got := pager.String(); !strings.Contains(got, "No drives found with at least 20 I/O errors.") {
+		t.Errorf("printErrorDrives above every count = %q", got)
+	}
+
+	args := expandOptionalFlags([]string{"mdb", "show", "disks", "--errors", "x.json"}, &flagDefaults{})
+	if got := strings.Join(args, " "); got != "mdb show disks --errors=1 x.json" {
+		t.Errorf("expandOptionalFlags = %q", got)
+	}
+}
+
+func TestFindUnknownFields(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	data := []byte(`{"status":"success","collectedBy":"subnet","info":{"mode":"online","newCounter":42,` +
+		`"servers":[{"Endpoint":"node1:9000","state":"online","zone":"a","a/b":{"c": [1, 2]}},` +
+		`{"endpoint":"node2:9000","zone":"b","note":"` + long + `"}]}}`)
+
+	want := []unknownField{
+		{"/collectedBy", "string", `"subnet"`},
+		{"/info/newCounter", "number", "42"},
+		{"/info/servers/0/a~1b", "object", `{"c":[1,2]}`},
+		{"/info/servers/0/zone", "string", `"a"`},
+		{"/info/servers/1/note", "string", `"` + strings.Repeat("x", 76) + "..."},
+	}
+	got := findUnknownFields(data)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("findUnknownFields =\n%v\nwant\n%v", got, want)
+	}
+	if len(got[4].Example) != unknownExampleLength {
+		t.Errorf("example length = %d, want %d", len(got[4].Example), unknownExampleLength)
+	}
+
+	wrapped := []byte(`{"minio":{"status":"success","info":{"servers":[{"endpoint":"node1:9000","extra":null}]}},"sys":{}}`)
+	want = []unknownField{
+		{"/minio/info/servers/0/extra", "null", "null"},
+		{"/sys", "object", "{}"},
+	}
+	if got := findUnknownFields(wrapped); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("findUnknownFields(wrapped) =\n%v\nwant\n%v", got, want)
+	}
+
+	if got := findUnknownFields([]byte("not json")); len(got) != 0 {
+		t.Errorf("findUnknownFields(invalid) = %v, want none", got)
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "cluster.json")
+	if err := os.WriteFile(snapshot, []byte(`{"status":"success"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		JSONFile:    snapshot,
+		ShowSummary: true,
+		ShowSets:    true,
+		Format:      formatMarkdown,
+		Command:     "show",
+		Flags:       []string{"--bundle=case-1234.tar.gz"},
+	}
+
+	path := filepath.Join(dir, "case-1234.tar.gz")
+	n, err := writeBundle(path, testCluster(), config)
+	if err != nil {
+		t.Fatalf("writeBundle failed: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("writeBundle wrote %d files, want 5", n)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := map[string][]byte{}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members[header.Name] = data
+		names = append(names, header.Name)
+	}
+	if got := strings.Join(names, ","); got != "snapshot/cluster.json,report.txt,report.html,report.json,manifest.json" {
+		t.Fatalf("bundle members = %s", got)
+	}
+
+	var manifest bundleManifest
+	if err := json.Unmarshal(members["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest does not parse: %v", err)
+	}
+	if manifest.Schema != bundleSchema || manifest.Command != "show" || strings.Join(manifest.Flags, " ") != "--bundle=case-1234.tar.gz" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(manifest.Members) != 4 {
+		t.Fatalf("manifest lists %d members, want 4", len(manifest.Members))
+	}
+	for _, member := range manifest.Members {
+		sum := sha256.Sum256(members[member.Name])
+		if member.SHA256 != hex.EncodeToString(sum[:]) || member.Size != len(members[member.Name]) {
+			t.Errorf("checksum mismatch for %s", member.Name)
+		}
+	}
+
+	if string(members["snapshot/cluster.json"]) != `{"status":"success"}` {
+		t.Errorf("snapshot not copied verbatim: %q", members["snapshot/cluster.json"])
+	}
+	if strings.Contains(string(members["report.txt"]), "\033[") {
+		t.Error("text report contains color codes")
+	}
+	if !strings.HasPrefix(string(members["report.html"]), "<!DOCTYPE html>") {
+		t.Errorf("report.html is not an HTML document")
+	}
+	if !json.Valid(members["report.json"]) {
+		t.Error("report.json is not valid JSON")
+	}
+}
+
+func TestSnapshotTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	stamped := filepath.Join(dir, "stamped.json")
+	if err := os.WriteFile(stamped, []byte(`{"timestamp":"2024-05-02T13:45:00Z","minio":{"info":{"servers":[{"endpoint":"node1:9000"}]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	infoStruct, err := loadJSON(stamped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 5, 2, 13, 45, 0, 0, time.UTC); !infoStruct.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v from the file", infoStruct.Timestamp, want)
+	}
+
+	plain := filepath.Join(dir, "plain.json")
+	if err := os.WriteFile(plain, []byte(`{"info":{"servers":[{"endpoint":"node1:9000"}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(plain, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if infoStruct, err = loadJSON(plain); err != nil {
+		t.Fatal(err)
+	}
+	if !infoStruct.Timestamp.Equal(mtime) {
+		t.Errorf("Timestamp = %v, want modification time %v", infoStruct.Timestamp, mtime)
+	}
+
+	// Only the last record of an NDJSON file was written at the modification
+	// time, the others were taken at an unknown time
+	t.Setenv("HOME", dir)
+	series := filepath.Join(dir, "series.ndjson")
+	line := `{"info":{"servers":[{"endpoint":"node1:9000","state":"online"}]}}` + "\n"
+	if err := os.WriteFile(series, []byte(line+line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(series, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	for record, want := range map[string]string{"1": "Snapshot taken: unknown", "2": "Snapshot taken: 2024-04-01 08:00 UTC"} {
+		if got := stripANSI(renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--record", record, series)); !strings.Contains(got, want) {
+			t.Errorf("--record %s misses %q:\n%s", record, want, got)
+		}
+	}
+
+	taken := time.Date(2024, 5, 2, 13, 45, 0, 0, time.UTC)
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{2 * time.Hour, "2024-05-02 13:45 UTC (2 hours 0 minutes 0 seconds ago)"},
+		{3 * 24 * time.Hour, Yellow + "2024-05-02 13:45 UTC (3 days 0 hours 0 minutes 0 seconds ago)" + Reset},
+		{8 * 24 * time.Hour, Red + "2024-05-02 13:45 UTC (8 days 0 hours 0 minutes 0 seconds ago)" + Reset},
+		{-time.Hour, "2024-05-02 13:45 UTC (0 seconds ago)"},
+	}
+	for _, tc := range tests {
+		if got := formatSnapshotTaken(taken, taken.Add(tc.age)); got != tc.want {
+			t.Errorf("formatSnapshotTaken(age %v) = %q, want %q", tc.age, got, tc.want)
+		}
+	}
+	if got := formatSnapshotTaken(time.Time{}, taken); got != "unknown" {
+		t.Errorf("formatSnapshotTaken(zero) = %q, want unknown", got)
+	}
+
+	for value, want := range map[string]time.Duration{"36h": 36 * time.Hour, "7d": 7 * 24 * time.Hour, "1.5d": 36 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := parseMaxAge(value); err != nil || got != want {
+			t.Errorf("parseMaxAge(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "7", "d", "-1h", "0d", "week"} {
+		if _, err := parseMaxAge(value); err == nil {
+			t.Errorf("parseMaxAge(%q) should fail", value)
+		}
+	}
+}
+
+func TestNDJSONRecords(t *testing.T) {
+	record := func(ts string, state string, used int) string {
+		return `{"timestamp":"` + ts + `","info":{"deploymentID":"d1","servers":[{"endpoint":"node1:9000","state":"online","drives":[` +
+			`{"endpoint":"/data/disk1","state":"` + state + `","totalspace":100,"usedspace":` + fmt.Sprint(used) + `,"pool_index":0,"set_index":0}]}]}}`
+	}
+	path := filepath.Join(t.TempDir(), "series.ndjson")
+	lines := []string{
+		record("2024-05-02T10:00:00Z", "ok", 10),
+		`{"truncated":`,
+		record("2024-05-02T10:10:00Z", "faulty", 20),
+		`{"status":"success"}`,
+		record("2024-05-02T10:20:00Z", "ok", 40),
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, skipped, err := loadNDJSONRecords(path)
+	if err != nil {
+		t.Fatalf("loadNDJSONRecords failed: %v", err)
+	}
+	if len(records) != 3 || skipped != 2 {
+		t.Fatalf("loadNDJSONRecords = %d records, %d skipped; want 3, 2", len(records), skipped)
+	}
+
+	tests := []struct {
+		latest bool
+		at     string
+		want   string
+	}{
+		{false, "", "2024-05-02T10:00:00Z"},
+		{true, "", "2024-05-02T10:20:00Z"},
+		{false, "2024-05-02T10:15:00Z", "2024-05-02T10:10:00Z"},
+		{false, "2024-05-02T10:10:00Z", "2024-05-02T10:10:00Z"},
+		{false, "2024-05-03", "2024-05-02T10:20:00Z"},
+	}
+	for _, tc := range tests {
+		var at time.Time
+		if tc.at != "" {
+			if at, err = parseRecordTime(tc.at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		selected, err := selectRecord(records, 0, tc.latest, at)
+		if err != nil {
+			t.Errorf("selectRecord(latest=%v, at=%q) returned error: %v", tc.latest, tc.at, err)
+			continue
+		}
+		if got := selected.Timestamp.UTC().Format(time.RFC3339); got != tc.want {
+			t.Errorf("selectRecord(latest=%v, at=%q) = %s, want %s", tc.latest, tc.at, got, tc.want)
+		}
+	}
+	early, _ := parseRecordTime("2024-05-02 09:00")
+	if _, err := selectRecord(records, 0, false, early); err == nil || !strings.Contains(err.Error(), "no record taken at or before 2024-05-02T09:00:00Z") {
+		t.Errorf("selectRecord before the first record error = %v", err)
+	}
+	for index, want := range map[int]string{1: "2024-05-02T10:00:00Z", 2: "2024-05-02T10:10:00Z", 3: "2024-05-02T10:20:00Z"} {
+		selected, err := selectRecord(records, index, false, time.Time{})
+		if err != nil || selected.Timestamp.UTC().Format(time.RFC3339) != want || selected.RecordIndex != index {
+			t.Errorf("selectRecord(record %d) = %v, %v; want %s", index, selected, err, want)
+		}
+	}
+	if _, err := selectRecord(records, 4, false, time.Time{}); err == nil || err.Error() != "record 4 is out of range, the file has 3 records" {
+		t.Errorf("selectRecord(record 4) error = %v", err)
+	}
+	if got := formatRecordPosition(records[1]); got != "2 of 3 (2024-05-02 10:10 UTC)" {
+		t.Errorf("formatRecordPosition = %q", got)
+	}
+
+	// Without --record the first record is analyzed, like --record=first
+	first, err := loadJSON(path)
+	if err != nil || first.RecordIndex != 1 || first.RecordCount != 3 {
+		t.Errorf("loadJSON(ndjson) = record %v of %v, %v; want 1 of 3", first.RecordIndex, first.RecordCount, err)
+	}
+
+	if _, err := parseRecordTime("yesterday"); err == nil {
+		t.Error("parseRecordTime accepted an invalid timestamp")
+	}
+
+	selected, err := loadRecord(&Config{JSONFile: path, Trend: true, Latest: true})
+	if err != nil {
+		t.Fatalf("loadRecord failed: %v", err)
+	}
+	if len(selected.Trend) != 3 {
+		t.Fatalf("trend has %d points, want 3", len(selected.Trend))
+	}
+	if bad := selected.Trend[1].Stats.BadDisks; bad != 1 {
+		t.Errorf("second record bad disks = %d, want 1", bad)
+	}
+
+	pager := NewPager(true)
+	printTrend(pager, selected.Trend, &Config{Format: formatMarkdown})
+	want := "## Trend (3 records, 2024-05-02 10:00 → 2024-05-02 10:20 UTC)\n\n" +
+		"| Metric | First | Last | Change | Trend |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| Used Space | 10 B | 40 B | **+30 B** | ▁▃█ |\n"
+	if got := pager.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("printTrend output:\n%s\nwant prefix:\n%s", got, want)
+	}
+	if got := pager.String(); !strings.Contains(got, "| Bad Disks | 0 | 0 | +0 | ▁█▁ |") {
+		t.Errorf("printTrend output misses bad disks row:\n%s", got)
+	}
+
+	// A pretty-printed single document is one record, which has no trend
+	single := filepath.Join(t.TempDir(), "single.json")
+	var doc bytes.Buffer
+	if err := json.Indent(&doc, []byte(record("2024-05-02T10:00:00Z", "ok", 10)), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(single, doc.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	selected, err = loadRecord(&Config{JSONFile: single, Latest: true})
+	if err != nil || selected.Info.DeploymentID != "d1" || selected.RecordCount != 0 {
+		t.Errorf("loadRecord(single document, --latest) = %+v, %v", selected, err)
+	}
+	at, _ := parseRecordTime("2024-05-02 09:00")
+	if _, err := loadRecord(&Config{JSONFile: single, At: at}); err == nil || !strings.Contains(err.Error(), "no record taken at or before") {
+		t.Errorf("loadRecord(single document, --at before it) error = %v", err)
+	}
+	if _, err := loadRecord(&Config{JSONFile: single, Trend: true}); err == nil || !strings.Contains(err.Error(), "is a single snapshot") {
+		t.Errorf("loadRecord(single document, --trend) error = %v", err)
+	}
+
+	if got := sparkline([]float64{1, 1, 1}); got != "▁▁▁" {
+		t.Errorf("sparkline of constant values = %q", got)
+	}
+	if got := []rune(sparkline(make([]float64, 100))); len(got) != sparklineWidth {
+		t.Errorf("sparkline width = %d, want %d", len(got), sparklineWidth)
+	}
+}
+
+func TestDisplayFiles(t *testing.T) {
+	dir := t.TempDir()
+	siteA := filepath.Join(dir, "siteA.json")
+	broken := filepath.Join(dir, "broken.json")
+	siteB := filepath.Join(dir, "siteB.json")
+	site := func(id, state string) string {
+		return `{"info":{"deploymentID":"` + id + `","servers":[{"endpoint":"node1:9000","state":"online","version":"` + id + `-2024-05-10T01:41:38Z","drives":[` +
+			`{"endpoint":"/data/disk1","state":"ok","totalspace":100,"usedspace":50,"pool_index":0,"set_index":0},` +
+			`{"endpoint":"/data/disk2","state":"` + state + `","totalspace":100,"usedspace":50,"pool_index":0,"set_index":0}]}]}}`
+	}
+	for path, content := range map[string]string{siteA: site("dep-a", "ok"), broken: `{"info":`, siteB: site("dep-b", "faulty")} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	pager := NewPager(false)
+	pager.stdout = &out
+	config := &Config{JSONFiles: []string{siteA, broken, siteB}, ShowSummary: true, Format: formatMarkdown}
+	err := displayFiles(pager, config)
+	if err == nil || err.Error() != "failed to load 1 of 3 files: "+broken {
+		t.Errorf("displayFiles error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"## " + siteA + " (deployment dep-a)",
+		"**Failed to load '" + broken + "': ",
+		"## " + siteB + " (deployment dep-b)",
+		"| File | Deployment ID | Drives | Bad Drives | Used % | Health % |",
+		"| " + siteA + " | dep-a | 2 | 0 | N/A | 100.0% |",
+		"| " + broken + " | **load failed** | - | - | - | - |",
+		"| " + siteB + " | dep-b | 2 | **1** | N/A | **50.0%** |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "dep-a)") > strings.Index(got, "dep-b)") || strings.Index(got, "dep-b)") > strings.Index(got, "## Comparison") {
+		t.Errorf("files are not shown in order before the comparison:\n%s", got)
+	}
+
+	// --compare shows only one column per cluster
+	out.Reset()
+	config.Compare = true
+	if err := displayFiles(pager, config); err == nil {
+		t.Error("displayFiles --compare should report the broken file")
+	}
+	want := "## Cluster Comparison (2 clusters)\n\n" +
+		"| Metric | siteA.json (dep-a) | siteB.json (dep-b) |\n" +
+		"| --- | --- | --- |\n" +
+		"| Servers | 1 | 1 |\n" +
+		"| Drives | 2 | 2 |\n" +
+		"| Bad Drives | 0 | **1** |\n" +
+		"| Raw Capacity | 200 B | 200 B |\n" +
+		"| Usable Capacity | 0 B | 0 B |\n" +
+		"| Used % | N/A | N/A |\n" +
+		"| Health % | 100.0% | **50.0% (worst)** |\n" +
+		"| MinIO Version | RELEASE.2024-05-10T01-41-38Z | RELEASE.2024-05-10T01-41-38Z |\n\n" +
+		"**Worst health: " + siteB + " (50.0%)**\n\n"
+	if got := out.String(); !strings.HasSuffix(got, want) || strings.Contains(got, "Detected Erasure Coding") {
+		t.Errorf("displayFiles --compare output:\n%s\nwant suffix:\n%s", got, want)
+	}
+
+	servers := []madmin.ServerProperties{{Version: "2024-05-10T01:41:38Z"}, {Version: "RELEASE.2024-06-01T01-41-38Z"}, {Version: "DEVELOPMENT.GOGET"}}
+	if got := clusterVersion(servers); got != "RELEASE.2024-05-10T01-41-38Z (mixed, 2 releases)" {
+		t.Errorf("clusterVersion = %q", got)
+	}
+	if got := clusterVersion(servers[2:]); got != "unknown" {
+		t.Errorf("clusterVersion(dev build) = %q, want unknown", got)
+	}
+}
+
+func TestAnonymizer(t *testing.T) {
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers
+	// node10 is in pool 0 and node2 in pool 1, so node10 comes first despite its name
+	servers[0].Endpoint = "node10.example.com:9000"
+	servers[1].Disks[0].PoolIndex, servers[1].Disks[1].PoolIndex = 1, 1
+	servers[0].Network = map[string]string{"node10.example.com:9000": "online", "node2.example.com:9000": "online"}
+	infoStruct.Info.Domain = []string{"example.com"}
+	servers[1].License = &madmin.LicenseInfo{ID: "lic-1", Organization: "Acme", Plan: "ENTERPRISE", APIKey: "secret"}
+
+	anon := newAnonymizer()
+	anon.apply("cluster.json", infoStruct)
+
+	if got := servers[0].Endpoint + " " + servers[1].Endpoint; got != "server-01:9000 server-02:9000" {
+		t.Errorf("server endpoints = %s", got)
+	}
+	if got := servers[0].Disks[1].Endpoint; got != "http://server-03:9000/data/disk1" {
+		t.Errorf("drive endpoint = %s, want host replaced and path kept", got)
+	}
+	if got := servers[0].Network["server-02:9000"]; got != "online" || len(servers[0].Network) != 2 {
+		t.Errorf("network = %v", servers[0].Network)
+	}
+	uuid := servers[1].Disks[1].UUID
+	if !strings.HasPrefix(uuid, "uuid-") || len(uuid) != 17 || anon.uuid("node2-uuid-3") != uuid {
+		t.Errorf("drive UUID = %s", uuid)
+	}
+	if infoStruct.Info.DeploymentID != "" || infoStruct.Info.Domain != nil {
+		t.Errorf("deployment ID %q and domain %v not dropped", infoStruct.Info.DeploymentID, infoStruct.Info.Domain)
+	}
+	if license := servers[1].License; license.ID != "" || license.Organization != "" || license.APIKey != "" || license.Plan != "ENTERPRISE" {
+		t.Errorf("license = %+v, want the plan only", license)
+	}
+
+	// Peers that are not servers of the snapshot are named in natural order
+	// of their hosts, not in the order of the network map
+	for i := 0; i < 20; i++ {
+		peers := testCluster()
+		peers.Info.Servers[0].Network = map[string]string{"peer10:9000": "offline", "peer2:9000": "offline", "peer1:9000": "offline"}
+		anon := newAnonymizer()
+		anon.apply("peers.json", peers)
+		if got := anon.servers["peer1"] + " " + anon.servers["peer2"] + " " + anon.servers["peer10"]; got != "server-05 server-06 server-07" {
+			t.Fatalf("peer names = %s, want server-05 server-06 server-07", got)
+		}
+	}
+
+	// The same host keeps its name in a second file
+	other := testCluster()
+	other.Info.Servers[0].Endpoint = "node10.example.com:9000"
+	anon.apply("other.json", other)
+	if got := other.Info.Servers[0].Endpoint; got != "server-01:9000" {
+		t.Errorf("second file endpoint = %s, want server-01:9000", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "map.json")
+	if err := anon.writeMap(path); err != nil {
+		t.Fatal(err)
+	}
+	var mapping struct {
+		Servers     map[string]string `json:"servers"`
+		UUIDs       map[string]string `json:"uuids"`
+		Deployments map[string]string `json:"deployments"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		t.Fatalf("map does not parse: %v", err)
+	}
+	if mapping.Servers["server-01"] != "node10.example.com" || mapping.UUIDs[uuid] != "node2-uuid-3" || mapping.Deployments["other.json"] != "test-deployment" {
+		t.Errorf("map = %+v", mapping)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("map file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid := `{"minio":{"info":{"servers":[` +
+		`{"endpoint":"node1:9000","state":"online","uptime":60,"drives":[` +
+		`{"path":"/data1","uuid":"uuid-1","state":"ok","totalspace":100,"pool_index":0,"set_index":0},` +
+		`{"path":"/data2","uuid":"uuid-2","state":"ok","totalspace":0,"pool_index":0,"set_index":2}]},` +
+		`{"endpoint":"node2:9000","state":"offline"}]}}}`
+
+	tests := []struct {
+		name    string
+		content string
+		fail    bool
+		want    []string
+	}{
+		{
+			name:    "wrapped.json",
+			content: valid,
+			want: []string{
+				`OK    Format: "minio" wrapper (SUBNET diagnostics)`,
+				"OK    Found 2 servers and 2 drives",
+				"WARN  1 server(s) list no drives: node2:9000 (offline)",
+				"WARN  Backend info missing (no field \"backend\")",
+				`WARN  Field "disk_index" is absent from 2 of 2 drives`,
+				"WARN  Pool 0 has no drives in erasure set 1",
+				"WARN  1 drive(s) in state ok report no total space",
+				"OK    Drive UUIDs are unique",
+				"File is usable (5 warnings)",
+			},
+		},
+		{
+			name:    "uuids.json",
+			content: strings.Replace(strings.Replace(valid, "uuid-2", "uuid-1", 1), `"uuid":"uuid-1",`, "", 1),
+			want: []string{
+				"WARN  1 drive(s) of online servers have no UUID",
+				"File is usable (6 warnings)",
+			},
+		},
+		{
+			name:    "duplicates.json",
+			content: strings.Replace(valid, "uuid-2", "uuid-1", 1),
+			want: []string{
+				"WARN  UUID uuid-1 is shared by 2 drives: node1:/data1 (pool 0, set 0), node1:/data2 (pool 0, set 2)",
+				"File is usable (6 warnings)",
+			},
+		},
+		{
+			name: "endpoints.json",
+			content: strings.Replace(strings.Replace(valid, `{"path":"/data1",`, `{"endpoint":"http://NODE1:9000/data1","path":"/data1",`, 1),
+				`{"path":"/data2",`, `{"endpoint":"http://node7.alias:9000/data2","path":"/data2",`, 1),
+			want: []string{
+				"WARN  Drive http://node7.alias:9000/data2 (pool 0, set 2) is listed under server node1:9000 on another host",
+				"File is usable (6 warnings)",
+			},
+		},
+		{
+			name:    "fs.json",
+			content: strings.Replace(valid, `{"info":{`, `{"info":{"backend":{"backendType":"FS"},`, 1),
+			want: []string{
+				"WARN  Backend type FS is not erasure coded, erasure sets and usable capacity do not apply",
+				"File is usable (5 warnings)",
+			},
+		},
+		{
+			name:    "truncated.json",
+			content: valid[:50],
+			fail:    true,
+			want:    []string{"FAIL  The file is truncated at byte 50"},
+		},
+		{
+			name:    "text.json",
+			content: "●  node1:9000\n   Uptime: 2 days\n   Drives: 4/4 OK\n",
+			fail:    true,
+			want:    []string{"FAIL  Looks like `mc admin info` without --json"},
+		},
+		{
+			name:    "invalid.json",
+			content: "{\n\"info\": {,}}",
+			fail:    true,
+			want:    []string{"FAIL  Invalid JSON at byte 12", "FAIL  First syntax error at byte 12 (line 2, column 10)"},
+		},
+		{
+			name:    "empty.json",
+			content: " \n",
+			fail:    true,
+			want:    []string{"FAIL  The file is empty"},
+		},
+		{
+			name:    "error.json",
+			content: `{"status":"error","error":"access denied","info":{}}`,
+			fail:    true,
+			want:    []string{"FAIL  The collection reported an error: access denied", "FAIL  No servers found"},
+		},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		path := write(tt.name, tt.content)
+		err := validateFile(&out, path, false)
+		if (err != nil) != tt.fail {
+			t.Errorf("%s: validateFile error = %v, want failure %v", tt.name, err, tt.fail)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: output misses %q:\n%s", tt.name, want, out.String())
+			}
+		}
+	}
+}
+
+func TestLoadJSONDiagnosis(t *testing.T) {
+	dir := t.TempDir()
+	long := `{"info":{"servers":[` + strings.Repeat(`{"endpoint":"node:9000"},`, 20)
+	tests := []struct {
+		name    string
+		content string
+		guess   string
+		syntax  string
+		formats string
+	}{
+		{"empty", "", "the file is empty", "", "plain,minio wrapper,NDJSON"},
+		{"gzip", "\x1f\x8b\x08\x00\x00\x00", "gzip-compressed", "byte 1 (line 1, column 1): invalid character", "plain,minio wrapper,NDJSON"},
+		{"html", "<!DOCTYPE html>\n<html><body>502 Bad Gateway</body></html>", "HTML page", "byte 1 (line 1, column 1)", "plain,minio wrapper,NDJSON"},
+		{"prometheus", "# HELP minio_node_drive_free_bytes Drive free\n# TYPE minio_node_drive_free_bytes gauge\n", "Prometheus metrics", "byte 1 (line 1, column 1)", "plain,minio wrapper,NDJSON"},
+		{"text", "●  node1:9000\n   Uptime: 2 days\n   Version: 2024-05-10T01:41:38Z\n   Drives: 4/4 OK\n", "without --json", "byte 3 (line 1, column 3)", "plain,minio wrapper,NDJSON"},
+		{"truncated", long, "truncated at byte 520", "byte 520 (end of file): unexpected EOF", "plain,minio wrapper,NDJSON"},
+		{"syntax", "{\n  \"info\": {\n    \"servers\": [}\n}", "invalid JSON at byte 31", "byte 31 (line 3, column 17): invalid character '}'", "plain,minio wrapper,NDJSON"},
+		{"ndjson", `{"info":{"servers":[]}}` + "\n" + `{"info":{"servers":[{"endpoint":"n`, "truncated at byte 58", "byte 58 (end of file)", "plain,minio wrapper,NDJSON"},
+		{"wrapper type", `{"info":{},"minio":"oops"}`, "valid JSON but without servers", "", "minio wrapper"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".json")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadJSON(path)
+		var loadErr *loadError
+		if !errors.As(err, &loadErr) {
+			t.Errorf("%s: loadJSON error = %v, want a loadError", tt.name, err)
+			continue
+		}
+		if !strings.Contains(loadErr.Guess, tt.guess) {
+			t.Errorf("%s: guess = %q, want %q", tt.name, loadErr.Guess, tt.guess)
+		}
+		if (tt.syntax == "") != (loadErr.Syntax == "") || !strings.Contains(loadErr.Syntax, tt.syntax) {
+			t.Errorf("%s: syntax = %q, want %q", tt.name, loadErr.Syntax, tt.syntax)
+		}
+		var formats []string
+		for _, attempt := range loadErr.Attempts {
+			formats = append(formats, attempt.Format)
+		}
+		if got := strings.Join(formats, ","); got != tt.formats {
+			t.Errorf("%s: attempted formats = %s, want %s", tt.name, got, tt.formats)
+		}
+		if want := tt.content[:min(len(tt.content), loadErrorHeadLength)]; loadErr.Head != strings.ToValidUTF8(want, "?") {
+			t.Errorf("%s: head = %q, want %q", tt.name, loadErr.Head, want)
+		}
+		if lines := strings.Count(err.Error(), "\n"); lines < len(formats)+1 {
+			t.Errorf("%s: error has %d lines:\n%v", tt.name, lines, err)
+		}
+	}
+}
+
+func TestDecodeSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	wrapped := filepath.Join(dir, "wrapped.json")
+	content := `{"version":"3"}{"timestamp":"2024-05-02T13:45:00Z","minio":{"status":"success","info":{"deploymentID":"dep","servers":[` +
+		`{"endpoint":"node1:9000","state":"online","drives":[` +
+		`{"endpoint":"/data/disk1","path":"/data/disk1","state":"ok","metrics":{"totalErrorsTimeout":3,"lastErrorTimeout":"2024-05-02T13:00:00Z"}},` +
+		`{"endpoint":"/data/disk2","state":"ok","metrics":null}]}]},"newField":1}}`
+	if err := os.WriteFile(wrapped, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	infoStruct, err := loadJSON(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !infoStruct.Wrapped || infoStruct.Info.DeploymentID != "dep" || len(infoStruct.Info.Servers) != 1 {
+		t.Fatalf("loadJSON(wrapped) = %+v", infoStruct)
+	}
+	if want := time.Date(2024, 5, 2, 13, 45, 0, 0, time.UTC); !infoStruct.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", infoStruct.Timestamp, want)
+	}
+	disks := infoStruct.Info.Servers[0].Disks
+	if disks[0].Metrics == nil || disks[0].Metrics.TotalErrorsTimeout != 3 || disks[1].Metrics != nil {
+		t.Errorf("drive metrics = %+v, %+v", disks[0].Metrics, disks[1].Metrics)
+	}
+	times, ok := infoStruct.ErrorTimes[mdbcore.DriveErrorKey("node1:9000", "/data/disk1", "/data/disk1")]
+	if !ok || times.LastErrorTimeout.Hour() != 13 || len(infoStruct.ErrorTimes) != 1 {
+		t.Errorf("ErrorTimes = %+v", infoStruct.ErrorTimes)
+	}
+
+	// --show-unknown reads the document again, without the version header
+	data, err := readSnapshotDocument(wrapped, 0)
+	if err != nil || !strings.HasPrefix(string(data), `{"timestamp"`) {
+		t.Errorf("readSnapshotDocument(wrapped) = %.20q, %v", data, err)
+	}
+	fields := findUnknownFields(data)
+	if len(fields) == 0 || fields[0].Pointer != "/minio/newField" {
+		t.Errorf("unknown fields = %+v", fields)
+	}
+
+	ndjson := filepath.Join(dir, "series.ndjson")
+	record := func(id string) string {
+		return `{"info":{"deploymentID":"` + id + `","servers":[{"endpoint":"node1:9000"}]}}`
+	}
+	if err := os.WriteFile(ndjson, []byte(record("a")+"\n\n"+record("b")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	records, _, err := loadNDJSONRecords(ndjson)
+	if err != nil || len(records) != 2 || records[1].RecordLine != 3 {
+		t.Fatalf("loadNDJSONRecords() = %d records, %v", len(records), err)
+	}
+	if data, err := readSnapshotDocument(ndjson, records[1].RecordLine); err != nil || string(data) != record("b") {
+		t.Errorf("readSnapshotDocument(ndjson, 3) = %q, %v", data, err)
+	}
+}
+
+func TestLoadNDJSONLongLines(t *testing.T) {
+	// Single-line dumps of large clusters are megabytes long
+	record := func(id string) string {
+		drives := strings.Repeat(`{"endpoint":"/data/disk","state":"ok","totalspace":8000000000000},`, 20000)
+		return `{"info":{"deploymentID":"` + id + `","servers":[{"endpoint":"node1:9000","drives":[` + strings.TrimSuffix(drives, ",") + `]}]}}`
+	}
+	path := filepath.Join(t.TempDir(), "large.ndjson")
+	content := record("a") + "\n" + record("b")
+	if len(content) < 2<<20 {
+		t.Fatalf("fixture is only %d bytes", len(content))
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, skipped, err := loadNDJSONRecords(path)
+	if err != nil || skipped != 0 || len(records) != 2 {
+		t.Fatalf("loadNDJSONRecords() = %d records, %d skipped, %v", len(records), skipped, err)
+	}
+	if got := len(records[1].Info.Servers[0].Disks); records[1].Info.DeploymentID != "b" || got != 20000 {
+		t.Errorf("second record = %s with %d drives", records[1].Info.DeploymentID, got)
+	}
+	if data, err := readSnapshotDocument(path, 2); err != nil || string(data) != record("b") {
+		t.Errorf("readSnapshotDocument(2) = %d bytes, %v", len(data), err)
+	}
+
+	// Read errors are reported, not treated as the end of the file
+	readErr := errors.New("disk failure")
+	err = mdbcore.ReadLines(io.MultiReader(strings.NewReader("{}\n"), iotest.ErrReader(readErr)), func(int, []byte) bool { return true })
+	if !errors.Is(err, readErr) {
+		t.Errorf("readLines() error = %v, want %v", err, readErr)
+	}
+}
+
+// loadJSONReadAll loads a file the way loadJSON did before it decoded while
+// reading, to compare memory use in BenchmarkLoadJSON
+func loadJSONReadAll(filename string) (*clusterStruct, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data = []byte(strings.Replace(string(data), mdbcore.VersionPrefix, "", 1))
+	var infoStruct clusterStruct
+	if err := json.Unmarshal(data, &infoStruct); err != nil {
+		return nil, err
+	}
+	var stamped struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &stamped); err == nil {
+		infoStruct.Timestamp = stamped.Timestamp
+	}
+	var raw struct {
+		Info struct {
+			Servers []struct {
+				Disks []struct {
+					Metrics *mdbcore.DriveErrorTimes `json:"metrics"`
+				} `json:"drives"`
+			} `json:"servers"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return &infoStruct, nil
+}
+
+// peakHeapInuse returns by how much HeapInuse grew at most while load ran,
+// sampled every 100µs. B/op counts every allocation, including the garbage of
+// decoding token by token, so it does not show that the streaming decoder
+// never holds the whole file.
+func peakHeapInuse(load func()) uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base, peak := stats.HeapInuse, stats.HeapInuse
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(100 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			peak = max(peak, stats.HeapInuse)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	load()
+	close(done)
+	<-sampled
+	return peak - base
+}
+
+// BenchmarkLoadJSON loads a snapshot of 100 servers with 60 drives each. Compare
+// the peak-heap-B of the streaming and read-all variants, the most the heap in
+// use grew during a load, and B/op with -benchmem.
+func BenchmarkLoadJSON(b *testing.B) {
+	var content strings.Builder
+	content.WriteString(`{"version":"3"}{"status":"success","info":{"servers":[`)
+	for server := 0; server < 100; server++ {
+		if server > 0 {
+			content.WriteString(",")
+		}
+		fmt.Fprintf(&content, `{"endpoint":"node%d.example.com:9000","state":"online","uptime":3600,"drives":[`, server)
+		for drive := 0; drive < 60; drive++ {
+			if drive > 0 {
+				content.WriteString(",")
+			}
+			fmt.Fprintf(&content, `{"endpoint":"https://node%d.example.com:9000/data/disk%d","path":"/data/disk%d","state":"ok",`+
+				`"uuid":"%08d-0000-0000-0000-000000000000","totalspace":8000000000000,"usedspace":4000000000000,"availspace":4000000000000,`+
+				`"pool_index":%d,"set_index":%d,"disk_index":%d,"metrics":{"apiCalls":{"ReadFile":123456,"WriteAll":654321},"totalErrorsTimeout":1}}`,
+				server, drive, drive, server*60+drive, server/50, (server%50*60+drive)/16, drive%16)
+		}
+		content.WriteString("]}")
+	}
+	content.WriteString("]}}")
+	path := filepath.Join(b.TempDir(), "large.json")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bench := range []struct {
+		name string
+		load func(string) (*clusterStruct, error)
+	}{
+		{"stream", loadJSON},
+		{"readall", loadJSONReadAll},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			// Measured outside the timed loop, sampling stops the world. The
+			// worst of a few loads, as it depends on when the GC runs.
+			var peak uint64
+			for i := 0; i < 5; i++ {
+				peak = max(peak, peakHeapInuse(func() {
+					if _, err := bench.load(path); err != nil {
+						b.Fatalf("load failed: %v", err)
+					}
+				}))
+			}
+			b.ReportAllocs()
+			b.SetBytes(int64(content.Len()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				infoStruct, err := bench.load(path)
+				if err != nil || len(infoStruct.Info.Servers) != 100 {
+					b.Fatalf("load failed: %v", err)
+				}
+			}
+			// After the loop, ResetTimer drops reported metrics
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
+
+// BenchmarkRenderDrives renders the Drives table of 500 servers with 100 drives
+// each, printed to stdout and collected for the pager.
+func BenchmarkRenderDrives(b *testing.B) {
+	infoStruct := &clusterStruct{Snapshot: mdbcore.Snapshot{Status: "success"}}
+	infoStruct.Info.Backend = madmin.ErasureBackend{StandardSCParity: 4}
+	for server := 0; server < 500; server++ {
+		props := madmin.ServerProperties{State: "online", Endpoint: fmt.Sprintf("node%d.example.com:9000", server)}
+		for drive := 0; drive < 100; drive++ {
+			props.Disks = append(props.Disks, madmin.Disk{
+				Endpoint:       fmt.Sprintf("https://node%d.example.com:9000/data/disk%d", server, drive),
+				DrivePath:      fmt.Sprintf("/data/disk%d", drive),
+				State:          "ok",
+				UUID:           fmt.Sprintf("%08d-0000-0000-0000-000000000000", server*100+drive),
+				TotalSpace:     8000000000000,
+				UsedSpace:      4000000000000,
+				AvailableSpace: 4000000000000,
+				PoolIndex:      server / 100,
+				SetIndex:       (server%100*100 + drive) / 16,
+				DiskIndex:      drive % 16,
+			})
+		}
+		infoStruct.Info.Servers = append(infoStruct.Info.Servers, props)
+	}
+	config := &Config{JSONFile: "large.json", ShowDisks: true, Format: formatText}
+
+	for _, bench := range []struct {
+		name  string
+		pager func() *Pager
+	}{
+		{"stdout", func() *Pager {
+			pager := NewPager(false)
+			pager.stdout = io.Discard
+			return pager
+		}},
+		{"pager", func() *Pager { return NewPager(true) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pager := bench.pager()
+				if err := renderReport(pager, infoStruct, config); err != nil {
+					b.Fatal(err)
+				}
+				pager.Close()
+			}
+		})
+	}
+}
+
+func TestPagerCollectsLines(t *testing.T) {
+	pager := NewPager(true)
+	pager.Printf("first")
+	pager.Printf(" line\nsecond line\n")
+	pager.Printf("\nlast")
+	if want := []string{"first line", "second line", ""}; !reflect.DeepEqual(pager.lines, want) {
+		t.Errorf("lines = %q, want %q", pager.lines, want)
+	}
+	if got, want := pager.String(), "first line\nsecond line\n\nlast"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := pager.contentLines(); len(got) != 4 || got[3] != "last" {
+		t.Errorf("contentLines() = %q, want the unterminated last line included", got)
+	}
+
+	// Without paging the output reaches stdout once the pager is closed
+	var stdout strings.Builder
+	pager = NewPager(false)
+	pager.stdout = &stdout
+	printTableRows(pager, &Config{}, []string{"Name", "State"}, [][]string{{"disk1", Green + "ok" + Reset}, {"disk10", "faulty"}})
+	pager.Close()
+	want := "  Name    State \n  ------  ------\n  disk1   " + Green + "ok" + Reset + "    \n  disk10  faulty\n"
+	if stdout.String() != want {
+		t.Errorf("table = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestPagerShow(t *testing.T) {
+	newPager := func(auto, terminal bool, height int, heightErr error, content string) (*Pager, *strings.Builder) {
+		var stdout strings.Builder
+		pager := NewPager(true)
+		pager.auto = auto
+		pager.stdout = &stdout
+		pager.isTerminal = func() bool { return terminal }
+		pager.termHeight = func() (int, error) { return height, heightErr }
+		pager.Printf("%s", content)
+		return pager, &stdout
+	}
+
+	tests := []struct {
+		name      string
+		auto      bool
+		terminal  bool
+		heightErr error
+		content   string
+		pages     bool
+	}{
+		{"not a terminal", false, false, nil, "1\n2\n3\n4\n5\n6\n7\nlast", false},
+		{"auto, fits", true, true, nil, "1\n2\n3\n4\n", false},
+		{"auto, the pending line does not fit", true, true, nil, "1\n2\n3\n4\nlast", true},
+		{"auto, taller than the terminal", true, true, nil, "1\n2\n3\n4\n5\n6\n", true},
+		{"auto, unknown height", true, true, errors.New("no size"), "1\n2\n3\n4\n5\n6\n", false},
+		{"requested", false, true, nil, "1\n", true},
+	}
+	for _, tc := range tests {
+		pager, stdout := newPager(tc.auto, tc.terminal, 5, tc.heightErr, tc.content)
+		if got := pager.pages(pager.contentLines()); got != tc.pages {
+			t.Errorf("%s: pages() = %v, want %v", tc.name, got, tc.pages)
+		}
+		if tc.pages {
+			continue
+		}
+		// Content that is not paged is printed as it was written
+		pager.Show()
+		if stdout.String() != tc.content {
+			t.Errorf("%s: Show() printed %q, want %q", tc.name, stdout.String(), tc.content)
+		}
+	}
+}
+
+func TestLoadJSONFileChangedDuringRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.json")
+	if err := os.WriteFile(path, []byte(`{"status":"success","info":{"servers":[{"endpoint":"node1:9000"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the collector appending to the file while it is being read
+	writes := 0
+	openFile = func(name string) (*os.File, error) {
+		file, err := os.Open(name)
+		if writes == 0 {
+			writes++
+			f, ferr := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+			if ferr != nil {
+				t.Fatal(ferr)
+			}
+			f.WriteString(`,"state":"online"}]}}`)
+			f.Close()
+		}
+		return file, err
+	}
+	defer func() { openFile = os.Open }()
+
+	_, err := loadJSON(path)
+	if !errors.Is(err, errFileChanged) {
+		t.Fatalf("loadJSON() error = %v, want errFileChanged", err)
+	}
+
+	// Once the writer is done the file reads cleanly
+	infoStruct, err := loadJSON(path)
+	if err != nil {
+		t.Fatalf("loadJSON() after write returned error: %v", err)
+	}
+	if len(infoStruct.Info.Servers) != 1 || infoStruct.Info.Servers[0].State != "online" {
+		t.Errorf("loadJSON() servers = %+v, want one online server", infoStruct.Info.Servers)
+	}
+}
+
+func TestTerminalGuard(t *testing.T) {
+	large := strings.Repeat("x", 2*terminalGuardLimit)
+
+	tests := []struct {
+		name     string
+		terminal bool
+		answer   string
+		wantOut  bool
+		wantAsk  bool
+	}{
+		{"not a terminal", false, "", true, false},
+		{"confirmed", true, "y\n", true, true},
+		{"declined", true, "n\n", false, true},
+		{"no answer", true, "", false, true},
+	}
+	for _, tc := range tests {
+		var stdout, prompt strings.Builder
+		pager := NewPager(false)
+		pager.stdout = &stdout
+		pager.guard = &terminalGuard{
+			limit:      terminalGuardLimit,
+			isTerminal: func() bool { return tc.terminal },
+			input:      strings.NewReader(tc.answer),
+			prompt:     &prompt,
+		}
+
+		pager.Printf("%s", large)
+		pager.Printf("%s", large)
+		err := pager.Close()
+
+		if got := stdout.Len() == 2*len(large); got != tc.wantOut {
+			t.Errorf("%s: wrote %d bytes, want output %v", tc.name, stdout.Len(), tc.wantOut)
+		}
+		if tc.wantOut && err != nil {
+			t.Errorf("%s: Close() returned error: %v", tc.name, err)
+		}
+		if !tc.wantOut && !errors.Is(err, errOutputDeclined) {
+			t.Errorf("%s: Close() error = %v, want errOutputDeclined", tc.name, err)
+		}
+		if asked := prompt.Len() > 0; asked != tc.wantAsk {
+			t.Errorf("%s: prompted = %v, want %v", tc.name, asked, tc.wantAsk)
+		}
+		if tc.wantAsk && strings.Count(prompt.String(), "continue?") != 1 {
+			t.Errorf("%s: prompt %q should be shown exactly once", tc.name, prompt.String())
+		}
+	}
+
+	// Output below the limit is written to a terminal without asking
+	var stdout, prompt strings.Builder
+	pager := NewPager(false)
+	pager.stdout = &stdout
+	pager.guard = &terminalGuard{limit: terminalGuardLimit, isTerminal: func() bool { return true }, input: strings.NewReader(""), prompt: &prompt}
+	pager.Printf("small")
+	pager.Close()
+	if stdout.String() != "small" || prompt.Len() > 0 {
+		t.Errorf("small output: stdout %q, prompt %q", stdout.String(), prompt.String())
+	}
+}
+
+// runShow runs the application with args and returns the config the show command would display
+func runShow(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	var got *Config
+	displayReport = func(config *Config) error {
+		got = config
+		return nil
+	}
+	defer func() { displayReport = processAndDisplay }()
+
+	app := newApp()
+	app.Writer = io.Discard
+	app.ErrWriter = io.Discard
+	err := app.Run(reorderShowArgs(app, withFlagDefaults(app, append([]string{"mdb"}, args...))))
+	return got, err
+}
+
+func TestShowFlagParsing(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join(home, "cluster.json")
+	current := filepath.Join(home, "current.json")
+	for _, path := range []string{file, current} {
+		if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveConfig("current", current); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantFile   string
+		lowSpace   float64
+		trimDomain string
+	}{
+		{"flag before file", []string{"show", "sets", "--low-space", "10", file}, file, 10, ""},
+		{"flag after file", []string{"show", "sets", file, "--low-space", "10"}, file, 10, ""},
+		{"equals before file", []string{"show", "sets", "--low-space=10", file}, file, 10, ""},
+		{"equals after file", []string{"show", "sets", file, "--low-space=10"}, file, 10, ""},
+		{"flags around file", []string{"show", "sets", "--trim-domain", ".corp.local", file, "--low-space", "10"}, file, 10, ".corp.local"},
+		{"current config", []string{"show", "sets", "--low-space", "10", "--trim-domain=.corp.local"}, current, 10, ".corp.local"},
+		{"show flag after file", []string{"show", file, "--trim-domain", ".corp.local"}, file, 0, ".corp.local"},
+		{"show flag before file", []string{"show", "--trim-domain", ".corp.local", file}, file, 0, ".corp.local"},
+		{"show equals after file", []string{"show", file, "--trim-domain=.corp.local"}, file, 0, ".corp.local"},
+	}
+	for _, tc := range tests {
+		config, err := runShow(t, tc.args...)
+		if err != nil {
+			t.Errorf("%s: %v returned error: %v", tc.name, tc.args, err)
+			continue
+		}
+		if config == nil {
+			t.Errorf("%s: %v did not display a report", tc.name, tc.args)
+			continue
+		}
+		if config.JSONFile != tc.wantFile {
+			t.Errorf("%s: file = %q, want %q", tc.name, config.JSONFile, tc.wantFile)
+		}
+		if tc.lowSpace != 0 && (config.LowSpaceThreshold == nil || *config.LowSpaceThreshold != tc.lowSpace) {
+			t.Errorf("%s: --low-space = %v, want %v", tc.name, config.LowSpaceThreshold, tc.lowSpace)
+		}
+		if config.TrimDomain != tc.trimDomain {
+			t.Errorf("%s: --trim-domain = %q, want %q", tc.name, config.TrimDomain, tc.trimDomain)
+		}
+	}
+
+	config, err := runShow(t, "show", "summary", file, "--trim-domain", ".corp.local", current)
+	if err != nil || config == nil || strings.Join(config.JSONFiles, ",") != file+","+current || config.JSONFile != file {
+		t.Errorf("several files: config %+v, error %v", config, err)
+	}
+
+	config, err = runShow(t, "show", "disks", file, "--errors=5")
+	if err != nil || config == nil || config.ErrorThreshold == nil || *config.ErrorThreshold != 5 {
+		t.Errorf("--errors=5: config %+v, error %v", config, err)
+	}
+
+	failing := [][]string{
+		{"show", "sets", "--bogus", file},
+		{"show", file, "--bogus"},
+		{"show", "sets", file, filepath.Join(home, "extra.json")},
+		{"show", "sets", file, current, "--format", "html"},
+		{"show", "sets", file, current, "--bundle", filepath.Join(home, "case.tar.gz")},
+		{"show", "sets", filepath.Join(home, "missing.json")},
+		{"show", "sets", file, "--errors=1"},
+		{"show", "disks", file, "--errors=0"},
+		{"show", "disks", file, "--errors=5", "--inodes=90"},
+	}
+	for _, args := range failing {
+		if config, err := runShow(t, args...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestTopLevelCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join(home, "cluster.json")
+	if err := os.WriteFile(file, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args                          []string
+		summary, servers, sets, disks bool
+		failed                        bool
+	}{
+		{[]string{"summary", file}, true, false, false, false, false},
+		{[]string{"drives", file, "--errors=2"}, false, false, false, true, false},
+		{[]string{"disks", "--failed", file}, false, false, false, true, true},
+		{[]string{"servers", file, "--busy-servers"}, false, true, false, false, false},
+		{[]string{"sets", file, "--low-space", "10"}, false, false, true, false, false},
+		{[]string{"failed", file, "--min-bad-disks", "2"}, false, false, true, false, true},
+		{[]string{"show", "sets", file, "--failed"}, false, false, true, false, true},
+	}
+	for _, tc := range tests {
+		config, err := runShow(t, tc.args...)
+		if err != nil || config == nil {
+			t.Errorf("%v: config %+v, error %v", tc.args, config, err)
+			continue
+		}
+		got := []bool{config.ShowSummary, config.ShowServers, config.ShowSets, config.ShowDisks, config.FailedMode}
+		want := []bool{tc.summary, tc.servers, tc.sets, tc.disks, tc.failed}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: summary, servers, sets, disks, failed = %v, want %v", tc.args, got, want)
+		}
+		if config.JSONFile != file {
+			t.Errorf("%v: file = %q, want %q", tc.args, config.JSONFile, file)
+		}
+	}
+	if config, _ := runShow(t, "failed", file, "--min-bad-disks", "2"); config == nil || config.MinBadDisks == nil || *config.MinBadDisks != 2 {
+		t.Errorf("failed --min-bad-disks 2: config %+v", config)
+	}
+
+	// Each command only accepts the flags of its view
+	failing := [][]string{
+		{"summary", file, "--low-space", "10"},
+		{"sets", file, "--inodes=90"},
+		{"servers", file, "--scanning"},
+		{"failed", file, "--scanning"},
+		{"drives", file, "--min-bad-disks", "2"},
+	}
+	for _, args := range failing {
+		if config, err := runShow(t, args...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestPrintServices(t *testing.T) {
+	services := madmin.Services{
+		KMSStatus: []madmin.KMS{
+			{Status: "online", Endpoint: "https://kes1:7373", Version: "2024-01-11", Encrypt: "success", Decrypt: "success"},
+			{Status: "offline", Endpoint: "https://kes2:7373", Encrypt: "Encryption failed"},
+		},
+		LDAP:   madmin.LDAP{Status: "online"},
+		Logger: []madmin.Logger{{"webhook10": {Status: "online"}, "webhook2": {Status: "offline"}}},
+		Notifications: []map[string][]madmin.TargetIDStatus{
+			{"webhook": {{"1:webhook": {Status: "online"}}}, "amqp": {{"1:amqp": {}}}},
+		},
+	}
+
+	pager := NewPager(true)
+	printServices(pager, services, &Config{Format: formatMarkdown})
+	want := "## Services\n\n" +
+		"| Service | Target | Status | Details |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| KMS | https://kes1:7373 | online | version 2024-01-11 |\n" +
+		"| KMS | https://kes2:7373 | **offline** | encrypt: Encryption failed |\n" +
+		"| LDAP | - | online |  |\n" +
+		"| Logger | webhook2 | **offline** |  |\n" +
+		"| Logger | webhook10 | online |  |\n" +
+		"| Notification (amqp) | 1:amqp | **unknown** |  |\n" +
+		"| Notification (webhook) | 1:webhook | online |  |\n\n" +
+		"- **2 of 7 services offline**\n\n"
+	if got := textToMarkdown(pager.String()); got != want {
+		t.Errorf("printServices output:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The deprecated single KMS entry is used by older servers
+	if rows := collectServices(madmin.Services{KMS: madmin.KMS{Status: "online", Endpoint: "https://kes:7373"}}); len(rows) != 1 || rows[0].target != "https://kes:7373" {
+		t.Errorf("collectServices with deprecated KMS = %+v", rows)
+	}
+
+	pager = NewPager(true)
+	printServices(pager, madmin.Services{}, &Config{})
+	if got := pager.String(); got != "" {
+		t.Errorf("printServices without services = %q, want nothing", got)
+	}
+}
+
+func TestPrintReplication(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	path := write("replicate.json", `{"status":"success","Enabled":true,"MaxBuckets":5,"MaxUsers":2,"MaxGroups":0,"MaxPolicies":1,
+		"Sites":{
+			"dep-a":{"endpoint":"https://a.example.com","name":"site-a","deploymentID":"dep-a","sync":"enable"},
+			"dep-b":{"endpoint":"https://b.example.com","name":"site-b","deploymentID":"dep-b","sync":"disable"},
+			"dep-c":{"endpoint":"https://c.example.com","name":"site-c","deploymentID":"dep-c"}},
+		"StatsSummary":{
+			"dep-a":{"TotalBucketsCount":5,"TotalUsersCount":2,"TotalIAMPoliciesCount":1},
+			"dep-b":{"TotalBucketsCount":3,"TotalUsersCount":2,"TotalIAMPoliciesCount":0}},
+		"Metrics":{"replMetrics":{
+			"dep-b":{"deploymentID":"dep-b","isOnline":false,"lastOnline":"2024-05-10T01:41:38Z"},
+			"dep-c":{"deploymentID":"dep-c","isOnline":true}}}}`)
+
+	status, err := loadReplicationStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pager := NewPager(true)
+	printReplication(pager, status, "dep-a", &Config{Format: formatMarkdown})
+	want := "## Replication\n\n" +
+		"| Site | Deployment ID | Endpoint | Mode | State | In Sync |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| site-a | dep-a | https://a.example.com | sync | this cluster | yes |\n" +
+		"| site-b | dep-b | https://b.example.com | async | **offline since 2024-05-10T01:41:38Z** | **missing 2 buckets, 1 policies** |\n" +
+		"| site-c | dep-c | https://c.example.com | async | online | N/A |\n\n" +
+		"- **1 of 3 peer sites offline**\n\n" +
+		"- **1 of 3 sites out of sync**\n\n"
+	if got := textToMarkdown(pager.String()); got != want {
+		t.Errorf("printReplication output:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without sites the section is omitted
+	pager = NewPager(true)
+	status, err = loadReplicationStatus(write("disabled.json", `{"status":"success","Enabled":false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	printReplication(pager, status, "dep-a", &Config{})
+	if got := pager.String(); got != "" {
+		t.Errorf("printReplication without sites = %q, want nothing", got)
+	}
+
+	for _, content := range []string{`{"status":"error","error":{"message":"not configured"}}`, `{"Sites":`} {
+		if _, err := loadReplicationStatus(write("bad.json", content)); err == nil {
+			t.Errorf("loadReplicationStatus(%s) should fail", content)
+		}
+	}
+}
+
+func TestHealStatus(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	path := write("heal.json", `{"status":"success","healinfo":{"offline_nodes":["node4:9000"],"ScannedItemsCount":5000,
+		"sets":[{"pool_index":0,"set_index":0,"disks":[
+			{"endpoint":"http://node1:9000/data/disk0","state":"ok"},
+			{"endpoint":"http://node2:9000/data/disk3","state":"ok","healing":true,"heal_info":{
+				"started":"2024-05-10T00:00:00Z","last_update":"2024-05-10T01:00:00Z",
+				"objects_total_count":1000,"items_healed":400,"items_skipped":100}},
+			{"endpoint":"http://node3:9000/data/disk9","uuid":"other","state":"ok","heal_info":{
+				"started":"2024-05-10T00:00:00Z","last_update":"2024-05-10T00:30:00Z",
+				"objects_total_count":1000,"items_healed":100,"items_failed":3}}]}]}}`)
+
+	heal, err := loadHealStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := testCluster()
+	// The drive of node2 is matched by UUID although its endpoint differs
+	infoStruct.Info.Servers[1].Disks[1].Endpoint = "http://node2.example.com:9000/data/disk3"
+	infoStruct.Info.Servers[1].Disks[1].UUID = "node2-uuid-3"
+	heal.Sets[0].Disks[1].UUID = "node2-uuid-3"
+	heal.merge(infoStruct)
+
+	if info := infoStruct.Info.Servers[1].Disks[1].HealInfo; info == nil || info.ItemsHealed != 400 || !infoStruct.Info.Servers[1].Disks[1].Healing {
+		t.Errorf("heal info of node2 disk3 not merged: %+v", info)
+	}
+	if len(heal.Unmatched) != 1 || heal.Unmatched[0].Endpoint != "http://node3:9000/data/disk9" {
+		t.Errorf("unmatched drives = %+v", heal.Unmatched)
+	}
+	if eta, ok := healETA(heal.Sets[0].Disks[1].HealInfo); !ok || eta != time.Hour {
+		t.Errorf("healETA = %v, %v; want 1h", eta, ok)
+	}
+
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, DriveColumns: []string{"disk_path", "state", "heal_pct"}}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		"Heal Progress\n  Healing 2 drives: 25.0% (500 of 2,000 objects healed), ETA 4 hours 21 minutes 0 seconds\n" +
+			"  Failed to heal: 3 objects\n  Background heal scanned 5,000 items\n  Offline, no heal status: node4:9000\n",
+		"  1 drives of the heal file are not in the info file:",
+		"  0     0            node3   /data/disk9  ok     10.0% (100 of 1,000)",
+		"  /data/disk3  faulty  40.0% (400 of 1,000)",
+		"  /data/disk0  ok      -",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+
+	for _, content := range []string{`{"status":"error"}`, `{"healinfo":{"sets":[]}}`, `{"sets":`} {
+		if _, err := loadHealStatus(write("bad.json", content)); err == nil {
+			t.Errorf("loadHealStatus(%s) should fail", content)
+		}
+	}
+}
+
+func TestMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.txt")
+	metrics := `# HELP minio_node_drive_latency_us Average last minute latency in µs for drive API storage operations
+# TYPE minio_node_drive_latency_us gauge
+minio_node_drive_latency_us{api="storage.ReadXL",drive="/data/disk0",server="node1.example.com:9000"} 850
+minio_node_drive_latency_us{api="storage.WalkDir",drive="/data/disk0",server="node1.example.com:9000"} 12500
+minio_node_drive_reads_per_sec{drive="/data/disk0",server="node1.example.com:9000"} 120.4
+minio_node_drive_writes_per_sec{drive="/data/disk0",server="node1.example.com:9000"} 30
+minio_node_drive_reads_per_sec{drive="http://node2.example.com:9000/data/disk2",server="node2.example.com:9000"} 7
+minio_node_drive_reads_per_sec{drive="/data/disk7",server="node9.example.com:9000"} 1
+minio_node_drive_online_total{server="node1.example.com:9000"} 2
+minio_node_drive_reads_per_sec{drive="/data/disk1",server="node1.example.com:9000"} not-a-number
+`
+	if err := os.WriteFile(path, []byte(metrics), 0644); err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := testCluster()
+	driveIO, err := loadDriveMetrics(path, infoStruct.Info.Servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if driveIO.Skipped != 1 || len(driveIO.Drives) != 3 {
+		t.Errorf("skipped %d lines, read %d drives; want 1 and 3", driveIO.Skipped, len(driveIO.Drives))
+	}
+	infoStruct.DriveIO = driveIO
+
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, SetMetrics: true, DriveColumns: []string{"server", "disk_path", "latency", "iops"}}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		// Drives without series, such as the unparsable disk1, show N/A
+		"  node1   /data/disk0  12.5ms WalkDir  150",
+		"  node1   /data/disk1  N/A             N/A",
+		"  node2   /data/disk2  N/A             7",
+		"Max Waiting  IOPS  Max Latency",
+		"  N/A          157   12.5ms",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+
+	for us, want := range map[float64]string{850: "850µs", 12500: "12.5ms", 2500000: "2.50s"} {
+		if got := formatLatency(us); got != want {
+			t.Errorf("formatLatency(%v) = %q, want %q", us, got, want)
+		}
+	}
+}
+
+func TestDiagArchive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	snapshot, err := os.ReadFile("testdata/failed-drives.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := `minio_node_drive_latency_us{api="storage.ReadXL",drive="/mnt/drive1",server="node1.cluster.example.net:9000"} 2500
+minio_node_drive_reads_per_sec{drive="/mnt/drive1",server="node1.cluster.example.net:9000"} 40
+`
+	writeTarGz := func(name string, members map[string]string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, member := range []string{"diag/cluster.json", "diag/metrics.prom"} {
+			data, ok := members[member]
+			if !ok {
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: member, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write([]byte(data))
+		}
+		tw.Close()
+		gz.Close()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// The metrics of the archive are read without --metrics-file
+	archive := writeTarGz("diag.tar.gz", map[string]string{"diag/cluster.json": string(snapshot), "diag/metrics.prom": metrics})
+	config, err := runShow(t, "show", "disks", archive, "--no-config", "--color", "never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct, err := prepareInput(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Archive != archive || inputName(config) != archive || filepath.Base(config.MetricsFile) != "metrics.prom" {
+		t.Errorf("Archive = %q, shown as %q, MetricsFile = %q", config.Archive, inputName(config), config.MetricsFile)
+	}
+	if drives := infoStruct.Drives(mdbcore.DriveFilter{}); drives[0].IO == nil || drives[0].IO.LatencyUs != 2500 || drives[1].IO != nil {
+		t.Errorf("drive IO = %v %v", drives[0].IO, drives[1].IO)
+	}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := stripANSI(pager.String()); !strings.Contains(got, "2.5ms ReadXL  40") {
+		t.Errorf("report of %s misses the latency of the archive's metrics:\n%s", archive, got)
+	}
+	removeLocalInput(config)
+	if _, err := os.Stat(filepath.Dir(config.JSONFile)); !os.IsNotExist(err) {
+		t.Errorf("extracted files should be removed: %v", err)
+	}
+
+	// A zip archive without metrics is read like its snapshot
+	zipPath := filepath.Join(dir, "diag.zip")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("cluster.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(snapshot)
+	zw.Close()
+	if err := os.WriteFile(zipPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config = &Config{JSONFile: zipPath}
+	if infoStruct, err := prepareInput(config); err != nil || config.MetricsFile != "" || infoStruct.DriveIO != nil {
+		t.Errorf("zip archive: %v, MetricsFile = %q", err, config.MetricsFile)
+	}
+	removeLocalInput(config)
+
+	// --metrics-file wins over the archive's metrics
+	config = &Config{JSONFile: archive, MetricsFile: "other.prom"}
+	if err := extractArchive(config); err != nil || config.MetricsFile != "other.prom" {
+		t.Errorf("--metrics-file replaced by %q: %v", config.MetricsFile, err)
+	}
+	removeLocalInput(config)
+
+	empty := writeTarGz("empty.tar.gz", map[string]string{"diag/metrics.prom": metrics})
+	if _, err := prepareInput(&Config{JSONFile: empty}); err == nil || err.Error() != "failed to read archive '"+empty+"': archive has no .json snapshot" {
+		t.Errorf("archive without snapshot: %v", err)
+	}
+
+	// A gzip-compressed snapshot, as completed for the file argument, is read
+	// decompressed and dated like the compressed file
+	buf.Reset()
+	gz := gzip.NewWriter(&buf)
+	gz.Write(snapshot)
+	gz.Close()
+	compressed := filepath.Join(dir, "cluster.json.gz")
+	if err := os.WriteFile(compressed, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(compressed, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	config = &Config{JSONFile: compressed}
+	infoStruct, err = prepareInput(config)
+	if err != nil {
+		t.Fatalf("gzip snapshot: %v", err)
+	}
+	if inputName(config) != compressed || filepath.Base(config.JSONFile) != "cluster.json" || len(infoStruct.Info.Servers) == 0 {
+		t.Errorf("gzip snapshot read from %q, shown as %q", config.JSONFile, inputName(config))
+	}
+	if fi, err := os.Stat(config.JSONFile); err != nil || !fi.ModTime().Equal(mtime) {
+		t.Errorf("decompressed snapshot should keep the modification time %v: %v", mtime, err)
+	}
+	removeLocalInput(config)
+	if _, err := os.Stat(compressed); err != nil {
+		t.Errorf("the compressed snapshot should be kept: %v", err)
+	}
+	var out bytes.Buffer
+	if err := validateFile(&out, compressed, false); err != nil || !strings.Contains(out.String(), "Validating "+compressed) {
+		t.Errorf("--validate of a gzip snapshot: %v\n%s", err, out.String())
+	}
+}
+
+func TestPoolStatus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// Pool 1 held 60 of 100 bytes and has 35 left
+	decommission := write("decommission.json", `[{"id":0,"cmdline":"http://node{1...4}/data{1...4}"},
+		{"id":1,"cmdline":"http://node{5...8}/data{1...4}","decommissionInfo":{"startTime":"2024-05-10T00:00:00Z",
+			"startSize":40,"totalSize":100,"currentSize":65}}]`)
+	rebalance := write("rebalance.json", `{"ID":"5a2b0c1e","pools":[{"id":0,"status":"Started","used":0.3},{"id":1,"status":"Started","used":0.9}]}`)
+	file := filepath.Join("testdata", "multi-pool.json")
+	render := func(args ...string) string {
+		t.Helper()
+		config, err := runShow(t, append([]string{"summary", "--no-config", "--color", "never", "--history-size", "0", file}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infoStruct, err := prepareInput(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pager := NewPager(true)
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(pager.String())
+	}
+
+	if got := render(); strings.Contains(got, "Used %  Status") {
+		t.Errorf("summary without --pool-status shows pool states:\n%s", got)
+	}
+	// The states are columns of the Storage Class Capacity table, not a table of their own
+	got := render("--pool-status", decommission)
+	for _, want := range []string{"Difference  Used      Used %  Status", "+16.0 TiB   10.4 TiB  32.5%   active", "57.5%   decommissioning (42% complete)\n",
+		"+32.0 TiB   28.8 TiB  45.0%", "  1 pool being decommissioned\n", "INFO", "pool=1", "decommissioning (42% complete)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("--pool-status %s misses %q:\n%s", decommission, want, got)
+		}
+	}
+	got = render("--pool-status", rebalance)
+	if !strings.Contains(got, "57.5%   rebalancing\n") || !strings.Contains(got, "  2 pools being rebalanced\n") {
+		t.Errorf("--pool-status %s:\n%s", rebalance, got)
+	}
+	if got = render("--pool-status", rebalance, "--bars", "--ascii"); !strings.Contains(got, "[######--------------] 32.5%  rebalancing") || strings.Contains(got, "Pool Status") {
+		t.Errorf("--pool-status with --bars:\n%s", got)
+	}
+
+	pools, err := loadPoolStatus(write("stream.json", `{"id":1,"decommissionInfo":{"totalSize":100,"failed":true}}
+{"id":0,"decommissionInfo":{"totalSize":100,"complete":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pools[0].text() != "decommissioned" || pools[1].text() != "decommission failed" {
+		t.Errorf("pool states = %+v", pools)
+	}
+	findings := collectFindings(nil, nil, mdbcore.NewServerNamer(nil, ""), -1, pools, &Config{})
+	if len(findings) != 1 || findings[0].Severity != severityWarning || *findings[0].Pool != 1 {
+		t.Errorf("findings = %+v", findings)
+	}
+
+	for _, content := range []string{`{"status":"error"}`, `[]`, `{"id":"x"}`, `{"id":`} {
+		if _, err := loadPoolStatus(write("bad.json", content)); err == nil {
+			t.Errorf("loadPoolStatus(%s) should fail", content)
+		}
+	}
+	if config, err := runShow(t, "show", file, file, "--pool-status", decommission); err == nil {
+		t.Errorf("--pool-status with two files: config %+v", config)
+	}
+}
+
+func TestStorageClassUsable(t *testing.T) {
+	render := func(rrs int) string {
+		infoStruct := testCluster()
+		infoStruct.Info.Backend.RRSCParity = rrs
+		pager := NewPager(true)
+		if err := renderReport(pager, infoStruct, &Config{JSONFile: "cluster.json", ShowSummary: true}); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(pager.String())
+	}
+
+	// 4 drives of 1000 bytes: 2 data drives with EC:2, 3 with EC:1
+	if got := render(1); !strings.Contains(got, "  Usable (STANDARD, EC:2): 2.0 KiB; Usable (REDUCED_REDUNDANCY, EC:1): 2.9 KiB\n") || strings.Contains(got, "saves no capacity") {
+		t.Errorf("EC:1 reduced redundancy:\n%s", got)
+	}
+	if got := render(2); !strings.Contains(got, "REDUCED_REDUNDANCY parity EC:2 is not below STANDARD EC:2, reduced redundancy saves no capacity") {
+		t.Errorf("equal parities should warn:\n%s", got)
+	}
+	if got := render(0); strings.Contains(got, "REDUCED_REDUNDANCY") {
+		t.Errorf("snapshot without reduced redundancy parity:\n%s", got)
+	}
+}
+
+func TestScanningHealProgress(t *testing.T) {
+	snapshot := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	infoStruct := testCluster()
+	infoStruct.Timestamp = snapshot
+	disks := infoStruct.Info.Servers[1].Disks
+	disks[0].Healing = true
+	disks[0].HealInfo = &madmin.HealingDisk{Started: snapshot.Add(-3 * time.Hour), ObjectsTotalCount: 200, ItemsHealed: 84}
+	// Older servers report neither totals nor a start time
+	disks[1].Healing = true
+	disks[1].HealInfo = &madmin.HealingDisk{ObjectsHealed: 10}
+
+	render := func(config *Config) string {
+		pager := NewPager(true)
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(stripANSI(pager.String()), "\n")
+		for i := range lines {
+			lines[i] = strings.TrimRight(lines[i], " ")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	got := render(&Config{JSONFile: "cluster.json", ShowDisks: true, ScanningMode: true, DriveColumns: []string{"disk_path", "scanning"}})
+	for _, want := range []string{"  /data/disk2  Yes (42%, started 3h ago)\n", "  /data/disk3  Yes\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("drives with --scanning miss %q:\n%s", want, got)
+		}
+	}
+	got = render(&Config{JSONFile: "cluster.json", ShowDisks: true, DriveColumns: []string{"disk_path", "scanning"}})
+	for _, want := range []string{"  /data/disk2  Yes, 3h\n", "  /data/disk3  Yes\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("drives without --scanning miss %q:\n%s", want, got)
+		}
+	}
+	if got := render(&Config{JSONFile: "cluster.json", ShowSummary: true}); !strings.Contains(got, "  Longest running heal: 3h (pool 0 set 0 disk 2)\n") {
+		t.Errorf("summary should show the longest running heal:\n%s", got)
+	}
+	// A drive without a disk index is not shown as disk 0
+	infoStruct.Info.Servers[1].Disks[0].DiskIndex = mdbcore.MissingDiskIndex
+	if got := render(&Config{JSONFile: "cluster.json", ShowSummary: true}); !strings.Contains(got, "  Longest running heal: 3h (pool 0 set 0)\n") {
+		t.Errorf("summary should show the longest running heal without a disk index:\n%s", got)
+	}
+	infoStruct.Info.Servers[1].Disks[0].DiskIndex = 2
+
+	// Heals running longer than --heal-stuck are red, shorter ones yellow
+	scanning := func(config *Config) string {
+		pager := NewPager(true)
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		return pager.String()
+	}
+	if got := scanning(&Config{JSONFile: "cluster.json", ShowDisks: true, DriveColumns: []string{"scanning"}}); !strings.Contains(got, Yellow+"Yes, 3h"+Reset) {
+		t.Errorf("a 3h heal should be yellow with the default --heal-stuck:\n%q", got)
+	}
+	if got := scanning(&Config{JSONFile: "cluster.json", ShowDisks: true, DriveColumns: []string{"scanning"}, HealStuck: 2 * time.Hour}); !strings.Contains(got, Red+"Yes, 3h"+Reset) {
+		t.Errorf("a 3h heal should be red with --heal-stuck 2h:\n%q", got)
+	}
+
+	got = render(&Config{JSONFile: "cluster.json", ShowSets: true, ScanningMode: true})
+	if !strings.Contains(got, "2 (min 42%)") {
+		t.Errorf("erasure sets with --scanning should show the lowest heal progress:\n%s", got)
+	}
+
+	// Progress by bytes when the object total is missing
+	if pct, ok := mdbcore.HealProgressPct(&madmin.HealingDisk{ObjectsTotalSize: 400, BytesDone: 100}); !ok || pct != 25 {
+		t.Errorf("healProgressPct by bytes = %v, %v; want 25, true", pct, ok)
+	}
+}
+
+func TestPrintSetMetrics(t *testing.T) {
+	drives := map[string][]DiskInfo{
+		"0:0": {
+			{PoolIndex: 0, SetIndex: 0, Metrics: &madmin.DiskMetrics{TotalWrites: 1500, TotalDeletes: 10, TotalTokens: 8, TotalWaiting: 2}},
+			{PoolIndex: 0, SetIndex: 0, Metrics: &madmin.DiskMetrics{TotalWrites: 500, TotalErrorsTimeout: 3, TotalErrorsAvailability: 5, TotalTokens: 8, TotalWaiting: 8}},
+			{PoolIndex: 0, SetIndex: 0},
+		},
+		"0:1":  {{PoolIndex: 0, SetIndex: 1, Metrics: &madmin.DiskMetrics{TotalWrites: 7}}},
+		"1:10": {{PoolIndex: 1, SetIndex: 10}},
+	}
+
+	pager := NewPager(true)
+	printSetMetrics(pager, drives, &Config{Format: formatMarkdown})
+	want := "## Erasure Set Metrics\n\n" +
+		"| Pool | Erasure Set | Drives | Writes | Deletes | Timeouts | Errors | Max Waiting |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| 0 | 0 | 2 of 3 | 2,000 | 10 | **3** | **5** | **8** |\n" +
+		"| 0 | 1 | 1 of 1 | 7 | 0 | 0 | 0 | 0 |\n" +
+		"| 1 | 10 | 0 of 1 | N/A | N/A | N/A | N/A | N/A |\n\n"
+	if got := textToMarkdown(pager.String()); got != want {
+		t.Errorf("printSetMetrics output:\n%s\nwant:\n%s", got, want)
+	}
+	if got := waitingColor(2, 8); got != Yellow {
+		t.Errorf("waitingColor(2, 8) = %q, want yellow", got)
+	}
+
+	pager = NewPager(true)
+	printSetMetrics(pager, map[string][]DiskInfo{"0:0": {{}}}, &Config{})
+	if got := stripANSI(pager.String()); got != "Erasure Set Metrics\n  No drive reports metrics\n\n" {
+		t.Errorf("printSetMetrics without metrics = %q", got)
+	}
+}
+
+func TestDriveSaturation(t *testing.T) {
+	drives := map[string][]DiskInfo{
+		"0:0": {
+			{Path: "/data/disk1", Metrics: &madmin.DiskMetrics{TotalTokens: 8, TotalWaiting: 2}},
+			{Path: "/data/disk2", Metrics: &madmin.DiskMetrics{TotalTokens: 8, TotalWaiting: 6}},
+			{Path: "/data/disk3", Metrics: &madmin.DiskMetrics{TotalTokens: 8, TotalWaiting: 12}},
+			{Path: "/data/disk4", Metrics: &madmin.DiskMetrics{TotalWrites: 7}},
+			{Path: "/data/disk5"},
+		},
+	}
+
+	if _, ok := driveWaitingRatio(drives["0:0"][3]); ok {
+		t.Error("drive without tokens should have no waiting ratio")
+	}
+	if ratio, ok := driveWaitingRatio(drives["0:0"][1]); !ok || ratio != 0.75 {
+		t.Errorf("driveWaitingRatio = %v, %v; want 0.75, true", ratio, ok)
+	}
+
+	pager := NewPager(true)
+	printSaturatedDrives(pager, drives, &Config{})
+	if got, want := pager.String(), "  Saturated drives: "+Red+"2"+Reset+" (waiting/tokens 0.50 or more)\n"; got != want {
+		t.Errorf("printSaturatedDrives = %q, want %q", got, want)
+	}
+	pager = NewPager(true)
+	printSaturatedDrives(pager, drives, &Config{SaturationRatio: 2})
+	if got, want := pager.String(), "  Saturated drives: "+Green+"0"+Reset+" (waiting/tokens 2.00 or more)\n"; got != want {
+		t.Errorf("printSaturatedDrives with --saturation=2 = %q, want %q", got, want)
+	}
+	pager = NewPager(true)
+	printSaturatedDrives(pager, map[string][]DiskInfo{"0:0": drives["0:0"][3:]}, &Config{})
+	if got := pager.String(); got != "" {
+		t.Errorf("printSaturatedDrives without tokens = %q, want nothing", got)
+	}
+
+	pager = NewPager(true)
+	printTable(pager, drives["0:0"], &Config{Format: formatMarkdown, DriveColumns: []string{"disk_path", "metrics"}})
+	want := "| Disk Path | Metrics |\n" +
+		"| --- | --- |\n" +
+		"| /data/disk1 | [tokens=8, waiting=2] |\n" +
+		"| /data/disk2 | [tokens=8, waiting=6] **saturated 0.75** |\n" +
+		"| /data/disk3 | [tokens=8, waiting=12] **saturated 1.50** |\n" +
+		"| /data/disk4 | [write=7] |\n" +
+		"| /data/disk5 |  |\n\n"
+	if got := textToMarkdown(pager.String()); got != want {
+		t.Errorf("Metrics column:\n%s\nwant:\n%s", got, want)
+	}
+
+	pager = NewPager(true)
+	if err := writeGrafanaSnapshot(pager, map[string]map[string]interface{}{"0": {"0": nil}}, drives, 2, nil, nil, nil, nil, &Config{}); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot struct {
+		Tables []struct {
+			Name    string
+			Columns []struct{ Text string }
+			Rows    [][]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(pager.String()), &snapshot); err != nil {
+		t.Fatalf("grafana snapshot: %v", err)
+	}
+	ratios := make([]interface{}, 0)
+	for _, table := range snapshot.Tables {
+		if table.Name != "drives" {
+			continue
+		}
+		last := len(table.Columns) - 1
+		if table.Columns[last].Text != "waiting_ratio" {
+			t.Errorf("last drives column = %q, want waiting_ratio", table.Columns[last].Text)
+		}
+		for _, row := range table.Rows {
+			ratios = append(ratios, row[last])
+		}
+	}
+	if want := []interface{}{0.25, 0.75, 1.5, nil, nil}; !reflect.DeepEqual(ratios, want) {
+		t.Errorf("waiting_ratio = %v, want %v", ratios, want)
+	}
+}
+
+func TestServicesFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join(home, "cluster.json")
+	if err := os.WriteFile(file, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"show", file, "--services"}, {"show", "summary", "--services", file}} {
+		config, err := runShow(t, args...)
+		if err != nil || config == nil {
+			t.Errorf("%v: config %+v, error %v", args, config, err)
+			continue
+		}
+		if !config.ShowServices || config.ShowSummary || config.ShowServers || config.ShowSets || config.ShowDisks {
+			t.Errorf("%v: should show only services, got %+v", args, config)
+		}
+	}
+	config, err := runShow(t, "show", "disks", file, "--heal", file, "--preset", "health")
+	if err != nil || config == nil || config.HealFile != file || strings.Join(config.DriveColumns, ",") != "pool,erasure_set,server,disk_path,state,healing,errors,heal_pct" {
+		t.Errorf("--heal: config %+v, error %v", config, err)
+	}
+	config, err = runShow(t, "show", "disks", file, "--metrics-file", file, "--preset", "health")
+	if err != nil || config == nil || config.MetricsFile != file || strings.Join(config.DriveColumns, ",") != "pool,erasure_set,server,disk_path,state,healing,errors" {
+		t.Errorf("--metrics-file: config %+v, error %v", config, err)
+	}
+
+	if config, err := runShow(t, "show", "sets", file, "--set-metrics"); err != nil || config == nil || !config.SetMetrics {
+		t.Errorf("--set-metrics: config %+v, error %v", config, err)
+	}
+
+	config, err = runShow(t, "show", file, "--replication", file)
+	if err != nil || config == nil || config.ReplicationFile != file || !config.ShowSummary {
+		t.Errorf("--replication: config %+v, error %v", config, err)
+	}
+
+	for _, args := range [][]string{
+		{"show", "disks", file, "--services"},
+		{"show", file, "--services", "--server-summary"},
+		{"show", file, "--services", "--replication", file},
+		{"show", file, "--replication", file, "--anonymize"},
+		{"show", file, "--replication", filepath.Join(home, "missing.json")},
+		{"show", file, "--heal", file, "--anonymize"},
+		{"show", "disks", file, "--set-metrics"},
+		{"show", "summary", file, file, "--heal", file},
+		{"show", file, "--metrics-file", file, "--anonymize"},
+		{"show", file, "--metrics-file", filepath.Join(home, "missing.txt")},
+	} {
+		if config, err := runShow(t, args...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestParseReleaseTime(t *testing.T) {
+	want := time.Date(2024, 5, 10, 1, 41, 38, 0, time.UTC)
+	accepted := []string{
+		"RELEASE.2024-05-10T01-41-38Z",
+		"2024-05-10T01:41:38Z",
+		" RELEASE.2024-05-10T01-41-38Z ",
+		"RELEASE.2024-05-10T01-41-38Z.hotfix.7d2c8a5a",
+		"minio version RELEASE.2024-05-10T01-41-38Z (commit-id=abc)",
+	}
+	for _, version := range accepted {
+		got, ok := parseReleaseTime(version)
+		if !ok || !got.Equal(want) {
+			t.Errorf("parseReleaseTime(%q) = %v, %v, want %v", version, got, ok, want)
+		}
+	}
+	if name := releaseName(want); name != "RELEASE.2024-05-10T01-41-38Z" {
+		t.Errorf("releaseName() = %q", name)
+	}
+
+	rejected := []string{
+		"",
+		"   ",
+		"DEVELOPMENT.GOGET",
+		"DEVELOPMENT.2024-05-10T01-41-38Z",
+		"dev",
+		"RELEASE.2024-13-10T01-41-38Z",
+		"RELEASE.2024-05-10",
+	}
+	for _, version := range rejected {
+		if got, ok := parseReleaseTime(version); ok {
+			t.Errorf("parseReleaseTime(%q) = %v, want no release", version, got)
+		}
+	}
+}
+
+// updateGolden rewrites the golden files instead of comparing against them:
+// go test -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// renderGolden runs mdb with args up to the rendered report, collected without
+// colors, and returns the report text
+func renderGolden(t *testing.T, args ...string) string {
+	t.Helper()
+	config, err := runShow(t, args...)
+	if err != nil {
+		t.Fatalf("mdb %s: %v", strings.Join(args, " "), err)
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		t.Fatalf("mdb %s: %v", strings.Join(args, " "), err)
+	}
+	pager := NewPager(true)
+	pager.stripColor = config.ColorMode == colorNever
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatalf("mdb %s: %v", strings.Join(args, " "), err)
+	}
+	return pager.String()
+}
+
+// setClock fixes the clock of mdb at now until the test ends
+func setClock(t *testing.T, now time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestClock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2025, 2, 4, 13, 45, 30, 0, time.UTC)
+	setClock(t, now)
+
+	// Ages and the times mdb records come from the clock
+	taken := now.Add(-(3*24*time.Hour + 4*time.Hour + 12*time.Minute))
+	if got, want := stripANSI(formatSnapshotTaken(taken, timeNow())), "2025-02-01 09:33 UTC (3 days 4 hours 12 minutes 0 seconds ago)"; got != want {
+		t.Errorf("formatSnapshotTaken() = %q, want %q", got, want)
+	}
+	history := recordHealthHistory(ClusterStats{ClusterStats: mdbcore.ClusterStats{DeploymentID: "clock-test"}}, &Config{JSONFile: "cluster.json", HistorySize: 5})
+	if len(history) != 1 || !history[0].RunAt.Equal(now) {
+		t.Errorf("history = %+v, want one run at %s", history, now)
+	}
+	if baseline := newBaseline(nil, mdbcore.NewServerNamer(nil, ""), "clock-test", "cluster.json", taken); !baseline.SavedAt.Equal(now) {
+		t.Errorf("baseline saved at %s, want %s", baseline.SavedAt, now)
+	}
+}
+
+func TestCompactDuration(t *testing.T) {
+	for _, tc := range []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "0s"},
+		{-time.Minute, "0s"},
+		{500 * time.Millisecond, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 5*time.Second, "12m5s"},
+		{time.Hour, "1h"},
+		{4*time.Hour + 12*time.Minute + 5*time.Second, "4h12m"},
+		{3*24*time.Hour + 4*time.Hour + 12*time.Minute + 5*time.Second, "3d4h"},
+		{10 * 24 * time.Hour, "10d"},
+		{400*24*time.Hour + 59*time.Minute, "400d"},
+	} {
+		if got := compactDuration(tc.duration); got != tc.want {
+			t.Errorf("compactDuration(%s) = %q, want %q", tc.duration, got, tc.want)
+		}
+	}
+	// The verbose form stays for sentences
+	if got, want := humanizeDuration(3*24*time.Hour+4*time.Hour+12*time.Minute+5*time.Second), "3 days 4 hours 12 minutes 5 seconds"; got != want {
+		t.Errorf("humanizeDuration() = %q, want %q", got, want)
+	}
+}
+
+func TestEditions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	setClock(t, time.Date(2025, 2, 4, 12, 0, 0, 0, time.UTC))
+	file := filepath.Join("testdata", "healthy.json")
+	args := []string{"summary", "--no-config", "--color", "never", "--history-size", "0", file}
+
+	// Uniform: a rollup without warning
+	got := renderGolden(t, args...)
+	if !strings.Contains(got, "  Edition: AGPLv3 (4 servers)\n") || strings.Contains(got, "MIXED") || strings.Contains(got, "mixed editions") || strings.Contains(got, "License:") {
+		t.Errorf("uniform editions:\n%s", got)
+	}
+
+	// Mixed: one server migrated to the enterprise edition with a license
+	config, err := runShow(t, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := infoStruct.Info.Servers
+	servers[3].Edition = "Enterprise"
+	servers[3].License = &madmin.LicenseInfo{Plan: "ENTERPRISE", Organization: "Acme", ExpiresAt: time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)}
+	servers[2].License = &madmin.LicenseInfo{Plan: "ENTERPRISE", Organization: "Acme", Trial: true, ExpiresAt: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got = stripANSI(pager.String())
+	for _, want := range []string{
+		"  Edition: AGPLv3 (3 servers), Enterprise (1 server) — MIXED\n",
+		"  WARNING   server    ",
+		"mixed editions: AGPLv3 on 3 servers, Enterprise on 1 server, unfinished migration?",
+		"  License: ENTERPRISE for Acme (trial), expired 2025-01-31 (4d ago)\n",
+		"  License: ENTERPRISE for Acme, expires 2025-02-20 (in 15d)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("mixed editions miss %q:\n%s", want, got)
+		}
+	}
+
+	for _, tc := range []struct {
+		expires time.Time
+		color   string
+	}{
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Red},
+		{time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC), Yellow},
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), ""},
+	} {
+		summaries := licenseSummaries([]madmin.ServerProperties{{License: &madmin.LicenseInfo{Plan: "ENTERPRISE", ExpiresAt: tc.expires}}}, timeNow())
+		if len(summaries) != 1 || strings.Contains(summaries[0], Red) != (tc.color == Red) || strings.Contains(summaries[0], Yellow) != (tc.color == Yellow) {
+			t.Errorf("license expiring %s: %q, want color %q", tc.expires, summaries, tc.color)
+		}
+	}
+}
+
+// TestGolden renders every view of the fixture snapshots in testdata and
+// compares the text with testdata/golden, so output changes show up in review
+func TestGolden(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	setClock(t, time.Date(2025, 2, 1, 14, 0, 0, 0, time.UTC))
+
+	fixtures := []string{"healthy", "failed-drives", "offline-server", "multi-pool", "fs-mode", "shared-mount", "old-version"}
+	views := []string{"summary", "servers", "sets", "drives", "failed"}
+	for _, fixture := range fixtures {
+		for _, view := range views {
+			name := fixture + "-" + view
+			t.Run(name, func(t *testing.T) {
+				got := renderGolden(t, view, "--no-config", "--color", "never", "--history-size", "0", filepath.Join("testdata", fixture+".json"))
+				// Run twice to catch output that depends on map iteration order
+				if again := renderGolden(t, view, "--no-config", "--color", "never", "--history-size", "0", filepath.Join("testdata", fixture+".json")); again != got {
+					t.Fatalf("output differs between runs:\n%s\nand:\n%s", got, again)
+				}
+
+				path := filepath.Join("testdata", "golden", name+".golden")
+				if *updateGolden {
+					if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v (run go test -run TestGolden -update to create it)", err)
+				}
+				if got != string(want) {
+					t.Errorf("output differs from %s (run go test -run TestGolden -update if intended):\n%s\nwant:\n%s", path, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	scripts := map[string]string{
+		"bash": generateBashCompletion(),
+		"zsh":  generateZshCompletion(),
+		"fish": generateFishCompletion(),
+	}
+
+	// Every flag and command of the application, including aliases
+	var flags, commands []string
+	var walk func(path string, cmds []cli.Command)
+	walk = func(path string, cmds []cli.Command) {
+		for _, command := range cmds {
+			for _, name := range commandNames(command) {
+				commands = append(commands, path+" "+name)
+				walk(path+" "+name, command.Subcommands)
+			}
+			for _, flag := range command.Flags {
+				flags = append(flags, strings.TrimSpace(strings.Split(flag.GetName(), ",")[0]))
+			}
+		}
+	}
+	app := newApp()
+	for _, flag := range app.Flags {
+		flags = append(flags, flag.GetName())
+	}
+	walk("mdb", app.Commands)
+
+	for _, flag := range flags {
+		if !regexp.MustCompile(`[" |]--` + flag + `[" |)]`).MatchString(scripts["bash"]) {
+			t.Errorf("bash completion does not offer --%s", flag)
+		}
+		if !strings.Contains(scripts["zsh"], "'--"+flag+":") {
+			t.Errorf("zsh completion does not offer --%s", flag)
+		}
+		if !strings.Contains(scripts["fish"], " -l "+flag+" ") {
+			t.Errorf("fish completion does not offer --%s", flag)
+		}
+	}
+	for shell, script := range scripts {
+		for _, command := range commands {
+			if !strings.Contains(script, command) {
+				t.Errorf("%s completion does not know the command %q", shell, command)
+			}
+		}
+		for name, hint := range flagValueHints {
+			for _, value := range hint.Values {
+				if !strings.Contains(script, value) {
+					t.Errorf("%s completion does not offer %s for --%s", shell, value, name)
+				}
+			}
+		}
+		if !strings.Contains(strings.ReplaceAll(script, `\`, ""), "json.gz") {
+			t.Errorf("%s completion does not complete .json.gz snapshots", shell)
+		}
+	}
+
+	// Flags that take a value complete their choices or nothing instead of the next flag
+	if !strings.Contains(scripts["bash"], "--format)\n            COMPREPLY=($(compgen -W \"text markdown html csv grafana\"") {
+		t.Errorf("bash completion does not complete --format values")
+	}
+	if strings.Contains(scripts["bash"], "|--inodes|") || strings.Contains(scripts["bash"], "--errors|") {
+		t.Errorf("bash completion expects a value after --inodes or --errors, whose value is optional")
+	}
+}
+
+func TestFlagDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	t.Setenv("MDB_PAGER", "")
+	file := filepath.Join(home, "cluster.json")
+	if err := os.WriteFile(file, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, "xdg", "mdb", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "trim-domain: .corp.local\ncolor: never\nhistory-size: 5\nbogus: 1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := runShow(t, "show", "sets", file)
+	if err != nil || config == nil {
+		t.Fatalf("defaults file with an unknown key: config %+v, error %v", config, err)
+	}
+	if config.TrimDomain != ".corp.local" || config.ColorMode != colorNever || config.HistorySize != 5 {
+		t.Errorf("defaults file not applied: trim-domain %q, color %q, history-size %d", config.TrimDomain, config.ColorMode, config.HistorySize)
+	}
+
+	t.Setenv("MDB_TRIM_DOMAIN", ".env.local")
+	t.Setenv("MDB_PAGER", "true")
+	config, err = runShow(t, "show", "sets", file)
+	if err != nil || config == nil || config.TrimDomain != ".env.local" || !config.PagerMode {
+		t.Errorf("environment does not override the defaults file: config %+v, error %v", config, err)
+	}
+
+	config, err = runShow(t, "show", "sets", file, "--trim-domain", ".flag.local", "--no-pager")
+	if err != nil || config == nil || config.TrimDomain != ".flag.local" || config.PagerMode || !config.NoPager {
+		t.Errorf("flags do not override the defaults: config %+v, error %v", config, err)
+	}
+
+	for _, noConfig := range []string{"--no-config", "--no-config=true"} {
+		config, err = runShow(t, "show", "sets", file, noConfig)
+		if err != nil || config == nil || config.TrimDomain != "" || config.PagerMode || config.HistorySize == 5 {
+			t.Errorf("%s does not ignore the defaults: config %+v, error %v", noConfig, config, err)
+		}
+	}
+
+	// A bare optional flag takes its default without changing the built-in one
+	t.Setenv("MDB_INODES", "70")
+	if got := strings.Join(expandOptionalFlags([]string{"mdb", "--inodes"}, resolveFlagDefaults(nil)), " "); got != "mdb --inodes=70" || optionalFlagDefaults["inodes"] != "80" {
+		t.Errorf("expandOptionalFlags with MDB_INODES = %q, built-in %s", got, optionalFlagDefaults["inodes"])
+	}
+	if got := strings.Join(expandOptionalFlags([]string{"mdb", "--inodes", "--no-config=true"}, resolveFlagDefaults([]string{"mdb", "--no-config=true"})), " "); got != "mdb --inodes=80 --no-config=true" {
+		t.Errorf("expandOptionalFlags with --no-config=true = %q", got)
+	}
+	t.Setenv("MDB_INODES", "")
+
+	t.Setenv("MDB_HISTORY_SIZE", "many")
+	if config, err := runShow(t, "show", "sets", file); err == nil {
+		t.Errorf("invalid MDB_HISTORY_SIZE: expected error, got config %+v", config)
+	}
+	t.Setenv("MDB_HISTORY_SIZE", "")
+
+	var out strings.Builder
+	if err := printFlagDefaults(&out, resolveFlagDefaults(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Defaults file: "+path+"\n") {
+		t.Errorf("config show output does not name the defaults file:\n%s", out.String())
+	}
+	rows := make(map[string]string)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 && strings.HasPrefix(fields[0], "--") {
+			rows[fields[0]] = fields[1] + " " + fields[2]
+		}
+	}
+	for flag, want := range map[string]string{
+		"--trim-domain":  ".env.local MDB_TRIM_DOMAIN",
+		"--color":        "never " + path,
+		"--format":       "text built-in",
+		"--history-size": "5 " + path,
+	} {
+		if rows[flag] != want {
+			t.Errorf("config show %s = %q, want %q:\n%s", flag, rows[flag], want, out.String())
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("trim-domain: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A malformed defaults file is a warning, not an error of every command
+	config, err = runShow(t, "show", "sets", file)
+	if err != nil || config == nil || config.TrimDomain != ".env.local" {
+		t.Errorf("malformed defaults file: config %+v, error %v", config, err)
+	}
+	if resolved := resolveFlagDefaults(nil); len(resolved.warnings) != 1 || !strings.Contains(resolved.warnings[0], "cannot be parsed") {
+		t.Errorf("malformed defaults file warnings = %q", resolved.warnings)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		units string
+		size  int64
+		want  string
+	}{
+		{unitsIEC, 0, "0 B"},
+		{unitsIEC, 1023, "1023 B"},
+		{unitsIEC, 1024, "1.0 KiB"},
+		{unitsIEC, 1536, "1.5 KiB"},
+		{unitsIEC, 8 << 40, "8.0 TiB"},
+		{unitsIEC, 4505 << 30, "4.4 TiB"},
+		{unitsIEC, 1<<40 - 1<<20, "1.0 TiB"},
+		{unitsIEC, 3 << 50, "3.0 PiB"},
+		{unitsIEC, -(512 << 30), "-512.0 GiB"},
+		{unitsSI, 999, "999 B"},
+		{unitsSI, 1000, "1.0 kB"},
+		{unitsSI, 8 << 40, "8.8 TB"},
+		{unitsSI, 4_000_000_000_000, "4.0 TB"},
+		{unitsSI, 999_960_000_000, "1.0 TB"},
+		{unitsSI, -1_500_000, "-1.5 MB"},
+		{unitsBytes, 8 << 40, "8796093022208 B"},
+		{unitsBytes, -42, "-42 B"},
+	}
+	for _, tc := range tests {
+		if got := formatSize(tc.size, &Config{Units: tc.units}); got != tc.want {
+			t.Errorf("formatSize(%d) with --units %s = %q, want %q", tc.size, tc.units, got, tc.want)
+		}
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "multi-pool.json")
+	for units, want := range map[string]string{
+		unitsIEC:   "  Raw Capacity: 128.0 TiB\n  Usable Capacity: 64.0 TiB\n",
+		unitsSI:    "  Raw Capacity: 140.7 TB\n  Usable Capacity: 70.4 TB\n",
+		unitsBytes: "  Raw Capacity: 140737488355328 B\n  Usable Capacity: 70368744177664 B\n",
+	} {
+		got := renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--units", units, fixture)
+		if !strings.Contains(got, want) {
+			t.Errorf("summary with --units %s does not contain %q:\n%s", units, want, got)
+		}
+	}
+	if config, err := runShow(t, "summary", "--no-config", "--units", "tb", fixture); err == nil {
+		t.Errorf("--units tb: expected error, got config %+v", config)
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	for digits, want := range map[int][]string{
+		0: {"4 TiB", "59%", "1 TiB"},
+		1: {"4.4 TiB", "59.3%", "1.0 TiB"},
+		3: {"4.399 TiB", "59.275%", "1023.999 GiB"},
+	} {
+		config := &Config{Precision: &digits}
+		got := []string{formatSize(4505<<30, config), formatPct(59.2751, config), formatSize(1<<40-1<<20, config)}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("--precision %d: got %q, want %q", digits, got, want)
+		}
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	got := renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--precision", "2", "--exact", fixture)
+	for _, want := range []string{
+		"  Health: 100.00%\n",
+		"  Raw Capacity: 64.00 TiB (70,368,744,177,664 bytes)\n",
+		"  Used Space: 28.48 TiB (31,314,091,159,056 bytes) (59.33%)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary with --precision 2 --exact does not contain %q:\n%s", want, got)
+		}
+	}
+	// Sizes in bytes are exact already
+	got = renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--units", "bytes", "--exact", fixture)
+	if !strings.Contains(got, "  Raw Capacity: 70368744177664 B\n") {
+		t.Errorf("--exact with --units bytes should not repeat the bytes:\n%s", got)
+	}
+
+	for _, value := range []string{"-1", "7"} {
+		if config, err := runShow(t, "summary", "--no-config", "--precision", value, fixture); err == nil {
+			t.Errorf("--precision %s: expected error, got config %+v", value, config)
+		}
+	}
+}
+
+func TestMinFreeBytes(t *testing.T) {
+	// node1 disk1 has 50 GiB free of 1 TiB and node2 disk2 500 GiB of 20 TiB:
+	// 4.9% and 2.4% free, the big drive looks worse by percentage
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers
+	servers[0].Disks[1].TotalSpace, servers[0].Disks[1].UsedSpace, servers[0].Disks[1].AvailableSpace = 1<<40, 1<<40-50<<30, 50<<30
+	servers[1].Disks[0].TotalSpace, servers[1].Disks[0].UsedSpace, servers[1].Disks[0].AvailableSpace = 20<<40, 20<<40-500<<30, 500<<30
+
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, MinFreeBytes: 200 << 30, FreeColorBy: freeColorByPct}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	if !strings.Contains(got, "node1-uuid-1") || strings.Contains(got, "node2-uuid-2") {
+		t.Errorf("--min-free-bytes 200GiB should show node1 disk1 but not node2 disk2:\n%s", got)
+	}
+
+	// By bytes the small drive is red and the big one green; by percentage both are red
+	for _, tc := range []struct {
+		by         string
+		small, big string
+	}{
+		{freeColorByPct, Red, Red},
+		{freeColorByBytes, Red, Green},
+	} {
+		config = &Config{JSONFile: "cluster.json", ShowDisks: true, FreeColorBy: tc.by, MinFreeBytes: 0}
+		pager = NewPager(true)
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		got = pager.String()
+		for name, want := range map[string]string{"50.0 GiB (" + tc.small: "small", "500.0 GiB (" + tc.big: "big"} {
+			if !strings.Contains(got, name) {
+				t.Errorf("--free-color-by %s: free space of the %s drive is not %q", tc.by, want, name)
+			}
+		}
+	}
+	config = &Config{FreeColorBy: freeColorByBytes, CritFreeBytes: 600 << 30}
+	if got := driveFreeColor(DiskInfo{AvailableSpace: 1 << 40}, config); got != Yellow {
+		t.Errorf("1 TiB free below twice --crit-free-bytes 600GiB: got %q, want yellow", got)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	got = renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--min-free-bytes", "1GiB", fixture)
+	if !strings.Contains(got, "No drives found with less than 1.0 GiB free.") {
+		t.Errorf("--min-free-bytes without matching drives:\n%s", got)
+	}
+
+	// The red limit has its own flag, --min-free-bytes only filters
+	for _, tc := range []struct {
+		args []string
+		want int64
+	}{
+		{[]string{"--free-color-by", "bytes"}, defaultCritFreeBytes},
+		{[]string{"--free-color-by", "bytes", "--min-free-bytes", "1TiB"}, defaultCritFreeBytes},
+		{[]string{"--free-color-by", "bytes", "--crit-free-bytes", "600GiB"}, 600 << 30},
+		{[]string{"--crit-free-bytes", "600GiB"}, 0},
+	} {
+		config, err := runShow(t, append([]string{"disks", "--no-config"}, append(tc.args, fixture)...)...)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		if got := configCritFreeBytes(config); got != tc.want {
+			t.Errorf("%v: red limit %d, want %d", tc.args, got, tc.want)
+		}
+	}
+	for _, args := range [][]string{
+		{"--min-free-bytes", "10%"},
+		{"--min-free-bytes", "0"},
+		{"--crit-free-bytes", "1,5TiB"},
+		{"--free-color-by", "inodes"},
+	} {
+		if config, err := runShow(t, append([]string{"disks", "--no-config"}, append(args, fixture)...)...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestSetCapacityColumns(t *testing.T) {
+	// Four drives of 1000 bytes at EC:2: 2000 bytes usable, node2 disk2 has the least free
+	infoStruct := testCluster()
+	infoStruct.Info.Servers[1].Disks[0].AvailableSpace = 100
+	render := func(minSetFree int64) string {
+		pager := NewPager(true)
+		config := &Config{JSONFile: "cluster.json", ShowSets: true, MinSetFree: minSetFree}
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		return pager.String()
+	}
+	got := render(0)
+	if want := "Usable   Free   Servers"; !strings.Contains(stripANSI(got), want) {
+		t.Errorf("erasure sets table misses the %q columns:\n%s", want, got)
+	}
+	// 200 of 2000 bytes free is 10%, below --warn-free
+	if want := "2.0 KiB  " + Yellow + "200 B"; !strings.Contains(got, want) {
+		t.Errorf("erasure set without --min-set-free: want %q in\n%s", want, got)
+	}
+	if want := Red + "200 B"; !strings.Contains(render(1024), want) {
+		t.Errorf("erasure set below --min-set-free 1KiB is not red")
+	}
+	if want := Green + "200 B"; !strings.Contains(render(100), want) {
+		t.Errorf("erasure set above --min-set-free 100 is not green")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	if config, err := runShow(t, "sets", "--no-config", "--min-set-free", "10TiB", fixture); err != nil || config.MinSetFree != 10<<40 {
+		t.Errorf("--min-set-free 10TiB: got %+v, %v", config, err)
+	}
+	if config, err := runShow(t, "sets", "--no-config", "--min-set-free", "ten", fixture); err == nil {
+		t.Errorf("--min-set-free ten: expected error, got config %+v", config)
+	}
+
+	// The drives that report no capacity are left out of the set as of the
+	// cluster, so the single set of uniform drives has the cluster's capacity
+	got = stripANSI(renderGolden(t, "show", "--no-config", "--color", "never", "--history-size", "0", filepath.Join("testdata", "failed-drives.json")))
+	if !strings.Contains(got, "Usable Capacity: 36.0 TiB") || !strings.Contains(got, "10.0%            36.0 TiB  16.2 TiB") {
+		t.Errorf("set and cluster usable capacity differ:\n%s", got)
+	}
+}
+
+func TestUnaccountedSpace(t *testing.T) {
+	for _, tc := range []struct {
+		drive DiskInfo
+		space int64
+		pct   float64
+		ok    bool
+	}{
+		{DiskInfo{TotalSpace: 1000, UsedSpace: 600, AvailableSpace: 400}, 0, 0, true},
+		{DiskInfo{TotalSpace: 1000, UsedSpace: 300, AvailableSpace: 450}, 250, 25, true},
+		// Used and available above the total are not negative unaccounted space
+		{DiskInfo{TotalSpace: 1000, UsedSpace: 700, AvailableSpace: 400}, 0, 0, true},
+		{DiskInfo{State: "offline"}, 0, 0, false},
+	} {
+		space, pct, ok := unaccountedSpace(tc.drive)
+		if space != tc.space || pct != tc.pct || ok != tc.ok {
+			t.Errorf("unaccountedSpace(%+v) = %d, %v, %v, want %d, %v, %v", tc.drive, space, pct, ok, tc.space, tc.pct, tc.ok)
+		}
+	}
+
+	// shared-mount has 2 TiB (25%) unaccounted on node2 /mnt/drive1 and 300 GiB (3.7%) on node3 /mnt/drive2
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "shared-mount.json")
+	got := renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--unaccounted", fixture)
+	want := "Drives with Unaccounted Space > 10% (sorted by unaccounted space)\n" +
+		"================================================================================\n" +
+		"  Server  Disk Path    Pool  Erasure Set  Total Space  Space Used       Free Space       Unaccounted    \n" +
+		"  ------  -----------  ----  -----------  -----------  ---------------  ---------------  ---------------\n" +
+		"  node2   /mnt/drive1  0     0            8.0 TiB      3.4 TiB (43.0%)  2.6 TiB (32.0%)  2.0 TiB (25.0%)\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("--unaccounted misses %q:\n%s", want, got)
+	}
+	got = renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--unaccounted", "--warn-unaccounted", "3", fixture)
+	if strings.Index(got, "node2   /mnt/drive1") > strings.Index(got, "node3   /mnt/drive2") || !strings.Contains(got, "300.0 GiB (3.7%)") {
+		t.Errorf("--unaccounted --warn-unaccounted 3 should list node2 before node3:\n%s", got)
+	}
+	got = renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--unaccounted", filepath.Join("testdata", "healthy.json"))
+	if !strings.Contains(got, "No drives found with unaccounted space above 10%.") {
+		t.Errorf("--unaccounted without unaccounted space:\n%s", got)
+	}
+	got = renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--columns", "server,path,unaccounted", fixture)
+	if !strings.Contains(got, "  node2   /mnt/drive1  2.0 TiB (25.0%)") {
+		t.Errorf("unaccounted column:\n%s", got)
+	}
+
+	if config, err := runShow(t, "disks", "--no-config", "--unaccounted", "--low-space", "10", fixture); err == nil {
+		t.Errorf("--unaccounted with --low-space: expected error, got config %+v", config)
+	}
+}
+
+func TestMissingTopology(t *testing.T) {
+	// old-version has 20 drives on 5 servers without backend, pool or set index
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "old-version.json")
+	note := "Topology information is missing: the drives report no pool or set index, but 20 drives cannot form a single erasure set of at most 16. Erasure sets are not analyzed, drives are listed as reported.\n"
+	got := renderGolden(t, "show", "--no-config", "--color", "never", "--history-size", "0", fixture)
+	if !strings.Contains(got, note) {
+		t.Errorf("summary misses the topology note:\n%s", got)
+	}
+	for _, bogus := range []string{"Erasure Sets", "Pools: 1", "Storage Class Capacity"} {
+		if strings.Contains(got, bogus) {
+			t.Errorf("summary shows %q without topology:\n%s", bogus, got)
+		}
+	}
+	got = renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", fixture)
+	if !strings.Contains(got, "Drives\n") || strings.Contains(got, "Erasure Set  Good Disks") {
+		t.Errorf("sets view without topology:\n%s", got)
+	}
+
+	config, err := runShow(t, "show", "--no-config", "--history-size", "0", "--simulate-loss", "server:node1", fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renderReport(NewPager(true), infoStruct, config); err == nil || !strings.Contains(err.Error(), "the drives report no pool or set index") {
+		t.Errorf("--simulate-loss without topology: error = %v", err)
+	}
+	if parity := findingsParity(infoStruct); parity >= 0 {
+		t.Errorf("findingsParity() = %d, want erasure sets not checked", parity)
+	}
+
+	var out strings.Builder
+	if err := validateFile(&out, fixture, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`WARN  Backend info missing (no field "backend"), usable capacity assumes parity EC:2`,
+		`WARN  Field "pool_index" is absent from 20 of 20 drives`,
+		`WARN  Field "set_index" is absent from 20 of 20 drives`,
+		`WARN  Field "disk_index" is absent from 20 of 20 drives`,
+		"WARN  Topology information is missing: the drives report no pool or set index",
+		"File is usable (5 warnings)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("--validate misses %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "indices are contiguous") {
+		t.Errorf("--validate checks the indices without topology:\n%s", out.String())
+	}
+}
+
+func TestLegend(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config, err := runShow(t, "show", "--no-config", "--color", "never", "--history-size", "0", "--legend", filepath.Join("testdata", "healthy.json"))
+	if err != nil || !config.Legend {
+		t.Fatalf("--legend: config %+v, error %v", config, err)
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pager := NewPager(true)
+	pager.stripColor = true
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := pager.String()
+	for _, want := range []string{
+		"Legend\n" +
+			"  Used:   below 80%  from 80%  from 95%\n" +
+			"  Free:   above 20%  20% or less  5% or less\n" +
+			"  Inodes: below 80%  from 80%  from 95%\n" +
+			"  States: ok  permission-denied  root-mount  unformatted  any other\n\n" +
+			"Sections (in the pager, keys 1-9 jump to the first nine)\n" +
+			"  #  Section                Lines   \n" +
+			"  -  ---------------------  --------\n" +
+			"  1  Problems               3 lines \n" +
+			"  2  Summary                26 lines\n",
+		"\nDetected Erasure Coding Configuration: EC:2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("--legend misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "Sections") > strings.Index(got, "Detected Erasure Coding") {
+		t.Errorf("legend is not above the report:\n%s", got)
+	}
+
+	// The sections point at their titles in the output and are the index entries
+	lines := pager.contentLines()
+	if len(pager.sections) < 5 {
+		t.Fatalf("sections = %+v", pager.sections)
+	}
+	for i, section := range pager.sections {
+		if lines[section.Line] != section.Title {
+			t.Errorf("section %d %q is at line %d: %q", i+1, section.Title, section.Line, lines[section.Line])
+		}
+		if entry := fmt.Sprintf("  %d  %s ", i+1, section.Title); !strings.Contains(got, entry) {
+			t.Errorf("index misses %q", entry)
+		}
+	}
+
+	// Number keys jump to the sections in the pager
+	var model tea.Model = newViewportModel(lines)
+	m := model.(viewportModel)
+	m.sections = pager.sections
+	model = m
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 200, Height: 6})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = model.(viewportModel)
+	if m.offset != pager.sections[1].Line || m.highlight != pager.sections[1].Line || m.status != fmt.Sprintf("Section 2 of %d: Summary", len(pager.sections)) {
+		t.Errorf("key 2: offset %d, highlight %d, status %q, want line %d", m.offset, m.highlight, m.status, pager.sections[1].Line)
+	}
+	if !strings.Contains(m.View(), "Summary") {
+		t.Errorf("key 2 does not show the Summary:\n%s", m.View())
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("9")})
+	if m = model.(viewportModel); !m.statusErr || !strings.Contains(m.status, "sections") {
+		t.Errorf("key 9: status %q", m.status)
+	}
+
+	// The legend is off by default
+	config.Legend = false
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(pager.String(), "Legend") {
+		t.Errorf("legend without --legend:\n%s", pager.String())
+	}
+}
+
+func TestFixedUnit(t *testing.T) {
+	for unit, want := range map[string][]string{
+		"":           {"512.0 MiB", "8.0 TiB"},
+		fixedUnitGiB: {"0.5 GiB", "8192.0 GiB"},
+		fixedUnitTiB: {"0.0 TiB", "8.0 TiB"},
+	} {
+		config := &Config{Units: unitsIEC, FixedUnit: unit}
+		got := []string{formatDriveSize(512<<20, config), formatDriveSize(8<<40, config)}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("--fixed-unit %q: got %q, want %q", unit, got, want)
+		}
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	got := renderGolden(t, "disks", "--no-config", "--color", "never", "--history-size", "0", "--fixed-unit", "gib", fixture)
+	if !strings.Contains(got, "8192.0 GiB") || strings.Contains(got, "TiB") {
+		t.Errorf("drive table with --fixed-unit gib should show every size in GiB:\n%s", got)
+	}
+	// The summary keeps its adaptive units
+	got = renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--fixed-unit", "GiB", fixture)
+	if !strings.Contains(got, "  Raw Capacity: 64.0 TiB\n") {
+		t.Errorf("summary should not follow --fixed-unit:\n%s", got)
+	}
+
+	for _, args := range [][]string{
+		{"--fixed-unit", "PiB"},
+		{"--fixed-unit", "GiB", "--units", "si"},
+		{"--fixed-unit", "TiB", "--units", "bytes"},
+	} {
+		if config, err := runShow(t, append([]string{"disks", "--no-config"}, append(args, fixture)...)...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestFailureGroups(t *testing.T) {
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers
+	servers[0].Endpoint = "minio-r01-c01.corp.net:9000"
+	servers[1].Endpoint = "minio-r02-c01.corp.net:9000"
+	infoStruct.Info.Servers = append(servers,
+		madmin.ServerProperties{State: "offline", Endpoint: "minio-r02-c02.corp.net:9000"},
+		madmin.ServerProperties{State: "online", Endpoint: "gateway.corp.net:9000"})
+
+	pattern := `^minio-(r\d+)`
+	config := &Config{JSONFile: "cluster.json", GroupByPattern: pattern, GroupBy: regexp.MustCompile(pattern)}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		"Failure Domains (--group-by ^minio-(r\\d+))\n" +
+			"  Group  Servers  Offline  Drives  Bad Drives  Raw Capacity  Used     Used %\n" +
+			"  -----  -------  -------  ------  ----------  ------------  -------  ------\n" +
+			"  r01    1        0        2       0           2.0 KiB       1.2 KiB  60.0% \n" +
+			"  r02    2        1        2       1           2.0 KiB       1.2 KiB  60.0% \n" +
+			"  other  1        0        0       0           0 B           0 B      0.0%  \n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+
+	config.Format = formatGrafana
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if want := `["r02",2,1,2,1,2000,1200,60]`; !strings.Contains(strings.Join(strings.Fields(pager.String()), ""), want) {
+		t.Errorf("grafana snapshot misses the groups row %s:\n%s", want, pager.String())
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	for _, value := range []string{"^minio-r\\d+", "(r"} {
+		if config, err := runShow(t, "summary", "--no-config", "--group-by", value, fixture); err == nil {
+			t.Errorf("--group-by %s: expected error, got config %+v", value, config)
+		}
+	}
+}
+
+func TestOfflineServers(t *testing.T) {
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers
+	servers[1].State = "offline"
+	servers[1].Version = "2025-01-20T14:49:07Z"
+	servers[1].Uptime = 7200
+	servers[1].Disks[1].PoolIndex, servers[1].Disks[1].SetIndex = 1, 3
+	infoStruct.Info.Servers = append(servers, madmin.ServerProperties{State: "offline", Endpoint: "node3.example.com:9000"})
+
+	config := &Config{JSONFile: "cluster.json", ShowSummary: true}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	want := "Offline Servers\n" +
+		"  Server  Pools  Drives  Erasure Sets          Version               Uptime\n" +
+		"  ------  -----  ------  --------------------  --------------------  ------\n" +
+		"  node2   0,1    2       pool 0: 0; pool 1: 3  2025-01-20T14:49:07Z  2h    \n" +
+		"  node3   N/A    0       N/A                   N/A                   N/A   \n" +
+		"2 servers offline with 2 drives.\n"
+	got := stripANSI(pager.String())
+	if !strings.Contains(got, want) {
+		t.Errorf("summary misses %q:\n%s", want, got)
+	}
+	if strings.Index(got, "Offline Servers") > strings.Index(got, "Summary\n") {
+		t.Errorf("offline servers are not listed before the summary:\n%s", got)
+	}
+
+	// --offline prints the block alone
+	config = &Config{JSONFile: "cluster.json", ShowOffline: true}
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := stripANSI(pager.String()); !strings.Contains(got, want) || strings.Contains(got, "Summary") {
+		t.Errorf("--offline output:\n%s", got)
+	}
+
+	// The block is omitted from the summary when all servers are online
+	pager = NewPager(true)
+	if err := renderReport(pager, testCluster(), &Config{JSONFile: "cluster.json", ShowSummary: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := pager.String(); strings.Contains(got, "Offline Servers") {
+		t.Errorf("healthy summary lists offline servers:\n%s", got)
+	}
+	pager = NewPager(true)
+	if err := renderReport(pager, testCluster(), config); err != nil {
+		t.Fatal(err)
+	}
+	if got := stripANSI(pager.String()); !strings.Contains(got, "No servers are offline.") {
+		t.Errorf("--offline on a healthy cluster:\n%s", got)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	if config, err := runShow(t, "summary", "--no-config", "--offline", fixture); err != nil {
+		t.Fatal(err)
+	} else if !config.ShowOffline || config.ShowSummary {
+		t.Errorf("--offline: got ShowOffline %v, ShowSummary %v", config.ShowOffline, config.ShowSummary)
+	}
+	if config, err := runShow(t, "summary", "--no-config", "--offline", "--buckets", fixture); err == nil {
+		t.Errorf("--offline --buckets: expected error, got config %+v", config)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	if baseline, err := loadBaseline(path); baseline != nil || err != nil {
+		t.Fatalf("missing baseline: got %+v, %v", baseline, err)
+	}
+
+	// The baseline knows node2 disk3 as ok and node3 as online
+	previous := testCluster()
+	servers := previous.Info.Servers
+	servers[1].Disks[1].State = "ok"
+	servers[1].Disks[1].UUID = "node2-uuid-3"
+	previous.Info.Servers = append(servers, madmin.ServerProperties{State: "online", Endpoint: "node3.example.com:9000"})
+	names := mdbcore.NewServerNamer(previous.Info.Servers, "")
+	saved := newBaseline(previous.Info.Servers, names, "test-deployment", "yesterday.json", time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC))
+	if err := saveBaseline(path, saved); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := loadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := baseline.Drives["node2.example.com|/data/disk3"]; got.State != "ok" || got.UUID != "node2-uuid-3" {
+		t.Errorf("baseline drive node2 disk3 = %+v", got)
+	}
+
+	// Today node2 disk3 is faulty and node3 offline
+	infoStruct := testCluster()
+	infoStruct.Info.Servers = append(infoStruct.Info.Servers, madmin.ServerProperties{State: "offline", Endpoint: "node3.example.com:9000"})
+	config := &Config{JSONFile: "cluster.json", ShowSummary: true, ShowServers: true, ShowDisks: true, BaselinePath: path}
+	applyBaseline(infoStruct, config)
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	// Rendering again, as --interactive does, shows the same changes
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		"server=node2 path=/data/disk3  faulty NEW",
+		"server=node3                                offline NEW",
+		"Since baseline of 2026-10-14 08:00 UTC (yesterday.json): 1 newly failed drive, 1 newly offline server\n",
+		"  node3 NEW  N/A    0       N/A           N/A      N/A   \n",
+		"node2   /data/disk3  faulty NEW",
+		"  N/A   node3   offline NEW  ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report with --baseline misses %q:\n%s", want, got)
+		}
+	}
+
+	// Failures already in the baseline are not new, recoveries are counted
+	names = mdbcore.NewServerNamer(infoStruct.Info.Servers, "")
+	changes := compareBaseline(newBaseline(infoStruct.Info.Servers, names, "test-deployment", "today.json", time.Time{}), previous.Info.Servers, names)
+	if len(changes.NewDrives) != 0 || len(changes.NewServers) != 0 || changes.RecoveredDrives != 1 || changes.RecoveredServers != 1 {
+		t.Errorf("changes back to the previous snapshot = %+v", changes)
+	}
+
+	// A baseline of another deployment is ignored and not overwritten
+	otherPath := filepath.Join(dir, "other.json")
+	if err := saveBaseline(otherPath, &Baseline{Schema: baselineSchema, DeploymentID: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	config = &Config{JSONFile: "cluster.json", ShowSummary: true, BaselinePath: otherPath, UpdateBaseline: true}
+	applyBaseline(infoStruct, config)
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := stripANSI(pager.String()); config.BaselineChanges != nil || strings.Contains(got, "NEW") || strings.Contains(got, "Since baseline") {
+		t.Errorf("baseline of another deployment was used:\n%s", got)
+	}
+	if other, err := loadBaseline(otherPath); err != nil || other.DeploymentID != "other" {
+		t.Errorf("baseline of another deployment was overwritten: %+v, %v", other, err)
+	}
+
+	// --update-baseline writes the current state once, after comparing with the saved one
+	config = &Config{JSONFile: "cluster.json", ShowSummary: true, BaselinePath: path, UpdateBaseline: true}
+	applyBaseline(infoStruct, config)
+	if config.BaselineChanges == nil || !config.BaselineChanges.NewServers["node3"] {
+		t.Errorf("--update-baseline compared with the new state: %+v", config.BaselineChanges)
+	}
+	if updated, err := loadBaseline(path); err != nil || updated.Drives["node2.example.com|/data/disk3"].State != "faulty" || updated.Servers["node3.example.com"] != "offline" {
+		t.Errorf("updated baseline = %+v, %v", updated, err)
+	}
+
+	// Corrupt and outdated files are errors the caller ignores
+	for name, content := range map[string]string{"corrupt": "{", "outdated": `{"schema": 99}`} {
+		file := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if baseline, err := loadBaseline(file); baseline != nil || err == nil {
+			t.Errorf("%s baseline: got %+v, %v", name, baseline, err)
+		}
+	}
+
+	t.Setenv("HOME", dir)
+	fixture := filepath.Join("testdata", "healthy.json")
+	if config, err := runShow(t, "summary", "--no-config", "--update-baseline", fixture); err == nil {
+		t.Errorf("--update-baseline without --baseline: expected error, got config %+v", config)
+	}
+	if config, err := runShow(t, "summary", "--no-config", "--baseline", path, fixture, fixture); err == nil {
+		t.Errorf("--baseline with several files: expected error, got config %+v", config)
+	}
+	config = &Config{JSONFile: fixture, BaselinePath: filepath.Join(dir, "corrupt.json")}
+	applyBaseline(infoStruct, config)
+	if config.BaselineChanges != nil {
+		t.Errorf("corrupt --baseline should be ignored: got %+v", config.BaselineChanges)
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	render := func(config *Config) string {
+		t.Helper()
+		pager := NewPager(true)
+		if err := renderReport(pager, testCluster(), config); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(pager.String())
+	}
+
+	got := render(&Config{JSONFile: "cluster.json", ShowDisks: true, MaxRows: 3})
+	if !strings.Contains(got, "/data/disk2") || strings.Contains(got, "/data/disk3") {
+		t.Errorf("--max-rows 3 does not keep the first 3 drives:\n%s", got)
+	}
+	if want := "  ... 1 more row (use --max-rows=0 or --pager to see all)\n"; !strings.Contains(got, want) {
+		t.Errorf("drives table misses %q:\n%s", want, got)
+	}
+
+	// Truncation follows the sort of the failed table
+	got = render(&Config{JSONFile: "cluster.json", ShowDisks: true, FailedMode: true, MaxRows: 1})
+	if !strings.Contains(got, "/data/disk3") || strings.Contains(got, "more row") {
+		t.Errorf("--failed --max-rows 1:\n%s", got)
+	}
+	if got := render(&Config{JSONFile: "cluster.json", ShowDisks: true, MaxRows: 4}); strings.Contains(got, "more row") {
+		t.Errorf("--max-rows 4 cuts a table of 4 drives:\n%s", got)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--max-rows", "10"}, 10},
+		{[]string{"--max-rows", "10", "--format", "csv"}, 0},
+		{[]string{"--max-rows", "10", "--pager"}, 0},
+	} {
+		config, err := runShow(t, append([]string{"disks", "--no-config", fixture}, tt.args...)...)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if config.MaxRows != tt.want {
+			t.Errorf("%v: got MaxRows %d, want %d", tt.args, config.MaxRows, tt.want)
+		}
+	}
+	if config, err := runShow(t, "disks", "--no-config", "--max-rows", "-1", fixture); err == nil {
+		t.Errorf("--max-rows -1: expected error, got config %+v", config)
+	}
+}
+
+func TestAtRiskDuringHeal(t *testing.T) {
+	tests := []struct {
+		scanning, bad, parity int
+		want                  bool
+	}{
+		{0, 2, 2, false}, // bad drives alone are degraded, not healing
+		{1, 0, 2, false},
+		{1, 1, 2, true},  // scanning+bad == parity
+		{2, 1, 2, true},  // scanning+bad > parity
+		{3, 0, 2, true},  // scanning alone
+		{1, 2, 2, true},  // one more failure loses read quorum
+		{1, 3, 2, false}, // read quorum is lost already
+		{1, 3, 4, true},
+	}
+	for _, tt := range tests {
+		if got := atRiskDuringHeal(tt.scanning, tt.bad, tt.parity); got != tt.want {
+			t.Errorf("atRiskDuringHeal(%d, %d, %d) = %v, want %v", tt.scanning, tt.bad, tt.parity, got, tt.want)
+		}
+	}
+
+	infoStruct := testCluster()
+	disks := infoStruct.Info.Servers[0].Disks
+	disks[0].Healing = true
+	config := &Config{JSONFile: "cluster.json", ShowSummary: true, ShowSets: true}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		"pool=0 set=0                                at risk during heal, 1 scanning and 1 bad drives reach parity EC:2",
+		"  Sets at risk during heal: 1 (scanning and bad drives reach parity EC:2)\n",
+		"  0     0            3           1          1, at risk during heal  ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+
+	// One scanning drive and no bad drive stay below EC:2
+	disks[0].Healing = false
+	disks[1].Healing = true
+	infoStruct.Info.Servers[1].Disks[1].State = "ok"
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := pager.String(); strings.Contains(got, setAtRiskDuringHeal) {
+		t.Errorf("set with 1 scanning drive at EC:2 is at risk:\n%s", got)
+	}
+}
+
+func TestNonErasureBackend(t *testing.T) {
+	infoStruct := testCluster()
+	infoStruct.Info.Backend = madmin.ErasureBackend{Type: madmin.FsType}
+	disks := infoStruct.Info.Servers[1].Disks
+	disks[0].State = "offline"
+	// The drives report less free space than total minus used, e.g. reserved blocks
+	for _, server := range infoStruct.Info.Servers {
+		for i := range server.Disks {
+			server.Disks[i].AvailableSpace = 256
+		}
+	}
+
+	// Without erasure sets, bad drives are reported but no set loses quorum
+	for _, f := range collectFindings(infoStruct.Info.Servers, infoStruct.DrivesBySet(mdbcore.DriveFilter{}), mdbcore.NewServerNamer(infoStruct.Info.Servers, ""), findingsParity(infoStruct), nil, &Config{}) {
+		if f.Category == "set" {
+			t.Errorf("FS snapshot has a set finding: %+v", f)
+		}
+	}
+
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, &Config{JSONFile: "cluster.json", ShowSummary: true, ShowSets: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{"Backend type FS is not erasure coded", "  Backend Type: FS\n", "  Raw Capacity: 3.9 KiB\n", "  Available Space: 1.0 KiB\n", "\nDrives\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Erasure Coding Configuration", "Usable Capacity", "Erasure Sets", "Pool  Erasure Set"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("report of an FS snapshot has %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestByPath(t *testing.T) {
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers
+	// Both servers mount /data/disk0 and /data/disk1, node2's disk1 has failed
+	for i := range servers[1].Disks {
+		servers[1].Disks[i].DrivePath = fmt.Sprintf("/data/disk%d", i)
+	}
+	servers[0].Disks[1].UsedSpace, servers[0].Disks[1].AvailableSpace = 800, 200
+
+	config := &Config{JSONFile: "cluster.json", ByPath: true}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	want := "Drives by Path\n" +
+		"  Path         Drives  Bad Drives  Bad %  Avg Used %\n" +
+		"  -----------  ------  ----------  -----  ----------\n" +
+		"  /data/disk1  2       1           50.0%  70.0%     \n" +
+		"  /data/disk0  2       0           0.0%   60.0%     \n"
+	if got := stripANSI(pager.String()); !strings.Contains(got, want) {
+		t.Errorf("report misses %q:\n%s", want, got)
+	}
+
+	// --path-regex collapses the paths to their capture
+	config.PathPattern = `disk(\d+)$`
+	config.PathRegex = regexp.MustCompile(config.PathPattern)
+	stats := pathStats(infoStruct.DrivesBySet(mdbcore.DriveFilter{}), config.PathRegex)
+	if len(stats) != 2 || stats[0].Path != "1" || stats[1].Path != "0" {
+		t.Errorf("pathStats with %s = %+v", config.PathPattern, stats)
+	}
+
+	config.Format = formatCSV
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Path,Drives,Bad Drives,Bad %,Avg Used %\n1,2,1,50.0%,70.0%\n0,2,0,0.0%,60.0%\n"; !strings.Contains(pager.String(), want) {
+		t.Errorf("CSV misses %q:\n%s", want, pager.String())
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	for _, args := range [][]string{
+		{"--path-regex", "(disk)"},
+		{"--by-path", "--path-regex", "disk"},
+		{"--by-path", "--path-regex", "(disk"},
+	} {
+		if config, err := runShow(t, append([]string{"disks", "--no-config", fixture}, args...)...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestRemoteDrives(t *testing.T) {
+	infoStruct := testCluster()
+	for i := range infoStruct.Info.Servers[0].Disks {
+		infoStruct.Info.Servers[0].Disks[i].Local = true
+	}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, &Config{JSONFile: "cluster.json", ShowSummary: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(pager.String())
+	for _, want := range []string{
+		"server=node2                                2 drives of 2 listed as remote, not reported by the server itself",
+		"  Snapshot perspective: node1 (the only server with local drives; the drives of 1 other server are remote and may lack metrics)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+
+	// Snapshots without local flags, like testCluster, say nothing
+	pager = NewPager(true)
+	if err := renderReport(pager, testCluster(), &Config{JSONFile: "cluster.json", ShowSummary: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := pager.String(); strings.Contains(got, "remote") || strings.Contains(got, "perspective") {
+		t.Errorf("snapshot without local flags reports remote drives:\n%s", got)
+	}
+}
+
+func TestDriveStates(t *testing.T) {
+	infoStruct := testCluster()
+	infoStruct.Info.Servers[0].Disks[1].State = "unformatted"
+
+	config := &Config{JSONFile: "cluster.json", ShowSummary: true, ShowSets: true}
+	pager := NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	raw := pager.String()
+	got := stripANSI(raw)
+	for _, want := range []string{
+		"  Drive States: 2 ok, 1 faulty, 1 unformatted\n",
+		"  0     0            2           2 (1 faulty, 1 unformatted)  ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+	// Recoverable states are yellow, hard failures red
+	if !strings.Contains(raw, Yellow+"1 unformatted"+Reset) || !strings.Contains(raw, Red+"1 faulty"+Reset) {
+		t.Errorf("state colors:\n%q", raw)
+	}
+
+	config = &Config{JSONFile: "cluster.json", ShowDisks: true, States: []string{"unformatted"}, DriveColumns: []string{"server", "disk_path", "state"}}
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	want := "  Server  Disk Path    State      \n" +
+		"  ------  -----------  -----------\n" +
+		"  node1   /data/disk1  unformatted\n\n"
+	if got := stripANSI(pager.String()); !strings.HasSuffix(got, want) {
+		t.Errorf("--state unformatted:\n%s", got)
+	}
+	config.States = []string{"corrupt"}
+	pager = NewPager(true)
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := stripANSI(pager.String()); !strings.Contains(got, "No drives in state corrupt.") {
+		t.Errorf("--state corrupt:\n%s", got)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	config, err := runShow(t, "disks", "--no-config", "--state", "Faulty", "--state", "unformatted", "--state", "faulty", filepath.Join("testdata", "healthy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"faulty", "unformatted"}; !reflect.DeepEqual(config.States, want) {
+		t.Errorf("--state: got %v, want %v", config.States, want)
+	}
+	if _, err := runShow(t, "disks", "--no-config", "--state", "fualty", filepath.Join("testdata", "healthy.json")); err == nil || !strings.Contains(err.Error(), "unsupported --state 'fualty'") {
+		t.Errorf("--state fualty error = %v, want an unsupported state", err)
+	}
+}
+
+func TestColorThresholds(t *testing.T) {
+	tests := []struct {
+		value, warn, crit float64
+		invert            bool
+		want              string
+	}{
+		{79.9, 80, 95, false, severityOK},
+		{80, 80, 95, false, severityWarning},
+		{95, 80, 95, false, severityCritical},
+		{21, 20, 5, true, severityOK},
+		{20, 20, 5, true, severityWarning},
+		{5, 20, 5, true, severityCritical},
+		{0, 20, 5, true, severityCritical},
+	}
+	for _, tc := range tests {
+		if got := severityForPct(tc.value, tc.warn, tc.crit, tc.invert); got != tc.want {
+			t.Errorf("severityForPct(%v, %v, %v, %v) = %q, want %q", tc.value, tc.warn, tc.crit, tc.invert, got, tc.want)
+		}
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join("testdata", "healthy.json")
+	config, err := runShow(t, "sets", "--no-config", "--warn-used", "40", "--crit-used=90%", "--warn-free", "60", fixture)
+	if err != nil || config == nil {
+		t.Fatalf("threshold flags: config %+v, error %v", config, err)
+	}
+	want := pctThresholds{WarnUsed: 40, CritUsed: 90, WarnFree: 60, CritFree: 5, WarnInodes: 80, CritInodes: 95, WarnUnaccounted: 10}
+	if config.Thresholds != want {
+		t.Errorf("thresholds = %+v, want %+v", config.Thresholds, want)
+	}
+	for _, args := range [][]string{
+		{"--warn-used", "96"},
+		{"--crit-free", "30"},
+		{"--warn-inodes", "90", "--crit-inodes", "85"},
+		{"--crit-used", "101"},
+	} {
+		args = append([]string{"sets", "--no-config", fixture}, args...)
+		if config, err := runShow(t, args...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+
+	// The sets average 44.5% used and 55.5% free, 10% of inodes used
+	got := renderGolden(t, "sets", "--no-config", "--format", "markdown", "--history-size", "0", "--warn-used", "40", "--crit-free", "60", "--warn-free", "70", fixture)
+	if !strings.Contains(got, "| **44.5%** | **55.5%** | 10.0% |") {
+		t.Errorf("sets with thresholds:\n%s", got)
+	}
+
+	got = renderGolden(t, "sets", "--no-config", "--format", "grafana", "--history-size", "0", "--warn-used", "40", "--crit-free", "60", "--warn-free", "70", fixture)
+	var snapshot struct {
+		Version int
+		Tables  []struct {
+			Name    string
+			Columns []struct{ Text string }
+			Rows    [][]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &snapshot); err != nil {
+		t.Fatalf("grafana snapshot: %v", err)
+	}
+	severities := make(map[string]interface{})
+	for _, table := range snapshot.Tables {
+		for i, column := range table.Columns {
+			if strings.HasSuffix(column.Text, "_severity") && len(table.Rows) > 0 {
+				severities[table.Name+"."+column.Text] = table.Rows[0][i]
+			}
+		}
+	}
+	wantSeverities := map[string]interface{}{
+		"drives.used_severity":   severityWarning,
+		"drives.free_severity":   severityCritical,
+		"drives.inodes_severity": severityOK,
+		"sets.used_severity":     severityWarning,
+		"sets.free_severity":     severityCritical,
+		"sets.inodes_severity":   severityOK,
+		"pools.used_severity":    severityWarning,
+	}
+	if !reflect.DeepEqual(severities, wantSeverities) {
+		t.Errorf("grafana severities = %v, want %v", severities, wantSeverities)
+	}
+}
+
+func TestDriveOrder(t *testing.T) {
+	drives := []DiskInfo{
+		{Server: "node10", Path: "/data/disk1", DiskIndex: 3},
+		{Server: "node2", Path: "/data/disk10", DiskIndex: 3},
+		{Server: "node2", Path: "/data/disk2", DiskIndex: 3},
+		{Server: "node1", Path: "/data/disk1", DiskIndex: 11},
+		{Server: "node1", Path: "/data/disk1", DiskIndex: 2},
+		{Server: "node1", Path: "/data/disk1", DiskIndex: 10, SetIndex: 1},
+		{Server: "node1", Path: "/data/disk1", DiskIndex: 0, PoolIndex: 1},
+	}
+	sort.Slice(drives, func(i, j int) bool { return driveLess(drives[i], drives[j]) })
+	var got []string
+	for _, drive := range drives {
+		got = append(got, fmt.Sprintf("%d:%d:%d %s%s", drive.PoolIndex, drive.SetIndex, drive.DiskIndex, drive.Server, drive.Path))
+	}
+	want := []string{
+		"0:0:2 node1/data/disk1",
+		"0:0:3 node2/data/disk2",
+		"0:0:3 node2/data/disk10",
+		"0:0:3 node10/data/disk1",
+		"0:0:11 node1/data/disk1",
+		"0:1:10 node1/data/disk1",
+		"1:0:0 node1/data/disk1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drive order:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A set of 12 drives listed out of order renders by numeric disk index
+	props := madmin.ServerProperties{State: "online", Endpoint: "node1.example.com:9000"}
+	for _, idx := range []int{11, 2, 10, 1, 0, 9, 3, 8, 4, 7, 5, 6} {
+		props.Disks = append(props.Disks, madmin.Disk{
+			DrivePath:  fmt.Sprintf("/data/disk%d", idx),
+			State:      "ok",
+			TotalSpace: 1000,
+			DiskIndex:  idx,
+		})
+	}
+	infoStruct := &clusterStruct{Snapshot: mdbcore.Snapshot{Status: "success"}}
+	infoStruct.Info.Servers = []madmin.ServerProperties{props}
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, Format: formatMarkdown, DriveColumns: []string{"disk_index", "disk_path"}}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, line := range strings.Split(textToMarkdown(pager.String()), "\n") {
+		if fields := strings.Split(line, " | "); len(fields) == 2 && strings.HasPrefix(fields[1], "/data/") {
+			got = append(got, strings.TrimPrefix(fields[0], "| ")+" "+strings.TrimSuffix(fields[1], " |"))
+		}
+	}
+	want = nil
+	for idx := 0; idx < 12; idx++ {
+		want = append(want, fmt.Sprintf("%d /data/disk%d", idx, idx))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drives table order:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMissingDiskIndex(t *testing.T) {
+	snapshot, err := mdbcore.Load(strings.NewReader(`{"status":"success","info":{"servers":[{"endpoint":"node1:9000","state":"online","drives":[
+		{"path":"/data/disk1","state":"ok","disk_index":"1","totalspace":1000},
+		{"path":"/data/disk2","state":"ok","totalspace":1000},
+		{"path":"/data/disk3","state":"ok","disk_index":0,"totalspace":1000}
+	]}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := &clusterStruct{Snapshot: *snapshot}
+
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, Format: formatMarkdown, DriveColumns: []string{"disk_index", "disk_path"}}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	want := "| Disk Index | Disk Path |\n" +
+		"| --- | --- |\n" +
+		"| 0 | /data/disk3 |\n" +
+		"| 1 | /data/disk1 |\n" +
+		"| - | /data/disk2 |\n"
+	if got := textToMarkdown(pager.String()); !strings.Contains(got, want) {
+		t.Errorf("drives table:\n%s\nwant:\n%s", got, want)
+	}
+
+	pager = NewPager(true)
+	config = &Config{JSONFile: "cluster.json", Format: formatGrafana}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	var grafana struct {
+		Tables []struct {
+			Name    string
+			Columns []struct{ Text string }
+			Rows    [][]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(pager.String()), &grafana); err != nil {
+		t.Fatalf("grafana snapshot: %v", err)
+	}
+	var indexes []interface{}
+	for _, table := range grafana.Tables {
+		if table.Name != "drives" {
+			continue
+		}
+		if table.Columns[2].Text != "disk_index" {
+			t.Fatalf("drives column 2 = %q, want disk_index", table.Columns[2].Text)
+		}
+		for _, row := range table.Rows {
+			indexes = append(indexes, row[2])
+		}
+	}
+	if want := []interface{}{0.0, 1.0, nil}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("grafana disk_index = %v, want %v", indexes, want)
+	}
+}
+
+func TestEndpointServers(t *testing.T) {
+	snapshot, err := mdbcore.Load(strings.NewReader(`{"status":"success","info":{"servers":[
+		{"endpoint":"10.0.3.15:9000","state":"online","drives":[{"endpoint":"http://10.0.3.15:9000/data/disk1","state":"ok","disk_index":0,"totalspace":1000}]},
+		{"endpoint":"10.0.4.16:9000","state":"online","drives":[{"endpoint":"http://10.0.4.16:9000/data/disk1","state":"ok","disk_index":1,"totalspace":1000}]},
+		{"endpoint":"[fd00::12]:9000","state":"online","drives":[{"endpoint":"https://[fd00::12]:9000/data/disk1","state":"ok","disk_index":2,"totalspace":1000}]},
+		{"endpoint":"node3.example.net:9000","state":"online","drives":[{"endpoint":"node3.example.net:9000/data/disk1","state":"ok","disk_index":3,"totalspace":1000}]}
+	]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := &clusterStruct{Snapshot: *snapshot}
+
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowDisks: true, Format: formatMarkdown, DriveColumns: []string{"server", "disk_path"}}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	want := "| Server | Disk Path |\n" +
+		"| --- | --- |\n" +
+		"| 10.0.3.15 | /data/disk1 |\n" +
+		"| 10.0.4.16 | /data/disk1 |\n" +
+		"| fd00::12 | /data/disk1 |\n" +
+		"| node3 | /data/disk1 |\n"
+	if got := textToMarkdown(pager.String()); !strings.Contains(got, want) {
+		t.Errorf("drives table:\n%s\nwant:\n%s", got, want)
+	}
+
+	pager = NewPager(true)
+	config.TrimDomain = ".example.net"
+	config.FQDN = true
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	if got := textToMarkdown(pager.String()); !strings.Contains(got, "| node3.example.net | /data/disk1 |") || !strings.Contains(got, "| 10.0.3.15 |") {
+		t.Errorf("drives table with --fqdn:\n%s", got)
+	}
+}
+
+func TestDriveUUIDs(t *testing.T) {
+	snapshot, err := mdbcore.Load(strings.NewReader(`{"status":"success","info":{"servers":[
+		{"endpoint":"node1:9000","state":"online","drives":[
+			{"path":"/data/disk1","uuid":"uuid-a","state":"ok","totalspace":1000},
+			{"path":"/data/disk2","uuid":"uuid-b","state":"ok","totalspace":1000},
+			{"path":"/data/disk3","state":"ok","totalspace":1000}
+		]},
+		{"endpoint":"node2:9000","state":"online","drives":[
+			{"path":"/data/disk1","uuid":"uuid-a","state":"ok","totalspace":1000,"set_index":1}
+		]},
+		{"endpoint":"node3:9000","state":"offline","drives":[
+			{"path":"/data/disk1","state":"offline"},
+			{"path":"/data/disk2","uuid":"uuid-b","state":"offline"}
+		]}
+	]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := &clusterStruct{Snapshot: *snapshot}
+
+	pager := NewPager(true)
+	config := &Config{JSONFile: "cluster.json", ShowSummary: true, Consistency: true, Format: formatMarkdown}
+	if err := renderReport(pager, infoStruct, config); err != nil {
+		t.Fatal(err)
+	}
+	got := textToMarkdown(pager.String())
+	for _, want := range []string{
+		"Duplicate UUIDs: **1** (2 drives)",
+		"Drives without UUID: **1**",
+		"| UUID | Server | Path | Pool | Erasure Set | State |\n" +
+			"| --- | --- | --- | --- | --- | --- |\n" +
+			"| **uuid-a** | node1 | /data/disk1 | 0 | 0 | ok |\n" +
+			"| **uuid-a** | node2 | /data/disk1 | 0 | 1 | ok |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "uuid-b") {
+		t.Errorf("drives of offline servers are reported:\n%s", got)
+	}
+	if want := "- **Pool 0 set 1 has 1 drives, expected 5**\n"; !strings.Contains(got, want) {
+		t.Errorf("report misses %q:\n%s", want, got)
+	}
+
+	got = renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--consistency", filepath.Join("testdata", "healthy.json"))
+	if !strings.Contains(got, "Pools, erasure sets and servers are consistent") || !strings.Contains(got, "Every drive has a unique UUID") ||
+		!strings.Contains(got, "Every drive endpoint is on its server") {
+		t.Errorf("healthy cluster is reported inconsistent:\n%s", got)
+	}
+}
+
+func TestSimulateLoss(t *testing.T) {
+	var servers []string
+	for node := 1; node <= 4; node++ {
+		state := func(set int) string {
+			if node == 4 && set == 1 {
+				return "faulty"
+			}
+			return "ok"
+		}
+		servers = append(servers, fmt.Sprintf(`{"endpoint":"node%d.example.net:9000","state":"online","drives":[
+			{"path":"/data/disk1","uuid":"uuid-%d-0","state":"ok","set_index":0},
+			{"path":"/data/disk2","uuid":"uuid-%d-1","state":"%s","set_index":1}]}`, node, node, node, state(1)))
+	}
+	snapshot, err := mdbcore.Load(strings.NewReader(`{"status":"success","info":{"servers":[` + strings.Join(servers, ",") + `]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoStruct := &clusterStruct{Snapshot: *snapshot}
+
+	render := func(targets ...string) (string, error) {
+		config := &Config{JSONFile: "cluster.json", Format: formatMarkdown}
+		for _, value := range targets {
+			target, err := parseLossTarget(value)
+			if err != nil {
+				return "", err
+			}
+			config.SimulateLoss = append(config.SimulateLoss, target)
+		}
+		pager := NewPager(true)
+		err := renderReport(pager, infoStruct, config)
+		return textToMarkdown(pager.String()), err
+	}
+
+	got, err := render("server:node3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Without: server:node3",
+		"| Pool | Erasure Set | Online | State |\n" +
+			"| --- | --- | --- | --- |\n" +
+			"| 0 | 1 | 3/4 | **degraded** |\n",
+		"| Pool | Erasure Set | Online Now | Online After | State After |\n" +
+			"| --- | --- | --- | --- | --- |\n" +
+			"| 0 | 0 | 4/4 | 3/4 | **degraded** |\n" +
+			"| 0 | 1 | 3/4 | 2/4 | **no write quorum** |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("server:node3 misses %q:\n%s", want, got)
+		}
+	}
+
+	// A rack of two servers, one of them by its full host name, and a drive
+	got, err = render("server:node1.example.net", "server:node2", "drive:uuid-3-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "| 0 | 0 | 4/4 | 1/4 | **no read quorum** |\n| 0 | 1 | 3/4 | 1/4 | **no read quorum** |\n"; !strings.Contains(got, want) {
+		t.Errorf("rack outage misses %q:\n%s", want, got)
+	}
+
+	// Bad drives are lost already, losing them again changes nothing
+	got, err = render("drive:uuid-4-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "No erasure set would lose a drive") || strings.Contains(got, "Online After") {
+		t.Errorf("loss of a faulty drive:\n%s", got)
+	}
+
+	for _, value := range []string{"rack:r1", "server:", "node1"} {
+		if _, err := render(value); err == nil || !strings.Contains(err.Error(), "invalid --simulate-loss value") {
+			t.Errorf("--simulate-loss %s: error = %v", value, err)
+		}
+	}
+	if _, err := render("server:node9"); err == nil || !strings.Contains(err.Error(), "matches no server") {
+		t.Errorf("--simulate-loss server:node9: error = %v", err)
+	}
+}
+
+func TestDecommission(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	// Two pools of one set of four drives with EC:2, half of every drive is usable
+	write := func(name string, pool1Used int) string {
+		var servers []string
+		for node := 1; node <= 4; node++ {
+			servers = append(servers, fmt.Sprintf(`{"endpoint":"node%d:9000","state":"online","drives":[
+				{"path":"/data/disk1","state":"ok","pool_index":0,"totalspace":1000,"usedspace":500},
+				{"path":"/data/disk2","state":"ok","pool_index":1,"totalspace":2000,"usedspace":%d}]}`, node, pool1Used))
+		}
+		path := filepath.Join(dir, name)
+		content := `{"status":"success","info":{"backend":{"standardSCParity":2},"servers":[` + strings.Join(servers, ",") + `]}}`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	roomy := write("roomy.json", 200)
+	full := write("full.json", 1900)
+
+	got := renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", "--units", "bytes", "--decommission", "0", roomy)
+	for _, want := range []string{
+		"## Decommission Pool 0",
+		"Data to move: 1000 B (50.0% of 2000 B usable)",
+		"Free on other pools: 3600 B",
+		"| 1 | 4000 B | 400 B | 1000 B | 1400 B (35.0%) |",
+		"The data of pool 0 fits in the other pools",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("roomy cluster misses %q:\n%s", want, got)
+		}
+	}
+
+	got = renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", "--units", "bytes", "--decommission", "0", full)
+	for _, want := range []string{
+		"Does not fit: its 1000 B of data exceed the 200 B free on the other pools",
+		"Does not fit: pool 1 would be 120.0% used, above 95%",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("full cluster misses %q:\n%s", want, got)
+		}
+	}
+
+	// The exit status tells scripts whether the data fits
+	for _, tc := range []struct {
+		file, pool, err string
+	}{
+		{roomy, "0", ""},
+		{roomy, "1", ""},
+		{full, "0", "pool 0 cannot be decommissioned: its 1000 B of data exceed"},
+		{full, "2", "--decommission 2: the snapshot has no pool 2 (pools: 0, 1)"},
+	} {
+		config, err := runShow(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--units", "bytes", "--decommission", tc.pool, "--output", filepath.Join(dir, "report.txt"), tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = processAndDisplay(config)
+		if (err == nil) != (tc.err == "") || err != nil && !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s --decommission %s: error = %v, want %q", filepath.Base(tc.file), tc.pool, err, tc.err)
+		}
+	}
+
+	if _, err := runShow(t, "summary", "--decommission", "first", roomy); err == nil || !strings.Contains(err.Error(), "invalid --decommission value") {
+		t.Errorf("--decommission first: error = %v", err)
+	}
+}
+
+func TestProjection(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	// Pool 0 grows by 20 B of usable space a day, pool 1 shrinks by 8 B
+	snapshot := func(timestamp string, pool0Used, pool1Used int) string {
+		var servers []string
+		for node := 1; node <= 4; node++ {
+			servers = append(servers, fmt.Sprintf(`{"endpoint":"node%d:9000","state":"online","drives":[`+
+				`{"path":"/data/disk1","state":"ok","pool_index":0,"totalspace":1000,"usedspace":%d},`+
+				`{"path":"/data/disk2","state":"ok","pool_index":1,"totalspace":1000,"usedspace":%d}]}`, node, pool0Used, pool1Used))
+		}
+		doc := `{"status":"success","info":{"backend":{"standardSCParity":2},"servers":[` + strings.Join(servers, ",") + `]}`
+		if timestamp != "" {
+			doc += `,"timestamp":"` + timestamp + `"`
+		}
+		return doc + "}"
+	}
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	first := snapshot("2025-01-01T00:00:00Z", 400, 600)
+	last := snapshot("2025-01-11T00:00:00Z", 500, 560)
+	before := write("before.json", first)
+	after := write("after.json", last)
+	series := write("series.ndjson", first, snapshot("2025-01-05T00:00:00Z", 450, 580), last)
+	untimedBefore := write("untimed-before.json", snapshot("", 400, 600))
+	untimedAfter := write("untimed-after.json", snapshot("", 500, 560))
+
+	run := func(args ...string) (string, error) {
+		output := filepath.Join(dir, "report.md")
+		config, err := runShow(t, append([]string{"summary", "--no-config", "--format", "markdown", "--history-size", "0", "--units", "bytes", "--output", output}, args...)...)
+		if err != nil {
+			return "", err
+		}
+		err = processAndDisplay(config)
+		report, _ := os.ReadFile(output)
+		return string(report), err
+	}
+	want := "| Scope | Usable Capacity | Used | Growth | 90% | 100% |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| cluster | 4000 B | 2120 B (53.0%) | +12 B/day | 2025-05-14 (in 124 days) | 2025-06-16 (in 157 days) |\n" +
+		"| pool 0 | 2000 B | 1000 B (50.0%) | +20 B/day | **2025-02-20 (in 40 days)** | **2025-03-02 (in 50 days)** |\n" +
+		"| pool 1 | 2000 B | 1120 B (56.0%) | -8 B/day | no exhaustion projected | no exhaustion projected |\n"
+	for _, args := range [][]string{
+		{"--project", after, before},
+		{"--project", series},
+	} {
+		got, err := run(args...)
+		if err != nil {
+			t.Errorf("%v: %v", args, err)
+		} else if !strings.Contains(got, want) {
+			t.Errorf("%v: projection misses:\n%s\ngot:\n%s", args, want, got)
+		}
+	}
+
+	// Files without a timestamp are dated by their modification time
+	got, err := run("--project", "--interval", "10d", untimedBefore, untimedAfter)
+	if err != nil || !strings.Contains(got, "## Capacity Projection (") || !strings.Contains(got, "10.0 days)") ||
+		!strings.Contains(got, " | +20 B/day | **") || !strings.Contains(got, " (in 40 days)** | **") {
+		t.Errorf("--interval 10d: %v\n%s", err, got)
+	}
+
+	got, err = run("--project", "--project-at", "50,95", before, after)
+	if err != nil || !strings.Contains(got, "## Capacity Projection (2025-01-01 00:00 → 2025-01-11 00:00 UTC, 10.0 days)") ||
+		!strings.Contains(got, "| Growth | 50% | 95% |") || !strings.Contains(got, "| pool 1 | 2000 B | 1120 B (56.0%) | -8 B/day | **reached** |") {
+		t.Errorf("--project-at 50,95: %v\n%s", err, got)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--project", untimedBefore, untimedAfter}, "with --interval (e.g. 7d)"},
+		{[]string{"--project", before}, "--project needs two snapshots"},
+		{[]string{"--project", "--project-at", "90,x", before, after}, "invalid --project-at value"},
+		{[]string{"--interval", "7d", before, after}, "--project-at and --interval need --project"},
+	} {
+		if _, err := run(tc.args...); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%v: error = %v, want %q", tc.args, err, tc.err)
+		}
+	}
+}
+
+func TestILMExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "cluster.json")
+	content := `{"status":"success","info":{"servers":[
+		{"endpoint":"node1.example.net:9000","state":"online","ilm_expiry_in_progress":true,"drives":[{"path":"/data/disk1","state":"ok"}]},
+		{"endpoint":"node2.example.net:9000","state":"online","drives":[{"path":"/data/disk1","state":"ok"}]},
+		{"endpoint":"node3.example.net:9000","state":"online","ilm_expiry_in_progress":true,"drives":[{"path":"/data/disk1","state":"ok"}]},
+		{"endpoint":"node4.example.net:9000","state":"offline","drives":[{"path":"/data/disk1","state":"offline"}]}
+	]}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", file)
+	if want := "- ILM expiry in progress on **2**/3 online servers: node1, node3\n"; !strings.Contains(got, want) {
+		t.Errorf("summary misses %q:\n%s", want, got)
+	}
+
+	for _, args := range [][]string{{"show", file, "--ilm"}, {"summary", "--ilm", file}} {
+		config, err := runShow(t, args...)
+		if err != nil || config == nil || !config.ShowILM || config.ShowSummary || config.ShowServers || config.ShowSets || config.ShowDisks {
+			t.Errorf("%v: should show only ILM expiry, got %+v, error %v", args, config, err)
+		}
+	}
+	got = renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", "--ilm", file)
+	want := "## ILM Expiry\n\n" +
+		"- ILM expiry in progress on **2**/3 online servers: node1, node3\n\n" +
+		"| Server | State | ILM Expiry |\n" +
+		"| --- | --- | --- |\n" +
+		"| node1 | online | **in progress** |\n" +
+		"| node2 | online | idle |\n" +
+		"| node3 | online | **in progress** |\n" +
+		"| node4 | offline | **unknown (offline)** |\n"
+	if !strings.Contains(got, want) || strings.Contains(got, "## Summary") {
+		t.Errorf("--ilm:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := runShow(t, "summary", "--ilm", "--services", file); err == nil || !strings.Contains(err.Error(), "--ilm cannot be used with --services") {
+		t.Errorf("--ilm --services: error = %v", err)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	var buckets []string
+	for i := 1; i <= 12; i++ {
+		buckets = append(buckets, fmt.Sprintf(`"bucket%d":{"size":%d,"objectsCount":%d,"versionsCount":%d,"deleteMarkersCount":1}`, i, i*1024*1024, i, i*2))
+	}
+	withBuckets := filepath.Join(dir, "buckets.json")
+	content := `{"status":"success","info":{"bucketsUsageInfo":{` + strings.Join(buckets, ",") + `},"servers":[
+		{"endpoint":"node1.example.net:9000","state":"online","drives":[{"path":"/data/disk1","state":"ok"}]}
+	]}}`
+	if err := os.WriteFile(withBuckets, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	without := filepath.Join(dir, "plain.json")
+	content = `{"status":"success","info":{"servers":[
+		{"endpoint":"node1.example.net:9000","state":"online","drives":[{"path":"/data/disk1","state":"ok"}]}
+	]}}`
+	if err := os.WriteFile(without, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", withBuckets)
+	want := "## Top Buckets (10 of 12)\n\n" +
+		"| Bucket | Size | Objects | Versions | Delete Markers |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| bucket12 | 12.0 MiB | 12 | 24 | 1 |\n"
+	if !strings.Contains(got, want) || strings.Contains(got, "| bucket2 |") {
+		t.Errorf("summary:\n%s\nwant:\n%s", got, want)
+	}
+	if got := renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", without); strings.Contains(got, "Buckets") {
+		t.Errorf("summary without per-bucket usage shows buckets:\n%s", got)
+	}
+
+	config, err := runShow(t, "show", withBuckets, "--buckets")
+	if err != nil || config == nil || !config.ShowBuckets || config.ShowSummary || config.ShowServers || config.ShowSets || config.ShowDisks {
+		t.Errorf("--buckets should show only buckets, got %+v, error %v", config, err)
+	}
+	got = renderGolden(t, "summary", "--no-config", "--format", "markdown", "--history-size", "0", "--buckets", withBuckets)
+	if !strings.Contains(got, "## Buckets\n") || !strings.Contains(got, "| bucket1 | 1.0 MiB | 1 | 2 | 1 |\n") || strings.Contains(got, "## Summary") {
+		t.Errorf("--buckets:\n%s", got)
+	}
+	got = renderGolden(t, "summary", "--no-config", "--history-size", "0", "--buckets", without)
+	if !strings.Contains(got, "No per-bucket usage in this snapshot.") {
+		t.Errorf("--buckets without per-bucket usage:\n%s", got)
+	}
+	if _, err := runShow(t, "summary", "--buckets", "--ilm", withBuckets); err == nil || !strings.Contains(err.Error(), "--buckets cannot be used with") {
+		t.Errorf("--buckets --ilm: error = %v", err)
+	}
+
+	infoStruct, err := loadJSON(withBuckets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newAnonymizer().apply(withBuckets, infoStruct)
+	if bucket, ok := infoStruct.BucketsUsage["bucket-01"]; !ok || bucket.Size != 12*1024*1024 || len(infoStruct.BucketsUsage) != 12 {
+		t.Errorf("anonymized buckets = %v", infoStruct.BucketsUsage)
+	}
+
+	got = renderGolden(t, "summary", "--no-config", "--format", "grafana", "--history-size", "0", withBuckets)
+	var snapshot grafanaSnapshot
+	if err := json.Unmarshal([]byte(got), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	last := snapshot.Tables[len(snapshot.Tables)-1]
+	if last.Name != "buckets" || len(last.Rows) != 12 || last.Rows[0][0] != "bucket12" {
+		t.Errorf("Grafana buckets table = %+v", last)
+	}
+}
+
+func TestSetsOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join("testdata", "multi-pool.json")
+	// The sets of pool 1 are fuller, set 1 of each pool the fullest
+	rows := func(got string) string {
+		var keys []string
+		for _, line := range strings.Split(got, "\n") {
+			if fields := strings.Fields(line); len(fields) > 2 && fields[2] == "4" {
+				keys = append(keys, fields[0]+":"+fields[1])
+			}
+		}
+		return strings.Join(keys, " ")
+	}
+
+	got := renderGolden(t, "show", "--no-config", "--color", "never", "--history-size", "0", "--sets-only", file)
+	if !strings.HasPrefix(got, "Erasure Sets\n") || strings.Contains(got, "Snapshot taken") || strings.Count(got, "\n\n") != 1 {
+		t.Errorf("--sets-only should print the erasure sets table alone:\n%s", got)
+	}
+	if order := rows(got); order != "1:1 1:0 0:1 0:0" {
+		t.Errorf("--sets-only order = %s:\n%s", order, got)
+	}
+	if got := renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--sets-only", file); rows(got) != "1:1 1:0 0:1 0:0" || strings.Contains(got, "Summary") {
+		t.Errorf("summary --sets-only should print the erasure sets table alone:\n%s", got)
+	}
+	got = renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", "--sets-sort", "risk", file)
+	if order := rows(got); !strings.HasPrefix(got, "Detected Erasure Coding") || order != "1:1 1:0 0:1 0:0" {
+		t.Errorf("sets --sets-sort risk order = %s:\n%s", order, got)
+	}
+	if order := rows(renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", file)); order != "0:0 0:1 1:0 1:1" {
+		t.Errorf("sets order = %s", order)
+	}
+
+	for _, args := range [][]string{
+		{"show", file, "--sets-only", "--tree"},
+		{"show", file, "--sets-only", "--server-capacity"},
+		{"show", file, "--sets-only", "--sort-by", "used"},
+		{"servers", file, "--sort-by", "risk"},
+		{"show", file, "--server-capacity", "--sort-by", "risk"},
+		{"sets", file, "--sort-by", "risk"},
+		{"sets", file, "--sets-sort", "used"},
+		{"show", file, "--server-capacity", "--sets-sort", "risk"},
+	} {
+		if config, err := runShow(t, args...); err == nil {
+			t.Errorf("%v: expected error, got config %+v", args, config)
+		}
+	}
+}
+
+func TestUsageBars(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join("testdata", "multi-pool.json")
+
+	got := renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", "--bars", file)
+	if want := "[███████████░░░░░░░░░] 55.0%"; !strings.Contains(got, want) {
+		t.Errorf("--bars misses %q:\n%s", want, got)
+	}
+	got = renderGolden(t, "show", "--no-config", "--color", "never", "--history-size", "0", "--bars", "--ascii", "--decommission", "1", file)
+	for _, want := range []string{"[######--------------] 30.0%", "28.8 TiB [##################--] 90.0%"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("--bars --ascii misses %q:\n%s", want, got)
+		}
+	}
+	if got := renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", file); strings.Contains(got, "[") {
+		t.Errorf("sets without --bars draws bars:\n%s", got)
+	}
+	got = renderGolden(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--bars", "--ascii", file)
+	for _, pool := range []string{"0", "1"} {
+		if !regexp.MustCompile(`(?m)^\s*` + pool + `\s.*\[[#-]{20}\] \d+\.\d%`).MatchString(got) {
+			t.Errorf("summary --bars misses the bar of pool %s:\n%s", pool, got)
+		}
+	}
+
+	config := &Config{Format: formatText, ASCII: true}
+	for pct, want := range map[float64]string{-5: "[--------------------]", 0: "[--------------------]", 100: "[####################]", 130: "[####################]"} {
+		if got := stripANSI(usageBar(pct, config)); !strings.HasPrefix(got, want) {
+			t.Errorf("usageBar(%v) = %q, want prefix %q", pct, got, want)
+		}
+	}
+}
+
+func TestPoolTree(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join("testdata", "failed-drives.json")
+
+	got := renderGolden(t, "sets", "--no-config", "--color", "never", "--history-size", "0", "--tree", file)
+	want := "Pool Tree\n" +
+		"  Pool 0: 1 set, 4 servers, 8 drives, 2 bad\n" +
+		"  └── Set 0: 4 servers, 8 drives, 2 bad\n" +
+		"      ├── node1: 2 drives, 1 bad\n" +
+		"      ├── node2: 2 drives\n" +
+		"      ├── node3: 2 drives, 1 bad\n" +
+		"      └── node4: 2 drives\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("--tree:\n%s\nwant:\n%s", got, want)
+	}
+
+	config, err := runShow(t, "sets", "--tree-drives", file)
+	if err != nil || !config.Tree || !config.TreeDrives {
+		t.Errorf("--tree-drives should imply --tree, got %+v, error %v", config, err)
+	}
+	got = renderGolden(t, "sets", "--no-config", "--format", "markdown", "--history-size", "0", "--tree-drives", "--ascii", file)
+	want = "## Pool Tree\n\n" +
+		"```\n" +
+		"Pool 0: 1 set, 4 servers, 8 drives, 2 bad\n" +
+		"`-- Set 0: 4 servers, 8 drives, 2 bad\n" +
+		"    |-- node1: 2 drives, 1 bad\n" +
+		"    |   |-- /mnt/drive1 ok\n" +
+		"    |   `-- /mnt/drive2 faulty\n"
+	if !strings.Contains(got, want) || !strings.Contains(got, "    `-- node4: 2 drives\n        |-- /mnt/drive1 ok\n        `-- /mnt/drive2 ok\n```\n") {
+		t.Errorf("--tree-drives --ascii markdown:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := renderGolden(t, "sets", "--no-config", "--history-size", "0", file); strings.Contains(got, "Pool Tree") {
+		t.Errorf("sets without --tree shows the tree:\n%s", got)
+	}
+}
+
+func TestPagerFilter(t *testing.T) {
+	lines := []string{
+		Bold + "Servers" + Reset,
+		"  Server  State",
+		"  ------  -------",
+		"  node1   " + Green + "online" + Reset,
+		"  node2   " + Red + "offline" + Reset,
+		"",
+		Bold + "Drives" + Reset,
+		"  Server  Path         State",
+		"  ------  -----------  -------",
+		"  node1   /mnt/drive1  ok",
+		"  node2   /mnt/drive1  " + Red + "OFFLINE" + Reset,
+		"  node2   /mnt/drive2  ok",
+		"",
+		"  Note: node2 was restarted",
+	}
+	sections := []pagerSection{{Title: "Servers", Line: 0}, {Title: "Drives", Line: 6}}
+	got := stripANSI(strings.Join(filterReportLines(lines, sections, "Offline"), "\n"))
+	want := "Servers\n  Server  State\n  ------  -------\n  node2   offline\n" +
+		"Drives\n  Server  Path         State\n  ------  -----------  -------\n  node2   /mnt/drive1  OFFLINE"
+	if got != want {
+		t.Errorf("filterReportLines(offline):\n%s\nwant:\n%s", got, want)
+	}
+	// Titles come from the sections, so reports without colors keep them
+	plain := make([]string, len(lines))
+	for i, line := range lines {
+		plain[i] = stripANSI(line)
+	}
+	if got := strings.Join(filterReportLines(plain, sections, "Offline"), "\n"); got != want {
+		t.Errorf("filterReportLines without colors:\n%s\nwant:\n%s", got, want)
+	}
+	// A matching header or title is shown once
+	got = strings.Join(filterReportLines(plain, sections, "state"), "\n")
+	want = "Servers\n  Server  State\n  ------  -------\n" +
+		"Drives\n  Server  Path         State\n  ------  -----------  -------"
+	if got != want {
+		t.Errorf("filterReportLines(state):\n%s\nwant:\n%s", got, want)
+	}
+	if got = strings.Join(filterReportLines(plain, sections, "drives"), "\n"); got != "Drives" {
+		t.Errorf("filterReportLines(drives) = %q", got)
+	}
+
+	pagerModel := newViewportModel(lines)
+	pagerModel.sections = sections
+	var model tea.Model = pagerModel
+	send := func(msgs ...tea.Msg) viewportModel {
+		t.Helper()
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(viewportModel)
+	}
+	runes := func(s string) tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	m := send(tea.WindowSizeMsg{Width: 80, Height: 5}, tea.KeyMsg{Type: tea.KeyPgDown})
+	m = send(runes("f"), runes("NODE2"), runes("j"))
+	if !m.filtering || m.query != "NODE2j" || !strings.Contains(m.View(), "Filter: NODE2j") {
+		t.Fatalf("filter prompt: filtering %v, query %q", m.filtering, m.query)
+	}
+	m = send(tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filter != "NODE2" || m.offset != 0 || len(m.lines) != 10 || len(m.problems) != 2 {
+		t.Errorf("filter NODE2: filter %q, offset %d, %d lines, problems %v", m.filter, m.offset, len(m.lines), m.problems)
+	}
+	if !strings.Contains(m.View(), `Filter "NODE2": 10 of 14 lines`) {
+		t.Errorf("help line misses the filter:\n%s", m.View())
+	}
+
+	m = send(runes("f"), runes("nothing"), tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filter != "NODE2" || !strings.Contains(m.status, "No lines match") {
+		t.Errorf("filter without match: filter %q, status %q", m.filter, m.status)
+	}
+	m = send(runes("F"))
+	if m.filter != "" || len(m.lines) != len(lines) || m.offset != 0 {
+		t.Errorf("F: filter %q, %d lines, offset %d", m.filter, len(m.lines), m.offset)
+	}
+}
+
+func TestSetErrorRecency(t *testing.T) {
+	age := func(d time.Duration) *time.Duration { return &d }
+	drive := func(availability, timeout uint64, lastError, lastTimeout *time.Duration) DiskInfo {
+		return DiskInfo{
+			Metrics:        &madmin.DiskMetrics{TotalErrorsAvailability: availability, TotalErrorsTimeout: timeout},
+			LastErrorAge:   lastError,
+			LastTimeoutAge: lastTimeout,
+		}
+	}
+	tests := []struct {
+		name   string
+		drives []DiskInfo
+		want   string
+	}{
+		{"no errors", []DiskInfo{drive(0, 0, nil, nil), {}}, ""},
+		{"recent", []DiskInfo{drive(3, 0, age(10*time.Minute), nil)}, "errors in last hour"},
+		{"historical", []DiskInfo{drive(3, 0, age(3*time.Hour), nil)}, "errors historical, none in last hour"},
+		{"recent timeouts only", []DiskInfo{drive(0, 2, nil, age(5*time.Minute))}, "errors in last hour"},
+		{"historical timeouts only", []DiskInfo{drive(0, 2, nil, age(2*time.Hour))}, "errors historical, none in last hour"},
+		{"a recent timeout on another drive", []DiskInfo{drive(3, 0, age(3*time.Hour), nil), drive(0, 1, nil, age(time.Minute))}, "errors in last hour"},
+		{"a timeout without timestamp", []DiskInfo{drive(3, 0, age(3*time.Hour), nil), drive(0, 1, nil, nil)}, ""},
+	}
+	for _, tc := range tests {
+		got := stripANSI(setErrorRecency(tc.drives))
+		if (tc.want == "" && got != "") || !strings.HasPrefix(got, tc.want) {
+			t.Errorf("%s: setErrorRecency() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProblemLines(t *testing.T) {
+	tests := []struct {
+		line    string
+		red     bool
+		problem bool
+	}{
+		{"node1  /data/disk1  ok", false, false},
+		{"Errors: " + Red + "5" + Reset, true, true},
+		{"Errors: " + Red + "0" + Reset, false, false},
+		{"Errors: " + Red + " 0 " + Reset, false, false},
+		{Red + "0" + Reset + " healing, " + Red + "3" + Reset + " bad", true, true},
+		{"Free: " + Yellow + "12.0%" + Reset, false, false},
+		{"node2  " + Red + "0" + Reset + "  OFFLINE", false, true},
+		{"/data/disk3  unformatted", false, true},
+	}
+	lines := make([]string, len(tests))
+	var want []int
+	for i, tc := range tests {
+		if got := hasRedValue(tc.line); got != tc.red {
+			t.Errorf("hasRedValue(%q) = %v, want %v", tc.line, got, tc.red)
+		}
+		lines[i] = tc.line
+		if tc.problem {
+			want = append(want, i)
+		}
+	}
+	if got := indexProblemLines(lines); !slices.Equal(got, want) {
+		t.Errorf("indexProblemLines() = %v, want %v", got, want)
+	}
+}
+
+func TestJumpToProblem(t *testing.T) {
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	// Problems on lines 5, 12 and 25 counted from 0, and a red zero count that is none
+	lines[5] = "node1  " + Red + "offline" + Reset
+	lines[12] = "Errors: " + Red + "4" + Reset
+	lines[20] = "Errors: " + Red + "0" + Reset
+	lines[25] = "/data/disk7  faulty"
+
+	tests := []struct {
+		name   string
+		lines  []string
+		keys   string
+		want   int
+		status string
+	}{
+		{"first from the top", lines, "e", 5, "Problem 1 of 3"},
+		{"n is e", lines, "nn", 12, "Problem 2 of 3"},
+		{"wrap at the end", lines, "eeee", 5, "Problem 1 of 3"},
+		{"wrap at the start", lines, "E", 25, "Problem 3 of 3"},
+		{"back from the first", lines, "eE", 25, "Problem 3 of 3"},
+		{"p is E", lines, "eep", 5, "Problem 1 of 3"},
+		{"from the visible page", lines, "Ge", 25, "Problem 3 of 3"},
+		{"no problems", lines[:5], "e", -1, "No problem lines"},
+		{"no problems backwards", lines[:5], "E", -1, "No problem lines"},
+	}
+	for _, tc := range tests {
+		var model tea.Model = newViewportModel(tc.lines)
+		model, _ = model.Update(tea.WindowSizeMsg{Width: 80, Height: 6})
+		for _, key := range tc.keys {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+		}
+		m := model.(viewportModel)
+		if m.current != tc.want || m.status != tc.status {
+			t.Errorf("%s: %q jumps to line %d with status %q, want line %d with %q", tc.name, tc.keys, m.current, m.status, tc.want, tc.status)
+		}
+		if tc.want >= 0 && (m.highlight != tc.want || tc.want < m.offset || tc.want >= m.offset+m.viewport.Height) {
+			t.Errorf("%s: line %d should be highlighted on screen, highlight %d, offset %d", tc.name, tc.want, m.highlight, m.offset)
+		}
+	}
+}
+
+func TestPagerMouseAndPosition(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	var model tea.Model = newViewportModel(lines)
+	send := func(msgs ...tea.Msg) viewportModel {
+		t.Helper()
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(viewportModel)
+	}
+	wheel := func(button tea.MouseButton) tea.Msg {
+		return tea.MouseMsg{Button: button, Action: tea.MouseActionPress}
+	}
+
+	m := send(tea.WindowSizeMsg{Width: 200, Height: 11})
+	if got := m.position(); got != "10%  10/100" {
+		t.Errorf("position() at the top = %q", got)
+	}
+	m = send(wheel(tea.MouseButtonWheelDown), wheel(tea.MouseButtonWheelDown))
+	if m.offset != 2*mouseWheelLines || !strings.HasSuffix(m.View(), "16%  16/100") {
+		t.Errorf("wheel down twice: offset %d, view ends %q", m.offset, m.View()[len(m.View())-20:])
+	}
+	m = send(wheel(tea.MouseButtonWheelUp), tea.MouseMsg{Button: tea.MouseButtonWheelUp, Action: tea.MouseActionRelease})
+	if m.offset != mouseWheelLines {
+		t.Errorf("wheel up: offset %d, want %d", m.offset, mouseWheelLines)
+	}
+	m = send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	if got := m.position(); got != "100%  100/100" {
+		t.Errorf("position() at the bottom = %q", got)
+	}
+
+	config, err := runShow(t, "show", "--no-mouse", filepath.Join("testdata", "healthy.json"))
+	if err != nil || !config.NoMouse {
+		t.Errorf("--no-mouse: config %+v, error %v", config, err)
+	}
+}
+
+func TestPagerSave(t *testing.T) {
+	dir := t.TempDir()
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%sline %d%s", Red, i+1, Reset)
+	}
+	var model tea.Model = newViewportModel(lines)
+	var cmd tea.Cmd
+	send := func(msgs ...tea.Msg) viewportModel {
+		t.Helper()
+		for _, msg := range msgs {
+			model, cmd = model.Update(msg)
+		}
+		return model.(viewportModel)
+	}
+	runes := func(s string) tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	m := send(tea.WindowSizeMsg{Width: 200, Height: 6})
+	if !strings.Contains(m.View(), "s: save") {
+		t.Errorf("help line misses the save key:\n%s", m.View())
+	}
+
+	// Scroll and quit keys are typed into the prompt
+	path := filepath.Join(dir, "report.txt")
+	m = send(runes("s"), runes(path), runes("j"), runes("q"), tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace})
+	if !m.saving || m.offset != 0 || m.filename != path || !strings.Contains(m.View(), "Save to: "+path) {
+		t.Fatalf("save prompt: saving %v, offset %d, filename %q", m.saving, m.offset, m.filename)
+	}
+	m = send(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.saving || m.statusErr || m.status != "Saved to "+path || !strings.Contains(m.View(), "Saved to "+path) {
+		t.Errorf("after saving: saving %v, status %q", m.saving, m.status)
+	}
+	want := ""
+	for i := range lines {
+		want += fmt.Sprintf("line %d\n", i+1)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != want {
+		t.Errorf("saved report = %q, %v, want the whole report without colors", got, err)
+	}
+
+	// The confirmation goes away with its timer
+	if cmd == nil {
+		t.Fatal("saving did not start the status timer")
+	}
+	m = send(clearStatusMsg{id: m.statusID})
+	if m.status != "" || !strings.Contains(m.View(), "s: save") {
+		t.Errorf("status after its timer: %q", m.status)
+	}
+
+	m = send(runes("s"), runes(filepath.Join(dir, "missing", "report.txt")), tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.statusErr || !strings.Contains(m.status, "failed to save report") {
+		t.Errorf("saving into a missing directory: status %q, error %v", m.status, m.statusErr)
+	}
+	m = send(runes("s"), runes(filepath.Join(dir, "cancelled.txt")), tea.KeyMsg{Type: tea.KeyEsc}, runes("j"))
+	if m.saving || m.offset != 1 {
+		t.Errorf("esc: saving %v, offset %d", m.saving, m.offset)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cancelled.txt")); !os.IsNotExist(err) {
+		t.Errorf("a cancelled save wrote the report: %v", err)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	out := stripANSI(renderGolden(t, "show", "testdata/failed-drives.json", "--grep", "NODE3"))
+	if !strings.Contains(out, `Row filter: --grep "NODE3" (the summary is not filtered)`) || !strings.Contains(out, "Total Disks: 8") {
+		t.Errorf("summary should note the pattern and stay unfiltered:\n%s", out)
+	}
+	servers := out[strings.Index(out, "\nServers\n"):strings.Index(out, "Server Health Summary")]
+	if !strings.Contains(servers, "node3") || strings.Contains(servers, "node1") {
+		t.Errorf("servers table should only have node3:\n%s", servers)
+	}
+	// Sets are computed from the matching drives, the heal risk from all drives of the set
+	if !strings.Contains(out, "  0     0            1           1          0, at risk during heal ") {
+		t.Errorf("erasure set should count the two drives of node3:\n%s", out)
+	}
+
+	drives := func(args ...string) []string {
+		out := stripANSI(renderGolden(t, append([]string{"show", "disks", "testdata/failed-drives.json", "--columns", "server,path"}, args...)...))
+		var paths []string
+		for _, line := range strings.Split(out, "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 && strings.HasPrefix(fields[1], "/") {
+				paths = append(paths, fields[0]+":"+fields[1])
+			}
+		}
+		return paths
+	}
+	// Columns that are not shown match too, and the full UUID
+	if got := drives("--grep", "faulty"); !reflect.DeepEqual(got, []string{"node1:/mnt/drive2"}) {
+		t.Errorf("--grep faulty = %v", got)
+	}
+	if got := drives("--grep", "node4:/mnt/drive1|node2.*drive2", "--grep-regex"); len(got) != 0 {
+		t.Errorf("regex matches cells, not whole lines, got %v", got)
+	}
+	if got := drives("--grep", "^/mnt/drive2$", "--grep-regex"); len(got) != 4 {
+		t.Errorf("--grep-regex ^/mnt/drive2$ = %v", got)
+	}
+	if got := drives("--grep", "zzz"); len(got) != 0 {
+		t.Errorf("--grep zzz = %v", got)
+	}
+	if out := renderGolden(t, "show", "disks", "testdata/failed-drives.json", "--grep", "zzz"); !strings.Contains(out, `No drives match --grep "zzz".`) {
+		t.Errorf("no matching drives should be reported:\n%s", out)
+	}
+
+	if _, err := runShow(t, "show", "testdata/failed-drives.json", "--grep", "(", "--grep-regex"); err == nil || !strings.Contains(err.Error(), "invalid --grep pattern") {
+		t.Errorf("invalid regex error = %v", err)
+	}
+	if config, err := runShow(t, "show", "testdata/failed-drives.json", "--grep", "a.b"); err != nil || config.Grep.MatchString("axb") {
+		t.Errorf("--grep without --grep-regex should match a substring, got %v", err)
+	}
+	if _, err := runShow(t, "show", "testdata/failed-drives.json", "--grep-regex"); err == nil {
+		t.Error("--grep-regex without --grep should fail")
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	headers := []string{"Server", "Path", "State", "Model"}
+	rows := [][]string{
+		{"node1", "/mnt/very/long/drive/path1", Green + "ok" + Reset, "SAMSUNG MZQL2"},
+		{"node2", "/mnt/d2", Red + "faulty" + Reset, "-"},
+	}
+	render := func(width int) string {
+		pager := NewPager(true)
+		printTableRows(pager, &Config{Format: formatText, Width: width}, headers, rows)
+		return pager.String()
+	}
+
+	if got := render(0); !strings.Contains(got, "/mnt/very/long/drive/path1") || !strings.Contains(got, "SAMSUNG MZQL2") {
+		t.Errorf("without a width the table should not be narrowed:\n%s", got)
+	}
+
+	// The path is narrowed first, the other columns are already at their minimum
+	want := "  Server  Path                 State   Model        \n" +
+		"  ------  -------------------  ------  -------------\n" +
+		"  node1   /mnt/very/long/d...  ok      SAMSUNG MZQL2\n" +
+		"  node2   /mnt/d2              faulty  -            \n"
+	if got := stripANSI(render(52)); got != want {
+		t.Errorf("width 52:\n%s\nwant:\n%s", got, want)
+	}
+	got := render(30)
+	for _, line := range strings.Split(strings.TrimSuffix(stripANSI(got), "\n"), "\n") {
+		if !strings.HasPrefix(line, "  (") && utf8.RuneCountInString(line) > 30 {
+			t.Errorf("line wider than 30: %q", line)
+		}
+	}
+	if !strings.Contains(stripANSI(got), "(1 column not shown: Model; widen the terminal or use --width 0)") {
+		t.Errorf("dropped columns should be named:\n%s", got)
+	}
+	if !strings.Contains(got, Red+"faulty"+Reset) {
+		t.Errorf("colors should be kept:\n%q", got)
+	}
+
+	if got := truncateVisible(Red+"offline"+Reset, 5); got != Red+"of"+Reset+"..."+Reset {
+		t.Errorf("truncateVisible = %q", got)
+	}
+	if got := fitTableWidths([]int{10, 30, 10}, []int{6, 8, 6}, 20); !reflect.DeepEqual(got, []int{8, 8}) {
+		t.Errorf("fitTableWidths = %v", got)
+	}
+
+	if config, err := runShow(t, "show", "testdata/healthy.json", "--width", "100"); err != nil || config.Width != 100 {
+		t.Errorf("--width 100 = %v, %v", config, err)
+	}
+	if config, err := runShow(t, "show", "testdata/healthy.json"); err != nil || config.Width != 0 {
+		t.Errorf("width should be unlimited when stdout is not a terminal, got %v, %v", config, err)
+	}
+	if _, err := runShow(t, "show", "testdata/healthy.json", "--width", "-1"); err == nil {
+		t.Error("--width -1 should fail")
+	}
+}
+
+func TestIncidentReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "report")
+	if _, err := runShow(t, "report", "testdata/failed-drives.json", "--out", dir); err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got := read("summary.md"); !strings.Contains(got, "## Summary\n") || strings.Contains(got, "## Servers") {
+		t.Errorf("summary.md should hold only the summary:\n%s", got)
+	}
+	if got := read("report.txt"); !strings.Contains(got, "Erasure Sets") || strings.Contains(got, "\x1b[") {
+		t.Errorf("report.txt should be the uncolored text report:\n%s", got)
+	}
+	wantFailed := "Pool,Erasure Set,Disk Index,Server,Disk Path,State,Scanning,UUID,Total Space,Space Used,Free Space,Inodes Used,Local,Metrics\n" +
+		"0,0,1,node1,/mnt/drive2,faulty,No,00000000-0000-40...,N/A,N/A,N/A,N/A,Yes,\n" +
+		"0,0,4,node3,/mnt/drive1,offline,No,00000000-0000-40...,N/A,N/A,N/A,N/A,Yes,\n"
+	if got := read("failed-drives.csv"); got != wantFailed {
+		t.Errorf("failed-drives.csv:\n%s\nwant:\n%s", got, wantFailed)
+	}
+	if got := read("low-space-sets.csv"); got != "Pool,Erasure Set,Drives,Good,Bad,Scanning,Avg Space Used,Avg Free Space,Avg Inodes Used\n" {
+		t.Errorf("low-space-sets.csv should only have the header below the --warn-free threshold:\n%s", got)
+	}
+	var drives []map[string]interface{}
+	if err := json.Unmarshal([]byte(read("drives.json")), &drives); err != nil {
+		t.Fatalf("drives.json: %v", err)
+	}
+	if len(drives) != 8 || drives[1]["path"] != "/mnt/drive2" || drives[1]["state"] != "faulty" || drives[1]["pool"] != 0.0 || drives[1]["disk_index"] != 1.0 {
+		t.Errorf("drives.json = %v", drives)
+	}
+
+	// The CSV files follow --precision and the drive filters of the views, so
+	// the set counts only the two drives of node3
+	if _, err := runShow(t, "report", "testdata/failed-drives.json", "--out", dir, "--low-space", "60", "--precision", "2", "--grep", "node3"); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("low-space-sets.csv"); !strings.Contains(got, "\n0,0,2,1,1,0,55.00%,45.00%,10.00%\n") {
+		t.Errorf("low-space-sets.csv with --low-space 60 --precision 2:\n%s", got)
+	}
+	if got := read("failed-drives.csv"); strings.Contains(got, "faulty") || !strings.Contains(got, "/mnt/drive1,offline") {
+		t.Errorf("failed-drives.csv with --grep node3:\n%s", got)
+	}
+	if got := read("drives.json"); !strings.Contains(got, `"state": "faulty"`) {
+		t.Errorf("drives.json should have every drive:\n%s", got)
+	}
+
+	// The archive of --out - holds the same files
+	infoStruct, err := loadJSON("testdata/failed-drives.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := renderIncidentReport(infoStruct, &Config{JSONFile: "testdata/failed-drives.json", ShowSummary: true, ShowServers: true, ShowSets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := writeTarGz(&archive, files, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	if got := strings.Join(names, " "); got != "summary.md report.txt failed-drives.csv low-space-sets.csv drives.json" {
+		t.Errorf("archive members = %s", got)
+	}
+
+	if _, err := runShow(t, "report", "testdata/failed-drives.json"); err == nil || !strings.Contains(err.Error(), "needs --out") {
+		t.Errorf("report without --out error = %v", err)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	if got := renderGolden(t, "show", "testdata/healthy.json", "--quiet"); got != "" {
+		t.Errorf("--quiet on a healthy cluster printed:\n%s", got)
+	}
+	want := "WARNING\tserver\tserver=node3\toffline\n" +
+		"WARNING\tdrive\tpool=0 set=0 server=node3 path=/mnt/drive1\toffline\n" +
+		"WARNING\tdrive\tpool=0 set=0 server=node3 path=/mnt/drive2\toffline\n"
+	if got := renderGolden(t, "show", "disks", "testdata/offline-server.json", "--quiet"); got != want {
+		t.Errorf("--quiet output:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Four drives with parity 2 keep write quorum with three online, read quorum with two
+	var drives []DiskInfo
+	for i, state := range []string{"ok", "ok", "offline", "faulty"} {
+		drives = append(drives, DiskInfo{Server: "node1", Path: fmt.Sprintf("/d%d", i), State: state, PoolIndex: 1, SetIndex: 4, DiskIndex: i})
+	}
+	pager := NewPager(true)
+	pager.stripColor = true
+	printQuietProblems(pager, collectFindings(nil, map[string][]DiskInfo{"1-4": drives}, mdbcore.NewServerNamer(nil, ""), 2, nil, &Config{}))
+	got := pager.String()
+	if !strings.HasPrefix(got, "CRITICAL\tset\tpool=1 set=4\tno write quorum, 2 of 4 drives online\n") {
+		t.Errorf("set below write quorum should come first:\n%s", got)
+	}
+	if !strings.Contains(got, "WARNING\tdrive\tpool=1 set=4 server=node1 path=/d3\tfaulty\n") {
+		t.Errorf("faulty drive missing:\n%s", got)
+	}
+
+	config, err := runShow(t, "show", "testdata/healthy.json", "--quiet")
+	if err != nil || config.ColorMode != colorNever {
+		t.Errorf("--quiet color mode = %v, %v, want never", config, err)
+	}
+	if config, err := runShow(t, "show", "testdata/healthy.json", "--quiet", "--color", "always"); err != nil || config.ColorMode != colorAlways {
+		t.Errorf("--quiet --color always should keep colors, got %v, %v", config, err)
+	}
+	if _, err := runShow(t, "show", "testdata/healthy.json", "--quiet", "--format", "markdown"); err == nil {
+		t.Error("--quiet with --format markdown should fail")
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join("testdata", "failed-drives.json")
+	config, err := runShow(t, "show", file, "--no-config", "--color", "never", "--history-size", "0", "--interactive")
+	if err != nil || !config.Interactive {
+		t.Fatalf("--interactive: config %+v, error %v", config, err)
+	}
+	infoStruct, err := loadInput(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var model tea.Model = newInteractiveModel(infoStruct, config)
+	send := func(msgs ...tea.Msg) interactiveModel {
+		t.Helper()
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(interactiveModel)
+	}
+	key := func(s string) tea.Msg {
+		switch s {
+		case "tab":
+			return tea.KeyMsg{Type: tea.KeyTab}
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	m := send(tea.WindowSizeMsg{Width: 160, Height: 40})
+	if m.view != viewSummary || !strings.Contains(m.View(), "Summary") {
+		t.Errorf("first view = %d, want the summary:\n%s", m.view, m.View())
+	}
+
+	m = send(key("tab"), key("tab"))
+	if m.view != viewSets || !strings.Contains(strings.Join(m.lines, "\n"), "Erasure Sets") || len(m.rows) != 1 {
+		t.Fatalf("sets view = %d with rows %v:\n%s", m.view, m.rows, m.View())
+	}
+	// The set of a row is kept with the row, not read back from its text
+	if set, ok := m.sets[m.rows[0]]; !ok || set.PoolIdx != 0 || set.SetIdx != 0 || len(set.Drives) != 8 {
+		t.Errorf("set of the selected row = %+v, %v", set, ok)
+	}
+	m = send(key("enter"))
+	drives := stripANSI(strings.Join(m.lines, "\n"))
+	if m.view != viewDrives || m.drill != "0:0" || strings.Count(drives, "/mnt/drive") != 8 || !strings.Contains(m.statusLine(), "Pool 0, Set 0") {
+		t.Errorf("drill into set 0:0: view %d, drill %q, status %q:\n%s", m.view, m.drill, m.statusLine(), drives)
+	}
+
+	m = send(key("f"))
+	drives = stripANSI(strings.Join(m.lines, "\n"))
+	if !m.failed || strings.Count(drives, "/mnt/drive") != 2 || !strings.Contains(m.statusLine(), "Filters: failed") {
+		t.Errorf("f should show only the failed drives, status %q:\n%s", m.statusLine(), drives)
+	}
+	m = send(key("j"), key("j"), key("k"))
+	if m.selected != 0 {
+		t.Errorf("selected row = %d after j, j, k, want 0", m.selected)
+	}
+	m = send(key("f"), key("s"))
+	if m.failed || !m.scanning || !strings.Contains(m.statusLine(), "Filters: scanning") {
+		t.Errorf("f, s: failed %v, scanning %v, status %q", m.failed, m.scanning, m.statusLine())
+	}
+
+	m = send(key("esc"))
+	if m.view != viewSets || m.drill != "" {
+		t.Errorf("esc: view %d, drill %q, want the sets view", m.view, m.drill)
+	}
+	m = send(key("2"))
+	if m.view != viewServers || !strings.Contains(strings.Join(m.lines, "\n"), "node4") {
+		t.Errorf("2 should show the servers view, got %d", m.view)
+	}
+
+	for _, args := range [][]string{{"mdb", "prod.json", "--interactive"}, {"mdb", "prod.json", "--interactive=true"}, {"mdb", "-interactive", "prod.json"}} {
+		if got := interactiveArgs(newApp(), args); strings.Join(got, " ") != "mdb show "+strings.Join(args[1:], " ") {
+			t.Errorf("interactiveArgs(%v) = %v", args, got)
+		}
+	}
+	for _, args := range [][]string{{"mdb", "prod.json", "--interactive=false"}, {"mdb", "sets", "prod.json", "--interactive"}, {"mdb", "prod.json"}} {
+		if got := interactiveArgs(newApp(), args); !slices.Equal(got, args) {
+			t.Errorf("interactiveArgs(%v) = %v, want it unchanged", args, got)
+		}
+	}
+	if _, err := runShow(t, "show", file, "--interactive", "--format", "markdown"); err == nil || !strings.Contains(err.Error(), "--interactive cannot be used with --format markdown") {
+		t.Errorf("--interactive --format markdown: error = %v", err)
+	}
+}
+
+func TestServerDetails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "cluster.json")
+	content := `{"status":"success","info":{"servers":[
+		{"endpoint":"node1.example.net:9000","state":"online","uptime":1000,"num_cpu":32,"go_max_procs":32,"runtime_version":"go1.24.4",
+			"gc_stats":{"num_gc":120,"pause_total":2500000000},"drives":[{"path":"/data/disk1","state":"ok"}]},
+		{"endpoint":"node2.example.net:9000","state":"online","uptime":1000,"num_cpu":64,"go_max_procs":16,"drives":[{"path":"/data/disk1","state":"ok"}]}
+	]}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := renderGolden(t, "servers", "--no-config", "--format", "markdown", "--history-size", "0", file)
+	if strings.Contains(got, "| CPUs |") {
+		t.Errorf("servers without --server-details shows detail columns:\n%s", got)
+	}
+	got = renderGolden(t, "servers", "--no-config", "--format", "markdown", "--history-size", "0", "--server-details", file)
+	for _, want := range []string{
+		"| ILM Status | Uptime | CPUs | Go | GC |",
+		"| 32 | go1.24.4 | 120 runs, 2.5s pause (0.25%) |",
+		"| 64 (max procs 16) | N/A | N/A |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("--server-details misses %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestTrimDomainFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	setClock(t, time.Date(2025, 2, 1, 14, 0, 0, 0, time.UTC))
+
+	// The fixture servers are nodeN.cluster.example.net, named nodeN by default
+	want, err := os.ReadFile(filepath.Join("testdata", "golden", "healthy-servers.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trimDomain := range []string{"auto", ".cluster.example.net", ".other.net,.cluster.example.net"} {
+		got := renderGolden(t, "servers", "--no-config", "--color", "never", "--history-size", "0", "--trim-domain", trimDomain, filepath.Join("testdata", "healthy.json"))
+		if got != string(want) {
+			t.Errorf("--trim-domain %s:\n%s\nwant:\n%s", trimDomain, got, want)
+		}
+	}
+	got := renderGolden(t, "servers", "--no-config", "--color", "never", "--history-size", "0", "--trim-domain", ".example.net", filepath.Join("testdata", "healthy.json"))
+	if !strings.Contains(got, " node1.cluster ") {
+		t.Errorf("--trim-domain .example.net does not keep the rest of the name:\n%s", got)
+	}
+	got = renderGolden(t, "servers", "--no-config", "--color", "never", "--history-size", "0", "--trim-domain", "auto", "--fqdn", filepath.Join("testdata", "healthy.json"))
+	if !strings.Contains(got, " node1.cluster.example.net ") {
+		t.Errorf("--fqdn does not show the full name:\n%s", got)
+	}
+}
+
+func TestBusyServers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "cluster.json")
+	// node1 scans, node2 heals one drive by flag and one by heal progress only,
+	// node3 heals one drive and node4 is idle but for a faulty and an offline
+	// drive, which are not counted
+	content := `{"status":"success","info":{"servers":[
+		{"endpoint":"node1.example.net:9000","state":"online","drives":[
+			{"path":"/data/disk1","state":"ok","scanning":true},{"path":"/data/disk2","state":"ok"}]},
+		{"endpoint":"node2.example.net:9000","state":"online","drives":[
+			{"path":"/data/disk1","state":"ok","healing":true,"scanning":true},
+			{"path":"/data/disk2","state":"ok","heal_info":{"finished":false}},
+			{"path":"/data/disk3","state":"ok","heal_info":{"finished":true}}]},
+		{"endpoint":"node3.example.net:9000","state":"online","drives":[
+			{"path":"/data/disk1","state":"ok","healing":true},{"path":"/data/disk2","state":"ok"}]},
+		{"endpoint":"node4.example.net:9000","state":"online","drives":[
+			{"path":"/data/disk1","state":"ok"},{"path":"/data/disk2","state":"faulty"},{"path":"/data/disk3","state":"offline"}]}
+	]}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Healing, Scanning and Idle counts, a healing drive not counted as scanning
+	// and a healing count highlighted
+	activity := func(report string) []string {
+		var rows []string
+		for _, line := range strings.Split(report, "\n") {
+			if cells := strings.Split(line, "|"); len(cells) > 7 && strings.HasPrefix(strings.TrimSpace(cells[2]), "node") {
+				rows = append(rows, strings.Join([]string{strings.TrimSpace(cells[2]), strings.TrimSpace(cells[4]), strings.TrimSpace(cells[5]), strings.TrimSpace(cells[6])}, " "))
+			}
+		}
+		return rows
+	}
+	got := renderGolden(t, "servers", "--no-config", "--format", "markdown", "--history-size", "0", file)
+	if want := []string{"node1 0 1 1", "node2 **2** 0 1", "node3 **1** 0 1", "node4 0 0 1"}; !reflect.DeepEqual(activity(got), want) {
+		t.Errorf("drive activity = %v, want %v:\n%s", activity(got), want, got)
+	}
+
+	// --busy-servers keeps the healing and scanning servers, most healing drives first
+	got = renderGolden(t, "servers", "--no-config", "--format", "markdown", "--history-size", "0", "--busy-servers", file)
+	if want := []string{"node2 **2** 0 1", "node3 **1** 0 1", "node1 0 1 1"}; !reflect.DeepEqual(activity(got), want) || !strings.Contains(got, "Busy Servers") {
+		t.Errorf("--busy-servers = %v, want %v:\n%s", activity(got), want, got)
+	}
+
+	idle := filepath.Join(t.TempDir(), "idle.json")
+	if err := os.WriteFile(idle, []byte(`{"status":"success","info":{"servers":[{"endpoint":"node1:9000","state":"online","drives":[{"path":"/data/disk1","state":"ok"}]}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderGolden(t, "servers", "--no-config", "--color", "never", "--history-size", "0", "--busy-servers", idle); !strings.Contains(got, "No servers with healing or scanning drives found.") {
+		t.Errorf("--busy-servers without activity:\n%s", got)
+	}
+	if _, err := runShow(t, "sets", "--no-config", "--busy-servers", file); err == nil {
+		t.Errorf("sets --busy-servers: expected error")
+	}
+}
+
+func TestServerCapacity(t *testing.T) {
+	out := renderGolden(t, "show", "servers", "testdata/failed-drives.json", "--server-capacity", "--format", "csv", "--sort-by", "free")
+	want := `Server,Drives,Raw Capacity,Used,Used %,Free,Share
+node4,2,16.0 TiB,4.8 TiB,30.0%,11.2 TiB,33.3%
+node2,2,16.0 TiB,8.8 TiB,55.0%,7.2 TiB,33.3%
+node1,2,8.0 TiB,4.4 TiB,55.0%,3.6 TiB,16.7%
+node3,2,8.0 TiB,4.4 TiB,55.0%,3.6 TiB,16.7%
+Total,8,48.0 TiB,22.4 TiB,46.7%,25.6 TiB,100.0%
+`
+	if out != want {
+		t.Errorf("--server-capacity csv =\n%s\nwant\n%s", out, want)
+	}
+
+	out = stripANSI(renderGolden(t, "show", "servers", "testdata/failed-drives.json", "--server-capacity", "--units", "bytes"))
+	if !strings.Contains(out, "Server Capacity") || strings.Contains(out, "Server Health Summary") || strings.Contains(out, "\nServers\n") {
+		t.Errorf("--server-capacity should be shown alone:\n%s", out)
+	}
+	if !strings.Contains(out, "52776558133248 B") {
+		t.Errorf("--units bytes should show the raw total in bytes:\n%s", out)
+	}
+
+	for _, args := range [][]string{
+		{"--sort-by", "used-pct"},
+		{"--server-capacity", "--sort-by", "share"},
+		{"--server-capacity", "--buckets"},
+	} {
+		if _, err := runShow(t, append([]string{"show", "testdata/failed-drives.json"}, args...)...); err == nil {
+			t.Errorf("%v should fail", args)
+		}
+	}
+}
+
+func TestDriveSort(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	out := renderGolden(t, "drives", "testdata/failed-drives.json", "--no-config", "--history-size", "0", "--format", "csv", "--columns", "server,path,used", "--sort-by", "used-pct")
+	want := `Server,Disk Path,Space Used
+node1,/mnt/drive1,4.4 TiB (55.0%)
+node2,/mnt/drive1,4.4 TiB (55.0%)
+node2,/mnt/drive2,4.4 TiB (55.0%)
+node3,/mnt/drive2,4.4 TiB (55.0%)
+node4,/mnt/drive2,4.4 TiB (55.0%)
+node4,/mnt/drive1,409.6 GiB (5.0%)
+node1,/mnt/drive2,N/A
+node3,/mnt/drive1,N/A
+`
+	if out != want {
+		t.Errorf("drives --sort-by used-pct =\n%s\nwant\n%s", out, want)
+	}
+
+	for _, args := range [][]string{
+		{"--sort-by", "drives"},
+		{"--sort-by", "free", "--errors"},
+		{"--sort-by", "free", "--failed"},
+		{"--decommission", "1"},
+		{"--simulate-loss", "server:node1"},
+		{"--tree"},
+	} {
+		if _, err := runShow(t, append([]string{"drives", "--no-config", "testdata/failed-drives.json"}, args...)...); err == nil {
+			t.Errorf("drives %v should fail", args)
+		}
+	}
+	if _, err := runShow(t, "sets", "--no-config", "--by-path", "testdata/failed-drives.json"); err == nil {
+		t.Errorf("sets --by-path should fail")
+	}
+}
+
+func TestFailedSetCounts(t *testing.T) {
+	infoStruct := testCluster()
+	servers := infoStruct.Info.Servers[:2]
+	for i := range servers {
+		disks := make([]madmin.Disk, 8)
+		for j := range disks {
+			idx := i*8 + j
+			disks[j] = madmin.Disk{
+				Endpoint:       fmt.Sprintf("http://node%d:9000/data/disk%d", i+1, idx),
+				DrivePath:      fmt.Sprintf("/data/disk%d", idx),
+				State:          "ok",
+				TotalSpace:     1000,
+				UsedSpace:      600,
+				AvailableSpace: 400,
+				DiskIndex:      idx,
+			}
+		}
+		servers[i].Disks = disks
+	}
+	servers[1].Disks[7].State = "faulty"
+	servers[1].Disks[7].UsedSpace = 0
+	infoStruct.Info.Servers = servers
+
+	counts := func(failedOnly bool) []string {
+		pager := NewPager(true)
+		config := &Config{JSONFile: "cluster.json", ShowSets: true, FailedMode: true, FailedOnlyAvg: failedOnly}
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(stripANSI(pager.String()), "\n") {
+			// The Usable and Free sizes are two fields each
+			if fields := strings.Fields(line); len(fields) == 13 && fields[0] == "0" && fields[1] == "0" {
+				return fields[2:6]
+			}
+		}
+		t.Fatalf("no erasure set row:\n%s", pager.String())
+		return nil
+	}
+	// A set with one failed drive is still counted over all 16 drives
+	if got, want := counts(false), []string{"15", "1", "0", "56.2%"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("--failed good/bad/scanning/used = %v, want %v", got, want)
+	}
+	if got, want := counts(true), []string{"0", "1", "0", "0.0%"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("--failed-only-averages good/bad/scanning/used = %v, want %v", got, want)
+	}
+}
+
+func TestFailedLowSpace(t *testing.T) {
+	// Set 0 has a faulty drive, set 1 is healthy and fuller
+	infoStruct := testCluster()
+	for i := range infoStruct.Info.Servers {
+		server := &infoStruct.Info.Servers[i]
+		for j := 0; j < 2; j++ {
+			disk := server.Disks[j]
+			disk.Endpoint = strings.Replace(disk.Endpoint, "/disk", "/full", 1)
+			disk.State, disk.SetIndex, disk.UsedSpace, disk.AvailableSpace = "ok", 1, 900, 100
+			server.Disks = append(server.Disks, disk)
+		}
+	}
+	render := func(config *Config) string {
+		t.Helper()
+		config.JSONFile = "cluster.json"
+		threshold := 70.0
+		config.LowSpaceThreshold = &threshold
+		pager := NewPager(true)
+		if err := renderReport(pager, infoStruct, config); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(pager.String())
+	}
+
+	// Both filters apply: only the set with failed drives, over all its drives
+	got := render(&Config{ShowSets: true, FailedMode: true, Format: formatCSV})
+	if want := "Pool,Erasure Set,Drives,Good,Bad,Scanning,Avg Space Used,Avg Free Space,Avg Inodes Used\n0,0,4,3,1,0,60.0%,40.0%,0.0%\n"; got != want {
+		t.Errorf("sets --failed --low-space:\n%s\nwant:\n%s", got, want)
+	}
+	got = render(&Config{ShowSets: true, FailedMode: true, FailedOnlyAvg: true, Format: formatCSV})
+	if want := "\n0,0,1,0,1,0,60.0%,40.0%,0.0%\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("sets --failed --failed-only-averages --low-space:\n%s\nwant suffix:\n%s", got, want)
+	}
+	if got := render(&Config{ShowSets: true, Format: formatCSV}); !strings.Contains(got, "\n0,1,4,4,0,") || !strings.Contains(got, "\n0,0,4,3,1,") {
+		t.Errorf("sets --low-space without --failed misses a set:\n%s", got)
+	}
+	got = render(&Config{ShowSets: true, FailedMode: true})
+	if !strings.Contains(got, "Erasure Sets with Failed Drives and Average Free Space < 70.0%") {
+		t.Errorf("sets --failed --low-space title:\n%s", got)
+	}
+
+	got = render(&Config{ShowDisks: true, FailedMode: true})
+	if !strings.Contains(got, "Failed Drives with Free Space < 70.0%") || !strings.Contains(got, "/data/disk3") || strings.Contains(got, "/data/full") {
+		t.Errorf("disks --failed --low-space should list only the faulty drive:\n%s", got)
+	}
+}
+
+func TestSetServersText(t *testing.T) {
+	var drives []DiskInfo
+	for i := 16; i >= 1; i-- {
+		state := "ok"
+		if i == 12 {
+			state = "offline"
+		}
+		drives = append(drives, DiskInfo{Server: fmt.Sprintf("rack3-%02d", i), State: state}, DiskInfo{Server: fmt.Sprintf("rack3-%02d", i), State: "ok"})
+	}
+	if got, want := setServersText(drives, false), Red+"rack3-12"+Reset+",rack3-01,rack3-02,rack3-03,+12 more"; got != want {
+		t.Errorf("elided servers = %q, want %q", got, want)
+	}
+	got := stripANSI(setServersText(drives, true))
+	if !strings.HasPrefix(got, "rack3-01,rack3-02,") || !strings.HasSuffix(got, ",rack3-16") || strings.Count(got, ",") != 15 {
+		t.Errorf("--wide servers = %q", got)
+	}
+	// One more server than shown is listed instead of "+1 more"
+	if got := stripANSI(setServersText(drives[:10], false)); got != "rack3-12,rack3-13,rack3-14,rack3-15,rack3-16" {
+		t.Errorf("five servers = %q", got)
+	}
+}
+
+func TestFindings(t *testing.T) {
+	pool, set := 0, 1
+	healAge := 72 * time.Hour
+	poolSetDrives := map[string][]DiskInfo{
+		"1-0": {
+			{Server: "node2", Path: "/d1", State: "ok", PoolIndex: 1, TotalSpace: 100, UsedSpace: 96, AvailableSpace: 4, UsedSpacePct: 96},
+			{Server: "node10", Path: "/d1", State: "faulty", PoolIndex: 1},
+		},
+		"0-1": {
+			{Server: "node1", Path: "/d1", State: "ok", SetIndex: 1, Scanning: true, Healing: true, HealAge: &healAge},
+			{Server: "node1", Path: "/d2", State: "offline", SetIndex: 1},
+			{Server: "node2", Path: "/d1", State: "offline", SetIndex: 1},
+			{Server: "node2", Path: "/d2", State: "offline", SetIndex: 1},
+		},
+	}
+	servers := []madmin.ServerProperties{{Endpoint: "node3:9000", State: "offline"}}
+	findings := collectFindings(servers, poolSetDrives, mdbcore.NewServerNamer(servers, ""), 2, nil, &Config{})
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, strings.ToUpper(f.Severity)+" "+f.location()+" "+f.Message)
+	}
+	want := []string{
+		"CRITICAL pool=0 set=1 no read quorum, 1 of 4 drives online",
+		"WARNING server=node3 offline",
+		"WARNING pool=0 set=1 server=node1 path=/d1 healing for 3d (--heal-stuck 2d)",
+		"WARNING pool=0 set=1 server=node1 path=/d2 offline",
+		"WARNING pool=0 set=1 server=node2 path=/d1 offline",
+		"WARNING pool=0 set=1 server=node2 path=/d2 offline",
+		"WARNING pool=1 set=0 server=node2 path=/d1 96.0% used (--warn-used 80%)",
+		"WARNING pool=1 set=0 server=node10 path=/d1 faulty",
+		"INFO pool=0 set=1 scanning in progress on 1 drive",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// A heal is stuck only on a healing drive, in the findings and in red alike
+	if healAgeColor(DiskInfo{Healing: true, HealAge: &healAge}, &Config{}) != Red || healAgeColor(DiskInfo{Scanning: true, HealAge: &healAge}, &Config{}) != Yellow {
+		t.Error("healAgeColor does not follow the stuck heal findings")
+	}
+	if *findings[0].Pool != pool || *findings[0].Set != set || findings[1].Pool != nil {
+		t.Errorf("finding locations = %+v, %+v", findings[0], findings[1])
+	}
+
+	if err := failOnFindings(findings, severityCritical); err == nil || err.Error() != "1 finding of severity critical or worse (--fail-on critical)" {
+		t.Errorf("--fail-on critical error = %v", err)
+	}
+	if err := failOnFindings(findings[1:], severityCritical); err != nil {
+		t.Errorf("--fail-on critical without critical findings = %v", err)
+	}
+	if err := failOnFindings(findings, severityInfo); err == nil || !strings.HasPrefix(err.Error(), "9 findings") {
+		t.Errorf("--fail-on info error = %v", err)
+	}
+
+	// The grafana snapshot carries the findings as an array of objects
+	var snapshot struct {
+		Findings []map[string]interface{} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(renderGolden(t, "show", "testdata/failed-drives.json", "--format", "grafana")), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Findings) != 4 || snapshot.Findings[0]["category"] != "set" || snapshot.Findings[1]["severity"] != "warning" || snapshot.Findings[1]["path"] != "/mnt/drive2" || snapshot.Findings[3]["server"] != nil {
+		t.Errorf("grafana findings = %v", snapshot.Findings)
+	}
+
+	if _, err := runShow(t, "show", "testdata/healthy.json", "--fail-on", "major"); err == nil {
+		t.Error("--fail-on major should fail")
+	}
+
+	// --fail-on counts the findings of the report, with the heal ages of its drives
+	t.Setenv("HOME", t.TempDir())
+	failOn := func(args ...string) string {
+		args = append([]string{"show", "disks", "testdata/failed-drives.json", "--no-config", "--failed", "--fail-on", "warning", "--output", filepath.Join(t.TempDir(), "report.txt")}, args...)
+		config, err := runShow(t, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := processAndDisplay(config); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	if got := failOn(); !strings.HasPrefix(got, "3 findings") {
+		t.Errorf("--fail-on warning error = %q", got)
+	}
+	if got := failOn("--heal-stuck", "1h"); !strings.HasPrefix(got, "4 findings") {
+		t.Errorf("--fail-on warning with a stuck heal error = %q", got)
+	}
+}
+
+func TestOutputAfterLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	output := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(output, []byte("previous report\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A snapshot that fails to load neither truncates nor creates the output file
+	fresh := filepath.Join(dir, "new.txt")
+	for _, path := range []string{output, fresh} {
+		config, err := runShow(t, "summary", "--no-config", "--history-size", "0", "--output", path, broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := processAndDisplay(config); err == nil {
+			t.Errorf("%s: loading a broken snapshot did not fail", path)
+		}
+	}
+	if got, _ := os.ReadFile(output); string(got) != "previous report\n" {
+		t.Errorf("a failed load overwrote --output with %q", got)
+	}
+	if _, err := os.Stat(fresh); !os.IsNotExist(err) {
+		t.Errorf("a failed load created --output: %v", err)
+	}
+
+	config, err := runShow(t, "summary", "--no-config", "--color", "never", "--history-size", "0", "--output", output, filepath.Join("testdata", "healthy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := processAndDisplay(config); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(output); !strings.Contains(string(got), "Health Grade") {
+		t.Errorf("--output after a successful load:\n%s", got)
+	}
+}
+
+func TestLogLine(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var buf bytes.Buffer
+	logLineWriter = &buf
+	defer func() { logLineWriter = os.Stderr }()
+
+	// The record covers the whole cluster even when the report shows only failed drives
+	config, err := runShow(t, "show", "disks", "testdata/failed-drives.json", "--failed", "--quiet", "--log-line",
+		"--output", filepath.Join(t.TempDir(), "out.txt"), "--no-config", "--history-size", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := processAndDisplay(config); err != nil {
+		t.Fatal(err)
+	}
+	want := "MDB_SUMMARY deployment=22222222-2222-4222-8222-222222222222 drives=8 bad=2 scanning=1 used_pct=62.2 usable_tb=39.6 health_pct=75.0\n"
+	if buf.String() != want {
+		t.Errorf("log line = %q, want %q", buf.String(), want)
+	}
+
+	if got := summaryLogLine(ClusterStats{}); got != "MDB_SUMMARY deployment=unknown drives=0 bad=0 scanning=0 used_pct=0.0 usable_tb=0.0 health_pct=0.0" {
+		t.Errorf("empty log line = %q", got)
+	}
+
+	buf.Reset()
+	if _, err := runShow(t, "report", "testdata/healthy.json", "--out", filepath.Join(t.TempDir(), "report"), "--log-line"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "MDB_SUMMARY deployment=11111111-1111-4111-8111-111111111111 drives=8 bad=0 ") {
+		t.Errorf("report log line = %q", buf.String())
+	}
+
+	if _, err := runShow(t, "show", "testdata/healthy.json", "--log-line", "--interactive"); err == nil {
+		t.Error("--log-line with --interactive should fail")
+	}
+}
+
+func TestURLInput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	data, err := os.ReadFile("testdata/failed-drives.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2025, 2, 1, 13, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest.json":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			w.Write(data)
+		case "/latest.json.gz":
+			gz := gzip.NewWriter(w)
+			gz.Write(data)
+			gz.Close()
+		case "/slow.json":
+			time.Sleep(200 * time.Millisecond)
+			w.Write(data)
+		default:
+			w.Write([]byte("<html>not a snapshot</html>"))
+		}
+	}))
+	defer server.Close()
+
+	// Parsing the flags does not fetch anything, loading the input does
+	var requests atomic.Int32
+	counted := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		counted.ServeHTTP(w, r)
+	})
+	config, err := runShow(t, "show", server.URL+"/latest.json", "--header", "Authorization: Bearer secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 0 || config.JSONFile != server.URL+"/latest.json" {
+		t.Errorf("parseShowFlags fetched %d times, JSONFile = %s", requests.Load(), config.JSONFile)
+	}
+	infoStruct, err := prepareInput(config)
+	if err != nil || infoStruct.Info.DeploymentID != "22222222-2222-4222-8222-222222222222" {
+		t.Errorf("prepareInput = %v", err)
+	}
+	if inputName(config) != server.URL+"/latest.json" || config.URL != server.URL+"/latest.json" {
+		t.Errorf("JSONFile = %s, shown as %s", config.JSONFile, inputName(config))
+	}
+	if fi, err := os.Stat(config.JSONFile); err != nil || !fi.ModTime().Equal(modified) {
+		t.Errorf("downloaded file should be dated Last-Modified: %v, %v", fi, err)
+	}
+	removeLocalInput(config)
+	if _, err := os.Stat(config.JSONFile); !os.IsNotExist(err) {
+		t.Errorf("downloaded file should be removed: %v", err)
+	}
+
+	config, err = runShow(t, "show", server.URL+"/latest.json.gz", "testdata/healthy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzConfig := &Config{JSONFile: config.JSONFiles[0]}
+	if _, err := prepareInput(gzConfig); err != nil {
+		t.Errorf("gzip body should be decompressed: %v", err)
+	}
+	removeLocalInput(gzConfig)
+	if config.JSONFiles[1] != "testdata/healthy.json" {
+		t.Errorf("local files stay as they are: %v", config.JSONFiles)
+	}
+
+	// Network errors are reported apart from files that are not snapshots
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{server.URL + "/latest.json"}, "failed to fetch '" + server.URL + "/latest.json': HTTP 403 Forbidden"},
+		{[]string{server.URL + "/slow.json", "--timeout", "50ms"}, "Client.Timeout exceeded"},
+		{[]string{"http://127.0.0.1:0/x.json"}, "failed to fetch 'http://127.0.0.1:0/x.json'"},
+		{[]string{server.URL + "/x.json", "--header", "Authorization"}, "invalid --header"},
+		{[]string{"testdata/healthy.json", "--insecure"}, "need an http:// or https:// URL"},
+	} {
+		config, err := runShow(t, append([]string{"show"}, tc.args...)...)
+		if err == nil {
+			_, err = prepareInput(config)
+			removeLocalInput(config)
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: error = %v, want %q", tc.args, err, tc.want)
+		}
+	}
+	config, err = runShow(t, "show", server.URL+"/page.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeLocalInput(config)
+	if _, err := prepareInput(config); err == nil || !strings.HasPrefix(err.Error(), "failed to load JSON file '"+server.URL+"/page.json': no supported format matched: looks like an HTML page") {
+		t.Errorf("parse error = %v", err)
+	}
+}
@@ -0,0 +1,1025 @@
+package mdbcore
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// DiskInfo represents a single disk. The JSON keys are those of the drives.json
+// file of "mdb report"; ages are in nanoseconds.
+type DiskInfo struct {
+	Server         string              `json:"server"`
+	Path           string              `json:"path"`
+	State          string              `json:"state"`
+	UUID           string              `json:"uuid"`
+	Scanning       bool                `json:"scanning"`
+	Healing        bool                `json:"healing"`
+	ScannerActive  bool                `json:"scanner_active"`
+	HealInfo       *madmin.HealingDisk `json:"heal_info,omitempty"`
+	DiskIndex      int                 `json:"disk_index"`
+	NoDiskIndex    bool                `json:"no_disk_index,omitempty"` // the snapshot has no usable disk index for the drive, DiskIndex is 0
+	TotalSpace     int64               `json:"total_bytes"`
+	UsedSpace      int64               `json:"used_bytes"`
+	AvailableSpace int64               `json:"free_bytes"`
+	UsedInodes     int64               `json:"used_inodes"`
+	FreeInodes     int64               `json:"free_inodes"`
+	Local          bool                `json:"local"`
+	RootDisk       bool                `json:"root_disk"`
+	Model          string              `json:"model,omitempty"`
+	Metrics        *madmin.DiskMetrics `json:"metrics,omitempty"`
+	LastErrorAge   *time.Duration      `json:"last_error_age,omitempty"`   // time between the last availability error and the snapshot, if known
+	LastTimeoutAge *time.Duration      `json:"last_timeout_age,omitempty"` // time between the last timeout error and the snapshot, if known
+	HealAge        *time.Duration      `json:"heal_age,omitempty"`         // time between the start of healing and the snapshot, if known
+	IO             *DriveIO            `json:"io,omitempty"`               // reads, writes and latency from --metrics-file, if the drive has series
+	PoolIndex      int                 `json:"pool"`
+	SetIndex       int                 `json:"set"`
+	FreeSpacePct   float64             `json:"free_pct"`
+	UsedSpacePct   float64             `json:"used_pct"`
+}
+
+// ErasureSetInfo holds information about an erasure set
+type ErasureSetInfo struct {
+	PoolIdx          int
+	SetIdx           int
+	Drives           []DiskInfo
+	AvgSpaceUsedPct  float64
+	AvgFreeSpacePct  float64
+	AvgInodesUsedPct float64
+	Good             int
+	Bad              int
+	Scanning         int
+	MinHealPct       *float64 // lowest heal progress among the scanning drives that report it
+}
+
+// ClusterStats holds cluster-wide statistics
+type ClusterStats struct {
+	TotalDisks    int
+	ScanningDisks int
+	OkDisks       int
+	BadDisks      int
+	TotalSpace    int64
+	UsedSpace     int64
+	FreeSpace     int64 // available space the drives report, all usable without erasure coding
+	DeploymentID  string
+	ParityDisks   int
+	UsableSpace   int64
+	Snapshot      time.Time
+}
+
+// DefaultParityDisks is the parity assumed when a snapshot does not report it
+const DefaultParityDisks = 2
+
+// MaxSetDrives is the largest erasure set MinIO forms
+const MaxSetDrives = 16
+
+// DriveFilter selects drives for Snapshot.Drives. The zero value selects all
+// drives, their server names shortened to the first label.
+type DriveFilter struct {
+	TrimDomain string // domain suffixes trimmed from server names instead, see NewServerNamer
+	FQDN       bool   // whole server host names, TrimDomain is ignored
+	Scanning   bool   // only drives being scanned (healing)
+	Failed     bool   // only drives whose state is not "ok"
+}
+
+// match reports whether drive passes the filter
+func (f DriveFilter) match(drive DiskInfo) bool {
+	if f.Scanning && !drive.Scanning {
+		return false
+	}
+	if f.Failed && drive.State == "ok" {
+		return false
+	}
+	return true
+}
+
+// SetKey is the key of an erasure set in maps of drives by set, "pool:set"
+func SetKey(poolIdx, setIdx int) string {
+	return fmt.Sprintf("%d:%d", poolIdx, setIdx)
+}
+
+// ParityDisks returns the parity of the standard storage class, or
+// DefaultParityDisks if the snapshot does not report it
+func (s *Snapshot) ParityDisks() int {
+	if parity := s.Info.Backend.StandardSCParity; parity > 0 {
+		return parity
+	}
+	return DefaultParityDisks
+}
+
+// BackendType returns the backend type the snapshot reports, such as
+// "Erasure" or "FS", or "" if it reports none
+func (s *Snapshot) BackendType() string {
+	return string(s.Info.Backend.Type)
+}
+
+// IsErasure reports whether the snapshot is of an erasure coded deployment.
+// Snapshots that report no backend type are taken to be.
+func (s *Snapshot) IsErasure() bool {
+	backend := s.BackendType()
+	return backend == "" || backend == string(madmin.ErasureType)
+}
+
+// MissingTopology returns why the pool and set indices of the drives cannot be
+// trusted, or "" if they can. Releases that leave the indices out put every
+// drive in pool 0 set 0, which is only plausible if the backend reports a
+// single set and the drives fit in one.
+func (s *Snapshot) MissingTopology() string {
+	if !s.IsErasure() {
+		return ""
+	}
+	drives := 0
+	for _, server := range s.Info.Servers {
+		for _, disk := range server.Disks {
+			if disk.PoolIndex != 0 || disk.SetIndex != 0 {
+				return ""
+			}
+			drives++
+		}
+	}
+	sets := 0
+	for _, count := range s.Info.Backend.TotalSets {
+		sets += count
+	}
+
+	var reason string
+	switch {
+	case sets > 1:
+		reason = fmt.Sprintf("the backend reports %d erasure sets", sets)
+	case drives > MaxSetDrives:
+		reason = fmt.Sprintf("%d drives cannot form a single erasure set of at most %d", drives, MaxSetDrives)
+	default:
+		return ""
+	}
+	absent := s.Missing[FieldPoolIndex]
+	if s.Missing[FieldSetIndex] > absent {
+		absent = s.Missing[FieldSetIndex]
+	}
+	switch {
+	case absent == drives:
+		return "the drives report no pool or set index, but " + reason
+	case absent > 0:
+		return fmt.Sprintf("%d of %d drives report no pool or set index and all are in pool 0 set 0, but %s", absent, drives, reason)
+	}
+	return "every drive is in pool 0 set 0, but " + reason
+}
+
+// RRSParityDisks returns the parity of the REDUCED_REDUNDANCY storage class, or
+// 0 if the snapshot does not report it
+func (s *Snapshot) RRSParityDisks() int {
+	return s.Info.Backend.RRSCParity
+}
+
+// Drives returns the drives of every server that pass filter, in server order.
+// Last-error and heal ages are measured from Timestamp when it is known, and
+// the I/O of DriveIO is joined to the drives.
+func (s *Snapshot) Drives(filter DriveFilter) []DiskInfo {
+	drives := make([]DiskInfo, 0)
+	var names *ServerNamer
+	if filter.FQDN {
+		names = NewFQDNServerNamer(s.Info.Servers)
+	} else {
+		names = NewServerNamer(s.Info.Servers, filter.TrimDomain)
+	}
+	for _, server := range s.Info.Servers {
+		serverDrives := ServerDrives(server, names)
+		if !s.Timestamp.IsZero() {
+			ApplyErrorTimes(serverDrives, server, s.ErrorTimes, s.Timestamp)
+			ApplyHealAges(serverDrives, s.Timestamp)
+		}
+		ApplyDriveIO(serverDrives, server, s.DriveIO)
+		for _, drive := range serverDrives {
+			if filter.match(drive) {
+				drives = append(drives, drive)
+			}
+		}
+	}
+	return drives
+}
+
+// DrivesBySet returns the drives that pass filter grouped by erasure set, keyed by SetKey
+func (s *Snapshot) DrivesBySet(filter DriveFilter) map[string][]DiskInfo {
+	poolSetDrives := make(map[string][]DiskInfo)
+	for _, drive := range s.Drives(filter) {
+		key := SetKey(drive.PoolIndex, drive.SetIndex)
+		poolSetDrives[key] = append(poolSetDrives[key], drive)
+	}
+	return poolSetDrives
+}
+
+// ErasureSets summarizes every erasure set over all of its drives, sorted by
+// pool and set
+func (s *Snapshot) ErasureSets() []ErasureSetInfo {
+	sets := make([]ErasureSetInfo, 0)
+	for _, drives := range s.DrivesBySet(DriveFilter{}) {
+		sets = append(sets, SummarizeErasureSet(drives[0].PoolIndex, drives[0].SetIndex, drives))
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].PoolIdx != sets[j].PoolIdx {
+			return sets[i].PoolIdx < sets[j].PoolIdx
+		}
+		return sets[i].SetIdx < sets[j].SetIdx
+	})
+	return sets
+}
+
+// ClusterStats counts the drives and space of all drives and the usable capacity
+func (s *Snapshot) ClusterStats() ClusterStats {
+	stats := ClusterStats{
+		DeploymentID: s.Info.DeploymentID,
+		ParityDisks:  s.ParityDisks(),
+		Snapshot:     s.Timestamp,
+	}
+	poolSetDrives := make(map[string][]DiskInfo)
+	for _, drive := range s.Drives(DriveFilter{}) {
+		stats.Add(drive)
+		key := SetKey(drive.PoolIndex, drive.SetIndex)
+		poolSetDrives[key] = append(poolSetDrives[key], drive)
+	}
+	stats.UsableSpace = UsableSpace(Pools(s.Info.Servers), poolSetDrives, stats.ParityDisks)
+	return stats
+}
+
+// Add counts drive in the disk and space totals
+func (stats *ClusterStats) Add(drive DiskInfo) {
+	stats.TotalDisks++
+	if drive.Scanning {
+		stats.ScanningDisks++
+	}
+	if drive.State == "ok" {
+		stats.OkDisks++
+	} else {
+		stats.BadDisks++
+	}
+	stats.TotalSpace += drive.TotalSpace
+	stats.UsedSpace += drive.UsedSpace
+	stats.FreeSpace += drive.AvailableSpace
+}
+
+// MissingDiskIndex is the disk index Load gives drives whose "disk_index" is
+// missing or not a number, so that ServerDrives sets their NoDiskIndex
+const MissingDiskIndex = -1
+
+// ServerDrives returns the drives of server, with the server named by names
+func ServerDrives(server madmin.ServerProperties, names *ServerNamer) []DiskInfo {
+	serverEndpoint := names.Name(server.Endpoint)
+	drives := make([]DiskInfo, 0, len(server.Disks))
+
+	for _, disk := range server.Disks {
+		diskInfo := DiskInfo{
+			Server:         serverEndpoint,
+			Path:           disk.DrivePath,
+			State:          disk.State,
+			UUID:           disk.UUID,
+			Scanning:       disk.Healing,
+			Healing:        disk.Healing,
+			ScannerActive:  disk.Scanning,
+			HealInfo:       disk.HealInfo,
+			DiskIndex:      disk.DiskIndex,
+			TotalSpace:     int64(disk.TotalSpace),
+			UsedSpace:      int64(disk.UsedSpace),
+			AvailableSpace: int64(disk.AvailableSpace),
+			UsedInodes:     int64(disk.UsedInodes),
+			FreeInodes:     int64(disk.FreeInodes),
+			Local:          disk.Local,
+			RootDisk:       disk.RootDisk,
+			Model:          disk.Model,
+			Metrics:        disk.Metrics,
+			PoolIndex:      disk.PoolIndex,
+			SetIndex:       disk.SetIndex,
+		}
+
+		// Drives without a usable index in the snapshot are loaded with MissingDiskIndex
+		if disk.DiskIndex < 0 {
+			diskInfo.DiskIndex = 0
+			diskInfo.NoDiskIndex = true
+		}
+
+		// Older snapshots may only carry heal progress without the healing flag
+		if disk.HealInfo != nil && !disk.HealInfo.Finished {
+			diskInfo.Healing = true
+		}
+
+		// Extract path from endpoint if path is not provided
+		if diskInfo.Path == "" && disk.Endpoint != "" {
+			diskInfo.Path = PathFromEndpoint(disk.Endpoint)
+		}
+
+		// Calculate percentages
+		if diskInfo.TotalSpace > 0 {
+			diskInfo.FreeSpacePct = float64(diskInfo.AvailableSpace) / float64(diskInfo.TotalSpace) * 100
+			diskInfo.UsedSpacePct = float64(diskInfo.UsedSpace) / float64(diskInfo.TotalSpace) * 100
+		}
+
+		drives = append(drives, diskInfo)
+	}
+
+	return drives
+}
+
+// PathFromEndpoint returns the drive path of an endpoint such as
+// "http://node1:9000/data/disk1", "https://[fd00::12]:9000/data/disk1" or
+// "node1:9000/data/disk1", or "" if it has none. Hadoop endpoints return the
+// part after "/hadoop/".
+func PathFromEndpoint(endpoint string) string {
+	if _, path, ok := strings.Cut(endpoint, "/hadoop/"); ok {
+		return "/" + path
+	}
+	// Without a scheme the host would be parsed as part of the path
+	if !strings.Contains(endpoint, "://") && !strings.HasPrefix(endpoint, "/") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Path == "/" {
+		return ""
+	}
+	return u.Path
+}
+
+// TrimDomain trims domain suffix from endpoint for cleaner display. domainString
+// may list several suffixes separated by commas, the longest one that matches is
+// trimmed. Without any, only the first label is kept.
+func TrimDomain(endpoint, domainString string) string {
+	return trimHost(EndpointHost(endpoint), domainString == "", splitDomains(domainString))
+}
+
+// splitDomains returns the domain suffixes of a comma-separated list, longest first
+func splitDomains(domainString string) []string {
+	var domains []string
+	for _, domain := range strings.Split(domainString, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	sort.SliceStable(domains, func(i, j int) bool { return len(domains[i]) > len(domains[j]) })
+	return domains
+}
+
+// trimHost trims the first of domains that host ends with, or with firstLabel
+// everything after the first label
+func trimHost(host string, firstLabel bool, domains []string) string {
+	// If host is an IP address (v4 or v6), return it as-is
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return ip.String()
+	}
+
+	// Fallback to previous behaviour for domain names
+	if firstLabel {
+		return strings.SplitN(host, ".", 2)[0]
+	}
+	for _, domain := range domains {
+		if trimmed := strings.TrimSuffix(strings.TrimSuffix(host, domain), "."); trimmed != host && trimmed != "" {
+			return trimmed
+		}
+	}
+	return host
+}
+
+// AutoTrimDomain is the --trim-domain value that trims the longest DNS suffix
+// shared by all servers, see NewServerNamer
+const AutoTrimDomain = "auto"
+
+// ServerNamer names servers after their endpoint host with domain suffixes
+// trimmed, keeping the names of different hosts apart
+type ServerNamer struct {
+	firstLabel bool
+	domains    []string
+	names      map[string]string // of the hosts of the servers it was made for
+}
+
+// NewServerNamer returns a namer for the endpoints of servers. trimDomain is a
+// comma-separated list of domain suffixes to trim, AutoTrimDomain for the
+// longest DNS suffix all host names share, or empty to keep the first label
+// only. Hosts whose names would collide keep as many labels of their suffix as
+// they need to stay distinguishable.
+func NewServerNamer(servers []madmin.ServerProperties, trimDomain string) *ServerNamer {
+	hosts := serverHosts(servers)
+	n := &ServerNamer{firstLabel: trimDomain == "", names: make(map[string]string, len(hosts))}
+	if strings.EqualFold(strings.TrimSpace(trimDomain), AutoTrimDomain) {
+		if domain := CommonDomain(hosts); domain != "" {
+			n.domains = []string{domain}
+		}
+	} else {
+		n.domains = splitDomains(trimDomain)
+	}
+	return n.name(hosts)
+}
+
+// NewFQDNServerNamer returns a namer that names the servers after their whole
+// endpoint host
+func NewFQDNServerNamer(servers []madmin.ServerProperties) *ServerNamer {
+	hosts := serverHosts(servers)
+	return (&ServerNamer{names: make(map[string]string, len(hosts))}).name(hosts)
+}
+
+// serverHosts returns the distinct endpoint hosts of servers, in server order
+func serverHosts(servers []madmin.ServerProperties) []string {
+	hosts := make([]string, 0, len(servers))
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		if host := EndpointHost(server.Endpoint); !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// name names hosts with the trimming of n and returns n
+func (n *ServerNamer) name(hosts []string) *ServerNamer {
+	// Start from the trimmed names and give colliding hosts one more label of
+	// their host name until every name is unique or is the whole host name
+	labels := make(map[string]int, len(hosts))
+	for _, host := range hosts {
+		name := trimHost(host, n.firstLabel, n.domains)
+		n.names[host] = name
+		labels[host] = strings.Count(name, ".") + 1
+	}
+	for {
+		byName := make(map[string][]string)
+		for _, host := range hosts {
+			byName[n.names[host]] = append(byName[n.names[host]], host)
+		}
+		extended := false
+		for _, group := range byName {
+			if len(group) < 2 {
+				continue
+			}
+			for _, host := range group {
+				hostLabels := strings.Split(host, ".")
+				if labels[host] >= len(hostLabels) {
+					continue
+				}
+				labels[host]++
+				n.names[host] = strings.Join(hostLabels[:labels[host]], ".")
+				extended = true
+			}
+		}
+		if !extended {
+			return n
+		}
+	}
+}
+
+// Name returns the display name of the host of endpoint. A nil namer keeps the
+// first label.
+func (n *ServerNamer) Name(endpoint string) string {
+	if n == nil {
+		return TrimDomain(endpoint, "")
+	}
+	host := EndpointHost(endpoint)
+	if name, ok := n.names[host]; ok {
+		return name
+	}
+	return trimHost(host, n.firstLabel, n.domains)
+}
+
+// CommonDomain returns the longest DNS suffix, with a leading dot, that all
+// host names share while each keeps at least one label of its own. IP
+// addresses are ignored. It returns "" if there is no such suffix.
+func CommonDomain(hosts []string) string {
+	var common []string
+	found := false
+	for _, host := range hosts {
+		if net.ParseIP(strings.Trim(host, "[]")) != nil {
+			continue
+		}
+		labels := strings.Split(host, ".")
+		// The first label is the host's own
+		suffix := labels[1:]
+		if !found {
+			common, found = suffix, true
+			continue
+		}
+		shared := 0
+		for shared < len(common) && shared < len(suffix) &&
+			strings.EqualFold(common[len(common)-1-shared], suffix[len(suffix)-1-shared]) {
+			shared++
+		}
+		common = common[len(common)-shared:]
+	}
+	if len(common) == 0 {
+		return ""
+	}
+	return "." + strings.Join(common, ".")
+}
+
+// EndpointHost returns the host of an endpoint, without scheme, path and port
+func EndpointHost(endpoint string) string {
+	host := endpoint
+
+	// If endpoint contains a scheme or a path, try parsing it as a URL
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Host
+		}
+	} else if strings.Contains(host, "/") {
+		// try parsing by adding a scheme so url.Parse treats the first part as host
+		if u, err := url.Parse("http://" + host); err == nil {
+			host = u.Host
+		}
+	}
+
+	// Strip port if present (handles host:port and [ipv6]:port)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// Pools returns the pools of servers and their erasure sets, keyed by index
+func Pools(servers []madmin.ServerProperties) map[string]map[string]interface{} {
+	pools := make(map[string]map[string]interface{})
+
+	// Build pools structure from drive information
+	for _, server := range servers {
+		for _, disk := range server.Disks {
+			poolIdx := disk.PoolIndex
+			setIdx := disk.SetIndex
+			poolKey := strconv.Itoa(poolIdx)
+			setKey := strconv.Itoa(setIdx)
+
+			if pools[poolKey] == nil {
+				pools[poolKey] = make(map[string]interface{})
+			}
+			if pools[poolKey][setKey] == nil {
+				pools[poolKey][setKey] = make(map[string]interface{})
+			}
+		}
+	}
+	return pools
+}
+
+// ApplyErrorTimes sets the last-error ages of drives returned by ServerDrives for server,
+// measured from the snapshot time. Without a snapshot time the ages stay unknown.
+func ApplyErrorTimes(drives []DiskInfo, server madmin.ServerProperties, errorTimes map[string]DriveErrorTimes, snapshot time.Time) {
+	if len(errorTimes) == 0 || snapshot.IsZero() {
+		return
+	}
+	age := func(t time.Time) *time.Duration {
+		if t.IsZero() {
+			return nil
+		}
+		d := snapshot.Sub(t)
+		if d < 0 {
+			d = 0
+		}
+		return &d
+	}
+	for i, disk := range server.Disks {
+		if i >= len(drives) {
+			break
+		}
+		times, ok := errorTimes[DriveErrorKey(server.Endpoint, disk.Endpoint, disk.DrivePath)]
+		if !ok {
+			continue
+		}
+		drives[i].LastErrorAge = age(times.LastErrorAvailability)
+		drives[i].LastTimeoutAge = age(times.LastErrorTimeout)
+	}
+}
+
+// ApplyHealAges sets how long before the snapshot the drives started healing.
+// Older snapshots have no heal start time and keep no age, nor do drives of a
+// snapshot whose time is unknown.
+func ApplyHealAges(drives []DiskInfo, snapshot time.Time) {
+	if snapshot.IsZero() {
+		return
+	}
+	for i := range drives {
+		info := drives[i].HealInfo
+		if info == nil || info.Started.IsZero() {
+			continue
+		}
+		age := max(snapshot.Sub(info.Started), 0)
+		drives[i].HealAge = &age
+	}
+}
+
+// HealedItems returns the number of objects healed on a drive
+func HealedItems(info *madmin.HealingDisk) uint64 {
+	if info.ItemsHealed == 0 {
+		// Older servers only report the deprecated counter
+		return info.ObjectsHealed
+	}
+	return info.ItemsHealed
+}
+
+// HealProgressPct returns how much of a healing drive is done, by objects or
+// else by bytes, and false when the server reports neither total
+func HealProgressPct(info *madmin.HealingDisk) (float64, bool) {
+	switch {
+	case info == nil:
+		return 0, false
+	case info.ObjectsTotalCount > 0:
+		return min(float64(HealedItems(info))/float64(info.ObjectsTotalCount)*100, 100), true
+	case info.ObjectsTotalSize > 0:
+		return min(float64(info.BytesDone)/float64(info.ObjectsTotalSize)*100, 100), true
+	}
+	return 0, false
+}
+
+// SummarizeErasureSet counts good/bad/scanning drives of a set and averages their space and inode usage
+func SummarizeErasureSet(poolIdx, setIdx int, drives []DiskInfo) ErasureSetInfo {
+	es := ErasureSetInfo{
+		PoolIdx: poolIdx,
+		SetIdx:  setIdx,
+		Drives:  drives,
+	}
+	if len(drives) == 0 {
+		return es
+	}
+
+	var avgTotalSpace, avgUsedSpace, avgFreeSpace, avgUsedInodes, avgFreeInodes int64
+	for _, d := range drives {
+		if d.State == "ok" {
+			es.Good++
+		} else {
+			es.Bad++
+		}
+		if d.Scanning {
+			es.Scanning++
+			if pct, ok := HealProgressPct(d.HealInfo); ok && (es.MinHealPct == nil || pct < *es.MinHealPct) {
+				es.MinHealPct = &pct
+			}
+		}
+		avgTotalSpace += d.TotalSpace
+		avgUsedSpace += d.UsedSpace
+		avgFreeSpace += d.AvailableSpace
+		avgUsedInodes += d.UsedInodes
+		avgFreeInodes += d.FreeInodes
+	}
+	totalDrives := int64(len(drives))
+	avgTotalSpace /= totalDrives
+	avgUsedSpace /= totalDrives
+	avgFreeSpace /= totalDrives
+	avgUsedInodes /= totalDrives
+	avgFreeInodes /= totalDrives
+
+	if avgTotalSpace > 0 {
+		es.AvgSpaceUsedPct = float64(avgUsedSpace) / float64(avgTotalSpace) * 100
+		es.AvgFreeSpacePct = float64(avgFreeSpace) / float64(avgTotalSpace) * 100
+	}
+	if avgTotalInodes := avgUsedInodes + avgFreeInodes; avgTotalInodes > 0 {
+		es.AvgInodesUsedPct = float64(avgUsedInodes) / float64(avgTotalInodes) * 100
+	}
+	return es
+}
+
+// RiskLess reports whether erasure set a is at more risk than b: it has more
+// bad drives, or as many and more scanning drives, or as many of both and less
+// average free space. Sets at the same risk keep pool and set order, so the
+// order of SortByRisk does not depend on the order of its input.
+func RiskLess(a, b ErasureSetInfo) bool {
+	switch {
+	case a.Bad != b.Bad:
+		return a.Bad > b.Bad
+	case a.Scanning != b.Scanning:
+		return a.Scanning > b.Scanning
+	case a.AvgFreeSpacePct != b.AvgFreeSpacePct:
+		return a.AvgFreeSpacePct < b.AvgFreeSpacePct
+	case a.PoolIdx != b.PoolIdx:
+		return a.PoolIdx < b.PoolIdx
+	}
+	return a.SetIdx < b.SetIdx
+}
+
+// SortByRisk sorts erasure sets with the sets at most risk first, see RiskLess
+func SortByRisk(sets []ErasureSetInfo) {
+	sort.Slice(sets, func(i, j int) bool {
+		return RiskLess(sets[i], sets[j])
+	})
+}
+
+// Quorum returns the number of online drives an erasure set of setSize drives
+// with parity parity drives needs to serve reads and to accept writes. Writes
+// need one drive more when data and parity drives are as many.
+func Quorum(setSize, parity int) (read, write int) {
+	read = setSize - parity
+	write = read
+	if read == parity {
+		write++
+	}
+	return read, write
+}
+
+// PoolSpace is the usable capacity of a pool and how much of it is used, both
+// without the parity drives of its sets
+type PoolSpace struct {
+	Pool     int
+	Capacity int64
+	Used     int64
+}
+
+// PoolSpaces returns the usable capacity and used space of every pool in pool
+// order, leaving out the parity drives of every set like UsableSpace
+func PoolSpaces(poolSetDrives map[string][]DiskInfo, parityDisks int) []PoolSpace {
+	byPool := make(map[int]*PoolSpace)
+	for _, drives := range poolSetDrives {
+		if len(drives) == 0 || len(drives) < parityDisks {
+			continue
+		}
+		pool := drives[0].PoolIndex
+		if byPool[pool] == nil {
+			byPool[pool] = &PoolSpace{Pool: pool}
+		}
+		usableRatio := float64(len(drives)-parityDisks) / float64(len(drives))
+		for _, drive := range drives {
+			byPool[pool].Capacity += int64(float64(drive.TotalSpace) * usableRatio)
+			byPool[pool].Used += int64(float64(drive.UsedSpace) * usableRatio)
+		}
+	}
+	spaces := make([]PoolSpace, 0, len(byPool))
+	for _, space := range byPool {
+		spaces = append(spaces, *space)
+	}
+	sort.Slice(spaces, func(i, j int) bool {
+		return spaces[i].Pool < spaces[j].Pool
+	})
+	return spaces
+}
+
+// SetCapacity returns the usable capacity of an erasure set and how much of it
+// is free. Objects are striped over every drive of the set, so the set is full
+// once its smallest drive is: the capacity is the smallest drive and the free
+// space the least free space of a drive, times the data share of the drives
+// that report capacity. Like UsableSpace, drives that report no capacity, such
+// as offline ones, are left out, so the sets of uniform drives add up to the
+// usable capacity of the cluster. ok is false when no drive reports capacity or
+// the set has no more drives than parity.
+func SetCapacity(drives []DiskInfo, parity int) (usable, free int64, ok bool) {
+	dataDisks := int64(len(drives) - parity)
+	if dataDisks <= 0 {
+		return 0, 0, false
+	}
+	reporting := int64(0)
+	for _, drive := range drives {
+		if drive.TotalSpace <= 0 {
+			continue
+		}
+		if !ok || drive.TotalSpace < usable {
+			usable = drive.TotalSpace
+		}
+		if !ok || drive.AvailableSpace < free {
+			free = drive.AvailableSpace
+		}
+		ok = true
+		reporting++
+	}
+	total := int64(len(drives))
+	return usable * reporting * dataDisks / total, free * reporting * dataDisks / total, ok
+}
+
+// UsableSpace returns the capacity left for data once the parity drives of every set are excluded
+func UsableSpace(pools map[string]map[string]interface{}, poolSetDrives map[string][]DiskInfo, parityDisks int) int64 {
+	totalUsableSpace := int64(0)
+	for poolIdx, sets := range pools {
+		for setIdx := range sets {
+			key := fmt.Sprintf("%s:%s", poolIdx, setIdx)
+			drives := poolSetDrives[key]
+			totalDisksInSet := len(drives)
+			if totalDisksInSet > 0 && totalDisksInSet >= parityDisks {
+				dataDisks := totalDisksInSet - parityDisks
+				usableRatio := float64(dataDisks) / float64(totalDisksInSet)
+				for _, drive := range drives {
+					totalUsableSpace += int64(float64(drive.TotalSpace) * usableRatio)
+				}
+			}
+		}
+	}
+	return totalUsableSpace
+}
+
+// EndpointMismatch is a drive whose endpoint names another host than the
+// server it is listed under
+type EndpointMismatch struct {
+	ServerEndpoint string
+	DiskEndpoint   string
+	PoolIndex      int
+	SetIndex       int
+}
+
+// CheckEndpointHosts compares the host of every drive endpoint with the host of
+// the server it is listed under, both parsed like EndpointHost and compared
+// without case, and returns the drives that differ in server order along with
+// the number of drives compared. Drives without an endpoint host, such as
+// local drives listed by path only, are skipped.
+func CheckEndpointHosts(servers []madmin.ServerProperties) ([]EndpointMismatch, int) {
+	var mismatches []EndpointMismatch
+	compared := 0
+	for _, server := range servers {
+		serverHost := EndpointHost(server.Endpoint)
+		if serverHost == "" {
+			continue
+		}
+		for _, disk := range server.Disks {
+			diskHost := EndpointHost(disk.Endpoint)
+			if diskHost == "" {
+				continue
+			}
+			compared++
+			if !strings.EqualFold(diskHost, serverHost) {
+				mismatches = append(mismatches, EndpointMismatch{
+					ServerEndpoint: server.Endpoint,
+					DiskEndpoint:   disk.Endpoint,
+					PoolIndex:      disk.PoolIndex,
+					SetIndex:       disk.SetIndex,
+				})
+			}
+		}
+	}
+	return mismatches, compared
+}
+
+// DriveLocality counts the local and remote drives a server lists
+type DriveLocality struct {
+	Endpoint string
+	Local    int
+	Remote   int
+}
+
+// CheckDriveLocality returns the online servers that list remote drives, in
+// server order. A server reports its own drives as local, so remote drives
+// were listed on its behalf, typically because the snapshot was taken through
+// a node that could not reach its peers. That node is returned as the
+// perspective when it is the only server with local drives, "" otherwise.
+// Snapshots without any local drive do not report the flag and return nothing.
+func CheckDriveLocality(servers []madmin.ServerProperties) ([]DriveLocality, string) {
+	var remote []DriveLocality
+	var withLocal []string
+	for _, server := range servers {
+		locality := DriveLocality{Endpoint: server.Endpoint}
+		for _, disk := range server.Disks {
+			if disk.Local {
+				locality.Local++
+			} else {
+				locality.Remote++
+			}
+		}
+		if locality.Local > 0 {
+			withLocal = append(withLocal, server.Endpoint)
+		}
+		if locality.Remote > 0 && server.State == "online" {
+			remote = append(remote, locality)
+		}
+	}
+	if len(withLocal) == 0 {
+		return nil, ""
+	}
+	perspective := ""
+	if len(withLocal) == 1 && len(remote) > 0 {
+		perspective = withLocal[0]
+	}
+	return remote, perspective
+}
+
+// UUIDCheck is the result of CheckUUIDs
+type UUIDCheck struct {
+	Duplicates [][]DiskInfo // drives sharing a UUID, one group per UUID in UUID order
+	Empty      int          // drives without a UUID
+}
+
+// CheckUUIDs groups drives by UUID and counts the drives without one. Drives of
+// the servers in offline are skipped, their UUIDs are often missing or stale.
+func CheckUUIDs(drives []DiskInfo, offline map[string]bool) UUIDCheck {
+	var check UUIDCheck
+	byUUID := make(map[string][]DiskInfo)
+	for _, drive := range drives {
+		switch {
+		case offline[drive.Server]:
+		case drive.UUID == "":
+			check.Empty++
+		default:
+			byUUID[drive.UUID] = append(byUUID[drive.UUID], drive)
+		}
+	}
+	uuids := make([]string, 0, len(byUUID))
+	for uuid, group := range byUUID {
+		if len(group) > 1 {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		check.Duplicates = append(check.Duplicates, byUUID[uuid])
+	}
+	return check
+}
+
+// CheckTopology returns the violations of the invariants of an erasure coded
+// layout, in pool and set order: pools and the sets of each pool are numbered
+// from 0 without gaps, every set of a pool has the same number of drives, the
+// drives of a set are spread evenly over the servers of its pool, and every
+// server of a pool holds the same number of its drives. The set counts and set
+// sizes of backend are expected when it reports them, the most common ones
+// otherwise.
+func CheckTopology(drives []DiskInfo, backend madmin.ErasureBackend) []string {
+	var findings []string
+	poolSets := make(map[int]map[int][]DiskInfo)
+	for _, drive := range drives {
+		if poolSets[drive.PoolIndex] == nil {
+			poolSets[drive.PoolIndex] = make(map[int][]DiskInfo)
+		}
+		poolSets[drive.PoolIndex][drive.SetIndex] = append(poolSets[drive.PoolIndex][drive.SetIndex], drive)
+	}
+
+	poolIndices := make([]int, 0, len(poolSets))
+	for pool := range poolSets {
+		poolIndices = append(poolIndices, pool)
+	}
+	sort.Ints(poolIndices)
+	for pool := 0; len(poolIndices) > 0 && pool < poolIndices[len(poolIndices)-1]; pool++ {
+		if poolSets[pool] == nil {
+			findings = append(findings, fmt.Sprintf("pool %d has no drives", pool))
+		}
+	}
+
+	for _, pool := range poolIndices {
+		sets := poolSets[pool]
+		setIndices := make([]int, 0, len(sets))
+		setSizes := make([]int, 0, len(sets))
+		serverDrives := make(map[string]int)
+		for set, setDrives := range sets {
+			setIndices = append(setIndices, set)
+			setSizes = append(setSizes, len(setDrives))
+			for _, drive := range setDrives {
+				serverDrives[drive.Server]++
+			}
+		}
+		sort.Ints(setIndices)
+
+		highest := setIndices[len(setIndices)-1]
+		for set := 0; set < highest; set++ {
+			if sets[set] == nil {
+				findings = append(findings, fmt.Sprintf("pool %d set %d has no drives", pool, set))
+			}
+		}
+		if pool < len(backend.TotalSets) && backend.TotalSets[pool] > highest+1 {
+			findings = append(findings, fmt.Sprintf("pool %d has %d sets, expected %d", pool, highest+1, backend.TotalSets[pool]))
+		}
+
+		setSize := mostCommon(setSizes)
+		if pool < len(backend.DrivesPerSet) && backend.DrivesPerSet[pool] > 0 {
+			setSize = backend.DrivesPerSet[pool]
+		}
+		// A set may only have more than one drive on a server if the pool has
+		// fewer servers than drives per set
+		perServer := (setSize + len(serverDrives) - 1) / len(serverDrives)
+		for _, set := range setIndices {
+			setDrives := sets[set]
+			if len(setDrives) != setSize {
+				findings = append(findings, fmt.Sprintf("pool %d set %d has %d drives, expected %d", pool, set, len(setDrives), setSize))
+			}
+			onServer := make(map[string]int)
+			for _, drive := range setDrives {
+				onServer[drive.Server]++
+			}
+			for _, server := range sortedKeys(onServer) {
+				if onServer[server] > perServer {
+					findings = append(findings, fmt.Sprintf("server %s holds %d drives of pool %d set %d, expected at most %d", server, onServer[server], pool, set, perServer))
+				}
+			}
+		}
+
+		counts := make([]int, 0, len(serverDrives))
+		for _, count := range serverDrives {
+			counts = append(counts, count)
+		}
+		serverSize := mostCommon(counts)
+		for _, server := range sortedKeys(serverDrives) {
+			if serverDrives[server] != serverSize {
+				findings = append(findings, fmt.Sprintf("server %s has %d drives in pool %d, expected %d", server, serverDrives[server], pool, serverSize))
+			}
+		}
+	}
+	return findings
+}
+
+// mostCommon returns the most common of values, the largest one on a tie
+func mostCommon(values []int) int {
+	counts := make(map[int]int)
+	best := 0
+	for _, value := range values {
+		counts[value]++
+		if counts[value] > counts[best] || counts[value] == counts[best] && value > best {
+			best = value
+		}
+	}
+	return best
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
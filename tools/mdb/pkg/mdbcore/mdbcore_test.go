@@ -0,0 +1,767 @@
+package mdbcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// loadFixture loads a snapshot from testdata
+func loadFixture(t *testing.T, name string) *Snapshot {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	snapshot, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load(%s) error = %v", name, err)
+	}
+	return snapshot
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		file         string
+		deploymentID string
+		wrapped      bool
+		timestamp    time.Time
+		servers      int
+	}{
+		{"plain.json", "plain-deployment", false, time.Time{}, 2},
+		{"wrapped.json", "wrapped-deployment", true, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), 2},
+		{"records.ndjson", "series", false, time.Time{}, 1},
+	}
+	for _, tt := range tests {
+		snapshot := loadFixture(t, tt.file)
+		if snapshot.Status != "success" {
+			t.Errorf("%s: Status = %q, want success", tt.file, snapshot.Status)
+		}
+		if snapshot.Info.DeploymentID != tt.deploymentID {
+			t.Errorf("%s: DeploymentID = %q, want %q", tt.file, snapshot.Info.DeploymentID, tt.deploymentID)
+		}
+		if snapshot.Wrapped != tt.wrapped {
+			t.Errorf("%s: Wrapped = %v, want %v", tt.file, snapshot.Wrapped, tt.wrapped)
+		}
+		if !snapshot.Timestamp.Equal(tt.timestamp) {
+			t.Errorf("%s: Timestamp = %v, want %v", tt.file, snapshot.Timestamp, tt.timestamp)
+		}
+		if len(snapshot.Info.Servers) != tt.servers {
+			t.Errorf("%s: %d servers, want %d", tt.file, len(snapshot.Info.Servers), tt.servers)
+		}
+	}
+
+	_, err := Load(strings.NewReader(`{"status":"success","info":{"servers":[]}} trailing`))
+	if _, ok := err.(*FormatError); !ok {
+		t.Errorf("Load() of invalid data error = %v, want *FormatError", err)
+	}
+}
+
+func TestLoadRecords(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "records.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, skipped, err := LoadRecords(file)
+	if err != nil {
+		t.Fatalf("LoadRecords() error = %v", err)
+	}
+	// The invalid line and the record without servers are skipped, the empty line is not counted
+	if skipped != 2 {
+		t.Errorf("skipped = %d, want 2", skipped)
+	}
+	if len(records) != 2 || records[0].Line != 1 || records[1].Line != 5 {
+		t.Fatalf("records = %+v, want lines 1 and 5", records)
+	}
+	if stats := records[1].ClusterStats(); stats.BadDisks != 1 || stats.UsedSpace != 600 {
+		t.Errorf("second record BadDisks = %d, UsedSpace = %d; want 1, 600", stats.BadDisks, stats.UsedSpace)
+	}
+
+	_, _, err = LoadRecords(strings.NewReader("not json\n"))
+	if err == nil || !strings.Contains(err.Error(), "no NDJSON records with servers found (line 1:") {
+		t.Errorf("LoadRecords() of invalid data error = %v", err)
+	}
+}
+
+func TestDrives(t *testing.T) {
+	snapshot := loadFixture(t, "plain.json")
+
+	all := snapshot.Drives(DriveFilter{TrimDomain: "example.com"})
+	if len(all) != 4 {
+		t.Fatalf("Drives() returned %d drives, want 4", len(all))
+	}
+	if all[0].Server != "node1" || all[0].Path != "/data/disk1" || all[0].UsedSpacePct != 60 {
+		t.Errorf("first drive = %s %s %.0f%%, want node1 /data/disk1 60%%", all[0].Server, all[0].Path, all[0].UsedSpacePct)
+	}
+
+	failed := snapshot.Drives(DriveFilter{Failed: true})
+	if len(failed) != 1 || failed[0].UUID != "uuid-4" || failed[0].Server != "node2" {
+		t.Errorf("failed drives = %+v, want uuid-4 of node2", failed)
+	}
+	scanning := snapshot.Drives(DriveFilter{Scanning: true})
+	if len(scanning) != 1 || scanning[0].UUID != "uuid-2" {
+		t.Errorf("scanning drives = %+v, want uuid-2", scanning)
+	}
+	if drives := snapshot.DrivesBySet(DriveFilter{})[SetKey(0, 0)]; len(drives) != 4 {
+		t.Errorf("DrivesBySet() set 0:0 has %d drives, want 4", len(drives))
+	}
+}
+
+func TestDiskIndex(t *testing.T) {
+	snapshot, err := Load(strings.NewReader(`{"status":"success","info":{"servers":[{"endpoint":"node1:9000","drives":[
+		{"path":"/data/disk1","state":"ok","disk_index":3},
+		{"path":"/data/disk2","state":"ok","disk_index":"7"},
+		{"path":"/data/disk3","state":"ok","disk_index":null},
+		{"path":"/data/disk4","state":"ok"},
+		{"path":"/data/disk5","state":"ok","disk_index":"first"},
+		{"path":"/data/disk6","state":"ok","disk_index":2.5},
+		{"path":"/data/disk7","state":"ok","disk_index":-1},
+		{"path":"/data/disk8","state":"ok","disk_index":0}
+	]}]}}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var got []string
+	for _, drive := range snapshot.Drives(DriveFilter{}) {
+		if drive.NoDiskIndex {
+			got = append(got, "-")
+		} else {
+			got = append(got, strconv.Itoa(drive.DiskIndex))
+		}
+	}
+	if want := "3 7 - - - - - 0"; strings.Join(got, " ") != want {
+		t.Errorf("disk indexes = %s, want %s", strings.Join(got, " "), want)
+	}
+
+	// Every caller of ServerDrives sees the missing indexes, also after a
+	// round trip through JSON as in a support bundle
+	data, err := json.Marshal(snapshot.Info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(strings.NewReader(`{"status":"success","info":` + string(data) + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, server := range map[string]madmin.ServerProperties{"loaded": snapshot.Info.Servers[0], "reloaded": reloaded.Info.Servers[0]} {
+		got = nil
+		for _, drive := range ServerDrives(server, nil) {
+			if drive.NoDiskIndex {
+				got = append(got, "-")
+			} else {
+				got = append(got, strconv.Itoa(drive.DiskIndex))
+			}
+		}
+		if want := "3 7 - - - - - 0"; strings.Join(got, " ") != want {
+			t.Errorf("%s ServerDrives disk indexes = %s, want %s", name, strings.Join(got, " "), want)
+		}
+	}
+}
+
+func TestIntLiteral(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{"-3", -3, true},
+		{"123456789", 123456789, true},
+		{"1234567890", 0, false}, // left to json.Unmarshal
+		{"2.5", 0, false},
+		{"1e3", 0, false},
+		{`"7"`, 0, false},
+		{"-", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tests {
+		if got, ok := intLiteral(json.RawMessage(tc.raw)); got != tc.want || ok != tc.ok {
+			t.Errorf("intLiteral(%q) = %d, %v; want %d, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestBucketsUsage(t *testing.T) {
+	snapshot, err := Load(strings.NewReader(`{"status":"success","info":{"buckets":{"count":3},"bucketsUsageInfo":{
+		"logs":{"size":2048,"objectsCount":10,"versionsCount":12,"deleteMarkersCount":2},
+		"images":{"size":4096,"objectsCount":3},
+		"empty":{"size":0}
+	},"servers":[{"endpoint":"node1:9000","drives":[{"path":"/data/disk1","state":"ok"}]}]}}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if snapshot.Info.Buckets.Count != 3 {
+		t.Errorf("Buckets.Count = %d, want 3", snapshot.Info.Buckets.Count)
+	}
+	var got []string
+	for _, bucket := range SortedBuckets(snapshot.BucketsUsage) {
+		got = append(got, fmt.Sprintf("%s:%d:%d:%d", bucket.Name, bucket.Size, bucket.ObjectsCount, bucket.DeleteMarkersCount))
+	}
+	if want := "images:4096:3:0 logs:2048:10:2 empty:0:0:0"; strings.Join(got, " ") != want {
+		t.Errorf("SortedBuckets() = %s, want %s", strings.Join(got, " "), want)
+	}
+
+	// Captures without per-bucket usage, or with a malformed one, have none
+	for _, doc := range []string{
+		`{"status":"success","info":{"servers":[{"endpoint":"node1:9000"}]}}`,
+		`{"status":"success","info":{"bucketsUsageInfo":[1],"servers":[{"endpoint":"node1:9000"}]}}`,
+	} {
+		snapshot, err := Load(strings.NewReader(doc))
+		if err != nil || snapshot.BucketsUsage != nil {
+			t.Errorf("Load(%s) = %v, error %v, want no buckets usage", doc, snapshot.BucketsUsage, err)
+		}
+	}
+}
+
+func TestErrorTimes(t *testing.T) {
+	want := time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name    string
+		metrics string
+		timeout time.Time
+	}{
+		{"RFC 3339", `{"totalErrorsTimeout":3,"lastErrorTimeout":"2024-05-02T13:00:00Z"}`, want},
+		{"epoch", fmt.Sprintf(`{"totalErrorsTimeout":3,"lastErrorTimeout":%d}`, want.Unix()), want},
+		{"missing", `{"totalErrorsTimeout":3}`, time.Time{}},
+		{"malformed", `{"totalErrorsTimeout":3,"lastErrorTimeout":"yesterday"}`, time.Time{}},
+	} {
+		snapshot, err := Load(strings.NewReader(`{"info":{"servers":[{"endpoint":"node1:9000","drives":[` +
+			`{"endpoint":"/data/disk1","path":"/data/disk1","state":"ok","metrics":` + tc.metrics + `}]}]}}`))
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", tc.name, err)
+		}
+		if metrics := snapshot.Info.Servers[0].Disks[0].Metrics; metrics == nil || metrics.TotalErrorsTimeout != 3 {
+			t.Errorf("%s: metrics = %+v, want the counters kept", tc.name, metrics)
+		}
+		times, ok := snapshot.ErrorTimes[DriveErrorKey("node1:9000", "/data/disk1", "/data/disk1")]
+		if ok != !tc.timeout.IsZero() || !times.LastErrorTimeout.Equal(tc.timeout) || !times.LastErrorAvailability.IsZero() {
+			t.Errorf("%s: error times = %+v, %v; want timeout %v", tc.name, times, ok, tc.timeout)
+		}
+	}
+}
+
+func TestErasureSets(t *testing.T) {
+	sets := loadFixture(t, "wrapped.json").ErasureSets()
+	if len(sets) != 2 {
+		t.Fatalf("ErasureSets() returned %d sets, want 2", len(sets))
+	}
+	for i, set := range sets {
+		if set.PoolIdx != 0 || set.SetIdx != i || len(set.Drives) != 2 || set.AvgSpaceUsedPct != 25 {
+			t.Errorf("set %d = pool %d set %d, %d drives, %.0f%% used", i, set.PoolIdx, set.SetIdx, len(set.Drives), set.AvgSpaceUsedPct)
+		}
+	}
+	if sets[0].Good != 2 || sets[0].Bad != 0 || sets[1].Good != 1 || sets[1].Bad != 1 {
+		t.Errorf("good/bad = %d/%d and %d/%d, want 2/0 and 1/1", sets[0].Good, sets[0].Bad, sets[1].Good, sets[1].Bad)
+	}
+}
+
+func TestIsErasure(t *testing.T) {
+	for backend, want := range map[string]bool{"": true, "Erasure": true, "FS": false, "Gateway": false} {
+		snapshot := &Snapshot{}
+		if err := json.Unmarshal([]byte(`{"backendType":"`+backend+`"}`), &snapshot.Info.Backend); err != nil {
+			t.Fatal(err)
+		}
+		if got := snapshot.IsErasure(); got != want || snapshot.BackendType() != backend {
+			t.Errorf("backend %q: IsErasure() = %v, BackendType() = %q", backend, got, snapshot.BackendType())
+		}
+	}
+}
+
+func TestMissingTopology(t *testing.T) {
+	// drives returns a server list of n drives of one server, all in pool 0 set 0
+	drives := func(n int, indices string) string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = fmt.Sprintf(`{"path":"/data/disk%d","state":"ok"%s}`, i+1, indices)
+		}
+		return `"servers":[{"endpoint":"node1:9000","drives":[` + strings.Join(list, ",") + `]}]`
+	}
+	for _, tc := range []struct {
+		name    string
+		doc     string
+		missing map[string]int
+		want    string
+	}{
+		{"old release", `{"info":{` + drives(20, "") + `}}`,
+			map[string]int{FieldBackend: 1, FieldPoolIndex: 20, FieldSetIndex: 20, FieldDiskIndex: 20},
+			"the drives report no pool or set index, but 20 drives cannot form a single erasure set of at most 16"},
+		{"backend sets", `{"info":{"backend":{"backendType":"Erasure","totalSets":[2]},` + drives(8, `,"pool_index":0,"set_index":0,"disk_index":0`) + `}}`,
+			nil, "every drive is in pool 0 set 0, but the backend reports 2 erasure sets"},
+		{"single set", `{"info":{` + drives(16, "") + `}}`,
+			map[string]int{FieldBackend: 1, FieldPoolIndex: 16, FieldSetIndex: 16, FieldDiskIndex: 16}, ""},
+		{"fs", `{"info":{"backend":{"backendType":"FS"},` + drives(20, "") + `}}`,
+			map[string]int{FieldPoolIndex: 20, FieldSetIndex: 20, FieldDiskIndex: 20}, ""},
+	} {
+		snapshot, err := Load(strings.NewReader(tc.doc))
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", tc.name, err)
+		}
+		if fmt.Sprint(snapshot.Missing) != fmt.Sprint(tc.missing) {
+			t.Errorf("%s: Missing = %v, want %v", tc.name, snapshot.Missing, tc.missing)
+		}
+		if got := snapshot.MissingTopology(); got != tc.want {
+			t.Errorf("%s: MissingTopology() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// An index that is not a number is a type error of the layout, not pool or set 0
+	for _, indices := range []string{`,"pool_index":"one","set_index":0`, `,"pool_index":0,"set_index":1.5`} {
+		_, err := Load(strings.NewReader(`{"minio":{"info":{` + drives(4, indices) + `}}}`))
+		if err == nil || !strings.Contains(err.Error(), "info.servers.drives.") {
+			t.Errorf("Load(%s) error = %v, want a type error of the index", indices, err)
+		}
+		if snapshot, _ := Load(strings.NewReader(`{"info":{` + drives(4, indices) + `}}`)); snapshot != nil && len(snapshot.Info.Servers) > 0 {
+			t.Errorf("Load(%s) kept the drives of a malformed index", indices)
+		}
+	}
+
+	// Drives in other sets are taken as reported
+	if got := loadFixture(t, "wrapped.json").MissingTopology(); got != "" {
+		t.Errorf("wrapped.json: MissingTopology() = %q, want none", got)
+	}
+}
+
+func TestClusterStats(t *testing.T) {
+	tests := []struct {
+		file string
+		want ClusterStats
+	}{
+		{"plain.json", ClusterStats{
+			TotalDisks: 4, ScanningDisks: 1, OkDisks: 3, BadDisks: 1,
+			TotalSpace: 4000, UsedSpace: 2400, FreeSpace: 1600, DeploymentID: "plain-deployment",
+			ParityDisks: 2, UsableSpace: 2000,
+		}},
+		{"wrapped.json", ClusterStats{
+			TotalDisks: 4, OkDisks: 3, BadDisks: 1,
+			TotalSpace: 8000, UsedSpace: 2000, FreeSpace: 6000, DeploymentID: "wrapped-deployment",
+			ParityDisks: 1, UsableSpace: 4000,
+			Snapshot: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		if got := loadFixture(t, tt.file).ClusterStats(); got != tt.want {
+			t.Errorf("%s: ClusterStats() = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+
+	var empty Snapshot
+	if parity := empty.ParityDisks(); parity != DefaultParityDisks {
+		t.Errorf("ParityDisks() without backend = %d, want %d", parity, DefaultParityDisks)
+	}
+}
+
+func TestTrimDomain(t *testing.T) {
+	tests := []struct {
+		endpoint, domain, want string
+	}{
+		{"node1.example.com:9000", "example.com", "node1"},
+		{"https://node1.example.com:9000", "", "node1"},
+		{"node1.dc1.example.com:9000", "example.com", "node1.dc1"},
+		{"node1.other.com:9000", "example.com", "node1.other.com"},
+		{"10.0.0.1:9000", "", "10.0.0.1"},
+		{"http://10.0.0.1:9000/data/disk1", "", "10.0.0.1"},
+		{"https://[fd00::12]:9000", "", "fd00::12"},
+		{"[fd00::12]:9000/data/disk1", "example.com", "fd00::12"},
+		{"[fd00::12]", "", "fd00::12"},
+	}
+	for _, tt := range tests {
+		if got := TrimDomain(tt.endpoint, tt.domain); got != tt.want {
+			t.Errorf("TrimDomain(%q, %q) = %q, want %q", tt.endpoint, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestPathFromEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint, want string
+	}{
+		{"http://node1.example.com:9000/data/disk1", "/data/disk1"},
+		{"https://10.0.0.1:9000/mnt/drive1/minio", "/mnt/drive1/minio"},
+		{"https://[fd00::12]:9000/data/disk1", "/data/disk1"},
+		{"node1:9000/data/disk1", "/data/disk1"},
+		{"[fd00::12]:9000/data/disk1", "/data/disk1"},
+		{"/data/disk1", "/data/disk1"},
+		{"http://node1:9000/hadoop/data1", "/data1"},
+		{"http://node1:9000", ""},
+		{"http://[fd00::12]:9000/", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := PathFromEndpoint(tt.endpoint); got != tt.want {
+			t.Errorf("PathFromEndpoint(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+
+	server := madmin.ServerProperties{
+		Endpoint: "[fd00::12]:9000",
+		Disks:    []madmin.Disk{{Endpoint: "https://[fd00::12]:9000/data/disk1"}},
+	}
+	drives := ServerDrives(server, NewServerNamer([]madmin.ServerProperties{server}, ""))
+	if len(drives) != 1 || drives[0].Server != "fd00::12" || drives[0].Path != "/data/disk1" {
+		t.Errorf("ServerDrives(%q) = %+v, want server fd00::12 and path /data/disk1", server.Endpoint, drives)
+	}
+}
+
+func TestCheckUUIDs(t *testing.T) {
+	drives := []DiskInfo{
+		{Server: "node1", Path: "/d1", UUID: "b"},
+		{Server: "node1", Path: "/d2", UUID: "a"},
+		{Server: "node1", Path: "/d3"},
+		{Server: "node2", Path: "/d1", UUID: "a"},
+		{Server: "node2", Path: "/d2", UUID: "b"},
+		{Server: "node3", Path: "/d1", UUID: "a"},
+		{Server: "node3", Path: "/d2"},
+	}
+	check := CheckUUIDs(drives, map[string]bool{"node3": true})
+	if check.Empty != 1 {
+		t.Errorf("Empty = %d, want 1", check.Empty)
+	}
+	var got []string
+	for _, group := range check.Duplicates {
+		for _, drive := range group {
+			got = append(got, drive.UUID+" "+drive.Server+drive.Path)
+		}
+	}
+	want := "a node1/d2, a node2/d1, b node1/d1, b node2/d2"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Duplicates = %v, want %s", got, want)
+	}
+}
+
+func TestCheckTopology(t *testing.T) {
+	// set lists the servers of the drives of pool:set
+	type set struct {
+		pool, set int
+		servers   []string
+	}
+	nodes := []string{"node1", "node2", "node3", "node4"}
+	tests := []struct {
+		name    string
+		sets    []set
+		backend madmin.ErasureBackend
+		want    []string
+	}{
+		{"consistent", []set{{0, 0, nodes}, {0, 1, nodes}}, madmin.ErasureBackend{TotalSets: []int{2}, DrivesPerSet: []int{4}}, nil},
+		{"two drives per server", []set{{0, 0, []string{"node1", "node1", "node2", "node2"}}}, madmin.ErasureBackend{}, nil},
+		{"short set", []set{{0, 0, nodes}, {0, 1, nodes[:3]}}, madmin.ErasureBackend{DrivesPerSet: []int{4}}, []string{
+			"pool 0 set 1 has 3 drives, expected 4",
+			"server node4 has 1 drives in pool 0, expected 2",
+		}},
+		{"drives on one host", []set{{0, 0, []string{"node1", "node1", "node3", "node4"}}, {0, 1, nodes}}, madmin.ErasureBackend{}, []string{
+			"server node1 holds 2 drives of pool 0 set 0, expected at most 1",
+			"server node1 has 3 drives in pool 0, expected 2",
+			"server node2 has 1 drives in pool 0, expected 2",
+		}},
+		{"gaps", []set{{0, 0, nodes}, {0, 2, nodes}, {2, 0, nodes}}, madmin.ErasureBackend{TotalSets: []int{4}}, []string{
+			"pool 1 has no drives",
+			"pool 0 set 1 has no drives",
+			"pool 0 has 3 sets, expected 4",
+		}},
+	}
+	for _, tt := range tests {
+		var drives []DiskInfo
+		for _, s := range tt.sets {
+			for _, server := range s.servers {
+				drives = append(drives, DiskInfo{Server: server, PoolIndex: s.pool, SetIndex: s.set})
+			}
+		}
+		got := CheckTopology(drives, tt.backend)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: CheckTopology() =\n%s\nwant:\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestCheckEndpointHosts(t *testing.T) {
+	servers := []madmin.ServerProperties{
+		{Endpoint: "node1.corp.net:9000", Disks: []madmin.Disk{
+			{Endpoint: "https://NODE1.corp.net:9000/data/disk1"},
+			{Endpoint: "https://node2.corp.net:9000/data/disk2", PoolIndex: 1, SetIndex: 3},
+			{Endpoint: "", DrivePath: "/data/disk3", Local: true},
+			{Endpoint: "/data/disk4"},
+		}},
+		{Endpoint: "[fd00::12]:9000", Disks: []madmin.Disk{{Endpoint: "http://[fd00::12]:9000/data/disk1"}}},
+	}
+	mismatches, compared := CheckEndpointHosts(servers)
+	if compared != 3 {
+		t.Errorf("compared %d drives, want 3", compared)
+	}
+	want := []EndpointMismatch{{ServerEndpoint: "node1.corp.net:9000", DiskEndpoint: "https://node2.corp.net:9000/data/disk2", PoolIndex: 1, SetIndex: 3}}
+	if fmt.Sprint(mismatches) != fmt.Sprint(want) {
+		t.Errorf("mismatches = %+v, want %+v", mismatches, want)
+	}
+}
+
+func TestCheckDriveLocality(t *testing.T) {
+	disks := func(local ...bool) []madmin.Disk {
+		var disks []madmin.Disk
+		for _, l := range local {
+			disks = append(disks, madmin.Disk{Local: l})
+		}
+		return disks
+	}
+	tests := []struct {
+		name        string
+		servers     []madmin.ServerProperties
+		remote      []DriveLocality
+		perspective string
+	}{
+		{
+			name: "healthy",
+			servers: []madmin.ServerProperties{
+				{Endpoint: "node1:9000", State: "online", Disks: disks(true, true)},
+				{Endpoint: "node2:9000", State: "online", Disks: disks(true, true)},
+			},
+		},
+		{
+			name: "no local flags",
+			servers: []madmin.ServerProperties{
+				{Endpoint: "node1:9000", State: "online", Disks: disks(false, false)},
+				{Endpoint: "node2:9000", State: "online", Disks: disks(false, false)},
+			},
+		},
+		{
+			name: "single node",
+			servers: []madmin.ServerProperties{
+				{Endpoint: "node1:9000", State: "online", Disks: disks(false, false)},
+				{Endpoint: "node2:9000", State: "online", Disks: disks(true, true)},
+				{Endpoint: "node3:9000", State: "offline", Disks: disks(false, false)},
+			},
+			remote:      []DriveLocality{{Endpoint: "node1:9000", Remote: 2}},
+			perspective: "node2:9000",
+		},
+		{
+			name: "partly remote",
+			servers: []madmin.ServerProperties{
+				{Endpoint: "node1:9000", State: "online", Disks: disks(true, false)},
+				{Endpoint: "node2:9000", State: "online", Disks: disks(true, true)},
+			},
+			remote: []DriveLocality{{Endpoint: "node1:9000", Local: 1, Remote: 1}},
+		},
+	}
+	for _, tt := range tests {
+		remote, perspective := CheckDriveLocality(tt.servers)
+		if fmt.Sprint(remote) != fmt.Sprint(tt.remote) || perspective != tt.perspective {
+			t.Errorf("%s: CheckDriveLocality() = %+v, %q, want %+v, %q", tt.name, remote, perspective, tt.remote, tt.perspective)
+		}
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		setSize, parity, read, write int
+	}{
+		{16, 4, 12, 12},
+		{8, 4, 4, 5},
+		{4, 2, 2, 3},
+		{6, 2, 4, 4},
+	}
+	for _, tt := range tests {
+		if read, write := Quorum(tt.setSize, tt.parity); read != tt.read || write != tt.write {
+			t.Errorf("Quorum(%d, %d) = %d, %d, want %d, %d", tt.setSize, tt.parity, read, write, tt.read, tt.write)
+		}
+	}
+}
+
+func TestSortByRisk(t *testing.T) {
+	set := func(pool, set, bad, scanning int, freePct float64) ErasureSetInfo {
+		return ErasureSetInfo{PoolIdx: pool, SetIdx: set, Bad: bad, Scanning: scanning, AvgFreeSpacePct: freePct}
+	}
+	sets := []ErasureSetInfo{
+		set(0, 0, 0, 0, 50),
+		set(0, 1, 1, 0, 80),
+		set(0, 2, 0, 2, 70),
+		set(1, 0, 1, 1, 90),
+		set(1, 1, 0, 0, 10),
+		set(1, 2, 1, 0, 20),
+		set(1, 3, 0, 0, 50),
+	}
+	// Bad drives first, then scanning drives, then the least free space; ties
+	// in pool and set order
+	want := "1:0 1:2 0:1 0:2 1:1 0:0 1:3"
+	for _, order := range [][]int{{0, 1, 2, 3, 4, 5, 6}, {6, 5, 4, 3, 2, 1, 0}, {3, 6, 0, 5, 1, 4, 2}} {
+		shuffled := make([]ErasureSetInfo, 0, len(sets))
+		for _, i := range order {
+			shuffled = append(shuffled, sets[i])
+		}
+		SortByRisk(shuffled)
+		keys := make([]string, 0, len(shuffled))
+		for _, es := range shuffled {
+			keys = append(keys, fmt.Sprintf("%d:%d", es.PoolIdx, es.SetIdx))
+		}
+		if got := strings.Join(keys, " "); got != want {
+			t.Errorf("SortByRisk(%v) = %s, want %s", order, got, want)
+		}
+	}
+	if RiskLess(sets[0], sets[0]) {
+		t.Errorf("RiskLess of a set with itself should be false")
+	}
+}
+
+func TestPoolSpaces(t *testing.T) {
+	poolSetDrives := map[string][]DiskInfo{
+		"0:0": {{TotalSpace: 1000, UsedSpace: 400}, {TotalSpace: 1000, UsedSpace: 400}, {TotalSpace: 1000, UsedSpace: 400}, {TotalSpace: 1000, UsedSpace: 400}},
+		"1:0": {{PoolIndex: 1, TotalSpace: 3000, UsedSpace: 300}, {PoolIndex: 1, TotalSpace: 3000, UsedSpace: 300}, {PoolIndex: 1, TotalSpace: 3000, UsedSpace: 300}},
+		"1:1": {{PoolIndex: 1, SetIndex: 1, TotalSpace: 1000}},
+	}
+	got := fmt.Sprint(PoolSpaces(poolSetDrives, 2))
+	if want := "[{0 2000 800} {1 3000 300}]"; got != want {
+		t.Errorf("PoolSpaces() = %s, want %s", got, want)
+	}
+}
+
+func TestSetCapacity(t *testing.T) {
+	drive := func(total, free int64) DiskInfo {
+		return DiskInfo{TotalSpace: total, UsedSpace: total - free, AvailableSpace: free}
+	}
+	tests := []struct {
+		name         string
+		drives       []DiskInfo
+		parity       int
+		usable, free int64
+		ok           bool
+	}{
+		{"uniform", []DiskInfo{drive(1000, 400), drive(1000, 400), drive(1000, 400), drive(1000, 400)}, 2, 2000, 800, true},
+		{"smallest drive", []DiskInfo{drive(2000, 1500), drive(1000, 900), drive(2000, 300), drive(2000, 1500)}, 2, 2000, 600, true},
+		{"offline drive", []DiskInfo{drive(1000, 400), {State: "offline"}, drive(1000, 500), drive(1000, 600)}, 2, 1500, 600, true},
+		{"no capacity", []DiskInfo{{State: "offline"}, {State: "offline"}, {State: "offline"}}, 2, 0, 0, false},
+		{"parity only", []DiskInfo{drive(1000, 400), drive(1000, 400)}, 2, 0, 0, false},
+	}
+	for _, tt := range tests {
+		usable, free, ok := SetCapacity(tt.drives, tt.parity)
+		if usable != tt.usable || free != tt.free || ok != tt.ok {
+			t.Errorf("%s: SetCapacity() = %d, %d, %v, want %d, %d, %v", tt.name, usable, free, ok, tt.usable, tt.free, tt.ok)
+		}
+	}
+}
+
+func TestServerNamer(t *testing.T) {
+	servers := func(endpoints ...string) []madmin.ServerProperties {
+		props := make([]madmin.ServerProperties, 0, len(endpoints))
+		for _, endpoint := range endpoints {
+			props = append(props, madmin.ServerProperties{Endpoint: endpoint})
+		}
+		return props
+	}
+	tests := []struct {
+		name       string
+		endpoints  []string
+		trimDomain string
+		want       []string
+	}{
+		{"first label", []string{"node1.dc1.corp.net:9000", "node2.dc2.corp.net:9000"}, "", []string{"node1", "node2"}},
+		{"single suffix", []string{"node1.dc1.corp.net:9000", "node2.dc2.corp.net:9000"}, ".dc1.corp.net", []string{"node1", "node2.dc2.corp.net"}},
+		{"several suffixes", []string{"node1.dc1.corp.net:9000", "node2.dc2.corp.net:9000"}, ".dc1.corp.net, .dc2.corp.net", []string{"node1", "node2"}},
+		{"longest suffix first", []string{"node1.dc1.corp.net:9000"}, ".corp.net,.dc1.corp.net", []string{"node1"}},
+		{"auto", []string{"node1.dc1.corp.net:9000", "node2.dc2.corp.net:9000"}, "auto", []string{"node1.dc1", "node2.dc2"}},
+		{"auto shared domain", []string{"https://node1.corp.net:9000", "https://node2.corp.net:9000"}, "auto", []string{"node1", "node2"}},
+		{"auto with addresses", []string{"node1.corp.net:9000", "10.0.0.7:9000"}, "auto", []string{"node1", "10.0.0.7"}},
+		{"auto nothing shared", []string{"node1.corp.net:9000", "node2.other.org:9000"}, "auto", []string{"node1.corp.net", "node2.other.org"}},
+		{"collision keeps suffix", []string{"node1.dc1.corp.net:9000", "node1.dc2.corp.net:9000", "node2.dc1.corp.net:9000"}, ".dc1.corp.net,.dc2.corp.net", []string{"node1.dc1", "node1.dc2", "node2"}},
+		{"collision first label", []string{"minio1.a.corp.net:9000", "minio1.b.corp.net:9000"}, "", []string{"minio1.a", "minio1.b"}},
+		{"collision with full host", []string{"node1:9000", "node1.dc1.corp.net:9000"}, ".dc1.corp.net", []string{"node1", "node1.dc1"}},
+		{"addresses", []string{"10.0.0.1:9000", "https://[fd00::12]:9000", "[fd00::13]:9000"}, "auto", []string{"10.0.0.1", "fd00::12", "fd00::13"}},
+		{"same host twice", []string{"node1.corp.net:9000", "node1.corp.net:9000"}, "auto", []string{"node1", "node1"}},
+	}
+	for _, tt := range tests {
+		names := NewServerNamer(servers(tt.endpoints...), tt.trimDomain)
+		var got []string
+		for _, endpoint := range tt.endpoints {
+			got = append(got, names.Name(endpoint))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: names = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// --fqdn keeps whole host names, also of a host that is named like a domain
+	endpoints := []string{"node1.dc1.corp.net:9000", "none:9000", "10.0.3.15:9000"}
+	fqdn := NewFQDNServerNamer(servers(endpoints...))
+	for i, want := range []string{"node1.dc1.corp.net", "none", "10.0.3.15"} {
+		if got := fqdn.Name(endpoints[i]); got != want {
+			t.Errorf("NewFQDNServerNamer: Name(%q) = %q, want %q", endpoints[i], got, want)
+		}
+	}
+
+	// Endpoints of other hosts, such as drives of a heal file, are trimmed alike
+	names := NewServerNamer(servers("node1.corp.net:9000", "node2.corp.net:9000"), "auto")
+	if got := names.Name("https://node9.corp.net:9000/data/disk1"); got != "node9" {
+		t.Errorf("Name() of an unknown host = %q, want node9", got)
+	}
+	var none *ServerNamer
+	if got := none.Name("node1.corp.net:9000"); got != "node1" {
+		t.Errorf("nil Name() = %q, want node1", got)
+	}
+
+	for hosts, want := range map[string]string{
+		"node1.corp.net":                    ".corp.net",
+		"node1.dc1.corp.net node2.CORP.net": ".corp.net",
+		"node1 node2.corp.net":              "",
+		"node1.corp.net node2.corp.org":     "",
+		"10.0.0.1 node1.corp.net":           ".corp.net",
+	} {
+		if got := CommonDomain(strings.Fields(hosts)); got != want {
+			t.Errorf("CommonDomain(%s) = %q, want %q", hosts, got, want)
+		}
+	}
+}
+
+func TestParseDriveMetrics(t *testing.T) {
+	dump := `# TYPE minio_node_drive_latency_us gauge
+minio_node_drive_latency_us{api="storage.ReadXL",drive="/data/disk1",server="Node1.corp.net:9000"} 900
+minio_node_drive_latency_us{api="storage.WalkDir",drive="/data/disk1",server="Node1.corp.net:9000"} 4000 1700000000000
+minio_node_drive_reads_per_sec{drive="https://node1.corp.net:9000/data/disk2",server="node1.corp.net:9000"} 10
+minio_node_drive_writes_per_sec{drive="/data/disk2",server="node1.corp.net:9000"} 5.5
+minio_node_drive_writes_per_sec{drive="/data/disk3",server="node1.corp.net:9000"} NaN
+minio_node_drive_total{server="node1.corp.net:9000"} 3
+minio_node_drive_free_bytes{drive="/data/\"odd\"",server="node1.corp.net:9000"} 1
+minio_node_drive_reads_per_sec{drive="/data/disk4,server="node1.corp.net:9000"} 1
+minio_node_drive_reads_per_sec{drive="/data/disk4"}
+minio_s3_requests_total{api="GetObject"} 12
+`
+	metrics, err := ParseDriveMetrics(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics.Series != 6 || metrics.Skipped != 2 {
+		t.Errorf("Series = %d, Skipped = %d; want 6 and 2", metrics.Series, metrics.Skipped)
+	}
+	disk1 := metrics.Drives[DriveIOKey("node1.corp.net:9000", "/data/disk1")]
+	if disk1 == nil || disk1.LatencyUs != 4000 || disk1.LatencyAPI != "storage.WalkDir" || disk1.HasRates {
+		t.Errorf("disk1 = %+v, want the WalkDir latency and no rates", disk1)
+	}
+	disk2 := metrics.Drives[DriveIOKey("node1.corp.net:9000", "/data/disk2")]
+	if disk2 == nil || disk2.IOPS() != 15.5 || disk2.HasLatency {
+		t.Errorf("disk2 = %+v, want 15.5 IOPS from both drive label forms", disk2)
+	}
+	if _, ok := metrics.Drives[DriveIOKey("node1.corp.net:9000", `/data/"odd"`)]; !ok {
+		t.Errorf("escaped drive label not unescaped: %v", metrics.Drives)
+	}
+
+	server := madmin.ServerProperties{Endpoint: "node1.corp.net:9000", Disks: []madmin.Disk{
+		{DrivePath: "/data/disk1"}, {Endpoint: "http://node1.corp.net:9000/data/disk2"}, {DrivePath: "/data/disk5"},
+	}}
+	drives := ServerDrives(server, nil)
+	if matched := ApplyDriveIO(drives, server, metrics); matched != 2 {
+		t.Errorf("ApplyDriveIO matched %d drives, want 2", matched)
+	}
+	if drives[0].IO != disk1 || drives[1].IO != disk2 || drives[2].IO != nil {
+		t.Errorf("drive IO = %v %v %v", drives[0].IO, drives[1].IO, drives[2].IO)
+	}
+
+	// Snapshot.Drives joins DriveIO like the report does
+	snapshot := &Snapshot{Info: madmin.InfoMessage{Servers: []madmin.ServerProperties{server}}, DriveIO: metrics}
+	if drives := snapshot.Drives(DriveFilter{}); drives[0].IO != disk1 || drives[1].IO != disk2 || drives[2].IO != nil {
+		t.Errorf("Snapshot.Drives IO = %v %v %v", drives[0].IO, drives[1].IO, drives[2].IO)
+	}
+}
@@ -0,0 +1,190 @@
+package mdbcore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// DriveMetricPrefix is the prefix of the per-drive series of the MinIO node
+// metrics that ParseDriveMetrics reads
+const DriveMetricPrefix = "minio_node_drive_"
+
+// DriveIO holds the I/O of a drive read from Prometheus node metrics
+type DriveIO struct {
+	ReadsPerSec  float64 `json:"reads_per_sec"`
+	WritesPerSec float64 `json:"writes_per_sec"`
+	HasRates     bool    `json:"has_rates"`   // the metrics report reads or writes per second
+	LatencyUs    float64 `json:"latency_us"`  // highest average latency of any storage API, in microseconds
+	LatencyAPI   string  `json:"latency_api"` // the storage API with the highest latency
+	HasLatency   bool    `json:"has_latency"`
+}
+
+// IOPS returns the reads and writes per second of the drive
+func (d *DriveIO) IOPS() float64 {
+	return d.ReadsPerSec + d.WritesPerSec
+}
+
+// DriveMetrics holds the DriveIO of the drives in a Prometheus metrics dump,
+// keyed by DriveIOKey
+type DriveMetrics struct {
+	Drives  map[string]*DriveIO
+	Series  int // minio_node_drive_* samples of a drive
+	Skipped int // minio_node_drive_* lines that could not be parsed
+}
+
+// DriveIOKey is the key of a drive in DriveMetrics: the host of the server
+// without port, lowercased, and the drive path
+func DriveIOKey(server, drivePath string) string {
+	return strings.ToLower(EndpointHost(server)) + "|" + drivePath
+}
+
+// ParseDriveMetrics reads the minio_node_drive_* series of a Prometheus text
+// exposition, as served by /minio/v2/metrics/node or bundled in a diagnostics
+// archive. Other series and samples without a server or drive label are
+// ignored, and samples that cannot be parsed are counted in Skipped rather than
+// failing the parse.
+func ParseDriveMetrics(r io.Reader) (*DriveMetrics, error) {
+	metrics := &DriveMetrics{Drives: make(map[string]*DriveIO)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || !strings.HasPrefix(line, DriveMetricPrefix) {
+			continue
+		}
+		name, labels, value, err := parseSample(line)
+		if err != nil {
+			metrics.Skipped++
+			continue
+		}
+		drivePath := labels["drive"]
+		if strings.Contains(drivePath, "://") {
+			drivePath = PathFromEndpoint(drivePath)
+		}
+		// Cluster-wide totals such as minio_node_drive_online_total name no drive
+		if labels["server"] == "" || drivePath == "" {
+			continue
+		}
+		metrics.Series++
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+
+		key := DriveIOKey(labels["server"], drivePath)
+		drive := metrics.Drives[key]
+		if drive == nil {
+			drive = &DriveIO{}
+			metrics.Drives[key] = drive
+		}
+		switch strings.TrimPrefix(name, DriveMetricPrefix) {
+		case "reads_per_sec":
+			drive.ReadsPerSec, drive.HasRates = value, true
+		case "writes_per_sec":
+			drive.WritesPerSec, drive.HasRates = value, true
+		case "latency_us":
+			if !drive.HasLatency || value > drive.LatencyUs {
+				drive.LatencyUs, drive.LatencyAPI, drive.HasLatency = value, labels["api"], true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// parseSample parses a sample line of the Prometheus text format,
+// `name{label="value",...} value [timestamp]`
+func parseSample(line string) (string, map[string]string, float64, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return "", nil, 0, fmt.Errorf("sample without value")
+	}
+	name, rest := line[:end], line[end:]
+	labels := make(map[string]string)
+	if rest[0] == '{' {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " \t,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.IndexByte(rest, '=')
+			if eq <= 0 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return "", nil, 0, fmt.Errorf("malformed labels")
+			}
+			label := strings.TrimSpace(rest[:eq])
+			value, n, err := parseLabelValue(rest[eq+2:])
+			if err != nil {
+				return "", nil, 0, err
+			}
+			labels[label] = value
+			rest = rest[eq+2+n:]
+		}
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("sample without value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	return name, labels, value, nil
+}
+
+// parseLabelValue unescapes a label value up to its closing quote and returns
+// it with the number of bytes consumed, the quote included
+func parseLabelValue(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", 0, fmt.Errorf("unterminated label value")
+			}
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated label value")
+}
+
+// ApplyDriveIO sets the I/O of the drives returned by ServerDrives for server
+// from metrics, joined by server host and drive path. It returns how many
+// drives were matched; drives without series keep a nil IO.
+func ApplyDriveIO(drives []DiskInfo, server madmin.ServerProperties, metrics *DriveMetrics) int {
+	if metrics == nil || len(metrics.Drives) == 0 {
+		return 0
+	}
+	matched := 0
+	for i, disk := range server.Disks {
+		if i >= len(drives) {
+			break
+		}
+		path := disk.DrivePath
+		if path == "" {
+			path = PathFromEndpoint(disk.Endpoint)
+		}
+		if driveIO, ok := metrics.Drives[DriveIOKey(server.Endpoint, path)]; ok {
+			drives[i].IO = driveIO
+			matched++
+		}
+	}
+	return matched
+}
@@ -0,0 +1,699 @@
+// Package mdbcore reads MinIO diagnostic snapshots ("mc admin info --json" and
+// SUBNET diagnostics uploads, as a single document or NDJSON records) and
+// aggregates their drives, erasure sets and capacity. It holds the data model of
+// mdb without any rendering, so other tools can reuse it.
+package mdbcore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// bufferSize is the read buffer of the streaming decoders
+const bufferSize = 1 << 20
+
+// Snapshot is a decoded diagnostic snapshot: the info message together with the
+// fields "status" and "error"
+type Snapshot struct {
+	Status string             `json:"status"`
+	Error  string             `json:"error,omitempty"`
+	Info   madmin.InfoMessage `json:"info,omitempty"`
+
+	// ErrorTimes holds last-error timestamps of drives whose metrics expose
+	// them, keyed by DriveErrorKey
+	ErrorTimes map[string]DriveErrorTimes `json:"-"`
+
+	// Missing counts the expected fields older releases leave out, keyed by
+	// field name: FieldBackend once for the info, the drive fields once for
+	// every drive without them
+	Missing map[string]int `json:"-"`
+
+	// Timestamp is when the snapshot was collected, from the document's
+	// "timestamp" field; it is zero if the document has none
+	Timestamp time.Time `json:"-"`
+
+	// Wrapped is set when the snapshot was nested under "minio", as in SUBNET
+	// diagnostics files
+	Wrapped bool `json:"-"`
+
+	// BucketsUsage holds the usage of each bucket from the info's
+	// "bucketsUsageInfo", which only some captures include
+	BucketsUsage map[string]madmin.BucketUsageInfo `json:"-"`
+
+	// DriveIO holds the drive series of a Prometheus metrics dump, joined to
+	// the drives by server and path; it is nil without a dump
+	DriveIO *DriveMetrics `json:"-"`
+}
+
+// Record is a snapshot read from one line of an NDJSON file
+type Record struct {
+	*Snapshot
+	Line int // 1-based line number
+}
+
+// FormatError is returned by Load when the data matches none of the formats
+type FormatError struct {
+	Attempts []FormatAttempt
+}
+
+func (e *FormatError) Error() string {
+	var b strings.Builder
+	b.WriteString("no supported format matched")
+	for _, attempt := range e.Attempts {
+		fmt.Fprintf(&b, "; as %s: %v", attempt.Format, attempt.Err)
+	}
+	return b.String()
+}
+
+// Load reads a snapshot from r: a single JSON document, plain or nested under
+// "minio", or else the first record of NDJSON data. A leading version header
+// is skipped.
+func Load(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte(VersionPrefix))
+	snapshot, attempts := Decode(bytes.NewReader(data))
+	if snapshot != nil {
+		return snapshot, nil
+	}
+	// A document that is valid as a whole is not NDJSON either
+	if attempts[0].Format != FormatPlainJSON {
+		return nil, &FormatError{Attempts: attempts}
+	}
+	records, _, err := LoadRecords(bytes.NewReader(data))
+	if err != nil {
+		return nil, &FormatError{Attempts: append(attempts, FormatAttempt{Format: FormatNDJSON, Err: err})}
+	}
+	return records[0].Snapshot, nil
+}
+
+// LoadRecords decodes every record of NDJSON data in order and returns how many
+// non-empty lines were skipped because they could not be parsed or have no
+// servers. It fails if no record is left.
+func LoadRecords(r io.Reader) ([]Record, int, error) {
+	var records []Record
+	var firstErr error
+	skipped := 0
+	err := ReadLines(r, func(lineNumber int, line []byte) bool {
+		if len(line) == 0 {
+			return true
+		}
+		snapshot, err := ParseRecord(line)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			skipped++
+			return true
+		}
+		records = append(records, Record{Snapshot: snapshot, Line: lineNumber})
+		return true
+	})
+	if err != nil {
+		return nil, 0, &ReadError{Err: err}
+	}
+	if len(records) == 0 {
+		if firstErr != nil {
+			return nil, skipped, fmt.Errorf("no NDJSON records with servers found (%v)", firstErr)
+		}
+		return nil, skipped, fmt.Errorf("no NDJSON records with servers found")
+	}
+	return records, skipped, nil
+}
+
+// ReadError is returned by LoadRecords when the data cannot be read
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// VersionPrefix is a header some collectors write in front of the document
+const VersionPrefix = `{"version":"3"}`
+
+// SkipVersionPrefix discards the version header if r starts with it
+func SkipVersionPrefix(r *bufio.Reader) error {
+	head, err := r.Peek(len(VersionPrefix))
+	if err != nil || string(head) != VersionPrefix {
+		return nil
+	}
+	_, err = r.Discard(len(VersionPrefix))
+	return err
+}
+
+// Snapshot layouts tried by Decode, and by Load after it
+const (
+	FormatPlainJSON = "plain"
+	FormatWrapper   = "minio wrapper"
+	FormatNDJSON    = "NDJSON"
+)
+
+// Expected fields counted in Snapshot.Missing when a snapshot leaves them out
+const (
+	FieldBackend   = "backend"
+	FieldPoolIndex = "pool_index"
+	FieldSetIndex  = "set_index"
+	FieldDiskIndex = "disk_index"
+)
+
+// snapshotDisk is a drive as stored in a snapshot. Its metrics are kept raw to
+// also decode the last-error timestamps madmin.DiskMetrics does not carry, and
+// its indices because some tools write the disk index as a string and older
+// releases leave them out.
+type snapshotDisk struct {
+	madmin.Disk
+	Metrics   json.RawMessage `json:"metrics,omitempty"`
+	PoolIndex json.RawMessage `json:"pool_index,omitempty"`
+	SetIndex  json.RawMessage `json:"set_index,omitempty"`
+	DiskIndex json.RawMessage `json:"disk_index,omitempty"`
+}
+
+// snapshotServer is a server as stored in a snapshot, its drives replace Disks
+type snapshotServer struct {
+	madmin.ServerProperties
+	Disks []snapshotDisk `json:"drives,omitempty"`
+}
+
+// snapshotBody is a snapshot in the layout of "mc admin info --json"
+type snapshotBody struct {
+	Status  string
+	Error   string
+	Info    madmin.InfoMessage
+	Disks   [][]snapshotDisk // of each server, with raw metrics
+	Buckets map[string]madmin.BucketUsageInfo
+
+	NoBackend bool // the info has no "backend"
+}
+
+// snapshotDocument is a snapshot file. It holds the plain layout and the same
+// nested under "minio", as in SUBNET diagnostics, so one pass decodes either.
+type snapshotDocument struct {
+	snapshotBody
+	Minio     *snapshotBody
+	Timestamp json.RawMessage
+}
+
+// snapshotDecoder decodes a snapshot token by token and each server as a whole,
+// so only one server at a time is buffered instead of the entire document.
+// Values of the wrong type are skipped and their error kept in the type error
+// of the layout they belong to; other errors end decoding.
+type snapshotDecoder struct {
+	decoder *json.Decoder
+}
+
+// Decode decodes a single JSON document from r. It returns the plain
+// layout if it has servers and else the "minio" wrapper, or nil and the error of
+// each layout that does not match.
+func Decode(r io.Reader) (*Snapshot, []FormatAttempt) {
+	d := snapshotDecoder{decoder: json.NewDecoder(r)}
+	var doc snapshotDocument
+	var plainErr, wrapperErr error
+	err := d.object("", &plainErr, func(key string) error {
+		switch {
+		case strings.EqualFold(key, "minio"):
+			doc.Minio = &snapshotBody{}
+			return d.object("minio", &wrapperErr, func(key string) error {
+				return d.member(doc.Minio, key, &wrapperErr)
+			})
+		case strings.EqualFold(key, "timestamp"):
+			return d.value(&doc.Timestamp, &plainErr)
+		default:
+			return d.member(&doc.snapshotBody, key, &plainErr)
+		}
+	})
+	if err == nil {
+		if _, tokenErr := d.decoder.Token(); tokenErr != io.EOF {
+			err = errors.New("invalid data after top-level value")
+		}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if err != nil || (errors.As(plainErr, &typeErr) && typeErr.Field == "") {
+		// Neither layout can be read from something that is not a single object
+		if err == nil {
+			err = plainErr
+		}
+		plainErr, wrapperErr = err, err
+	}
+
+	var attempts []FormatAttempt
+	var body *snapshotBody
+	wrapped := doc.Minio
+	if wrapped == nil {
+		wrapped = &snapshotBody{}
+	}
+	switch {
+	case plainErr == nil && len(doc.Info.Servers) > 0:
+		body = &doc.snapshotBody
+	case wrapperErr == nil && (len(wrapped.Info.Servers) > 0 || plainErr != nil):
+		// If there is no server found on the first try, the data could be from
+		// the subnet diagnostics page
+		body = wrapped
+	case plainErr == nil && wrapperErr == nil:
+		// Neither has servers, keep the status and error of the plain document
+		body = &doc.snapshotBody
+	default:
+		if plainErr != nil {
+			attempts = append(attempts, FormatAttempt{FormatPlainJSON, plainErr})
+		}
+		if wrapperErr != nil {
+			attempts = append(attempts, FormatAttempt{FormatWrapper, wrapperErr})
+		}
+		return nil, attempts
+	}
+
+	infoStruct := body.snapshot()
+	infoStruct.Wrapped = body == wrapped
+	// Health diagnostics record when they were collected
+	var timestamp time.Time
+	if len(doc.Timestamp) > 0 && json.Unmarshal(doc.Timestamp, &timestamp) == nil {
+		infoStruct.Timestamp = timestamp
+	}
+	return infoStruct, nil
+}
+
+// member decodes the value of key, a member of a snapshot body
+func (d snapshotDecoder) member(body *snapshotBody, key string, typeErr *error) error {
+	switch {
+	case strings.EqualFold(key, "status"):
+		return d.value(&body.Status, typeErr)
+	case strings.EqualFold(key, "error"):
+		return d.value(&body.Error, typeErr)
+	case strings.EqualFold(key, "info"):
+		return d.info(body, typeErr)
+	default:
+		var skipped json.RawMessage
+		return d.value(&skipped, typeErr)
+	}
+}
+
+// info decodes the info message of a snapshot. Servers are decoded one by one,
+// the other members are small and decoded together.
+func (d snapshotDecoder) info(body *snapshotBody, typeErr *error) error {
+	members := make(map[string]json.RawMessage)
+	err := d.object("info", typeErr, func(key string) error {
+		if !strings.EqualFold(key, "servers") {
+			var value json.RawMessage
+			if err := d.decoder.Decode(&value); err != nil {
+				return err
+			}
+			members[key] = value
+			return nil
+		}
+
+		tok, err := d.decoder.Token()
+		if err != nil || tok == nil {
+			return err
+		}
+		if tok != json.Delim('[') {
+			return d.wrongType(tok, "info.servers", reflect.TypeOf(body.Info.Servers), typeErr)
+		}
+		for d.decoder.More() {
+			var server snapshotServer
+			if err := d.value(&server, typeErr); err != nil {
+				return err
+			}
+			if err := checkIndexTypes(server.Disks, typeErr); err != nil {
+				return err
+			}
+			body.Info.Servers = append(body.Info.Servers, server.ServerProperties)
+			body.Disks = append(body.Disks, server.Disks)
+		}
+		_, err = d.decoder.Token()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	body.NoBackend = true
+	for key := range members {
+		if strings.EqualFold(key, FieldBackend) {
+			body.NoBackend = false
+		}
+	}
+
+	servers := body.Info.Servers
+	data, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	if err := keepTypeError(json.Unmarshal(data, &body.Info), typeErr); err != nil {
+		return err
+	}
+	body.Info.Servers = servers
+
+	// Per-bucket usage is optional, a malformed one is left out
+	var usage struct {
+		BucketsUsage map[string]madmin.BucketUsageInfo `json:"bucketsUsageInfo"`
+	}
+	if json.Unmarshal(data, &usage) == nil {
+		body.Buckets = usage.BucketsUsage
+	}
+	return nil
+}
+
+// checkIndexTypes stores a type error in typeErr for the first pool or set
+// index of drives that is not a number. Both are kept raw to tell a missing
+// index from a zero one, so decoding the server does not check them.
+func checkIndexTypes(drives []snapshotDisk, typeErr *error) error {
+	for _, disk := range drives {
+		for _, field := range []struct {
+			name string
+			raw  json.RawMessage
+		}{{FieldPoolIndex, disk.PoolIndex}, {FieldSetIndex, disk.SetIndex}} {
+			if len(field.raw) == 0 || string(field.raw) == "null" {
+				continue
+			}
+			if _, ok := intLiteral(field.raw); ok {
+				continue
+			}
+			var index int
+			err := json.Unmarshal(field.raw, &index)
+			var unmarshalTypeErr *json.UnmarshalTypeError
+			if errors.As(err, &unmarshalTypeErr) {
+				unmarshalTypeErr.Field = "info.servers.drives." + field.name
+			}
+			if err := keepTypeError(err, typeErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// value decodes the next value into v
+func (d snapshotDecoder) value(v interface{}, typeErr *error) error {
+	return keepTypeError(d.decoder.Decode(v), typeErr)
+}
+
+// keepTypeError stores err in typeErr if it is the first type error, and
+// returns err if it is any other error
+func keepTypeError(err error, typeErr *error) error {
+	if err == nil {
+		return nil
+	}
+	var unmarshalTypeErr *json.UnmarshalTypeError
+	if !errors.As(err, &unmarshalTypeErr) {
+		return err
+	}
+	if *typeErr == nil {
+		*typeErr = err
+	}
+	return nil
+}
+
+// object calls member with the key of each member of the object that is the
+// next value. null is an empty object, any other value is skipped as wrong type.
+func (d snapshotDecoder) object(field string, typeErr *error, member func(key string) error) error {
+	tok, err := d.decoder.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return d.wrongType(tok, field, reflect.TypeOf(map[string]interface{}{}), typeErr)
+	}
+	for d.decoder.More() {
+		tok, err := d.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if err := member(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = d.decoder.Token()
+	return err
+}
+
+// wrongType skips the rest of the value that starts with tok and stores a type
+// error for field in typeErr
+func (d snapshotDecoder) wrongType(tok json.Token, field string, t reflect.Type, typeErr *error) error {
+	kind := "value"
+	switch tok.(type) {
+	case json.Delim:
+		kind = "array"
+		if tok == json.Delim('{') {
+			kind = "object"
+		}
+		for depth := 1; depth > 0; {
+			next, err := d.decoder.Token()
+			if err != nil {
+				return err
+			}
+			switch next {
+			case json.Delim('{'), json.Delim('['):
+				depth++
+			case json.Delim('}'), json.Delim(']'):
+				depth--
+			}
+		}
+	case string:
+		kind = "string"
+	case float64, json.Number:
+		kind = "number"
+	case bool:
+		kind = "bool"
+	}
+	if *typeErr == nil {
+		*typeErr = &json.UnmarshalTypeError{Value: kind, Type: t, Offset: d.decoder.InputOffset(), Field: field}
+	}
+	return nil
+}
+
+// snapshot converts a decoded snapshot body into a Snapshot and collects the
+// drive last-error timestamps, missing disk indexes and missing fields. Metrics
+// that cannot be decoded are left out.
+func (body *snapshotBody) snapshot() *Snapshot {
+	infoStruct := &Snapshot{Status: body.Status, Error: body.Error, Info: body.Info, BucketsUsage: body.Buckets}
+	missing := func(field string) {
+		if infoStruct.Missing == nil {
+			infoStruct.Missing = make(map[string]int)
+		}
+		infoStruct.Missing[field]++
+	}
+	if body.NoBackend && len(body.Info.Servers) > 0 {
+		missing(FieldBackend)
+	}
+	for i := range infoStruct.Info.Servers {
+		server := &infoStruct.Info.Servers[i]
+		server.Disks = nil
+		if n := len(body.Disks[i]); n > 0 {
+			server.Disks = make([]madmin.Disk, 0, n)
+		}
+		for _, disk := range body.Disks[i] {
+			// Filled in place, a copy would escape through the index pointers
+			server.Disks = append(server.Disks, disk.Disk)
+			drive := &server.Disks[len(server.Disks)-1]
+			for _, field := range []struct {
+				name  string
+				raw   json.RawMessage
+				index *int
+			}{
+				{FieldPoolIndex, disk.PoolIndex, &drive.PoolIndex},
+				{FieldSetIndex, disk.SetIndex, &drive.SetIndex},
+			} {
+				if len(field.raw) == 0 || string(field.raw) == "null" {
+					missing(field.name)
+				} else if index, ok := intLiteral(field.raw); ok {
+					*field.index = index
+				} else {
+					// checkIndexTypes rejected indexes that are not numbers while decoding
+					_ = json.Unmarshal(field.raw, field.index)
+				}
+			}
+			if len(disk.DiskIndex) == 0 {
+				missing(FieldDiskIndex)
+			}
+			// ServerDrives marks drives without a usable index by MissingDiskIndex
+			index, ok := parseDiskIndex(disk.DiskIndex)
+			drive.DiskIndex = index
+			if !ok {
+				drive.DiskIndex = MissingDiskIndex
+			}
+			if len(disk.Metrics) > 0 && string(disk.Metrics) != "null" {
+				var metrics snapshotMetrics
+				if err := json.Unmarshal(disk.Metrics, &metrics); err == nil {
+					drive.Metrics = &metrics.DiskMetrics
+					times := DriveErrorTimes{LastErrorAvailability: metrics.LastErrorAvailability.Time, LastErrorTimeout: metrics.LastErrorTimeout.Time}
+					if !times.LastErrorAvailability.IsZero() || !times.LastErrorTimeout.IsZero() {
+						if infoStruct.ErrorTimes == nil {
+							infoStruct.ErrorTimes = make(map[string]DriveErrorTimes)
+						}
+						infoStruct.ErrorTimes[DriveErrorKey(server.Endpoint, disk.Endpoint, disk.DrivePath)] = times
+					}
+				}
+			}
+		}
+	}
+	return infoStruct
+}
+
+// parseDiskIndex returns the disk index of a drive from its raw "disk_index",
+// a number or a string holding one, and false if it is missing or invalid
+func parseDiskIndex(raw json.RawMessage) (int, bool) {
+	if index, ok := intLiteral(raw); ok && index >= 0 {
+		return index, true
+	}
+	var value interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &value) != nil {
+		return 0, false
+	}
+	var index float64
+	switch value := value.(type) {
+	case float64:
+		index = value
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return 0, false
+		}
+		index = float64(n)
+	default:
+		return 0, false
+	}
+	if index < 0 || index != math.Trunc(index) || index > math.MaxInt32 {
+		return 0, false
+	}
+	return int(index), true
+}
+
+// intLiteral returns the value of raw if it is a JSON integer of at most nine
+// digits, as indexes are, so the common case is read without json.Unmarshal
+func intLiteral(raw json.RawMessage) (int, bool) {
+	digits := bytes.TrimPrefix(raw, []byte("-"))
+	if len(digits) == 0 || len(digits) > 9 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	if len(digits) < len(raw) {
+		n = -n
+	}
+	return n, true
+}
+
+// BucketUsage is the usage of one bucket
+type BucketUsage struct {
+	Name string
+	madmin.BucketUsageInfo
+}
+
+// SortedBuckets returns the buckets of usage by size, largest first, and by
+// name for equal sizes
+func SortedBuckets(usage map[string]madmin.BucketUsageInfo) []BucketUsage {
+	buckets := make([]BucketUsage, 0, len(usage))
+	for name, info := range usage {
+		buckets = append(buckets, BucketUsage{Name: name, BucketUsageInfo: info})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Size != buckets[j].Size {
+			return buckets[i].Size > buckets[j].Size
+		}
+		return buckets[i].Name < buckets[j].Name
+	})
+	return buckets
+}
+
+// FormatAttempt is the error of one input format Decode or Load tried
+type FormatAttempt struct {
+	Format string
+	Err    error
+}
+
+// ParseRecord decodes one NDJSON line, which must contain servers
+func ParseRecord(line []byte) (*Snapshot, error) {
+	infoStruct, attempts := Decode(bytes.NewReader(line))
+	if infoStruct == nil {
+		return nil, attempts[0].Err
+	}
+	if len(infoStruct.Info.Servers) == 0 {
+		return nil, errors.New("no servers")
+	}
+	return infoStruct, nil
+}
+
+// ReadLines calls line with the number and the content of each line of r,
+// without surrounding white space, until it returns false. Lines can be of any
+// length, single-line dumps of large clusters are several megabytes.
+func ReadLines(r io.Reader, line func(number int, text []byte) bool) error {
+	reader := bufio.NewReaderSize(r, bufferSize)
+	for number := 1; ; number++ {
+		text, err := reader.ReadBytes('\n')
+		if len(text) > 0 && !line(number, bytes.TrimSpace(text)) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DriveErrorTimes are the last-error timestamps that newer servers add to the
+// drive metrics. madmin.DiskMetrics does not carry them, see snapshotMetrics.
+type DriveErrorTimes struct {
+	LastErrorAvailability time.Time `json:"lastErrorAvailability"`
+	LastErrorTimeout      time.Time `json:"lastErrorTimeout"`
+}
+
+// snapshotMetrics are the metrics of a drive as stored in a snapshot, decoded
+// once for the counters and the last-error timestamps
+type snapshotMetrics struct {
+	madmin.DiskMetrics
+	LastErrorAvailability errorTime `json:"lastErrorAvailability"`
+	LastErrorTimeout      errorTime `json:"lastErrorTimeout"`
+}
+
+// errorTime is a last-error timestamp, RFC 3339 or seconds since the epoch.
+// Other values are left zero, like a missing one, and keep the counters.
+type errorTime struct {
+	time.Time
+}
+
+func (t *errorTime) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if json.Unmarshal(data, &value) != nil {
+		return nil
+	}
+	switch value := value.(type) {
+	case string:
+		t.Time, _ = time.Parse(time.RFC3339Nano, value)
+	case float64:
+		if value > 0 {
+			seconds, fraction := math.Modf(value)
+			t.Time = time.Unix(int64(seconds), int64(fraction*1e9)).UTC()
+		}
+	}
+	return nil
+}
+
+func DriveErrorKey(serverEndpoint, diskEndpoint, drivePath string) string {
+	return serverEndpoint + "|" + diskEndpoint + "|" + drivePath
+}